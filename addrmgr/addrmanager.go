@@ -15,6 +15,7 @@ import (
 	"io"
 	"math/rand"
 	"net"
+	"sort"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -907,8 +908,17 @@ func (a *AddrManager) reset() {
 }
 
 // HostToNetAddress returns a netaddress given a host address. If
-// the host is not an IP address it will be resolved.
+// the host is neither an IP address nor an overlay network address it will be
+// resolved.
 func (a *AddrManager) HostToNetAddress(host string, port uint16, services wire.ServiceFlag) (*wire.NetAddress, error) {
+	if wire.IsOverlayHost(host) {
+		overlay, err := wire.ParseOverlayAddress(host)
+		if err != nil {
+			return nil, err
+		}
+		return wire.NewNetAddressOverlay(overlay, port, services), nil
+	}
+
 	ip := net.ParseIP(host)
 	if ip == nil {
 		ips, err := a.lookupFunc(host)
@@ -924,12 +934,12 @@ func (a *AddrManager) HostToNetAddress(host string, port uint16, services wire.S
 	return wire.NewNetAddressIPPort(ip, port, services), nil
 }
 
-// NetAddressKey returns a string key in the form of ip:port for IPv4 addresses
-// or [ip]:port for IPv6 addresses.
+// NetAddressKey returns a string key in the form of ip:port for IPv4 addresses,
+// [ip]:port for IPv6 addresses or host:port for overlay network addresses.
 func NetAddressKey(na *wire.NetAddress) string {
 	port := strconv.FormatUint(uint64(na.Port), 10)
 
-	return net.JoinHostPort(na.IP.String(), port)
+	return net.JoinHostPort(na.Host(), port)
 }
 
 // GetAddress returns a single address that should be routable. It picks a
@@ -1226,7 +1236,7 @@ func (a *AddrManager) Good(addr *wire.NetAddress, subnetworkID *subnetworkid.Sub
 // with the given priority.
 func (a *AddrManager) AddLocalAddress(na *wire.NetAddress, priority AddressPriority) error {
 	if !IsRoutable(na) {
-		return errors.Errorf("address %s is not routable", na.IP)
+		return errors.Errorf("address %s is not routable", na.Host())
 	}
 
 	a.lamtx.Lock()
@@ -1247,6 +1257,32 @@ func (a *AddrManager) AddLocalAddress(na *wire.NetAddress, priority AddressPrior
 	return nil
 }
 
+// LocalAddressInfo describes a single known local address along with the
+// priority it was added with.
+type LocalAddressInfo struct {
+	Address *wire.NetAddress
+	Score   AddressPriority
+}
+
+// LocalAddresses returns all the known local addresses, ordered by
+// descending score.
+func (a *AddrManager) LocalAddresses() []*LocalAddressInfo {
+	a.lamtx.Lock()
+	defer a.lamtx.Unlock()
+
+	localAddresses := make([]*LocalAddressInfo, 0, len(a.localAddresses))
+	for _, la := range a.localAddresses {
+		localAddresses = append(localAddresses, &LocalAddressInfo{
+			Address: la.na,
+			Score:   la.score,
+		})
+	}
+	sort.Slice(localAddresses, func(i, j int) bool {
+		return localAddresses[i].Score > localAddresses[j].Score
+	})
+	return localAddresses
+}
+
 // getReachabilityFrom returns the relative reachability of the provided local
 // address to the provided remote address.
 func getReachabilityFrom(localAddr, remoteAddr *wire.NetAddress) int {
@@ -1280,6 +1316,18 @@ func getReachabilityFrom(localAddr, remoteAddr *wire.NetAddress) int {
 		return Ipv6Weak
 	}
 
+	if IsOverlay(remoteAddr) {
+		if IsOverlay(localAddr) && localAddr.Overlay.Network == remoteAddr.Overlay.Network {
+			return Private
+		}
+
+		if IsRoutable(localAddr) && IsIPv4(localAddr) {
+			return Ipv4
+		}
+
+		return Default
+	}
+
 	if IsIPv4(remoteAddr) {
 		if IsRoutable(localAddr) && IsIPv4(localAddr) {
 			return Ipv4
@@ -1294,7 +1342,7 @@ func getReachabilityFrom(localAddr, remoteAddr *wire.NetAddress) int {
 		tunnelled = true
 	}
 
-	if !IsRoutable(localAddr) {
+	if !IsRoutable(localAddr) || IsOverlay(localAddr) {
 		return Default
 	}
 
@@ -1333,10 +1381,10 @@ func (a *AddrManager) GetBestLocalAddress(remoteAddr *wire.NetAddress) *wire.Net
 		}
 	}
 	if bestAddress != nil {
-		log.Debugf("Suggesting address %s:%d for %s:%d", bestAddress.IP,
-			bestAddress.Port, remoteAddr.IP, remoteAddr.Port)
+		log.Debugf("Suggesting address %s:%d for %s:%d", bestAddress.Host(),
+			bestAddress.Port, remoteAddr.Host(), remoteAddr.Port)
 	} else {
-		log.Debugf("No worthy address for %s:%d", remoteAddr.IP,
+		log.Debugf("No worthy address for %s:%d", remoteAddr.Host(),
 			remoteAddr.Port)
 
 		// Send something unroutable if nothing suitable.
@@ -697,6 +697,97 @@ func TestGetBestLocalAddress(t *testing.T) {
 	*/
 }
 
+// TestOverlayAddresses tests that the address manager handles overlay network
+// addresses without resolving them, and prefers advertising a local address in
+// the same overlay network to peers in it.
+func TestOverlayAddresses(t *testing.T) {
+	originalActiveCfg := config.ActiveConfig()
+	config.SetActiveConfig(&config.Config{
+		Flags: &config.Flags{
+			NetworkFlags: config.NetworkFlags{
+				ActiveNetParams: &dagconfig.SimnetParams},
+		},
+	})
+	defer config.SetActiveConfig(originalActiveCfg)
+
+	amgr, teardown := newAddrManagerForTest(t, "TestOverlayAddresses", nil)
+	defer teardown()
+
+	const (
+		onionHost      = "2gzyxa5ihm7nsggfxnu52rck2vv4rvmdlkiu3zzui5du4xyclen53wid.onion"
+		otherOnionHost = "aebagbafaydqqcikbmga2dqpcaireeyuculbogazdinryhi6d4qcmeqd.onion"
+		i2pHost        = "udhdrtrcetjm5sxzskjyr5ztpeszydbh4dpl3pl4utgqqw2v4jna.b32.i2p"
+	)
+	newOverlayAddress := func(host string) *wire.NetAddress {
+		na, err := amgr.HostToNetAddress(host, 16111, wire.SFNodeNetwork)
+		if err != nil {
+			t.Fatalf("HostToNetAddress %s: %s", host, err)
+		}
+		return na
+	}
+
+	onion := newOverlayAddress(onionHost)
+	if onion.Overlay == nil || onion.Overlay.Network != wire.NetworkTorV3 {
+		t.Fatalf("HostToNetAddress: %s is not an onion address", onionHost)
+	}
+	if key, want := NetAddressKey(onion), onionHost+":16111"; key != want {
+		t.Errorf("NetAddressKey\n got: %s want: %s", key, want)
+	}
+
+	_, err := amgr.HostToNetAddress("invalid.onion", 16111, wire.SFNodeNetwork)
+	if err == nil {
+		t.Errorf("HostToNetAddress: expected an error for an invalid onion address")
+	}
+
+	// Ensure known overlay addresses report their network.
+	amgr.AddAddress(onion, onion, nil)
+	ka := amgr.find(onion)
+	if ka == nil {
+		t.Fatalf("AddAddress: the onion address was not added")
+	}
+	if ka.Network() != wire.NetworkTorV3 {
+		t.Errorf("Network\n got: %s want: %s", ka.Network(), wire.NetworkTorV3)
+	}
+
+	publicIPv4 := wire.NewNetAddressIPPort(net.ParseIP("204.124.8.100"), 16111, wire.SFNodeNetwork)
+	for _, localAddr := range []*wire.NetAddress{publicIPv4, onion} {
+		err := amgr.AddLocalAddress(localAddr, ManualPrio)
+		if err != nil {
+			t.Fatalf("AddLocalAddress: %s", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr *wire.NetAddress
+		want       *wire.NetAddress
+	}{
+		{
+			name:       "remote in the same overlay network",
+			remoteAddr: newOverlayAddress(otherOnionHost),
+			want:       onion,
+		},
+		{
+			name:       "remote in another overlay network",
+			remoteAddr: newOverlayAddress(i2pHost),
+			want:       publicIPv4,
+		},
+		{
+			name:       "remote public IPv4",
+			remoteAddr: wire.NewNetAddressIPPort(net.ParseIP("12.1.2.3"), 16111, wire.SFNodeNetwork),
+			want:       publicIPv4,
+		},
+	}
+
+	for _, test := range tests {
+		got := amgr.GetBestLocalAddress(test.remoteAddr)
+		if NetAddressKey(got) != NetAddressKey(test.want) {
+			t.Errorf("GetBestLocalAddress: %s\n got: %s want: %s", test.name,
+				NetAddressKey(got), NetAddressKey(test.want))
+		}
+	}
+}
+
 func TestNetAddressKey(t *testing.T) {
 	addNaTests()
 
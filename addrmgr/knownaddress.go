@@ -36,6 +36,11 @@ func (ka *KnownAddress) SubnetworkID() *subnetworkid.SubnetworkID {
 	return ka.subnetworkID
 }
 
+// Network returns the network of the known address.
+func (ka *KnownAddress) Network() wire.NetworkID {
+	return ka.na.NetworkID()
+}
+
 // LastAttempt returns the last time the known address was attempted.
 func (ka *KnownAddress) LastAttempt() time.Time {
 	return ka.lastattempt
@@ -5,6 +5,7 @@
 package addrmgr
 
 import (
+	"fmt"
 	"net"
 
 	"github.com/kaspanet/kaspad/config"
@@ -92,6 +93,12 @@ func IsIPv4(na *wire.NetAddress) bool {
 	return na.IP.To4() != nil
 }
 
+// IsOverlay returns whether or not the given address is an address in an
+// overlay network, such as a Tor onion service.
+func IsOverlay(na *wire.NetAddress) bool {
+	return na.Overlay != nil
+}
+
 // IsLocal returns whether or not the given address is a local address.
 func IsLocal(na *wire.NetAddress) bool {
 	return na.IP.IsLoopback() || zero4Net.Contains(na.IP)
@@ -192,7 +199,12 @@ func IsRFC6598(na *wire.NetAddress) bool {
 // considered invalid under the following circumstances:
 // IPv4: It is either a zero or all bits set address.
 // IPv6: It is either a zero or RFC3849 documentation address.
+// Overlay network addresses are always valid.
 func IsValid(na *wire.NetAddress) bool {
+	if IsOverlay(na) {
+		return true
+	}
+
 	// IsUnspecified returns if address is 0, so only all bits set, and
 	// RFC3849 need to be explicitly checked.
 	return na.IP != nil && !(na.IP.IsUnspecified() ||
@@ -214,9 +226,9 @@ func IsRoutable(na *wire.NetAddress) bool {
 }
 
 // GroupKey returns a string representing the network group an address is part
-// of. This is the /16 for IPv4, the /32 (/36 for he.net) for IPv6, the string
-// "local" for a local address, and the string "unroutable" for an unroutable
-// address.
+// of. This is the /16 for IPv4, the /32 (/36 for he.net) for IPv6, the first
+// four bits of the key for overlay network addresses, the string "local" for a
+// local address, and the string "unroutable" for an unroutable address.
 func GroupKey(na *wire.NetAddress) string {
 	if IsLocal(na) {
 		return "local"
@@ -224,6 +236,12 @@ func GroupKey(na *wire.NetAddress) string {
 	if !IsRoutable(na) {
 		return "unroutable"
 	}
+	if IsOverlay(na) {
+		// Overlay network addresses carry no information about the
+		// location of the node, so they are only spread over a few
+		// groups per network by the first bits of their key.
+		return fmt.Sprintf("%s:%d", na.Overlay.Network, na.Overlay.Key[0]>>4)
+	}
 	if IsIPv4(na) {
 		return na.IP.Mask(net.CIDRMask(16, 32)).String()
 	}
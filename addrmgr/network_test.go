@@ -153,6 +153,59 @@ func TestIPTypes(t *testing.T) {
 	}
 }
 
+// TestOverlayTypes ensures that overlay network addresses are classified as
+// intended.
+func TestOverlayTypes(t *testing.T) {
+	originalActiveCfg := config.ActiveConfig()
+	config.SetActiveConfig(&config.Config{
+		Flags: &config.Flags{
+			NetworkFlags: config.NetworkFlags{
+				ActiveNetParams: &dagconfig.SimnetParams},
+		},
+	})
+	defer config.SetActiveConfig(originalActiveCfg)
+
+	tests := []struct {
+		host     string
+		groupKey string
+	}{
+		{"2gzyxa5ihm7nsggfxnu52rck2vv4rvmdlkiu3zzui5du4xyclen53wid.onion", "onion:13"},
+		{"udhdrtrcetjm5sxzskjyr5ztpeszydbh4dpl3pl4utgqqw2v4jna.b32.i2p", "i2p:10"},
+	}
+
+	for _, test := range tests {
+		overlay, err := wire.ParseOverlayAddress(test.host)
+		if err != nil {
+			t.Fatalf("ParseOverlayAddress %s: %s", test.host, err)
+		}
+		na := wire.NewNetAddressOverlay(overlay, 16111, wire.SFNodeNetwork)
+
+		if !addrmgr.IsOverlay(na) {
+			t.Errorf("IsOverlay %s\n got: false want: true", test.host)
+		}
+		if addrmgr.IsIPv4(na) {
+			t.Errorf("IsIPv4 %s\n got: true want: false", test.host)
+		}
+		if addrmgr.IsLocal(na) {
+			t.Errorf("IsLocal %s\n got: true want: false", test.host)
+		}
+		if !addrmgr.IsValid(na) {
+			t.Errorf("IsValid %s\n got: false want: true", test.host)
+		}
+		if !addrmgr.IsRoutable(na) {
+			t.Errorf("IsRoutable %s\n got: false want: true", test.host)
+		}
+		if key := addrmgr.GroupKey(na); key != test.groupKey {
+			t.Errorf("GroupKey %s\n got: %s want: %s", test.host, key, test.groupKey)
+		}
+	}
+
+	na := wire.NewNetAddressIPPort(net.ParseIP("12.1.2.3"), 16111, wire.SFNodeNetwork)
+	if addrmgr.IsOverlay(na) {
+		t.Errorf("IsOverlay %s\n got: true want: false", na.IP)
+	}
+}
+
 // TestGroupKey tests the GroupKey function to ensure it properly groups various
 // IP addresses.
 func TestGroupKey(t *testing.T) {
@@ -122,8 +122,10 @@ func (dag *BlockDAG) maybeAcceptBlock(block *util.Block, flags BehaviorFlags) er
 	})
 	if len(chainUpdates.addedChainBlockHashes) > 0 {
 		dag.sendNotification(NTChainChanged, &ChainChangedNotificationData{
-			RemovedChainBlockHashes: chainUpdates.removedChainBlockHashes,
-			AddedChainBlockHashes:   chainUpdates.addedChainBlockHashes,
+			RemovedChainBlockHashes:        chainUpdates.removedChainBlockHashes,
+			AddedChainBlockHashes:          chainUpdates.addedChainBlockHashes,
+			dag:                            dag,
+			addedChainBlocksAcceptanceData: chainUpdates.addedChainBlocksAcceptanceData,
 		})
 	}
 	dag.dagLock.Lock()
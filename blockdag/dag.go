@@ -48,10 +48,14 @@ type delayedBlock struct {
 }
 
 // chainUpdates represents the updates made to the selected parent chain after
-// a block had been added to the DAG.
+// a block had been added to the DAG. addedChainBlocksAcceptanceData only holds
+// the acceptance data of the added block itself. The acceptance data of the
+// other added chain blocks is nil, and is calculated only when needed. See
+// ChainChangedNotificationData.AddedChainBlocksAcceptanceData.
 type chainUpdates struct {
-	removedChainBlockHashes []*daghash.Hash
-	addedChainBlockHashes   []*daghash.Hash
+	removedChainBlockHashes        []*daghash.Hash
+	addedChainBlockHashes          []*daghash.Hash
+	addedChainBlocksAcceptanceData []MultiBlockTxsAcceptanceData
 }
 
 // BlockDAG provides functions for working with the kaspa block DAG.
@@ -151,6 +155,16 @@ type BlockDAG struct {
 
 	lastFinalityPoint *blockNode
 
+	// pruneDepth is the blue score depth below the last finality point
+	// from which the data of blocks is pruned. Zero disables pruning.
+	//
+	// prunedBlueScore is the blue score below which the data of blocks
+	// was pruned. It must be accessed atomically. pruneLock makes sure
+	// that blocks are pruned by a single goroutine at a time.
+	pruneDepth      uint64
+	prunedBlueScore uint64
+	pruneLock       sync.Mutex
+
 	utxoDiffStore *utxoDiffStore
 	multisetStore *multisetStore
 
@@ -602,6 +616,18 @@ func (dag *BlockDAG) connectBlock(node *blockNode,
 		return nil, err
 	}
 
+	// The acceptance data of the new block was already calculated, so
+	// it's reused. Calculating that of the other added chain blocks
+	// requires restoring their past UTXO, so it's deferred until it's
+	// needed, when the DAG lock is no longer held for writes.
+	chainUpdates.addedChainBlocksAcceptanceData =
+		make([]MultiBlockTxsAcceptanceData, len(chainUpdates.addedChainBlockHashes))
+	for i, hash := range chainUpdates.addedChainBlockHashes {
+		if hash.IsEqual(node.hash) {
+			chainUpdates.addedChainBlocksAcceptanceData[i] = txsAcceptanceData
+		}
+	}
+
 	return chainUpdates, nil
 }
 
@@ -879,6 +905,7 @@ func (dag *BlockDAG) updateFinalityPoint() {
 	dag.lastFinalityPoint = currentNode
 	spawn(func() {
 		dag.finalizeNodesBelowFinalityPoint(true)
+		dag.pruneBlocksWithRetry(currentNode)
 	})
 }
 
@@ -1406,6 +1433,61 @@ func (dag *BlockDAG) UTXOSet() *FullUTXOSet {
 	return dag.virtual.utxoSet
 }
 
+// UTXOSetStats describes the UTXO set of the virtual block.
+type UTXOSetStats struct {
+	// SelectedTipHash is the hash of the selected tip at the time the
+	// statistics were gathered.
+	SelectedTipHash *daghash.Hash
+
+	// VirtualBlueScore is the blue score of the virtual block at the time
+	// the statistics were gathered.
+	VirtualBlueScore uint64
+
+	// UTXOCount is the number of unspent outputs in the UTXO set.
+	UTXOCount uint64
+
+	// TotalAmount is the sum of the amounts of all the unspent outputs in
+	// the UTXO set, in sompi.
+	TotalAmount uint64
+
+	// SerializedSize is the total size of all the UTXO set entries, as
+	// serialized for the multiset.
+	SerializedSize uint64
+
+	// MultisetHash is the finalized ECMH multiset of all the UTXO set
+	// entries. Since the multiset does not depend on the order in which
+	// entries are added to it, two nodes that agree on the UTXO set
+	// always agree on this hash.
+	MultisetHash *daghash.Hash
+}
+
+// UTXOSetStats returns statistics about the UTXO set of the virtual block.
+// The statistics are maintained as the UTXO set changes, and its multiset
+// is derived from the multiset of the selected tip, so the UTXO set itself
+// is never iterated over.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) UTXOSetStats() (*UTXOSetStats, error) {
+	dag.dagLock.RLock()
+	defer dag.dagLock.RUnlock()
+
+	multiset, err := dag.NextBlockMultiset()
+	if err != nil {
+		return nil, err
+	}
+	multisetHash := daghash.Hash(*multiset.Finalize())
+
+	utxoSet := dag.virtual.utxoSet
+	return &UTXOSetStats{
+		SelectedTipHash:  dag.selectedTip().hash,
+		VirtualBlueScore: dag.virtual.blueScore,
+		UTXOCount:        uint64(len(utxoSet.utxoCollection)),
+		TotalAmount:      utxoSet.totalAmount,
+		SerializedSize:   utxoSet.serializedSize,
+		MultisetHash:     &multisetHash,
+	}, nil
+}
+
 // CalcPastMedianTime returns the past median time of the DAG.
 func (dag *BlockDAG) CalcPastMedianTime() time.Time {
 	return dag.virtual.tips().bluest().PastMedianTime(dag)
@@ -1807,6 +1889,13 @@ func (dag *BlockDAG) antiPastBetween(lowHash, highHash *daghash.Hash, maxEntries
 	return nodes, nil
 }
 
+// pastChecker checks whether a block is in the past of another block. It's
+// implemented by BlockDAG using its reachability tree, and by headerNodes,
+// which also holds nodes that were built from headers only.
+type pastChecker interface {
+	isInPast(this *blockNode, other *blockNode) (bool, error)
+}
+
 func (dag *BlockDAG) isInPast(this *blockNode, other *blockNode) (bool, error) {
 	return dag.reachabilityTree.isInPast(this, other)
 }
@@ -2025,6 +2114,13 @@ type Config struct {
 	//
 	// This field is required.
 	SubnetworkID *subnetworkid.SubnetworkID
+
+	// PruneDepth is the blue score depth below the last finality point
+	// from which the bodies, fee data and acceptance data of blocks are
+	// deleted. It may not be less than the finality interval.
+	//
+	// This field can be zero if the caller does not wish to prune blocks.
+	PruneDepth uint64
 }
 
 // New returns a BlockDAG instance using the provided configuration details.
@@ -2036,6 +2132,10 @@ func New(config *Config) (*BlockDAG, error) {
 	if config.TimeSource == nil {
 		return nil, errors.New("BlockDAG.New timesource is nil")
 	}
+	err := checkPruneDepth(config.PruneDepth, config.DAGParams.FinalityInterval)
+	if err != nil {
+		return nil, err
+	}
 
 	params := config.DAGParams
 	targetTimePerBlock := int64(params.TargetTimePerBlock / time.Second)
@@ -2060,6 +2160,7 @@ func New(config *Config) (*BlockDAG, error) {
 		blockCount:                     0,
 		subnetworkID:                   config.SubnetworkID,
 		startTime:                      time.Now(),
+		pruneDepth:                     config.PruneDepth,
 	}
 
 	dag.virtual = newVirtualBlock(dag, nil)
@@ -2070,7 +2171,7 @@ func New(config *Config) (*BlockDAG, error) {
 	// Initialize the DAG state from the passed database. When the db
 	// does not yet contain any DAG state, both it and the DAG state
 	// will be initialized to contain only the genesis block.
-	err := dag.initDAGState()
+	err = dag.initDAGState()
 	if err != nil {
 		return nil, err
 	}
@@ -5,6 +5,7 @@
 package blockdag
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/kaspanet/go-secp256k1"
 	"github.com/kaspanet/kaspad/dbaccess"
@@ -13,6 +14,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -1406,3 +1408,102 @@ func TestPastUTXOMultiSet(t *testing.T) {
 		t.Fatalf("TestPastUTXOMultiSet: selectedParentMultiset appears to have changed")
 	}
 }
+
+func TestUTXOSetStats(t *testing.T) {
+	// Create a new database and dag instance to run tests against.
+	params := dagconfig.SimnetParams
+	dag, teardownFunc, err := DAGSetup("TestUTXOSetStats", true, Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("TestUTXOSetStats: Failed to setup dag instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Build a short chain
+	genesis := params.GenesisBlock
+	blockA := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{genesis.BlockHash()}, nil)
+	blockB := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{blockA.BlockHash()}, nil)
+
+	stats, err := dag.UTXOSetStats()
+	if err != nil {
+		t.Fatalf("TestUTXOSetStats: UTXOSetStats unexpectedly failed: %s", err)
+	}
+	if !stats.SelectedTipHash.IsEqual(blockB.BlockHash()) {
+		t.Fatalf("TestUTXOSetStats: unexpected selected tip hash: got %s, want %s",
+			stats.SelectedTipHash, blockB.BlockHash())
+	}
+
+	if stats.UTXOCount == 0 {
+		t.Fatalf("TestUTXOSetStats: expected a non-empty UTXO set")
+	}
+
+	// Recalculate the expected statistics by adding the UTXO set
+	// entries in the opposite order of their serialization.
+	utxoCollection := dag.virtual.utxoSet.utxoCollection
+	outpoints := make([]wire.Outpoint, 0, len(utxoCollection))
+	for outpoint := range utxoCollection {
+		outpoints = append(outpoints, outpoint)
+	}
+	sort.Slice(outpoints, func(i, j int) bool {
+		return outpoints[i].String() > outpoints[j].String()
+	})
+	expectedMultiset := secp256k1.NewMultiset()
+	var expectedTotalAmount, expectedSerializedSize uint64
+	for _, outpoint := range outpoints {
+		entry := utxoCollection[outpoint]
+		expectedMultiset, err = addUTXOToMultiset(expectedMultiset, entry, &outpoint)
+		if err != nil {
+			t.Fatalf("TestUTXOSetStats: addUTXOToMultiset unexpectedly failed: %s", err)
+		}
+		expectedTotalAmount += entry.Amount()
+		w := &bytes.Buffer{}
+		err = serializeUTXO(w, entry, &outpoint)
+		if err != nil {
+			t.Fatalf("TestUTXOSetStats: serializeUTXO unexpectedly failed: %s", err)
+		}
+		expectedSerializedSize += uint64(w.Len())
+	}
+	expectedMultisetHash := daghash.Hash(*expectedMultiset.Finalize())
+
+	if stats.UTXOCount != uint64(len(outpoints)) {
+		t.Errorf("TestUTXOSetStats: unexpected UTXO count: got %d, want %d",
+			stats.UTXOCount, len(outpoints))
+	}
+	if stats.TotalAmount != expectedTotalAmount {
+		t.Errorf("TestUTXOSetStats: unexpected total amount: got %d, want %d",
+			stats.TotalAmount, expectedTotalAmount)
+	}
+	if stats.SerializedSize != expectedSerializedSize {
+		t.Errorf("TestUTXOSetStats: unexpected serialized size: got %d, want %d",
+			stats.SerializedSize, expectedSerializedSize)
+	}
+	if !stats.MultisetHash.IsEqual(&expectedMultisetHash) {
+		t.Errorf("TestUTXOSetStats: unexpected multiset hash: got %s, want %s",
+			stats.MultisetHash, expectedMultisetHash)
+	}
+
+	// Adding a block must change the multiset hash.
+	PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{blockB.BlockHash()}, nil)
+	statsAfterBlock, err := dag.UTXOSetStats()
+	if err != nil {
+		t.Fatalf("TestUTXOSetStats: UTXOSetStats unexpectedly failed: %s", err)
+	}
+	if statsAfterBlock.MultisetHash.IsEqual(stats.MultisetHash) {
+		t.Errorf("TestUTXOSetStats: multiset hash unexpectedly did not change after adding a block")
+	}
+
+	// The maintained statistics must still match the UTXO set.
+	var totalAmountAfterBlock uint64
+	for _, entry := range dag.virtual.utxoSet.utxoCollection {
+		totalAmountAfterBlock += entry.Amount()
+	}
+	if statsAfterBlock.UTXOCount != uint64(len(dag.virtual.utxoSet.utxoCollection)) {
+		t.Errorf("TestUTXOSetStats: unexpected UTXO count after adding a block: got %d, want %d",
+			statsAfterBlock.UTXOCount, len(dag.virtual.utxoSet.utxoCollection))
+	}
+	if statsAfterBlock.TotalAmount != totalAmountAfterBlock {
+		t.Errorf("TestUTXOSetStats: unexpected total amount after adding a block: got %d, want %d",
+			statsAfterBlock.TotalAmount, totalAmountAfterBlock)
+	}
+}
@@ -0,0 +1,223 @@
+package blockdag
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// DAGGraphBlock is a block of a sub-DAG that was exported by DAGGraph, along
+// with its GHOSTDAG coloring and its reachability interval.
+type DAGGraphBlock struct {
+	Hash               *daghash.Hash
+	ParentHashes       []*daghash.Hash
+	SelectedParentHash *daghash.Hash
+	BlueScore          uint64
+
+	// IsBlue is whether the block is blue in the worldview of the
+	// virtual block.
+	IsBlue bool
+
+	// MergeSetBlueHashes and MergeSetRedHashes are the blocks that this
+	// block colored blue and red, respectively, when it was added to the
+	// DAG. These are the blocks in the anticone of its selected parent,
+	// where the selected parent itself is always blue.
+	MergeSetBlueHashes []*daghash.Hash
+	MergeSetRedHashes  []*daghash.Hash
+
+	// ReachabilityIntervalStart and ReachabilityIntervalEnd are the
+	// bounds of the interval of the block in the reachability tree.
+	ReachabilityIntervalStart uint64
+	ReachabilityIntervalEnd   uint64
+}
+
+// DAGGraphTooLargeError is returned by DAGGraph when exporting its window
+// requires traversing more blocks than allowed.
+type DAGGraphTooLargeError struct {
+	MaxBlocks int
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e DAGGraphTooLargeError) Error() string {
+	return fmt.Sprintf("exporting the DAG graph requires traversing more than %d blocks", e.MaxBlocks)
+}
+
+// DAGGraph exports a window of the DAG, ordered by blue score, such that every
+// block appears after all of its parents in the window.
+//
+// If hash is nil, the window holds the blocks of the last levels blue scores,
+// counting back from the selected tip. Otherwise, it holds the block of the
+// given hash, along with the blocks in its past and in its anticone whose blue
+// scores are at most levels away from its own.
+//
+// The window is found by traversing the DAG from its tips, so a
+// DAGGraphTooLargeError is returned if more than maxBlocks blocks have to be
+// traversed, which bounds the work done for windows deep below the tips.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) DAGGraph(hash *daghash.Hash, levels uint64, maxBlocks int) ([]*DAGGraphBlock, error) {
+	dag.dagLock.RLock()
+	defer dag.dagLock.RUnlock()
+
+	if levels == 0 {
+		return nil, nil
+	}
+
+	var centerNode *blockNode
+	lowBlueScore := uint64(0)
+	highBlueScore := dag.selectedTip().blueScore
+	if hash != nil {
+		node, ok := dag.index.LookupNode(hash)
+		if !ok || dag.index.NodeStatus(node).KnownInvalid() {
+			return nil, errNotInDAG(fmt.Sprintf("block %s is not in the DAG", hash))
+		}
+		centerNode = node
+		if node.blueScore > levels {
+			lowBlueScore = node.blueScore - levels
+		}
+		if node.blueScore+levels < highBlueScore {
+			highBlueScore = node.blueScore + levels
+		}
+	} else if highBlueScore >= levels {
+		lowBlueScore = highBlueScore - levels + 1
+	}
+
+	nodes, err := dag.dagGraphNodes(centerNode, lowBlueScore, highBlueScore, maxBlocks)
+	if err != nil {
+		return nil, err
+	}
+	blueNodes := dag.virtualBlueNodes(lowBlueScore)
+
+	blocks := make([]*DAGGraphBlock, 0, len(nodes))
+	for _, node := range nodes {
+		block, err := dag.dagGraphBlock(node, blueNodes)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// dagGraphNodes returns the nodes of the window of DAGGraph, sorted by
+// their blue scores. The DAG is traversed from its tips down to
+// lowBlueScore, which is possible since the blue score of every block is
+// higher than the blue scores of its parents. It fails once more than
+// maxBlocks blocks above lowBlueScore were traversed.
+//
+// This function MUST be called with the DAG state lock held (for reads).
+func (dag *BlockDAG) dagGraphNodes(centerNode *blockNode,
+	lowBlueScore, highBlueScore uint64, maxBlocks int) ([]*blockNode, error) {
+
+	visited := newBlockSet()
+	var queue []*blockNode
+	for tip := range dag.virtual.parents {
+		visited.add(tip)
+		queue = append(queue, tip)
+	}
+
+	var nodes []*blockNode
+	traversedCount := 0
+	for len(queue) > 0 {
+		var current *blockNode
+		current, queue = queue[0], queue[1:]
+		if current.blueScore < lowBlueScore {
+			continue
+		}
+		traversedCount++
+		if traversedCount > maxBlocks {
+			return nil, DAGGraphTooLargeError{MaxBlocks: maxBlocks}
+		}
+		for parent := range current.parents {
+			if !visited.contains(parent) {
+				visited.add(parent)
+				queue = append(queue, parent)
+			}
+		}
+		if current.blueScore > highBlueScore {
+			continue
+		}
+
+		// Blocks in the future of the center node are not part of
+		// the window.
+		if centerNode != nil && current != centerNode {
+			isInFuture, err := dag.isInPast(centerNode, current)
+			if err != nil {
+				return nil, err
+			}
+			if isInFuture {
+				continue
+			}
+		}
+		nodes = append(nodes, current)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].less(nodes[j])
+	})
+	return nodes, nil
+}
+
+// virtualBlueNodes returns the blocks that are blue in the worldview of the
+// virtual block, down to lowBlueScore. Every block is colored by the block
+// of the selected parent chain of the virtual that has it in the anticone
+// of its selected parent, so only the part of the chain whose blocks may
+// color blocks above lowBlueScore is traversed.
+//
+// This function MUST be called with the DAG state lock held (for reads).
+func (dag *BlockDAG) virtualBlueNodes(lowBlueScore uint64) blockSet {
+	blueNodes := newBlockSet()
+	for current := &dag.virtual.blockNode; current != nil; current = current.selectedParent {
+		for _, blue := range current.blues {
+			blueNodes.add(blue)
+		}
+		if current.blueScore <= lowBlueScore {
+			break
+		}
+	}
+
+	// The genesis is not colored by any block, and is always blue.
+	blueNodes.add(dag.genesis)
+	return blueNodes
+}
+
+// dagGraphBlock returns the DAGGraphBlock of the given node.
+//
+// This function MUST be called with the DAG state lock held (for reads).
+func (dag *BlockDAG) dagGraphBlock(node *blockNode, blueNodes blockSet) (*DAGGraphBlock, error) {
+	block := &DAGGraphBlock{
+		Hash:         node.hash,
+		ParentHashes: node.ParentHashes(),
+		BlueScore:    node.blueScore,
+		IsBlue:       blueNodes.contains(node),
+	}
+	if node.selectedParent != nil {
+		block.SelectedParentHash = node.selectedParent.hash
+	}
+
+	mergeSetBlues := newBlockSet()
+	for _, blue := range node.blues {
+		mergeSetBlues.add(blue)
+		block.MergeSetBlueHashes = append(block.MergeSetBlueHashes, blue.hash)
+	}
+	if node.selectedParent != nil {
+		selectedParentAnticone, err := dag.selectedParentAnticone(node)
+		if err != nil {
+			return nil, err
+		}
+		for _, anticoneNode := range selectedParentAnticone {
+			if !mergeSetBlues.contains(anticoneNode) {
+				block.MergeSetRedHashes = append(block.MergeSetRedHashes, anticoneNode.hash)
+			}
+		}
+	}
+
+	treeNode, err := dag.reachabilityTree.store.treeNodeByBlockNode(node)
+	if err != nil {
+		return nil, err
+	}
+	block.ReachabilityIntervalStart = treeNode.interval.start
+	block.ReachabilityIntervalEnd = treeNode.interval.end
+	return block, nil
+}
@@ -0,0 +1,114 @@
+package blockdag
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+func TestDAGGraph(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.K = 1
+	dag, teardownFunc, err := DAGSetup("TestDAGGraph", true, Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup DAG instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Create three parallel blocks and merge them, so that one of them
+	// is colored red when K is 1
+	blockA := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{params.GenesisHash}, nil)
+	blockB := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{params.GenesisHash}, nil)
+	blockC := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{params.GenesisHash}, nil)
+	mergingBlock := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{
+		blockA.BlockHash(), blockB.BlockHash(), blockC.BlockHash()}, nil)
+	mergingHash := mergingBlock.BlockHash()
+
+	blocks, err := dag.DAGGraph(nil, 1, 100)
+	if err != nil {
+		t.Fatalf("TestDAGGraph: DAGGraph unexpectedly failed: %s", err)
+	}
+	if len(blocks) != 1 || !blocks[0].Hash.IsEqual(mergingHash) {
+		t.Fatalf("TestDAGGraph: expected only the merging block in the "+
+			"last blue score level, but got %d blocks", len(blocks))
+	}
+
+	blocks, err = dag.DAGGraph(nil, 100, 100)
+	if err != nil {
+		t.Fatalf("TestDAGGraph: DAGGraph unexpectedly failed: %s", err)
+	}
+	if len(blocks) != 5 {
+		t.Fatalf("TestDAGGraph: expected all 5 blocks, but got %d", len(blocks))
+	}
+	blocksByHash := make(map[daghash.Hash]*DAGGraphBlock)
+	for _, block := range blocks {
+		for _, parentHash := range block.ParentHashes {
+			if _, ok := blocksByHash[*parentHash]; !ok {
+				t.Fatalf("TestDAGGraph: block %s appears before its "+
+					"parent %s", block.Hash, parentHash)
+			}
+		}
+		blocksByHash[*block.Hash] = block
+	}
+
+	merging := blocksByHash[*mergingHash]
+	if len(merging.MergeSetBlueHashes) != 2 || len(merging.MergeSetRedHashes) != 1 {
+		t.Fatalf("TestDAGGraph: expected 2 blues and 1 red in the merge "+
+			"set of the merging block, but got %d blues and %d reds",
+			len(merging.MergeSetBlueHashes), len(merging.MergeSetRedHashes))
+	}
+	redBlock := blocksByHash[*merging.MergeSetRedHashes[0]]
+	if redBlock.IsBlue {
+		t.Fatalf("TestDAGGraph: red block %s is unexpectedly blue", redBlock.Hash)
+	}
+	for _, blueHash := range merging.MergeSetBlueHashes {
+		if !blocksByHash[*blueHash].IsBlue {
+			t.Fatalf("TestDAGGraph: blue block %s is unexpectedly red", blueHash)
+		}
+	}
+	if !merging.IsBlue || !blocksByHash[*params.GenesisHash].IsBlue {
+		t.Fatalf("TestDAGGraph: the merging block and the genesis are " +
+			"unexpectedly red")
+	}
+
+	selectedParent := blocksByHash[*merging.SelectedParentHash]
+	if merging.ReachabilityIntervalStart < selectedParent.ReachabilityIntervalStart ||
+		merging.ReachabilityIntervalEnd > selectedParent.ReachabilityIntervalEnd {
+		t.Fatalf("TestDAGGraph: the reachability interval of the merging " +
+			"block is not inside the interval of its selected parent")
+	}
+
+	// The window around a block holds its past and anticone, but not
+	// its future
+	blocks, err = dag.DAGGraph(blockA.BlockHash(), 1, 100)
+	if err != nil {
+		t.Fatalf("TestDAGGraph: DAGGraph unexpectedly failed: %s", err)
+	}
+	if len(blocks) != 4 {
+		t.Fatalf("TestDAGGraph: expected 4 blocks around block A, but got %d", len(blocks))
+	}
+	for _, block := range blocks {
+		if block.Hash.IsEqual(mergingHash) {
+			t.Fatalf("TestDAGGraph: the window around block A unexpectedly " +
+				"contains a block in its future")
+		}
+	}
+
+	_, err = dag.DAGGraph(&daghash.Hash{1}, 1, 100)
+	if err == nil {
+		t.Fatalf("TestDAGGraph: DAGGraph unexpectedly succeeded for a " +
+			"block that is not in the DAG")
+	}
+
+	// Exporting all 5 blocks requires traversing all of them
+	_, err = dag.DAGGraph(nil, 100, 4)
+	if !errors.As(err, &DAGGraphTooLargeError{}) {
+		t.Fatalf("TestDAGGraph: expected a DAGGraphTooLargeError when "+
+			"traversing more than 4 blocks, but got %v", err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/kaspanet/kaspad/dagconfig"
 	"github.com/kaspanet/kaspad/dbaccess"
@@ -232,6 +233,12 @@ func (dag *BlockDAG) initDAGState() error {
 		return err
 	}
 
+	log.Debugf("Loading the pruned blue score...")
+	err = dag.initPrunedBlueScore()
+	if err != nil {
+		return err
+	}
+
 	log.Debugf("Setting the last finality point...")
 	var ok bool
 	dag.lastFinalityPoint, ok = dag.index.LookupNode(dagState.LastFinalityPoint)
@@ -241,6 +248,11 @@ func (dag *BlockDAG) initDAGState() error {
 	}
 	dag.finalizeNodesBelowFinalityPoint(false)
 
+	err = dag.verifyUTXOSnapshot()
+	if err != nil {
+		return err
+	}
+
 	log.Debugf("Processing unprocessed blockNodes...")
 	err = dag.processUnprocessedBlockNodes(unprocessedBlockNodes)
 	if err != nil {
@@ -610,13 +622,66 @@ func (dag *BlockDAG) BlockByHash(hash *daghash.Hash) (*util.Block, error) {
 		return nil, errNotInDAG(str)
 	}
 
+	// The data of the genesis block may have been deleted from the
+	// block store when blocks were pruned. See pruneBlocks.
+	if node.isGenesis() {
+		return util.NewBlock(dag.dagParams.GenesisBlock), nil
+	}
+
 	block, err := fetchBlockByHash(dbaccess.NoTx(), node.hash)
+	if dbaccess.IsNotFoundError(err) && dag.isNodePruned(node) {
+		str := fmt.Sprintf("the data of block %s was pruned", hash)
+		return nil, errPruned(str)
+	}
 	if err != nil {
 		return nil, err
 	}
 	return block, err
 }
 
+// BlockHashesByBlueScore returns the hashes of the valid blocks whose blue
+// scores are between lowBlueScore and highBlueScore, inclusive, ordered by
+// their blue scores, so that every block appears after all of its parents.
+// Blocks whose data was pruned are skipped, and so is the genesis block once
+// any block was pruned, since their bodies are no longer stored.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) BlockHashesByBlueScore(lowBlueScore, highBlueScore uint64) []*daghash.Hash {
+	dag.dagLock.RLock()
+	defer dag.dagLock.RUnlock()
+
+	var hashes []*daghash.Hash
+	for _, node := range dag.nodesInBlueScoreOrder() {
+		if node.blueScore < lowBlueScore || dag.index.NodeStatus(node).KnownInvalid() {
+			continue
+		}
+		if dag.isNodePruned(node) || (node.isGenesis() && dag.HasPrunedBlocks()) {
+			continue
+		}
+		if node.blueScore > highBlueScore {
+			break
+		}
+		hashes = append(hashes, node.hash)
+	}
+	return hashes
+}
+
+// nodesInBlueScoreOrder returns all the nodes in the block index, sorted
+// by their blue scores, so that every node appears after all of its parents.
+func (dag *BlockDAG) nodesInBlueScoreOrder() []*blockNode {
+	dag.index.RLock()
+	defer dag.index.RUnlock()
+
+	nodes := make([]*blockNode, 0, len(dag.index.index))
+	for _, node := range dag.index.index {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].less(nodes[j])
+	})
+	return nodes
+}
+
 // BlockHashesFrom returns a slice of blocks starting from lowHash
 // ordered by blueScore. If lowHash is nil then the genesis block is used.
 //
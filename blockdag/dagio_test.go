@@ -9,8 +9,10 @@ import (
 	"encoding/hex"
 	"github.com/pkg/errors"
 	"reflect"
+	"sync/atomic"
 	"testing"
 
+	"github.com/kaspanet/kaspad/dagconfig"
 	"github.com/kaspanet/kaspad/util/daghash"
 )
 
@@ -244,3 +246,70 @@ func newHashFromStr(hexStr string) *daghash.Hash {
 	}
 	return hash
 }
+
+func TestBlockHashesByBlueScore(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.K = 1
+	dag, teardownFunc, err := DAGSetup("TestBlockHashesByBlueScore", true, Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup DAG instance: %v", err)
+	}
+	defer teardownFunc()
+
+	blockA := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{params.GenesisHash}, nil)
+	blockB := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{params.GenesisHash}, nil)
+	blockC := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{blockA.BlockHash(), blockB.BlockHash()}, nil)
+	blockD := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{blockC.BlockHash()}, nil)
+
+	// Blocks A and B have the same blue score, so they're ordered by hash
+	blocksWithBlueScore1 := []*daghash.Hash{blockA.BlockHash(), blockB.BlockHash()}
+	if daghash.Less(blocksWithBlueScore1[1], blocksWithBlueScore1[0]) {
+		blocksWithBlueScore1[0], blocksWithBlueScore1[1] = blocksWithBlueScore1[1], blocksWithBlueScore1[0]
+	}
+
+	tests := []struct {
+		name           string
+		lowBlueScore   uint64
+		highBlueScore  uint64
+		expectedHashes []*daghash.Hash
+	}{
+		{
+			name:          "all blocks",
+			lowBlueScore:  0,
+			highBlueScore: dag.SelectedTipBlueScore(),
+			expectedHashes: append(append([]*daghash.Hash{params.GenesisHash}, blocksWithBlueScore1...),
+				blockC.BlockHash(), blockD.BlockHash()),
+		},
+		{
+			name:           "a single blue score",
+			lowBlueScore:   1,
+			highBlueScore:  1,
+			expectedHashes: blocksWithBlueScore1,
+		},
+		{
+			name:           "above the selected tip",
+			lowBlueScore:   dag.SelectedTipBlueScore() + 1,
+			highBlueScore:  dag.SelectedTipBlueScore() + 10,
+			expectedHashes: nil,
+		},
+	}
+	for _, test := range tests {
+		hashes := dag.BlockHashesByBlueScore(test.lowBlueScore, test.highBlueScore)
+		if !reflect.DeepEqual(hashes, test.expectedHashes) {
+			t.Errorf("TestBlockHashesByBlueScore: %s: unexpected hashes. "+
+				"Want: %v, got: %v", test.name, test.expectedHashes, hashes)
+		}
+	}
+
+	// Once the blocks below blue score 2 are pruned, neither they nor the
+	// genesis block are returned
+	atomic.StoreUint64(&dag.prunedBlueScore, 2)
+	hashes := dag.BlockHashesByBlueScore(0, dag.SelectedTipBlueScore())
+	expectedHashes := []*daghash.Hash{blockC.BlockHash(), blockD.BlockHash()}
+	if !reflect.DeepEqual(hashes, expectedHashes) {
+		t.Errorf("TestBlockHashesByBlueScore: pruned blocks: unexpected hashes. "+
+			"Want: %v, got: %v", expectedHashes, hashes)
+	}
+}
@@ -211,6 +211,10 @@ const (
 	// ErrDelayedBlockIsNotAllowed indicates that a block with a delayed timestamp was
 	// submitted with BFDisallowDelay flag raised.
 	ErrDelayedBlockIsNotAllowed
+
+	// ErrHeadersNotSorted indicates that a batch of block headers is not
+	// sorted by GHOSTDAG order
+	ErrHeadersNotSorted
 )
 
 // Map of ErrorCode values back to their constant names for pretty printing.
@@ -260,6 +264,7 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrInvalidPayloadHash:        "ErrInvalidPayloadHash",
 	ErrInvalidParentsRelation:    "ErrInvalidParentsRelation",
 	ErrDelayedBlockIsNotAllowed:  "ErrDelayedBlockIsNotAllowed",
+	ErrHeadersNotSorted:          "ErrHeadersNotSorted",
 }
 
 // String returns the ErrorCode as a human-readable name.
@@ -26,10 +26,19 @@ import (
 //
 // For further details see the article https://eprint.iacr.org/2018/104.pdf
 func (dag *BlockDAG) ghostdag(newNode *blockNode) (selectedParentAnticone []*blockNode, err error) {
+	return dag.ghostdagWithPastChecker(newNode, dag)
+}
+
+// ghostdagWithPastChecker runs the GHOSTDAG protocol like ghostdag, using
+// the given pastChecker to check whether blocks are in the past of each
+// other.
+func (dag *BlockDAG) ghostdagWithPastChecker(newNode *blockNode,
+	pastChecker pastChecker) (selectedParentAnticone []*blockNode, err error) {
+
 	newNode.selectedParent = newNode.parents.bluest()
 	newNode.bluesAnticoneSizes[newNode.selectedParent] = 0
 	newNode.blues = []*blockNode{newNode.selectedParent}
-	selectedParentAnticone, err = dag.selectedParentAnticone(newNode)
+	selectedParentAnticone, err = selectedParentAnticoneWithPastChecker(newNode, pastChecker)
 	if err != nil {
 		return nil, err
 	}
@@ -57,7 +66,7 @@ func (dag *BlockDAG) ghostdag(newNode *blockNode) (selectedParentAnticone []*blo
 			// newNode is always in the future of blueCandidate, so there's
 			// no point in checking it.
 			if chainBlock != newNode {
-				if isAncestorOfBlueCandidate, err := dag.isInPast(chainBlock, blueCandidate); err != nil {
+				if isAncestorOfBlueCandidate, err := pastChecker.isInPast(chainBlock, blueCandidate); err != nil {
 					return nil, err
 				} else if isAncestorOfBlueCandidate {
 					break
@@ -66,7 +75,7 @@ func (dag *BlockDAG) ghostdag(newNode *blockNode) (selectedParentAnticone []*blo
 
 			for _, block := range chainBlock.blues {
 				// Skip blocks that exist in the past of blueCandidate.
-				if isAncestorOfBlueCandidate, err := dag.isInPast(block, blueCandidate); err != nil {
+				if isAncestorOfBlueCandidate, err := pastChecker.isInPast(block, blueCandidate); err != nil {
 					return nil, err
 				} else if isAncestorOfBlueCandidate {
 					continue
@@ -126,6 +135,14 @@ func (dag *BlockDAG) ghostdag(newNode *blockNode) (selectedParentAnticone []*blo
 //   we check whether it is in the past of the selected parent.
 //   If not, we add the node to the resulting anticone-set and queue it for processing.
 func (dag *BlockDAG) selectedParentAnticone(node *blockNode) ([]*blockNode, error) {
+	return selectedParentAnticoneWithPastChecker(node, dag)
+}
+
+// selectedParentAnticoneWithPastChecker returns the blocks in the anticone
+// of the selected parent of the given node like selectedParentAnticone,
+// using the given pastChecker to check whether blocks are in the past of
+// each other.
+func selectedParentAnticoneWithPastChecker(node *blockNode, pastChecker pastChecker) ([]*blockNode, error) {
 	anticoneSet := newBlockSet()
 	var anticoneSlice []*blockNode
 	selectedParentPast := newBlockSet()
@@ -148,7 +165,7 @@ func (dag *BlockDAG) selectedParentAnticone(node *blockNode) ([]*blockNode, erro
 			if anticoneSet.contains(parent) || selectedParentPast.contains(parent) {
 				continue
 			}
-			isAncestorOfSelectedParent, err := dag.isInPast(parent, node.selectedParent)
+			isAncestorOfSelectedParent, err := pastChecker.isInPast(parent, node.selectedParent)
 			if err != nil {
 				return nil, err
 			}
@@ -0,0 +1,192 @@
+package blockdag
+
+import (
+	"fmt"
+
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// ValidateHeaders validates a batch of block headers that were received
+// before their block bodies, so that bogus chains can be rejected before
+// any of their blocks is downloaded. The headers must be sorted by GHOSTDAG
+// order, as returned by AntiPastHeadersBetween, so every header appears
+// after all of its parents.
+//
+// Headers of blocks that are already in the DAG are only checked for their
+// order. Every other header is checked for proof of work, timestamp
+// precision and the order of its parents, and each of its parents must
+// either be in the DAG or appear earlier in the batch. A temporary node is
+// then built for the header on top of its parents, and GHOSTDAG is run on
+// it, so that every header is checked in the context of its parents: its
+// parents must not be finalized or ancestors of each other, and its
+// difficulty and timestamp must match the ones required by
+// requiredDifficulty and the past median time. The DAG is left untouched.
+//
+// The flags are passed along to checkProofOfWork.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) ValidateHeaders(headers []*wire.BlockHeader, flags BehaviorFlags) error {
+	dag.dagLock.RLock()
+	defer dag.dagLock.RUnlock()
+
+	headerNodes := newHeaderNodes(dag)
+	var previousNode *blockNode
+	for _, header := range headers {
+		blockHash := header.BlockHash()
+		if _, ok := headerNodes.nodes[*blockHash]; ok {
+			str := fmt.Sprintf("header %s appears more than once", blockHash)
+			return ruleError(ErrDuplicateBlock, str)
+		}
+
+		node, ok := dag.index.LookupNode(blockHash)
+		if ok {
+			// The header of a block that is already in the DAG was
+			// validated when the block was added.
+			if dag.index.NodeStatus(node).KnownInvalid() {
+				str := fmt.Sprintf("header %s belongs to a block that is "+
+					"known to be invalid", blockHash)
+				return ruleError(ErrInvalidAncestorBlock, str)
+			}
+		} else {
+			var err error
+			node, err = headerNodes.addHeader(header, flags)
+			if err != nil {
+				return err
+			}
+		}
+
+		if previousNode != nil && node.less(previousNode) {
+			str := fmt.Sprintf("header %s with blue score %d appears after "+
+				"header %s with blue score %d", node.hash, node.blueScore,
+				previousNode.hash, previousNode.blueScore)
+			return ruleError(ErrHeadersNotSorted, str)
+		}
+		previousNode = node
+	}
+	return nil
+}
+
+// headerNodes is a temporary set of block nodes that are built from headers
+// whose blocks are not in the DAG yet. The parents of the nodes are either
+// in the DAG or in the set, but the nodes are never added to the block
+// index, to the reachability tree or to the children of their parents.
+type headerNodes struct {
+	dag   *BlockDAG
+	nodes map[daghash.Hash]*blockNode
+}
+
+func newHeaderNodes(dag *BlockDAG) *headerNodes {
+	return &headerNodes{
+		dag:   dag,
+		nodes: make(map[daghash.Hash]*blockNode),
+	}
+}
+
+// addHeader validates the given header in the context of its parents, and
+// adds a node that's built from it to the set.
+//
+// This function MUST be called with the DAG state lock held (for reads).
+func (hn *headerNodes) addHeader(header *wire.BlockHeader, flags BehaviorFlags) (*blockNode, error) {
+	_, err := hn.dag.checkBlockHeaderSanity(header, flags)
+	if err != nil {
+		return nil, err
+	}
+
+	blockHash := header.BlockHash()
+	parents := newBlockSet()
+	for _, parentHash := range header.ParentHashes {
+		parent, ok := hn.nodes[*parentHash]
+		if !ok {
+			parent, ok = hn.dag.index.LookupNode(parentHash)
+			if !ok {
+				str := fmt.Sprintf("parent %s of header %s is neither "+
+					"in the DAG nor earlier in the batch", parentHash, blockHash)
+				return nil, ruleError(ErrParentBlockUnknown, str)
+			}
+			if hn.dag.index.NodeStatus(parent).KnownInvalid() {
+				str := fmt.Sprintf("parent %s of header %s is known "+
+					"to be invalid", parentHash, blockHash)
+				return nil, ruleError(ErrInvalidAncestorBlock, str)
+			}
+		}
+		parents.add(parent)
+	}
+
+	err = validateParentsWithPastChecker(header, parents, hn)
+	if err != nil {
+		return nil, err
+	}
+
+	// The node is created without parents, so that GHOSTDAG runs on it
+	// against the nodes in the set rather than against the reachability
+	// tree, which doesn't know them.
+	node, _ := hn.dag.newBlockNode(header, newBlockSet())
+	node.parents = parents
+	_, err = hn.dag.ghostdagWithPastChecker(node, hn)
+	if err != nil {
+		return nil, err
+	}
+
+	err = hn.dag.checkBlockHeaderContext(header, node.selectedParent, false)
+	if err != nil {
+		return nil, err
+	}
+
+	hn.nodes[*blockHash] = node
+	return node, nil
+}
+
+// isInPast implements pastChecker. Nodes in the set are never in the past of
+// nodes in the DAG. Otherwise, the past of a node in the set is traversed
+// back to the nodes in the DAG, which are checked by the reachability tree.
+// Blue scores strictly increase from parents to children, so only nodes
+// whose blue scores are higher than that of this are traversed.
+//
+// This function MUST be called with the DAG state lock held (for reads).
+func (hn *headerNodes) isInPast(this *blockNode, other *blockNode) (bool, error) {
+	isThisInSet := hn.contains(this)
+	if !hn.contains(other) {
+		if isThisInSet {
+			return false, nil
+		}
+		return hn.dag.isInPast(this, other)
+	}
+
+	visited := newBlockSet()
+	queue := []*blockNode{other}
+	for len(queue) > 0 {
+		var current *blockNode
+		current, queue = queue[0], queue[1:]
+		for parent := range current.parents {
+			if parent == this {
+				return true, nil
+			}
+			if parent.blueScore <= this.blueScore || visited.contains(parent) {
+				continue
+			}
+			visited.add(parent)
+			if hn.contains(parent) {
+				queue = append(queue, parent)
+				continue
+			}
+			if isThisInSet {
+				continue
+			}
+			isInPast, err := hn.dag.isInPast(this, parent)
+			if err != nil {
+				return false, err
+			}
+			if isInPast {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// contains returns whether the given node is in the set.
+func (hn *headerNodes) contains(node *blockNode) bool {
+	setNode, ok := hn.nodes[*node.hash]
+	return ok && setNode == node
+}
@@ -0,0 +1,132 @@
+package blockdag
+
+import (
+	"testing"
+
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+func TestValidateHeaders(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.K = 1
+
+	// Build a DAG in order to collect the headers of its blocks, and then
+	// validate them in a new DAG that doesn't have these blocks.
+	dag, teardownFunc, err := DAGSetup("TestValidateHeaders", true, Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup DAG instance: %v", err)
+	}
+	blockA := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{params.GenesisHash}, nil)
+	blockB := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{params.GenesisHash}, nil)
+	blockC := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{blockA.BlockHash(), blockB.BlockHash()}, nil)
+	blockD := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{blockC.BlockHash()}, nil)
+	headers, err := dag.AntiPastHeadersBetween(params.GenesisHash, blockD.BlockHash(), wire.MaxBlockHeadersPerMsg)
+	if err != nil {
+		t.Fatalf("TestValidateHeaders: AntiPastHeadersBetween unexpectedly "+
+			"failed: %s", err)
+	}
+	teardownFunc()
+
+	// The first header is the header of the genesis, which the new DAG
+	// already has
+	headers = headers[1:]
+
+	dag, teardownFunc, err = DAGSetup("TestValidateHeaders", true, Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup DAG instance: %v", err)
+	}
+	defer teardownFunc()
+
+	withBits := func(header *wire.BlockHeader, bits uint32) *wire.BlockHeader {
+		headerCopy := *header
+		headerCopy.Bits = bits
+		return &headerCopy
+	}
+
+	withParents := func(header *wire.BlockHeader, parentHeaders ...*wire.BlockHeader) *wire.BlockHeader {
+		headerCopy := *header
+		headerCopy.ParentHashes = make([]*daghash.Hash, len(parentHeaders))
+		for i, parentHeader := range parentHeaders {
+			headerCopy.ParentHashes[i] = parentHeader.BlockHash()
+		}
+		daghash.Sort(headerCopy.ParentHashes)
+		return &headerCopy
+	}
+
+	tests := []struct {
+		name          string
+		headers       []*wire.BlockHeader
+		flags         BehaviorFlags
+		expectedError error
+	}{
+		{
+			name:    "valid headers",
+			headers: headers,
+			flags:   BFNoPoWCheck,
+		},
+		{
+			name:          "child before its parent",
+			headers:       []*wire.BlockHeader{headers[3], headers[0], headers[1], headers[2]},
+			flags:         BFNoPoWCheck,
+			expectedError: ruleError(ErrParentBlockUnknown, ""),
+		},
+		{
+			name:          "duplicate header",
+			headers:       []*wire.BlockHeader{headers[0], headers[0]},
+			flags:         BFNoPoWCheck,
+			expectedError: ruleError(ErrDuplicateBlock, ""),
+		},
+		{
+			name:          "unexpected difficulty",
+			headers:       []*wire.BlockHeader{withBits(headers[0], 0x1e7fffff)},
+			flags:         BFNoPoWCheck,
+			expectedError: ruleError(ErrUnexpectedDifficulty, ""),
+		},
+		{
+			name: "unexpected difficulty of a header whose parent is in the batch",
+			headers: []*wire.BlockHeader{headers[0], headers[1], headers[2],
+				withBits(headers[3], 0x1e7fffff)},
+			flags:         BFNoPoWCheck,
+			expectedError: ruleError(ErrUnexpectedDifficulty, ""),
+		},
+		{
+			name: "parent that is an ancestor of another parent in the batch",
+			headers: []*wire.BlockHeader{headers[0], headers[1], headers[2],
+				withParents(headers[3], headers[0], headers[2])},
+			flags:         BFNoPoWCheck,
+			expectedError: ruleError(ErrInvalidParentsRelation, ""),
+		},
+		{
+			name:          "headers not sorted by GHOSTDAG order",
+			headers:       []*wire.BlockHeader{headers[1], headers[0], headers[2], headers[3]},
+			flags:         BFNoPoWCheck,
+			expectedError: ruleError(ErrHeadersNotSorted, ""),
+		},
+		{
+			name:          "insufficient proof of work",
+			headers:       []*wire.BlockHeader{withBits(headers[0], 0x1d00ffff)},
+			flags:         BFNone,
+			expectedError: ruleError(ErrHighHash, ""),
+		},
+	}
+
+	for _, test := range tests {
+		err := dag.ValidateHeaders(test.headers, test.flags)
+		if test.expectedError == nil {
+			if err != nil {
+				t.Errorf("TestValidateHeaders: %s: ValidateHeaders "+
+					"unexpectedly failed: %s", test.name, err)
+			}
+			continue
+		}
+		if checkErr := checkRuleError(err, test.expectedError); checkErr != nil {
+			t.Errorf("TestValidateHeaders: %s: %s", test.name, checkErr)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package indexers
 import (
 	"bytes"
 	"encoding/gob"
+	"fmt"
 	"github.com/kaspanet/kaspad/blockdag"
 	"github.com/kaspanet/kaspad/dbaccess"
 	"github.com/kaspanet/kaspad/util"
@@ -59,31 +60,92 @@ func (idx *AcceptanceIndex) Init(dag *blockdag.BlockDAG) error {
 //
 // This is part of the Indexer interface.
 func (idx *AcceptanceIndex) recover() error {
-	return idx.dag.ForEachHash(func(hash daghash.Hash) error {
-		dbTx, err := dbaccess.NewTx()
+	missingHashes, err := idx.missingBlockHashes()
+	if err != nil {
+		return err
+	}
+	for _, hash := range missingHashes {
+		err := idx.recoverBlock(hash)
 		if err != nil {
 			return err
 		}
-		defer dbTx.RollbackUnlessClosed()
+	}
+	return nil
+}
 
-		exists, err := dbaccess.HasAcceptanceData(dbTx, &hash)
-		if err != nil {
-			return err
-		}
-		if exists {
+// CheckIntegrity makes sure that the acceptance index has the acceptance
+// data of all the valid blocks in the DAG whose data was not pruned, and returns
+// the inconsistency that was found, if any. If repair is true, the missing
+// acceptance data is calculated and inserted into the index.
+func (idx *AcceptanceIndex) CheckIntegrity(dag *blockdag.BlockDAG, repair bool) (*blockdag.IntegrityIssue, error) {
+	idx.dag = dag
+	missingHashes, err := idx.missingBlockHashes()
+	if err != nil {
+		return nil, err
+	}
+	if len(missingHashes) == 0 {
+		return nil, nil
+	}
+
+	issue := &blockdag.IntegrityIssue{
+		Description: fmt.Sprintf("the acceptance data of %d blocks is "+
+			"missing from the acceptance index", len(missingHashes)),
+	}
+	if !repair {
+		return issue, nil
+	}
+	err = idx.recover()
+	if err != nil {
+		return nil, err
+	}
+	issue.IsRepaired = true
+	return issue, nil
+}
+
+// missingBlockHashes returns the hashes of the blocks whose acceptance data
+// is missing from the acceptance index. The acceptance data of pruned blocks
+// and of blocks that are known to be invalid is not expected to be in the
+// index.
+func (idx *AcceptanceIndex) missingBlockHashes() ([]*daghash.Hash, error) {
+	var missingHashes []*daghash.Hash
+	err := idx.dag.ForEachHash(func(hash daghash.Hash) error {
+		if idx.dag.IsPruned(&hash) || idx.dag.IsKnownInvalid(&hash) {
 			return nil
 		}
-		txAcceptanceData, err := idx.dag.TxsAcceptedByBlockHash(&hash)
+		exists, err := dbaccess.HasAcceptanceData(dbaccess.NoTx(), &hash)
 		if err != nil {
 			return err
 		}
-		err = idx.ConnectBlock(dbTx, &hash, txAcceptanceData)
-		if err != nil {
-			return err
+		if !exists {
+			missingHashes = append(missingHashes, &hash)
 		}
-
-		return dbTx.Commit()
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	return missingHashes, nil
+}
+
+// recoverBlock calculates the acceptance data of the block with the given
+// hash and inserts it into the acceptance index.
+func (idx *AcceptanceIndex) recoverBlock(hash *daghash.Hash) error {
+	dbTx, err := dbaccess.NewTx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	txAcceptanceData, err := idx.dag.TxsAcceptedByBlockHash(hash)
+	if err != nil {
+		return err
+	}
+	err = idx.ConnectBlock(dbTx, hash, txAcceptanceData)
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
 }
 
 // ConnectBlock is invoked by the index manager when a new block has been
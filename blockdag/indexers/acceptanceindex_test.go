@@ -334,3 +334,53 @@ func copySymLink(source, dest string) error {
 	}
 	return os.Symlink(link, dest)
 }
+
+func TestAcceptanceIndexCheckIntegrity(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.K = 1
+
+	// Process some blocks without an acceptance index, so that all of
+	// their acceptance data is missing from it
+	closeOpenDatabase(t, "TestAcceptanceIndexCheckIntegrity")
+	dag, teardown, err := blockdag.DAGSetup("TestAcceptanceIndexCheckIntegrity", true, blockdag.Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("TestAcceptanceIndexCheckIntegrity: Failed to setup DAG instance: %v", err)
+	}
+	defer teardown()
+	tipHash := params.GenesisHash
+	for i := 0; i < 3; i++ {
+		block := blockdag.PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{tipHash}, nil)
+		tipHash = block.BlockHash()
+	}
+
+	acceptanceIndex := NewAcceptanceIndex()
+	issue, err := acceptanceIndex.CheckIntegrity(dag, false)
+	if err != nil {
+		t.Fatalf("TestAcceptanceIndexCheckIntegrity: CheckIntegrity unexpectedly failed: %s", err)
+	}
+	if issue == nil || issue.IsRepaired {
+		t.Fatalf("TestAcceptanceIndexCheckIntegrity: expected an unrepaired issue, but got %v", issue)
+	}
+
+	issue, err = acceptanceIndex.CheckIntegrity(dag, true)
+	if err != nil {
+		t.Fatalf("TestAcceptanceIndexCheckIntegrity: CheckIntegrity unexpectedly failed: %s", err)
+	}
+	if issue == nil || !issue.IsRepaired {
+		t.Fatalf("TestAcceptanceIndexCheckIntegrity: expected a repaired issue, but got %v", issue)
+	}
+	_, err = acceptanceIndex.TxsAcceptanceData(tipHash)
+	if err != nil {
+		t.Fatalf("TestAcceptanceIndexCheckIntegrity: TxsAcceptanceData unexpectedly failed: %s", err)
+	}
+
+	issue, err = acceptanceIndex.CheckIntegrity(dag, true)
+	if err != nil {
+		t.Fatalf("TestAcceptanceIndexCheckIntegrity: CheckIntegrity unexpectedly failed: %s", err)
+	}
+	if issue != nil {
+		t.Fatalf("TestAcceptanceIndexCheckIntegrity: unexpected issue after repair: %s", issue)
+	}
+}
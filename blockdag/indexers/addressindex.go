@@ -0,0 +1,458 @@
+package indexers
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+const (
+	// addressIndexTxKeySize is the size of the key of a single
+	// transaction entry under some script hash: the blue score
+	// of the accepting block followed by the transaction ID.
+	addressIndexTxKeySize = 8 + daghash.TxIDSize
+
+	// outpointKeySize is the size of a serialized outpoint: the
+	// transaction ID followed by the output index.
+	outpointKeySize = daghash.TxIDSize + 4
+
+	// addressIndexRecoverBatchSize is the number of blocks that are
+	// indexed in a single database transaction when recovering the
+	// index.
+	addressIndexRecoverBatchSize = 1000
+)
+
+// errTooManyUTXOs signifies that more outputs than were allowed pay to
+// some public key script.
+type errTooManyUTXOs string
+
+// Error implements the error interface.
+func (e errTooManyUTXOs) Error() string {
+	return string(e)
+}
+
+// IsTooManyUTXOsErr returns whether or not the passed error is an
+// errTooManyUTXOs error, which means that more outputs than were allowed
+// pay to the queried public key script.
+func IsTooManyUTXOsErr(err error) bool {
+	var tooManyUTXOsErr errTooManyUTXOs
+	return errors.As(err, &tooManyUTXOsErr)
+}
+
+// AddressIndex implements an address to transactions index. That is to say,
+// it stores a mapping between the hash of every public key script and the
+// transactions that either spend from it or pay to it.
+//
+// Only transactions that were accepted by some block are indexed, so a
+// transaction that merely got included in a block, but whose inputs
+// were double-spent, is never reported.
+type AddressIndex struct {
+	dag *blockdag.BlockDAG
+}
+
+// Ensure the AddressIndex type implements the Indexer interface.
+var _ Indexer = (*AddressIndex)(nil)
+
+// AddressTx describes a single transaction that touched some address.
+type AddressTx struct {
+	TxID               daghash.TxID
+	AcceptingBlockHash daghash.Hash
+	AcceptingBlueScore uint64
+}
+
+// AddressUTXO describes a single unspent output that pays to some address.
+type AddressUTXO struct {
+	Outpoint wire.Outpoint
+	Entry    *blockdag.UTXOEntry
+}
+
+// NewAddressIndex returns a new instance of an indexer that is used to create a
+// mapping between public key scripts and the transactions that involve them.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockdag package. This allows the index to be
+// seamlessly maintained along with the DAG.
+func NewAddressIndex() *AddressIndex {
+	return &AddressIndex{}
+}
+
+// DropAddressIndex drops the address index.
+func DropAddressIndex() error {
+	dbTx, err := dbaccess.NewTx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	err = dbaccess.DropAddressIndex(dbTx)
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// Init initializes the address index.
+//
+// This is part of the Indexer interface.
+func (idx *AddressIndex) Init(dag *blockdag.BlockDAG) error {
+	idx.dag = dag
+	return idx.recover()
+}
+
+// recover attempts to index any block that's missing from the
+// address index.
+//
+// Missing blocks are indexed in ascending blue score order, so that
+// transactions that fund outputs are always indexed before the
+// transactions that spend them, and in batches of
+// addressIndexRecoverBatchSize blocks per database transaction.
+//
+// Blocks whose acceptance data can no longer be calculated, such as
+// pruned blocks, are skipped. See canRecoverBlock.
+func (idx *AddressIndex) recover() error {
+	var missingHashes []*daghash.Hash
+	err := idx.dag.ForEachHash(func(hash daghash.Hash) error {
+		canRecover, err := canRecoverBlock(idx.dag, &hash)
+		if err != nil {
+			return err
+		}
+		if !canRecover {
+			return nil
+		}
+		exists, err := dbaccess.HasAddressIndexIndexedBlock(dbaccess.NoTx(), &hash)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			hashCopy := hash
+			missingHashes = append(missingHashes, &hashCopy)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	blueScores := make(map[daghash.Hash]uint64, len(missingHashes))
+	for _, hash := range missingHashes {
+		blueScore, err := idx.dag.BlueScoreByBlockHash(hash)
+		if err != nil {
+			return err
+		}
+		blueScores[*hash] = blueScore
+	}
+	sort.Slice(missingHashes, func(i, j int) bool {
+		return blueScores[*missingHashes[i]] < blueScores[*missingHashes[j]]
+	})
+
+	for len(missingHashes) > 0 {
+		batchSize := addressIndexRecoverBatchSize
+		if batchSize > len(missingHashes) {
+			batchSize = len(missingHashes)
+		}
+		err := idx.recoverBlocks(missingHashes[:batchSize])
+		if err != nil {
+			return err
+		}
+		missingHashes = missingHashes[batchSize:]
+	}
+	return nil
+}
+
+// recoverBlocks indexes the blocks of the given hashes in a single
+// database transaction.
+func (idx *AddressIndex) recoverBlocks(hashes []*daghash.Hash) error {
+	dbTx, err := dbaccess.NewTx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	for _, hash := range hashes {
+		txsAcceptanceData, err := idx.txsAcceptedByBlockHash(hash)
+		if err != nil {
+			return err
+		}
+		err = idx.ConnectBlock(dbTx, hash, txsAcceptanceData)
+		if err != nil {
+			return err
+		}
+	}
+
+	return dbTx.Commit()
+}
+
+// txsAcceptedByBlockHash returns the acceptance data of the block with
+// the given hash while holding the DAG lock.
+func (idx *AddressIndex) txsAcceptedByBlockHash(hash *daghash.Hash) (blockdag.MultiBlockTxsAcceptanceData, error) {
+	idx.dag.RLock()
+	defer idx.dag.RUnlock()
+
+	return idx.dag.TxsAcceptedByBlockHash(hash)
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the DAG.
+//
+// This is part of the Indexer interface.
+func (idx *AddressIndex) ConnectBlock(dbContext *dbaccess.TxContext, blockHash *daghash.Hash,
+	txsAcceptanceData blockdag.MultiBlockTxsAcceptanceData) error {
+
+	blueScore, err := idx.dag.BlueScoreByBlockHash(blockHash)
+	if err != nil {
+		return err
+	}
+
+	for _, blockTxsAcceptanceData := range txsAcceptanceData {
+		for _, txAcceptanceData := range blockTxsAcceptanceData.TxAcceptanceData {
+			if !txAcceptanceData.IsAccepted {
+				continue
+			}
+			err := idx.indexTx(dbContext, txAcceptanceData.Tx.MsgTx(), blockHash, blueScore)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return dbaccess.StoreAddressIndexIndexedBlock(dbContext, blockHash)
+}
+
+// indexTx adds the given accepted transaction to the entries of
+// every public key script that it spends from or pays to.
+func (idx *AddressIndex) indexTx(dbContext *dbaccess.TxContext, msgTx *wire.MsgTx,
+	acceptingBlockHash *daghash.Hash, acceptingBlueScore uint64) error {
+
+	txID := msgTx.TxID()
+
+	// A transaction may be accepted by more than one block.
+	// Only the first block that accepted it is indexed.
+	exists, err := dbaccess.HasAddressIndexIndexedTx(dbContext, txID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	// The outputs spent by the transaction are removed from the
+	// outpoints of their owners, so that looking up the UTXOs of
+	// some address only ever iterates over unspent outputs.
+	scriptHashes := make(map[daghash.Hash]struct{})
+	if !msgTx.IsCoinBase() {
+		for _, txIn := range msgTx.TxIn {
+			outpointKey := serializeOutpointKey(&txIn.PreviousOutpoint)
+			scriptHash, err := dbaccess.FetchAddressIndexOutpointOwner(dbContext, outpointKey)
+			if dbaccess.IsNotFoundError(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			hash, err := daghash.NewHash(scriptHash)
+			if err != nil {
+				return err
+			}
+			scriptHashes[*hash] = struct{}{}
+
+			err = dbaccess.RemoveAddressIndexOutpoint(dbContext, outpointKey)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for i, txOut := range msgTx.TxOut {
+		scriptHash := scriptPubKeyHash(txOut.ScriptPubKey)
+		outpoint := wire.NewOutpoint(txID, uint32(i))
+		err := dbaccess.StoreAddressIndexOutpoint(dbContext, scriptHash[:], serializeOutpointKey(outpoint))
+		if err != nil {
+			return err
+		}
+		scriptHashes[scriptHash] = struct{}{}
+	}
+
+	txKey := serializeAddressIndexTxKey(acceptingBlueScore, txID)
+	for scriptHash := range scriptHashes {
+		err := dbaccess.StoreAddressIndexTx(dbContext, scriptHash[:], txKey, acceptingBlockHash[:])
+		if err != nil {
+			return err
+		}
+	}
+
+	return dbaccess.StoreAddressIndexIndexedTx(dbContext, txID)
+}
+
+// TxsByScriptPubKey returns the transactions that either spend from or pay
+// to the given public key script, ordered by the blue score of the blocks
+// that accepted them. The first skip transactions are skipped and at most
+// limit transactions are returned.
+func (idx *AddressIndex) TxsByScriptPubKey(scriptPubKey []byte, skip uint64, limit uint64) ([]*AddressTx, error) {
+	scriptHash := scriptPubKeyHash(scriptPubKey)
+	cursor, err := dbaccess.AddressIndexTxsCursor(dbaccess.NoTx(), scriptHash[:])
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	var addressTxs []*AddressTx
+	for i := uint64(0); cursor.Next() && uint64(len(addressTxs)) < limit; i++ {
+		if i < skip {
+			continue
+		}
+		key, err := cursor.Key()
+		if err != nil {
+			return nil, err
+		}
+		acceptingBlueScore, txID, err := deserializeAddressIndexTxKey(key.Suffix())
+		if err != nil {
+			return nil, err
+		}
+		value, err := cursor.Value()
+		if err != nil {
+			return nil, err
+		}
+		acceptingBlockHash, err := daghash.NewHash(value)
+		if err != nil {
+			return nil, err
+		}
+		addressTxs = append(addressTxs, &AddressTx{
+			TxID:               *txID,
+			AcceptingBlockHash: *acceptingBlockHash,
+			AcceptingBlueScore: acceptingBlueScore,
+		})
+	}
+	return addressTxs, nil
+}
+
+// UTXOsByScriptPubKey returns the outputs that pay to the given public key
+// script and are still unspent in the UTXO set of the virtual block. The
+// first skip UTXOs are skipped and at most limit UTXOs are returned.
+func (idx *AddressIndex) UTXOsByScriptPubKey(scriptPubKey []byte, skip uint64, limit uint64) ([]*AddressUTXO, error) {
+	if limit == 0 {
+		return nil, nil
+	}
+	var utxos []*AddressUTXO
+	i := uint64(0)
+	err := idx.forEachUTXO(scriptPubKey, func(utxo *AddressUTXO) bool {
+		if i >= skip {
+			utxos = append(utxos, utxo)
+		}
+		i++
+		return uint64(len(utxos)) < limit
+	})
+	if err != nil {
+		return nil, err
+	}
+	return utxos, nil
+}
+
+// BalanceByScriptPubKey returns the total amount and the number of the
+// outputs that pay to the given public key script and are still unspent
+// in the UTXO set of the virtual block. Returns an error for which
+// IsTooManyUTXOsErr returns true if more than maxUTXOCount outputs pay
+// to the script, so that the scan is bounded.
+func (idx *AddressIndex) BalanceByScriptPubKey(scriptPubKey []byte, maxUTXOCount uint64) (
+	balance uint64, utxoCount uint64, err error) {
+
+	hasTooManyUTXOs := false
+	err = idx.forEachUTXO(scriptPubKey, func(utxo *AddressUTXO) bool {
+		if utxoCount == maxUTXOCount {
+			hasTooManyUTXOs = true
+			return false
+		}
+		balance += utxo.Entry.Amount()
+		utxoCount++
+		return true
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if hasTooManyUTXOs {
+		str := fmt.Sprintf("more than %d unspent outputs pay to the script", maxUTXOCount)
+		return 0, 0, errTooManyUTXOs(str)
+	}
+	return balance, utxoCount, nil
+}
+
+// forEachUTXO calls the given function for every output that pays to the
+// given public key script and is still unspent in the UTXO set of the
+// virtual block, in outpoint order, until the function returns false.
+func (idx *AddressIndex) forEachUTXO(scriptPubKey []byte, fn func(utxo *AddressUTXO) bool) error {
+	scriptHash := scriptPubKeyHash(scriptPubKey)
+	cursor, err := dbaccess.AddressIndexOutpointsCursor(dbaccess.NoTx(), scriptHash[:])
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+
+	for cursor.Next() {
+		key, err := cursor.Key()
+		if err != nil {
+			return err
+		}
+		outpoint, err := deserializeOutpointKey(key.Suffix())
+		if err != nil {
+			return err
+		}
+		entry, ok := idx.dag.GetUTXOEntry(*outpoint)
+		if !ok {
+			continue
+		}
+		if !fn(&AddressUTXO{Outpoint: *outpoint, Entry: entry}) {
+			break
+		}
+	}
+	return nil
+}
+
+func scriptPubKeyHash(scriptPubKey []byte) daghash.Hash {
+	return daghash.HashH(scriptPubKey)
+}
+
+func serializeAddressIndexTxKey(acceptingBlueScore uint64, txID *daghash.TxID) []byte {
+	// The blue score is serialized in big-endian so that
+	// transaction entries are iterated in acceptance order.
+	key := make([]byte, addressIndexTxKeySize)
+	binary.BigEndian.PutUint64(key[:8], acceptingBlueScore)
+	copy(key[8:], txID[:])
+	return key
+}
+
+func deserializeAddressIndexTxKey(key []byte) (uint64, *daghash.TxID, error) {
+	if len(key) != addressIndexTxKeySize {
+		return 0, nil, errors.Errorf("unexpected address index tx key "+
+			"size: got %d, want %d", len(key), addressIndexTxKeySize)
+	}
+	acceptingBlueScore := binary.BigEndian.Uint64(key[:8])
+	txID, err := daghash.NewTxID(key[8:])
+	if err != nil {
+		return 0, nil, err
+	}
+	return acceptingBlueScore, txID, nil
+}
+
+func serializeOutpointKey(outpoint *wire.Outpoint) []byte {
+	key := make([]byte, outpointKeySize)
+	copy(key[:daghash.TxIDSize], outpoint.TxID[:])
+	binary.LittleEndian.PutUint32(key[daghash.TxIDSize:], outpoint.Index)
+	return key
+}
+
+func deserializeOutpointKey(key []byte) (*wire.Outpoint, error) {
+	if len(key) != outpointKeySize {
+		return nil, errors.Errorf("unexpected outpoint key size: "+
+			"got %d, want %d", len(key), outpointKeySize)
+	}
+	txID, err := daghash.NewTxID(key[:daghash.TxIDSize])
+	if err != nil {
+		return nil, err
+	}
+	index := binary.LittleEndian.Uint32(key[daghash.TxIDSize:])
+	return wire.NewOutpoint(txID, index), nil
+}
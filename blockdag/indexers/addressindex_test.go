@@ -0,0 +1,254 @@
+package indexers
+
+import (
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestAddressIndexKeysSerializationAndDeserialization(t *testing.T) {
+	txID, _ := daghash.NewTxIDFromStr("1111111111111111111111111111111111111111111111111111111111111111")
+
+	serializedTxKey := serializeAddressIndexTxKey(math.MaxUint64-1, txID)
+	blueScore, deserializedTxID, err := deserializeAddressIndexTxKey(serializedTxKey)
+	if err != nil {
+		t.Fatalf("TestAddressIndexKeysSerializationAndDeserialization: tx key deserialization failed: %s", err)
+	}
+	if blueScore != math.MaxUint64-1 || !deserializedTxID.IsEqual(txID) {
+		t.Fatalf("TestAddressIndexKeysSerializationAndDeserialization: unexpected tx key: "+
+			"got (%d, %s), want (%d, %s)", blueScore, deserializedTxID, uint64(math.MaxUint64-1), txID)
+	}
+
+	outpoint := wire.NewOutpoint(txID, 7)
+	deserializedOutpoint, err := deserializeOutpointKey(serializeOutpointKey(outpoint))
+	if err != nil {
+		t.Fatalf("TestAddressIndexKeysSerializationAndDeserialization: outpoint deserialization failed: %s", err)
+	}
+	if *deserializedOutpoint != *outpoint {
+		t.Fatalf("TestAddressIndexKeysSerializationAndDeserialization: unexpected outpoint: "+
+			"got %s, want %s", deserializedOutpoint, outpoint)
+	}
+}
+
+func TestAddressIndex(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.BlockCoinbaseMaturity = 0
+
+	blocks, err := blockdag.LoadBlocks(filepath.Join("../testdata/", "blk_0_to_4.dat"))
+	if err != nil {
+		t.Fatalf("Error loading file: %v\n", err)
+	}
+
+	addressIndex := NewAddressIndex()
+	closeOpenDatabase(t, "TestAddressIndex")
+	dag, teardown, err := blockdag.DAGSetup("TestAddressIndex", true, blockdag.Config{
+		IndexManager: NewManager([]Indexer{addressIndex}),
+		DAGParams:    &params,
+	})
+	if err != nil {
+		t.Fatalf("TestAddressIndex: Failed to setup DAG instance: %v", err)
+	}
+	if teardown != nil {
+		defer teardown()
+	}
+
+	for i := 1; i < len(blocks); i++ {
+		isOrphan, isDelayed, err := dag.ProcessBlock(blocks[i], blockdag.BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlock fail on block %v: %v\n", i, err)
+		}
+		if isDelayed {
+			t.Fatalf("ProcessBlock: block %d "+
+				"is too far in the future", i)
+		}
+		if isOrphan {
+			t.Fatalf("ProcessBlock incorrectly returned block %v "+
+				"is an orphan\n", i)
+		}
+	}
+
+	// Every transaction that was accepted by some block must be
+	// indexed under all of its outputs' scripts.
+	for _, block := range blocks {
+		acceptanceData, err := dag.TxsAcceptedByBlockHash(block.Hash())
+		if err != nil {
+			t.Fatalf("TestAddressIndex: TxsAcceptedByBlockHash unexpectedly failed: %s", err)
+		}
+		for _, blockAcceptanceData := range acceptanceData {
+			for _, txAcceptanceData := range blockAcceptanceData.TxAcceptanceData {
+				if !txAcceptanceData.IsAccepted {
+					continue
+				}
+				tx := txAcceptanceData.Tx
+				for _, txOut := range tx.MsgTx().TxOut {
+					addressTxs, err := addressIndex.TxsByScriptPubKey(txOut.ScriptPubKey, 0, math.MaxUint64)
+					if err != nil {
+						t.Fatalf("TestAddressIndex: TxsByScriptPubKey unexpectedly failed: %s", err)
+					}
+					found := false
+					for _, addressTx := range addressTxs {
+						if addressTx.TxID.IsEqual(tx.ID()) {
+							found = true
+							break
+						}
+					}
+					if !found {
+						t.Fatalf("TestAddressIndex: tx %s is missing from the address index", tx.ID())
+					}
+				}
+			}
+		}
+	}
+
+	// Every UTXO returned by the index must exist in the virtual's UTXO
+	// set and pay to the queried script.
+	for _, block := range blocks {
+		for _, txOut := range block.CoinbaseTransaction().MsgTx().TxOut {
+			utxos, err := addressIndex.UTXOsByScriptPubKey(txOut.ScriptPubKey, 0, math.MaxUint64)
+			if err != nil {
+				t.Fatalf("TestAddressIndex: UTXOsByScriptPubKey unexpectedly failed: %s", err)
+			}
+			balance, utxoCount, err := addressIndex.BalanceByScriptPubKey(txOut.ScriptPubKey, math.MaxUint64)
+			if err != nil {
+				t.Fatalf("TestAddressIndex: BalanceByScriptPubKey unexpectedly failed: %s", err)
+			}
+			if utxoCount > 0 {
+				_, _, err := addressIndex.BalanceByScriptPubKey(txOut.ScriptPubKey, utxoCount-1)
+				if !IsTooManyUTXOsErr(err) {
+					t.Fatalf("TestAddressIndex: expected BalanceByScriptPubKey to fail when "+
+						"more than the allowed number of UTXOs pay to the script, got: %v", err)
+				}
+			}
+			if utxoCount != uint64(len(utxos)) {
+				t.Fatalf("TestAddressIndex: unexpected UTXO count: got %d, want %d", utxoCount, len(utxos))
+			}
+			expectedBalance := uint64(0)
+			for _, utxo := range utxos {
+				expectedBalance += utxo.Entry.Amount()
+			}
+			if balance != expectedBalance {
+				t.Fatalf("TestAddressIndex: unexpected balance: got %d, want %d", balance, expectedBalance)
+			}
+			for _, utxo := range utxos {
+				entry, ok := dag.GetUTXOEntry(utxo.Outpoint)
+				if !ok {
+					t.Fatalf("TestAddressIndex: outpoint %s is not in the UTXO set", utxo.Outpoint)
+				}
+				if string(entry.ScriptPubKey()) != string(txOut.ScriptPubKey) {
+					t.Fatalf("TestAddressIndex: outpoint %s pays to an unexpected script", utxo.Outpoint)
+				}
+			}
+		}
+	}
+
+	// Outpoints spent by accepted transactions must be removed from
+	// the index.
+	for _, block := range blocks {
+		acceptanceData, err := dag.TxsAcceptedByBlockHash(block.Hash())
+		if err != nil {
+			t.Fatalf("TestAddressIndex: TxsAcceptedByBlockHash unexpectedly failed: %s", err)
+		}
+		for _, blockAcceptanceData := range acceptanceData {
+			for _, txAcceptanceData := range blockAcceptanceData.TxAcceptanceData {
+				if !txAcceptanceData.IsAccepted || txAcceptanceData.Tx.IsCoinBase() {
+					continue
+				}
+				for _, txIn := range txAcceptanceData.Tx.MsgTx().TxIn {
+					_, err := dbaccess.FetchAddressIndexOutpointOwner(dbaccess.NoTx(),
+						serializeOutpointKey(&txIn.PreviousOutpoint))
+					if !dbaccess.IsNotFoundError(err) {
+						t.Fatalf("TestAddressIndex: spent outpoint %s is still indexed", txIn.PreviousOutpoint)
+					}
+				}
+			}
+		}
+	}
+
+	// Pagination must honor skip and limit.
+	for _, block := range blocks {
+		for _, txOut := range block.CoinbaseTransaction().MsgTx().TxOut {
+			allTxs, err := addressIndex.TxsByScriptPubKey(txOut.ScriptPubKey, 0, math.MaxUint64)
+			if err != nil {
+				t.Fatalf("TestAddressIndex: TxsByScriptPubKey unexpectedly failed: %s", err)
+			}
+			if len(allTxs) < 2 {
+				continue
+			}
+			pagedTxs, err := addressIndex.TxsByScriptPubKey(txOut.ScriptPubKey, 1, 1)
+			if err != nil {
+				t.Fatalf("TestAddressIndex: TxsByScriptPubKey unexpectedly failed: %s", err)
+			}
+			if len(pagedTxs) != 1 || pagedTxs[0].TxID != allTxs[1].TxID {
+				t.Fatalf("TestAddressIndex: unexpected paged result")
+			}
+		}
+	}
+}
+
+func TestAddressIndexRecoverPruned(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.BlockCoinbaseMaturity = 0
+	params.FinalityInterval = 100
+
+	addressIndex := NewAddressIndex()
+	closeOpenDatabase(t, "TestAddressIndexRecoverPruned")
+	dag, teardown, err := blockdag.DAGSetup("TestAddressIndexRecoverPruned", true, blockdag.Config{
+		IndexManager: NewManager([]Indexer{addressIndex}),
+		DAGParams:    &params,
+		PruneDepth:   100,
+	})
+	if err != nil {
+		t.Fatalf("TestAddressIndexRecoverPruned: Failed to setup DAG instance: %v", err)
+	}
+	if teardown != nil {
+		defer teardown()
+	}
+
+	blocks := []*wire.MsgBlock{params.GenesisBlock}
+	for i := uint64(0); i < 4*params.FinalityInterval; i++ {
+		parentHash := blocks[len(blocks)-1].BlockHash()
+		block := blockdag.PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{parentHash}, nil)
+		blocks = append(blocks, block)
+	}
+	err = blockdag.PruneBlocksForTest(dag)
+	if err != nil {
+		t.Fatalf("TestAddressIndexRecoverPruned: PruneBlocksForTest unexpectedly failed: %s", err)
+	}
+	if !dag.HasPrunedBlocks() {
+		t.Fatalf("TestAddressIndexRecoverPruned: expected some blocks to be pruned")
+	}
+
+	// Drop the index and make sure that it's recovered, except for the
+	// transactions that were accepted by blocks whose acceptance data
+	// can no longer be calculated
+	err = DropAddressIndex()
+	if err != nil {
+		t.Fatalf("TestAddressIndexRecoverPruned: DropAddressIndex unexpectedly failed: %s", err)
+	}
+	err = addressIndex.recover()
+	if err != nil {
+		t.Fatalf("TestAddressIndexRecoverPruned: recover unexpectedly failed: %s", err)
+	}
+
+	// The blocks form a chain, so every block is the selected parent of
+	// the block that accepts its transactions.
+	for _, block := range blocks[:len(blocks)-1] {
+		for _, tx := range block.Transactions {
+			exists, err := dbaccess.HasAddressIndexIndexedTx(dbaccess.NoTx(), tx.TxID())
+			if err != nil {
+				t.Fatalf("TestAddressIndexRecoverPruned: HasAddressIndexIndexedTx "+
+					"unexpectedly failed: %s", err)
+			}
+			shouldBeIndexed := !dag.IsKnownFinalizedBlock(block.BlockHash())
+			if exists != shouldBeIndexed {
+				t.Fatalf("TestAddressIndexRecoverPruned: unexpected indexing of tx %s: "+
+					"got %t, want %t", tx.TxID(), exists, shouldBeIndexed)
+			}
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package indexers
+
+import (
+	"testing"
+
+	"github.com/kaspanet/kaspad/dbaccess"
+)
+
+// closeOpenDatabase closes the database if a previous test left it open,
+// so that the calling test may set up a DAG with a database of its own.
+func closeOpenDatabase(t *testing.T, testName string) {
+	err := dbaccess.Close()
+	if err != nil {
+		t.Fatalf("%s: Close unexpectedly failed: %s", testName, err)
+	}
+}
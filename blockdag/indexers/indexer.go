@@ -26,3 +26,20 @@ type Indexer interface {
 		blockHash *daghash.Hash,
 		acceptedTxsData blockdag.MultiBlockTxsAcceptanceData) error
 }
+
+// canRecoverBlock returns whether the acceptance data of the block with the
+// given hash can still be calculated in order to index the block. It can't be
+// calculated for blocks that were pruned or that are known to be invalid, nor
+// for blocks whose selected parent was finalized, since the UTXO diff data
+// that's needed in order to restore the past UTXO of the selected parent was
+// deleted.
+func canRecoverBlock(dag *blockdag.BlockDAG, hash *daghash.Hash) (bool, error) {
+	if dag.IsPruned(hash) || dag.IsKnownInvalid(hash) {
+		return false, nil
+	}
+	selectedParentHash, err := dag.SelectedParentHash(hash)
+	if err != nil {
+		return false, err
+	}
+	return selectedParentHash == nil || !dag.IsKnownFinalizedBlock(selectedParentHash), nil
+}
@@ -0,0 +1,303 @@
+package indexers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+const (
+	// txIndexEntrySize is the size of a serialized transaction index
+	// entry: the hash of the block that includes the transaction, the
+	// hash of the block that accepted it, and the transaction's offset
+	// and length within the including block's serialized bytes.
+	txIndexEntrySize = daghash.HashSize + daghash.HashSize + 4 + 4
+
+	// txIndexRecoverBatchSize is the number of blocks that are indexed
+	// in a single database transaction when recovering the index.
+	txIndexRecoverBatchSize = 1000
+)
+
+// TxIndex implements a transaction by ID index. That is to say, it stores
+// a mapping between a transaction's ID and the first block that accepted
+// it, along with the location of the transaction in the block that
+// includes it, so that transactions may be retrieved even after they had
+// left the mempool.
+//
+// Only transactions that were accepted by some block are indexed, so a
+// transaction that merely got included in a block, but whose inputs
+// were double-spent, is never reported.
+type TxIndex struct {
+	dag *blockdag.BlockDAG
+}
+
+// Ensure the TxIndex type implements the Indexer interface.
+var _ Indexer = (*TxIndex)(nil)
+
+// NewTxIndex returns a new instance of an indexer that is used to create a
+// mapping between transaction IDs and the blocks that include them.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockdag package. This allows the index to be
+// seamlessly maintained along with the DAG.
+func NewTxIndex() *TxIndex {
+	return &TxIndex{}
+}
+
+// DropTxIndex drops the transaction index.
+func DropTxIndex() error {
+	dbTx, err := dbaccess.NewTx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	err = dbaccess.DropTxIndex(dbTx)
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// Init initializes the transaction index.
+//
+// This is part of the Indexer interface.
+func (idx *TxIndex) Init(dag *blockdag.BlockDAG) error {
+	idx.dag = dag
+	return idx.recover()
+}
+
+// recover attempts to index any block that's missing from the
+// transaction index.
+//
+// Missing blocks are indexed in ascending blue score order, so that
+// every transaction is indexed by the first block that accepted it,
+// and in batches of txIndexRecoverBatchSize blocks per database
+// transaction.
+//
+// Blocks whose acceptance data can no longer be calculated, such as
+// pruned blocks, are skipped. See canRecoverBlock.
+func (idx *TxIndex) recover() error {
+	var missingHashes []*daghash.Hash
+	err := idx.dag.ForEachHash(func(hash daghash.Hash) error {
+		canRecover, err := canRecoverBlock(idx.dag, &hash)
+		if err != nil {
+			return err
+		}
+		if !canRecover {
+			return nil
+		}
+		exists, err := dbaccess.HasTxIndexIndexedBlock(dbaccess.NoTx(), &hash)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			hashCopy := hash
+			missingHashes = append(missingHashes, &hashCopy)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	blueScores := make(map[daghash.Hash]uint64, len(missingHashes))
+	for _, hash := range missingHashes {
+		blueScore, err := idx.dag.BlueScoreByBlockHash(hash)
+		if err != nil {
+			return err
+		}
+		blueScores[*hash] = blueScore
+	}
+	sort.Slice(missingHashes, func(i, j int) bool {
+		return blueScores[*missingHashes[i]] < blueScores[*missingHashes[j]]
+	})
+
+	for len(missingHashes) > 0 {
+		batchSize := txIndexRecoverBatchSize
+		if batchSize > len(missingHashes) {
+			batchSize = len(missingHashes)
+		}
+		err := idx.recoverBlocks(missingHashes[:batchSize])
+		if err != nil {
+			return err
+		}
+		missingHashes = missingHashes[batchSize:]
+	}
+	return nil
+}
+
+// recoverBlocks indexes the blocks of the given hashes in a single
+// database transaction.
+func (idx *TxIndex) recoverBlocks(hashes []*daghash.Hash) error {
+	dbTx, err := dbaccess.NewTx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	for _, hash := range hashes {
+		txsAcceptanceData, err := idx.txsAcceptedByBlockHash(hash)
+		if err != nil {
+			return err
+		}
+		err = idx.ConnectBlock(dbTx, hash, txsAcceptanceData)
+		if err != nil {
+			return err
+		}
+	}
+
+	return dbTx.Commit()
+}
+
+// txsAcceptedByBlockHash returns the acceptance data of the block with
+// the given hash while holding the DAG lock.
+func (idx *TxIndex) txsAcceptedByBlockHash(hash *daghash.Hash) (blockdag.MultiBlockTxsAcceptanceData, error) {
+	idx.dag.RLock()
+	defer idx.dag.RUnlock()
+
+	return idx.dag.TxsAcceptedByBlockHash(hash)
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the DAG.
+//
+// Every transaction that the block accepted and that was not accepted by
+// any block before is indexed by the block.
+//
+// This is part of the Indexer interface.
+func (idx *TxIndex) ConnectBlock(dbContext *dbaccess.TxContext, blockHash *daghash.Hash,
+	txsAcceptanceData blockdag.MultiBlockTxsAcceptanceData) error {
+
+	for _, blockTxsAcceptanceData := range txsAcceptanceData {
+		err := idx.indexAcceptedTxs(dbContext, blockHash, &blockTxsAcceptanceData)
+		if err != nil {
+			return err
+		}
+	}
+
+	return dbaccess.StoreTxIndexIndexedBlock(dbContext, blockHash)
+}
+
+// indexAcceptedTxs indexes the transactions of a single block that were
+// accepted by the block with the given acceptingBlockHash, unless they
+// were already accepted by another block.
+func (idx *TxIndex) indexAcceptedTxs(dbContext *dbaccess.TxContext, acceptingBlockHash *daghash.Hash,
+	blockTxsAcceptanceData *blockdag.BlockTxsAcceptanceData) error {
+
+	var txLocs []wire.TxLoc
+	for i, txAcceptanceData := range blockTxsAcceptanceData.TxAcceptanceData {
+		if !txAcceptanceData.IsAccepted {
+			continue
+		}
+		exists, err := dbaccess.HasTxIndexEntry(dbContext, txAcceptanceData.Tx.ID())
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		// The locations of the transactions are only needed if
+		// any of them is indexed, so the including block is
+		// fetched lazily.
+		if txLocs == nil {
+			txLocs, err = fetchTxLocs(dbContext, &blockTxsAcceptanceData.BlockHash)
+			if err != nil {
+				return err
+			}
+		}
+		entry := serializeTxIndexEntry(&blockTxsAcceptanceData.BlockHash, acceptingBlockHash, txLocs[i])
+		err = dbaccess.StoreTxIndexEntry(dbContext, txAcceptanceData.Tx.ID(), entry)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchTxLocs returns the locations of all the transactions within the
+// serialized bytes of the block with the given hash.
+func fetchTxLocs(dbContext *dbaccess.TxContext, blockHash *daghash.Hash) ([]wire.TxLoc, error) {
+	blockBytes, err := dbaccess.FetchBlock(dbContext, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	block, err := util.NewBlockFromBytes(blockBytes)
+	if err != nil {
+		return nil, err
+	}
+	return block.TxLoc()
+}
+
+// TxByID returns the transaction with the given ID along with the hash of
+// the block that includes it and the hash of the first block that accepted
+// it. Returns an error for which dbaccess.IsNotFoundError returns true if
+// the transaction is not indexed.
+func (idx *TxIndex) TxByID(txID *daghash.TxID) (
+	msgTx *wire.MsgTx, blockHash *daghash.Hash, acceptingBlockHash *daghash.Hash, err error) {
+
+	entry, err := dbaccess.FetchTxIndexEntry(dbaccess.NoTx(), txID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	blockHash, acceptingBlockHash, txLoc, err := deserializeTxIndexEntry(entry)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	blockBytes, err := dbaccess.FetchBlock(dbaccess.NoTx(), blockHash)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	txEnd := txLoc.TxStart + txLoc.TxLen
+	if txEnd > len(blockBytes) {
+		return nil, nil, nil, errors.Errorf("tx index entry of tx %s points "+
+			"outside of block %s", txID, blockHash)
+	}
+
+	msgTx = &wire.MsgTx{}
+	err = msgTx.Deserialize(bytes.NewReader(blockBytes[txLoc.TxStart:txEnd]))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return msgTx, blockHash, acceptingBlockHash, nil
+}
+
+func serializeTxIndexEntry(blockHash *daghash.Hash, acceptingBlockHash *daghash.Hash, txLoc wire.TxLoc) []byte {
+	entry := make([]byte, txIndexEntrySize)
+	copy(entry[:daghash.HashSize], blockHash[:])
+	copy(entry[daghash.HashSize:2*daghash.HashSize], acceptingBlockHash[:])
+	binary.LittleEndian.PutUint32(entry[2*daghash.HashSize:], uint32(txLoc.TxStart))
+	binary.LittleEndian.PutUint32(entry[2*daghash.HashSize+4:], uint32(txLoc.TxLen))
+	return entry
+}
+
+func deserializeTxIndexEntry(entry []byte) (blockHash *daghash.Hash,
+	acceptingBlockHash *daghash.Hash, txLoc wire.TxLoc, err error) {
+
+	if len(entry) != txIndexEntrySize {
+		return nil, nil, wire.TxLoc{}, errors.Errorf("unexpected tx index entry "+
+			"size: got %d, want %d", len(entry), txIndexEntrySize)
+	}
+	blockHash, err = daghash.NewHash(entry[:daghash.HashSize])
+	if err != nil {
+		return nil, nil, wire.TxLoc{}, err
+	}
+	acceptingBlockHash, err = daghash.NewHash(entry[daghash.HashSize : 2*daghash.HashSize])
+	if err != nil {
+		return nil, nil, wire.TxLoc{}, err
+	}
+	txLoc = wire.TxLoc{
+		TxStart: int(binary.LittleEndian.Uint32(entry[2*daghash.HashSize:])),
+		TxLen:   int(binary.LittleEndian.Uint32(entry[2*daghash.HashSize+4:])),
+	}
+	return blockHash, acceptingBlockHash, txLoc, nil
+}
@@ -0,0 +1,207 @@
+package indexers
+
+import (
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTxIndexEntrySerializationAndDeserialization(t *testing.T) {
+	hash, _ := daghash.NewHashFromStr("1111111111111111111111111111111111111111111111111111111111111111")
+	acceptingHash, _ := daghash.NewHashFromStr("2222222222222222222222222222222222222222222222222222222222222222")
+	txLoc := wire.TxLoc{TxStart: 123, TxLen: 456}
+
+	serializedEntry := serializeTxIndexEntry(hash, acceptingHash, txLoc)
+	deserializedHash, deserializedAcceptingHash, deserializedTxLoc, err := deserializeTxIndexEntry(serializedEntry)
+	if err != nil {
+		t.Fatalf("TestTxIndexEntrySerializationAndDeserialization: deserialization failed: %s", err)
+	}
+	if !deserializedHash.IsEqual(hash) {
+		t.Fatalf("TestTxIndexEntrySerializationAndDeserialization: unexpected block hash: "+
+			"got %s, want %s", deserializedHash, hash)
+	}
+	if !deserializedAcceptingHash.IsEqual(acceptingHash) {
+		t.Fatalf("TestTxIndexEntrySerializationAndDeserialization: unexpected accepting block hash: "+
+			"got %s, want %s", deserializedAcceptingHash, acceptingHash)
+	}
+	if deserializedTxLoc != txLoc {
+		t.Fatalf("TestTxIndexEntrySerializationAndDeserialization: unexpected tx location: "+
+			"got %v, want %v", deserializedTxLoc, txLoc)
+	}
+
+	_, _, _, err = deserializeTxIndexEntry(serializedEntry[1:])
+	if err == nil {
+		t.Fatalf("TestTxIndexEntrySerializationAndDeserialization: expected an error " +
+			"when deserializing a truncated entry")
+	}
+}
+
+func TestTxIndex(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.BlockCoinbaseMaturity = 0
+
+	blocks, err := blockdag.LoadBlocks(filepath.Join("../testdata/", "blk_0_to_4.dat"))
+	if err != nil {
+		t.Fatalf("Error loading file: %v\n", err)
+	}
+
+	txIndex := NewTxIndex()
+	closeOpenDatabase(t, "TestTxIndex")
+	dag, teardown, err := blockdag.DAGSetup("TestTxIndex", true, blockdag.Config{
+		IndexManager: NewManager([]Indexer{txIndex}),
+		DAGParams:    &params,
+	})
+	if err != nil {
+		t.Fatalf("TestTxIndex: Failed to setup DAG instance: %v", err)
+	}
+	if teardown != nil {
+		defer teardown()
+	}
+
+	for i := 1; i < len(blocks); i++ {
+		isOrphan, isDelayed, err := dag.ProcessBlock(blocks[i], blockdag.BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlock fail on block %v: %v\n", i, err)
+		}
+		if isDelayed {
+			t.Fatalf("ProcessBlock: block %d "+
+				"is too far in the future", i)
+		}
+		if isOrphan {
+			t.Fatalf("ProcessBlock incorrectly returned block %v "+
+				"is an orphan\n", i)
+		}
+	}
+
+	checkTxIndex := func() {
+		// The blocks form a chain, so the transactions of every block
+		// are accepted by the block that follows it, and the
+		// transactions of the last block aren't accepted yet.
+		for i, block := range blocks {
+			for _, tx := range block.Transactions() {
+				msgTx, blockHash, acceptingBlockHash, err := txIndex.TxByID(tx.ID())
+				if i == len(blocks)-1 {
+					if !dbaccess.IsNotFoundError(err) {
+						t.Fatalf("TestTxIndex: expected a not-found error for tx %s, "+
+							"which was not accepted yet, got: %v", tx.ID(), err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("TestTxIndex: TxByID unexpectedly failed for tx %s: %s", tx.ID(), err)
+				}
+				if !reflect.DeepEqual(msgTx, tx.MsgTx()) {
+					t.Fatalf("TestTxIndex: unexpected transaction returned for tx %s", tx.ID())
+				}
+				if !blockHash.IsEqual(block.Hash()) {
+					t.Fatalf("TestTxIndex: unexpected block hash for tx %s: got %s, want %s",
+						tx.ID(), blockHash, block.Hash())
+				}
+				if !acceptingBlockHash.IsEqual(blocks[i+1].Hash()) {
+					t.Fatalf("TestTxIndex: unexpected accepting block hash for tx %s: got %s, want %s",
+						tx.ID(), acceptingBlockHash, blocks[i+1].Hash())
+				}
+			}
+		}
+	}
+	checkTxIndex()
+
+	unknownTx := util.NewTx(wire.NewNativeMsgTx(wire.TxVersion, nil, nil))
+	_, _, _, err = txIndex.TxByID(unknownTx.ID())
+	if !dbaccess.IsNotFoundError(err) {
+		t.Fatalf("TestTxIndex: expected a not-found error for an unknown tx, got: %v", err)
+	}
+
+	// Drop the index and make sure that it's fully recovered
+	err = DropTxIndex()
+	if err != nil {
+		t.Fatalf("TestTxIndex: DropTxIndex unexpectedly failed: %s", err)
+	}
+	err = txIndex.recover()
+	if err != nil {
+		t.Fatalf("TestTxIndex: recover unexpectedly failed: %s", err)
+	}
+	checkTxIndex()
+}
+
+func TestTxIndexRecoverPruned(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.BlockCoinbaseMaturity = 0
+	params.FinalityInterval = 100
+
+	txIndex := NewTxIndex()
+	closeOpenDatabase(t, "TestTxIndexRecoverPruned")
+	dag, teardown, err := blockdag.DAGSetup("TestTxIndexRecoverPruned", true, blockdag.Config{
+		IndexManager: NewManager([]Indexer{txIndex}),
+		DAGParams:    &params,
+		PruneDepth:   100,
+	})
+	if err != nil {
+		t.Fatalf("TestTxIndexRecoverPruned: Failed to setup DAG instance: %v", err)
+	}
+	if teardown != nil {
+		defer teardown()
+	}
+
+	blocks := []*wire.MsgBlock{params.GenesisBlock}
+	for i := uint64(0); i < 4*params.FinalityInterval; i++ {
+		parentHash := blocks[len(blocks)-1].BlockHash()
+		block := blockdag.PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{parentHash}, nil)
+		blocks = append(blocks, block)
+	}
+	err = blockdag.PruneBlocksForTest(dag)
+	if err != nil {
+		t.Fatalf("TestTxIndexRecoverPruned: PruneBlocksForTest unexpectedly failed: %s", err)
+	}
+	if !dag.HasPrunedBlocks() {
+		t.Fatalf("TestTxIndexRecoverPruned: expected some blocks to be pruned")
+	}
+
+	// Drop the index and make sure that it's recovered, except for the
+	// transactions that were accepted by blocks whose acceptance data
+	// can no longer be calculated
+	err = DropTxIndex()
+	if err != nil {
+		t.Fatalf("TestTxIndexRecoverPruned: DropTxIndex unexpectedly failed: %s", err)
+	}
+	err = txIndex.recover()
+	if err != nil {
+		t.Fatalf("TestTxIndexRecoverPruned: recover unexpectedly failed: %s", err)
+	}
+
+	// The blocks form a chain, so every block is the selected parent of
+	// the block that accepts its transactions.
+	indexedTxCount := 0
+	for i, block := range blocks[:len(blocks)-1] {
+		blockHash := block.BlockHash()
+		for _, tx := range block.Transactions {
+			_, _, acceptingBlockHash, err := txIndex.TxByID(tx.TxID())
+			if dag.IsKnownFinalizedBlock(blockHash) {
+				if !dbaccess.IsNotFoundError(err) {
+					t.Fatalf("TestTxIndexRecoverPruned: expected a not-found error for tx %s, "+
+						"which was accepted by a block whose selected parent was finalized, got: %v",
+						tx.TxID(), err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("TestTxIndexRecoverPruned: TxByID unexpectedly failed for tx %s: %s", tx.TxID(), err)
+			}
+			if !acceptingBlockHash.IsEqual(blocks[i+1].BlockHash()) {
+				t.Fatalf("TestTxIndexRecoverPruned: unexpected accepting block hash for tx %s: got %s, want %s",
+					tx.TxID(), acceptingBlockHash, blocks[i+1].BlockHash())
+			}
+			indexedTxCount++
+		}
+	}
+	if indexedTxCount == 0 {
+		t.Fatalf("TestTxIndexRecoverPruned: expected the transactions of the blocks " +
+			"that were not finalized to be indexed")
+	}
+}
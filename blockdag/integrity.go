@@ -0,0 +1,546 @@
+package blockdag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+	"github.com/pkg/errors"
+)
+
+// The levels of the database integrity checks that are done by
+// CheckStoreIntegrity and CheckIntegrity. Every level includes the checks of
+// the levels below it.
+const (
+	// IntegrityCheckLevelIndex checks that the block index is consistent,
+	// that the block store has the body of every block in the block index
+	// and nothing else, that the bodies at the tail of the block store are
+	// intact, and that the reachability data is consistent.
+	IntegrityCheckLevelIndex = 1
+
+	// IntegrityCheckLevelBlocks also reads every block body from the
+	// block store and makes sure that it matches its hash.
+	IntegrityCheckLevelBlocks = 2
+
+	// IntegrityCheckLevelUTXOSet also restores the past UTXO set of the
+	// selected tip and makes sure that it matches both its stored
+	// multiset and its UTXO commitment.
+	IntegrityCheckLevelUTXOSet = 3
+)
+
+// storeTailCheckBlockCount is the number of block bodies with the highest
+// blue scores that are read back from the block store even at
+// IntegrityCheckLevelIndex. These are the bodies that were stored last, and
+// are the ones that are broken if the tail of the block store is.
+const storeTailCheckBlockCount = 100
+
+// IntegrityIssue describes an inconsistency that was found in the database
+// by an integrity check.
+type IntegrityIssue struct {
+	// Description describes the inconsistency.
+	Description string
+
+	// IsRepaired is true if the inconsistency was repaired.
+	IsRepaired bool
+}
+
+func (issue *IntegrityIssue) String() string {
+	if issue.IsRepaired {
+		return issue.Description + " (repaired)"
+	}
+	return issue.Description
+}
+
+// storedBlockIndexEntry is the part of a block index entry that's needed to
+// check the block store without loading the DAG.
+type storedBlockIndexEntry struct {
+	hash      *daghash.Hash
+	blueScore uint64
+	status    blockStatus
+	isGenesis bool
+}
+
+// CheckStoreIntegrity checks the block index and the block store in the
+// database at the given level, which is one of the IntegrityCheckLevel
+// constants, and returns the inconsistencies that were found. It reads the
+// database directly, so it should be run before the DAG is loaded, which
+// fails if the block index is inconsistent. The blocks whose data was pruned
+// and the blocks that are known to be invalid are not expected to have
+// bodies.
+//
+// If repair is true, the bodies of blocks that are not in the block index
+// are removed from the block store. An inconsistent block index and missing
+// or corrupted block bodies can't be repaired.
+//
+// Note that a broken tail of a flat-file store is already truncated to the
+// last committed location when the database is opened. The bodies at the
+// tail are read back to make sure that nothing committed was lost with it.
+func CheckStoreIntegrity(level int, repair bool) ([]*IntegrityIssue, error) {
+	log.Infof("Checking the block index...")
+	entries, issues, err := readStoredBlockIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	bodyIssues, err := checkBlockBodies(entries, level >= IntegrityCheckLevelBlocks)
+	if err != nil {
+		return nil, err
+	}
+	issues = append(issues, bodyIssues...)
+
+	staleBlocksIssue, err := checkStaleBlockBodies(entries, repair)
+	if err != nil {
+		return nil, err
+	}
+	if staleBlocksIssue != nil {
+		issues = append(issues, staleBlocksIssue)
+	}
+	return issues, nil
+}
+
+// readStoredBlockIndex reads all the entries of the block index, which are
+// sorted by blue score, and returns them along with the inconsistencies
+// that were found in them: an entry whose key doesn't match its header, and
+// an entry whose parent is missing from the block index.
+func readStoredBlockIndex() ([]*storedBlockIndexEntry, []*IntegrityIssue, error) {
+	cursor, err := dbaccess.BlockIndexCursor(dbaccess.NoTx())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close()
+
+	var entries []*storedBlockIndexEntry
+	var issues []*IntegrityIssue
+	hashes := make(map[daghash.Hash]struct{})
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		key, err := cursor.Key()
+		if err != nil {
+			return nil, nil, err
+		}
+		blockRow, err := cursor.Value()
+		if err != nil {
+			return nil, nil, err
+		}
+		keyHash, err := blockHashFromBlockIndexKey(key.Suffix())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		buffer := bytes.NewReader(blockRow)
+		var header wire.BlockHeader
+		err = header.Deserialize(buffer)
+		if err != nil {
+			issues = append(issues, corruptBlockIndexEntryIssue(keyHash, err))
+			continue
+		}
+		statusByte, err := buffer.ReadByte()
+		if err != nil {
+			issues = append(issues, corruptBlockIndexEntryIssue(keyHash, err))
+			continue
+		}
+		if !header.BlockHash().IsEqual(keyHash) {
+			err := errors.Errorf("it holds the header of block %s", header.BlockHash())
+			issues = append(issues, corruptBlockIndexEntryIssue(keyHash, err))
+			continue
+		}
+
+		// The parents of every block have lower blue scores, so they
+		// were already read.
+		for _, parentHash := range header.ParentHashes {
+			if _, ok := hashes[*parentHash]; !ok {
+				issues = append(issues, &IntegrityIssue{
+					Description: fmt.Sprintf("parent %s of block %s is "+
+						"missing from the block index", parentHash, keyHash),
+				})
+			}
+		}
+
+		hashes[*keyHash] = struct{}{}
+		entries = append(entries, &storedBlockIndexEntry{
+			hash:      keyHash,
+			blueScore: binary.BigEndian.Uint64(key.Suffix()[:8]),
+			status:    blockStatus(statusByte),
+			isGenesis: len(header.ParentHashes) == 0,
+		})
+	}
+	return entries, issues, nil
+}
+
+func corruptBlockIndexEntryIssue(hash *daghash.Hash, err error) *IntegrityIssue {
+	return &IntegrityIssue{
+		Description: fmt.Sprintf("the block index entry of block %s is "+
+			"corrupted: %s", hash, err),
+	}
+}
+
+// checkBlockBodies makes sure that the block store has the body of every
+// block index entry whose body is expected to be there. If verifyBodies is
+// true, every body is also read and deserialized, and its hash is compared
+// to the hash of its entry. Otherwise, only the bodies at the tail of the
+// block store are.
+func checkBlockBodies(entries []*storedBlockIndexEntry, verifyBodies bool) ([]*IntegrityIssue, error) {
+	prunedBlueScore, err := dbaccess.FetchPrunedBlueScore(dbaccess.NoTx())
+	if err != nil && !dbaccess.IsNotFoundError(err) {
+		return nil, err
+	}
+
+	log.Infof("Checking the bodies of %d blocks...", len(entries))
+	var issues []*IntegrityIssue
+	tailStart := len(entries) - storeTailCheckBlockCount
+	for i, entry := range entries {
+		if !isBodyExpected(entry, prunedBlueScore) {
+			continue
+		}
+		if !verifyBodies && i < tailStart {
+			exists, err := dbaccess.HasBlock(dbaccess.NoTx(), entry.hash)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				issues = append(issues, missingBlockBodyIssue(entry.hash))
+			}
+			continue
+		}
+
+		blockBytes, err := dbaccess.FetchBlock(dbaccess.NoTx(), entry.hash)
+		if dbaccess.IsNotFoundError(err) {
+			issues = append(issues, missingBlockBodyIssue(entry.hash))
+			continue
+		}
+		if err != nil {
+			issues = append(issues, corruptBlockBodyIssue(entry.hash, err))
+			continue
+		}
+		block, err := util.NewBlockFromBytes(blockBytes)
+		if err != nil {
+			issues = append(issues, corruptBlockBodyIssue(entry.hash, err))
+			continue
+		}
+		if !block.Hash().IsEqual(entry.hash) {
+			err := errors.Errorf("its hash is %s", block.Hash())
+			issues = append(issues, corruptBlockBodyIssue(entry.hash, err))
+		}
+	}
+	return issues, nil
+}
+
+// isBodyExpected returns whether the body of the block of the given entry
+// is expected to be in the block store. The body of the genesis block may
+// have been deleted from the block store along with the bodies of pruned
+// blocks. See pruneBlocks.
+func isBodyExpected(entry *storedBlockIndexEntry, prunedBlueScore uint64) bool {
+	if entry.isGenesis {
+		return prunedBlueScore == 0
+	}
+	return entry.blueScore >= prunedBlueScore && !entry.status.KnownInvalid()
+}
+
+func missingBlockBodyIssue(hash *daghash.Hash) *IntegrityIssue {
+	return &IntegrityIssue{
+		Description: fmt.Sprintf("block %s is in the block index but its "+
+			"body is missing from the block store", hash),
+	}
+}
+
+func corruptBlockBodyIssue(hash *daghash.Hash, err error) *IntegrityIssue {
+	return &IntegrityIssue{
+		Description: fmt.Sprintf("the body of block %s is corrupted: %s", hash, err),
+	}
+}
+
+// checkStaleBlockBodies makes sure that the block store doesn't have the
+// bodies of blocks that are not in the block index. If repair is true, such
+// bodies are deleted. Their space is reclaimed once blocks are pruned past
+// them.
+func checkStaleBlockBodies(entries []*storedBlockIndexEntry, repair bool) (*IntegrityIssue, error) {
+	staleHashes, err := staleBlockHashes(entries)
+	if err != nil {
+		return nil, err
+	}
+	if len(staleHashes) == 0 {
+		return nil, nil
+	}
+
+	issue := &IntegrityIssue{
+		Description: fmt.Sprintf("the block store has the bodies of %d blocks "+
+			"that are not in the block index", len(staleHashes)),
+	}
+	if !repair {
+		return issue, nil
+	}
+
+	dbTx, err := dbaccess.NewTx()
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	for _, hash := range staleHashes {
+		err := dbaccess.DeleteBlock(dbTx, hash)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = dbTx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	issue.IsRepaired = true
+	return issue, nil
+}
+
+// staleBlockHashes returns the hashes of the blocks whose bodies are in the
+// block store but which are not in the given block index entries.
+func staleBlockHashes(entries []*storedBlockIndexEntry) ([]*daghash.Hash, error) {
+	indexHashes := make(map[daghash.Hash]struct{}, len(entries))
+	for _, entry := range entries {
+		indexHashes[*entry.hash] = struct{}{}
+	}
+
+	cursor, err := dbaccess.BlockLocationsCursor(dbaccess.NoTx())
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	var staleHashes []*daghash.Hash
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		key, err := cursor.Key()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := daghash.NewHash(key.Suffix())
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := indexHashes[*hash]; !ok {
+			staleHashes = append(staleHashes, hash)
+		}
+	}
+	return staleHashes, nil
+}
+
+// CheckIntegrity checks the data of the DAG in the database at the given
+// level, which is one of the IntegrityCheckLevel constants, and returns the
+// inconsistencies that were found. The checks of the block index and the
+// block store are done by CheckStoreIntegrity, which should be run before
+// the DAG is loaded.
+//
+// If repair is true and the reachability data is inconsistent, it's rebuilt
+// from the block index. A mismatching UTXO set can't be repaired.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) CheckIntegrity(level int, repair bool) ([]*IntegrityIssue, error) {
+	issues, isReachabilityInconsistent, err := dag.checkIntegrity(level)
+	if err != nil {
+		return nil, err
+	}
+	if !repair || !isReachabilityInconsistent {
+		return issues, nil
+	}
+
+	dag.dagLock.Lock()
+	defer dag.dagLock.Unlock()
+
+	reachabilityIssue, err := dag.repairReachabilityData()
+	if err != nil {
+		return nil, err
+	}
+	if reachabilityIssue != nil {
+		issues[0] = reachabilityIssue
+	}
+	return issues, nil
+}
+
+// checkIntegrity does the checks of CheckIntegrity without repairing
+// anything, so that the DAG only has to be locked for reads. If the
+// reachability data is inconsistent, its issue is the first one.
+func (dag *BlockDAG) checkIntegrity(level int) (issues []*IntegrityIssue,
+	isReachabilityInconsistent bool, err error) {
+
+	dag.dagLock.RLock()
+	defer dag.dagLock.RUnlock()
+
+	log.Infof("Checking the reachability data...")
+	inconsistency := dag.findReachabilityInconsistency(dag.nodesInBlueScoreOrder())
+	if inconsistency != nil {
+		isReachabilityInconsistent = true
+		issues = append(issues, reachabilityIssue(inconsistency))
+	}
+
+	if level >= IntegrityCheckLevelUTXOSet {
+		log.Infof("Verifying the past UTXO set of the selected tip...")
+		err := dag.checkPastUTXOMultiset(dag.virtual.selectedParent)
+		if err != nil {
+			issues = append(issues, &IntegrityIssue{
+				Description: fmt.Sprintf("the UTXO set doesn't match its multiset: %s", err),
+			})
+		}
+	}
+
+	return issues, isReachabilityInconsistent, nil
+}
+
+func reachabilityIssue(inconsistency error) *IntegrityIssue {
+	return &IntegrityIssue{
+		Description: fmt.Sprintf("the reachability data is inconsistent: %s", inconsistency),
+	}
+}
+
+// repairReachabilityData checks the reachability data again, now that the
+// DAG is locked for writes, and rebuilds the reachability tree if it's still
+// inconsistent. It returns nil if the reachability data turned out to be
+// consistent.
+//
+// This function MUST be called with the DAG state lock held (for writes).
+func (dag *BlockDAG) repairReachabilityData() (*IntegrityIssue, error) {
+	nodes := dag.nodesInBlueScoreOrder()
+	inconsistency := dag.findReachabilityInconsistency(nodes)
+	if inconsistency == nil {
+		return nil, nil
+	}
+
+	log.Infof("Rebuilding the reachability tree...")
+	err := dag.rebuildReachabilityTree(nodes)
+	if err != nil {
+		return nil, err
+	}
+	issue := reachabilityIssue(inconsistency)
+	issue.IsRepaired = true
+	return issue, nil
+}
+
+// findReachabilityInconsistency returns an error that describes the first
+// inconsistency between the reachability data and the block index, or nil if
+// there is none. The tree node of every valid node must be the child of the
+// tree node of its selected parent and have an interval within the interval
+// of its parent, the intervals of siblings and of the nodes in a future
+// covering set must be ordered and disjoint, and every node must be in the
+// future of all of its parents.
+func (dag *BlockDAG) findReachabilityInconsistency(nodes []*blockNode) error {
+	store := dag.reachabilityTree.store
+	for _, node := range nodes {
+		if dag.index.NodeStatus(node).KnownInvalid() {
+			continue
+		}
+		treeNode, err := store.treeNodeByBlockNode(node)
+		if err != nil {
+			return err
+		}
+		if treeNode.blockNode != node {
+			return errors.Errorf("the reachability tree node of block %s "+
+				"belongs to block %s", node.hash, treeNode.blockNode.hash)
+		}
+		err = checkOrderedIntervals(treeNode.children)
+		if err != nil {
+			return errors.Wrapf(err, "the children of block %s", node.hash)
+		}
+		futureCoveringSet, err := store.futureCoveringSetByBlockNode(node)
+		if err != nil {
+			return err
+		}
+		err = checkOrderedIntervals(orderedTreeNodeSet(futureCoveringSet))
+		if err != nil {
+			return errors.Wrapf(err, "the future covering set of block %s", node.hash)
+		}
+
+		if node.isGenesis() {
+			continue
+		}
+		if treeNode.parent == nil || treeNode.parent.blockNode != node.selectedParent {
+			return errors.Errorf("the reachability tree parent of block %s "+
+				"is not its selected parent %s", node.hash, node.selectedParent.hash)
+		}
+		if !treeNode.parent.interval.contains(treeNode.interval) {
+			return errors.Errorf("the interval %s of block %s is not within "+
+				"the interval %s of its selected parent", treeNode.interval,
+				node.hash, treeNode.parent.interval)
+		}
+		for parent := range node.parents {
+			isInPast, err := dag.reachabilityTree.isInPast(parent, node)
+			if err != nil {
+				return err
+			}
+			if !isInPast {
+				return errors.Errorf("parent %s of block %s is not in its "+
+					"past", parent.hash, node.hash)
+			}
+		}
+	}
+	return nil
+}
+
+// checkOrderedIntervals makes sure that the intervals of the given tree
+// nodes are ordered and disjoint.
+func checkOrderedIntervals(treeNodes orderedTreeNodeSet) error {
+	for i := 1; i < len(treeNodes); i++ {
+		previous, current := treeNodes[i-1].interval, treeNodes[i].interval
+		if previous.end >= current.start {
+			return errors.Errorf("have the overlapping or unordered "+
+				"intervals %s and %s", previous, current)
+		}
+	}
+	return nil
+}
+
+// rebuildReachabilityTree replaces the reachability tree with a new one,
+// to which the valid nodes are added in the order of their blue scores,
+// just like they were added when they were processed, and stores it in
+// the database. The reachability tree is left as is if this fails.
+func (dag *BlockDAG) rebuildReachabilityTree(nodes []*blockNode) error {
+	oldReachabilityTree := dag.reachabilityTree
+	dag.reachabilityTree = newReachabilityTree(dag)
+	err := dag.addNodesToReachabilityTree(nodes)
+	if err != nil {
+		dag.reachabilityTree = oldReachabilityTree
+		return err
+	}
+
+	dbTx, err := dbaccess.NewTx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	err = dag.reachabilityTree.storeState(dbTx)
+	if err != nil {
+		return err
+	}
+	err = dbTx.Commit()
+	if err != nil {
+		return err
+	}
+	dag.reachabilityTree.store.clearDirtyEntries()
+	return nil
+}
+
+func (dag *BlockDAG) addNodesToReachabilityTree(nodes []*blockNode) error {
+	var selectedTip *blockNode
+	for _, node := range nodes {
+		if dag.index.NodeStatus(node).KnownInvalid() {
+			continue
+		}
+		var selectedParentAnticone []*blockNode
+		if !node.isGenesis() {
+			var err error
+			selectedParentAnticone, err = dag.selectedParentAnticone(node)
+			if err != nil {
+				return err
+			}
+		}
+		isNewSelectedTip := selectedTip == nil || node.blueScore > selectedTip.blueScore
+		err := dag.reachabilityTree.addTreeNode(node, selectedParentAnticone, isNewSelectedTip)
+		if err != nil {
+			return err
+		}
+		if isNewSelectedTip {
+			selectedTip = node
+		}
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package blockdag
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// setupDAGForIntegrityTest creates a DAG with a few blocks, one of which
+// merges two others, and returns it along with the hashes of its blocks
+// in the order they were added.
+func setupDAGForIntegrityTest(t *testing.T, testName string) (*BlockDAG, []*daghash.Hash, func()) {
+	params := dagconfig.SimnetParams
+	params.K = 1
+	dag, teardownFunc, err := DAGSetup(testName, true, Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup DAG instance: %v", err)
+	}
+
+	blockA := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{params.GenesisHash}, nil)
+	blockB := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{params.GenesisHash}, nil)
+	blockC := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{blockA.BlockHash(), blockB.BlockHash()}, nil)
+	blockD := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{blockC.BlockHash()}, nil)
+	hashes := []*daghash.Hash{blockA.BlockHash(), blockB.BlockHash(), blockC.BlockHash(), blockD.BlockHash()}
+	return dag, hashes, teardownFunc
+}
+
+// checkIntegrityForTest runs CheckStoreIntegrity and CheckIntegrity and
+// makes sure that they found the expected number of issues, of which the
+// expected number was repaired.
+func checkIntegrityForTest(t *testing.T, testName string, dag *BlockDAG, level int, repair bool,
+	expectedIssues int, expectedRepaired int) {
+
+	issues, err := CheckStoreIntegrity(level, repair)
+	if err != nil {
+		t.Fatalf("%s: CheckStoreIntegrity unexpectedly failed: %s", testName, err)
+	}
+	dagIssues, err := dag.CheckIntegrity(level, repair)
+	if err != nil {
+		t.Fatalf("%s: CheckIntegrity unexpectedly failed: %s", testName, err)
+	}
+	issues = append(issues, dagIssues...)
+	if len(issues) != expectedIssues {
+		t.Fatalf("%s: expected %d issues at level %d, but got %d: %v",
+			testName, expectedIssues, level, len(issues), issues)
+	}
+	repaired := 0
+	for _, issue := range issues {
+		if issue.IsRepaired {
+			repaired++
+		}
+	}
+	if repaired != expectedRepaired {
+		t.Fatalf("%s: expected %d repaired issues, but got %d: %v",
+			testName, expectedRepaired, repaired, issues)
+	}
+}
+
+func TestCheckIntegrityConsistentDAG(t *testing.T) {
+	dag, _, teardownFunc := setupDAGForIntegrityTest(t, "TestCheckIntegrityConsistentDAG")
+	defer teardownFunc()
+
+	checkIntegrityForTest(t, "TestCheckIntegrityConsistentDAG", dag,
+		IntegrityCheckLevelUTXOSet, true, 0, 0)
+}
+
+func TestCheckIntegrityBlockBodies(t *testing.T) {
+	dag, hashes, teardownFunc := setupDAGForIntegrityTest(t, "TestCheckIntegrityBlockBodies")
+	defer teardownFunc()
+
+	// Replace the body of the first block with the body of the second
+	// one, which can only be noticed once the bodies are verified. The
+	// DAG is small enough for all of its blocks to be at the tail of the
+	// block store, so their bodies are verified at every level
+	blockBytes, err := dbaccess.FetchBlock(dbaccess.NoTx(), hashes[1])
+	if err != nil {
+		t.Fatalf("TestCheckIntegrityBlockBodies: FetchBlock unexpectedly failed: %s", err)
+	}
+	dbTx, err := dbaccess.NewTx()
+	if err != nil {
+		t.Fatalf("TestCheckIntegrityBlockBodies: NewTx unexpectedly failed: %s", err)
+	}
+	defer dbTx.RollbackUnlessClosed()
+	err = dbaccess.DeleteBlock(dbTx, hashes[0])
+	if err != nil {
+		t.Fatalf("TestCheckIntegrityBlockBodies: DeleteBlock unexpectedly failed: %s", err)
+	}
+
+	// Delete the body of the third block, which can't be repaired
+	err = dbaccess.DeleteBlock(dbTx, hashes[2])
+	if err != nil {
+		t.Fatalf("TestCheckIntegrityBlockBodies: DeleteBlock unexpectedly failed: %s", err)
+	}
+	err = dbTx.Commit()
+	if err != nil {
+		t.Fatalf("TestCheckIntegrityBlockBodies: Commit unexpectedly failed: %s", err)
+	}
+
+	dbTx, err = dbaccess.NewTx()
+	if err != nil {
+		t.Fatalf("TestCheckIntegrityBlockBodies: NewTx unexpectedly failed: %s", err)
+	}
+	defer dbTx.RollbackUnlessClosed()
+	err = dbaccess.StoreBlock(dbTx, hashes[0], blockBytes)
+	if err != nil {
+		t.Fatalf("TestCheckIntegrityBlockBodies: StoreBlock unexpectedly failed: %s", err)
+	}
+	err = dbTx.Commit()
+	if err != nil {
+		t.Fatalf("TestCheckIntegrityBlockBodies: Commit unexpectedly failed: %s", err)
+	}
+
+	checkIntegrityForTest(t, "TestCheckIntegrityBlockBodies", dag,
+		IntegrityCheckLevelIndex, true, 2, 0)
+	checkIntegrityForTest(t, "TestCheckIntegrityBlockBodies", dag,
+		IntegrityCheckLevelBlocks, true, 2, 0)
+}
+
+func TestCheckIntegrityStaleBlockBodies(t *testing.T) {
+	dag, hashes, teardownFunc := setupDAGForIntegrityTest(t, "TestCheckIntegrityStaleBlockBodies")
+	defer teardownFunc()
+
+	// Store the body of the last block under a hash that's not in the
+	// block index
+	blockBytes, err := dbaccess.FetchBlock(dbaccess.NoTx(), hashes[3])
+	if err != nil {
+		t.Fatalf("TestCheckIntegrityStaleBlockBodies: FetchBlock unexpectedly failed: %s", err)
+	}
+	staleHash := &daghash.Hash{1}
+	dbTx, err := dbaccess.NewTx()
+	if err != nil {
+		t.Fatalf("TestCheckIntegrityStaleBlockBodies: NewTx unexpectedly failed: %s", err)
+	}
+	defer dbTx.RollbackUnlessClosed()
+	err = dbaccess.StoreBlock(dbTx, staleHash, blockBytes)
+	if err != nil {
+		t.Fatalf("TestCheckIntegrityStaleBlockBodies: StoreBlock unexpectedly failed: %s", err)
+	}
+	err = dbTx.Commit()
+	if err != nil {
+		t.Fatalf("TestCheckIntegrityStaleBlockBodies: Commit unexpectedly failed: %s", err)
+	}
+
+	checkIntegrityForTest(t, "TestCheckIntegrityStaleBlockBodies", dag,
+		IntegrityCheckLevelIndex, false, 1, 0)
+	checkIntegrityForTest(t, "TestCheckIntegrityStaleBlockBodies", dag,
+		IntegrityCheckLevelIndex, true, 1, 1)
+
+	exists, err := dbaccess.HasBlock(dbaccess.NoTx(), staleHash)
+	if err != nil {
+		t.Fatalf("TestCheckIntegrityStaleBlockBodies: HasBlock unexpectedly failed: %s", err)
+	}
+	if exists {
+		t.Fatalf("TestCheckIntegrityStaleBlockBodies: the stale block body " +
+			"was unexpectedly not deleted")
+	}
+	checkIntegrityForTest(t, "TestCheckIntegrityStaleBlockBodies", dag,
+		IntegrityCheckLevelBlocks, true, 0, 0)
+}
+
+func TestCheckIntegrityReachability(t *testing.T) {
+	dag, hashes, teardownFunc := setupDAGForIntegrityTest(t, "TestCheckIntegrityReachability")
+	defer teardownFunc()
+
+	// Move the interval of a block out of the interval of its parent
+	node, ok := dag.index.LookupNode(hashes[2])
+	if !ok {
+		t.Fatalf("TestCheckIntegrityReachability: block %s is not in the DAG", hashes[2])
+	}
+	treeNode, err := dag.reachabilityTree.store.treeNodeByBlockNode(node)
+	if err != nil {
+		t.Fatalf("TestCheckIntegrityReachability: treeNodeByBlockNode "+
+			"unexpectedly failed: %s", err)
+	}
+	parentInterval := treeNode.parent.interval
+	treeNode.interval = newReachabilityInterval(parentInterval.end+1, parentInterval.end+1)
+
+	checkIntegrityForTest(t, "TestCheckIntegrityReachability", dag,
+		IntegrityCheckLevelIndex, false, 1, 0)
+	checkIntegrityForTest(t, "TestCheckIntegrityReachability", dag,
+		IntegrityCheckLevelIndex, true, 1, 1)
+	checkIntegrityForTest(t, "TestCheckIntegrityReachability", dag,
+		IntegrityCheckLevelIndex, true, 0, 0)
+
+	// Make sure that the DAG keeps working with the rebuilt tree
+	block := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{hashes[3]}, nil)
+	blockNode, ok := dag.index.LookupNode(block.BlockHash())
+	if !ok {
+		t.Fatalf("TestCheckIntegrityReachability: block %s is not in the DAG", block.BlockHash())
+	}
+	isInPast, err := dag.isInPast(node, blockNode)
+	if err != nil {
+		t.Fatalf("TestCheckIntegrityReachability: isInPast unexpectedly failed: %s", err)
+	}
+	if !isInPast {
+		t.Fatalf("TestCheckIntegrityReachability: block %s is unexpectedly "+
+			"not in the past of block %s", hashes[2], block.BlockHash())
+	}
+}
+
+func TestCheckIntegrityUTXOSet(t *testing.T) {
+	dag, _, teardownFunc := setupDAGForIntegrityTest(t, "TestCheckIntegrityUTXOSet")
+	defer teardownFunc()
+
+	// Remove a single UTXO from the UTXO set of the virtual
+	for outpoint := range dag.virtual.utxoSet.utxoCollection {
+		dag.virtual.utxoSet.remove(outpoint)
+		break
+	}
+
+	checkIntegrityForTest(t, "TestCheckIntegrityUTXOSet", dag,
+		IntegrityCheckLevelBlocks, true, 0, 0)
+	checkIntegrityForTest(t, "TestCheckIntegrityUTXOSet", dag,
+		IntegrityCheckLevelUTXOSet, true, 1, 0)
+}
+
+func TestCheckStoreIntegrityBlockIndex(t *testing.T) {
+	_, _, teardownFunc := setupDAGForIntegrityTest(t, "TestCheckStoreIntegrityBlockIndex")
+	defer teardownFunc()
+
+	// Add a block index entry whose parent is not in the block index,
+	// and which has no body
+	header := wire.NewBlockHeader(1, []*daghash.Hash{{1}}, &daghash.ZeroHash,
+		&daghash.ZeroHash, &daghash.ZeroHash, 0, 0)
+	w := &bytes.Buffer{}
+	err := header.Serialize(w)
+	if err != nil {
+		t.Fatalf("TestCheckStoreIntegrityBlockIndex: Serialize unexpectedly failed: %s", err)
+	}
+	w.WriteByte(byte(statusDataStored))
+	err = dbaccess.StoreIndexBlock(dbaccess.NoTx(), blockIndexKey(header.BlockHash(), 100), w.Bytes())
+	if err != nil {
+		t.Fatalf("TestCheckStoreIntegrityBlockIndex: StoreIndexBlock unexpectedly failed: %s", err)
+	}
+
+	issues, err := CheckStoreIntegrity(IntegrityCheckLevelIndex, true)
+	if err != nil {
+		t.Fatalf("TestCheckStoreIntegrityBlockIndex: CheckStoreIntegrity unexpectedly failed: %s", err)
+	}
+	if len(issues) != 2 || issues[0].IsRepaired || issues[1].IsRepaired {
+		t.Fatalf("TestCheckStoreIntegrityBlockIndex: expected 2 unrepaired "+
+			"issues, but got %v", issues)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"github.com/kaspanet/kaspad/util"
 	"github.com/kaspanet/kaspad/util/daghash"
+	"sync"
 )
 
 // NotificationType represents the type of a notification message.
@@ -86,4 +87,44 @@ type BlockAddedNotificationData struct {
 type ChainChangedNotificationData struct {
 	RemovedChainBlockHashes []*daghash.Hash
 	AddedChainBlockHashes   []*daghash.Hash
+
+	dag                            *BlockDAG
+	addedChainBlocksAcceptanceData []MultiBlockTxsAcceptanceData
+	acceptanceDataOnce             sync.Once
+	acceptanceDataErr              error
+}
+
+// AddedChainBlocksAcceptanceData returns the transactions that were accepted
+// by each of the blocks in AddedChainBlockHashes, in the same order.
+//
+// Only the acceptance data of the block that changed the chain is known when
+// the notification is sent. That of the other added chain blocks is
+// calculated the first time this function is called, so that it's only
+// calculated if some subscriber needs it.
+//
+// This function is safe for concurrent access, but it must not be called
+// with the DAG lock held.
+func (data *ChainChangedNotificationData) AddedChainBlocksAcceptanceData() (
+	[]MultiBlockTxsAcceptanceData, error) {
+
+	data.acceptanceDataOnce.Do(func() {
+		data.dag.RLock()
+		defer data.dag.RUnlock()
+
+		for i, hash := range data.AddedChainBlockHashes {
+			if data.addedChainBlocksAcceptanceData[i] != nil {
+				continue
+			}
+			txsAcceptanceData, err := data.dag.TxsAcceptedByBlockHash(hash)
+			if err != nil {
+				data.acceptanceDataErr = err
+				return
+			}
+			data.addedChainBlocksAcceptanceData[i] = txsAcceptanceData
+		}
+	})
+	if data.acceptanceDataErr != nil {
+		return nil, data.acceptanceDataErr
+	}
+	return data.addedChainBlocksAcceptanceData, nil
 }
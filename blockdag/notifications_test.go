@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/util/daghash"
 )
 
 // TestNotifications ensures that notification callbacks are fired on events.
@@ -59,3 +60,77 @@ func TestNotifications(t *testing.T) {
 			"times, found %d", numSubscribers, notificationCount)
 	}
 }
+
+// TestChainChangedNotificationAcceptanceData ensures that the acceptance data
+// of all the blocks that were added to the selected parent chain is passed
+// along with chain changed notifications.
+func TestChainChangedNotificationAcceptanceData(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.K = 1
+	dag, teardownFunc, err := DAGSetup("TestChainChangedNotificationAcceptanceData", true, Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup dag instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Build two competing chains, so that extending the second chain
+	// adds several of its blocks to the selected parent chain at once.
+	firstChainBlock := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{params.GenesisHash}, nil)
+	PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{firstChainBlock.BlockHash()}, nil)
+	secondChainBlock := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{params.GenesisHash}, nil)
+	secondChainTipHash := secondChainBlock.BlockHash()
+
+	var data *ChainChangedNotificationData
+	var addedChainBlocksAcceptanceData []MultiBlockTxsAcceptanceData
+	dag.Subscribe(func(notification *Notification) {
+		if notification.Type != NTChainChanged {
+			return
+		}
+		notificationData := notification.Data.(*ChainChangedNotificationData)
+		if data != nil && len(notificationData.AddedChainBlockHashes) <= len(data.AddedChainBlockHashes) {
+			return
+		}
+		data = notificationData
+		addedChainBlocksAcceptanceData, err = data.AddedChainBlocksAcceptanceData()
+		if err != nil {
+			t.Errorf("TestChainChangedNotificationAcceptanceData: "+
+				"AddedChainBlocksAcceptanceData unexpectedly failed: %s", err)
+		}
+	})
+	for i := 0; i < 2; i++ {
+		block := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{secondChainTipHash}, nil)
+		secondChainTipHash = block.BlockHash()
+	}
+
+	if data == nil || len(data.AddedChainBlockHashes) < 2 {
+		t.Fatalf("TestChainChangedNotificationAcceptanceData: expected " +
+			"more than one block to be added to the selected parent chain")
+	}
+	if len(addedChainBlocksAcceptanceData) != len(data.AddedChainBlockHashes) {
+		t.Fatalf("TestChainChangedNotificationAcceptanceData: unexpected acceptance "+
+			"data length: got %d, want %d", len(addedChainBlocksAcceptanceData),
+			len(data.AddedChainBlockHashes))
+	}
+	for i, hash := range data.AddedChainBlockHashes {
+		expectedTxsAcceptanceData, err := dag.TxsAcceptedByBlockHash(hash)
+		if err != nil {
+			t.Fatalf("TestChainChangedNotificationAcceptanceData: "+
+				"TxsAcceptedByBlockHash unexpectedly failed: %s", err)
+		}
+		txsAcceptanceData := addedChainBlocksAcceptanceData[i]
+		if len(txsAcceptanceData) != len(expectedTxsAcceptanceData) {
+			t.Fatalf("TestChainChangedNotificationAcceptanceData: unexpected acceptance "+
+				"data of block %s: got %d blocks, want %d", hash, len(txsAcceptanceData),
+				len(expectedTxsAcceptanceData))
+		}
+		for j, blockTxsAcceptanceData := range txsAcceptanceData {
+			if blockTxsAcceptanceData.BlockHash != expectedTxsAcceptanceData[j].BlockHash {
+				t.Fatalf("TestChainChangedNotificationAcceptanceData: unexpected acceptance "+
+					"data of block %s: got block %s, want %s", hash, blockTxsAcceptanceData.BlockHash,
+					expectedTxsAcceptanceData[j].BlockHash)
+			}
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package blockdag
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+// errPruned signifies that the data of a block that was pruned was
+// requested.
+type errPruned string
+
+// Error implements the error interface.
+func (e errPruned) Error() string {
+	return string(e)
+}
+
+// IsPrunedErr returns whether or not the passed error is an errPruned
+// error, which means that the requested block data was pruned.
+func IsPrunedErr(err error) bool {
+	var prunedErr errPruned
+	return errors.As(err, &prunedErr)
+}
+
+// PruneDepth returns the blue score depth below the last finality point
+// from which the data of blocks is pruned, or zero if pruning is disabled.
+func (dag *BlockDAG) PruneDepth() uint64 {
+	return dag.pruneDepth
+}
+
+// HasPrunedBlocks returns whether the data of any block was pruned, or is
+// missing because the DAG was loaded from a UTXO snapshot.
+func (dag *BlockDAG) HasPrunedBlocks() bool {
+	return atomic.LoadUint64(&dag.prunedBlueScore) > 0
+}
+
+// IsPruned returns whether the data of the block with the given hash
+// was pruned.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) IsPruned(hash *daghash.Hash) bool {
+	node, ok := dag.index.LookupNode(hash)
+	if !ok {
+		return false
+	}
+	return dag.isNodePruned(node)
+}
+
+// isNodePruned returns whether the data of the given node was pruned.
+// The genesis block is never pruned, since its data is always available
+// from the DAG params.
+func (dag *BlockDAG) isNodePruned(node *blockNode) bool {
+	return !node.isGenesis() && node.blueScore < atomic.LoadUint64(&dag.prunedBlueScore)
+}
+
+// initPrunedBlueScore loads the blue score below which block data was
+// pruned from the database.
+func (dag *BlockDAG) initPrunedBlueScore() error {
+	prunedBlueScore, err := dbaccess.FetchPrunedBlueScore(dbaccess.NoTx())
+	if dbaccess.IsNotFoundError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	atomic.StoreUint64(&dag.prunedBlueScore, prunedBlueScore)
+	return nil
+}
+
+const (
+	// maxPruneAttempts is the number of times pruneBlocksWithRetry
+	// attempts to prune blocks before it gives up.
+	maxPruneAttempts = 3
+
+	// pruneRetryInterval is the time pruneBlocksWithRetry waits after a
+	// failed attempt to prune blocks before it retries.
+	pruneRetryInterval = 10 * time.Second
+)
+
+// pruneBlocksWithRetry prunes the blocks below the given finality point,
+// retrying a few times if that fails. Pruning is not critical for the
+// operation of the node, so failures are only logged. Blocks that were
+// not pruned are pruned along with the blocks below the next finality
+// point.
+func (dag *BlockDAG) pruneBlocksWithRetry(finalityPoint *blockNode) {
+	for attempt := 1; ; attempt++ {
+		err := dag.pruneBlocks(finalityPoint)
+		if err == nil {
+			return
+		}
+		if attempt == maxPruneAttempts {
+			log.Errorf("Failed to prune the blocks below finality point %s "+
+				"after %d attempts: %s. Pruning will resume with the next "+
+				"finality point", finalityPoint.hash, attempt, err)
+			return
+		}
+		log.Warnf("Failed to prune the blocks below finality point %s: "+
+			"%s. Retrying in %s", finalityPoint.hash, err, pruneRetryInterval)
+		time.Sleep(pruneRetryInterval)
+	}
+}
+
+// pruneBlocks deletes the bodies, fee data and acceptance data of all the
+// blocks whose blue score is more than dag.pruneDepth below the given
+// finality point, and reclaims the space of the deleted blocks in the
+// block store.
+func (dag *BlockDAG) pruneBlocks(finalityPoint *blockNode) error {
+	if dag.pruneDepth == 0 || finalityPoint.blueScore <= dag.pruneDepth {
+		return nil
+	}
+
+	dag.pruneLock.Lock()
+	defer dag.pruneLock.Unlock()
+
+	oldPrunedBlueScore := atomic.LoadUint64(&dag.prunedBlueScore)
+	newPrunedBlueScore := finalityPoint.blueScore - dag.pruneDepth
+	if newPrunedBlueScore <= oldPrunedBlueScore {
+		return nil
+	}
+
+	// Blue scores strictly increase from parents to children, so all
+	// the nodes that were not pruned yet are reachable from the finality
+	// point through nodes with a blue score of at least oldPrunedBlueScore.
+	//
+	// Every block is stored after its parents, so the earliest stored
+	// block that is kept doesn't have any kept parents. Its blue score is
+	// at most K+1 more than that of its selected parent, so only the
+	// nodes whose blue score is at most K more than newPrunedBlueScore
+	// mark the low-water mark of the block store. The genesis block is
+	// left out, since it's stored first. Its data may be deleted from the
+	// block store along with that of the pruned blocks, so it's always
+	// taken from the DAG params instead. See BlockByHash.
+	var nodesToPrune []*blockNode
+	var lowWaterMarkHashes []*daghash.Hash
+	maxLowWaterMarkBlueScore := newPrunedBlueScore + uint64(dag.dagParams.K)
+	visited := newBlockSet()
+	queue := []*blockNode{finalityPoint}
+	for len(queue) > 0 {
+		var current *blockNode
+		current, queue = queue[0], queue[1:]
+		for parent := range current.parents {
+			if parent.blueScore < oldPrunedBlueScore || visited.contains(parent) {
+				continue
+			}
+			visited.add(parent)
+			queue = append(queue, parent)
+			if parent.isGenesis() {
+				continue
+			}
+			if parent.blueScore < newPrunedBlueScore {
+				nodesToPrune = append(nodesToPrune, parent)
+			} else if parent.blueScore <= maxLowWaterMarkBlueScore {
+				lowWaterMarkHashes = append(lowWaterMarkHashes, parent.hash)
+			}
+		}
+	}
+
+	dbTx, err := dbaccess.NewTx()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	for _, node := range nodesToPrune {
+		err = dbaccess.DeleteBlock(dbTx, node.hash)
+		if err != nil {
+			return err
+		}
+		err = dbaccess.DeleteFeeData(dbTx, node.hash)
+		if err != nil {
+			return err
+		}
+		err = dbaccess.DeleteAcceptanceData(dbTx, node.hash)
+		if err != nil {
+			return err
+		}
+	}
+	err = dbaccess.StorePrunedBlueScore(dbTx, newPrunedBlueScore)
+	if err != nil {
+		return err
+	}
+	err = dbTx.Commit()
+	if err != nil {
+		return err
+	}
+	atomic.StoreUint64(&dag.prunedBlueScore, newPrunedBlueScore)
+
+	err = dbaccess.PruneBlockStore(dbaccess.NoTx(), lowWaterMarkHashes)
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Pruned the data of %d blocks below blue score %d",
+		len(nodesToPrune), newPrunedBlueScore)
+	return nil
+}
+
+// checkPruneDepth makes sure that blocks are never pruned while they may
+// still be needed in order to validate new blocks.
+func checkPruneDepth(pruneDepth uint64, finalityInterval uint64) error {
+	if pruneDepth != 0 && pruneDepth < finalityInterval {
+		return errors.Errorf("the prune depth may not be less than "+
+			"the finality interval (%d)", finalityInterval)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package blockdag
+
+import (
+	"testing"
+
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+func TestPruneBlocks(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.K = 1
+	params.FinalityInterval = 100
+	const pruneDepth = 100
+	dag, teardownFunc, err := DAGSetup("TestPruneBlocks", true, Config{
+		DAGParams:  &params,
+		PruneDepth: pruneDepth,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup DAG instance: %v", err)
+	}
+	defer teardownFunc()
+
+	blockHashes := []*daghash.Hash{params.GenesisHash}
+	for i := uint64(0); i < 4*params.FinalityInterval; i++ {
+		block := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{blockHashes[len(blockHashes)-1]}, nil)
+		blockHashes = append(blockHashes, block.BlockHash())
+	}
+
+	// Pruning normally happens in a separate goroutine whenever the
+	// finality point is updated, so prune explicitly in order to make
+	// sure that it's done.
+	err = dag.pruneBlocks(dag.lastFinalityPoint)
+	if err != nil {
+		t.Fatalf("TestPruneBlocks: pruneBlocks unexpectedly "+
+			"returned an error: %s", err)
+	}
+	prunedBlueScore := dag.lastFinalityPoint.blueScore - pruneDepth
+	if dag.prunedBlueScore != prunedBlueScore {
+		t.Fatalf("TestPruneBlocks: unexpected pruned blue score. "+
+			"Want: %d, got: %d", prunedBlueScore, dag.prunedBlueScore)
+	}
+
+	for _, blockHash := range blockHashes {
+		node, ok := dag.index.LookupNode(blockHash)
+		if !ok {
+			t.Fatalf("TestPruneBlocks: block %s is not in the DAG", blockHash)
+		}
+		_, err := dag.BlockByHash(blockHash)
+		shouldBePruned := !node.isGenesis() && node.blueScore < prunedBlueScore
+		if shouldBePruned {
+			if !IsPrunedErr(err) {
+				t.Fatalf("TestPruneBlocks: expected block %s with blue score %d "+
+					"to be pruned, but got error: %v", blockHash, node.blueScore, err)
+			}
+			if !dag.IsPruned(blockHash) {
+				t.Fatalf("TestPruneBlocks: IsPruned unexpectedly "+
+					"returned false for block %s", blockHash)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("TestPruneBlocks: BlockByHash unexpectedly returned "+
+				"an error for block %s with blue score %d: %s", blockHash, node.blueScore, err)
+		}
+	}
+
+	// Make sure that new blocks are still processed after pruning
+	PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{blockHashes[len(blockHashes)-1]}, nil)
+}
+
+func TestCheckPruneDepth(t *testing.T) {
+	const finalityInterval = 100
+	tests := []struct {
+		pruneDepth    uint64
+		expectedError bool
+	}{
+		{pruneDepth: 0, expectedError: false},
+		{pruneDepth: finalityInterval - 1, expectedError: true},
+		{pruneDepth: finalityInterval, expectedError: false},
+		{pruneDepth: finalityInterval * 10, expectedError: false},
+	}
+	for _, test := range tests {
+		err := checkPruneDepth(test.pruneDepth, finalityInterval)
+		if (err != nil) != test.expectedError {
+			t.Errorf("TestCheckPruneDepth: unexpected result for prune "+
+				"depth %d. Expected error: %t, got: %v", test.pruneDepth,
+				test.expectedError, err)
+		}
+	}
+}
@@ -810,6 +810,21 @@ func (fb futureCoveringTreeNodeSet) String() string {
 }
 
 func (rt *reachabilityTree) addBlock(node *blockNode, selectedParentAnticone []*blockNode) error {
+	// Note that we check for blue score here in order to find out
+	// whether the new node is going to be the virtual's selected
+	// parent. We don't check node == virtual.selectedParent because
+	// at this stage the virtual had not yet been updated. The genesis
+	// node is added before the virtual has a selected parent.
+	isNewSelectedTip := node.isGenesis() || node.blueScore > rt.dag.SelectedTipBlueScore()
+	return rt.addTreeNode(node, selectedParentAnticone, isNewSelectedTip)
+}
+
+// addTreeNode adds the given node to the reachability tree. The reindex
+// root is updated only if the node is going to be the virtual's selected
+// parent.
+func (rt *reachabilityTree) addTreeNode(node *blockNode, selectedParentAnticone []*blockNode,
+	isNewSelectedTip bool) error {
+
 	// Allocate a new reachability tree node
 	newTreeNode := newReachabilityTreeNode(node)
 
@@ -848,12 +863,8 @@ func (rt *reachabilityTree) addBlock(node *blockNode, selectedParentAnticone []*
 		}
 	}
 
-	// Update the reindex root.
-	// Note that we check for blue score here in order to find out
-	// whether the new node is going to be the virtual's selected
-	// parent. We don't check node == virtual.selectedParent because
-	// at this stage the virtual had not yet been updated.
-	if node.blueScore > rt.dag.SelectedTipBlueScore() {
+	// Update the reindex root
+	if isNewSelectedTip {
 		updateStartTime := time.Now()
 		modifiedNodes := newModifiedTreeNodes()
 		err := rt.updateReindexRoot(newTreeNode, modifiedNodes)
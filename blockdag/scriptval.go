@@ -230,3 +230,54 @@ func checkBlockScripts(block *blockNode, utxoSet UTXOSet, transactions []*util.T
 
 	return nil
 }
+
+// CheckBlockScripts validates the scripts of the transactions in the passed
+// block before the block is processed, using the previous outputs that
+// prevOutput returns. Transactions that spend an output that prevOutput
+// doesn't return are skipped, and their scripts are validated when the
+// block is processed. The signatures that are verified are added to the
+// DAG's signature cache, so processing the block afterwards doesn't verify
+// them again.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) CheckBlockScripts(block *util.Block,
+	prevOutput func(outpoint wire.Outpoint) (*UTXOEntry, bool)) error {
+
+	utxoSet := NewFullUTXOSet()
+	var transactions []*util.Tx
+	for _, tx := range block.Transactions() {
+		if tx.IsCoinBase() {
+			continue
+		}
+		hasAllPrevOutputs := true
+		for _, txIn := range tx.MsgTx().TxIn {
+			entry, ok := prevOutput(txIn.PreviousOutpoint)
+			if !ok {
+				hasAllPrevOutputs = false
+				break
+			}
+			utxoSet.add(txIn.PreviousOutpoint, entry)
+		}
+		if hasAllPrevOutputs {
+			transactions = append(transactions, tx)
+		}
+	}
+
+	numInputs := 0
+	for _, tx := range transactions {
+		numInputs += len(tx.MsgTx().TxIn)
+	}
+	txValItems := make([]*txValidateItem, 0, numInputs)
+	for _, tx := range transactions {
+		for txInIdx, txIn := range tx.MsgTx().TxIn {
+			txValItems = append(txValItems, &txValidateItem{
+				txInIndex: txInIdx,
+				txIn:      txIn,
+				tx:        tx,
+			})
+		}
+	}
+
+	validator := newTxValidator(utxoSet, txscript.ScriptNoFlags, dag.sigCache)
+	return validator.Validate(txValItems)
+}
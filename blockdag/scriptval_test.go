@@ -10,7 +10,12 @@ import (
 	"runtime"
 	"testing"
 
+	"github.com/kaspanet/kaspad/dagconfig"
 	"github.com/kaspanet/kaspad/txscript"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+	"github.com/pkg/errors"
 )
 
 // TestCheckBlockScripts ensures that validating the all of the scripts in a
@@ -53,3 +58,87 @@ func TestCheckBlockScripts(t *testing.T) {
 		return
 	}
 }
+
+// TestCheckBlockScriptsWithPrevOutputs ensures that CheckBlockScripts
+// validates the scripts of a block using the previous outputs it's given,
+// and skips the transactions whose previous outputs are missing.
+func TestCheckBlockScriptsWithPrevOutputs(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.BlockCoinbaseMaturity = 0
+	dag, teardownFunc, err := DAGSetup("TestCheckBlockScriptsWithPrevOutputs", true, Config{
+		DAGParams: &params,
+		SigCache:  txscript.NewSigCache(10),
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup DAG instance: %v", err)
+	}
+	defer teardownFunc()
+
+	fundingBlock := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{params.GenesisHash}, nil)
+	cbTx := fundingBlock.Transactions[0]
+
+	signatureScript, err := txscript.PayToScriptHashSignatureScript(OpTrueScript, nil)
+	if err != nil {
+		t.Fatalf("Failed to build signature script: %s", err)
+	}
+	txIn := &wire.TxIn{
+		PreviousOutpoint: wire.Outpoint{TxID: *cbTx.TxID(), Index: 0},
+		SignatureScript:  signatureScript,
+		Sequence:         wire.MaxTxInSequenceNum,
+	}
+	txOut := &wire.TxOut{
+		ScriptPubKey: OpTrueScript,
+		Value:        uint64(1),
+	}
+	tx := wire.NewNativeMsgTx(wire.TxVersion, []*wire.TxIn{txIn}, []*wire.TxOut{txOut})
+	msgBlock, err := PrepareBlockForTest(dag, []*daghash.Hash{fundingBlock.BlockHash()}, []*wire.MsgTx{tx})
+	if err != nil {
+		t.Fatalf("PrepareBlockForTest: %v", err)
+	}
+	block := util.NewBlock(msgBlock)
+
+	tests := []struct {
+		name          string
+		prevOutput    *UTXOEntry
+		isValid       bool
+		expectedError ErrorCode
+	}{
+		{
+			name:       "valid previous output",
+			prevOutput: NewUTXOEntry(cbTx.TxOut[0], true, 1),
+			isValid:    true,
+		},
+		{
+			name: "invalid previous output",
+			prevOutput: NewUTXOEntry(&wire.TxOut{
+				ScriptPubKey: []byte{txscript.OpFalse},
+				Value:        cbTx.TxOut[0].Value,
+			}, true, 1),
+			expectedError: ErrScriptValidation,
+		},
+		{
+			name:    "missing previous output",
+			isValid: true,
+		},
+	}
+	for _, test := range tests {
+		err := dag.CheckBlockScripts(block, func(outpoint wire.Outpoint) (*UTXOEntry, bool) {
+			if outpoint != txIn.PreviousOutpoint || test.prevOutput == nil {
+				return nil, false
+			}
+			return test.prevOutput, true
+		})
+		if test.isValid {
+			if err != nil {
+				t.Errorf("TestCheckBlockScriptsWithPrevOutputs (%s): unexpected "+
+					"error: %s", test.name, err)
+			}
+			continue
+		}
+		var ruleErr RuleError
+		if !errors.As(err, &ruleErr) || ruleErr.ErrorCode != test.expectedError {
+			t.Errorf("TestCheckBlockScriptsWithPrevOutputs (%s): expected "+
+				"error code %s, but got %v", test.name, test.expectedError, err)
+		}
+	}
+}
@@ -0,0 +1,672 @@
+package blockdag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/kaspanet/go-secp256k1"
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/binaryserializer"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/util/subnetworkid"
+	"github.com/kaspanet/kaspad/wire"
+	"github.com/pkg/errors"
+)
+
+// utxoSnapshotVersion is the current version of the UTXO snapshot format.
+const utxoSnapshotVersion = 1
+
+const (
+	// snapshotBlockSection is the section of the entries that hold the
+	// bodies of the blocks that were not finalized yet.
+	snapshotBlockSection = 0xff
+
+	// snapshotEndSection marks the end of a UTXO snapshot.
+	snapshotEndSection = 0xfe
+)
+
+// UTXOSnapshotInfo describes a UTXO snapshot.
+type UTXOSnapshotInfo struct {
+	// FinalityPointHash is the hash of the last finality point at the
+	// time the snapshot was taken. The snapshot is verified against the
+	// UTXO commitment of this block when it's loaded.
+	FinalityPointHash *daghash.Hash
+
+	// FinalityPointBlueScore is the blue score of the finality point.
+	FinalityPointBlueScore uint64
+
+	// UTXOCount is the number of unspent outputs in the UTXO set of the
+	// virtual block in the snapshot.
+	UTXOCount uint64
+
+	// BlockCount is the number of block bodies in the snapshot.
+	BlockCount uint64
+}
+
+// WriteUTXOSnapshot writes a snapshot of the UTXO set of the virtual block,
+// along with the block index, reachability data, multisets, UTXO diffs and
+// the rest of the DAG state that's needed in order to keep processing blocks
+// on top of it, to w. Only the bodies of the genesis block and of the blocks
+// that were not finalized yet are included. The snapshot can be loaded into
+// an empty database with LoadUTXOSnapshot.
+//
+// The snapshot is read from a database transaction that's opened under the
+// DAG lock, so blocks keep being processed while it's written. Blocks are not
+// pruned until it's written, so that none of the block bodies in it are
+// deleted from the block store meanwhile.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) WriteUTXOSnapshot(w io.Writer) (*UTXOSnapshotInfo, error) {
+	dag.pruneLock.Lock()
+	defer dag.pruneLock.Unlock()
+
+	dbTx, info, nodes, err := dag.beginUTXOSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	err = writeUTXOSnapshotHeader(w, dag.dagParams.GenesisHash, info)
+	if err != nil {
+		return nil, err
+	}
+
+	err = dbaccess.ForEachSnapshotEntry(dbTx, func(entry *dbaccess.SnapshotEntry) error {
+		if entry.Section == dbaccess.SnapshotUTXOSection {
+			info.UTXOCount++
+		}
+		return writeUTXOSnapshotEntry(w, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, node := range nodes {
+		var blockBytes []byte
+		if node.isGenesis() {
+			// The data of the genesis block may have been deleted from
+			// the block store when blocks were pruned. See pruneBlocks.
+			blockBytes, err = util.NewBlock(dag.dagParams.GenesisBlock).Bytes()
+		} else {
+			blockBytes, err = dbaccess.FetchBlock(dbTx, node.hash)
+		}
+		if err != nil {
+			return nil, err
+		}
+		err = writeUTXOSnapshotEntry(w, &dbaccess.SnapshotEntry{
+			Section: snapshotBlockSection,
+			Suffix:  node.hash[:],
+			Value:   blockBytes,
+		})
+		if err != nil {
+			return nil, err
+		}
+		info.BlockCount++
+	}
+
+	_, err = w.Write([]byte{snapshotEndSection})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Wrote a UTXO snapshot at finality point %s with %d UTXOs "+
+		"and %d blocks", info.FinalityPointHash, info.UTXOCount, info.BlockCount)
+	return info, nil
+}
+
+// beginUTXOSnapshot opens the database transaction that a UTXO snapshot is
+// read from, and returns it along with the finality point of the snapshot
+// and the nodes whose block bodies are included in it. The transaction
+// provides a consistent view of the database as it was when it was opened,
+// so the DAG lock is only held until it's opened.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) beginUTXOSnapshot() (*dbaccess.TxContext, *UTXOSnapshotInfo, []*blockNode, error) {
+	dag.dagLock.RLock()
+	defer dag.dagLock.RUnlock()
+
+	dbTx, err := dbaccess.NewTx()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	info := &UTXOSnapshotInfo{
+		FinalityPointHash:      dag.lastFinalityPoint.hash,
+		FinalityPointBlueScore: dag.lastFinalityPoint.blueScore,
+	}
+	return dbTx, info, dag.nodesForUTXOSnapshot(), nil
+}
+
+// nodesForUTXOSnapshot returns the genesis block and all the blocks that
+// were not finalized yet, whose bodies may still be needed in order to
+// process new blocks.
+//
+// This function MUST be called with the DAG state lock held (for reads).
+func (dag *BlockDAG) nodesForUTXOSnapshot() []*blockNode {
+	dag.index.RLock()
+	defer dag.index.RUnlock()
+
+	var nodes []*blockNode
+	for _, node := range dag.index.index {
+		if node.isGenesis() || !node.isFinalized {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+func writeUTXOSnapshotHeader(w io.Writer, genesisHash *daghash.Hash, info *UTXOSnapshotInfo) error {
+	err := binaryserializer.PutUint32(w, byteOrder, utxoSnapshotVersion)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(genesisHash[:])
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(info.FinalityPointHash[:])
+	if err != nil {
+		return err
+	}
+	return binaryserializer.PutUint64(w, byteOrder, info.FinalityPointBlueScore)
+}
+
+func writeUTXOSnapshotEntry(w io.Writer, entry *dbaccess.SnapshotEntry) error {
+	_, err := w.Write([]byte{entry.Section})
+	if err != nil {
+		return err
+	}
+	err = wire.WriteVarBytes(w, 0, entry.Suffix)
+	if err != nil {
+		return err
+	}
+	return wire.WriteVarBytes(w, 0, entry.Value)
+}
+
+func readUTXOSnapshotHeader(r io.Reader, dagParams *dagconfig.Params) (*UTXOSnapshotInfo, error) {
+	version, err := binaryserializer.Uint32(r, byteOrder)
+	if err != nil {
+		return nil, err
+	}
+	if version != utxoSnapshotVersion {
+		return nil, errors.Errorf("unknown UTXO snapshot version %d", version)
+	}
+
+	var genesisHash daghash.Hash
+	_, err = io.ReadFull(r, genesisHash[:])
+	if err != nil {
+		return nil, err
+	}
+	if !genesisHash.IsEqual(dagParams.GenesisHash) {
+		return nil, errors.Errorf("the UTXO snapshot was taken on a "+
+			"network with genesis %s, which is not %s", genesisHash, dagParams.Name)
+	}
+
+	info := &UTXOSnapshotInfo{FinalityPointHash: &daghash.Hash{}}
+	_, err = io.ReadFull(r, info.FinalityPointHash[:])
+	if err != nil {
+		return nil, err
+	}
+	info.FinalityPointBlueScore, err = binaryserializer.Uint64(r, byteOrder)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// readUTXOSnapshotEntry reads the next entry of a UTXO snapshot from r. It
+// returns nil once the end of the snapshot is reached.
+func readUTXOSnapshotEntry(r io.Reader) (*dbaccess.SnapshotEntry, error) {
+	section, err := binaryserializer.Uint8(r)
+	if err != nil {
+		return nil, err
+	}
+	if section == snapshotEndSection {
+		return nil, nil
+	}
+	suffix, err := wire.ReadVarBytes(r, 0, wire.MaxMessagePayload, "suffix")
+	if err != nil {
+		return nil, err
+	}
+	value, err := wire.ReadVarBytes(r, 0, wire.MaxMessagePayload, "value")
+	if err != nil {
+		return nil, err
+	}
+	return &dbaccess.SnapshotEntry{
+		Section: section,
+		Suffix:  suffix,
+		Value:   value,
+	}, nil
+}
+
+// LoadUTXOSnapshot loads a UTXO snapshot that was written by
+// WriteUTXOSnapshot into the database, which must not contain any DAG state
+// yet. The snapshot is verified against the UTXO commitment of its finality
+// point once the DAG is created with New, which fails if the verification
+// fails. The blocks below the finality point are treated as pruned.
+func LoadUTXOSnapshot(r io.Reader, dagParams *dagconfig.Params) (*UTXOSnapshotInfo, error) {
+	_, err := dbaccess.FetchDAGState(dbaccess.NoTx())
+	if err == nil {
+		return nil, errors.New("a UTXO snapshot may only be loaded " +
+			"into an empty database")
+	}
+	if !dbaccess.IsNotFoundError(err) {
+		return nil, err
+	}
+
+	info, err := readUTXOSnapshotHeader(r, dagParams)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading UTXO snapshot header")
+	}
+
+	dbTx, err := dbaccess.NewTx()
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.RollbackUnlessClosed()
+
+	hasDAGState := false
+	for {
+		entry, err := readUTXOSnapshotEntry(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading UTXO snapshot entry")
+		}
+		if entry == nil {
+			break
+		}
+
+		err = validateUTXOSnapshotEntry(entry, info)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid UTXO snapshot entry "+
+				"in section %d", entry.Section)
+		}
+
+		switch entry.Section {
+		case snapshotBlockSection:
+			err = storeUTXOSnapshotBlock(dbTx, entry)
+			info.BlockCount++
+		case dbaccess.SnapshotUTXOSection:
+			err = dbaccess.StoreSnapshotEntry(dbTx, entry)
+			info.UTXOCount++
+		case dbaccess.SnapshotDAGStateSection:
+			err = dbaccess.StoreSnapshotEntry(dbTx, entry)
+			hasDAGState = true
+		default:
+			err = dbaccess.StoreSnapshotEntry(dbTx, entry)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !hasDAGState {
+		return nil, errors.New("the UTXO snapshot does not contain a DAG state")
+	}
+
+	err = dbaccess.StorePrunedBlueScore(dbTx, info.FinalityPointBlueScore)
+	if err != nil {
+		return nil, err
+	}
+	err = dbaccess.StoreUnverifiedSnapshot(dbTx, info.FinalityPointHash[:])
+	if err != nil {
+		return nil, err
+	}
+	err = dbTx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Loaded a UTXO snapshot at finality point %s with %d UTXOs "+
+		"and %d blocks", info.FinalityPointHash, info.UTXOCount, info.BlockCount)
+	return info, nil
+}
+
+// storeUTXOSnapshotBlock stores the block body in the given UTXO snapshot
+// entry, which must already be validated.
+func storeUTXOSnapshotBlock(dbTx *dbaccess.TxContext, entry *dbaccess.SnapshotEntry) error {
+	hash, err := daghash.NewHash(entry.Suffix)
+	if err != nil {
+		return err
+	}
+	return dbaccess.StoreBlock(dbTx, hash, entry.Value)
+}
+
+// validateUTXOSnapshotEntry makes sure that the given UTXO snapshot entry
+// belongs to a known section, and that its key suffix and value are well
+// formed for that section, before it's stored in the database. Whether the
+// entries are consistent with each other is verified by verifyUTXOSnapshot
+// once the DAG is loaded.
+func validateUTXOSnapshotEntry(entry *dbaccess.SnapshotEntry, info *UTXOSnapshotInfo) error {
+	r := bytes.NewReader(entry.Value)
+	var err error
+	switch entry.Section {
+	case snapshotBlockSection:
+		return validateUTXOSnapshotBlock(entry)
+	case dbaccess.SnapshotUTXOSection:
+		if len(entry.Suffix) != outpointSerializeSize {
+			return errors.Errorf("unexpected outpoint length %d", len(entry.Suffix))
+		}
+		_, err = deserializeUTXOEntry(r)
+	case dbaccess.SnapshotBlockIndexSection:
+		err = validateUTXOSnapshotBlockIndexEntry(entry.Suffix, r)
+	case dbaccess.SnapshotReachabilityDataSection:
+		err = validateUTXOSnapshotHashSuffix(entry.Suffix)
+		if err == nil {
+			err = validateSerializedReachabilityData(r)
+		}
+	case dbaccess.SnapshotMultisetSection:
+		err = validateUTXOSnapshotHashSuffix(entry.Suffix)
+		if err == nil {
+			_, err = deserializeMultiset(r)
+		}
+	case dbaccess.SnapshotUTXODiffSection:
+		err = validateUTXOSnapshotHashSuffix(entry.Suffix)
+		if err == nil {
+			err = validateSerializedBlockUTXODiffData(r)
+		}
+	case dbaccess.SnapshotSubnetworkSection:
+		if len(entry.Suffix) != subnetworkid.IDLength {
+			return errors.Errorf("unexpected subnetwork ID length %d", len(entry.Suffix))
+		}
+		// The gas limit of the subnetwork. See serializeSubnetwork.
+		_, err = binaryserializer.Uint64(r, byteOrder)
+	case dbaccess.SnapshotFeeDataSection:
+		err = validateUTXOSnapshotHashSuffix(entry.Suffix)
+		if err != nil {
+			return err
+		}
+		if len(entry.Value)%8 != 0 {
+			return errors.Errorf("unexpected fee data length %d", len(entry.Value))
+		}
+		return nil
+	case dbaccess.SnapshotDAGStateSection:
+		return validateUTXOSnapshotDAGState(entry.Value, info)
+	case dbaccess.SnapshotReachabilityReindexSection:
+		_, err = daghash.NewHash(entry.Value)
+		return err
+	default:
+		return errors.New("unknown section")
+	}
+	if err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return errors.Errorf("%d unexpected trailing bytes", r.Len())
+	}
+	return nil
+}
+
+// validateUTXOSnapshotBlock makes sure that the block body in the given
+// UTXO snapshot entry matches the hash it's stored under.
+func validateUTXOSnapshotBlock(entry *dbaccess.SnapshotEntry) error {
+	hash, err := daghash.NewHash(entry.Suffix)
+	if err != nil {
+		return err
+	}
+	block, err := util.NewBlockFromBytes(entry.Value)
+	if err != nil {
+		return err
+	}
+	if !block.Hash().IsEqual(hash) {
+		return errors.Errorf("the block stored under %s has the hash %s",
+			hash, block.Hash())
+	}
+	return nil
+}
+
+func validateUTXOSnapshotHashSuffix(suffix []byte) error {
+	if len(suffix) != daghash.HashSize {
+		return errors.Errorf("unexpected hash length %d", len(suffix))
+	}
+	return nil
+}
+
+// validateUTXOSnapshotBlockIndexEntry makes sure that the given block index
+// entry is well formed and matches the hash and blue score in its key. See
+// serializeBlockNode for the format.
+func validateUTXOSnapshotBlockIndexEntry(suffix []byte, r *bytes.Reader) error {
+	if len(suffix) != daghash.HashSize+8 {
+		return errors.Errorf("unexpected block index key length %d", len(suffix))
+	}
+	hash, err := blockHashFromBlockIndexKey(suffix)
+	if err != nil {
+		return err
+	}
+	var header wire.BlockHeader
+	err = header.Deserialize(r)
+	if err != nil {
+		return err
+	}
+	if !header.BlockHash().IsEqual(hash) {
+		return errors.Errorf("the block header stored under %s has the "+
+			"hash %s", hash, header.BlockHash())
+	}
+
+	_, err = binaryserializer.Uint8(r)
+	if err != nil {
+		return err
+	}
+	err = skipSerializedHashes(r, 1)
+	if err != nil {
+		return err
+	}
+	blueScore, err := binaryserializer.Uint64(r, byteOrder)
+	if err != nil {
+		return err
+	}
+	if blueScore != binary.BigEndian.Uint64(suffix[:8]) {
+		return errors.Errorf("block %s is stored under the wrong blue score", hash)
+	}
+	bluesCount, err := wire.ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+	err = skipSerializedHashes(r, bluesCount)
+	if err != nil {
+		return err
+	}
+	bluesAnticoneSizesLen, err := wire.ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < bluesAnticoneSizesLen; i++ {
+		err = skipSerializedHashes(r, 1)
+		if err != nil {
+			return err
+		}
+		_, err = binaryserializer.Uint8(r)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSerializedReachabilityData makes sure that the given reachability
+// data is well formed. See serializeReachabilityData for the format.
+func validateSerializedReachabilityData(r *bytes.Reader) error {
+	start, err := binaryserializer.Uint64(r, byteOrder)
+	if err != nil {
+		return err
+	}
+	end, err := binaryserializer.Uint64(r, byteOrder)
+	if err != nil {
+		return err
+	}
+	// Empty intervals are represented by an end that's one less than
+	// their start
+	if start > end && start-end != 1 {
+		return errors.Errorf("invalid reachability interval [%d, %d]", start, end)
+	}
+	// The parent, the children and the future covering set
+	err = skipSerializedHashes(r, 1)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < 2; i++ {
+		count, err := wire.ReadVarInt(r)
+		if err != nil {
+			return err
+		}
+		err = skipSerializedHashes(r, count)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSerializedBlockUTXODiffData makes sure that the given UTXO diff
+// data is well formed. See serializeBlockUTXODiffData for the format.
+func validateSerializedBlockUTXODiffData(r *bytes.Reader) error {
+	var hasDiffChild bool
+	err := wire.ReadElement(r, &hasDiffChild)
+	if err != nil {
+		return err
+	}
+	if hasDiffChild {
+		err = skipSerializedHashes(r, 1)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = deserializeUTXODiff(r)
+	return err
+}
+
+// validateUTXOSnapshotDAGState makes sure that the given DAG state has tips,
+// and that its last finality point is the one in the snapshot header.
+func validateUTXOSnapshotDAGState(serializedDAGState []byte, info *UTXOSnapshotInfo) error {
+	state, err := deserializeDAGState(serializedDAGState)
+	if err != nil {
+		return err
+	}
+	if state == nil || len(state.TipHashes) == 0 || state.LastFinalityPoint == nil {
+		return errors.New("the DAG state is incomplete")
+	}
+	if !state.LastFinalityPoint.IsEqual(info.FinalityPointHash) {
+		return errors.Errorf("the last finality point of the DAG state is %s, "+
+			"but the snapshot was taken at %s", state.LastFinalityPoint,
+			info.FinalityPointHash)
+	}
+	return nil
+}
+
+// skipSerializedHashes reads count serialized hashes from r and discards
+// them. The hashes are read one at a time, so that a corrupted count can't
+// cause a large allocation.
+func skipSerializedHashes(r *bytes.Reader, count uint64) error {
+	var hash daghash.Hash
+	for i := uint64(0); i < count; i++ {
+		_, err := io.ReadFull(r, hash[:])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyUTXOSnapshot verifies the DAG state that was loaded from a UTXO
+// snapshot, if it was not verified yet. The past UTXO set of the finality
+// point of the snapshot is restored from the UTXO set of the virtual block
+// and the UTXO diffs, and its multiset must match both the multiset that's
+// stored for the finality point and the UTXO commitment in its header.
+//
+// This function MUST be called before any new block is processed.
+func (dag *BlockDAG) verifyUTXOSnapshot() error {
+	serializedHash, err := dbaccess.FetchUnverifiedSnapshot(dbaccess.NoTx())
+	if dbaccess.IsNotFoundError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	hash, err := daghash.NewHash(serializedHash)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Verifying the UTXO snapshot at finality point %s...", hash)
+	node, ok := dag.index.LookupNode(hash)
+	if !ok {
+		return errors.Errorf("UTXO snapshot finality point %s "+
+			"does not exist in the DAG", hash)
+	}
+	if !node.Header().BlockHash().IsEqual(hash) {
+		return errors.Errorf("UTXO snapshot finality point %s has a "+
+			"header with a different hash", hash)
+	}
+
+	err = dag.checkPastUTXOMultiset(node)
+	if err != nil {
+		return errors.Wrap(err, "UTXO snapshot verification failed")
+	}
+
+	err = dbaccess.DeleteUnverifiedSnapshot(dbaccess.NoTx())
+	if err != nil {
+		return err
+	}
+	log.Infof("Verified the UTXO snapshot at finality point %s", hash)
+	return nil
+}
+
+// checkPastUTXOMultiset makes sure that the multiset hash of the past UTXO
+// set of the given node, which is restored from the UTXO set of the virtual
+// block and the UTXO diffs, matches both the multiset that's stored for the
+// node and its UTXO commitment.
+//
+// This function MUST be called with the DAG state lock held (for reads).
+func (dag *BlockDAG) checkPastUTXOMultiset(node *blockNode) error {
+	pastUTXO, err := dag.restorePastUTXO(node)
+	if err != nil {
+		return err
+	}
+	multisetHash, err := calcDiffUTXOSetMultisetHash(pastUTXO.(*DiffUTXOSet))
+	if err != nil {
+		return err
+	}
+	storedMultiset, err := dag.multisetStore.multisetByBlockNode(node)
+	if err != nil {
+		return err
+	}
+	storedMultisetHash := daghash.Hash(*storedMultiset.Finalize())
+	if !multisetHash.IsEqual(node.utxoCommitment) || !storedMultisetHash.IsEqual(node.utxoCommitment) {
+		return errors.Errorf("the UTXO commitment of block %s is %s, but "+
+			"the multiset hash of its past UTXO set is %s and its stored "+
+			"multiset hash is %s", node.hash, node.utxoCommitment,
+			multisetHash, storedMultisetHash)
+	}
+	return nil
+}
+
+// calcDiffUTXOSetMultisetHash returns the finalized multiset hash of all
+// the entries in the given UTXO set.
+func calcDiffUTXOSetMultisetHash(utxoSet *DiffUTXOSet) (*daghash.Hash, error) {
+	multiset := secp256k1.NewMultiset()
+	w := &bytes.Buffer{}
+	for outpoint, entry := range utxoSet.base.utxoCollection {
+		w.Reset()
+		err := serializeUTXO(w, entry, &outpoint)
+		if err != nil {
+			return nil, err
+		}
+		multiset.Add(w.Bytes())
+	}
+	for outpoint, entry := range utxoSet.UTXODiff.toRemove {
+		var err error
+		multiset, err = removeUTXOFromMultiset(multiset, entry, &outpoint)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for outpoint, entry := range utxoSet.UTXODiff.toAdd {
+		var err error
+		multiset, err = addUTXOToMultiset(multiset, entry, &outpoint)
+		if err != nil {
+			return nil, err
+		}
+	}
+	multisetHash := daghash.Hash(*multiset.Finalize())
+	return &multisetHash, nil
+}
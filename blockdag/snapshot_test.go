@@ -0,0 +1,268 @@
+package blockdag
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// loadUTXOSnapshotForTest loads the given UTXO snapshot into a new database
+// and runs the given function before the DAG is created from it. It returns
+// the DAG, or the error that New returned.
+func loadUTXOSnapshotForTest(t *testing.T, testName string, params *dagconfig.Params,
+	snapshot []byte, beforeNew func()) (dag *BlockDAG, teardownFunc func(), err error) {
+
+	dbPath, err := ioutil.TempDir("", testName)
+	if err != nil {
+		t.Fatalf("%s: TempDir unexpectedly failed: %s", testName, err)
+	}
+	err = dbaccess.Open(dbPath)
+	if err != nil {
+		t.Fatalf("%s: Open unexpectedly failed: %s", testName, err)
+	}
+	closeDB := func() {
+		dbaccess.Close()
+		os.RemoveAll(dbPath)
+	}
+
+	_, err = LoadUTXOSnapshot(bytes.NewReader(snapshot), params)
+	if err != nil {
+		closeDB()
+		t.Fatalf("%s: LoadUTXOSnapshot unexpectedly failed: %s", testName, err)
+	}
+	beforeNew()
+
+	dag, teardownDAG, err := DAGSetup(testName, false, Config{DAGParams: params})
+	if err != nil {
+		closeDB()
+		return nil, nil, err
+	}
+	return dag, func() {
+		teardownDAG()
+		closeDB()
+	}, nil
+}
+
+func TestUTXOSnapshot(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.K = 1
+	params.FinalityInterval = 100
+	dag, teardownFunc, err := DAGSetup("TestUTXOSnapshot", true, Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup DAG instance: %v", err)
+	}
+
+	tipHash := params.GenesisHash
+	for i := uint64(0); i < 3*params.FinalityInterval; i++ {
+		block := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{tipHash}, nil)
+		tipHash = block.BlockHash()
+	}
+
+	buffer := &bytes.Buffer{}
+	info, err := dag.WriteUTXOSnapshot(buffer)
+	if err != nil {
+		t.Fatalf("TestUTXOSnapshot: WriteUTXOSnapshot unexpectedly "+
+			"failed: %s", err)
+	}
+	if !info.FinalityPointHash.IsEqual(dag.LastFinalityPointHash()) {
+		t.Fatalf("TestUTXOSnapshot: unexpected finality point. "+
+			"Want: %s, got: %s", dag.LastFinalityPointHash(), info.FinalityPointHash)
+	}
+	expectedStats, err := dag.UTXOSetStats()
+	if err != nil {
+		t.Fatalf("TestUTXOSnapshot: UTXOSetStats unexpectedly "+
+			"failed: %s", err)
+	}
+	if info.UTXOCount != expectedStats.UTXOCount {
+		t.Fatalf("TestUTXOSnapshot: unexpected UTXO count. "+
+			"Want: %d, got: %d", expectedStats.UTXOCount, info.UTXOCount)
+	}
+	teardownFunc()
+	snapshot := buffer.Bytes()
+
+	// Load the snapshot into a new database and make sure that the
+	// new DAG continues from where the old one stopped
+	loadedDAG, teardownFunc, err := loadUTXOSnapshotForTest(t, "TestUTXOSnapshot",
+		&params, snapshot, func() {})
+	if err != nil {
+		t.Fatalf("TestUTXOSnapshot: failed to create a DAG from the "+
+			"snapshot: %s", err)
+	}
+	defer teardownFunc()
+
+	if !loadedDAG.SelectedTipHash().IsEqual(tipHash) {
+		t.Fatalf("TestUTXOSnapshot: unexpected selected tip. "+
+			"Want: %s, got: %s", tipHash, loadedDAG.SelectedTipHash())
+	}
+	stats, err := loadedDAG.UTXOSetStats()
+	if err != nil {
+		t.Fatalf("TestUTXOSnapshot: UTXOSetStats unexpectedly "+
+			"failed: %s", err)
+	}
+	if !stats.MultisetHash.IsEqual(expectedStats.MultisetHash) {
+		t.Fatalf("TestUTXOSnapshot: unexpected UTXO set multiset hash. "+
+			"Want: %s, got: %s", expectedStats.MultisetHash, stats.MultisetHash)
+	}
+	if !loadedDAG.HasPrunedBlocks() {
+		t.Fatalf("TestUTXOSnapshot: the blocks below the finality " +
+			"point are unexpectedly not treated as pruned")
+	}
+	_, err = dbaccess.FetchUnverifiedSnapshot(dbaccess.NoTx())
+	if !dbaccess.IsNotFoundError(err) {
+		t.Fatalf("TestUTXOSnapshot: the snapshot was unexpectedly "+
+			"not marked as verified: %v", err)
+	}
+
+	PrepareAndProcessBlockForTest(t, loadedDAG, []*daghash.Hash{tipHash}, nil)
+}
+
+func TestUTXOSnapshotVerificationFailure(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.K = 1
+	params.FinalityInterval = 100
+	dag, teardownFunc, err := DAGSetup("TestUTXOSnapshotVerificationFailure", true, Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup DAG instance: %v", err)
+	}
+
+	tipHash := params.GenesisHash
+	for i := uint64(0); i < 3*params.FinalityInterval; i++ {
+		block := PrepareAndProcessBlockForTest(t, dag, []*daghash.Hash{tipHash}, nil)
+		tipHash = block.BlockHash()
+	}
+	buffer := &bytes.Buffer{}
+	_, err = dag.WriteUTXOSnapshot(buffer)
+	if err != nil {
+		t.Fatalf("TestUTXOSnapshotVerificationFailure: WriteUTXOSnapshot "+
+			"unexpectedly failed: %s", err)
+	}
+	teardownFunc()
+
+	// Remove a single UTXO from the loaded UTXO set, which should make
+	// the verification fail
+	removeUTXO := func() {
+		cursor, err := dbaccess.UTXOSetCursor(dbaccess.NoTx())
+		if err != nil {
+			t.Fatalf("TestUTXOSnapshotVerificationFailure: UTXOSetCursor "+
+				"unexpectedly failed: %s", err)
+		}
+		defer cursor.Close()
+		if !cursor.First() {
+			t.Fatalf("TestUTXOSnapshotVerificationFailure: the loaded " +
+				"UTXO set is unexpectedly empty")
+		}
+		key, err := cursor.Key()
+		if err != nil {
+			t.Fatalf("TestUTXOSnapshotVerificationFailure: Key "+
+				"unexpectedly failed: %s", err)
+		}
+		err = dbaccess.RemoveFromUTXOSet(dbaccess.NoTx(), key.Suffix())
+		if err != nil {
+			t.Fatalf("TestUTXOSnapshotVerificationFailure: RemoveFromUTXOSet "+
+				"unexpectedly failed: %s", err)
+		}
+	}
+	_, _, err = loadUTXOSnapshotForTest(t, "TestUTXOSnapshotVerificationFailure",
+		&params, buffer.Bytes(), removeUTXO)
+	if err == nil {
+		t.Fatalf("TestUTXOSnapshotVerificationFailure: a DAG was " +
+			"unexpectedly created from a corrupted snapshot")
+	}
+}
+
+func TestLoadUTXOSnapshotInvalidEntries(t *testing.T) {
+	params := dagconfig.SimnetParams
+	info := &UTXOSnapshotInfo{
+		FinalityPointHash:      params.GenesisHash,
+		FinalityPointBlueScore: 0,
+	}
+	genesisBytes, err := util.NewBlock(params.GenesisBlock).Bytes()
+	if err != nil {
+		t.Fatalf("Bytes unexpectedly failed: %s", err)
+	}
+
+	tests := []struct {
+		name  string
+		entry *dbaccess.SnapshotEntry
+	}{
+		{
+			name:  "unknown section",
+			entry: &dbaccess.SnapshotEntry{Section: 0xfd},
+		},
+		{
+			name: "truncated multiset",
+			entry: &dbaccess.SnapshotEntry{
+				Section: dbaccess.SnapshotMultisetSection,
+				Suffix:  params.GenesisHash[:],
+				Value:   []byte{1, 2, 3},
+			},
+		},
+		{
+			name: "malformed UTXO key",
+			entry: &dbaccess.SnapshotEntry{
+				Section: dbaccess.SnapshotUTXOSection,
+				Suffix:  []byte{1},
+			},
+		},
+		{
+			name: "trailing bytes",
+			entry: &dbaccess.SnapshotEntry{
+				Section: dbaccess.SnapshotSubnetworkSection,
+				Suffix:  make([]byte, 20),
+				Value:   make([]byte, 9),
+			},
+		},
+		{
+			name: "block under the wrong hash",
+			entry: &dbaccess.SnapshotEntry{
+				Section: snapshotBlockSection,
+				Suffix:  make([]byte, daghash.HashSize),
+				Value:   genesisBytes,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		dbPath, err := ioutil.TempDir("", "TestLoadUTXOSnapshotInvalidEntries")
+		if err != nil {
+			t.Fatalf("TempDir unexpectedly failed: %s", err)
+		}
+		err = dbaccess.Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open unexpectedly failed: %s", err)
+		}
+
+		buffer := &bytes.Buffer{}
+		err = writeUTXOSnapshotHeader(buffer, params.GenesisHash, info)
+		if err != nil {
+			t.Fatalf("%s: writeUTXOSnapshotHeader unexpectedly failed: %s", test.name, err)
+		}
+		err = writeUTXOSnapshotEntry(buffer, test.entry)
+		if err != nil {
+			t.Fatalf("%s: writeUTXOSnapshotEntry unexpectedly failed: %s", test.name, err)
+		}
+		buffer.WriteByte(snapshotEndSection)
+
+		_, err = LoadUTXOSnapshot(buffer, &params)
+		if err == nil {
+			t.Errorf("%s: LoadUTXOSnapshot unexpectedly succeeded", test.name)
+		}
+		_, err = dbaccess.FetchDAGState(dbaccess.NoTx())
+		if !dbaccess.IsNotFoundError(err) {
+			t.Errorf("%s: the invalid snapshot was unexpectedly stored: %v", test.name, err)
+		}
+
+		dbaccess.Close()
+		os.RemoveAll(dbPath)
+	}
+}
@@ -154,6 +154,15 @@ func SetVirtualForTest(dag *BlockDAG, virtual VirtualForTest) VirtualForTest {
 	return VirtualForTest(oldVirtual)
 }
 
+// PruneBlocksForTest finalizes and prunes the blocks below the DAG's last
+// finality point. This normally happens in a separate goroutine whenever the
+// finality point is updated, so this function is used for test purposes only,
+// to make sure that it's done.
+func PruneBlocksForTest(dag *BlockDAG) error {
+	dag.finalizeNodesBelowFinalityPoint(true)
+	return dag.pruneBlocks(dag.lastFinalityPoint)
+}
+
 // GetVirtualFromParentsForTest generates a virtual block with the given parents.
 func GetVirtualFromParentsForTest(dag *BlockDAG, parentHashes []*daghash.Hash) (VirtualForTest, error) {
 	parents := newBlockSet()
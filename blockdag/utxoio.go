@@ -161,6 +161,15 @@ func serializeUTXO(w io.Writer, entry *UTXOEntry, outpoint *wire.Outpoint) error
 	return nil
 }
 
+// utxoSerializeSize returns the number of bytes it would take to serialize
+// the given UTXO entry along with its outpoint. See serializeUTXO.
+func utxoSerializeSize(entry *UTXOEntry) uint64 {
+	// outpoint + blue score + packed flags + amount + script pub key
+	scriptPubKeyLen := uint64(len(entry.ScriptPubKey()))
+	return uint64(outpointSerializeSize) + 8 + 1 + 8 +
+		uint64(wire.VarIntSerializeSize(scriptPubKeyLen)) + scriptPubKeyLen
+}
+
 // p2pkhUTXOEntrySerializeSize is the serialized size for a P2PKH UTXO entry.
 // 8 bytes (header code) + 8 bytes (amount) + varint for script pub key length of 25 (for P2PKH) + 25 bytes for P2PKH script.
 var p2pkhUTXOEntrySerializeSize = 8 + 8 + wire.VarIntSerializeSize(25) + 25
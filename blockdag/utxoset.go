@@ -407,6 +407,12 @@ type UTXOSet interface {
 // FullUTXOSet represents a full list of transaction outputs and their values
 type FullUTXOSet struct {
 	utxoCollection
+
+	// totalAmount and serializedSize are kept up to date as entries are
+	// added and removed, so that statistics about the set never require
+	// iterating over it.
+	totalAmount    uint64
+	serializedSize uint64
 }
 
 // NewFullUTXOSet creates a new utxoSet with full list of transaction outputs and their values
@@ -420,15 +426,38 @@ func NewFullUTXOSet() *FullUTXOSet {
 func newFullUTXOSetFromUTXOCollection(collection utxoCollection) (*FullUTXOSet, error) {
 	var err error
 	multiset := secp256k1.NewMultiset()
+	fus := &FullUTXOSet{
+		utxoCollection: collection,
+	}
 	for outpoint, utxoEntry := range collection {
 		multiset, err = addUTXOToMultiset(multiset, utxoEntry, &outpoint)
 		if err != nil {
 			return nil, err
 		}
+		fus.totalAmount += utxoEntry.Amount()
+		fus.serializedSize += utxoSerializeSize(utxoEntry)
 	}
-	return &FullUTXOSet{
-		utxoCollection: collection,
-	}, nil
+	return fus, nil
+}
+
+// add adds a new UTXO entry to this set, replacing the
+// existing entry of the outpoint, if any
+func (fus *FullUTXOSet) add(outpoint wire.Outpoint, entry *UTXOEntry) {
+	fus.remove(outpoint)
+	fus.utxoCollection.add(outpoint, entry)
+	fus.totalAmount += entry.Amount()
+	fus.serializedSize += utxoSerializeSize(entry)
+}
+
+// remove removes a UTXO entry from this set if it exists
+func (fus *FullUTXOSet) remove(outpoint wire.Outpoint) {
+	entry, ok := fus.utxoCollection[outpoint]
+	if !ok {
+		return
+	}
+	fus.utxoCollection.remove(outpoint)
+	fus.totalAmount -= entry.Amount()
+	fus.serializedSize -= utxoSerializeSize(entry)
 }
 
 // diffFrom returns the difference between this utxoSet and another
@@ -488,7 +517,11 @@ func (fus *FullUTXOSet) containsInputs(tx *wire.MsgTx) bool {
 
 // clone returns a clone of this utxoSet
 func (fus *FullUTXOSet) clone() UTXOSet {
-	return &FullUTXOSet{utxoCollection: fus.utxoCollection.clone()}
+	return &FullUTXOSet{
+		utxoCollection: fus.utxoCollection.clone(),
+		totalAmount:    fus.totalAmount,
+		serializedSize: fus.serializedSize,
+	}
 }
 
 // Get returns the UTXOEntry associated with the given Outpoint, and a boolean indicating if such entry was found
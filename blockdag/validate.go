@@ -400,11 +400,10 @@ func CalcTxMass(tx *util.Tx, previousScriptPubKeys [][]byte) uint64 {
 //
 // The flags do not modify the behavior of this function directly, however they
 // are needed to pass along to checkProofOfWork.
-func (dag *BlockDAG) checkBlockHeaderSanity(block *util.Block, flags BehaviorFlags) (delay time.Duration, err error) {
+func (dag *BlockDAG) checkBlockHeaderSanity(header *wire.BlockHeader, flags BehaviorFlags) (delay time.Duration, err error) {
 	// Ensure the proof of work bits in the block header is in min/max range
 	// and the block hash is less than the target value described by the
 	// bits.
-	header := &block.MsgBlock().Header
 	err = dag.checkProofOfWork(header, flags)
 	if err != nil {
 		return 0, err
@@ -466,7 +465,7 @@ func checkBlockParentsOrder(header *wire.BlockHeader) error {
 // The flags do not modify the behavior of this function directly, however they
 // are needed to pass along to checkBlockHeaderSanity.
 func (dag *BlockDAG) checkBlockSanity(block *util.Block, flags BehaviorFlags) (time.Duration, error) {
-	delay, err := dag.checkBlockHeaderSanity(block, flags)
+	delay, err := dag.checkBlockHeaderSanity(&block.MsgBlock().Header, flags)
 	if err != nil {
 		return 0, err
 	}
@@ -517,6 +516,18 @@ func (dag *BlockDAG) checkBlockSanity(block *util.Block, flags BehaviorFlags) (t
 	return delay, nil
 }
 
+// CheckBlockSanity performs the context free checks of checkBlockSanity on
+// a block, which don't require its parents to be in the DAG. This allows
+// callers that already have many blocks at hand, such as block importers,
+// to check them in parallel before they're processed in order. It returns
+// the duration by which the block's timestamp is too far in the future,
+// just like checkBlockSanity.
+//
+// This function is safe for concurrent access.
+func (dag *BlockDAG) CheckBlockSanity(block *util.Block, flags BehaviorFlags) (time.Duration, error) {
+	return dag.checkBlockSanity(block, flags)
+}
+
 func (dag *BlockDAG) checkBlockContainsAtLeastOneTransaction(block *util.Block) error {
 	transactions := block.Transactions()
 	numTx := len(transactions)
@@ -695,6 +706,12 @@ func (dag *BlockDAG) validateDifficulty(header *wire.BlockHeader, bluestParent *
 
 // validateParents validates that no parent is an ancestor of another parent, and no parent is finalized
 func (dag *BlockDAG) validateParents(blockHeader *wire.BlockHeader, parents blockSet) error {
+	return validateParentsWithPastChecker(blockHeader, parents, dag)
+}
+
+// validateParentsWithPastChecker validates the parents like validateParents,
+// using the given pastChecker to check whether they are ancestors of each other
+func validateParentsWithPastChecker(blockHeader *wire.BlockHeader, parents blockSet, pastChecker pastChecker) error {
 	for parentA := range parents {
 		// isFinalized might be false-negative because node finality status is
 		// updated in a separate goroutine. This is why later the block is
@@ -709,7 +726,7 @@ func (dag *BlockDAG) validateParents(blockHeader *wire.BlockHeader, parents bloc
 				continue
 			}
 
-			isAncestorOf, err := dag.isInPast(parentA, parentB)
+			isAncestorOf, err := pastChecker.isInPast(parentA, parentB)
 			if err != nil {
 				return err
 			}
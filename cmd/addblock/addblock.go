@@ -5,17 +5,28 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
+	"time"
 
+	"github.com/kaspanet/kaspad/database/ffldb"
+	"github.com/kaspanet/kaspad/dbaccess"
 	"github.com/kaspanet/kaspad/limits"
 	"github.com/kaspanet/kaspad/logs"
+	"github.com/kaspanet/kaspad/signal"
 	"github.com/kaspanet/kaspad/util/panics"
+	"github.com/pkg/errors"
 )
 
 const (
-	// blockDBNamePrefix is the prefix for the kaspad block database.
-	blockDBNamePrefix = "blocks"
+	// blockDbNamePrefix is the prefix for the kaspad block database.
+	blockDbNamePrefix = "db"
 )
 
 var (
@@ -24,6 +35,109 @@ var (
 	spawn func(func())
 )
 
+// blockDbPath returns the path to the block database of the given type,
+// which is named just like kaspad names it.
+func blockDbPath(dbType string) string {
+	dbName := blockDbNamePrefix
+	if dbType != ffldb.DbType {
+		dbName = dbName + "_" + dbType
+	}
+	return filepath.Join(cfg.DataDir, dbName)
+}
+
+// gzipMagic is the header of gzip compressed data. None of the network
+// magics starts with it, so it can't be confused with an uncompressed
+// block file.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// blockFile is a block file that's opened for reading.
+type blockFile struct {
+	io.Reader
+	file *os.File
+
+	// bufferedReader reads from file. If the file is compressed, it's
+	// read through Reader, which decompresses it.
+	bufferedReader *bufio.Reader
+	isCompressed   bool
+
+	// offset is the offset in the uncompressed file of the next Read.
+	offset int64
+}
+
+// Read reads from the uncompressed file.
+func (bf *blockFile) Read(p []byte) (int, error) {
+	n, err := bf.Reader.Read(p)
+	bf.offset += int64(n)
+	return n, err
+}
+
+// Seek sets the offset in the uncompressed file of the next Read. This is
+// part of the io.Seeker interface implementation. A compressed file can't
+// be seeked in, so it's decompressed and discarded up to the given offset
+// instead, which is only possible if the offset is ahead of the current
+// one.
+func (bf *blockFile) Seek(offset int64, whence int) (int64, error) {
+	if !bf.isCompressed {
+		newOffset, err := bf.file.Seek(offset, whence)
+		if err != nil {
+			return 0, err
+		}
+		bf.bufferedReader.Reset(bf.file)
+		bf.offset = newOffset
+		return newOffset, nil
+	}
+
+	var newOffset int64
+	switch whence {
+	case io.SeekStart:
+		newOffset = offset
+	case io.SeekCurrent:
+		newOffset = bf.offset + offset
+	default:
+		return 0, errors.Errorf("compressed block files can't be seeked "+
+			"with whence %d", whence)
+	}
+	if newOffset < bf.offset {
+		return 0, errors.Errorf("compressed block files can't be seeked "+
+			"backwards from offset %d to offset %d", bf.offset, newOffset)
+	}
+	_, err := io.CopyN(ioutil.Discard, bf, newOffset-bf.offset)
+	if err != nil {
+		return 0, err
+	}
+	return bf.offset, nil
+}
+
+// Close closes the underlying file.
+func (bf *blockFile) Close() error {
+	return bf.file.Close()
+}
+
+// openBlockFile opens the given block file for reading. Files that were
+// compressed with gzip, such as the ones that are written by exportblocks
+// with --compress, are decompressed while they're read.
+func openBlockFile(path string) (*blockFile, error) {
+	fi, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(fi)
+	magic, err := r.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		fi.Close()
+		return nil, err
+	}
+	if !bytes.Equal(magic, gzipMagic) {
+		return &blockFile{Reader: r, file: fi, bufferedReader: r}, nil
+	}
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		fi.Close()
+		return nil, err
+	}
+	return &blockFile{Reader: gzipReader, file: fi, bufferedReader: r, isCompressed: true}, nil
+}
+
 // realMain is the real main function for the utility. It is necessary to work
 // around the fact that deferred functions do not run when os.Exit() is called.
 func realMain() error {
@@ -40,7 +154,16 @@ func realMain() error {
 	log = backendLogger.Logger("MAIN")
 	spawn = panics.GoroutineWrapperFunc(log)
 
-	fi, err := os.Open(cfg.InFile)
+	// Open the database. Note that kaspad must not be running, since it
+	// holds the database open.
+	err = dbaccess.OpenWithDriver(cfg.DbType, blockDbPath(cfg.DbType))
+	if err != nil {
+		log.Errorf("Failed to open the database: %s", err)
+		return err
+	}
+	defer dbaccess.Close()
+
+	fi, err := openBlockFile(cfg.InFile)
 	if err != nil {
 		log.Errorf("Failed to open file %s: %s", cfg.InFile, err)
 		return err
@@ -50,12 +173,23 @@ func realMain() error {
 	// Create a block importer for the database and input file and start it.
 	// The done channel returned from start will contain an error if
 	// anything went wrong.
-	importer, err := newBlockImporter(fi)
+	interrupt := signal.InterruptListener()
+	importer, err := newBlockImporter(fi, interrupt)
 	if err != nil {
 		log.Errorf("Failed create block importer: %s", err)
 		return err
 	}
 
+	// Continue an earlier import of the same file that was interrupted,
+	// unless requested otherwise.
+	if !cfg.NoResume {
+		err = importer.resume()
+		if err != nil {
+			log.Errorf("Failed to resume the import: %s", err)
+			return err
+		}
+	}
+
 	// Perform the import asynchronously. This allows blocks to be
 	// processed and read in parallel. The results channel returned from
 	// Import contains the statistics about the import including an error
@@ -63,6 +197,7 @@ func realMain() error {
 	log.Info("Starting import")
 	resultsChan := importer.Import()
 	results := <-resultsChan
+	logThroughput(results)
 	if results.err != nil {
 		log.Errorf("%s", results.err)
 		return results.err
@@ -71,9 +206,34 @@ func realMain() error {
 	log.Infof("Processed a total of %d blocks (%d imported, %d already "+
 		"known)", results.blocksProcessed, results.blocksImported,
 		results.blocksProcessed-results.blocksImported)
+	if results.blocksSkipped > 0 {
+		log.Infof("Skipped %d blocks that were processed before the "+
+			"import was resumed", results.blocksSkipped)
+	}
+
+	// The whole file was imported, so there's nothing left to resume.
+	err = removeCheckpoint(checkpointPath())
+	if err != nil {
+		log.Errorf("Failed to remove the import checkpoint: %s", err)
+		return err
+	}
 	return nil
 }
 
+// logThroughput logs the duration of the import and the rate at which
+// blocks, transactions and bytes of the input file were processed.
+func logThroughput(results *importResults) {
+	seconds := results.duration.Seconds()
+	if seconds == 0 {
+		return
+	}
+	log.Infof("Import took %s (%.2f blocks/s, %.2f transactions/s, "+
+		"%.2f MB/s)", results.duration.Round(time.Millisecond),
+		float64(results.blocksProcessed)/seconds,
+		float64(results.txsProcessed)/seconds,
+		float64(results.bytesProcessed)/seconds/1e6)
+}
+
 func main() {
 	// Use all processor cores and up some limits.
 	runtime.GOMAXPROCS(runtime.NumCPU())
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+// checkpointFileName is the name of the file in the data directory which
+// holds the progress of the last import that didn't finish.
+const checkpointFileName = "addblock.checkpoint"
+
+// importCheckpoint is the progress of an import, which allows an interrupted
+// import of the same file to resume from where it stopped instead of reading
+// the file from its beginning.
+type importCheckpoint struct {
+	// InFile and InFileSize identify the block file that is imported.
+	InFile     string `json:"inFile"`
+	InFileSize int64  `json:"inFileSize"`
+
+	// Offset is the offset in the uncompressed block file right after
+	// LastBlockHash, which is where the import resumes from.
+	Offset          int64  `json:"offset"`
+	BlocksProcessed int64  `json:"blocksProcessed"`
+	LastBlockHash   string `json:"lastBlockHash"`
+}
+
+// checkpointPath returns the path of the checkpoint file of the data
+// directory.
+func checkpointPath() string {
+	return filepath.Join(cfg.DataDir, checkpointFileName)
+}
+
+// newImportCheckpoint returns an empty checkpoint of the given block file.
+func newImportCheckpoint(inFile string) (*importCheckpoint, error) {
+	absPath, err := filepath.Abs(inFile)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo, err := os.Stat(absPath)
+	if err != nil {
+		return nil, err
+	}
+	return &importCheckpoint{
+		InFile:     absPath,
+		InFileSize: fileInfo.Size(),
+	}, nil
+}
+
+// isOfSameFile returns whether both checkpoints belong to the same block
+// file.
+func (cp *importCheckpoint) isOfSameFile(other *importCheckpoint) bool {
+	return cp.InFile == other.InFile && cp.InFileSize == other.InFileSize
+}
+
+// lastBlockHash returns the hash of the last block that was processed
+// before the checkpoint was saved.
+func (cp *importCheckpoint) lastBlockHash() (*daghash.Hash, error) {
+	return daghash.NewHashFromStr(cp.LastBlockHash)
+}
+
+// loadCheckpoint loads the checkpoint from the given path. It returns nil
+// if there's no checkpoint.
+func loadCheckpoint(path string) (*importCheckpoint, error) {
+	serializedCheckpoint, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	checkpoint := &importCheckpoint{}
+	err = json.Unmarshal(serializedCheckpoint, checkpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "checkpoint file %s is corrupted", path)
+	}
+	return checkpoint, nil
+}
+
+// save writes the checkpoint to the given path. The checkpoint is written
+// to a temporary file first and then renamed, so that an interruption
+// while it's written doesn't leave a corrupted checkpoint behind.
+func (cp *importCheckpoint) save(path string) error {
+	serializedCheckpoint, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tempPath := path + ".tmp"
+	err = ioutil.WriteFile(tempPath, serializedCheckpoint, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tempPath, path)
+}
+
+// removeCheckpoint removes the checkpoint from the given path, if there's
+// one.
+func removeCheckpoint(path string) error {
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestImportCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestImportCheckpoint")
+	if err != nil {
+		t.Fatalf("TempDir unexpectedly failed: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	inFile := filepath.Join(dir, "blocks.dat")
+	err = ioutil.WriteFile(inFile, []byte{1, 2, 3}, 0600)
+	if err != nil {
+		t.Fatalf("WriteFile unexpectedly failed: %s", err)
+	}
+	path := filepath.Join(dir, checkpointFileName)
+
+	// There's no checkpoint before one is saved
+	checkpoint, err := loadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("loadCheckpoint unexpectedly failed: %s", err)
+	}
+	if checkpoint != nil {
+		t.Fatalf("loadCheckpoint: unexpectedly loaded checkpoint %v", checkpoint)
+	}
+
+	checkpoint, err = newImportCheckpoint(inFile)
+	if err != nil {
+		t.Fatalf("newImportCheckpoint unexpectedly failed: %s", err)
+	}
+	if checkpoint.InFileSize != 3 {
+		t.Fatalf("newImportCheckpoint: unexpected file size. Want: 3, got: %d",
+			checkpoint.InFileSize)
+	}
+	checkpoint.Offset = 2
+	checkpoint.BlocksProcessed = 1
+	checkpoint.LastBlockHash = "0000000000000000000000000000000000000000000000000000000000000001"
+	err = checkpoint.save(path)
+	if err != nil {
+		t.Fatalf("save unexpectedly failed: %s", err)
+	}
+	loadedCheckpoint, err := loadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("loadCheckpoint unexpectedly failed: %s", err)
+	}
+	if !reflect.DeepEqual(loadedCheckpoint, checkpoint) {
+		t.Fatalf("loadCheckpoint: unexpected checkpoint. Want: %v, got: %v",
+			checkpoint, loadedCheckpoint)
+	}
+	if _, err := loadedCheckpoint.lastBlockHash(); err != nil {
+		t.Fatalf("lastBlockHash unexpectedly failed: %s", err)
+	}
+
+	// A checkpoint of the file after it changed is of a different file
+	err = ioutil.WriteFile(inFile, []byte{1, 2, 3, 4}, 0600)
+	if err != nil {
+		t.Fatalf("WriteFile unexpectedly failed: %s", err)
+	}
+	otherCheckpoint, err := newImportCheckpoint(inFile)
+	if err != nil {
+		t.Fatalf("newImportCheckpoint unexpectedly failed: %s", err)
+	}
+	if loadedCheckpoint.isOfSameFile(otherCheckpoint) {
+		t.Fatalf("isOfSameFile: the checkpoint of a changed file is " +
+			"unexpectedly of the same file")
+	}
+
+	err = removeCheckpoint(path)
+	if err != nil {
+		t.Fatalf("removeCheckpoint unexpectedly failed: %s", err)
+	}
+	err = removeCheckpoint(path)
+	if err != nil {
+		t.Fatalf("removeCheckpoint of a missing checkpoint unexpectedly failed: %s", err)
+	}
+
+	// A corrupted checkpoint can't be loaded
+	err = ioutil.WriteFile(path, []byte("{"), 0600)
+	if err != nil {
+		t.Fatalf("WriteFile unexpectedly failed: %s", err)
+	}
+	_, err = loadCheckpoint(path)
+	if err == nil {
+		t.Fatalf("loadCheckpoint: a corrupted checkpoint was unexpectedly loaded")
+	}
+}
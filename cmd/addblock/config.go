@@ -8,6 +8,7 @@ import (
 	"fmt"
 	flags "github.com/jessevdk/go-flags"
 	"github.com/kaspanet/kaspad/config"
+	"github.com/kaspanet/kaspad/database"
 	"github.com/kaspanet/kaspad/util"
 	"github.com/pkg/errors"
 	"os"
@@ -15,8 +16,11 @@ import (
 )
 
 const (
-	defaultDataFile = "bootstrap.dat"
-	defaultProgress = 10
+	defaultDataFile           = "bootstrap.dat"
+	defaultDbType             = "ffldb"
+	defaultProgress           = 10
+	defaultCheckpointInterval = 1000
+	defaultSigCacheMaxSize    = 100000
 )
 
 var (
@@ -34,10 +38,16 @@ func ActiveConfig() *ConfigFlags {
 //
 // See loadConfig for details on the configuration load process.
 type ConfigFlags struct {
-	DataDir         string `short:"b" long:"datadir" description:"Location of the kaspad data directory"`
-	InFile          string `short:"i" long:"infile" description:"File containing the block(s)"`
-	Progress        int    `short:"p" long:"progress" description:"Show a progress message each time this number of seconds have passed -- Use 0 to disable progress announcements"`
-	AcceptanceIndex bool   `long:"acceptanceindex" description:"Maintain a full hash-based acceptance index which makes the getChainFromBlock RPC available"`
+	DataDir            string `short:"b" long:"datadir" description:"Location of the kaspad data directory"`
+	DbType             string `long:"dbtype" description:"Database backend of the kaspad data directory"`
+	InFile             string `short:"i" long:"infile" description:"File containing the block(s) -- It may be compressed with gzip"`
+	Progress           int    `short:"p" long:"progress" description:"Show a progress message each time this number of seconds have passed -- Use 0 to disable progress announcements"`
+	Workers            int    `short:"w" long:"workers" description:"Fully validate the blocks, checking them and their scripts on this number of goroutines before they're added to the DAG -- Use 0 to add the blocks without validating their scripts"`
+	CheckpointInterval int    `long:"checkpointinterval" description:"Save the progress of the import each time this number of blocks were processed, so that an interrupted import can be resumed"`
+	NoResume           bool   `long:"noresume" description:"Import the whole file, even if an earlier import of it was interrupted"`
+	AcceptanceIndex    bool   `long:"acceptanceindex" description:"Maintain a full hash-based acceptance index which makes the getChainFromBlock RPC available"`
+	TxIndex            bool   `long:"txindex" description:"Maintain a full hash-based transaction index which makes all transactions available via the getRawTransaction RPC"`
+	AddrIndex          bool   `long:"addrindex" description:"Maintain a full address-based transaction index which makes the getAddressTransactions, getAddressBalance and getAddressUTXOs RPCs available"`
 	config.NetworkFlags
 }
 
@@ -55,13 +65,15 @@ func fileExists(name string) bool {
 func loadConfig() (*ConfigFlags, []string, error) {
 	// Default config.
 	activeConfig = &ConfigFlags{
-		DataDir:  defaultDataDir,
-		InFile:   defaultDataFile,
-		Progress: defaultProgress,
+		DataDir:            defaultDataDir,
+		DbType:             defaultDbType,
+		InFile:             defaultDataFile,
+		Progress:           defaultProgress,
+		CheckpointInterval: defaultCheckpointInterval,
 	}
 
 	// Parse command line options.
-	parser := flags.NewParser(&activeConfig, flags.Default)
+	parser := flags.NewParser(activeConfig, flags.Default)
 	remainingArgs, err := parser.Parse()
 	if err != nil {
 		var flagsErr *flags.Error
@@ -82,16 +94,52 @@ func loadConfig() (*ConfigFlags, []string, error) {
 	// All data is specific to a network, so namespacing the data directory
 	// means each individual piece of serialized data does not have to
 	// worry about changing names per network and such.
-	cfg.DataDir = filepath.Join(cfg.DataDir, ActiveConfig().NetParams().Name)
+	activeConfig.DataDir = filepath.Join(activeConfig.DataDir, ActiveConfig().NetParams().Name)
+
+	if !isSupportedDbType(activeConfig.DbType) {
+		str := "%s: The specified database type [%s] is invalid -- " +
+			"supported types are %s"
+		err := errors.Errorf(str, "loadConfig", activeConfig.DbType, database.SupportedDrivers())
+		fmt.Fprintln(os.Stderr, err)
+		parser.WriteHelp(os.Stderr)
+		return nil, nil, err
+	}
 
 	// Ensure the specified block file exists.
-	if !fileExists(cfg.InFile) {
+	if !fileExists(activeConfig.InFile) {
 		str := "%s: The specified block file [%s] does not exist"
-		err := errors.Errorf(str, "loadConfig", cfg.InFile)
+		err := errors.Errorf(str, "loadConfig", activeConfig.InFile)
+		fmt.Fprintln(os.Stderr, err)
+		parser.WriteHelp(os.Stderr)
+		return nil, nil, err
+	}
+
+	if activeConfig.Workers < 0 {
+		str := "%s: The number of workers may not be negative -- parsed [%d]"
+		err := errors.Errorf(str, "loadConfig", activeConfig.Workers)
 		fmt.Fprintln(os.Stderr, err)
 		parser.WriteHelp(os.Stderr)
 		return nil, nil, err
 	}
 
-	return cfg, remainingArgs, nil
+	if activeConfig.CheckpointInterval <= 0 {
+		str := "%s: The checkpoint interval must be positive -- parsed [%d]"
+		err := errors.Errorf(str, "loadConfig", activeConfig.CheckpointInterval)
+		fmt.Fprintln(os.Stderr, err)
+		parser.WriteHelp(os.Stderr)
+		return nil, nil, err
+	}
+
+	return activeConfig, remainingArgs, nil
+}
+
+// isSupportedDbType returns whether or not the passed database type is
+// currently supported.
+func isSupportedDbType(dbType string) bool {
+	for _, supportedDbType := range database.SupportedDrivers() {
+		if dbType == supportedDbType {
+			return true
+		}
+	}
+	return false
 }
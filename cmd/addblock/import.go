@@ -7,6 +7,7 @@ package main
 import (
 	"encoding/binary"
 	"github.com/kaspanet/kaspad/blockdag/indexers"
+	"github.com/kaspanet/kaspad/txscript"
 	"github.com/pkg/errors"
 	"io"
 	"sync"
@@ -21,26 +22,147 @@ import (
 type importResults struct {
 	blocksProcessed int64
 	blocksImported  int64
+	blocksSkipped   int64
+	txsProcessed    int64
+	bytesProcessed  int64
+	duration        time.Duration
 	err             error
 }
 
+// importItem is a block that was read from the import file.
+type importItem struct {
+	serializedBlock []byte
+
+	// block is the deserialized block. It's set by the read handler only
+	// for blocks that are checked before they're processed.
+	block *util.Block
+
+	// checkResult receives the result of the checks of the block. It's
+	// nil for blocks that aren't checked before they're processed.
+	checkResult chan error
+
+	// endOffset is the offset in the uncompressed import file right after
+	// the block.
+	endOffset int64
+}
+
+// countingReader is a reader that counts the number of bytes that were read
+// through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read reads from the underlying reader and counts the read bytes.
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// Seek seeks in the underlying reader, which must implement io.Seeker, and
+// sets the count to the new offset. This is part of the io.Seeker interface
+// implementation.
+func (cr *countingReader) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := cr.r.(io.Seeker)
+	if !ok {
+		return 0, errors.New("the input file can't be seeked in")
+	}
+	newOffset, err := seeker.Seek(offset, whence)
+	if err != nil {
+		return 0, err
+	}
+	cr.n = newOffset
+	return newOffset, nil
+}
+
+// prevOutputSet holds the outputs of the blocks that were read from the
+// import file but not processed yet, so that the scripts of the blocks that
+// spend them can be checked before these blocks are processed. The outputs
+// of a block are removed from the set once the block is processed, since
+// they're then taken from the UTXO set of the DAG, so the set only ever
+// holds the outputs of the blocks that are queued for processing.
+type prevOutputSet struct {
+	sync.RWMutex
+	dag     *blockdag.BlockDAG
+	entries map[wire.Outpoint]*blockdag.UTXOEntry
+}
+
+// newPrevOutputSet returns a new empty prevOutputSet that falls back to
+// the UTXO set of the given DAG.
+func newPrevOutputSet(dag *blockdag.BlockDAG) *prevOutputSet {
+	return &prevOutputSet{
+		dag:     dag,
+		entries: make(map[wire.Outpoint]*blockdag.UTXOEntry),
+	}
+}
+
+// addBlock adds the outputs of the transactions of the given block to the
+// set. The blue score of the entries is left zero, since it doesn't affect
+// script validation.
+func (s *prevOutputSet) addBlock(block *util.Block) {
+	s.Lock()
+	defer s.Unlock()
+	for _, tx := range block.Transactions() {
+		isCoinbase := tx.IsCoinBase()
+		for i, txOut := range tx.MsgTx().TxOut {
+			outpoint := wire.Outpoint{TxID: *tx.ID(), Index: uint32(i)}
+			s.entries[outpoint] = blockdag.NewUTXOEntry(txOut, isCoinbase, 0)
+		}
+	}
+}
+
+// get returns the output of the given outpoint, if it's in the set or in
+// the UTXO set of the DAG.
+func (s *prevOutputSet) get(outpoint wire.Outpoint) (*blockdag.UTXOEntry, bool) {
+	s.RLock()
+	entry, ok := s.entries[outpoint]
+	s.RUnlock()
+	if ok {
+		return entry, true
+	}
+	return s.dag.GetUTXOEntry(outpoint)
+}
+
+// removeBlock removes the outputs of the transactions of the given block,
+// which was just processed, from the set.
+func (s *prevOutputSet) removeBlock(block *util.Block) {
+	s.Lock()
+	defer s.Unlock()
+	for _, tx := range block.Transactions() {
+		for i := range tx.MsgTx().TxOut {
+			delete(s.entries, wire.Outpoint{TxID: *tx.ID(), Index: uint32(i)})
+		}
+	}
+}
+
 // blockImporter houses information about an ongoing import from a block data
 // file to the block database.
 type blockImporter struct {
-	dag               *blockdag.BlockDAG
-	r                 io.ReadSeeker
-	processQueue      chan []byte
-	doneChan          chan bool
-	errChan           chan error
-	quit              chan struct{}
-	wg                sync.WaitGroup
-	blocksProcessed   int64
-	blocksImported    int64
-	receivedLogBlocks int64
-	receivedLogTx     int64
-	lastHeight        int64
-	lastBlockTime     time.Time
-	lastLogTime       time.Time
+	dag                   *blockdag.BlockDAG
+	r                     *countingReader
+	processQueue          chan *importItem
+	checkQueue            chan *importItem
+	prevOutputs           *prevOutputSet
+	processFlags          blockdag.BehaviorFlags
+	doneChan              chan bool
+	errChan               chan error
+	quit                  chan struct{}
+	interrupt             <-chan struct{}
+	wg                    sync.WaitGroup
+	checkpoint            *importCheckpoint
+	blocksSinceCheckpoint int64
+	startOffset           int64
+	startTime             time.Time
+	blocksProcessed       int64
+	blocksImported        int64
+	blocksSkipped         int64
+	txsProcessed          int64
+	receivedLogBlocks     int64
+	receivedLogTx         int64
+	lastHeight            int64
+	lastBlockTime         time.Time
+	lastLogTime           time.Time
 }
 
 // readBlock reads the next block from the input file.
@@ -82,20 +204,26 @@ func (bi *blockImporter) readBlock() ([]byte, error) {
 }
 
 // processBlock potentially imports the block into the database. It first
-// deserializes the raw block while checking for errors. Already known blocks
-// are skipped and orphan blocks are considered errors. Finally, it runs the
-// block through the DAG rules to ensure it follows all rules.
+// deserializes the raw block while checking for errors, unless the read
+// handler already did. Already known blocks are skipped and orphan blocks
+// are considered errors. Finally, it runs the block through the DAG rules
+// to ensure it follows all rules.
 // Returns whether the block was imported along with any potential errors.
-func (bi *blockImporter) processBlock(serializedBlock []byte) (bool, error) {
+func (bi *blockImporter) processBlock(item *importItem) (bool, error) {
 	// Deserialize the block which includes checks for malformed blocks.
-	block, err := util.NewBlockFromBytes(serializedBlock)
-	if err != nil {
-		return false, err
+	if item.block == nil {
+		block, err := util.NewBlockFromBytes(item.serializedBlock)
+		if err != nil {
+			return false, err
+		}
+		item.block = block
 	}
+	block := item.block
 
 	// update progress statistics
 	bi.lastBlockTime = block.MsgBlock().Header.Timestamp
 	bi.receivedLogTx += int64(len(block.MsgBlock().Transactions))
+	bi.txsProcessed += int64(len(block.MsgBlock().Transactions))
 
 	// Skip blocks that already exist.
 	blockHash := block.Hash()
@@ -114,8 +242,7 @@ func (bi *blockImporter) processBlock(serializedBlock []byte) (bool, error) {
 	}
 
 	// Ensure the blocks follows all of the DAG rules.
-	isOrphan, isDelayed, err := bi.dag.ProcessBlock(block,
-		blockdag.BFFastAdd)
+	isOrphan, isDelayed, err := bi.dag.ProcessBlock(block, bi.processFlags)
 	if err != nil {
 		return false, err
 	}
@@ -132,7 +259,9 @@ func (bi *blockImporter) processBlock(serializedBlock []byte) (bool, error) {
 
 // readHandler is the main handler for reading blocks from the import file.
 // This allows block processing to take place in parallel with block reads.
-// It must be run as a goroutine.
+// When blocks are checked before they're processed, it also sends them to
+// the check handlers, in the same order in which they're sent to the
+// process handler. It must be run as a goroutine.
 func (bi *blockImporter) readHandler() {
 out:
 	for {
@@ -150,17 +279,81 @@ out:
 			break out
 		}
 
+		item := &importItem{
+			serializedBlock: serializedBlock,
+			endOffset:       bi.r.n,
+		}
+
+		// Blocks that can't be deserialized are left to the process
+		// handler, which reports them in order.
+		if bi.checkQueue != nil {
+			block, err := util.NewBlockFromBytes(serializedBlock)
+			if err == nil {
+				item.block = block
+				item.checkResult = make(chan error, 1)
+				bi.prevOutputs.addBlock(block)
+				select {
+				case bi.checkQueue <- item:
+				case <-bi.quit:
+					break out
+				}
+			}
+		}
+
 		// Send the block or quit if we've been signalled to exit by
 		// the status handler due to an error elsewhere.
 		select {
-		case bi.processQueue <- serializedBlock:
+		case bi.processQueue <- item:
 		case <-bi.quit:
 			break out
 		}
 	}
 
-	// Close the processing channel to signal no more blocks are coming.
+	// Close the processing channels to signal no more blocks are coming.
 	close(bi.processQueue)
+	if bi.checkQueue != nil {
+		close(bi.checkQueue)
+	}
+	bi.wg.Done()
+}
+
+// checkBlock checks the given block in its own context, and checks the
+// scripts of its transactions that spend outputs of earlier blocks in the
+// import file. This allows most of the validation of the block to take
+// place before its parents are processed.
+func (bi *blockImporter) checkBlock(block *util.Block) error {
+	// Blocks that already exist are skipped when they're processed.
+	if bi.dag.IsKnownBlock(block.Hash()) {
+		return nil
+	}
+
+	// A delayed block is reported when it's processed.
+	_, err := bi.dag.CheckBlockSanity(block, blockdag.BFNone)
+	if err != nil {
+		return err
+	}
+	return bi.dag.CheckBlockScripts(block, bi.prevOutputs.get)
+}
+
+// checkHandler is a handler for checking blocks before they're processed.
+// Any number of check handlers may run in parallel. It must be run as a
+// goroutine.
+func (bi *blockImporter) checkHandler() {
+out:
+	for {
+		select {
+		case item, ok := <-bi.checkQueue:
+			// We're done when the channel is closed.
+			if !ok {
+				break out
+			}
+
+			item.checkResult <- bi.checkBlock(item.block)
+
+		case <-bi.quit:
+			break out
+		}
+	}
 	bi.wg.Done()
 }
 
@@ -198,6 +391,34 @@ func (bi *blockImporter) logProgress() {
 	bi.lastLogTime = now
 }
 
+// updateCheckpoint records the given block, which was just processed, in
+// the checkpoint, and saves the checkpoint once every cfg.CheckpointInterval
+// blocks.
+func (bi *blockImporter) updateCheckpoint(item *importItem) error {
+	bi.checkpoint.Offset = item.endOffset
+	bi.checkpoint.BlocksProcessed++
+	bi.checkpoint.LastBlockHash = item.block.Hash().String()
+	bi.blocksSinceCheckpoint++
+	if bi.blocksSinceCheckpoint < int64(cfg.CheckpointInterval) {
+		return nil
+	}
+	return bi.saveCheckpoint()
+}
+
+// saveCheckpoint saves the checkpoint, if any block was processed since it
+// was last saved.
+func (bi *blockImporter) saveCheckpoint() error {
+	if bi.blocksSinceCheckpoint == 0 {
+		return nil
+	}
+	err := bi.checkpoint.save(checkpointPath())
+	if err != nil {
+		return errors.Wrap(err, "failed to save the import checkpoint")
+	}
+	bi.blocksSinceCheckpoint = 0
+	return nil
+}
+
 // processHandler is the main handler for processing blocks. This allows block
 // processing to take place in parallel with block reads from the import file.
 // It must be run as a goroutine.
@@ -205,15 +426,29 @@ func (bi *blockImporter) processHandler() {
 out:
 	for {
 		select {
-		case serializedBlock, ok := <-bi.processQueue:
+		case item, ok := <-bi.processQueue:
 			// We're done when the channel is closed.
 			if !ok {
 				break out
 			}
 
+			// Wait for the checks of the block, if it's checked
+			// before it's processed.
+			if item.checkResult != nil {
+				select {
+				case err := <-item.checkResult:
+					if err != nil {
+						bi.errChan <- err
+						break out
+					}
+				case <-bi.quit:
+					break out
+				}
+			}
+
 			bi.blocksProcessed++
 			bi.lastHeight++
-			imported, err := bi.processBlock(serializedBlock)
+			imported, err := bi.processBlock(item)
 			if err != nil {
 				bi.errChan <- err
 				break out
@@ -222,6 +457,15 @@ out:
 			if imported {
 				bi.blocksImported++
 			}
+			if bi.prevOutputs != nil {
+				bi.prevOutputs.removeBlock(item.block)
+			}
+
+			err = bi.updateCheckpoint(item)
+			if err != nil {
+				bi.errChan <- err
+				break out
+			}
 
 			bi.logProgress()
 
@@ -229,44 +473,118 @@ out:
 			break out
 		}
 	}
+
+	// Save the progress of an import that stopped early, so that it can
+	// be resumed.
+	err := bi.saveCheckpoint()
+	if err != nil {
+		log.Errorf("%s", err)
+	}
 	bi.wg.Done()
 }
 
+// results returns the results of the import with the given error.
+func (bi *blockImporter) results(err error) *importResults {
+	return &importResults{
+		blocksProcessed: bi.blocksProcessed,
+		blocksImported:  bi.blocksImported,
+		blocksSkipped:   bi.blocksSkipped,
+		txsProcessed:    bi.txsProcessed,
+		bytesProcessed:  bi.checkpoint.Offset - bi.startOffset,
+		duration:        time.Since(bi.startTime),
+		err:             err,
+	}
+}
+
 // statusHandler waits for updates from the import operation and notifies
 // the passed doneChan with the results of the import. It also causes all
-// goroutines to exit if an error is reported from any of them.
+// goroutines to exit if an error is reported from any of them or if the
+// import is interrupted.
 func (bi *blockImporter) statusHandler(resultsChan chan *importResults) {
+	var err error
 	select {
 	// An error from either of the goroutines means we're done so signal
-	// caller with the error and signal all goroutines to quit.
-	case err := <-bi.errChan:
-		resultsChan <- &importResults{
-			blocksProcessed: bi.blocksProcessed,
-			blocksImported:  bi.blocksImported,
-			err:             err,
-		}
-		close(bi.quit)
+	// all goroutines to quit.
+	case err = <-bi.errChan:
+
+	case <-bi.interrupt:
+		err = errors.New("import interrupted -- run addblock " +
+			"again to resume it")
 
 	// The import finished normally.
 	case <-bi.doneChan:
-		resultsChan <- &importResults{
-			blocksProcessed: bi.blocksProcessed,
-			blocksImported:  bi.blocksImported,
-			err:             nil,
-		}
 	}
+
+	// Wait for all goroutines to exit before reporting an error, so that
+	// the progress is saved and the database is no longer in use.
+	if err != nil {
+		close(bi.quit)
+		<-bi.doneChan
+	}
+	resultsChan <- bi.results(err)
+}
+
+// resume makes the import continue from where an earlier import of the same
+// file stopped, if that import saved a checkpoint and the last block it
+// recorded is in the DAG. It must be called before Import.
+func (bi *blockImporter) resume() error {
+	checkpoint, err := loadCheckpoint(checkpointPath())
+	if err != nil {
+		return err
+	}
+	if checkpoint == nil {
+		return nil
+	}
+	if !checkpoint.isOfSameFile(bi.checkpoint) {
+		log.Infof("Ignoring the checkpoint of the import of %s, which "+
+			"is a different file", checkpoint.InFile)
+		return nil
+	}
+	lastBlockHash, err := checkpoint.lastBlockHash()
+	if err != nil {
+		return errors.Wrap(err, "checkpoint file is corrupted")
+	}
+	if !bi.dag.IsKnownBlock(lastBlockHash) {
+		log.Warnf("Importing the file from its beginning, since block "+
+			"%s of the checkpoint is not in the DAG", lastBlockHash)
+		return nil
+	}
+
+	_, err = bi.r.Seek(checkpoint.Offset, io.SeekStart)
+	if err != nil {
+		return errors.Wrapf(err, "failed to skip to offset %d of the "+
+			"input file", checkpoint.Offset)
+	}
+	bi.checkpoint = checkpoint
+	bi.startOffset = checkpoint.Offset
+	bi.blocksSkipped = checkpoint.BlocksProcessed
+	log.Infof("Resuming the import after block %s, skipping the %d "+
+		"blocks that were already processed", lastBlockHash,
+		checkpoint.BlocksProcessed)
+	return nil
 }
 
 // Import is the core function which handles importing the blocks from the file
 // associated with the block importer to the database. It returns a channel
 // on which the results will be returned when the operation has completed.
 func (bi *blockImporter) Import() chan *importResults {
+	bi.startTime = time.Now()
+
 	// Start up the read and process handling goroutines. This setup allows
 	// blocks to be read from disk in parallel while being processed.
 	bi.wg.Add(2)
 	spawn(bi.readHandler)
 	spawn(bi.processHandler)
 
+	// Start up the check handlers, which check blocks in parallel before
+	// they're processed.
+	if bi.checkQueue != nil {
+		bi.wg.Add(cfg.Workers)
+		for i := 0; i < cfg.Workers; i++ {
+			spawn(bi.checkHandler)
+		}
+	}
+
 	// Wait for the import to finish in a separate goroutine and signal
 	// the status handler when done.
 	spawn(func() {
@@ -283,15 +601,24 @@ func (bi *blockImporter) Import() chan *importResults {
 	return resultChan
 }
 
-// newBlockImporter returns a new importer for the provided file reader seeker
-// and database.
-func newBlockImporter(r io.ReadSeeker) (*blockImporter, error) {
-	// Create the acceptance index if needed.
+// newBlockImporter returns a new importer for the provided file reader
+// and database. The import stops early when the interrupt channel is
+// closed.
+func newBlockImporter(r io.Reader, interrupt <-chan struct{}) (*blockImporter, error) {
+	// Create the optional indexes if needed.
 	var indexes []indexers.Indexer
 	if cfg.AcceptanceIndex {
 		log.Info("Acceptance index is enabled")
 		indexes = append(indexes, indexers.NewAcceptanceIndex())
 	}
+	if cfg.TxIndex {
+		log.Info("Transaction index is enabled")
+		indexes = append(indexes, indexers.NewTxIndex())
+	}
+	if cfg.AddrIndex {
+		log.Info("Address index is enabled")
+		indexes = append(indexes, indexers.NewAddressIndex())
+	}
 
 	// Create an index manager if any of the optional indexes are enabled.
 	var indexManager blockdag.IndexManager
@@ -299,22 +626,50 @@ func newBlockImporter(r io.ReadSeeker) (*blockImporter, error) {
 		indexManager = indexers.NewManager(indexes)
 	}
 
+	// Blocks that are checked before they're processed are fully
+	// validated, and the signatures that were verified by the checks are
+	// cached so that they're not verified again. Otherwise, the scripts
+	// aren't validated at all.
+	var sigCache *txscript.SigCache
+	processFlags := blockdag.BFFastAdd
+	processQueueSize := 2
+	if cfg.Workers > 0 {
+		sigCache = txscript.NewSigCache(defaultSigCacheMaxSize)
+		processFlags = blockdag.BFNone
+		processQueueSize = 2 * cfg.Workers
+	}
+
 	dag, err := blockdag.New(&blockdag.Config{
+		Interrupt:    interrupt,
 		DAGParams:    ActiveConfig().NetParams(),
 		TimeSource:   blockdag.NewTimeSource(),
+		SigCache:     sigCache,
 		IndexManager: indexManager,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &blockImporter{
-		r:            r,
-		processQueue: make(chan []byte, 2),
+	checkpoint, err := newImportCheckpoint(cfg.InFile)
+	if err != nil {
+		return nil, err
+	}
+
+	bi := &blockImporter{
+		r:            &countingReader{r: r},
+		processQueue: make(chan *importItem, processQueueSize),
+		processFlags: processFlags,
 		doneChan:     make(chan bool),
-		errChan:      make(chan error),
+		errChan:      make(chan error, 2),
 		quit:         make(chan struct{}),
+		interrupt:    interrupt,
+		checkpoint:   checkpoint,
 		dag:          dag,
 		lastLogTime:  time.Now(),
-	}, nil
+	}
+	if cfg.Workers > 0 {
+		bi.checkQueue = make(chan *importItem, cfg.Workers)
+		bi.prevOutputs = newPrevOutputSet(dag)
+	}
+	return bi, nil
 }
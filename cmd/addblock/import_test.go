@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/config"
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/logs"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// writeBlockFileForTest writes a block file with the given serialized
+// blocks to the given path, and returns the offset right after every block.
+func writeBlockFileForTest(t *testing.T, path string, compress bool, serializedBlocks ...[]byte) []int64 {
+	var buf bytes.Buffer
+	endOffsets := make([]int64, len(serializedBlocks))
+	for i, serializedBlock := range serializedBlocks {
+		binary.Write(&buf, binary.LittleEndian, uint32(dagconfig.SimnetParams.Net))
+		binary.Write(&buf, binary.LittleEndian, uint32(len(serializedBlock)))
+		buf.Write(serializedBlock)
+		endOffsets[i] = int64(buf.Len())
+	}
+
+	fileBytes := buf.Bytes()
+	if compress {
+		var compressed bytes.Buffer
+		gzipWriter := gzip.NewWriter(&compressed)
+		gzipWriter.Write(fileBytes)
+		gzipWriter.Close()
+		fileBytes = compressed.Bytes()
+	}
+	err := ioutil.WriteFile(path, fileBytes, 0600)
+	if err != nil {
+		t.Fatalf("WriteFile unexpectedly failed: %s", err)
+	}
+	return endOffsets
+}
+
+func TestResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestResume")
+	if err != nil {
+		t.Fatalf("TempDir unexpectedly failed: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	log = logs.NewBackend().Logger("MAIN")
+	activeConfig = &ConfigFlags{
+		DataDir:      dir,
+		NetworkFlags: config.NetworkFlags{ActiveNetParams: &dagconfig.SimnetParams},
+	}
+	cfg = activeConfig
+
+	dag, teardownFunc, err := blockdag.DAGSetup("TestResume", true, blockdag.Config{
+		DAGParams: &dagconfig.SimnetParams,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup DAG instance: %v", err)
+	}
+	defer teardownFunc()
+
+	firstBlock := []byte{1, 2, 3}
+	secondBlock := []byte{4, 5}
+	tests := []struct {
+		name            string
+		compress        bool
+		lastBlockHash   *daghash.Hash
+		isResumed       bool
+		expectedBlock   []byte
+		expectedSkipped int64
+	}{
+		{
+			name:            "uncompressed file",
+			lastBlockHash:   dagconfig.SimnetParams.GenesisHash,
+			isResumed:       true,
+			expectedBlock:   secondBlock,
+			expectedSkipped: 1,
+		},
+		{
+			name:            "compressed file",
+			compress:        true,
+			lastBlockHash:   dagconfig.SimnetParams.GenesisHash,
+			isResumed:       true,
+			expectedBlock:   secondBlock,
+			expectedSkipped: 1,
+		},
+		{
+			name:            "last block not in the DAG",
+			lastBlockHash:   &daghash.Hash{1},
+			isResumed:       false,
+			expectedBlock:   firstBlock,
+			expectedSkipped: 0,
+		},
+	}
+	for _, test := range tests {
+		inFile := filepath.Join(dir, "blocks.dat")
+		endOffsets := writeBlockFileForTest(t, inFile, test.compress, firstBlock, secondBlock)
+		cfg.InFile = inFile
+
+		// Save a checkpoint after the first block
+		checkpoint, err := newImportCheckpoint(inFile)
+		if err != nil {
+			t.Fatalf("%s: newImportCheckpoint unexpectedly failed: %s", test.name, err)
+		}
+		checkpoint.Offset = endOffsets[0]
+		checkpoint.BlocksProcessed = 1
+		checkpoint.LastBlockHash = test.lastBlockHash.String()
+		err = checkpoint.save(checkpointPath())
+		if err != nil {
+			t.Fatalf("%s: save unexpectedly failed: %s", test.name, err)
+		}
+
+		fi, err := openBlockFile(inFile)
+		if err != nil {
+			t.Fatalf("%s: openBlockFile unexpectedly failed: %s", test.name, err)
+		}
+		newCheckpoint, err := newImportCheckpoint(inFile)
+		if err != nil {
+			t.Fatalf("%s: newImportCheckpoint unexpectedly failed: %s", test.name, err)
+		}
+		bi := &blockImporter{
+			dag:        dag,
+			r:          &countingReader{r: fi},
+			checkpoint: newCheckpoint,
+		}
+		err = bi.resume()
+		if err != nil {
+			t.Fatalf("%s: resume unexpectedly failed: %s", test.name, err)
+		}
+
+		expectedStartOffset := int64(0)
+		if test.isResumed {
+			expectedStartOffset = endOffsets[0]
+		}
+		if bi.startOffset != expectedStartOffset || bi.r.n != expectedStartOffset {
+			t.Errorf("%s: unexpected offset. Want: %d, got: start offset %d, "+
+				"read offset %d", test.name, expectedStartOffset, bi.startOffset, bi.r.n)
+		}
+		if bi.blocksSkipped != test.expectedSkipped {
+			t.Errorf("%s: unexpected number of skipped blocks. Want: %d, got: %d",
+				test.name, test.expectedSkipped, bi.blocksSkipped)
+		}
+		serializedBlock, err := bi.readBlock()
+		if err != nil {
+			t.Fatalf("%s: readBlock unexpectedly failed: %s", test.name, err)
+		}
+		if !bytes.Equal(serializedBlock, test.expectedBlock) {
+			t.Errorf("%s: unexpected block after resuming. Want: %v, got: %v",
+				test.name, test.expectedBlock, serializedBlock)
+		}
+		fi.Close()
+	}
+}
+
+func TestPrevOutputSet(t *testing.T) {
+	dag, teardownFunc, err := blockdag.DAGSetup("TestPrevOutputSet", true, blockdag.Config{
+		DAGParams: &dagconfig.SimnetParams,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup DAG instance: %v", err)
+	}
+	defer teardownFunc()
+
+	msgBlock, err := blockdag.PrepareBlockForTest(dag, []*daghash.Hash{dagconfig.SimnetParams.GenesisHash}, nil)
+	if err != nil {
+		t.Fatalf("PrepareBlockForTest unexpectedly failed: %s", err)
+	}
+	block := util.NewBlock(msgBlock)
+	outpoint := wire.Outpoint{TxID: *block.CoinbaseTransaction().ID(), Index: 0}
+
+	prevOutputs := newPrevOutputSet(dag)
+	prevOutputs.addBlock(block)
+	if _, ok := prevOutputs.get(outpoint); !ok {
+		t.Fatalf("TestPrevOutputSet: output %s of a block that wasn't "+
+			"processed yet is missing", outpoint)
+	}
+
+	// Once the block is processed, its outputs are removed from the set
+	// and are taken from the UTXO set of the DAG instead.
+	isOrphan, isDelayed, err := dag.ProcessBlock(block, blockdag.BFNoPoWCheck)
+	if err != nil || isOrphan || isDelayed {
+		t.Fatalf("TestPrevOutputSet: ProcessBlock unexpectedly failed: isOrphan: %t, "+
+			"isDelayed: %t, err: %v", isOrphan, isDelayed, err)
+	}
+	prevOutputs.removeBlock(block)
+	if len(prevOutputs.entries) != 0 {
+		t.Fatalf("TestPrevOutputSet: expected the set to be empty after the "+
+			"block was processed, but it has %d entries", len(prevOutputs.entries))
+	}
+	if _, ok := prevOutputs.get(outpoint); !ok {
+		t.Fatalf("TestPrevOutputSet: output %s of a processed block "+
+			"is missing", outpoint)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	flags "github.com/jessevdk/go-flags"
+	"github.com/kaspanet/kaspad/config"
+	"github.com/kaspanet/kaspad/database"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultDataFile = "bootstrap.dat"
+	defaultDbType   = "ffldb"
+	defaultProgress = 10
+)
+
+var (
+	kaspadHomeDir  = util.AppDataDir("kaspad", false)
+	defaultDataDir = filepath.Join(kaspadHomeDir, "data")
+	activeConfig   *ConfigFlags
+)
+
+// ActiveConfig returns the active configuration struct
+func ActiveConfig() *ConfigFlags {
+	return activeConfig
+}
+
+// ConfigFlags defines the configuration options for exportblocks.
+//
+// See loadConfig for details on the configuration load process.
+type ConfigFlags struct {
+	DataDir       string `short:"b" long:"datadir" description:"Location of the kaspad data directory"`
+	DbType        string `long:"dbtype" description:"Database backend of the kaspad data directory"`
+	OutFile       string `short:"o" long:"outfile" description:"File to write the blocks to -- It must not exist"`
+	LowBlueScore  uint64 `long:"lowbluescore" description:"Only export the blocks whose blue scores are at least this blue score"`
+	HighBlueScore uint64 `long:"highbluescore" description:"Only export the blocks whose blue scores are at most this blue score"`
+	Compress      bool   `short:"z" long:"compress" description:"Compress the output file with gzip -- addblock detects compressed files by themselves"`
+	Progress      int    `short:"p" long:"progress" description:"Show a progress message each time this number of seconds have passed -- Use 0 to disable progress announcements"`
+	config.NetworkFlags
+}
+
+// loadConfig initializes and parses the config using command line options.
+func loadConfig() (*ConfigFlags, []string, error) {
+	// Default config.
+	activeConfig = &ConfigFlags{
+		DataDir:       defaultDataDir,
+		DbType:        defaultDbType,
+		OutFile:       defaultDataFile,
+		HighBlueScore: math.MaxUint64,
+		Progress:      defaultProgress,
+	}
+
+	// Parse command line options.
+	parser := flags.NewParser(activeConfig, flags.Default)
+	remainingArgs, err := parser.Parse()
+	if err != nil {
+		var flagsErr *flags.Error
+		if ok := errors.As(err, &flagsErr); !ok || flagsErr.Type != flags.ErrHelp {
+			parser.WriteHelp(os.Stderr)
+		}
+		return nil, nil, err
+	}
+
+	err = activeConfig.ResolveNetwork(parser)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Append the network type to the data directory, just like kaspad
+	// does.
+	activeConfig.DataDir = filepath.Join(activeConfig.DataDir, activeConfig.NetParams().Name)
+
+	funcName := "loadConfig"
+	if !isSupportedDbType(activeConfig.DbType) {
+		str := "%s: The specified database type [%s] is invalid -- " +
+			"supported types are %s"
+		err := errors.Errorf(str, funcName, activeConfig.DbType, database.SupportedDrivers())
+		fmt.Fprintln(os.Stderr, err)
+		parser.WriteHelp(os.Stderr)
+		return nil, nil, err
+	}
+
+	if activeConfig.LowBlueScore > activeConfig.HighBlueScore {
+		str := "%s: The low blue score [%d] is above the high blue score [%d]"
+		err := errors.Errorf(str, funcName, activeConfig.LowBlueScore, activeConfig.HighBlueScore)
+		fmt.Fprintln(os.Stderr, err)
+		parser.WriteHelp(os.Stderr)
+		return nil, nil, err
+	}
+
+	// Ensure the specified output file doesn't exist, so that an
+	// existing file is never overwritten.
+	if _, err := os.Stat(activeConfig.OutFile); !os.IsNotExist(err) {
+		str := "%s: The specified output file [%s] already exists"
+		err := errors.Errorf(str, funcName, activeConfig.OutFile)
+		fmt.Fprintln(os.Stderr, err)
+		parser.WriteHelp(os.Stderr)
+		return nil, nil, err
+	}
+
+	return activeConfig, remainingArgs, nil
+}
+
+// isSupportedDbType returns whether or not the passed database type is
+// currently supported.
+func isSupportedDbType(dbType string) bool {
+	for _, supportedDbType := range database.SupportedDrivers() {
+		if dbType == supportedDbType {
+			return true
+		}
+	}
+	return false
+}
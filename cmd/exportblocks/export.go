@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// exportResults houses the stats of an export operation.
+type exportResults struct {
+	blocksExported int64
+	bytesExported  int64
+}
+
+// blockExporter houses information about an ongoing export of blocks from
+// the block database to a block data file.
+type blockExporter struct {
+	dag               *blockdag.BlockDAG
+	w                 io.Writer
+	results           exportResults
+	receivedLogBlocks int64
+	lastBlueScore     uint64
+	lastLogTime       time.Time
+}
+
+// writeBlock writes the given serialized block to the output file in the
+// format that addblock reads.
+func (be *blockExporter) writeBlock(serializedBlock []byte) error {
+	// The block file format is:
+	//  <network> <block length> <serialized block>
+	err := binary.Write(be.w, binary.LittleEndian, uint32(ActiveConfig().NetParams().Net))
+	if err != nil {
+		return err
+	}
+	err = binary.Write(be.w, binary.LittleEndian, uint32(len(serializedBlock)))
+	if err != nil {
+		return err
+	}
+	_, err = be.w.Write(serializedBlock)
+	if err != nil {
+		return err
+	}
+
+	be.results.blocksExported++
+	be.results.bytesExported += int64(8 + len(serializedBlock))
+	return nil
+}
+
+// logProgress logs block progress as an information message. In order to
+// prevent spam, it limits logging to one message every cfg.Progress seconds
+// with duration and totals included.
+func (be *blockExporter) logProgress() {
+	be.receivedLogBlocks++
+
+	now := time.Now()
+	duration := now.Sub(be.lastLogTime)
+	if cfg.Progress == 0 || duration < time.Second*time.Duration(cfg.Progress) {
+		return
+	}
+
+	// Truncate the duration to 10s of milliseconds.
+	durationMillis := int64(duration / time.Millisecond)
+	tDuration := 10 * time.Millisecond * time.Duration(durationMillis/10)
+
+	blockStr := "blocks"
+	if be.receivedLogBlocks == 1 {
+		blockStr = "block"
+	}
+	log.Infof("Exported %d %s in the last %s (blue score %d)",
+		be.receivedLogBlocks, blockStr, tDuration, be.lastBlueScore)
+
+	be.receivedLogBlocks = 0
+	be.lastLogTime = now
+}
+
+// Export writes the blocks with the given hashes, which must be ordered such
+// that every block appears after all of its parents, to the output file.
+func (be *blockExporter) Export(hashes []*daghash.Hash) (*exportResults, error) {
+	for _, hash := range hashes {
+		block, err := be.dag.BlockByHash(hash)
+		if err != nil {
+			return nil, err
+		}
+		serializedBlock, err := block.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		err = be.writeBlock(serializedBlock)
+		if err != nil {
+			return nil, err
+		}
+
+		be.lastBlueScore, err = be.dag.BlueScoreByBlockHash(hash)
+		if err != nil {
+			return nil, err
+		}
+		be.logProgress()
+	}
+	return &be.results, nil
+}
+
+// newBlockExporter returns a new exporter of the blocks in the DAG to the
+// provided writer.
+func newBlockExporter(w io.Writer) (*blockExporter, error) {
+	dag, err := blockdag.New(&blockdag.Config{
+		DAGParams:  ActiveConfig().NetParams(),
+		TimeSource: blockdag.NewTimeSource(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &blockExporter{
+		dag:         dag,
+		w:           w,
+		lastLogTime: time.Now(),
+	}, nil
+}
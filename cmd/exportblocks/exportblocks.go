@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/kaspanet/kaspad/database/ffldb"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/limits"
+	"github.com/kaspanet/kaspad/logs"
+)
+
+const (
+	// blockDbNamePrefix is the prefix for the kaspad block database.
+	blockDbNamePrefix = "db"
+)
+
+var (
+	cfg *ConfigFlags
+	log *logs.Logger
+)
+
+// blockDbPath returns the path to the block database of the given type,
+// which is named just like kaspad names it.
+func blockDbPath(dbType string) string {
+	dbName := blockDbNamePrefix
+	if dbType != ffldb.DbType {
+		dbName = dbName + "_" + dbType
+	}
+	return filepath.Join(cfg.DataDir, dbName)
+}
+
+// realMain is the real main function for the utility. It is necessary to work
+// around the fact that deferred functions do not run when os.Exit() is called.
+func realMain() error {
+	// Load configuration and parse command line.
+	tcfg, _, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	cfg = tcfg
+
+	// Setup logging.
+	backendLogger := logs.NewBackend()
+	defer os.Stdout.Sync()
+	log = backendLogger.Logger("MAIN")
+
+	// Open the database. Note that kaspad must not be running, since it
+	// holds the database open.
+	err = dbaccess.OpenWithDriver(cfg.DbType, blockDbPath(cfg.DbType))
+	if err != nil {
+		log.Errorf("Failed to open the database: %s", err)
+		return err
+	}
+	defer dbaccess.Close()
+
+	fo, err := os.Create(cfg.OutFile)
+	if err != nil {
+		log.Errorf("Failed to create file %s: %s", cfg.OutFile, err)
+		return err
+	}
+	defer fo.Close()
+
+	// Buffer the writes to the file, and compress them if requested.
+	bufferedWriter := bufio.NewWriter(fo)
+	var w io.Writer = bufferedWriter
+	var gzipWriter *gzip.Writer
+	if cfg.Compress {
+		gzipWriter = gzip.NewWriter(bufferedWriter)
+		w = gzipWriter
+	}
+
+	exporter, err := newBlockExporter(w)
+	if err != nil {
+		log.Errorf("Failed to create block exporter: %s", err)
+		return err
+	}
+
+	hashes := exporter.dag.BlockHashesByBlueScore(cfg.LowBlueScore, cfg.HighBlueScore)
+	log.Infof("Exporting %d blocks to %s", len(hashes), cfg.OutFile)
+	results, err := exporter.Export(hashes)
+	if err != nil {
+		log.Errorf("%s", err)
+		return err
+	}
+
+	if gzipWriter != nil {
+		err := gzipWriter.Close()
+		if err != nil {
+			log.Errorf("Failed to compress file %s: %s", cfg.OutFile, err)
+			return err
+		}
+	}
+	err = bufferedWriter.Flush()
+	if err != nil {
+		log.Errorf("Failed to write to file %s: %s", cfg.OutFile, err)
+		return err
+	}
+
+	log.Infof("Exported a total of %d blocks (%d bytes)",
+		results.blocksExported, results.bytesExported)
+	return nil
+}
+
+func main() {
+	// Use all processor cores and up some limits.
+	runtime.GOMAXPROCS(runtime.NumCPU())
+	if err := limits.SetLimits(); err != nil {
+		os.Exit(1)
+	}
+
+	// Work around defer not working after os.Exit()
+	if err := realMain(); err != nil {
+		os.Exit(1)
+	}
+}
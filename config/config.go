@@ -8,6 +8,7 @@ import (
 	"bufio"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net"
@@ -22,11 +23,13 @@ import (
 
 	"github.com/btcsuite/go-socks/socks"
 	"github.com/jessevdk/go-flags"
+	"github.com/kaspanet/kaspad/database"
 	"github.com/kaspanet/kaspad/logger"
 	"github.com/kaspanet/kaspad/util"
 	"github.com/kaspanet/kaspad/util/network"
 	"github.com/kaspanet/kaspad/util/subnetworkid"
 	"github.com/kaspanet/kaspad/version"
+	"github.com/kaspanet/kaspad/wire"
 )
 
 const (
@@ -38,6 +41,7 @@ const (
 	defaultErrLogFilename      = "kaspad_err.log"
 	defaultTargetOutboundPeers = 8
 	defaultMaxInboundPeers     = 117
+	defaultBlockRelayOnlyPeers = 2
 	defaultBanDuration         = time.Hour * 24
 	defaultBanThreshold        = 100
 	//DefaultConnectTimeout is the default connection timeout when dialing
@@ -50,11 +54,31 @@ const (
 	blockMaxMassMax              = 10000000
 	defaultMinRelayTxFee         = 1e-5 // 1 sompi per byte
 	defaultMaxOrphanTransactions = 100
+	defaultMaxReplacements       = 100
+	defaultMaxMempool            = 5000000000
+	defaultMempoolExpiry         = time.Hour * 24
 	//DefaultMaxOrphanTxSize is the default maximum size for an orphan transaction
 	DefaultMaxOrphanTxSize = 100000
 	defaultSigCacheMaxSize = 100000
 	sampleConfigFilename   = "sample-kaspad.conf"
 	defaultAcceptanceIndex = false
+	defaultTxIndex         = false
+	defaultAddrIndex       = false
+	defaultPruneDepth      = 86400
+	defaultDbType          = "ffldb"
+	maxCheckDBLevel        = 3
+	defaultP2PEncryption   = P2PEncryptionDisabled
+
+	// p2pIdentityPublicKeySize is the size of a serialized compressed
+	// public key of a peer identity.
+	p2pIdentityPublicKeySize = 33
+)
+
+// The supported values of the --p2pencryption option.
+const (
+	P2PEncryptionDisabled  = "disabled"
+	P2PEncryptionPreferred = "preferred"
+	P2PEncryptionRequired  = "required"
 )
 
 var (
@@ -88,6 +112,7 @@ type Flags struct {
 	Listeners            []string      `long:"listen" description:"Add an interface/port to listen for connections (default all interfaces port: 16111, testnet: 16211)"`
 	TargetOutboundPeers  int           `long:"outpeers" description:"Target number of outbound peers"`
 	MaxInboundPeers      int           `long:"maxinpeers" description:"Max number of inbound peers"`
+	BlockRelayOnlyPeers  int           `long:"blockrelayonlypeers" description:"Number of additional outbound peers with which only blocks are exchanged, and never transactions or addresses"`
 	DisableBanning       bool          `long:"nobanning" description:"Disable banning of misbehaving peers"`
 	BanDuration          time.Duration `long:"banduration" description:"How long to ban misbehaving peers. Valid time units are {s, m, h}. Minimum 1 second"`
 	BanThreshold         uint32        `long:"banthreshold" description:"Maximum allowed ban score before disconnecting and banning misbehaving peers."`
@@ -110,22 +135,42 @@ type Flags struct {
 	Proxy                string        `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
 	ProxyUser            string        `long:"proxyuser" description:"Username for proxy server"`
 	ProxyPass            string        `long:"proxypass" default-mask:"-" description:"Password for proxy server"`
-	DbType               string        `long:"dbtype" description:"Database backend to use for the Block DAG"`
+	OverlayProxies       []string      `long:"overlayproxy" description:"Connect to peers in an overlay network via its SOCKS5 proxy, in the form of <network>=[<username>:<password>@]<host:port> (eg. onion=127.0.0.1:9050) -- Supported networks are onion and i2p. May be specified multiple times. Onion addresses are connected to via --proxy, with --proxyuser and --proxypass, when no onion proxy is specified"`
+	HiddenServices       []string      `long:"hiddenservice" description:"Advertise an overlay network address that forwards incoming connections to one of the --listen interfaces, in the form of <host>[:port] (eg. an onion service) -- May be specified multiple times"`
+	DbType               string        `long:"dbtype" description:"Database backend to use for the Block DAG -- ffldb, logdb or memdb, which is lost on shutdown"`
 	Profile              string        `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
 	CPUProfile           string        `long:"cpuprofile" description:"Write CPU profile to the specified file"`
 	DebugLevel           string        `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
 	Upnp                 bool          `long:"upnp" description:"Use UPnP to map our listening port outside of NAT"`
 	MinRelayTxFee        float64       `long:"minrelaytxfee" description:"The minimum transaction fee in KAS/kB to be considered a non-zero fee."`
 	MaxOrphanTxs         int           `long:"maxorphantx" description:"Max number of orphan transactions to keep in memory"`
+	AcceptReplacement    bool          `long:"acceptreplacement" description:"Accept transactions that replace transactions in the memory pool which signal replaceability, if they pay a higher fee"`
+	MaxReplacements      int           `long:"maxreplacements" description:"Max number of memory pool transactions, including descendants, a single replacement transaction may evict"`
+	MaxMempool           uint64        `long:"maxmempool" description:"Max total mass of the transactions in the memory pool -- The transactions with the lowest fee rates are evicted once it's exceeded. 0 means no limit"`
+	PersistMempool       bool          `long:"persistmempool" description:"Save the memory pool to the database on shutdown and load it back on startup"`
+	MempoolExpiry        time.Duration `long:"mempoolexpiry" description:"Remove transactions, along with their descendants, from the memory pool once they stay in it for this long -- Valid time units are {s, m, h}. 0 means transactions never expire"`
 	BlockMaxMass         uint64        `long:"blockmaxmass" description:"Maximum transaction mass to be used when creating a block"`
 	UserAgentComments    []string      `long:"uacomment" description:"Comment to add to the user agent -- See BIP 14 for more information."`
 	NoPeerBloomFilters   bool          `long:"nopeerbloomfilters" description:"Disable bloom filtering support"`
 	SigCacheMaxSize      uint          `long:"sigcachemaxsize" description:"The maximum number of entries in the signature verification cache"`
 	BlocksOnly           bool          `long:"blocksonly" description:"Do not accept transactions from remote peers."`
+	HeadersFirst         bool          `long:"headersfirst" description:"During the initial block download, download and validate the headers of the missing blocks before their bodies"`
 	AcceptanceIndex      bool          `long:"acceptanceindex" description:"Maintain a full hash-based acceptance index which makes the getChainFromBlock RPC available"`
 	DropAcceptanceIndex  bool          `long:"dropacceptanceindex" description:"Deletes the hash-based acceptance index from the database on start up and then exits."`
+	TxIndex              bool          `long:"txindex" description:"Maintain a full hash-based transaction index which makes all transactions available via the getRawTransaction RPC"`
+	DropTxIndex          bool          `long:"droptxindex" description:"Deletes the hash-based transaction index from the database on start up and then exits."`
+	AddrIndex            bool          `long:"addrindex" description:"Maintain a full address-based transaction index which makes the getAddressTransactions, getAddressBalance and getAddressUTXOs RPCs available"`
+	DropAddrIndex        bool          `long:"dropaddrindex" description:"Deletes the address-based transaction index from the database on start up and then exits."`
+	Prune                bool          `long:"prune" description:"Delete the bodies, fee data and acceptance data of blocks that are deep below the last finality point -- Pruned blocks can no longer be served to peers or over RPC. May not be used with --txindex or --addrindex"`
+	PruneDepth           uint64        `long:"prunedepth" description:"The blue score depth below the last finality point from which blocks are pruned when --prune is set -- May not be less than the finality interval"`
+	LoadSnapshot         string        `long:"loadsnapshot" description:"Load the UTXO snapshot in the given file, which was written by the dumpUTXOSnapshot RPC, into the empty database on start up -- The snapshot is verified against the UTXO commitment of its finality point, and the blocks below it are treated as pruned. May not be used with --acceptanceindex, --txindex or --addrindex"`
 	RelayNonStd          bool          `long:"relaynonstd" description:"Relay non-standard transactions regardless of the default settings for the active network."`
 	RejectNonStd         bool          `long:"rejectnonstd" description:"Reject non-standard transactions regardless of the default settings for the active network."`
+	CheckDB              int           `long:"checkdb" optional:"yes" optional-value:"2" description:"Check the integrity of the database on start up, repair what can be repaired and then exit -- Level 1 checks the block index, the block store, the reachability data and the acceptance index, level 2 also verifies every block body and level 3 also verifies the UTXO set"`
+	CheckDBNoRepair      bool          `long:"checkdbnorepair" description:"Only report the inconsistencies that are found by --checkdb without repairing them"`
+	P2PEncryption        string        `long:"p2pencryption" description:"Whether to encrypt the connections to peers {disabled, preferred, required} -- preferred falls back to plaintext for peers that don't support encryption, and required disconnects them"`
+	P2PIdentity          bool          `long:"p2pidentity" description:"Identify this node to peers over encrypted connections with a persistent key, which is created in the data directory"`
+	P2PAllowedIdentities []string      `long:"p2pallowedidentity" description:"Only allow peers that identify with the given hex-encoded public key over encrypted connections -- May be specified multiple times. Requires --p2pencryption=required"`
 	ResetDatabase        bool          `long:"reset-db" description:"Reset database before starting node. It's needed when switching between subnetworks."`
 	NetworkFlags
 }
@@ -141,6 +186,14 @@ type Config struct {
 	MinRelayTxFee util.Amount
 	Whitelists    []*net.IPNet
 	SubnetworkID  *subnetworkid.SubnetworkID // nil in full nodes
+
+	// P2PAllowedIdentities are the parsed public keys of
+	// Flags.P2PAllowedIdentities.
+	P2PAllowedIdentities [][]byte
+
+	// OverlayProxies are the SOCKS5 proxies through which addresses of
+	// overlay networks are dialed, by their network.
+	OverlayProxies map[wire.NetworkID]*socks.Proxy
 }
 
 // serviceOptions defines the configuration options for the daemon as a service on
@@ -163,6 +216,17 @@ func cleanAndExpandPath(path string) string {
 	return filepath.Clean(os.ExpandEnv(path))
 }
 
+// isSupportedDbType returns whether or not the passed database type is
+// registered with the database package.
+func isSupportedDbType(dbType string) bool {
+	for _, supportedType := range database.SupportedDrivers() {
+		if dbType == supportedType {
+			return true
+		}
+	}
+	return false
+}
+
 // newConfigParser returns a new command line flags parser.
 func newConfigParser(cfgFlags *Flags, so *serviceOptions, options flags.Options) *flags.Parser {
 	parser := flags.NewParser(cfgFlags, options)
@@ -212,6 +276,7 @@ func loadConfig() (*Config, []string, error) {
 		DebugLevel:           defaultLogLevel,
 		TargetOutboundPeers:  defaultTargetOutboundPeers,
 		MaxInboundPeers:      defaultMaxInboundPeers,
+		BlockRelayOnlyPeers:  defaultBlockRelayOnlyPeers,
 		BanDuration:          defaultBanDuration,
 		BanThreshold:         defaultBanThreshold,
 		RPCMaxClients:        defaultMaxRPCClients,
@@ -223,9 +288,17 @@ func loadConfig() (*Config, []string, error) {
 		RPCCert:              defaultRPCCertFile,
 		BlockMaxMass:         defaultBlockMaxMass,
 		MaxOrphanTxs:         defaultMaxOrphanTransactions,
+		MaxReplacements:      defaultMaxReplacements,
+		MaxMempool:           defaultMaxMempool,
+		MempoolExpiry:        defaultMempoolExpiry,
 		SigCacheMaxSize:      defaultSigCacheMaxSize,
 		MinRelayTxFee:        defaultMinRelayTxFee,
 		AcceptanceIndex:      defaultAcceptanceIndex,
+		TxIndex:              defaultTxIndex,
+		AddrIndex:            defaultAddrIndex,
+		PruneDepth:           defaultPruneDepth,
+		DbType:               defaultDbType,
+		P2PEncryption:        defaultP2PEncryption,
 	}
 
 	// Service options which are only added on Windows.
@@ -419,6 +492,15 @@ func loadConfig() (*Config, []string, error) {
 		}
 	}
 
+	// The number of block relay only peers can't be negative.
+	if activeConfig.BlockRelayOnlyPeers < 0 {
+		str := "%s: The blockrelayonlypeers option may not be negative -- parsed [%d]"
+		err := errors.Errorf(str, funcName, activeConfig.BlockRelayOnlyPeers)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Don't allow ban durations that are too short.
 	if activeConfig.BanDuration < time.Second {
 		str := "%s: The banduration option may not be less than 1s -- parsed [%s]"
@@ -470,9 +552,20 @@ func loadConfig() (*Config, []string, error) {
 		return nil, nil, err
 	}
 
-	// --proxy or --connect without --listen disables listening.
+	// Hidden services can only forward connections when listening.
+	if len(activeConfig.HiddenServices) > 0 && activeConfig.DisableListen {
+		str := "%s: the --hiddenservice and --nolisten options can not be " +
+			"mixed"
+		err := errors.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --proxy or --connect without --listen disables listening, unless
+	// there are hidden services to forward connections.
 	if (activeConfig.Proxy != "" || len(activeConfig.ConnectPeers) > 0) &&
-		len(activeConfig.Listeners) == 0 {
+		len(activeConfig.Listeners) == 0 && len(activeConfig.HiddenServices) == 0 {
 		activeConfig.DisableListen = true
 	}
 
@@ -584,6 +677,37 @@ func loadConfig() (*Config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Limit the max replacement count to a sane value.
+	if activeConfig.MaxReplacements < 0 {
+		str := "%s: The maxreplacements option may not be less than 0 " +
+			"-- parsed [%d]"
+		err := errors.Errorf(str, funcName, activeConfig.MaxReplacements)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Don't allow negative mempool expiry.
+	if activeConfig.MempoolExpiry < 0 {
+		str := "%s: The mempoolexpiry option may not be less than 0 " +
+			"-- parsed [%s]"
+		err := errors.Errorf(str, funcName, activeConfig.MempoolExpiry)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Validate the database type.
+	if !isSupportedDbType(activeConfig.DbType) {
+		str := "%s: The specified database type [%s] is invalid -- " +
+			"supported types are %s"
+		err := errors.Errorf(str, funcName, activeConfig.DbType,
+			database.SupportedDrivers())
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Look for illegal characters in the user agent comments.
 	for _, uaComment := range activeConfig.UserAgentComments {
 		if strings.ContainsAny(uaComment, "/:()") {
@@ -606,6 +730,137 @@ func loadConfig() (*Config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --txindex and --droptxindex do not mix.
+	if activeConfig.TxIndex && activeConfig.DropTxIndex {
+		err := errors.Errorf("%s: the --txindex and --droptxindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --addrindex and --dropaddrindex do not mix.
+	if activeConfig.AddrIndex && activeConfig.DropAddrIndex {
+		err := errors.Errorf("%s: the --addrindex and --dropaddrindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --checkdb must be a valid integrity check level.
+	if activeConfig.CheckDB < 0 || activeConfig.CheckDB > maxCheckDBLevel {
+		str := "%s: the --checkdb level must be between 1 and %d -- parsed [%d]"
+		err := errors.Errorf(str, funcName, maxCheckDBLevel, activeConfig.CheckDB)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --checkdbnorepair requires --checkdb.
+	if activeConfig.CheckDBNoRepair && activeConfig.CheckDB == 0 {
+		err := errors.Errorf("%s: the --checkdbnorepair option requires "+
+			"the --checkdb option", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Validate the encryption mode of the connections to peers.
+	switch activeConfig.P2PEncryption {
+	case P2PEncryptionDisabled, P2PEncryptionPreferred, P2PEncryptionRequired:
+	default:
+		str := "%s: The specified p2pencryption mode [%s] is invalid -- " +
+			"supported modes are %s, %s and %s"
+		err := errors.Errorf(str, funcName, activeConfig.P2PEncryption,
+			P2PEncryptionDisabled, P2PEncryptionPreferred, P2PEncryptionRequired)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --p2pidentity is only used over encrypted connections.
+	if activeConfig.P2PIdentity && activeConfig.P2PEncryption == P2PEncryptionDisabled {
+		err := errors.Errorf("%s: the --p2pidentity option requires "+
+			"the --p2pencryption option to be %s or %s", funcName,
+			P2PEncryptionPreferred, P2PEncryptionRequired)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Validate any given allowed peer identities. Peers may only identify
+	// themselves over encrypted connections, so encryption must be
+	// required.
+	if len(activeConfig.Flags.P2PAllowedIdentities) > 0 {
+		if activeConfig.P2PEncryption != P2PEncryptionRequired {
+			err := errors.Errorf("%s: the --p2pallowedidentity option "+
+				"requires --p2pencryption=%s", funcName, P2PEncryptionRequired)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		activeConfig.P2PAllowedIdentities = make([][]byte, 0, len(activeConfig.Flags.P2PAllowedIdentities))
+		for _, identity := range activeConfig.Flags.P2PAllowedIdentities {
+			publicKey, err := hex.DecodeString(identity)
+			if err != nil || len(publicKey) != p2pIdentityPublicKeySize {
+				str := "%s: The p2pallowedidentity value of '%s' is invalid " +
+					"-- it must be a hex-encoded %d bytes compressed public key"
+				err = errors.Errorf(str, funcName, identity, p2pIdentityPublicKeySize)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, nil, err
+			}
+			activeConfig.P2PAllowedIdentities = append(activeConfig.P2PAllowedIdentities, publicKey)
+		}
+	}
+
+	// --prune and --txindex do not mix.
+	if activeConfig.Prune && activeConfig.TxIndex {
+		err := errors.Errorf("%s: the --prune and --txindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --prune and --addrindex do not mix.
+	if activeConfig.Prune && activeConfig.AddrIndex {
+		err := errors.Errorf("%s: the --prune and --addrindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --loadsnapshot does not mix with the optional indexes, since the
+	// blocks below the finality point of the snapshot are missing.
+	if activeConfig.LoadSnapshot != "" &&
+		(activeConfig.AcceptanceIndex || activeConfig.TxIndex || activeConfig.AddrIndex) {
+
+		err := errors.Errorf("%s: the --loadsnapshot option may not be "+
+			"activated together with --acceptanceindex, --txindex or "+
+			"--addrindex", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Validate the prune depth.
+	if activeConfig.Prune && activeConfig.PruneDepth < activeConfig.NetParams().FinalityInterval {
+		str := "%s: The prunedepth option may not be less than the " +
+			"finality interval %d -- parsed [%d]"
+		err := errors.Errorf(str, funcName, activeConfig.NetParams().FinalityInterval,
+			activeConfig.PruneDepth)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Add default port to all listener addresses if needed and remove
 	// duplicate addresses.
 	activeConfig.Listeners, err = network.NormalizeAddresses(activeConfig.Listeners,
@@ -673,6 +928,7 @@ func loadConfig() (*Config, []string, error) {
 	// dial function.
 	activeConfig.Dial = net.DialTimeout
 	activeConfig.Lookup = net.LookupIP
+	var proxy *socks.Proxy
 	if activeConfig.Proxy != "" {
 		_, _, err := net.SplitHostPort(activeConfig.Proxy)
 		if err != nil {
@@ -683,7 +939,7 @@ func loadConfig() (*Config, []string, error) {
 			return nil, nil, err
 		}
 
-		proxy := &socks.Proxy{
+		proxy = &socks.Proxy{
 			Addr:     activeConfig.Proxy,
 			Username: activeConfig.ProxyUser,
 			Password: activeConfig.ProxyPass,
@@ -691,6 +947,27 @@ func loadConfig() (*Config, []string, error) {
 		activeConfig.Dial = proxy.DialTimeout
 	}
 
+	// Overlay network addresses are dialed through the proxy of their
+	// network.
+	activeConfig.OverlayProxies, err = parseOverlayProxies(activeConfig.Flags.OverlayProxies,
+		proxy)
+	if err != nil {
+		err := errors.Errorf("%s: %s", funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	activeConfig.Dial = newOverlayDial(activeConfig.Dial, activeConfig.OverlayProxies)
+
+	activeConfig.HiddenServices, err = parseHiddenServices(activeConfig.HiddenServices,
+		activeConfig.NetParams().DefaultPort)
+	if err != nil {
+		err := errors.Errorf("%s: %s", funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Warn about missing config file only after all other configuration is
 	// done. This prevents the warning on help messages and invalid
 	// options. Note this should go directly before the return.
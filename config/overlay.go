@@ -0,0 +1,115 @@
+package config
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/go-socks/socks"
+	"github.com/kaspanet/kaspad/wire"
+	"github.com/pkg/errors"
+)
+
+// dialFunc is the signature of Config.Dial.
+type dialFunc func(network, address string, timeout time.Duration) (net.Conn, error)
+
+// parseOverlayProxies parses the values of the --overlayproxy option, which
+// are in the form of <network>=[<username>:<password>@]<host:port>, into a map
+// from the overlay network to its SOCKS5 proxy. Every proxy has its own
+// credentials, if any. When no proxy is given for onion addresses, they are
+// dialed through defaultOnionProxy, if it's not nil.
+func parseOverlayProxies(overlayProxies []string,
+	defaultOnionProxy *socks.Proxy) (map[wire.NetworkID]*socks.Proxy, error) {
+
+	overlayNetworks := make(map[string]wire.NetworkID, len(wire.OverlayNetworks))
+	for _, network := range wire.OverlayNetworks {
+		overlayNetworks[network.String()] = network
+	}
+
+	proxies := make(map[wire.NetworkID]*socks.Proxy, len(overlayProxies))
+	for _, overlayProxy := range overlayProxies {
+		parts := strings.SplitN(overlayProxy, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("overlay proxy '%s' is not in the form "+
+				"of <network>=[<username>:<password>@]<host:port>", overlayProxy)
+		}
+		network, ok := overlayNetworks[strings.ToLower(parts[0])]
+		if !ok {
+			return nil, errors.Errorf("unknown overlay network '%s' -- "+
+				"supported networks are onion and i2p", parts[0])
+		}
+		if _, ok := proxies[network]; ok {
+			return nil, errors.Errorf("more than one proxy is specified "+
+				"for the %s network", network)
+		}
+
+		proxy := &socks.Proxy{Addr: parts[1]}
+		if separatorIndex := strings.LastIndex(parts[1], "@"); separatorIndex != -1 {
+			credentials := strings.SplitN(parts[1][:separatorIndex], ":", 2)
+			if len(credentials) != 2 || credentials[0] == "" {
+				return nil, errors.Errorf("credentials of the %s network proxy "+
+					"are not in the form of <username>:<password>", network)
+			}
+			proxy.Username, proxy.Password = credentials[0], credentials[1]
+			proxy.Addr = parts[1][separatorIndex+1:]
+		}
+		_, _, err := net.SplitHostPort(proxy.Addr)
+		if err != nil {
+			return nil, errors.Errorf("proxy address '%s' of the %s network "+
+				"is invalid: %s", proxy.Addr, network, err)
+		}
+		proxies[network] = proxy
+	}
+
+	if _, ok := proxies[wire.NetworkTorV3]; !ok && defaultOnionProxy != nil {
+		proxies[wire.NetworkTorV3] = defaultOnionProxy
+	}
+	return proxies, nil
+}
+
+// newOverlayDial returns a dial function that dials overlay network addresses
+// through the SOCKS5 proxy of their network, and any other address with the
+// given dial function.
+func newOverlayDial(dial dialFunc, proxies map[wire.NetworkID]*socks.Proxy) dialFunc {
+	return func(network, address string, timeout time.Duration) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil || !wire.IsOverlayHost(host) {
+			return dial(network, address, timeout)
+		}
+
+		overlay, err := wire.ParseOverlayAddress(host)
+		if err != nil {
+			return nil, err
+		}
+		proxy, ok := proxies[overlay.Network]
+		if !ok {
+			return nil, errors.Errorf("cannot connect to %s: no proxy is "+
+				"configured for the %s network", address, overlay.Network)
+		}
+		return proxy.DialTimeout(network, address, timeout)
+	}
+}
+
+// parseHiddenServices parses the values of the --hiddenservice option into
+// overlay network addresses in the form of host:port. defaultPort is used
+// for the ones that don't specify a port.
+func parseHiddenServices(hiddenServices []string, defaultPort string) ([]string, error) {
+	parsed := make([]string, 0, len(hiddenServices))
+	for _, hiddenService := range hiddenServices {
+		host, port, err := net.SplitHostPort(hiddenService)
+		if err != nil {
+			host, port = hiddenService, defaultPort
+		}
+		overlay, err := wire.ParseOverlayAddress(host)
+		if err != nil {
+			return nil, errors.Wrapf(err, "hidden service '%s' is invalid", hiddenService)
+		}
+		_, err = strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			return nil, errors.Errorf("port of hidden service '%s' is invalid", hiddenService)
+		}
+		parsed = append(parsed, net.JoinHostPort(overlay.String(), port))
+	}
+	return parsed, nil
+}
@@ -0,0 +1,393 @@
+package config
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/go-socks/socks"
+	"github.com/kaspanet/kaspad/wire"
+	"github.com/pkg/errors"
+)
+
+const (
+	testOnionHost = "2gzyxa5ihm7nsggfxnu52rck2vv4rvmdlkiu3zzui5du4xyclen53wid.onion"
+	testI2PHost   = "udhdrtrcetjm5sxzskjyr5ztpeszydbh4dpl3pl4utgqqw2v4jna.b32.i2p"
+)
+
+// socksStandIn is a minimal SOCKS5 proxy that records the addresses it's
+// asked to connect to, along with the credentials it's given, and echoes back
+// whatever is sent over the connections instead of actually connecting to
+// them.
+type socksStandIn struct {
+	listener    net.Listener
+	requested   chan string
+	credentials chan string
+}
+
+func newSOCKSStandIn(t *testing.T) *socksStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %s", err)
+	}
+	s := &socksStandIn{
+		listener:    listener,
+		requested:   make(chan string, 10),
+		credentials: make(chan string, 10),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *socksStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+
+	// Greeting: version, number of auth methods and the methods. The
+	// username and password method is chosen if it's offered, and
+	// connecting without authentication otherwise.
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return
+	}
+	if !bytes.Contains(methods, []byte{0x02}) {
+		if _, err := conn.Write([]byte{0x05, 0x00}); err != nil {
+			return
+		}
+	} else {
+		if _, err := conn.Write([]byte{0x05, 0x02}); err != nil {
+			return
+		}
+
+		// Authentication: version, the username length, the username,
+		// the password length and the password.
+		usernameLength := make([]byte, 2)
+		if _, err := io.ReadFull(conn, usernameLength); err != nil {
+			return
+		}
+		username := make([]byte, usernameLength[1])
+		if _, err := io.ReadFull(conn, username); err != nil {
+			return
+		}
+		passwordLength := make([]byte, 1)
+		if _, err := io.ReadFull(conn, passwordLength); err != nil {
+			return
+		}
+		password := make([]byte, passwordLength[0])
+		if _, err := io.ReadFull(conn, password); err != nil {
+			return
+		}
+		s.credentials <- string(username) + ":" + string(password)
+		if _, err := conn.Write([]byte{0x01, 0x00}); err != nil {
+			return
+		}
+	}
+
+	// Connect request: version, command, reserved, the domain address
+	// type, the domain length, the domain and the port.
+	request := make([]byte, 5)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return
+	}
+	if request[3] != 0x03 {
+		conn.Write([]byte{0x05, 0x08, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	domainAndPort := make([]byte, int(request[4])+2)
+	if _, err := io.ReadFull(conn, domainAndPort); err != nil {
+		return
+	}
+	domain := string(domainAndPort[:request[4]])
+	port := int(domainAndPort[request[4]])<<8 | int(domainAndPort[request[4]+1])
+	s.requested <- net.JoinHostPort(domain, strconv.Itoa(port))
+
+	if _, err := conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+	io.Copy(conn, conn)
+}
+
+func (s *socksStandIn) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *socksStandIn) close() {
+	s.listener.Close()
+}
+
+// TestOverlayDial tests that overlay network addresses are dialed through the
+// proxy of their network, and other addresses are dialed directly.
+func TestOverlayDial(t *testing.T) {
+	onionProxy := newSOCKSStandIn(t)
+	defer onionProxy.close()
+
+	proxies, err := parseOverlayProxies([]string{"onion=" + onionProxy.addr()}, nil)
+	if err != nil {
+		t.Fatalf("parseOverlayProxies: %s", err)
+	}
+
+	var directlyDialed []string
+	directDial := func(network, address string, timeout time.Duration) (net.Conn, error) {
+		directlyDialed = append(directlyDialed, address)
+		return nil, errors.New("direct dial")
+	}
+	dial := newOverlayDial(directDial, proxies)
+
+	// Ensure onion addresses go through the proxy, which gets the hostname
+	// rather than a resolved address.
+	onionAddress := net.JoinHostPort(testOnionHost, "16111")
+	conn, err := dial("tcp", onionAddress, time.Second)
+	if err != nil {
+		t.Fatalf("dial %s: %s", onionAddress, err)
+	}
+	defer conn.Close()
+	select {
+	case requested := <-onionProxy.requested:
+		if requested != onionAddress {
+			t.Errorf("proxy was asked to connect to %s, want %s",
+				requested, onionAddress)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("proxy was not asked to connect")
+	}
+
+	// Ensure the connection is usable.
+	message := []byte("version")
+	if _, err := conn.Write(message); err != nil {
+		t.Fatalf("Write: %s", err)
+	}
+	echoed := make([]byte, len(message))
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadFull(conn, echoed); err != nil {
+		t.Fatalf("Read: %s", err)
+	}
+	if string(echoed) != string(message) {
+		t.Errorf("read %q through the proxy, want %q", echoed, message)
+	}
+
+	// Ensure overlay networks without a proxy can't be dialed.
+	_, err = dial("tcp", net.JoinHostPort(testI2PHost, "16111"), time.Second)
+	if err == nil {
+		t.Errorf("dialing an i2p address without an i2p proxy unexpectedly succeeded")
+	}
+
+	// Ensure invalid overlay addresses are rejected rather than dialed.
+	_, err = dial("tcp", "invalid.onion:16111", time.Second)
+	if err == nil {
+		t.Errorf("dialing an invalid onion address unexpectedly succeeded")
+	}
+
+	// Ensure other addresses are dialed directly.
+	dial("tcp", "127.0.0.1:16111", time.Second)
+	dial("tcp", "seeder.example.com:16111", time.Second)
+	if len(directlyDialed) != 2 {
+		t.Errorf("dialed %v directly, want only the non-overlay addresses",
+			directlyDialed)
+	}
+	if len(onionProxy.requested) != 0 {
+		t.Errorf("proxy was asked to connect to %s, want no more requests",
+			<-onionProxy.requested)
+	}
+	if len(onionProxy.credentials) != 0 {
+		t.Errorf("proxy was given credentials %s, want none",
+			<-onionProxy.credentials)
+	}
+}
+
+// TestOverlayDialCredentials tests that every overlay network proxy is given
+// its own credentials, and that the credentials of the default onion proxy
+// are not given to the other proxies.
+func TestOverlayDialCredentials(t *testing.T) {
+	onionProxy := newSOCKSStandIn(t)
+	defer onionProxy.close()
+	i2pProxy := newSOCKSStandIn(t)
+	defer i2pProxy.close()
+
+	defaultOnionProxy := &socks.Proxy{Addr: onionProxy.addr(), Username: "default", Password: "secret"}
+	proxies, err := parseOverlayProxies([]string{"i2p=" + i2pProxy.addr()}, defaultOnionProxy)
+	if err != nil {
+		t.Fatalf("parseOverlayProxies: %s", err)
+	}
+	directDial := func(network, address string, timeout time.Duration) (net.Conn, error) {
+		return nil, errors.New("direct dial")
+	}
+	dial := newOverlayDial(directDial, proxies)
+
+	tests := []struct {
+		name                string
+		host                string
+		proxy               *socksStandIn
+		expectedCredentials string
+	}{
+		{name: "onion", host: testOnionHost, proxy: onionProxy, expectedCredentials: "default:secret"},
+		{name: "i2p", host: testI2PHost, proxy: i2pProxy},
+	}
+	for _, test := range tests {
+		conn, err := dial("tcp", net.JoinHostPort(test.host, "16111"), time.Second)
+		if err != nil {
+			t.Fatalf("%s: dial: %s", test.name, err)
+		}
+		conn.Close()
+
+		credentials := ""
+		if len(test.proxy.credentials) != 0 {
+			credentials = <-test.proxy.credentials
+		}
+		if credentials != test.expectedCredentials {
+			t.Errorf("%s: proxy was given credentials %q, want %q",
+				test.name, credentials, test.expectedCredentials)
+		}
+	}
+
+	// Ensure a proxy with its own credentials is given them.
+	proxies, err = parseOverlayProxies([]string{"i2p=user:pass@" + i2pProxy.addr()}, defaultOnionProxy)
+	if err != nil {
+		t.Fatalf("parseOverlayProxies: %s", err)
+	}
+	dial = newOverlayDial(directDial, proxies)
+	conn, err := dial("tcp", net.JoinHostPort(testI2PHost, "16111"), time.Second)
+	if err != nil {
+		t.Fatalf("dial: %s", err)
+	}
+	conn.Close()
+	select {
+	case credentials := <-i2pProxy.credentials:
+		if credentials != "user:pass" {
+			t.Errorf("i2p proxy was given credentials %q, want %q", credentials, "user:pass")
+		}
+	default:
+		t.Errorf("i2p proxy was not given credentials")
+	}
+}
+
+// TestParseOverlayProxies tests parsing the --overlayproxy option.
+func TestParseOverlayProxies(t *testing.T) {
+	tests := []struct {
+		name              string
+		overlayProxies    []string
+		defaultOnionProxy *socks.Proxy
+		expected          map[wire.NetworkID]socks.Proxy
+		expectedErr       bool
+	}{
+		{
+			name:           "onion and i2p",
+			overlayProxies: []string{"onion=127.0.0.1:9050", "I2P=127.0.0.1:4447"},
+			expected: map[wire.NetworkID]socks.Proxy{
+				wire.NetworkTorV3: {Addr: "127.0.0.1:9050"},
+				wire.NetworkI2P:   {Addr: "127.0.0.1:4447"},
+			},
+		},
+		{
+			name:              "onion falls back to --proxy",
+			overlayProxies:    []string{"i2p=127.0.0.1:4447"},
+			defaultOnionProxy: &socks.Proxy{Addr: "127.0.0.1:9150", Username: "user", Password: "pass"},
+			expected: map[wire.NetworkID]socks.Proxy{
+				wire.NetworkTorV3: {Addr: "127.0.0.1:9150", Username: "user", Password: "pass"},
+				wire.NetworkI2P:   {Addr: "127.0.0.1:4447"},
+			},
+		},
+		{
+			name:              "explicit onion proxy overrides --proxy",
+			overlayProxies:    []string{"onion=127.0.0.1:9050"},
+			defaultOnionProxy: &socks.Proxy{Addr: "127.0.0.1:9150", Username: "user", Password: "pass"},
+			expected: map[wire.NetworkID]socks.Proxy{
+				wire.NetworkTorV3: {Addr: "127.0.0.1:9050"},
+			},
+		},
+		{
+			name:           "credentials",
+			overlayProxies: []string{"onion=tor:p@ss:word@127.0.0.1:9050", "i2p=i2p:@127.0.0.1:4447"},
+			expected: map[wire.NetworkID]socks.Proxy{
+				wire.NetworkTorV3: {Addr: "127.0.0.1:9050", Username: "tor", Password: "p@ss:word"},
+				wire.NetworkI2P:   {Addr: "127.0.0.1:4447", Username: "i2p"},
+			},
+		},
+		{
+			name:           "credentials without a password",
+			overlayProxies: []string{"onion=tor@127.0.0.1:9050"},
+			expectedErr:    true,
+		},
+		{
+			name:           "credentials without a username",
+			overlayProxies: []string{"onion=:pass@127.0.0.1:9050"},
+			expectedErr:    true,
+		},
+		{
+			name:           "unknown network",
+			overlayProxies: []string{"ipv4=127.0.0.1:9050"},
+			expectedErr:    true,
+		},
+		{
+			name:           "missing network",
+			overlayProxies: []string{"127.0.0.1:9050"},
+			expectedErr:    true,
+		},
+		{
+			name:           "invalid proxy address",
+			overlayProxies: []string{"onion=127.0.0.1"},
+			expectedErr:    true,
+		},
+		{
+			name:           "duplicate network",
+			overlayProxies: []string{"onion=127.0.0.1:9050", "onion=127.0.0.1:9150"},
+			expectedErr:    true,
+		},
+	}
+
+	for _, test := range tests {
+		proxies, err := parseOverlayProxies(test.overlayProxies, test.defaultOnionProxy)
+		if test.expectedErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if len(proxies) != len(test.expected) {
+			t.Errorf("%s: got proxies %v, want %v", test.name, proxies, test.expected)
+			continue
+		}
+		for network, proxy := range test.expected {
+			if proxies[network] == nil || *proxies[network] != proxy {
+				t.Errorf("%s: got proxies %v, want %v", test.name, proxies, test.expected)
+				break
+			}
+		}
+	}
+}
+
+// TestParseHiddenServices tests parsing the --hiddenservice option.
+func TestParseHiddenServices(t *testing.T) {
+	parsed, err := parseHiddenServices([]string{testOnionHost, testI2PHost + ":16211"}, "16111")
+	if err != nil {
+		t.Fatalf("parseHiddenServices: unexpected error: %s", err)
+	}
+	expected := []string{testOnionHost + ":16111", testI2PHost + ":16211"}
+	if len(parsed) != len(expected) || parsed[0] != expected[0] || parsed[1] != expected[1] {
+		t.Errorf("parseHiddenServices: got %v, want %v", parsed, expected)
+	}
+
+	for _, invalid := range []string{"127.0.0.1:16111", "invalid.onion", testOnionHost + ":port"} {
+		_, err := parseHiddenServices([]string{invalid}, "16111")
+		if err == nil {
+			t.Errorf("parseHiddenServices: expected an error for %s", invalid)
+		}
+	}
+}
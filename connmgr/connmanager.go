@@ -7,6 +7,7 @@ package connmgr
 import (
 	nativeerrors "errors"
 	"fmt"
+	"github.com/btcsuite/go-socks/socks"
 	"github.com/kaspanet/kaspad/addrmgr"
 	"github.com/kaspanet/kaspad/config"
 	"github.com/kaspanet/kaspad/wire"
@@ -79,9 +80,13 @@ type ConnReq struct {
 	// The following variables must only be used atomically.
 	id uint64
 
-	Addr      *net.TCPAddr
+	Addr      net.Addr
 	Permanent bool
 
+	// BlockRelayOnly specifies whether only blocks should be relayed over
+	// the connection, and never transactions or addresses.
+	BlockRelayOnly bool
+
 	conn       net.Conn
 	state      ConnState
 	stateMtx   sync.RWMutex
@@ -145,6 +150,13 @@ type Config struct {
 	// maintain. Defaults to 8.
 	TargetOutbound uint32
 
+	// TargetBlockRelayOnlyOutbound is the number of additional outbound
+	// network connections to maintain, over which only blocks are relayed.
+	// These connections are harder for an attacker to learn about, since
+	// they don't reveal themselves by relaying transactions or addresses.
+	// Defaults to 0.
+	TargetBlockRelayOnlyOutbound uint32
+
 	// RetryDuration is the duration to wait before retrying connection
 	// requests. Defaults to 5s.
 	RetryDuration time.Duration
@@ -165,6 +177,11 @@ type Config struct {
 
 	// Dial connects to the address on the named network. It cannot be nil.
 	Dial func(net.Addr) (net.Conn, error)
+
+	// OverlayProxies are the SOCKS5 proxies through which Dial connects
+	// to addresses of overlay networks, by their network. Addresses of
+	// overlay networks without a proxy are not connected to.
+	OverlayProxies map[wire.NetworkID]*socks.Proxy
 }
 
 // registerPending is used to register a pending connection attempt. By
@@ -252,14 +269,18 @@ func (cm *ConnManager) handleFailedConn(c *ConnReq, err error) {
 					"-- retrying further connections every %s", maxFailedAttempts,
 					cm.cfg.RetryDuration)
 			}
-			spawnAfter(cm.cfg.RetryDuration, cm.NewConnReq)
+			spawnAfter(cm.cfg.RetryDuration, func() {
+				cm.newConnReq(c.BlockRelayOnly)
+			})
 		} else {
-			spawn(cm.NewConnReq)
+			spawn(func() {
+				cm.newConnReq(c.BlockRelayOnly)
+			})
 		}
 	}
 }
 
-func (cm *ConnManager) releaseAddress(addr *net.TCPAddr) {
+func (cm *ConnManager) releaseAddress(addr net.Addr) {
 	cm.addressMtx.Lock()
 	defer cm.addressMtx.Unlock()
 
@@ -271,28 +292,36 @@ func (cm *ConnManager) releaseAddress(addr *net.TCPAddr) {
 	delete(cm.usedAddresses, usedAddressesKey(addr))
 }
 
-func (cm *ConnManager) markAddressAsUsed(addr *net.TCPAddr) {
+func (cm *ConnManager) markAddressAsUsed(addr net.Addr) {
 	cm.usedOutboundGroups[usedOutboundGroupsKey(addr)]++
 	cm.usedAddresses[usedAddressesKey(addr)] = struct{}{}
 }
 
-func (cm *ConnManager) isOutboundGroupUsed(addr *net.TCPAddr) bool {
+func (cm *ConnManager) isOutboundGroupUsed(addr net.Addr) bool {
 	_, ok := cm.usedOutboundGroups[usedOutboundGroupsKey(addr)]
 	return ok
 }
 
-func (cm *ConnManager) isAddressUsed(addr *net.TCPAddr) bool {
+func (cm *ConnManager) isAddressUsed(addr net.Addr) bool {
 	_, ok := cm.usedAddresses[usedAddressesKey(addr)]
 	return ok
 }
 
-func usedOutboundGroupsKey(addr *net.TCPAddr) string {
+func usedOutboundGroupsKey(addr net.Addr) string {
 	// A fake service flag is used since it doesn't affect the group key.
-	na := wire.NewNetAddress(addr, wire.SFNodeNetwork)
+	var na *wire.NetAddress
+	switch addr := addr.(type) {
+	case *net.TCPAddr:
+		na = wire.NewNetAddress(addr, wire.SFNodeNetwork)
+	case *wire.OverlayTCPAddr:
+		na = wire.NewNetAddressOverlay(&addr.Overlay, uint16(addr.Port), wire.SFNodeNetwork)
+	default:
+		return addr.String()
+	}
 	return addrmgr.GroupKey(na)
 }
 
-func usedAddressesKey(addr *net.TCPAddr) string {
+func usedAddressesKey(addr net.Addr) string {
 	return addr.String()
 }
 
@@ -350,7 +379,8 @@ func (cm *ConnManager) connHandler() {
 		pending = make(map[uint64]*ConnReq)
 
 		// conns represents the set of all actively connected peers.
-		conns = make(map[uint64]*ConnReq, cm.cfg.TargetOutbound)
+		conns = make(map[uint64]*ConnReq,
+			cm.cfg.TargetOutbound+cm.cfg.TargetBlockRelayOnlyOutbound)
 	)
 
 out:
@@ -485,11 +515,21 @@ func (cm *ConnManager) NotifyConnectionRequestComplete() {
 // NewConnReq creates a new connection request and connects to the
 // corresponding address.
 func (cm *ConnManager) NewConnReq() {
+	cm.newConnReq(false)
+}
+
+// NewBlockRelayOnlyConnReq creates a new connection request over which only
+// blocks are relayed, and connects to the corresponding address.
+func (cm *ConnManager) NewBlockRelayOnlyConnReq() {
+	cm.newConnReq(true)
+}
+
+func (cm *ConnManager) newConnReq(blockRelayOnly bool) {
 	if atomic.LoadInt32(&cm.stop) != 0 {
 		return
 	}
 
-	c := &ConnReq{}
+	c := &ConnReq{BlockRelayOnly: blockRelayOnly}
 	atomic.StoreUint64(&c.id, atomic.AddUint64(&cm.connReqCount, 1))
 
 	// Submit a request of a pending connection attempt to the connection
@@ -657,6 +697,12 @@ func (cm *ConnManager) listenHandler(listener net.Listener) {
 	log.Tracef("Listener handler done for %s", listener.Addr())
 }
 
+// IsActive returns whether the connection manager has been started and has
+// not been stopped yet.
+func (cm *ConnManager) IsActive() bool {
+	return atomic.LoadInt32(&cm.start) != 0 && atomic.LoadInt32(&cm.stop) == 0
+}
+
 // Start launches the connection manager and begins connecting to the network.
 func (cm *ConnManager) Start() {
 	// Already started?
@@ -685,6 +731,9 @@ func (cm *ConnManager) Start() {
 	for i := atomic.LoadUint64(&cm.connReqCount); i < uint64(cm.cfg.TargetOutbound); i++ {
 		spawn(cm.NewConnReq)
 	}
+	for i := uint32(0); i < cm.cfg.TargetBlockRelayOnlyOutbound; i++ {
+		spawn(cm.NewBlockRelayOnlyConnReq)
+	}
 }
 
 // Wait blocks until the connection manager halts gracefully.
@@ -711,15 +760,23 @@ func (cm *ConnManager) Stop() {
 	log.Trace("Connection manager stopped")
 }
 
-func (cm *ConnManager) getNewAddress() (*net.TCPAddr, error) {
+func (cm *ConnManager) getNewAddress() (net.Addr, error) {
 	for tries := 0; tries < 100; tries++ {
 		addr := cm.cfg.AddrManager.GetAddress()
 		if addr == nil {
 			break
 		}
 
+		// Overlay network addresses can only be connected to through
+		// the proxy of their network.
+		if overlay := addr.NetAddress().Overlay; overlay != nil {
+			if _, ok := cm.cfg.OverlayProxies[overlay.Network]; !ok {
+				continue
+			}
+		}
+
 		// Check if there's already a connection to the same address.
-		netAddr := addr.NetAddress().TCPAddress()
+		netAddr := addr.NetAddress().NetAddr()
 		if cm.isAddressUsed(netAddr) {
 			continue
 		}
@@ -745,7 +802,7 @@ func (cm *ConnManager) getNewAddress() (*net.TCPAddr, error) {
 		}
 
 		// allow nondefault ports after 50 failed tries.
-		if tries < 50 && fmt.Sprintf("%d", netAddr.Port) !=
+		if tries < 50 && fmt.Sprintf("%d", addr.NetAddress().Port) !=
 			config.ActiveConfig().NetParams().DefaultPort {
 			continue
 		}
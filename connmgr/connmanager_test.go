@@ -311,6 +311,71 @@ func TestTargetOutbound(t *testing.T) {
 	cmgr.Wait()
 }
 
+// TestTargetBlockRelayOnlyOutbound tests the target number of block relay only
+// outbound connections, and that they are replaced by new block relay only
+// connections when they're disconnected.
+func TestTargetBlockRelayOnlyOutbound(t *testing.T) {
+	restoreConfig := overrideActiveConfig()
+	defer restoreConfig()
+
+	const numAddressesInAddressManager = 10
+	targetOutbound := uint32(2)
+	targetBlockRelayOnlyOutbound := uint32(2)
+	connected := make(chan *ConnReq)
+
+	amgr, teardown := addressManagerForTest(t, "TestTargetBlockRelayOnlyOutbound", numAddressesInAddressManager)
+	defer teardown()
+
+	cmgr, err := New(&Config{
+		TargetOutbound:               targetOutbound,
+		TargetBlockRelayOnlyOutbound: targetBlockRelayOnlyOutbound,
+		Dial:                         mockDialer,
+		AddrManager:                  amgr,
+		OnConnection: func(c *ConnReq, conn net.Conn) {
+			connected <- c
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error from New: %s", err)
+	}
+	cmgr.Start()
+	var blockRelayOnly []*ConnReq
+	for i := uint32(0); i < targetOutbound+targetBlockRelayOnlyOutbound; i++ {
+		c := <-connected
+		if c.BlockRelayOnly {
+			blockRelayOnly = append(blockRelayOnly, c)
+		}
+	}
+	if uint32(len(blockRelayOnly)) != targetBlockRelayOnlyOutbound {
+		t.Fatalf("target block relay only outbound: got %d block relay only "+
+			"connections, want %d", len(blockRelayOnly), targetBlockRelayOnlyOutbound)
+	}
+
+	select {
+	case c := <-connected:
+		t.Fatalf("target block relay only outbound: got unexpected connection - %v", c.Addr)
+	case <-time.After(time.Millisecond):
+		break
+	}
+
+	// Ensure a disconnected block relay only connection is replaced by
+	// another block relay only connection.
+	cmgr.Disconnect(blockRelayOnly[0].ID())
+	select {
+	case c := <-connected:
+		if !c.BlockRelayOnly {
+			t.Fatalf("target block relay only outbound: a block relay only " +
+				"connection was replaced by a full relay connection")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("target block relay only outbound: a disconnected block " +
+			"relay only connection wasn't replaced")
+	}
+
+	cmgr.Stop()
+	cmgr.Wait()
+}
+
 // TestDuplicateOutboundConnections tests that connection requests cannot use an already used address.
 // It checks it by creating one connection request for each address in the address manager, so that
 // the next connection request will have to fail because no unused address will be available.
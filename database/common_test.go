@@ -2,29 +2,41 @@ package database_test
 
 import (
 	"fmt"
-	"github.com/kaspanet/kaspad/database"
-	"github.com/kaspanet/kaspad/database/ffldb"
 	"io/ioutil"
+	"os"
 	"testing"
+
+	"github.com/kaspanet/kaspad/database"
+	_ "github.com/kaspanet/kaspad/database/ffldb"
+	_ "github.com/kaspanet/kaspad/database/logdb"
+	_ "github.com/kaspanet/kaspad/database/memdb"
 )
 
 type databasePrepareFunc func(t *testing.T, testName string) (db database.Database, name string, teardownFunc func())
 
-// databasePrepareFuncs is a set of functions, in which each function
-// prepares a separate database type for testing.
+// databasePrepareFuncs returns a set of functions, in which each
+// function prepares a separate registered database type for testing.
 // See testForAllDatabaseTypes for further details.
-var databasePrepareFuncs = []databasePrepareFunc{
-	prepareFFLDBForTest,
+func databasePrepareFuncs() []databasePrepareFunc {
+	dbTypes := database.SupportedDrivers()
+	prepareFuncs := make([]databasePrepareFunc, len(dbTypes))
+	for i, dbType := range dbTypes {
+		dbType := dbType
+		prepareFuncs[i] = func(t *testing.T, testName string) (database.Database, string, func()) {
+			return prepareDatabaseForTest(t, dbType, testName)
+		}
+	}
+	return prepareFuncs
 }
 
-func prepareFFLDBForTest(t *testing.T, testName string) (db database.Database, name string, teardownFunc func()) {
+func prepareDatabaseForTest(t *testing.T, dbType string, testName string) (db database.Database, name string, teardownFunc func()) {
 	// Create a temp db to run tests against
 	path, err := ioutil.TempDir("", testName)
 	if err != nil {
 		t.Fatalf("%s: TempDir unexpectedly "+
 			"failed: %s", testName, err)
 	}
-	db, err = ffldb.Open(path)
+	db, err = database.Open(dbType, path)
 	if err != nil {
 		t.Fatalf("%s: Open unexpectedly "+
 			"failed: %s", testName, err)
@@ -35,18 +47,19 @@ func prepareFFLDBForTest(t *testing.T, testName string) (db database.Database, n
 			t.Fatalf("%s: Close unexpectedly "+
 				"failed: %s", testName, err)
 		}
+		os.RemoveAll(path)
 	}
-	return db, "ffldb", teardownFunc
+	return db, dbType, teardownFunc
 }
 
 // testForAllDatabaseTypes runs the given testFunc for every database
-// type defined in databasePrepareFuncs. This is to make sure that
+// type registered with database.RegisterDriver. This is to make sure that
 // all supported database types adhere to the assumptions defined in
 // the interfaces in this package.
 func testForAllDatabaseTypes(t *testing.T, testName string,
 	testFunc func(t *testing.T, db database.Database, testName string)) {
 
-	for _, prepareDatabase := range databasePrepareFuncs {
+	for _, prepareDatabase := range databasePrepareFuncs() {
 		func() {
 			db, dbType, teardownFunc := prepareDatabase(t, testName)
 			defer teardownFunc()
@@ -14,6 +14,16 @@ type Database interface {
 	// Begin begins a new database transaction.
 	Begin() (Transaction, error)
 
+	// PruneStore deletes the data in the store defined by storeName
+	// that was appended before all the data at the given location
+	// handles. It's meant to reclaim the space of data that is no longer
+	// referenced, so the given locations must include the earliest one
+	// that is still referenced. Nothing is deleted if no locations are
+	// given. Implementations may keep some of the data before these
+	// locations, for example when it shares a file with data that is
+	// still referenced.
+	PruneStore(storeName string, keptLocations [][]byte) error
+
 	// Close closes the database.
 	Close() error
 }
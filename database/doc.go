@@ -6,8 +6,17 @@ Overview
 This package provides a database layer to store and retrieve data in a simple
 and efficient manner.
 
-The current backend is ffldb, which makes use of leveldb, flat files, and strict
-checksums in key areas to ensure data integrity.
+Backends register themselves with RegisterDriver under a database type, and are
+opened by that type with Open. The following backends are available:
+
+ffldb, the default, which makes use of leveldb, flat files, and strict checksums
+in key areas to ensure data integrity.
+
+logdb, which makes use of a log-structured key-value store with checksummed
+records, and of the same flat files as ffldb. It doesn't depend on leveldb.
+
+memdb, which keeps all of its data in memory. It's meant for tests and for
+ephemeral nodes.
 
 Implementors of additional backends are required to implement the following interfaces:
 
@@ -0,0 +1,16 @@
+package ffldb
+
+import "github.com/kaspanet/kaspad/database"
+
+// DbType is the type of the ffldb backend, as used by the --dbtype flag.
+const DbType = "ffldb"
+
+func init() {
+	err := database.RegisterDriver(database.Driver{
+		DbType: DbType,
+		Open:   Open,
+	})
+	if err != nil {
+		panic(err)
+	}
+}
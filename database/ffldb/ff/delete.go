@@ -0,0 +1,71 @@
+package ff
+
+import (
+	"github.com/pkg/errors"
+	"os"
+)
+
+// deleteBefore deletes all the flat files that only contain data that was
+// written before the provided location. Since data is only ever appended to
+// the store, these are all the files that precede the file of the provided
+// location. That file itself is never deleted, and neither is the file that
+// is currently written to.
+//
+// Files are always deleted from the beginning of the store, so the remaining
+// files always have consecutive file numbers.
+func (s *flatFileStore) deleteBefore(location *flatFileLocation) error {
+	if s.isClosed {
+		return errors.Errorf("cannot delete from a closed store %s",
+			s.storeName)
+	}
+
+	// Grab the write cursor mutex so that the write cursor doesn't move
+	// while files are deleted.
+	s.writeCursor.Lock()
+	defer s.writeCursor.Unlock()
+
+	if s.writeCursor.currentFileNumber < location.fileNumber {
+		return errors.Errorf("location in file %d is greater than "+
+			"the current write cursor", location.fileNumber)
+	}
+
+	s.lruMutex.Lock()
+	defer s.lruMutex.Unlock()
+	s.openFilesMutex.Lock()
+	defer s.openFilesMutex.Unlock()
+
+	// Delete the files in descending order, and stop at the first file
+	// that doesn't exist, since all the files before it were already
+	// deleted.
+	deletedFiles := 0
+	for fileNumber := location.fileNumber; fileNumber > 0; {
+		fileNumber--
+		filePath := flatFilePath(s.basePath, s.storeName, fileNumber)
+		_, err := os.Stat(filePath)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = s.deleteFile(fileNumber)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete file number "+
+				"%d in store '%s'", fileNumber, s.storeName)
+		}
+		deletedFiles++
+	}
+
+	if deletedFiles > 0 {
+		log.Debugf("Deleted %d files before file %d in store '%s'",
+			deletedFiles, location.fileNumber, s.storeName)
+	}
+	return nil
+}
+
+// isBefore returns whether the data at the location was written before the
+// data at the other location.
+func (location *flatFileLocation) isBefore(other *flatFileLocation) bool {
+	return location.fileNumber < other.fileNumber ||
+		(location.fileNumber == other.fileNumber && location.fileOffset < other.fileOffset)
+}
@@ -18,13 +18,13 @@ const (
 	maxOpenFiles = 25
 )
 
-var (
-	// maxFileSize is the maximum size for each file used to store data.
+const (
+	// DefaultMaxFileSize is the default maximum size for each file used
+	// to store data.
 	//
 	// NOTE: The current code uses uint32 for all offsets, so this value
 	// must be less than 2^32 (4 GiB).
-	// NOTE: This is a var rather than a const for testing purposes.
-	maxFileSize uint32 = 512 * 1024 * 1024 // 512 MiB
+	DefaultMaxFileSize uint32 = 512 * 1024 * 1024 // 512 MiB
 )
 
 var (
@@ -56,6 +56,10 @@ type flatFileStore struct {
 	// storeName is the name of this flat-file store.
 	storeName string
 
+	// maxFileSize is the maximum size for each file used to store data.
+	// It must be less than 2^32 (4 GiB), since all offsets are uint32.
+	maxFileSize uint32
+
 	// The following fields are related to the flat files which hold the
 	// actual data. The number of open files is limited by maxOpenFiles.
 	//
@@ -135,7 +139,7 @@ type writeCursor struct {
 
 // openFlatFileStore returns a new flat file store with the current file number
 // and offset set and all fields initialized.
-func openFlatFileStore(basePath string, storeName string) (*flatFileStore, error) {
+func openFlatFileStore(basePath string, storeName string, maxFileSize uint32) (*flatFileStore, error) {
 	// Look for the end of the latest file to determine what the write cursor
 	// position is from the viewpoint of the flat files on disk.
 	fileNumber, fileOffset, err := findCurrentLocation(basePath, storeName)
@@ -146,6 +150,7 @@ func openFlatFileStore(basePath string, storeName string) (*flatFileStore, error
 	store := &flatFileStore{
 		basePath:               basePath,
 		storeName:              storeName,
+		maxFileSize:            maxFileSize,
 		openFiles:              make(map[uint32]*lockableFile),
 		openFilesLRU:           list.New(),
 		fileNumberToLRUElement: make(map[uint32]*list.Element),
@@ -195,25 +200,35 @@ func (s *flatFileStore) currentLocation() *flatFileLocation {
 
 // findCurrentLocation searches the database directory for all flat files for a given
 // store to find the end of the most recent file. This position is considered
-// the current write cursor.
+// the current write cursor. Files at the beginning of the store may have been
+// deleted, so the most recent file is the one with the highest file number.
 func findCurrentLocation(dbPath string, storeName string) (fileNumber uint32, fileLength uint32, err error) {
-	currentFileNumber := uint32(0)
-	currentFileLength := uint32(0)
-	for {
-		currentFilePath := flatFilePath(dbPath, storeName, currentFileNumber)
-		stat, err := os.Stat(currentFilePath)
+	filePaths, err := filepath.Glob(filepath.Join(dbPath, storeName+"-*.fdb"))
+	if err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+
+	found := false
+	for _, filePath := range filePaths {
+		var currentFileNumber uint32
+		_, err := fmt.Sscanf(filepath.Base(filePath), storeName+"-%09d.fdb", &currentFileNumber)
+		if err != nil || filePath != flatFilePath(dbPath, storeName, currentFileNumber) {
+			// The file belongs to another store whose name
+			// starts with this store's name.
+			continue
+		}
+		if !found || currentFileNumber > fileNumber {
+			fileNumber = currentFileNumber
+			found = true
+		}
+	}
+
+	if found {
+		stat, err := os.Stat(flatFilePath(dbPath, storeName, fileNumber))
 		if err != nil {
-			if !os.IsNotExist(err) {
-				return 0, 0, errors.WithStack(err)
-			}
-			if currentFileNumber > 0 {
-				fileNumber = currentFileNumber - 1
-			}
-			fileLength = currentFileLength
-			break
+			return 0, 0, errors.WithStack(err)
 		}
-		currentFileLength = uint32(stat.Size())
-		currentFileNumber++
+		fileLength = uint32(stat.Size())
 	}
 
 	log.Tracef("Scan for store '%s' found latest file #%d with length %d",
@@ -17,7 +17,7 @@ func prepareStoreForTest(t *testing.T, testName string) (store *flatFileStore, t
 			"failed: %s", testName, err)
 	}
 	name := "test"
-	store, err = openFlatFileStore(path, name)
+	store, err = openFlatFileStore(path, name, DefaultMaxFileSize)
 	if err != nil {
 		t.Fatalf("%s: openFlatFileStore "+
 			"unexpectedly failed: %s", testName, err)
@@ -96,11 +96,7 @@ func TestFlatFileMultiFileRollback(t *testing.T) {
 	// Set the maxFileSize to 16 bytes so that we don't have to write
 	// an enormous amount of data to disk to get multiple files, all
 	// for the sake of this test.
-	currentMaxFileSize := maxFileSize
-	maxFileSize = 16
-	defer func() {
-		maxFileSize = currentMaxFileSize
-	}()
+	store.maxFileSize = 16
 
 	// Write five 8 byte chunks and keep the last location written to
 	var lastWriteLocation1 *flatFileLocation
@@ -120,7 +116,7 @@ func TestFlatFileMultiFileRollback(t *testing.T) {
 
 	// Write (2 * maxOpenFiles) more 8 byte chunks and keep the last location written to
 	var lastWriteLocation2 *flatFileLocation
-	for i := byte(0); i < byte(2*maxFileSize); i++ {
+	for i := byte(0); i < byte(2*store.maxFileSize); i++ {
 		writeData := []byte{0, 1, 2, 3, 4, 5, 6, 7}
 		var err error
 		lastWriteLocation2, err = store.write(writeData)
@@ -173,3 +169,86 @@ func TestFlatFileMultiFileRollback(t *testing.T) {
 		}
 	}
 }
+
+func TestFlatFileDeleteBefore(t *testing.T) {
+	store, teardownFunc := prepareStoreForTest(t, "TestFlatFileDeleteBefore")
+	defer teardownFunc()
+
+	// Set the maxFileSize to 16 bytes so that we don't have to write
+	// an enormous amount of data to disk to get multiple files, all
+	// for the sake of this test.
+	store.maxFileSize = 16
+
+	// Write ten 8 byte chunks and keep all the locations written to
+	writeLocations := make([]*flatFileLocation, 10)
+	for i := byte(0); i < 10; i++ {
+		writeData := []byte{i, i, i, i, i, i, i, i}
+		var err error
+		writeLocations[i], err = store.write(writeData)
+		if err != nil {
+			t.Fatalf("TestFlatFileDeleteBefore: write returned "+
+				"unexpected error: %s", err)
+		}
+	}
+	deleteLocation := writeLocations[5]
+	if deleteLocation.fileNumber == 0 {
+		t.Fatalf("TestFlatFileDeleteBefore: all the data " +
+			"was unexpectedly written to the first file")
+	}
+
+	err := store.deleteBefore(deleteLocation)
+	if err != nil {
+		t.Fatalf("TestFlatFileDeleteBefore: deleteBefore returned "+
+			"unexpected error: %s", err)
+	}
+
+	// Make sure that all the data in files before the delete location's
+	// file no longer exists, and that all the data after it still does
+	for i, location := range writeLocations {
+		data, err := store.read(location)
+		if location.fileNumber < deleteLocation.fileNumber {
+			if !database.IsNotFoundError(err) {
+				t.Fatalf("TestFlatFileDeleteBefore: read of "+
+					"deleted location %d returned unexpected error: %v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("TestFlatFileDeleteBefore: read returned "+
+				"unexpected error: %s", err)
+		}
+		expectedData := []byte{byte(i), byte(i), byte(i), byte(i), byte(i), byte(i), byte(i), byte(i)}
+		if !bytes.Equal(data, expectedData) {
+			t.Fatalf("TestFlatFileDeleteBefore: read returned "+
+				"unexpected data. Want: %v, got: %v", expectedData, data)
+		}
+	}
+
+	// Deleting before the same location again should do nothing
+	err = store.deleteBefore(deleteLocation)
+	if err != nil {
+		t.Fatalf("TestFlatFileDeleteBefore: second deleteBefore returned "+
+			"unexpected error: %s", err)
+	}
+
+	// Make sure that the current location is found again once the
+	// store is reopened, even though the first files are missing
+	reopenedStore, err := openFlatFileStore(store.basePath, store.storeName, store.maxFileSize)
+	if err != nil {
+		t.Fatalf("TestFlatFileDeleteBefore: openFlatFileStore returned "+
+			"unexpected error: %s", err)
+	}
+	defer func() {
+		err := reopenedStore.Close()
+		if err != nil {
+			t.Fatalf("TestFlatFileDeleteBefore: Close returned "+
+				"unexpected error: %s", err)
+		}
+	}()
+	currentLocation := store.currentLocation()
+	reopenedLocation := reopenedStore.currentLocation()
+	if *reopenedLocation != *currentLocation {
+		t.Fatalf("TestFlatFileDeleteBefore: unexpected current location "+
+			"after reopening. Want: %v, got: %v", currentLocation, reopenedLocation)
+	}
+}
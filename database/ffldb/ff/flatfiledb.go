@@ -5,14 +5,18 @@ package ff
 // details.
 type FlatFileDB struct {
 	path           string
+	maxFileSize    uint32
 	flatFileStores map[string]*flatFileStore
 }
 
 // NewFlatFileDB opens the flat-file database defined by
-// the given path.
-func NewFlatFileDB(path string) *FlatFileDB {
+// the given path. Each of its stores splits its data into
+// files of at most maxFileSize bytes, which must be less
+// than 2^32 (4 GiB). See DefaultMaxFileSize.
+func NewFlatFileDB(path string, maxFileSize uint32) *FlatFileDB {
 	return &FlatFileDB{
 		path:           path,
+		maxFileSize:    maxFileSize,
 		flatFileStores: make(map[string]*flatFileStore),
 	}
 }
@@ -89,11 +93,43 @@ func (ffdb *FlatFileDB) Rollback(storeName string, serializedLocation []byte) er
 	return store.rollback(location)
 }
 
+// DeleteBefore deletes the data in the flat-file store defined by the
+// given storeName that was written before the location defined by the
+// given serialized location handle. Data is deleted a whole file at a
+// time, so data that shares a file with the given location is kept.
+// See flatFileStore.deleteBefore() for further details.
+func (ffdb *FlatFileDB) DeleteBefore(storeName string, serializedLocation []byte) error {
+	store, err := ffdb.store(storeName)
+	if err != nil {
+		return err
+	}
+	location, err := deserializeLocation(serializedLocation)
+	if err != nil {
+		return err
+	}
+	return store.deleteBefore(location)
+}
+
+// IsBefore returns whether the data at the location defined by the
+// given serialized location handle was written before the data at the
+// location defined by the other given serialized location handle.
+func IsBefore(serializedLocation []byte, otherSerializedLocation []byte) (bool, error) {
+	location, err := deserializeLocation(serializedLocation)
+	if err != nil {
+		return false, err
+	}
+	otherLocation, err := deserializeLocation(otherSerializedLocation)
+	if err != nil {
+		return false, err
+	}
+	return location.isBefore(otherLocation), nil
+}
+
 func (ffdb *FlatFileDB) store(storeName string) (*flatFileStore, error) {
 	store, ok := ffdb.flatFileStores[storeName]
 	if !ok {
 		var err error
-		store, err = openFlatFileStore(ffdb.path, storeName)
+		store, err = openFlatFileStore(ffdb.path, storeName, ffdb.maxFileSize)
 		if err != nil {
 			return nil, err
 		}
@@ -111,6 +111,11 @@ func (s *flatFileStore) openFile(fileNumber uint32) (*lockableFile, error) {
 	// Open the appropriate file as read-only.
 	filePath := flatFilePath(s.basePath, s.storeName, fileNumber)
 	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		// The file was deleted along with the data in it.
+		return nil, errors.Wrapf(database.ErrNotFound, "file %d in "+
+			"store '%s' was deleted", fileNumber, s.storeName)
+	}
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -116,8 +116,7 @@ func (s *flatFileStore) rollback(targetLocation *flatFileLocation) error {
 func (s *flatFileStore) deleteFile(fileNumber uint32) error {
 	// Cleanup the file before deleting it
 	if file, ok := s.openFiles[fileNumber]; ok {
-		file.Lock()
-		defer file.Unlock()
+		// Close locks the file by itself
 		err := file.Close()
 		if err != nil {
 			return err
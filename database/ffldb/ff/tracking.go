@@ -0,0 +1,141 @@
+package ff
+
+import (
+	"github.com/kaspanet/kaspad/database"
+	"github.com/pkg/errors"
+)
+
+var (
+	// flatFilesBucket keeps an index flat-file stores and their
+	// current locations. Among other things, it is used to repair
+	// the database in case a corruption occurs.
+	flatFilesBucket = database.MakeBucket([]byte("flat-files"))
+)
+
+// AppendToStore appends the given data to the flat file store defined by
+// storeName, and saves the new current location of the store using the
+// given accessor, which is the key-value side of the database the store
+// belongs to. This allows Repair to sync the store with the key-value data
+// in case the two diverge. It returns a serialized location handle that's
+// meant to be stored and later used when querying the data that has just
+// now been inserted.
+func (ffdb *FlatFileDB) AppendToStore(accessor database.DataAccessor, storeName string, data []byte) ([]byte, error) {
+	// Save a reference to the current location in case
+	// we fail and need to rollback.
+	previousLocation, err := ffdb.CurrentLocation(storeName)
+	if err != nil {
+		return nil, err
+	}
+	rollback := func() error {
+		return ffdb.Rollback(storeName, previousLocation)
+	}
+
+	// Append the data to the store and rollback in case of an error.
+	location, err := ffdb.Write(storeName, data)
+	if err != nil {
+		rollbackErr := rollback()
+		if rollbackErr != nil {
+			return nil, errors.Wrapf(err, "error occurred during rollback: %s", rollbackErr)
+		}
+		return nil, err
+	}
+
+	// Get the new location. If this fails we won't be able to update
+	// the current store location, in which case we roll back.
+	currentLocation, err := ffdb.CurrentLocation(storeName)
+	if err != nil {
+		rollbackErr := rollback()
+		if rollbackErr != nil {
+			return nil, errors.Wrapf(err, "error occurred during rollback: %s", rollbackErr)
+		}
+		return nil, err
+	}
+
+	// Set the current store location and roll back in case an error.
+	err = SetCurrentStoreLocation(accessor, storeName, currentLocation)
+	if err != nil {
+		rollbackErr := rollback()
+		if rollbackErr != nil {
+			return nil, errors.Wrapf(err, "error occurred during rollback: %s", rollbackErr)
+		}
+		return nil, err
+	}
+
+	return location, err
+}
+
+// SetCurrentStoreLocation saves the given location as the current location
+// of the flat file store defined by storeName using the given accessor.
+func SetCurrentStoreLocation(accessor database.DataAccessor, storeName string, location []byte) error {
+	locationKey := flatFilesBucket.Key([]byte(storeName))
+	return accessor.Put(locationKey, location)
+}
+
+// Repair syncs every flat file store with the current location that was
+// saved for it using the given accessor. If a store is ahead of its saved
+// location, it's truncated. If a store is behind its saved location, an
+// error is returned, since this indicates definite database corruption,
+// which is irrecoverable.
+func (ffdb *FlatFileDB) Repair(accessor database.DataAccessor) error {
+	currentLocations, err := currentStoreLocations(accessor)
+	if err != nil {
+		return err
+	}
+	for storeName, currentLocation := range currentLocations {
+		err := ffdb.Rollback(storeName, currentLocation)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func currentStoreLocations(accessor database.DataAccessor) (map[string][]byte, error) {
+	flatFilesCursor, err := accessor.Cursor(flatFilesBucket)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := flatFilesCursor.Close()
+		if err != nil {
+			log.Warnf("cursor failed to close")
+		}
+	}()
+
+	currentLocations := make(map[string][]byte)
+	for flatFilesCursor.Next() {
+		storeNameKey, err := flatFilesCursor.Key()
+		if err != nil {
+			return nil, err
+		}
+		storeName := string(storeNameKey.Suffix())
+
+		currentLocation, err := flatFilesCursor.Value()
+		if err != nil {
+			return nil, err
+		}
+		currentLocations[storeName] = append([]byte(nil), currentLocation...)
+	}
+	return currentLocations, nil
+}
+
+// PruneStore deletes the data in the flat file store defined by storeName
+// that was appended before all the data at the given location handles.
+// Nothing is deleted if no locations are given. Only whole flat files are
+// deleted.
+func (ffdb *FlatFileDB) PruneStore(storeName string, keptLocations [][]byte) error {
+	if len(keptLocations) == 0 {
+		return nil
+	}
+	earliestLocation := keptLocations[0]
+	for _, location := range keptLocations[1:] {
+		isBefore, err := IsBefore(location, earliestLocation)
+		if err != nil {
+			return err
+		}
+		if isBefore {
+			earliestLocation = location
+		}
+	}
+	return ffdb.DeleteBefore(storeName, earliestLocation)
+}
@@ -39,7 +39,7 @@ func (s *flatFileStore) write(data []byte) (*flatFileLocation, error) {
 	// only one at a time.
 	cursor := s.writeCursor
 	finalOffset := cursor.currentOffset + fullLength
-	if finalOffset < cursor.currentOffset || finalOffset > maxFileSize {
+	if finalOffset < cursor.currentOffset || finalOffset > s.maxFileSize {
 		// This is done under the write cursor lock since the curFileNum
 		// field is accessed elsewhere by readers.
 		//
@@ -7,13 +7,6 @@ import (
 	"github.com/pkg/errors"
 )
 
-var (
-	// flatFilesBucket keeps an index flat-file stores and their
-	// current locations. Among other things, it is used to repair
-	// the database in case a corruption occurs.
-	flatFilesBucket = database.MakeBucket([]byte("flat-files"))
-)
-
 // ffldb is a database utilizing LevelDB for key-value data and
 // flat-files for raw data storage.
 type ffldb struct {
@@ -23,7 +16,17 @@ type ffldb struct {
 
 // Open opens a new ffldb with the given path.
 func Open(path string) (database.Database, error) {
-	flatFileDB := ff.NewFlatFileDB(path)
+	db, err := open(path, ff.DefaultMaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// open opens a new ffldb with the given path, whose flat
+// files are at most maxFileSize bytes long.
+func open(path string, maxFileSize uint32) (*ffldb, error) {
+	flatFileDB := ff.NewFlatFileDB(path, maxFileSize)
 	levelDB, err := ldb.NewLevelDB(path)
 	if err != nil {
 		return nil, err
@@ -91,57 +94,7 @@ func (db *ffldb) Delete(key *database.Key) error {
 // that has just now been inserted.
 // This method is part of the DataAccessor interface.
 func (db *ffldb) AppendToStore(storeName string, data []byte) ([]byte, error) {
-	return appendToStore(db, db.flatFileDB, storeName, data)
-}
-
-func appendToStore(accessor database.DataAccessor, ffdb *ff.FlatFileDB, storeName string, data []byte) ([]byte, error) {
-	// Save a reference to the current location in case
-	// we fail and need to rollback.
-	previousLocation, err := ffdb.CurrentLocation(storeName)
-	if err != nil {
-		return nil, err
-	}
-	rollback := func() error {
-		return ffdb.Rollback(storeName, previousLocation)
-	}
-
-	// Append the data to the store and rollback in case of an error.
-	location, err := ffdb.Write(storeName, data)
-	if err != nil {
-		rollbackErr := rollback()
-		if rollbackErr != nil {
-			return nil, errors.Wrapf(err, "error occurred during rollback: %s", rollbackErr)
-		}
-		return nil, err
-	}
-
-	// Get the new location. If this fails we won't be able to update
-	// the current store location, in which case we roll back.
-	currentLocation, err := ffdb.CurrentLocation(storeName)
-	if err != nil {
-		rollbackErr := rollback()
-		if rollbackErr != nil {
-			return nil, errors.Wrapf(err, "error occurred during rollback: %s", rollbackErr)
-		}
-		return nil, err
-	}
-
-	// Set the current store location and roll back in case an error.
-	err = setCurrentStoreLocation(accessor, storeName, currentLocation)
-	if err != nil {
-		rollbackErr := rollback()
-		if rollbackErr != nil {
-			return nil, errors.Wrapf(err, "error occurred during rollback: %s", rollbackErr)
-		}
-		return nil, err
-	}
-
-	return location, err
-}
-
-func setCurrentStoreLocation(accessor database.DataAccessor, storeName string, location []byte) error {
-	locationKey := flatFilesBucket.Key([]byte(storeName))
-	return accessor.Put(locationKey, location)
+	return db.flatFileDB.AppendToStore(db, storeName, data)
 }
 
 // RetrieveFromStore retrieves data from the store defined by
@@ -153,6 +106,16 @@ func (db *ffldb) RetrieveFromStore(storeName string, location []byte) ([]byte, e
 	return db.flatFileDB.Read(storeName, location)
 }
 
+// PruneStore deletes the data in the flat file store
+// defined by storeName that was appended before all the
+// data at the given location handles. Nothing is deleted
+// if no locations are given. Only whole flat files are
+// deleted.
+// This method is part of the Database interface.
+func (db *ffldb) PruneStore(storeName string, keptLocations [][]byte) error {
+	return db.flatFileDB.PruneStore(storeName, keptLocations)
+}
+
 // Cursor begins a new cursor over the given bucket.
 // This method is part of the DataAccessor interface.
 func (db *ffldb) Cursor(bucket *database.Bucket) (database.Cursor, error) {
@@ -1,7 +1,9 @@
 package ffldb
 
 import (
+	"bytes"
 	"github.com/kaspanet/kaspad/database"
+	"github.com/kaspanet/kaspad/database/ffldb/ff"
 	"io/ioutil"
 	"reflect"
 	"testing"
@@ -82,9 +84,9 @@ func TestRepairFlatFiles(t *testing.T) {
 	}
 
 	// Manually update the current location to point to the first piece of data
-	err = setCurrentStoreLocation(ffldbInstance, storeName, oldCurrentLocation)
+	err = ff.SetCurrentStoreLocation(ffldbInstance, storeName, oldCurrentLocation)
 	if err != nil {
-		t.Fatalf("TestRepairFlatFiles: setCurrentStoreLocation "+
+		t.Fatalf("TestRepairFlatFiles: SetCurrentStoreLocation "+
 			"unexpectedly failed: %s", err)
 	}
 
@@ -129,3 +131,79 @@ func TestRepairFlatFiles(t *testing.T) {
 			"returned wrong error: %s", err)
 	}
 }
+
+func TestPruneStore(t *testing.T) {
+	// Create a temp db to run tests against. Use 64 byte flat files
+	// so that we don't have to write an enormous amount of data to
+	// get multiple files.
+	path, err := ioutil.TempDir("", "TestPruneStore")
+	if err != nil {
+		t.Fatalf("TestPruneStore: TempDir unexpectedly "+
+			"failed: %s", err)
+	}
+	db, err := open(path, 64)
+	if err != nil {
+		t.Fatalf("TestPruneStore: open unexpectedly "+
+			"failed: %s", err)
+	}
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("TestPruneStore: Close unexpectedly "+
+				"failed: %s", err)
+		}
+	}()
+
+	// Append ten entries of 16 bytes of data, which take 24 bytes
+	// each along with their length and checksum, so that each flat
+	// file holds two of them. Keep the locations of all the entries.
+	storeName := "test"
+	locations := make([][]byte, 10)
+	for i := range locations {
+		data := bytes.Repeat([]byte{byte(i)}, 16)
+		locations[i], err = db.AppendToStore(storeName, data)
+		if err != nil {
+			t.Fatalf("TestPruneStore: AppendToStore unexpectedly "+
+				"failed: %s", err)
+		}
+	}
+
+	// Pruning without any kept locations should do nothing
+	err = db.PruneStore(storeName, nil)
+	if err != nil {
+		t.Fatalf("TestPruneStore: PruneStore unexpectedly "+
+			"failed: %s", err)
+	}
+	_, err = db.RetrieveFromStore(storeName, locations[0])
+	if err != nil {
+		t.Fatalf("TestPruneStore: RetrieveFromStore unexpectedly "+
+			"failed after pruning without kept locations: %s", err)
+	}
+
+	// Prune the store while keeping entries 7, 5 and 9. Only the
+	// files before the file of entry 5 should be deleted.
+	err = db.PruneStore(storeName, [][]byte{locations[7], locations[5], locations[9]})
+	if err != nil {
+		t.Fatalf("TestPruneStore: PruneStore unexpectedly "+
+			"failed: %s", err)
+	}
+	for i, location := range locations {
+		data, err := db.RetrieveFromStore(storeName, location)
+		if i < 4 {
+			if !database.IsNotFoundError(err) {
+				t.Fatalf("TestPruneStore: RetrieveFromStore of pruned "+
+					"entry %d returned unexpected error: %v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("TestPruneStore: RetrieveFromStore of kept "+
+				"entry %d unexpectedly failed: %s", i, err)
+		}
+		expectedData := bytes.Repeat([]byte{byte(i)}, 16)
+		if !bytes.Equal(data, expectedData) {
+			t.Fatalf("TestPruneStore: RetrieveFromStore of entry %d "+
+				"returned unexpected data. Want: %x, got: %x", i, expectedData, data)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/syndtr/goleveldb/leveldb"
 	ldbErrors "github.com/syndtr/goleveldb/leveldb/errors"
+	"github.com/syndtr/goleveldb/leveldb/storage"
 )
 
 // LevelDB defines a thin wrapper around leveldb.
@@ -43,6 +44,19 @@ func NewLevelDB(path string) (*LevelDB, error) {
 	return db, nil
 }
 
+// NewMemoryLevelDB opens a leveldb instance that's kept entirely in
+// memory. Its data is lost once it's closed.
+func NewMemoryLevelDB() (*LevelDB, error) {
+	ldb, err := leveldb.Open(storage.NewMemStorage(), &memoryOptions)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	db := &LevelDB{
+		ldb: ldb,
+	}
+	return db, nil
+}
+
 // Close closes the leveldb instance.
 func (db *LevelDB) Close() error {
 	err := db.ldb.Close()
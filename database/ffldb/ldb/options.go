@@ -10,6 +10,13 @@ var (
 		DisableSeeksCompaction: true,
 	}
 
+	// memoryOptions are the options of in-memory leveldb
+	// instances. Their data is already in memory, so they
+	// have no use for a big block cache or write buffer.
+	memoryOptions = opt.Options{
+		Compression: opt.NoCompression,
+	}
+
 	// Options is a function that returns a leveldb
 	// opt.Options struct for opening a database.
 	// It's defined as a variable for the sake of testing.
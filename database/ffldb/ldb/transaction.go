@@ -4,6 +4,7 @@ import (
 	"github.com/kaspanet/kaspad/database"
 	"github.com/pkg/errors"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 // LevelDBTransaction is a thin wrapper around native leveldb
@@ -130,11 +131,17 @@ func (tx *LevelDBTransaction) Delete(key *database.Key) error {
 	return nil
 }
 
-// Cursor begins a new cursor over the given bucket.
+// Cursor begins a new cursor over the given bucket. The cursor
+// iterates over the snapshot of the transaction.
 func (tx *LevelDBTransaction) Cursor(bucket *database.Bucket) (*LevelDBCursor, error) {
 	if tx.isClosed {
 		return nil, errors.New("cannot open a cursor from a closed transaction")
 	}
 
-	return tx.db.Cursor(bucket), nil
+	ldbIterator := tx.snapshot.NewIterator(util.BytesPrefix(bucket.Path()), nil)
+	return &LevelDBCursor{
+		ldbIterator: ldbIterator,
+		bucket:      bucket,
+		isClosed:    false,
+	}, nil
 }
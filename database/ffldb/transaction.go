@@ -74,7 +74,7 @@ func (tx *transaction) AppendToStore(storeName string, data []byte) ([]byte, err
 		return nil, errors.New("cannot append to store on a closed transaction")
 	}
 
-	return appendToStore(tx, tx.ffdb, storeName, data)
+	return tx.ffdb.AppendToStore(tx, storeName, data)
 }
 
 // RetrieveFromStore retrieves data from the store defined by
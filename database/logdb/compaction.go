@@ -0,0 +1,135 @@
+package logdb
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
+)
+
+var (
+	// minCompactionGarbage is the minimum amount of garbage bytes in
+	// the log file that trigger a compaction. Compaction also requires
+	// the log file to be mostly garbage.
+	// It's defined as a variable for the sake of testing.
+	minCompactionGarbage int64 = 64 << 20
+
+	// compactionRecordSize is the approximate size of every record in
+	// a compacted log file.
+	compactionRecordSize = 4 << 20
+)
+
+// compactionFileName is the name of the file a compacted log is written
+// to before it replaces the log file.
+const compactionFileName = logFileName + ".compact"
+
+// shouldCompact returns whether the log file contains enough garbage to
+// justify a compaction. The caller must hold the lock.
+func (kv *kvStore) shouldCompact() bool {
+	garbage := kv.fileSize - kv.liveBytes
+	return garbage >= minCompactionGarbage && garbage > kv.liveBytes
+}
+
+// compact rewrites the log file so that it contains only the newest
+// versions of keys that were not deleted, and rebuilds the index to point
+// into the new file. The caller must hold the lock for writing, and there
+// must be no live snapshots.
+func (kv *kvStore) compact() error {
+	log.Debugf("Compacting %s: %d bytes, of which %d are live",
+		kv.logFilePath(), kv.fileSize, kv.liveBytes)
+
+	compactionPath := filepath.Join(kv.path, compactionFileName)
+	compactionFile, err := os.OpenFile(compactionPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	abort := func(err error) error {
+		closeErr := compactionFile.Close()
+		removeErr := os.Remove(compactionPath)
+		if closeErr != nil || removeErr != nil {
+			return errors.Wrapf(err, "error occurred during compaction "+
+				"cleanup: close: %v, remove: %v", closeErr, removeErr)
+		}
+		return err
+	}
+
+	index := memdb.New(internalKeyComparer{}, 0)
+	fileSize := int64(0)
+	var operations []operation
+	operationsSize := 0
+	flush := func() error {
+		record, valueOffsets := encodeRecord(kv.sequenceNumber, operations)
+		_, err := compactionFile.WriteAt(record, fileSize)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for i, op := range operations {
+			entry := &indexEntry{offset: fileSize + valueOffsets[i], length: uint32(len(op.value))}
+			_ = index.Put(makeInternalKey(op.key, kv.sequenceNumber), serializeIndexEntry(entry))
+		}
+		fileSize += int64(len(record))
+		operations = operations[:0]
+		operationsSize = 0
+		return nil
+	}
+
+	var previousKey []byte
+	liveBytes := int64(0)
+	iterator := kv.index.NewIterator(nil)
+	defer iterator.Release()
+	for iterator.Next() {
+		key, _ := parseInternalKey(iterator.Key())
+		if previousKey != nil && bytes.Equal(key, previousKey) {
+			continue
+		}
+		previousKey = key
+
+		entry := deserializeIndexEntry(iterator.Value())
+		if entry.isDeleted {
+			continue
+		}
+		value, err := kv.readValue(entry)
+		if err != nil {
+			return abort(err)
+		}
+		operations = append(operations, operation{opType: opPut, key: key, value: value})
+		operationsSize += len(key) + len(value)
+		liveBytes += int64(len(key) + len(value))
+		if operationsSize >= compactionRecordSize {
+			err := flush()
+			if err != nil {
+				return abort(err)
+			}
+		}
+	}
+	if len(operations) > 0 {
+		err := flush()
+		if err != nil {
+			return abort(err)
+		}
+	}
+
+	err = compactionFile.Sync()
+	if err != nil {
+		return abort(errors.WithStack(err))
+	}
+	err = os.Rename(compactionPath, kv.logFilePath())
+	if err != nil {
+		return abort(errors.WithStack(err))
+	}
+	err = kv.file.Close()
+	if err != nil {
+		log.Warnf("Failed to close the log file that was replaced by "+
+			"compaction: %s", err)
+	}
+
+	log.Debugf("Compacted %s from %d bytes to %d bytes",
+		kv.logFilePath(), kv.fileSize, fileSize)
+	kv.file = compactionFile
+	kv.fileSize = fileSize
+	kv.index = index
+	kv.liveBytes = liveBytes
+	return nil
+}
@@ -0,0 +1,176 @@
+package logdb
+
+import (
+	"bytes"
+
+	"github.com/kaspanet/kaspad/database"
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+)
+
+// cursor iterates over the keys of a single bucket as they were when
+// the snapshot it holds was taken.
+type cursor struct {
+	snapshot *snapshot
+	iterator iterator.Iterator
+	bucket   *database.Bucket
+
+	// lastKey is the last key whose newest visible version the
+	// cursor passed. Older versions of it are skipped.
+	lastKey []byte
+
+	// currentKey and currentEntry are the key and index entry of
+	// the key/value pair the cursor is positioned at. currentKey
+	// is nil if the cursor is not positioned at any pair.
+	currentKey   []byte
+	currentEntry *indexEntry
+
+	isStarted bool
+	isClosed  bool
+}
+
+// newCursor begins a new cursor over the given bucket that sees the store
+// as it was when the given snapshot was taken. The cursor takes ownership
+// of the snapshot and releases it once it's closed.
+func (kv *kvStore) newCursor(bucket *database.Bucket, snapshot *snapshot) *cursor {
+	kv.lock.RLock()
+	defer kv.lock.RUnlock()
+
+	return &cursor{
+		snapshot: snapshot,
+		iterator: kv.index.NewIterator(nil),
+		bucket:   bucket,
+	}
+}
+
+// Next moves the iterator to the next key/value pair. It returns whether the
+// iterator is exhausted. Panics if the cursor is closed.
+// This method is part of the Cursor interface.
+func (c *cursor) Next() bool {
+	if c.isClosed {
+		panic("cannot call next on a closed cursor")
+	}
+	if !c.isStarted {
+		return c.First()
+	}
+	if c.currentKey == nil {
+		return false
+	}
+	c.iterator.Next()
+	return c.skipToVisibleEntry()
+}
+
+// First moves the iterator to the first key/value pair. It returns false if
+// such a pair does not exist. Panics if the cursor is closed.
+// This method is part of the Cursor interface.
+func (c *cursor) First() bool {
+	if c.isClosed {
+		panic("cannot call first on a closed cursor")
+	}
+	c.isStarted = true
+	c.lastKey = nil
+	c.iterator.Seek(makeInternalKey(c.bucket.Path(), maxSequenceNumber))
+	return c.skipToVisibleEntry()
+}
+
+// Seek moves the iterator to the first key/value pair whose key is greater
+// than or equal to the given key. It returns ErrNotFound if such pair does not
+// exist.
+// This method is part of the Cursor interface.
+func (c *cursor) Seek(key *database.Key) error {
+	if c.isClosed {
+		return errors.New("cannot seek a closed cursor")
+	}
+	c.isStarted = true
+	c.lastKey = nil
+	c.iterator.Seek(makeInternalKey(key.Bytes(), c.snapshot.sequenceNumber))
+	found := c.skipToVisibleEntry()
+	if !found || !bytes.Equal(c.currentKey, key.Bytes()) {
+		return errors.Wrapf(database.ErrNotFound, "key %s not found", key)
+	}
+	return nil
+}
+
+// skipToVisibleEntry moves the underlying iterator forward until it's
+// positioned at the newest version of a key that's visible to the
+// cursor's snapshot and that was not deleted. It returns false if
+// there's no such key in the cursor's bucket.
+func (c *cursor) skipToVisibleEntry() bool {
+	c.currentKey = nil
+	c.currentEntry = nil
+	for ; c.iterator.Valid(); c.iterator.Next() {
+		key, sequenceNumber := parseInternalKey(c.iterator.Key())
+		if !bytes.HasPrefix(key, c.bucket.Path()) {
+			return false
+		}
+		if sequenceNumber > c.snapshot.sequenceNumber {
+			continue
+		}
+		if c.lastKey != nil && bytes.Equal(key, c.lastKey) {
+			continue
+		}
+		c.lastKey = key
+
+		entry := deserializeIndexEntry(c.iterator.Value())
+		if entry.isDeleted {
+			continue
+		}
+		c.currentKey = key
+		c.currentEntry = entry
+		return true
+	}
+	return false
+}
+
+// Key returns the key of the current key/value pair, or ErrNotFound if done.
+// Note that the key is trimmed to not include the prefix the cursor was opened
+// with. The caller should not modify the contents of the returned slice, and
+// its contents may change on the next call to Next.
+// This method is part of the Cursor interface.
+func (c *cursor) Key() (*database.Key, error) {
+	if c.isClosed {
+		return nil, errors.New("cannot get the key of a closed cursor")
+	}
+	if c.currentKey == nil {
+		return nil, errors.Wrapf(database.ErrNotFound, "cannot get the "+
+			"key of an exhausted cursor")
+	}
+	suffix := bytes.TrimPrefix(c.currentKey, c.bucket.Path())
+	return c.bucket.Key(suffix), nil
+}
+
+// Value returns the value of the current key/value pair, or ErrNotFound if done.
+// The caller should not modify the contents of the returned slice, and its
+// contents may change on the next call to Next.
+// This method is part of the Cursor interface.
+func (c *cursor) Value() ([]byte, error) {
+	if c.isClosed {
+		return nil, errors.New("cannot get the value of a closed cursor")
+	}
+	if c.currentKey == nil {
+		return nil, errors.Wrapf(database.ErrNotFound, "cannot get the "+
+			"value of an exhausted cursor")
+	}
+
+	kv := c.snapshot.kv
+	kv.lock.RLock()
+	defer kv.lock.RUnlock()
+
+	if kv.isClosed {
+		return nil, errors.New("cannot get the value of a cursor of a closed database")
+	}
+	return kv.readValue(c.currentEntry)
+}
+
+// Close releases associated resources.
+// This method is part of the Cursor interface.
+func (c *cursor) Close() error {
+	if c.isClosed {
+		return errors.New("cannot close an already closed cursor")
+	}
+	c.isClosed = true
+
+	c.iterator.Release()
+	c.snapshot.release()
+	return nil
+}
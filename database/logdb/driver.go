@@ -0,0 +1,16 @@
+package logdb
+
+import "github.com/kaspanet/kaspad/database"
+
+// DbType is the type of the logdb backend, as used by the --dbtype flag.
+const DbType = "logdb"
+
+func init() {
+	err := database.RegisterDriver(database.Driver{
+		DbType: DbType,
+		Open:   Open,
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,98 @@
+package logdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+const (
+	// sequenceNumberSize is the size in bytes of the sequence number
+	// that's appended to keys in the index.
+	sequenceNumberSize = 8
+
+	// indexEntrySize is the size in bytes of a serialized index entry.
+	// See serializeIndexEntry for further details.
+	indexEntrySize = 13
+
+	// maxSequenceNumber is used to seek to the newest version of a key.
+	maxSequenceNumber = math.MaxUint64
+)
+
+// The index maps every version of every key to the location of its value
+// in the log file. The index is kept in a skiplist sorted by internal
+// keys, which are keys suffixed by the sequence number of the record that
+// wrote them. Versions of the same key are sorted from the newest to the
+// oldest, which lets a snapshot find the newest version that it's allowed
+// to see with a single seek.
+
+// makeInternalKey returns the index key of the given version of the
+// given key.
+func makeInternalKey(key []byte, sequenceNumber uint64) []byte {
+	internalKey := make([]byte, len(key)+sequenceNumberSize)
+	copy(internalKey, key)
+	binary.BigEndian.PutUint64(internalKey[len(key):], sequenceNumber)
+	return internalKey
+}
+
+// parseInternalKey splits the given index key into the key and the
+// sequence number of its version. The returned key references the
+// given internal key.
+func parseInternalKey(internalKey []byte) (key []byte, sequenceNumber uint64) {
+	keyLength := len(internalKey) - sequenceNumberSize
+	return internalKey[:keyLength], binary.BigEndian.Uint64(internalKey[keyLength:])
+}
+
+// internalKeyComparer orders internal keys by their keys, and versions
+// of the same key by their sequence numbers in descending order.
+type internalKeyComparer struct{}
+
+// Compare implements comparer.BasicComparer.
+func (internalKeyComparer) Compare(a, b []byte) int {
+	aKey, aSequenceNumber := parseInternalKey(a)
+	bKey, bSequenceNumber := parseInternalKey(b)
+	if result := bytes.Compare(aKey, bKey); result != 0 {
+		return result
+	}
+	switch {
+	case aSequenceNumber > bSequenceNumber:
+		return -1
+	case aSequenceNumber < bSequenceNumber:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// indexEntry is the location of a single version of a value in the log
+// file. Deleted versions have no location.
+type indexEntry struct {
+	isDeleted bool
+	offset    int64
+	length    uint32
+}
+
+// serializeIndexEntry serializes the given entry. The format is:
+//
+//	[0:1]  Is deleted (1 byte)
+//	[1:9]  Value offset in the log file (8 bytes)
+//	[9:13] Value length (4 bytes)
+func serializeIndexEntry(entry *indexEntry) []byte {
+	var serializedEntry [indexEntrySize]byte
+	if entry.isDeleted {
+		serializedEntry[0] = 1
+	}
+	binary.BigEndian.PutUint64(serializedEntry[1:9], uint64(entry.offset))
+	binary.BigEndian.PutUint32(serializedEntry[9:13], entry.length)
+	return serializedEntry[:]
+}
+
+// deserializeIndexEntry deserializes the given entry. See
+// serializeIndexEntry for further details.
+func deserializeIndexEntry(serializedEntry []byte) *indexEntry {
+	return &indexEntry{
+		isDeleted: serializedEntry[0] == 1,
+		offset:    int64(binary.BigEndian.Uint64(serializedEntry[1:9])),
+		length:    binary.BigEndian.Uint32(serializedEntry[9:13]),
+	}
+}
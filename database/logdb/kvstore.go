@@ -0,0 +1,359 @@
+package logdb
+
+import (
+	"bufio"
+	"bytes"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/kaspanet/kaspad/database"
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
+)
+
+// logFileName is the name of the log file within the database directory.
+const logFileName = "kv.log"
+
+// kvStore is a log-structured key-value store. Every committed batch is
+// appended as a single checksummed record to a log file, and an in-memory
+// index maps every key to the location of its value in that file. The log
+// is replayed to rebuild the index when the store is opened, and it's
+// compacted once most of it is taken by overwritten or deleted values.
+//
+// Every record is tagged with an increasing sequence number. The index
+// keeps older versions of keys as long as there are snapshots that may
+// still read them, which is what gives transactions and cursors a frozen
+// view of the store.
+type kvStore struct {
+	path string
+
+	// lock protects the log file, the index and the fields below.
+	// Writes and compaction take it for writing. Reads take it for
+	// reading.
+	lock           sync.RWMutex
+	file           *os.File
+	fileSize       int64
+	index          *memdb.DB
+	sequenceNumber uint64
+	isClosed       bool
+
+	// liveBytes is the amount of bytes in the log file that are taken
+	// by the keys and values of the newest versions of keys. The rest
+	// of the file is garbage that's removed by compaction.
+	liveBytes int64
+
+	// snapshots maps the sequence number of every open snapshot
+	// to the amount of references to it.
+	snapshots     map[uint64]int
+	snapshotsLock sync.Mutex
+}
+
+// openKVStore opens the key-value store in the given directory, creating
+// it if it doesn't exist. Records at the end of the log that were not
+// completely written, for example due to a crash, are discarded.
+func openKVStore(path string) (*kvStore, error) {
+	err := os.MkdirAll(path, 0700)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	file, err := os.OpenFile(filepath.Join(path, logFileName), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	kv := &kvStore{
+		path:      path,
+		file:      file,
+		index:     memdb.New(internalKeyComparer{}, 0),
+		snapshots: make(map[uint64]int),
+	}
+	err = kv.replay()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if kv.shouldCompact() {
+		err := kv.compact()
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return kv, nil
+}
+
+func (kv *kvStore) logFilePath() string {
+	return filepath.Join(kv.path, logFileName)
+}
+
+// replay rebuilds the index from the log file.
+func (kv *kvStore) replay() error {
+	fileInfo, err := kv.file.Stat()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	fileSize := fileInfo.Size()
+	reader := bufio.NewReaderSize(io.NewSectionReader(kv.file, 0, fileSize), 1<<20)
+
+	offset := int64(0)
+	header := make([]byte, recordHeaderSize)
+	for offset < fileSize {
+		if fileSize-offset < recordHeaderSize {
+			break
+		}
+		_, err := io.ReadFull(reader, header)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		payloadLength, checksum := decodeRecordHeader(header)
+		if payloadLength > maxRecordPayloadSize ||
+			int64(payloadLength) > fileSize-offset-recordHeaderSize {
+			break
+		}
+		payload := make([]byte, payloadLength)
+		_, err = io.ReadFull(reader, payload)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if crc32.Checksum(payload, castagnoli) != checksum {
+			break
+		}
+
+		sequenceNumber, operations, valueOffsets, err := decodeRecordPayload(payload)
+		if err != nil {
+			return errors.Wrapf(err, "record at offset %d in %s is corrupted",
+				offset, kv.logFilePath())
+		}
+		for i, op := range operations {
+			kv.apply(op, sequenceNumber, offset+valueOffsets[i], nil)
+		}
+		if sequenceNumber > kv.sequenceNumber {
+			kv.sequenceNumber = sequenceNumber
+		}
+		offset += recordHeaderSize + int64(payloadLength)
+	}
+
+	if offset < fileSize {
+		log.Warnf("Discarding %d bytes of incomplete records at the end of %s",
+			fileSize-offset, kv.logFilePath())
+		err := kv.file.Truncate(offset)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	kv.fileSize = offset
+	return nil
+}
+
+// write appends the operations of the given batch to the log as a single
+// record, syncs the log to disk, and updates the index accordingly.
+func (kv *kvStore) write(b *batch) error {
+	if len(b.operations) == 0 {
+		return nil
+	}
+
+	kv.lock.Lock()
+	defer kv.lock.Unlock()
+
+	if kv.isClosed {
+		return errors.New("cannot write to a closed database")
+	}
+
+	sequenceNumber := kv.sequenceNumber + 1
+	record, valueOffsets := encodeRecord(sequenceNumber, b.operations)
+	offset := kv.fileSize
+	_, err := kv.file.WriteAt(record, offset)
+	if err == nil {
+		// The batch is committed only once its record is on disk,
+		// so that a crash can't lose it after write returns.
+		err = kv.file.Sync()
+	}
+	if err != nil {
+		// Remove whatever part of the record that was written, so
+		// that the next record is appended right after the previous
+		// one.
+		truncateErr := kv.file.Truncate(offset)
+		if truncateErr != nil {
+			return errors.Wrapf(err, "error occurred during truncate: %s", truncateErr)
+		}
+		return errors.WithStack(err)
+	}
+	kv.fileSize += int64(len(record))
+	kv.sequenceNumber = sequenceNumber
+
+	liveSnapshots := kv.liveSnapshots()
+	for i, op := range b.operations {
+		kv.apply(op, sequenceNumber, offset+valueOffsets[i], liveSnapshots)
+	}
+
+	if len(liveSnapshots) == 0 && kv.shouldCompact() {
+		return kv.compact()
+	}
+	return nil
+}
+
+// apply adds the given operation to the index, and removes the versions of
+// its key that are no longer visible to any of the given live snapshots.
+func (kv *kvStore) apply(op operation, sequenceNumber uint64, valueOffset int64,
+	liveSnapshots []uint64) {
+
+	previousEntry, ok := kv.newestEntry(op.key)
+	if ok && !previousEntry.isDeleted {
+		kv.liveBytes -= int64(len(op.key)) + int64(previousEntry.length)
+	}
+
+	entry := &indexEntry{isDeleted: true}
+	if op.opType == opPut {
+		entry = &indexEntry{offset: valueOffset, length: uint32(len(op.value))}
+		kv.liveBytes += int64(len(op.key)) + int64(len(op.value))
+	}
+
+	// memdb.Put only fails when the given key is nil, which
+	// internal keys never are.
+	_ = kv.index.Put(makeInternalKey(op.key, sequenceNumber), serializeIndexEntry(entry))
+
+	kv.removeObsoleteVersions(op.key, liveSnapshots)
+}
+
+// newestEntry returns the index entry of the newest version of the given
+// key, regardless of which snapshots are allowed to see it.
+func (kv *kvStore) newestEntry(key []byte) (*indexEntry, bool) {
+	entry, ok := kv.entry(key, maxSequenceNumber)
+	return entry, ok
+}
+
+// entry returns the index entry of the newest version of the given key
+// whose sequence number is not greater than the given one.
+func (kv *kvStore) entry(key []byte, sequenceNumber uint64) (*indexEntry, bool) {
+	internalKey, serializedEntry, err := kv.index.Find(makeInternalKey(key, sequenceNumber))
+	if err != nil {
+		return nil, false
+	}
+	foundKey, _ := parseInternalKey(internalKey)
+	if !bytes.Equal(foundKey, key) {
+		return nil, false
+	}
+	return deserializeIndexEntry(serializedEntry), true
+}
+
+// removeObsoleteVersions removes all the versions of the given key that
+// are neither the newest version nor the newest version that's visible to
+// one of the given live snapshots. If the newest version is a deletion and
+// no older version remains, it's removed as well.
+func (kv *kvStore) removeObsoleteVersions(key []byte, liveSnapshots []uint64) {
+	var obsoleteKeys [][]byte
+	var newestKey []byte
+	isNewestDeleted := false
+	hasOlderVersions := false
+	newerSequenceNumber := uint64(0)
+
+	iterator := kv.index.NewIterator(nil)
+	for ok := iterator.Seek(makeInternalKey(key, maxSequenceNumber)); ok; ok = iterator.Next() {
+		foundKey, sequenceNumber := parseInternalKey(iterator.Key())
+		if !bytes.Equal(foundKey, key) {
+			break
+		}
+		if newestKey == nil {
+			newestKey = append([]byte(nil), iterator.Key()...)
+			isNewestDeleted = deserializeIndexEntry(iterator.Value()).isDeleted
+			newerSequenceNumber = sequenceNumber
+			continue
+		}
+		if isVisibleToAnySnapshot(sequenceNumber, newerSequenceNumber, liveSnapshots) {
+			hasOlderVersions = true
+		} else {
+			obsoleteKeys = append(obsoleteKeys, append([]byte(nil), iterator.Key()...))
+		}
+		newerSequenceNumber = sequenceNumber
+	}
+	iterator.Release()
+
+	if isNewestDeleted && !hasOlderVersions {
+		obsoleteKeys = append(obsoleteKeys, newestKey)
+	}
+	for _, obsoleteKey := range obsoleteKeys {
+		// memdb.Delete only fails when the key doesn't exist, and
+		// these keys were just found.
+		_ = kv.index.Delete(obsoleteKey)
+	}
+}
+
+// isVisibleToAnySnapshot returns whether a version with the given sequence
+// number, whose next newer version has newerSequenceNumber, is the newest
+// version that one of the given sorted snapshots is allowed to see.
+func isVisibleToAnySnapshot(sequenceNumber uint64, newerSequenceNumber uint64,
+	sortedSnapshots []uint64) bool {
+
+	i := sort.Search(len(sortedSnapshots), func(i int) bool {
+		return sortedSnapshots[i] >= sequenceNumber
+	})
+	return i < len(sortedSnapshots) && sortedSnapshots[i] < newerSequenceNumber
+}
+
+// get returns the value of the newest version of the given key that's
+// visible to the given sequence number. It returns ErrNotFound if there
+// is no such version or if it was deleted.
+func (kv *kvStore) get(key []byte, sequenceNumber uint64) ([]byte, error) {
+	kv.lock.RLock()
+	defer kv.lock.RUnlock()
+
+	if kv.isClosed {
+		return nil, errors.New("cannot get from a closed database")
+	}
+
+	entry, ok := kv.entry(key, sequenceNumber)
+	if !ok || entry.isDeleted {
+		return nil, errors.Wrapf(database.ErrNotFound, "key %x not found", key)
+	}
+	return kv.readValue(entry)
+}
+
+// has returns whether the newest version of the given key that's visible
+// to the given sequence number exists and was not deleted.
+func (kv *kvStore) has(key []byte, sequenceNumber uint64) (bool, error) {
+	kv.lock.RLock()
+	defer kv.lock.RUnlock()
+
+	if kv.isClosed {
+		return false, errors.New("cannot has from a closed database")
+	}
+
+	entry, ok := kv.entry(key, sequenceNumber)
+	return ok && !entry.isDeleted, nil
+}
+
+// readValue reads the value that the given index entry points to from the
+// log file. The caller must hold the lock.
+func (kv *kvStore) readValue(entry *indexEntry) ([]byte, error) {
+	value := make([]byte, entry.length)
+	_, err := kv.file.ReadAt(value, entry.offset)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return value, nil
+}
+
+// currentSequenceNumber returns the sequence number of the last record
+// that was written.
+func (kv *kvStore) currentSequenceNumber() uint64 {
+	kv.lock.RLock()
+	defer kv.lock.RUnlock()
+
+	return kv.sequenceNumber
+}
+
+// close closes the log file. The store must not be used afterwards.
+func (kv *kvStore) close() error {
+	kv.lock.Lock()
+	defer kv.lock.Unlock()
+
+	if kv.isClosed {
+		return errors.New("cannot close an already closed database")
+	}
+	kv.isClosed = true
+	return errors.WithStack(kv.file.Close())
+}
@@ -0,0 +1,5 @@
+package logdb
+
+import "github.com/kaspanet/kaspad/logger"
+
+var log, _ = logger.Get(logger.SubsystemTags.KSDB)
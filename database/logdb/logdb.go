@@ -0,0 +1,143 @@
+package logdb
+
+import (
+	"github.com/kaspanet/kaspad/database"
+	"github.com/kaspanet/kaspad/database/ffldb/ff"
+	"github.com/pkg/errors"
+)
+
+// logdb is a database utilizing a log-structured key-value store for
+// key-value data and flat-files for raw data storage. Unlike ffldb, it
+// doesn't depend on any external storage engine.
+type logdb struct {
+	flatFileDB *ff.FlatFileDB
+	kvStore    *kvStore
+}
+
+// Open opens a new logdb with the given path.
+func Open(path string) (database.Database, error) {
+	kvStore, err := openKVStore(path)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &logdb{
+		flatFileDB: ff.NewFlatFileDB(path, ff.DefaultMaxFileSize),
+		kvStore:    kvStore,
+	}
+
+	// Sync the flat-file stores with the key-value store in
+	// case the two diverged. If this fails then the database
+	// is irrecoverably corrupted.
+	err = db.flatFileDB.Repair(db)
+	if err != nil {
+		closeErr := db.Close()
+		if closeErr != nil {
+			return nil, errors.Wrapf(err, "err occurred during close: %s", closeErr)
+		}
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Close closes the database.
+// This method is part of the Database interface.
+func (db *logdb) Close() error {
+	err := db.flatFileDB.Close()
+	if err != nil {
+		kvStoreCloseErr := db.kvStore.close()
+		if kvStoreCloseErr != nil {
+			return errors.Wrapf(err, "err occurred during key-value store close: %s", kvStoreCloseErr)
+		}
+		return err
+	}
+	return db.kvStore.close()
+}
+
+// Put sets the value for the given key. It overwrites
+// any previous value for that key.
+// This method is part of the DataAccessor interface.
+func (db *logdb) Put(key *database.Key, value []byte) error {
+	b := &batch{}
+	b.put(key.Bytes(), value)
+	return db.kvStore.write(b)
+}
+
+// Get gets the value for the given key. It returns
+// ErrNotFound if the given key does not exist.
+// This method is part of the DataAccessor interface.
+func (db *logdb) Get(key *database.Key) ([]byte, error) {
+	return db.kvStore.get(key.Bytes(), maxSequenceNumber)
+}
+
+// Has returns true if the database does contains the
+// given key.
+// This method is part of the DataAccessor interface.
+func (db *logdb) Has(key *database.Key) (bool, error) {
+	return db.kvStore.has(key.Bytes(), maxSequenceNumber)
+}
+
+// Delete deletes the value for the given key. Will not
+// return an error if the key doesn't exist.
+// This method is part of the DataAccessor interface.
+func (db *logdb) Delete(key *database.Key) error {
+	b := &batch{}
+	b.delete(key.Bytes())
+	return db.kvStore.write(b)
+}
+
+// AppendToStore appends the given data to the flat
+// file store defined by storeName. This function
+// returns a serialized location handle that's meant
+// to be stored and later used when querying the data
+// that has just now been inserted.
+// This method is part of the DataAccessor interface.
+func (db *logdb) AppendToStore(storeName string, data []byte) ([]byte, error) {
+	return db.flatFileDB.AppendToStore(db, storeName, data)
+}
+
+// RetrieveFromStore retrieves data from the store defined by
+// storeName using the given serialized location handle. It
+// returns ErrNotFound if the location does not exist. See
+// AppendToStore for further details.
+// This method is part of the DataAccessor interface.
+func (db *logdb) RetrieveFromStore(storeName string, location []byte) ([]byte, error) {
+	return db.flatFileDB.Read(storeName, location)
+}
+
+// PruneStore deletes the data in the flat file store
+// defined by storeName that was appended before all the
+// data at the given location handles. Only whole flat files
+// are deleted.
+// This method is part of the Database interface.
+func (db *logdb) PruneStore(storeName string, keptLocations [][]byte) error {
+	return db.flatFileDB.PruneStore(storeName, keptLocations)
+}
+
+// Cursor begins a new cursor over the given bucket.
+// This method is part of the DataAccessor interface.
+func (db *logdb) Cursor(bucket *database.Bucket) (database.Cursor, error) {
+	snapshot, err := db.kvStore.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return db.kvStore.newCursor(bucket, snapshot), nil
+}
+
+// Begin begins a new logdb transaction.
+// This method is part of the Database interface.
+func (db *logdb) Begin() (database.Transaction, error) {
+	snapshot, err := db.kvStore.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := &transaction{
+		db:       db,
+		snapshot: snapshot,
+		batch:    &batch{},
+		isClosed: false,
+	}
+	return transaction, nil
+}
@@ -0,0 +1,244 @@
+package logdb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kaspanet/kaspad/database"
+)
+
+// prepareDatabaseForTest opens a new database in a temp directory. Since
+// tests reopen the database, teardownFunc receives the instance to close.
+func prepareDatabaseForTest(t *testing.T, testName string) (db *logdb, path string, teardownFunc func(db *logdb)) {
+	// Create a temp db to run tests against
+	path, err := ioutil.TempDir("", testName)
+	if err != nil {
+		t.Fatalf("%s: TempDir unexpectedly "+
+			"failed: %s", testName, err)
+	}
+	db = openDatabaseForTest(t, testName, path)
+	teardownFunc = func(db *logdb) {
+		err := db.Close()
+		if err != nil {
+			t.Fatalf("%s: Close unexpectedly "+
+				"failed: %s", testName, err)
+		}
+		os.RemoveAll(path)
+	}
+	return db, path, teardownFunc
+}
+
+func openDatabaseForTest(t *testing.T, testName string, path string) *logdb {
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("%s: Open unexpectedly "+
+			"failed: %s", testName, err)
+	}
+	return db.(*logdb)
+}
+
+func reopenDatabaseForTest(t *testing.T, testName string, db *logdb, path string) *logdb {
+	err := db.Close()
+	if err != nil {
+		t.Fatalf("%s: Close unexpectedly "+
+			"failed: %s", testName, err)
+	}
+	return openDatabaseForTest(t, testName, path)
+}
+
+func TestReopen(t *testing.T) {
+	db, path, teardownFunc := prepareDatabaseForTest(t, "TestReopen")
+	defer func() { teardownFunc(db) }()
+
+	key1 := database.MakeBucket().Key([]byte("key1"))
+	key2 := database.MakeBucket().Key([]byte("key2"))
+	err := db.Put(key1, []byte("value1"))
+	if err != nil {
+		t.Fatalf("TestReopen: Put unexpectedly failed: %s", err)
+	}
+	err = db.Put(key2, []byte("value2"))
+	if err != nil {
+		t.Fatalf("TestReopen: Put unexpectedly failed: %s", err)
+	}
+	err = db.Put(key1, []byte("value3"))
+	if err != nil {
+		t.Fatalf("TestReopen: Put unexpectedly failed: %s", err)
+	}
+	err = db.Delete(key2)
+	if err != nil {
+		t.Fatalf("TestReopen: Delete unexpectedly failed: %s", err)
+	}
+
+	db = reopenDatabaseForTest(t, "TestReopen", db, path)
+
+	value, err := db.Get(key1)
+	if err != nil {
+		t.Fatalf("TestReopen: Get unexpectedly failed: %s", err)
+	}
+	if !bytes.Equal(value, []byte("value3")) {
+		t.Fatalf("TestReopen: Get returned wrong value. "+
+			"Want: value3, got: %s", value)
+	}
+	exists, err := db.Has(key2)
+	if err != nil {
+		t.Fatalf("TestReopen: Has unexpectedly failed: %s", err)
+	}
+	if exists {
+		t.Fatalf("TestReopen: deleted key unexpectedly exists")
+	}
+}
+
+func TestIncompleteRecordDiscarded(t *testing.T) {
+	db, path, teardownFunc := prepareDatabaseForTest(t, "TestIncompleteRecordDiscarded")
+	defer func() { teardownFunc(db) }()
+
+	key1 := database.MakeBucket().Key([]byte("key1"))
+	key2 := database.MakeBucket().Key([]byte("key2"))
+	err := db.Put(key1, []byte("value1"))
+	if err != nil {
+		t.Fatalf("TestIncompleteRecordDiscarded: Put unexpectedly failed: %s", err)
+	}
+	sizeBeforeSecondRecord := db.kvStore.fileSize
+	err = db.Put(key2, []byte("value2"))
+	if err != nil {
+		t.Fatalf("TestIncompleteRecordDiscarded: Put unexpectedly failed: %s", err)
+	}
+	err = db.Close()
+	if err != nil {
+		t.Fatalf("TestIncompleteRecordDiscarded: Close unexpectedly failed: %s", err)
+	}
+
+	// Cut the second record in the middle, as if the node had crashed
+	// while writing it
+	err = os.Truncate(filepath.Join(path, logFileName), sizeBeforeSecondRecord+5)
+	if err != nil {
+		t.Fatalf("TestIncompleteRecordDiscarded: Truncate unexpectedly failed: %s", err)
+	}
+
+	db = openDatabaseForTest(t, "TestIncompleteRecordDiscarded", path)
+	if db.kvStore.fileSize != sizeBeforeSecondRecord {
+		t.Fatalf("TestIncompleteRecordDiscarded: the incomplete record was "+
+			"not discarded. Want size: %d, got: %d",
+			sizeBeforeSecondRecord, db.kvStore.fileSize)
+	}
+	exists, err := db.Has(key1)
+	if err != nil {
+		t.Fatalf("TestIncompleteRecordDiscarded: Has unexpectedly failed: %s", err)
+	}
+	if !exists {
+		t.Fatalf("TestIncompleteRecordDiscarded: the first key unexpectedly " +
+			"doesn't exist")
+	}
+	exists, err = db.Has(key2)
+	if err != nil {
+		t.Fatalf("TestIncompleteRecordDiscarded: Has unexpectedly failed: %s", err)
+	}
+	if exists {
+		t.Fatalf("TestIncompleteRecordDiscarded: the key of the incomplete " +
+			"record unexpectedly exists")
+	}
+
+	// Make sure that new records are appended right after the
+	// last complete record
+	err = db.Put(key2, []byte("value3"))
+	if err != nil {
+		t.Fatalf("TestIncompleteRecordDiscarded: Put unexpectedly failed: %s", err)
+	}
+	db = reopenDatabaseForTest(t, "TestIncompleteRecordDiscarded", db, path)
+	value, err := db.Get(key2)
+	if err != nil {
+		t.Fatalf("TestIncompleteRecordDiscarded: Get unexpectedly failed: %s", err)
+	}
+	if !bytes.Equal(value, []byte("value3")) {
+		t.Fatalf("TestIncompleteRecordDiscarded: Get returned wrong value. "+
+			"Want: value3, got: %s", value)
+	}
+}
+
+func TestCompaction(t *testing.T) {
+	oldMinCompactionGarbage := minCompactionGarbage
+	minCompactionGarbage = 1024
+	defer func() { minCompactionGarbage = oldMinCompactionGarbage }()
+
+	db, path, teardownFunc := prepareDatabaseForTest(t, "TestCompaction")
+	defer func() { teardownFunc(db) }()
+
+	// Keep overwriting a small set of keys, which makes most of the
+	// log garbage
+	bucket := database.MakeBucket([]byte("bucket"))
+	value := bytes.Repeat([]byte{1}, 100)
+	for i := 0; i < 1000; i++ {
+		key := bucket.Key([]byte(fmt.Sprintf("key%d", i%10)))
+		err := db.Put(key, append(value, byte(i)))
+		if err != nil {
+			t.Fatalf("TestCompaction: Put unexpectedly failed: %s", err)
+		}
+	}
+
+	// Make sure that the log was compacted while the database was open
+	if db.kvStore.fileSize > 2*minCompactionGarbage+2*db.kvStore.liveBytes {
+		t.Fatalf("TestCompaction: the log was unexpectedly not compacted. "+
+			"Size: %d, live bytes: %d", db.kvStore.fileSize, db.kvStore.liveBytes)
+	}
+
+	// Make sure that compaction doesn't happen while a snapshot is open,
+	// and that the snapshot still sees the old values
+	dbTx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("TestCompaction: Begin unexpectedly failed: %s", err)
+	}
+	for i := 0; i < 1000; i++ {
+		key := bucket.Key([]byte(fmt.Sprintf("key%d", i%10)))
+		err := db.Put(key, value)
+		if err != nil {
+			t.Fatalf("TestCompaction: Put unexpectedly failed: %s", err)
+		}
+	}
+	for i := 990; i < 1000; i++ {
+		key := bucket.Key([]byte(fmt.Sprintf("key%d", i%10)))
+		txValue, err := dbTx.Get(key)
+		if err != nil {
+			t.Fatalf("TestCompaction: Get unexpectedly failed: %s", err)
+		}
+		if !bytes.Equal(txValue, append(value, byte(i))) {
+			t.Fatalf("TestCompaction: the transaction unexpectedly "+
+				"sees a value that was written after it began: %x", txValue)
+		}
+	}
+	err = dbTx.Rollback()
+	if err != nil {
+		t.Fatalf("TestCompaction: Rollback unexpectedly failed: %s", err)
+	}
+
+	// Make sure that the compacted log is replayed correctly
+	db = reopenDatabaseForTest(t, "TestCompaction", db, path)
+	cursor, err := db.Cursor(bucket)
+	if err != nil {
+		t.Fatalf("TestCompaction: Cursor unexpectedly failed: %s", err)
+	}
+	defer cursor.Close()
+	count := 0
+	for cursor.Next() {
+		cursorValue, err := cursor.Value()
+		if err != nil {
+			t.Fatalf("TestCompaction: Value unexpectedly failed: %s", err)
+		}
+		if !bytes.Equal(cursorValue, value) {
+			t.Fatalf("TestCompaction: unexpected value: %x", cursorValue)
+		}
+		count++
+	}
+	if count != 10 {
+		t.Fatalf("TestCompaction: unexpected amount of keys. "+
+			"Want: 10, got: %d", count)
+	}
+	if db.kvStore.fileSize > 2*minCompactionGarbage+2*db.kvStore.liveBytes {
+		t.Fatalf("TestCompaction: the log was unexpectedly not compacted "+
+			"when it was opened. Size: %d, live bytes: %d",
+			db.kvStore.fileSize, db.kvStore.liveBytes)
+	}
+}
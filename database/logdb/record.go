@@ -0,0 +1,191 @@
+package logdb
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// recordHeaderSize is the size in bytes of the header of a
+	// record in the log file. See encodeRecord for further details.
+	recordHeaderSize = 8
+
+	// recordPayloadHeaderSize is the size in bytes of the sequence
+	// number and operation count at the beginning of a record's payload.
+	recordPayloadHeaderSize = 12
+
+	// maxRecordPayloadSize is the maximum size in bytes of the payload
+	// of a single record. Longer lengths are treated as corruption.
+	maxRecordPayloadSize = 1 << 30
+)
+
+// opType is the type of a single operation within a record.
+type opType byte
+
+const (
+	opPut opType = iota
+	opDelete
+)
+
+// castagnoli is the crc32 table used to checksum records.
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// operation is a single put or delete of a key.
+type operation struct {
+	opType opType
+	key    []byte
+	value  []byte
+}
+
+// batch is an ordered set of operations that's written to
+// the log atomically.
+type batch struct {
+	operations []operation
+}
+
+// put adds a put operation to the batch. The key and value are
+// copied, so it's safe to modify them after put returns.
+func (b *batch) put(key []byte, value []byte) {
+	b.operations = append(b.operations, operation{
+		opType: opPut,
+		key:    append([]byte(nil), key...),
+		value:  append([]byte(nil), value...),
+	})
+}
+
+// delete adds a delete operation to the batch. The key is copied,
+// so it's safe to modify it after delete returns.
+func (b *batch) delete(key []byte) {
+	b.operations = append(b.operations, operation{
+		opType: opDelete,
+		key:    append([]byte(nil), key...),
+	})
+}
+
+func (b *batch) reset() {
+	b.operations = nil
+}
+
+// encodeRecord serializes the given operations into a record that's ready
+// to be appended to the log file. It also returns the offset of every put
+// operation's value relative to the beginning of the record. The record
+// format is:
+//
+//	[0:4]   Payload length (4 bytes)
+//	[4:8]   Castagnoli crc32 checksum of the payload (4 bytes)
+//	[8:16]  Sequence number (8 bytes)
+//	[16:20] Operation count (4 bytes)
+//	[20:]   Operations
+//
+// Every operation is serialized as:
+//
+//	Operation type (1 byte)
+//	Key length (varint)
+//	Key
+//	Value length (varint, put operations only)
+//	Value (put operations only)
+func encodeRecord(sequenceNumber uint64, operations []operation) (record []byte, valueOffsets []int64) {
+	size := recordHeaderSize + recordPayloadHeaderSize
+	for _, op := range operations {
+		size += 1 + binary.MaxVarintLen64 + len(op.key)
+		if op.opType == opPut {
+			size += binary.MaxVarintLen64 + len(op.value)
+		}
+	}
+
+	record = make([]byte, recordHeaderSize+recordPayloadHeaderSize, size)
+	binary.LittleEndian.PutUint64(record[8:16], sequenceNumber)
+	binary.LittleEndian.PutUint32(record[16:20], uint32(len(operations)))
+
+	valueOffsets = make([]int64, len(operations))
+	var varintBuf [binary.MaxVarintLen64]byte
+	for i, op := range operations {
+		record = append(record, byte(op.opType))
+		n := binary.PutUvarint(varintBuf[:], uint64(len(op.key)))
+		record = append(record, varintBuf[:n]...)
+		record = append(record, op.key...)
+		if op.opType == opPut {
+			n := binary.PutUvarint(varintBuf[:], uint64(len(op.value)))
+			record = append(record, varintBuf[:n]...)
+			valueOffsets[i] = int64(len(record))
+			record = append(record, op.value...)
+		}
+	}
+
+	payload := record[recordHeaderSize:]
+	binary.LittleEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(record[4:8], crc32.Checksum(payload, castagnoli))
+	return record, valueOffsets
+}
+
+// decodeRecordHeader deserializes the header of a record. See
+// encodeRecord for further details.
+func decodeRecordHeader(header []byte) (payloadLength uint32, checksum uint32) {
+	return binary.LittleEndian.Uint32(header[0:4]), binary.LittleEndian.Uint32(header[4:8])
+}
+
+// decodeRecordPayload deserializes the payload of a record. The returned
+// operations reference the given payload. The returned value offsets are
+// relative to the beginning of the record. See encodeRecord for further
+// details.
+func decodeRecordPayload(payload []byte) (sequenceNumber uint64,
+	operations []operation, valueOffsets []int64, err error) {
+
+	if len(payload) < recordPayloadHeaderSize {
+		return 0, nil, nil, errors.Errorf("record payload of length %d "+
+			"is too short", len(payload))
+	}
+	sequenceNumber = binary.LittleEndian.Uint64(payload[0:8])
+	operationCount := binary.LittleEndian.Uint32(payload[8:12])
+
+	offset := recordPayloadHeaderSize
+	readBytes := func() ([]byte, int, error) {
+		length, n := binary.Uvarint(payload[offset:])
+		if n <= 0 || length > uint64(len(payload)-offset-n) {
+			return nil, 0, errors.Errorf("malformed length at offset %d", offset)
+		}
+		start := offset + n
+		offset = start + int(length)
+		return payload[start:offset], start, nil
+	}
+
+	// Every operation takes at least two bytes, so there's no need to
+	// allocate more than that, even if the count claims otherwise.
+	capacity := operationCount
+	if maxOperations := uint32(len(payload) / 2); capacity > maxOperations {
+		capacity = maxOperations
+	}
+	operations = make([]operation, 0, capacity)
+	valueOffsets = make([]int64, 0, capacity)
+	for i := uint32(0); i < operationCount; i++ {
+		if offset >= len(payload) {
+			return 0, nil, nil, errors.Errorf("record has %d operations "+
+				"instead of %d", i, operationCount)
+		}
+		op := operation{opType: opType(payload[offset])}
+		offset++
+		if op.opType != opPut && op.opType != opDelete {
+			return 0, nil, nil, errors.Errorf("unknown operation type %d", op.opType)
+		}
+		op.key, _, err = readBytes()
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		var valueOffset int
+		if op.opType == opPut {
+			op.value, valueOffset, err = readBytes()
+			if err != nil {
+				return 0, nil, nil, err
+			}
+		}
+		operations = append(operations, op)
+		valueOffsets = append(valueOffsets, int64(recordHeaderSize+valueOffset))
+	}
+	if offset != len(payload) {
+		return 0, nil, nil, errors.Errorf("record has %d unexpected "+
+			"trailing bytes", len(payload)-offset)
+	}
+	return sequenceNumber, operations, valueOffsets, nil
+}
@@ -0,0 +1,92 @@
+package logdb
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// snapshot is a frozen view of the store as it was when the snapshot was
+// taken. The versions of keys it may read are kept in the index until it's
+// released, and the store is not compacted while it's open.
+type snapshot struct {
+	kv             *kvStore
+	sequenceNumber uint64
+	isReleased     bool
+}
+
+// snapshot takes a new snapshot of the store.
+func (kv *kvStore) snapshot() (*snapshot, error) {
+	kv.lock.RLock()
+	defer kv.lock.RUnlock()
+
+	if kv.isClosed {
+		return nil, errors.New("cannot take a snapshot of a closed database")
+	}
+	return kv.snapshotAt(kv.sequenceNumber), nil
+}
+
+// snapshotAt adds a reference to the snapshot with the given sequence
+// number. The snapshot must either be the current state of the store or
+// already be referenced, or otherwise versions it may read might have
+// been removed.
+func (kv *kvStore) snapshotAt(sequenceNumber uint64) *snapshot {
+	kv.snapshotsLock.Lock()
+	defer kv.snapshotsLock.Unlock()
+
+	kv.snapshots[sequenceNumber]++
+	return &snapshot{
+		kv:             kv,
+		sequenceNumber: sequenceNumber,
+	}
+}
+
+// liveSnapshots returns the sorted sequence numbers of all the snapshots
+// that were not released yet.
+func (kv *kvStore) liveSnapshots() []uint64 {
+	kv.snapshotsLock.Lock()
+	defer kv.snapshotsLock.Unlock()
+
+	if len(kv.snapshots) == 0 {
+		return nil
+	}
+	sequenceNumbers := make([]uint64, 0, len(kv.snapshots))
+	for sequenceNumber := range kv.snapshots {
+		sequenceNumbers = append(sequenceNumbers, sequenceNumber)
+	}
+	sort.Slice(sequenceNumbers, func(i, j int) bool {
+		return sequenceNumbers[i] < sequenceNumbers[j]
+	})
+	return sequenceNumbers
+}
+
+// clone returns a new reference to the same snapshot, which has to be
+// released separately.
+func (s *snapshot) clone() *snapshot {
+	return s.kv.snapshotAt(s.sequenceNumber)
+}
+
+// release releases the snapshot. It does nothing if the snapshot
+// was already released.
+func (s *snapshot) release() {
+	if s.isReleased {
+		return
+	}
+	s.isReleased = true
+
+	s.kv.snapshotsLock.Lock()
+	defer s.kv.snapshotsLock.Unlock()
+
+	s.kv.snapshots[s.sequenceNumber]--
+	if s.kv.snapshots[s.sequenceNumber] == 0 {
+		delete(s.kv.snapshots, s.sequenceNumber)
+	}
+}
+
+func (s *snapshot) get(key []byte) ([]byte, error) {
+	return s.kv.get(key, s.sequenceNumber)
+}
+
+func (s *snapshot) has(key []byte) (bool, error) {
+	return s.kv.has(key, s.sequenceNumber)
+}
@@ -0,0 +1,16 @@
+package memdb
+
+import "github.com/kaspanet/kaspad/database"
+
+// DbType is the type of the memdb backend, as used by the --dbtype flag.
+const DbType = "memdb"
+
+func init() {
+	err := database.RegisterDriver(database.Driver{
+		DbType: DbType,
+		Open:   Open,
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,116 @@
+package memdb
+
+import (
+	"github.com/kaspanet/kaspad/database"
+	"github.com/kaspanet/kaspad/database/ffldb/ldb"
+)
+
+// memdb is a database that's kept entirely in memory. It uses an
+// in-memory LevelDB for key-value data and in-memory stores instead
+// of flat files. All of its data is lost once it's closed, which
+// makes it useful for tests and for ephemeral nodes.
+type memdb struct {
+	levelDB *ldb.LevelDB
+	stores  *stores
+}
+
+// Open opens a new memdb. The given path is ignored, since
+// memdb doesn't persist its data.
+func Open(_ string) (database.Database, error) {
+	levelDB, err := ldb.NewMemoryLevelDB()
+	if err != nil {
+		return nil, err
+	}
+
+	db := &memdb{
+		levelDB: levelDB,
+		stores:  newStores(),
+	}
+	return db, nil
+}
+
+// Close closes the database.
+// This method is part of the Database interface.
+func (db *memdb) Close() error {
+	db.stores.clear()
+	return db.levelDB.Close()
+}
+
+// Put sets the value for the given key. It overwrites
+// any previous value for that key.
+// This method is part of the DataAccessor interface.
+func (db *memdb) Put(key *database.Key, value []byte) error {
+	return db.levelDB.Put(key, value)
+}
+
+// Get gets the value for the given key. It returns
+// ErrNotFound if the given key does not exist.
+// This method is part of the DataAccessor interface.
+func (db *memdb) Get(key *database.Key) ([]byte, error) {
+	return db.levelDB.Get(key)
+}
+
+// Has returns true if the database does contains the
+// given key.
+// This method is part of the DataAccessor interface.
+func (db *memdb) Has(key *database.Key) (bool, error) {
+	return db.levelDB.Has(key)
+}
+
+// Delete deletes the value for the given key. Will not
+// return an error if the key doesn't exist.
+// This method is part of the DataAccessor interface.
+func (db *memdb) Delete(key *database.Key) error {
+	return db.levelDB.Delete(key)
+}
+
+// AppendToStore appends the given data to the store
+// defined by storeName. This function returns a
+// serialized location handle that's meant to be stored
+// and later used when querying the data that has just
+// now been inserted.
+// This method is part of the DataAccessor interface.
+func (db *memdb) AppendToStore(storeName string, data []byte) ([]byte, error) {
+	return db.stores.append(storeName, data), nil
+}
+
+// RetrieveFromStore retrieves data from the store defined by
+// storeName using the given serialized location handle. It
+// returns ErrNotFound if the location does not exist. See
+// AppendToStore for further details.
+// This method is part of the DataAccessor interface.
+func (db *memdb) RetrieveFromStore(storeName string, location []byte) ([]byte, error) {
+	return db.stores.retrieve(storeName, location)
+}
+
+// PruneStore deletes the data in the store defined by
+// storeName that was appended before all the data at the
+// given location handles.
+// This method is part of the Database interface.
+func (db *memdb) PruneStore(storeName string, keptLocations [][]byte) error {
+	return db.stores.prune(storeName, keptLocations)
+}
+
+// Cursor begins a new cursor over the given bucket.
+// This method is part of the DataAccessor interface.
+func (db *memdb) Cursor(bucket *database.Bucket) (database.Cursor, error) {
+	ldbCursor := db.levelDB.Cursor(bucket)
+
+	return ldbCursor, nil
+}
+
+// Begin begins a new memdb transaction.
+// This method is part of the Database interface.
+func (db *memdb) Begin() (database.Transaction, error) {
+	ldbTx, err := db.levelDB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := &transaction{
+		ldbTx:    ldbTx,
+		stores:   db.stores,
+		isClosed: false,
+	}
+	return transaction, nil
+}
@@ -0,0 +1,108 @@
+package memdb
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/kaspanet/kaspad/database"
+	"github.com/pkg/errors"
+)
+
+// locationSize is the size in bytes of a serialized store
+// location, which is the big-endian index of the data
+// within its store.
+const locationSize = 8
+
+// stores holds the in-memory replacements of the flat file
+// stores of ffldb. Data that's appended to a store is kept
+// even if the transaction it was appended in is rolled back,
+// in the same way that it would be kept in a flat file.
+type stores struct {
+	stores map[string]*store
+	lock   sync.RWMutex
+}
+
+// store is a single in-memory store. Its data is indexed by
+// the order in which it was appended.
+type store struct {
+	data      map[uint64][]byte
+	nextIndex uint64
+}
+
+func newStores() *stores {
+	return &stores{
+		stores: make(map[string]*store),
+	}
+}
+
+func (s *stores) append(storeName string, data []byte) []byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	st, ok := s.stores[storeName]
+	if !ok {
+		st = &store{data: make(map[uint64][]byte)}
+		s.stores[storeName] = st
+	}
+	index := st.nextIndex
+	st.data[index] = append([]byte(nil), data...)
+	st.nextIndex++
+
+	location := make([]byte, locationSize)
+	binary.BigEndian.PutUint64(location, index)
+	return location
+}
+
+func (s *stores) retrieve(storeName string, location []byte) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	st, ok := s.stores[storeName]
+	if !ok || len(location) != locationSize {
+		return nil, errors.Wrapf(database.ErrNotFound,
+			"location %x not found in store %s", location, storeName)
+	}
+	data, ok := st.data[binary.BigEndian.Uint64(location)]
+	if !ok {
+		return nil, errors.Wrapf(database.ErrNotFound,
+			"location %x not found in store %s", location, storeName)
+	}
+	return data, nil
+}
+
+// prune deletes all the data in the given store that was appended
+// before the earliest of the given locations.
+func (s *stores) prune(storeName string, keptLocations [][]byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	st, ok := s.stores[storeName]
+	if !ok || len(keptLocations) == 0 {
+		return nil
+	}
+
+	earliestIndex := st.nextIndex
+	for _, location := range keptLocations {
+		if len(location) != locationSize {
+			return errors.Errorf("unexpected location length: %d", len(location))
+		}
+		index := binary.BigEndian.Uint64(location)
+		if index < earliestIndex {
+			earliestIndex = index
+		}
+	}
+
+	for index := range st.data {
+		if index < earliestIndex {
+			delete(st.data, index)
+		}
+	}
+	return nil
+}
+
+func (s *stores) clear() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.stores = make(map[string]*store)
+}
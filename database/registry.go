@@ -0,0 +1,73 @@
+package database
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Driver defines a database backend that can be opened by its type
+// using Open.
+type Driver struct {
+	// DbType is the unique type of the backend, which is used to select
+	// it using the --dbtype flag.
+	DbType string
+
+	// Open opens the database of this backend at the given path,
+	// creating it if it doesn't exist. Backends that don't persist
+	// their data may ignore the path.
+	Open func(path string) (Database, error)
+}
+
+var (
+	drivers     = make(map[string]*Driver)
+	driversLock sync.RWMutex
+)
+
+// RegisterDriver adds a backend database driver to the available drivers.
+// It returns an error if a driver with the same type had already been
+// registered. Backends are expected to call it from their init function.
+func RegisterDriver(driver Driver) error {
+	driversLock.Lock()
+	defer driversLock.Unlock()
+
+	if driver.DbType == "" {
+		return errors.New("driver type must not be empty")
+	}
+	if driver.Open == nil {
+		return errors.Errorf("driver %s has no Open function", driver.DbType)
+	}
+	if _, exists := drivers[driver.DbType]; exists {
+		return errors.Errorf("driver %s is already registered", driver.DbType)
+	}
+	drivers[driver.DbType] = &driver
+	return nil
+}
+
+// SupportedDrivers returns the sorted types of all the registered
+// database drivers.
+func SupportedDrivers() []string {
+	driversLock.RLock()
+	defer driversLock.RUnlock()
+
+	dbTypes := make([]string, 0, len(drivers))
+	for dbType := range drivers {
+		dbTypes = append(dbTypes, dbType)
+	}
+	sort.Strings(dbTypes)
+	return dbTypes
+}
+
+// Open opens the database of the given type at the given path. It returns
+// an error if no driver of the given type is registered.
+func Open(dbType string, path string) (Database, error) {
+	driversLock.RLock()
+	driver, exists := drivers[dbType]
+	driversLock.RUnlock()
+	if !exists {
+		return nil, errors.Errorf("unknown database type %s, supported "+
+			"types are %s", dbType, SupportedDrivers())
+	}
+	return driver.Open(path)
+}
@@ -58,6 +58,17 @@ func FetchAcceptanceData(context Context, hash *daghash.Hash) ([]byte, error) {
 	return acceptanceData, nil
 }
 
+// DeleteAcceptanceData deletes the acceptanceData of the given hash.
+func DeleteAcceptanceData(context Context, hash *daghash.Hash) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	key := acceptanceIndexKey(hash)
+	return accessor.Delete(key)
+}
+
 // DropAcceptanceIndex completely removes all acceptanceData entries.
 func DropAcceptanceIndex(dbTx *TxContext) error {
 	return clearBucket(dbTx, acceptanceIndexBucket)
@@ -0,0 +1,196 @@
+package dbaccess
+
+import (
+	"github.com/kaspanet/kaspad/database"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+var (
+	addressIndexTxsBucket            = database.MakeBucket([]byte("address-index-txs"))
+	addressIndexOutpointsBucket      = database.MakeBucket([]byte("address-index-outpoints"))
+	addressIndexOutpointOwnersBucket = database.MakeBucket([]byte("address-index-outpoint-owners"))
+	addressIndexIndexedTxsBucket     = database.MakeBucket([]byte("address-index-indexed-txs"))
+	addressIndexIndexedBlocksBucket  = database.MakeBucket([]byte("address-index-indexed-blocks"))
+)
+
+func addressIndexTxKey(scriptHash []byte, txKey []byte) *database.Key {
+	return addressIndexTxsBucket.Bucket(scriptHash).Key(txKey)
+}
+
+func addressIndexOutpointKey(scriptHash []byte, outpointKey []byte) *database.Key {
+	return addressIndexOutpointsBucket.Bucket(scriptHash).Key(outpointKey)
+}
+
+func addressIndexOutpointOwnerKey(outpointKey []byte) *database.Key {
+	return addressIndexOutpointOwnersBucket.Key(outpointKey)
+}
+
+func addressIndexIndexedTxKey(txID *daghash.TxID) *database.Key {
+	return addressIndexIndexedTxsBucket.Key(txID[:])
+}
+
+func addressIndexIndexedBlockKey(hash *daghash.Hash) *database.Key {
+	return addressIndexIndexedBlocksBucket.Key(hash[:])
+}
+
+// StoreAddressIndexTx stores the given address index transaction
+// entry under the given script hash.
+func StoreAddressIndexTx(context Context, scriptHash []byte, txKey []byte, entry []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	key := addressIndexTxKey(scriptHash, txKey)
+	return accessor.Put(key, entry)
+}
+
+// AddressIndexTxsCursor opens a cursor over all the address index
+// transaction entries that were stored under the given script hash.
+func AddressIndexTxsCursor(context Context, scriptHash []byte) (database.Cursor, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	return accessor.Cursor(addressIndexTxsBucket.Bucket(scriptHash))
+}
+
+// StoreAddressIndexOutpoint stores the given outpoint as an output
+// that pays to the given script hash.
+func StoreAddressIndexOutpoint(context Context, scriptHash []byte, outpointKey []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	err = accessor.Put(addressIndexOutpointKey(scriptHash, outpointKey), []byte{})
+	if err != nil {
+		return err
+	}
+	return accessor.Put(addressIndexOutpointOwnerKey(outpointKey), scriptHash)
+}
+
+// FetchAddressIndexOutpointOwner returns the script hash that the
+// given outpoint pays to. Returns ErrNotFound if the outpoint had
+// not been previously inserted into the database.
+func FetchAddressIndexOutpointOwner(context Context, outpointKey []byte) ([]byte, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	key := addressIndexOutpointOwnerKey(outpointKey)
+	scriptHash, err := accessor.Get(key)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			return nil, errors.Wrapf(err, "owner of outpoint %x not found", outpointKey)
+		}
+		return nil, err
+	}
+
+	return scriptHash, nil
+}
+
+// RemoveAddressIndexOutpoint removes the given outpoint from the
+// outputs that pay to its owner. Outpoints that had not been
+// previously inserted into the database are ignored.
+func RemoveAddressIndexOutpoint(context Context, outpointKey []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	ownerKey := addressIndexOutpointOwnerKey(outpointKey)
+	scriptHash, err := accessor.Get(ownerKey)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+
+	err = accessor.Delete(addressIndexOutpointKey(scriptHash, outpointKey))
+	if err != nil {
+		return err
+	}
+	return accessor.Delete(ownerKey)
+}
+
+// AddressIndexOutpointsCursor opens a cursor over all the outpoints
+// that were stored as paying to the given script hash.
+func AddressIndexOutpointsCursor(context Context, scriptHash []byte) (database.Cursor, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	return accessor.Cursor(addressIndexOutpointsBucket.Bucket(scriptHash))
+}
+
+// StoreAddressIndexIndexedTx marks the transaction of the given ID
+// as indexed by the address index.
+func StoreAddressIndexIndexedTx(context Context, txID *daghash.TxID) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	key := addressIndexIndexedTxKey(txID)
+	return accessor.Put(key, []byte{})
+}
+
+// HasAddressIndexIndexedTx returns whether the transaction of the
+// given ID has been previously indexed by the address index.
+func HasAddressIndexIndexedTx(context Context, txID *daghash.TxID) (bool, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return false, err
+	}
+
+	key := addressIndexIndexedTxKey(txID)
+	return accessor.Has(key)
+}
+
+// StoreAddressIndexIndexedBlock marks the block of the given hash
+// as indexed by the address index.
+func StoreAddressIndexIndexedBlock(context Context, hash *daghash.Hash) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	key := addressIndexIndexedBlockKey(hash)
+	return accessor.Put(key, []byte{})
+}
+
+// HasAddressIndexIndexedBlock returns whether the block of the given
+// hash has been previously indexed by the address index.
+func HasAddressIndexIndexedBlock(context Context, hash *daghash.Hash) (bool, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return false, err
+	}
+
+	key := addressIndexIndexedBlockKey(hash)
+	return accessor.Has(key)
+}
+
+// DropAddressIndex completely removes all address index entries.
+func DropAddressIndex(dbTx *TxContext) error {
+	buckets := []*database.Bucket{
+		addressIndexTxsBucket,
+		addressIndexOutpointsBucket,
+		addressIndexOutpointOwnersBucket,
+		addressIndexIndexedTxsBucket,
+		addressIndexIndexedBlocksBucket,
+	}
+	for _, bucket := range buckets {
+		err := clearBucket(dbTx, bucket)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
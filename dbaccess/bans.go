@@ -0,0 +1,26 @@
+package dbaccess
+
+import "github.com/kaspanet/kaspad/database"
+
+var (
+	bansKey = database.MakeBucket().Key([]byte("bans"))
+)
+
+// StoreBans stores the banned subnets in the database.
+func StoreBans(context Context, bans []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+	return accessor.Put(bansKey, bans)
+}
+
+// FetchBans retrieves the banned subnets from the database.
+// Returns ErrNotFound if the bans are missing from the database.
+func FetchBans(context Context) ([]byte, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+	return accessor.Get(bansKey)
+}
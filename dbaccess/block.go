@@ -88,3 +88,57 @@ func FetchBlock(context Context, hash *daghash.Hash) ([]byte, error) {
 
 	return bytes, nil
 }
+
+// BlockLocationsCursor opens a cursor over the locations of all the
+// blocks that have been previously inserted into the database. The
+// suffix of every key is the hash of a block.
+func BlockLocationsCursor(context Context) (database.Cursor, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	return accessor.Cursor(blockLocationsBucket)
+}
+
+// DeleteBlock deletes the block of the given hash from the
+// database. The block's bytes remain in the block store until
+// PruneBlockStore is called.
+func DeleteBlock(context *TxContext, hash *daghash.Hash) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	blockLocationsKey := blockLocationKey(hash)
+	return accessor.Delete(blockLocationsKey)
+}
+
+// PruneBlockStore reclaims the space of the blocks that were
+// appended to the block store before all the blocks of the given
+// hashes. The given hashes must include the earliest stored block
+// that is kept. Blocks that are not in the database are ignored.
+func PruneBlockStore(context Context, keptHashes []*daghash.Hash) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	keptLocations := make([][]byte, 0, len(keptHashes))
+	for _, hash := range keptHashes {
+		blockLocation, err := accessor.Get(blockLocationKey(hash))
+		if database.IsNotFoundError(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		keptLocations = append(keptLocations, blockLocation)
+	}
+
+	db, err := db()
+	if err != nil {
+		return err
+	}
+	return db.PruneStore(blockStoreName, keptLocations)
+}
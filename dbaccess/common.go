@@ -23,7 +23,11 @@ func clearBucket(dbTx *TxContext, bucket *database.Bucket) error {
 		if err != nil {
 			return err
 		}
-		keys = append(keys, key)
+		// The suffix of the key may change on the next call to
+		// Next, so it's copied.
+		suffix := make([]byte, len(key.Suffix()))
+		copy(suffix, key.Suffix())
+		keys = append(keys, bucket.Key(suffix))
 	}
 
 	// Delete all of the keys
@@ -2,8 +2,13 @@ package dbaccess
 
 import (
 	"github.com/kaspanet/kaspad/database"
-	"github.com/kaspanet/kaspad/database/ffldb"
 	"github.com/pkg/errors"
+
+	"github.com/kaspanet/kaspad/database/ffldb"
+
+	// Register the rest of the database backends
+	_ "github.com/kaspanet/kaspad/database/logdb"
+	_ "github.com/kaspanet/kaspad/database/memdb"
 )
 
 // dbSingleton is a handle to an instance of the kaspad database
@@ -19,11 +24,17 @@ func db() (database.Database, error) {
 
 // Open opens the database for given path
 func Open(path string) error {
+	return OpenWithDriver(ffldb.DbType, path)
+}
+
+// OpenWithDriver opens the database for given path using the driver of
+// the given type. See database.SupportedDrivers for the available types.
+func OpenWithDriver(dbType string, path string) error {
 	if dbSingleton != nil {
 		return errors.New("database is already open")
 	}
 
-	db, err := ffldb.Open(path)
+	db, err := database.Open(dbType, path)
 	if err != nil {
 		return err
 	}
@@ -37,3 +37,14 @@ func StoreFeeData(context Context, blockHash *daghash.Hash, feeData []byte) erro
 	key := feeDataKey(blockHash)
 	return accessor.Put(key, feeData)
 }
+
+// DeleteFeeData deletes the fee data of a block by its hash.
+func DeleteFeeData(context Context, blockHash *daghash.Hash) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	key := feeDataKey(blockHash)
+	return accessor.Delete(key)
+}
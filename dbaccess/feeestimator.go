@@ -0,0 +1,26 @@
+package dbaccess
+
+import "github.com/kaspanet/kaspad/database"
+
+var (
+	feeEstimatorStateKey = database.MakeBucket().Key([]byte("fee-estimator-state"))
+)
+
+// StoreFeeEstimatorState stores the fee estimator state in the database.
+func StoreFeeEstimatorState(context Context, feeEstimatorState []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+	return accessor.Put(feeEstimatorStateKey, feeEstimatorState)
+}
+
+// FetchFeeEstimatorState retrieves the fee estimator state from the database.
+// Returns ErrNotFound if the state is missing from the database.
+func FetchFeeEstimatorState(context Context) ([]byte, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+	return accessor.Get(feeEstimatorStateKey)
+}
@@ -0,0 +1,26 @@
+package dbaccess
+
+import "github.com/kaspanet/kaspad/database"
+
+var (
+	mempoolKey = database.MakeBucket().Key([]byte("mempool"))
+)
+
+// StoreMempool stores the serialized mempool transactions in the database.
+func StoreMempool(context Context, serializedMempool []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+	return accessor.Put(mempoolKey, serializedMempool)
+}
+
+// FetchMempool retrieves the serialized mempool transactions from the
+// database. Returns ErrNotFound if they are missing from the database.
+func FetchMempool(context Context) ([]byte, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+	return accessor.Get(mempoolKey)
+}
@@ -0,0 +1,38 @@
+package dbaccess
+
+import (
+	"encoding/binary"
+
+	"github.com/kaspanet/kaspad/database"
+)
+
+var (
+	prunedBlueScoreKey = database.MakeBucket().Key([]byte("pruned-blue-score"))
+)
+
+// StorePrunedBlueScore stores the blue score below which
+// block data was pruned.
+func StorePrunedBlueScore(context Context, blueScore uint64) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+	serializedBlueScore := make([]byte, 8)
+	binary.LittleEndian.PutUint64(serializedBlueScore, blueScore)
+	return accessor.Put(prunedBlueScoreKey, serializedBlueScore)
+}
+
+// FetchPrunedBlueScore retrieves the blue score below which
+// block data was pruned. Returns ErrNotFound if no block data
+// was ever pruned.
+func FetchPrunedBlueScore(context Context) (uint64, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return 0, err
+	}
+	serializedBlueScore, err := accessor.Get(prunedBlueScoreKey)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(serializedBlueScore), nil
+}
@@ -0,0 +1,170 @@
+package dbaccess
+
+import (
+	"github.com/kaspanet/kaspad/database"
+	"github.com/pkg/errors"
+)
+
+var (
+	unverifiedSnapshotKey = database.MakeBucket().Key([]byte("unverified-snapshot"))
+)
+
+// snapshotBuckets are the buckets whose entries make up a UTXO snapshot,
+// along with snapshotKeys. Together they hold the UTXO set of the virtual
+// block and all the DAG state that's needed in order to keep processing
+// blocks on top of it. Block bodies and optional indexes are not a part
+// of them.
+//
+// The order of the buckets and keys defines the section numbers of their
+// entries, so it must never change. New buckets and keys may only be
+// appended.
+var snapshotBuckets = []*database.Bucket{
+	utxoBucket,
+	blockIndexBucket,
+	reachabilityDataBucket,
+	multisetBucket,
+	utxoDiffsBucket,
+	subnetworkBucket,
+	feeBucket,
+}
+
+var snapshotKeys = []*database.Key{
+	dagStateKey,
+	reachabilityReindexKey,
+}
+
+// The sections of the entries in a UTXO snapshot. They follow the order of
+// snapshotBuckets and snapshotKeys.
+const (
+	SnapshotUTXOSection byte = iota
+	SnapshotBlockIndexSection
+	SnapshotReachabilityDataSection
+	SnapshotMultisetSection
+	SnapshotUTXODiffSection
+	SnapshotSubnetworkSection
+	SnapshotFeeDataSection
+	SnapshotDAGStateSection
+	SnapshotReachabilityReindexSection
+)
+
+// SnapshotEntry is a single database entry that's a part of a UTXO
+// snapshot. Section identifies the bucket or the key of the entry, and
+// Suffix is the suffix of the entry's key within its bucket.
+type SnapshotEntry struct {
+	Section byte
+	Suffix  []byte
+	Value   []byte
+}
+
+// ForEachSnapshotEntry calls the given function with every database entry
+// that's a part of a UTXO snapshot. The entry passed to the function is
+// only valid until the function returns.
+func ForEachSnapshotEntry(context Context, fn func(entry *SnapshotEntry) error) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	for i, bucket := range snapshotBuckets {
+		err := func() error {
+			cursor, err := accessor.Cursor(bucket)
+			if err != nil {
+				return err
+			}
+			defer cursor.Close()
+
+			for cursor.Next() {
+				key, err := cursor.Key()
+				if err != nil {
+					return err
+				}
+				value, err := cursor.Value()
+				if err != nil {
+					return err
+				}
+				err = fn(&SnapshotEntry{
+					Section: byte(i),
+					Suffix:  key.Suffix(),
+					Value:   value,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, key := range snapshotKeys {
+		value, err := accessor.Get(key)
+		if IsNotFoundError(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = fn(&SnapshotEntry{
+			Section: byte(len(snapshotBuckets) + i),
+			Value:   value,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StoreSnapshotEntry stores an entry that was read from a UTXO snapshot
+// in the database.
+func StoreSnapshotEntry(context Context, entry *SnapshotEntry) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	section := int(entry.Section)
+	if section < len(snapshotBuckets) {
+		return accessor.Put(snapshotBuckets[section].Key(entry.Suffix), entry.Value)
+	}
+	keyIndex := section - len(snapshotBuckets)
+	if keyIndex >= len(snapshotKeys) || len(entry.Suffix) != 0 {
+		return errors.Errorf("invalid snapshot entry in section %d", section)
+	}
+	return accessor.Put(snapshotKeys[keyIndex], entry.Value)
+}
+
+// StoreUnverifiedSnapshot marks the DAG state in the database as loaded from
+// a UTXO snapshot at the block with the given hash, which was not verified
+// yet.
+func StoreUnverifiedSnapshot(context Context, serializedHash []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+	return accessor.Put(unverifiedSnapshotKey, serializedHash)
+}
+
+// FetchUnverifiedSnapshot retrieves the hash of the block at which the DAG
+// state in the database was loaded from a UTXO snapshot, if it was not
+// verified yet. Returns ErrNotFound if there's no such snapshot.
+func FetchUnverifiedSnapshot(context Context) ([]byte, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+	return accessor.Get(unverifiedSnapshotKey)
+}
+
+// DeleteUnverifiedSnapshot marks the UTXO snapshot that the DAG state in the
+// database was loaded from as verified.
+func DeleteUnverifiedSnapshot(context Context) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+	return accessor.Delete(unverifiedSnapshotKey)
+}
@@ -0,0 +1,98 @@
+package dbaccess
+
+import (
+	"github.com/kaspanet/kaspad/database"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+var (
+	txIndexBucket              = database.MakeBucket([]byte("tx-index"))
+	txIndexIndexedBlocksBucket = database.MakeBucket([]byte("tx-index-indexed-blocks"))
+)
+
+func txIndexKey(txID *daghash.TxID) *database.Key {
+	return txIndexBucket.Key(txID[:])
+}
+
+func txIndexIndexedBlockKey(hash *daghash.Hash) *database.Key {
+	return txIndexIndexedBlocksBucket.Key(hash[:])
+}
+
+// StoreTxIndexEntry stores the given transaction index entry
+// in the database.
+func StoreTxIndexEntry(context Context, txID *daghash.TxID, entry []byte) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	key := txIndexKey(txID)
+	return accessor.Put(key, entry)
+}
+
+// HasTxIndexEntry returns whether a transaction index entry for the
+// given transaction ID has been previously inserted into the database.
+func HasTxIndexEntry(context Context, txID *daghash.TxID) (bool, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return false, err
+	}
+
+	key := txIndexKey(txID)
+	return accessor.Has(key)
+}
+
+// FetchTxIndexEntry returns the transaction index entry of the given
+// transaction ID. Returns ErrNotFound if the entry had not been
+// previously inserted into the database.
+func FetchTxIndexEntry(context Context, txID *daghash.TxID) ([]byte, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return nil, err
+	}
+
+	key := txIndexKey(txID)
+	entry, err := accessor.Get(key)
+	if err != nil {
+		if database.IsNotFoundError(err) {
+			return nil, errors.Wrapf(err, "tx index entry not found for tx %s", txID)
+		}
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// StoreTxIndexIndexedBlock marks the block of the given hash as
+// indexed by the transaction index.
+func StoreTxIndexIndexedBlock(context Context, hash *daghash.Hash) error {
+	accessor, err := context.accessor()
+	if err != nil {
+		return err
+	}
+
+	key := txIndexIndexedBlockKey(hash)
+	return accessor.Put(key, []byte{})
+}
+
+// HasTxIndexIndexedBlock returns whether the block of the given hash
+// has been previously indexed by the transaction index.
+func HasTxIndexIndexedBlock(context Context, hash *daghash.Hash) (bool, error) {
+	accessor, err := context.accessor()
+	if err != nil {
+		return false, err
+	}
+
+	key := txIndexIndexedBlockKey(hash)
+	return accessor.Has(key)
+}
+
+// DropTxIndex completely removes all transaction index entries.
+func DropTxIndex(dbTx *TxContext) error {
+	err := clearBucket(dbTx, txIndexBucket)
+	if err != nil {
+		return err
+	}
+	return clearBucket(dbTx, txIndexIndexedBlocksBucket)
+}
@@ -5,6 +5,7 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
 	_ "net/http/pprof"
 	"os"
@@ -16,22 +17,30 @@ import (
 
 	"github.com/kaspanet/kaspad/dbaccess"
 
+	"github.com/kaspanet/kaspad/blockdag"
 	"github.com/kaspanet/kaspad/blockdag/indexers"
 	"github.com/kaspanet/kaspad/config"
+	"github.com/kaspanet/kaspad/database"
+	"github.com/kaspanet/kaspad/database/ffldb"
+	"github.com/kaspanet/kaspad/database/memdb"
 	"github.com/kaspanet/kaspad/limits"
 	"github.com/kaspanet/kaspad/server"
 	"github.com/kaspanet/kaspad/signal"
+	"github.com/kaspanet/kaspad/txscript"
 	"github.com/kaspanet/kaspad/util/fs"
 	"github.com/kaspanet/kaspad/util/panics"
 	"github.com/kaspanet/kaspad/util/profiling"
 	"github.com/kaspanet/kaspad/version"
+	"github.com/pkg/errors"
 )
 
 const (
 	// blockDbNamePrefix is the prefix for the block database name. The
 	// database type is appended to this value to form the full block
-	// database name.
-	blockDbNamePrefix = "blocks"
+	// database name, except for ffldb databases, which are named by the
+	// prefix alone, as they were before other database types were
+	// supported.
+	blockDbNamePrefix = "db"
 )
 
 var (
@@ -130,10 +139,42 @@ func kaspadMain(serverChan chan<- *server.Server) error {
 			kasdLog.Errorf("%s", err)
 			return err
 		}
+	}
+	if cfg.DropTxIndex {
+		if err := indexers.DropTxIndex(); err != nil {
+			kasdLog.Errorf("%s", err)
+			return err
+		}
+	}
+	if cfg.DropAddrIndex {
+		if err := indexers.DropAddressIndex(); err != nil {
+			kasdLog.Errorf("%s", err)
+			return err
+		}
+	}
+	if cfg.DropAcceptanceIndex || cfg.DropTxIndex || cfg.DropAddrIndex {
+		return nil
+	}
 
+	// Check the integrity of the database and exit if requested.
+	if cfg.CheckDB > 0 {
+		err := checkDatabase(cfg.CheckDB, !cfg.CheckDBNoRepair, interrupt)
+		if err != nil {
+			kasdLog.Errorf("%s", err)
+			return err
+		}
 		return nil
 	}
 
+	// Load the UTXO snapshot into the database if requested.
+	if cfg.LoadSnapshot != "" {
+		err := loadUTXOSnapshot(cfg.LoadSnapshot)
+		if err != nil {
+			kasdLog.Errorf("Unable to load UTXO snapshot: %s", err)
+			return err
+		}
+	}
+
 	// Create server and start it.
 	server, err := server.NewServer(cfg.Listeners, config.ActiveConfig().NetParams(),
 		interrupt)
@@ -206,12 +247,12 @@ func removeRegressionDB(dbPath string) error {
 	return nil
 }
 
-// dbPath returns the path to the block database given a database type.
+// blockDbPath returns the path to the block database given a database type.
 func blockDbPath(dbType string) string {
 	// The database name is based on the database type.
-	dbName := blockDbNamePrefix + "_" + dbType
-	if dbType == "sqlite" {
-		dbName = dbName + ".db"
+	dbName := blockDbNamePrefix
+	if dbType != ffldb.DbType {
+		dbName = dbName + "_" + dbType
 	}
 	dbPath := filepath.Join(cfg.DataDir, dbName)
 	return dbPath
@@ -221,10 +262,7 @@ func blockDbPath(dbType string) string {
 // This is not a situation most users want. It is handy for development however
 // to support multiple side-by-side databases.
 func warnMultipleDBs() {
-	// This is intentionally not using the known db types which depend
-	// on the database types compiled into the binary since we want to
-	// detect legacy db types as well.
-	dbTypes := []string{"ffldb", "leveldb", "sqlite"}
+	dbTypes := database.SupportedDrivers()
 	duplicateDbPaths := make([]string, 0, len(dbTypes)-1)
 	for _, dbType := range dbTypes {
 		if dbType == cfg.DbType {
@@ -251,15 +289,110 @@ func warnMultipleDBs() {
 }
 
 func openDB() error {
-	dbPath := filepath.Join(cfg.DataDir, "db")
-	kasdLog.Infof("Loading database from '%s'", dbPath)
-	err := dbaccess.Open(dbPath)
+	if cfg.DbType == memdb.DbType {
+		kasdLog.Infof("Loading a %s database, which is kept in memory "+
+			"and will be lost on shutdown", cfg.DbType)
+		return dbaccess.OpenWithDriver(cfg.DbType, "")
+	}
+
+	warnMultipleDBs()
+
+	dbPath := blockDbPath(cfg.DbType)
+	kasdLog.Infof("Loading %s database from '%s'", cfg.DbType, dbPath)
+	err := dbaccess.OpenWithDriver(cfg.DbType, dbPath)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// loadUTXOSnapshot loads the UTXO snapshot in the given file into the
+// database. It's verified once the DAG is created.
+func loadUTXOSnapshot(path string) error {
+	kasdLog.Infof("Loading UTXO snapshot from '%s'", path)
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = blockdag.LoadUTXOSnapshot(bufio.NewReader(file), cfg.NetParams())
+	return err
+}
+
+// checkDatabase checks the integrity of the database at the given level,
+// and repairs the inconsistencies that can be repaired if repair is true.
+// The block index and the block store are checked before the DAG is loaded,
+// since loading it fails if they are inconsistent.
+func checkDatabase(level int, repair bool, interrupt <-chan struct{}) error {
+	kasdLog.Infof("Checking the integrity of the database at level %d", level)
+	issues, err := blockdag.CheckStoreIntegrity(level, repair)
 	if err != nil {
 		return err
 	}
+
+	dag, err := blockdag.New(&blockdag.Config{
+		Interrupt:    interrupt,
+		DAGParams:    cfg.NetParams(),
+		TimeSource:   blockdag.NewTimeSource(),
+		SigCache:     txscript.NewSigCache(cfg.SigCacheMaxSize),
+		SubnetworkID: cfg.SubnetworkID,
+	})
+	if err != nil {
+		logIntegrityIssues(issues)
+		return errors.Wrap(err, "failed to load the DAG from the database")
+	}
+
+	dagIssues, err := dag.CheckIntegrity(level, repair)
+	if err != nil {
+		return err
+	}
+	issues = append(issues, dagIssues...)
+	if cfg.AcceptanceIndex {
+		kasdLog.Infof("Checking the acceptance index...")
+		issue, err := indexers.NewAcceptanceIndex().CheckIntegrity(dag, repair)
+		if err != nil {
+			return err
+		}
+		if issue != nil {
+			issues = append(issues, issue)
+		}
+	}
+
+	unrepairedCount := logIntegrityIssues(issues)
+	if !repair && len(issues) > 0 {
+		return errors.Errorf("found %d inconsistencies in the database", len(issues))
+	}
+	if unrepairedCount > 0 {
+		return errors.Errorf("%d of the %d inconsistencies that were found "+
+			"in the database were not repaired -- missing block bodies and "+
+			"a corrupted block index or UTXO set require the database to be reset with "+
+			"--reset-db", unrepairedCount, len(issues))
+	}
+	if len(issues) == 0 {
+		kasdLog.Infof("No inconsistencies were found in the database")
+		return nil
+	}
+	kasdLog.Infof("Repaired all %d inconsistencies that were found in "+
+		"the database", len(issues))
 	return nil
 }
 
+// logIntegrityIssues logs the given integrity issues and returns how many
+// of them were not repaired.
+func logIntegrityIssues(issues []*blockdag.IntegrityIssue) int {
+	unrepairedCount := 0
+	for _, issue := range issues {
+		if issue.IsRepaired {
+			kasdLog.Warnf("%s", issue)
+			continue
+		}
+		kasdLog.Errorf("%s", issue)
+		unrepairedCount++
+	}
+	return unrepairedCount
+}
+
 func main() {
 	// Use all processor cores.
 	runtime.GOMAXPROCS(runtime.NumCPU())
@@ -0,0 +1,100 @@
+package mempool
+
+import (
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// addToShortIDIndex adds the given transaction to the short ID index, which
+// holds every transaction in the main pool, the depend pool and the orphan
+// pool by its short ID.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) addToShortIDIndex(tx *util.Tx) {
+	shortID := wire.ShortTxID(tx.ID())
+	txs, ok := mp.shortIDs[shortID]
+	if !ok {
+		txs = make(map[daghash.TxID]*util.Tx, 1)
+		mp.shortIDs[shortID] = txs
+	}
+	txs[*tx.ID()] = tx
+}
+
+// removeFromShortIDIndex removes the given transaction from the short ID
+// index, unless it's still in one of the pools. An orphan, for example, is
+// removed from the orphan pool only after it's accepted to the main pool.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) removeFromShortIDIndex(tx *util.Tx) {
+	txID := tx.ID()
+	if mp.isTransactionInPool(txID) || mp.isOrphanInPool(txID) {
+		return
+	}
+	shortID := wire.ShortTxID(txID)
+	txs, ok := mp.shortIDs[shortID]
+	if !ok {
+		return
+	}
+	delete(txs, *txID)
+	if len(txs) == 0 {
+		delete(mp.shortIDs, shortID)
+	}
+}
+
+// FillCompactBlock looks up the transactions of the given compact block in
+// the pool, including the orphan pool, by their short IDs. It returns the
+// transactions of the block by their index, where the prefilled transactions
+// are taken from the compact block and the transactions that weren't found
+// are nil, along with the indexes of the transactions that weren't found.
+//
+// A short ID that matches more than one transaction, or that appears more
+// than once in the compact block, is treated as if it matched none, since
+// the right transaction can't be told apart.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) FillCompactBlock(msg *wire.MsgCmpctBlock) (txs []*wire.MsgTx, missingIndexes []uint32) {
+	txs = make([]*wire.MsgTx, msg.TxCount())
+	for _, prefilledTx := range msg.PrefilledTxs {
+		txs[prefilledTx.Index] = prefilledTx.Tx
+	}
+
+	// Map every short ID to the index it stands for. The short IDs fill
+	// the indexes that were not prefilled, in order.
+	const ambiguousIndex = -1
+	shortIDIndexes := make(map[uint64]int, len(msg.ShortIDs))
+	index := 0
+	for _, shortID := range msg.ShortIDs {
+		for txs[index] != nil {
+			index++
+		}
+		if _, ok := shortIDIndexes[shortID]; ok {
+			shortIDIndexes[shortID] = ambiguousIndex
+		} else {
+			shortIDIndexes[shortID] = index
+		}
+		index++
+	}
+
+	mp.mtx.RLock()
+	for shortID, index := range shortIDIndexes {
+		if index == ambiguousIndex {
+			continue
+		}
+		matchingTxs := mp.shortIDs[shortID]
+		if len(matchingTxs) != 1 {
+			continue
+		}
+		for _, tx := range matchingTxs {
+			txs[index] = tx.MsgTx()
+		}
+	}
+	mp.mtx.RUnlock()
+
+	for i, tx := range txs {
+		if tx == nil {
+			missingIndexes = append(missingIndexes, uint32(i))
+		}
+	}
+	return txs, missingIndexes
+}
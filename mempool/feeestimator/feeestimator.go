@@ -0,0 +1,380 @@
+package feeestimator
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math"
+	"sync"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/mining"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxTargetBlueScoreDelta is the maximum number of blue score units
+	// within which a transaction may be requested to be accepted by the
+	// virtual block. Observed transactions that take longer than that to
+	// be accepted are considered to have failed.
+	MaxTargetBlueScoreDelta = 100
+
+	// bucketCount is the number of fee rate buckets the estimator keeps
+	// statistics for.
+	bucketCount = 50
+
+	// minBucketFeeRate is the lower bound of the lowest fee rate bucket,
+	// in sompi per megagram.
+	minBucketFeeRate = 1e5
+
+	// bucketFeeRateSpacing is the ratio between the lower bounds of two
+	// consecutive fee rate buckets.
+	bucketFeeRateSpacing = 1.2
+
+	// decayFactor is the factor by which all the recorded statistics are
+	// multiplied whenever the blue score of the virtual block grows by
+	// one. This makes the estimator favor recent data over old data.
+	decayFactor = 0.998
+
+	// successThreshold is the minimal ratio of transactions in a group of
+	// buckets that must have been accepted within the target blue score
+	// delta for the group to be considered successful.
+	successThreshold = 0.85
+
+	// minSamples is the minimal (decayed) number of transactions a group
+	// of buckets must contain before its success rate is evaluated.
+	minSamples = 10
+
+	// serializationVersion is the current version of the serialized fee
+	// estimator state.
+	serializationVersion = 1
+
+	// DefaultFeeRate is the fee rate, in sompi per megagram, that should
+	// be used while EstimateFee returns ErrInsufficientData. It equals
+	// the default minimum relay fee of 1e-5 KAS/kB, since a kB roughly
+	// equals a kilogram of mass.
+	DefaultFeeRate = 1e6
+)
+
+// ErrInsufficientData is returned from EstimateFee when the estimator had
+// not yet observed enough transactions to give a meaningful estimate.
+var ErrInsufficientData = errors.New("insufficient data to estimate fee")
+
+// feeRateBucket holds statistics about the transactions whose fee rate
+// falls within a single fee rate bucket.
+type feeRateBucket struct {
+	// AcceptedAt holds, for every blue score delta, the decayed number of
+	// transactions that were accepted by the virtual block exactly that
+	// many blue score units after they were observed.
+	AcceptedAt [MaxTargetBlueScoreDelta + 1]float64
+
+	// Total is the decayed number of transactions that were either
+	// accepted or considered to have failed.
+	Total float64
+}
+
+// observedTx is a transaction that had been admitted into the mempool and
+// was not yet accepted by the virtual block.
+type observedTx struct {
+	feeRate   uint64
+	blueScore uint64
+}
+
+// serializedState is the data model that is used to persist the fee
+// estimator state across restarts.
+type serializedState struct {
+	Version       int
+	LastBlueScore uint64
+	Buckets       []*feeRateBucket
+}
+
+// FeeEstimator learns how long transactions of different fee rates take to
+// be accepted by the virtual block, and uses that knowledge to estimate the
+// fee rate a new transaction should pay in order to be accepted within a
+// given number of blue score units.
+//
+// The estimator is fed by the mempool, which reports every transaction it
+// admits, and by the DAG, which reports the transactions accepted by every
+// block that gets added to the selected parent chain.
+type FeeEstimator struct {
+	mtx           sync.Mutex
+	dag           *blockdag.BlockDAG
+	buckets       [bucketCount]*feeRateBucket
+	observed      map[daghash.TxID]*observedTx
+	lastBlueScore uint64
+}
+
+// New returns a new fee estimator that learns from the given DAG. The
+// previously saved estimator state is loaded from the database, if any.
+func New(dag *blockdag.BlockDAG) (*FeeEstimator, error) {
+	fe := newFeeEstimator(dag)
+	err := fe.load()
+	if err != nil {
+		return nil, err
+	}
+	dag.Subscribe(fe.handleBlockDAGNotification)
+	return fe, nil
+}
+
+func newFeeEstimator(dag *blockdag.BlockDAG) *FeeEstimator {
+	fe := &FeeEstimator{
+		dag:      dag,
+		observed: make(map[daghash.TxID]*observedTx),
+	}
+	for i := range fe.buckets {
+		fe.buckets[i] = &feeRateBucket{}
+	}
+	return fe
+}
+
+// ObserveTransaction is called by the mempool whenever a new transaction is
+// admitted into it.
+//
+// This function is safe for concurrent access.
+func (fe *FeeEstimator) ObserveTransaction(txDesc *mining.TxDesc) {
+	fe.mtx.Lock()
+	defer fe.mtx.Unlock()
+
+	fe.observeTransaction(txDesc.Tx.ID(), txDesc.FeePerMegaGram, fe.dag.VirtualBlueScore())
+}
+
+func (fe *FeeEstimator) observeTransaction(txID *daghash.TxID, feeRate uint64, blueScore uint64) {
+	if _, ok := fe.observed[*txID]; ok {
+		return
+	}
+	fe.observed[*txID] = &observedTx{
+		feeRate:   feeRate,
+		blueScore: blueScore,
+	}
+}
+
+// handleBlockDAGNotification handles notifications from the DAG. The
+// transactions that were accepted by the blocks that were added to the
+// selected parent chain are considered to be accepted by the virtual block.
+//
+// Blocks that were removed from the selected parent chain are ignored, so
+// a transaction that was accepted by one of them is not observed again.
+// Since such reorganizations are rare and shallow, this barely affects the
+// estimates.
+func (fe *FeeEstimator) handleBlockDAGNotification(notification *blockdag.Notification) {
+	if notification.Type != blockdag.NTChainChanged {
+		return
+	}
+	data, ok := notification.Data.(*blockdag.ChainChangedNotificationData)
+	if !ok {
+		log.Warnf("Chain changed notification data is of wrong type.")
+		return
+	}
+
+	addedChainBlocksAcceptanceData, err := data.AddedChainBlocksAcceptanceData()
+	if err != nil {
+		log.Warnf("Failed to get the acceptance data of the added chain blocks: %s", err)
+		return
+	}
+
+	fe.mtx.Lock()
+	defer fe.mtx.Unlock()
+
+	fe.registerAcceptedTransactions(addedChainBlocksAcceptanceData, fe.dag.VirtualBlueScore())
+}
+
+func (fe *FeeEstimator) registerAcceptedTransactions(
+	addedChainBlocksAcceptanceData []blockdag.MultiBlockTxsAcceptanceData, blueScore uint64) {
+
+	fe.advanceBlueScore(blueScore)
+
+	for _, txsAcceptanceData := range addedChainBlocksAcceptanceData {
+		for _, blockTxsAcceptanceData := range txsAcceptanceData {
+			for _, txAcceptanceData := range blockTxsAcceptanceData.TxAcceptanceData {
+				if !txAcceptanceData.IsAccepted {
+					continue
+				}
+				fe.registerAcceptedTransaction(txAcceptanceData.Tx.ID(), blueScore)
+			}
+		}
+	}
+
+	// Transactions that were not accepted within the maximum target blue
+	// score delta are considered to have failed.
+	for txID, observed := range fe.observed {
+		if blueScore > observed.blueScore+MaxTargetBlueScoreDelta {
+			fe.buckets[bucketIndex(observed.feeRate)].Total++
+			delete(fe.observed, txID)
+		}
+	}
+}
+
+func (fe *FeeEstimator) registerAcceptedTransaction(txID *daghash.TxID, blueScore uint64) {
+	observed, ok := fe.observed[*txID]
+	if !ok {
+		return
+	}
+	delete(fe.observed, *txID)
+
+	blueScoreDelta := uint64(0)
+	if blueScore > observed.blueScore {
+		blueScoreDelta = blueScore - observed.blueScore
+	}
+	bucket := fe.buckets[bucketIndex(observed.feeRate)]
+	if blueScoreDelta <= MaxTargetBlueScoreDelta {
+		bucket.AcceptedAt[blueScoreDelta]++
+	}
+	bucket.Total++
+}
+
+// advanceBlueScore decays all the recorded statistics by the number of
+// blue score units that passed since the last time it was called.
+func (fe *FeeEstimator) advanceBlueScore(blueScore uint64) {
+	if blueScore <= fe.lastBlueScore {
+		return
+	}
+	decay := math.Pow(decayFactor, float64(blueScore-fe.lastBlueScore))
+	fe.lastBlueScore = blueScore
+
+	for _, bucket := range fe.buckets {
+		for i := range bucket.AcceptedAt {
+			bucket.AcceptedAt[i] *= decay
+		}
+		bucket.Total *= decay
+	}
+}
+
+// EstimateFee returns the minimal fee rate, in sompi per megagram, a
+// transaction should pay in order to be accepted by the virtual block
+// within the given number of blue score units. Returns ErrInsufficientData
+// if not enough transactions were observed to estimate that.
+//
+// This function is safe for concurrent access.
+func (fe *FeeEstimator) EstimateFee(targetBlueScoreDelta uint64) (uint64, error) {
+	if targetBlueScoreDelta == 0 || targetBlueScoreDelta > MaxTargetBlueScoreDelta {
+		return 0, errors.Errorf("target blue score delta must be "+
+			"between 1 and %d", MaxTargetBlueScoreDelta)
+	}
+
+	fe.mtx.Lock()
+	defer fe.mtx.Unlock()
+
+	// Starting from the highest fee rate, group consecutive buckets until
+	// every group holds enough samples, and find the lowest group in which
+	// enough of the transactions were accepted in time.
+	bestBucketIndex := -1
+	var groupAccepted, groupTotal float64
+	for i := bucketCount - 1; i >= 0; i-- {
+		bucket := fe.buckets[i]
+		for blueScoreDelta := uint64(0); blueScoreDelta <= targetBlueScoreDelta; blueScoreDelta++ {
+			groupAccepted += bucket.AcceptedAt[blueScoreDelta]
+		}
+		groupTotal += bucket.Total
+		if groupTotal < minSamples {
+			continue
+		}
+		if groupAccepted/groupTotal < successThreshold {
+			break
+		}
+		bestBucketIndex = i
+		groupAccepted = 0
+		groupTotal = 0
+	}
+	if bestBucketIndex == -1 {
+		return 0, ErrInsufficientData
+	}
+
+	// Return the upper bound of the bucket, so that the estimated fee
+	// rate is never lower than the fee rate of the transactions that
+	// were actually accepted in time.
+	return bucketFeeRate(bestBucketIndex + 1), nil
+}
+
+// bucketIndex returns the index of the bucket the given fee rate
+// falls in.
+func bucketIndex(feeRate uint64) int {
+	if feeRate < minBucketFeeRate {
+		return 0
+	}
+	index := int(math.Log(float64(feeRate)/minBucketFeeRate) / math.Log(bucketFeeRateSpacing))
+	if index >= bucketCount {
+		return bucketCount - 1
+	}
+	return index
+}
+
+// bucketFeeRate returns the lower bound of the bucket of the given index.
+func bucketFeeRate(index int) uint64 {
+	return uint64(math.Ceil(minBucketFeeRate * math.Pow(bucketFeeRateSpacing, float64(index))))
+}
+
+// Save saves the fee estimator state to the database so that it can be
+// loaded back on the next run.
+//
+// This function is safe for concurrent access.
+func (fe *FeeEstimator) Save() error {
+	serializedFeeEstimatorState, err := fe.serialize()
+	if err != nil {
+		return err
+	}
+	return dbaccess.StoreFeeEstimatorState(dbaccess.NoTx(), serializedFeeEstimatorState)
+}
+
+func (fe *FeeEstimator) serialize() ([]byte, error) {
+	fe.mtx.Lock()
+	defer fe.mtx.Unlock()
+
+	state := serializedState{
+		Version:       serializationVersion,
+		LastBlueScore: fe.lastBlueScore,
+		Buckets:       fe.buckets[:],
+	}
+	w := &bytes.Buffer{}
+	encoder := gob.NewEncoder(w)
+	err := encoder.Encode(&state)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode fee estimator state")
+	}
+	return w.Bytes(), nil
+}
+
+// load loads the fee estimator state from the database. If missing, the
+// estimator just starts fresh.
+func (fe *FeeEstimator) load() error {
+	serializedFeeEstimatorState, err := dbaccess.FetchFeeEstimatorState(dbaccess.NoTx())
+	if dbaccess.IsNotFoundError(err) {
+		log.Info("No fee estimator state was found in the database. Created a new one")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = fe.deserialize(serializedFeeEstimatorState)
+	if err != nil {
+		return err
+	}
+	log.Infof("Loaded fee estimator state from database")
+	return nil
+}
+
+func (fe *FeeEstimator) deserialize(serializedFeeEstimatorState []byte) error {
+	fe.mtx.Lock()
+	defer fe.mtx.Unlock()
+
+	var state serializedState
+	r := bytes.NewBuffer(serializedFeeEstimatorState)
+	decoder := gob.NewDecoder(r)
+	err := decoder.Decode(&state)
+	if err != nil {
+		return errors.Wrap(err, "error deserializing fee estimator state")
+	}
+	if state.Version != serializationVersion {
+		return errors.Errorf("unknown version %d in serialized "+
+			"fee estimator state", state.Version)
+	}
+	if len(state.Buckets) != bucketCount {
+		return errors.Errorf("unexpected number of buckets in serialized "+
+			"fee estimator state: got %d, want %d", len(state.Buckets), bucketCount)
+	}
+
+	fe.lastBlueScore = state.LastBlueScore
+	copy(fe.buckets[:], state.Buckets)
+	return nil
+}
@@ -0,0 +1,174 @@
+package feeestimator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// newTestTx returns a new transaction with a unique ID.
+func newTestTx(index uint32) *util.Tx {
+	txIn := &wire.TxIn{
+		PreviousOutpoint: wire.Outpoint{TxID: daghash.TxID{}, Index: index},
+		SignatureScript:  []byte{},
+		Sequence:         wire.MaxTxInSequenceNum,
+	}
+	return util.NewTx(wire.NewNativeMsgTx(wire.TxVersion, []*wire.TxIn{txIn}, nil))
+}
+
+// newTestAcceptanceData returns the acceptance data of a single chain block
+// that accepted the given transactions and rejected the given rejected ones.
+func newTestAcceptanceData(acceptedTxs []*util.Tx, rejectedTxs []*util.Tx) []blockdag.MultiBlockTxsAcceptanceData {
+	var txAcceptanceData []blockdag.TxAcceptanceData
+	for _, tx := range acceptedTxs {
+		txAcceptanceData = append(txAcceptanceData, blockdag.TxAcceptanceData{Tx: tx, IsAccepted: true})
+	}
+	for _, tx := range rejectedTxs {
+		txAcceptanceData = append(txAcceptanceData, blockdag.TxAcceptanceData{Tx: tx, IsAccepted: false})
+	}
+	return []blockdag.MultiBlockTxsAcceptanceData{{
+		{BlockHash: daghash.Hash{}, TxAcceptanceData: txAcceptanceData},
+	}}
+}
+
+func TestBucketIndex(t *testing.T) {
+	tests := []struct {
+		feeRate       uint64
+		expectedIndex int
+	}{
+		{feeRate: 0, expectedIndex: 0},
+		{feeRate: minBucketFeeRate, expectedIndex: 0},
+		{feeRate: bucketFeeRate(1), expectedIndex: 1},
+		{feeRate: bucketFeeRate(10), expectedIndex: 10},
+		{feeRate: bucketFeeRate(bucketCount + 10), expectedIndex: bucketCount - 1},
+	}
+	for _, test := range tests {
+		index := bucketIndex(test.feeRate)
+		if index != test.expectedIndex {
+			t.Errorf("TestBucketIndex: unexpected index for fee rate %d: "+
+				"got %d, want %d", test.feeRate, index, test.expectedIndex)
+		}
+	}
+}
+
+func TestEstimateFee(t *testing.T) {
+	fe := newFeeEstimator(nil)
+
+	_, err := fe.EstimateFee(1)
+	if err != ErrInsufficientData {
+		t.Fatalf("TestEstimateFee: expected ErrInsufficientData, got: %v", err)
+	}
+	_, err = fe.EstimateFee(0)
+	if err == nil {
+		t.Fatalf("TestEstimateFee: expected an error for a zero target")
+	}
+	_, err = fe.EstimateFee(MaxTargetBlueScoreDelta + 1)
+	if err == nil {
+		t.Fatalf("TestEstimateFee: expected an error for a target that is too large")
+	}
+
+	// High fee rate transactions get accepted right away, while low fee
+	// rate transactions take a long time to get accepted.
+	const txsPerFeeRate = 20
+	highFeeRate := bucketFeeRate(30)
+	lowFeeRate := bucketFeeRate(10)
+	var highFeeTxs, lowFeeTxs []*util.Tx
+	for i := uint32(0); i < txsPerFeeRate; i++ {
+		highFeeTx := newTestTx(i)
+		fe.observeTransaction(highFeeTx.ID(), highFeeRate, 1)
+		highFeeTxs = append(highFeeTxs, highFeeTx)
+
+		lowFeeTx := newTestTx(txsPerFeeRate + i)
+		fe.observeTransaction(lowFeeTx.ID(), lowFeeRate, 1)
+		lowFeeTxs = append(lowFeeTxs, lowFeeTx)
+	}
+	// Transactions that were included in a block but were not accepted
+	// must not be resolved.
+	fe.registerAcceptedTransactions(newTestAcceptanceData(nil, lowFeeTxs), 2)
+	if len(fe.observed) != len(lowFeeTxs)+len(highFeeTxs) {
+		t.Fatalf("TestEstimateFee: expected rejected transactions to remain observed")
+	}
+	fe.registerAcceptedTransactions(newTestAcceptanceData(highFeeTxs, nil), 2)
+	fe.registerAcceptedTransactions(newTestAcceptanceData(lowFeeTxs, nil), 50)
+
+	if len(fe.observed) != 0 {
+		t.Fatalf("TestEstimateFee: expected all the observed transactions "+
+			"to be resolved, but %d remain", len(fe.observed))
+	}
+
+	fastFeeRate, err := fe.EstimateFee(1)
+	if err != nil {
+		t.Fatalf("TestEstimateFee: EstimateFee unexpectedly failed: %s", err)
+	}
+	if fastFeeRate <= lowFeeRate || fastFeeRate < highFeeRate {
+		t.Errorf("TestEstimateFee: expected a fast fee rate above %d, got %d",
+			highFeeRate, fastFeeRate)
+	}
+
+	slowFeeRate, err := fe.EstimateFee(MaxTargetBlueScoreDelta)
+	if err != nil {
+		t.Fatalf("TestEstimateFee: EstimateFee unexpectedly failed: %s", err)
+	}
+	if slowFeeRate >= fastFeeRate || slowFeeRate < lowFeeRate {
+		t.Errorf("TestEstimateFee: expected a slow fee rate between %d and %d, got %d",
+			lowFeeRate, fastFeeRate, slowFeeRate)
+	}
+}
+
+func TestUnacceptedTransactionsFail(t *testing.T) {
+	fe := newFeeEstimator(nil)
+
+	const txCount = 20
+	feeRate := bucketFeeRate(20)
+	for i := uint32(0); i < txCount; i++ {
+		fe.observeTransaction(newTestTx(i).ID(), feeRate, 1)
+	}
+	fe.registerAcceptedTransactions(newTestAcceptanceData(nil, nil), MaxTargetBlueScoreDelta+2)
+
+	if len(fe.observed) != 0 {
+		t.Fatalf("TestUnacceptedTransactionsFail: expected all the observed "+
+			"transactions to fail, but %d remain", len(fe.observed))
+	}
+	_, err := fe.EstimateFee(MaxTargetBlueScoreDelta)
+	if err != ErrInsufficientData {
+		t.Fatalf("TestUnacceptedTransactionsFail: expected ErrInsufficientData, got: %v", err)
+	}
+}
+
+func TestFeeEstimatorSerialization(t *testing.T) {
+	fe := newFeeEstimator(nil)
+	for i := uint32(0); i < 20; i++ {
+		tx := newTestTx(i)
+		fe.observeTransaction(tx.ID(), bucketFeeRate(int(i)), 1)
+		fe.registerAcceptedTransactions(newTestAcceptanceData([]*util.Tx{tx}, nil), uint64(i)+2)
+	}
+
+	serialized, err := fe.serialize()
+	if err != nil {
+		t.Fatalf("TestFeeEstimatorSerialization: serialize unexpectedly failed: %s", err)
+	}
+	deserialized := newFeeEstimator(nil)
+	err = deserialized.deserialize(serialized)
+	if err != nil {
+		t.Fatalf("TestFeeEstimatorSerialization: deserialize unexpectedly failed: %s", err)
+	}
+
+	if deserialized.lastBlueScore != fe.lastBlueScore {
+		t.Errorf("TestFeeEstimatorSerialization: unexpected last blue score: "+
+			"got %d, want %d", deserialized.lastBlueScore, fe.lastBlueScore)
+	}
+	if !reflect.DeepEqual(deserialized.buckets, fe.buckets) {
+		t.Errorf("TestFeeEstimatorSerialization: deserialized buckets are " +
+			"different than the original buckets")
+	}
+
+	err = deserialized.deserialize(serialized[:len(serialized)/2])
+	if err == nil {
+		t.Errorf("TestFeeEstimatorSerialization: expected an error when " +
+			"deserializing a truncated state")
+	}
+}
@@ -0,0 +1,7 @@
+package feeestimator
+
+import (
+	"github.com/kaspanet/kaspad/logger"
+)
+
+var log, _ = logger.Get(logger.SubsystemTags.TXMP)
@@ -0,0 +1,286 @@
+package mempool
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// minFeeRateHalfLife is the time it takes the dynamic minimum fee rate,
+// which is raised whenever transactions are evicted from a full pool, to
+// decay by half.
+const minFeeRateHalfLife = 12 * time.Hour
+
+// IncrementalFeeRate returns the fee rate, in sompi per megagram, that is
+// added on top of the fee rate of evicted transactions to form the dynamic
+// minimum fee rate. It's derived from the minimum relay fee, which is defined
+// per kB and roughly equals a kilogram of mass.
+func (mp *TxPool) IncrementalFeeRate() uint64 {
+	return uint64(mp.cfg.Policy.MinRelayTxFee) * 1e3
+}
+
+// currentMinFeeRate decays the dynamic minimum fee rate according to the
+// time that passed since it was last updated, and returns it. Once it
+// decays below half of the incremental fee rate it's reset to zero.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) currentMinFeeRate() uint64 {
+	if mp.minFeeRate == 0 {
+		return 0
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(mp.minFeeRateLastUpdated)
+	decay := math.Pow(2, -elapsed.Seconds()/minFeeRateHalfLife.Seconds())
+	mp.minFeeRate = uint64(float64(mp.minFeeRate) * decay)
+	mp.minFeeRateLastUpdated = now
+	if mp.minFeeRate < mp.IncrementalFeeRate()/2 {
+		mp.minFeeRate = 0
+	}
+	return mp.minFeeRate
+}
+
+// MinFeeRate returns the dynamic minimum fee rate, in sompi per megagram,
+// that transactions have to pay in order to enter the pool. It's zero unless
+// transactions were recently evicted from a full pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) MinFeeRate() uint64 {
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+	return mp.currentMinFeeRate()
+}
+
+// TotalMass returns the total mass of the transactions in the main pool. It
+// does not include the orphan pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) TotalMass() uint64 {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+	return mp.totalMass
+}
+
+// checkMinFeeRate makes sure that tx pays at least the dynamic minimum fee
+// rate of the pool.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) checkMinFeeRate(tx *util.Tx, txFee uint64, utxoSet blockdag.UTXOSet) error {
+	minFeeRate := mp.currentMinFeeRate()
+	if minFeeRate == 0 {
+		return nil
+	}
+
+	mass, err := blockdag.CalcTxMassFromUTXOSet(tx, utxoSet)
+	if err != nil {
+		return err
+	}
+	feePerMegaGram := txFee * 1e6 / mass
+	if feePerMegaGram < minFeeRate {
+		str := fmt.Sprintf("transaction %s has a fee rate of %d "+
+			"sompi/megagram, which is under the minimum of %d "+
+			"sompi/megagram required by the full mempool", tx.ID(),
+			feePerMegaGram, minFeeRate)
+		return txRuleError(wire.RejectInsufficientFee, str)
+	}
+	return nil
+}
+
+// evictionFeeRate returns the fee and mass by whose ratio the passed
+// transaction is ranked for eviction. Evicting a transaction evicts all of
+// its descendants as well, so that's normally the fee rate of the whole
+// package. However, a transaction that pays a higher fee rate on its own is
+// ranked by it, since its low fee rate descendants are evicted before it.
+func evictionFeeRate(txDesc *TxDesc) (fee uint64, mass uint64) {
+	if hasHigherFeeRate(txDesc.Fee, txDesc.Mass, txDesc.descendantFee, txDesc.descendantMass) {
+		return txDesc.Fee, txDesc.Mass
+	}
+	return txDesc.descendantFee, txDesc.descendantMass
+}
+
+// evictionHeap is a min-heap of transactions in the pool, ordered by their
+// eviction fee rates. It implements heap.Interface. Every transaction keeps
+// track of its index in the heap, so that it can be fixed or removed once
+// its eviction fee rate changes or it leaves the pool.
+type evictionHeap []*TxDesc
+
+func (h evictionHeap) Len() int {
+	return len(h)
+}
+
+func (h evictionHeap) Less(i, j int) bool {
+	fee, mass := evictionFeeRate(h[i])
+	otherFee, otherMass := evictionFeeRate(h[j])
+	return hasHigherFeeRate(otherFee, otherMass, fee, mass)
+}
+
+func (h evictionHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].evictionHeapIndex = i
+	h[j].evictionHeapIndex = j
+}
+
+func (h *evictionHeap) Push(x interface{}) {
+	txDesc := x.(*TxDesc)
+	txDesc.evictionHeapIndex = len(*h)
+	*h = append(*h, txDesc)
+}
+
+func (h *evictionHeap) Pop() interface{} {
+	oldHeap := *h
+	txDesc := oldHeap[len(oldHeap)-1]
+	oldHeap[len(oldHeap)-1] = nil
+	*h = oldHeap[:len(oldHeap)-1]
+	txDesc.evictionHeapIndex = -1
+	return txDesc
+}
+
+// fix restores the heap ordering after the eviction fee rate of the passed
+// transaction has changed. It does nothing if the transaction is not in the
+// heap.
+func (h *evictionHeap) fix(txDesc *TxDesc) {
+	if txDesc.evictionHeapIndex >= 0 {
+		heap.Fix(h, txDesc.evictionHeapIndex)
+	}
+}
+
+// remove removes the passed transaction from the heap. It does nothing if
+// the transaction is not in the heap.
+func (h *evictionHeap) remove(txDesc *TxDesc) {
+	if txDesc.evictionHeapIndex >= 0 {
+		heap.Remove(h, txDesc.evictionHeapIndex)
+	}
+}
+
+// forEachEvictionCandidate calls fn with the transactions in the pool in
+// ascending order of their eviction fee rates, until fn returns false. fn
+// must not add transactions to the pool or remove transactions from it.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) forEachEvictionCandidate(fn func(txDesc *TxDesc) bool) {
+	var poppedTxs []*TxDesc
+	for mp.evictionHeap.Len() > 0 {
+		txDesc := heap.Pop(&mp.evictionHeap).(*TxDesc)
+		poppedTxs = append(poppedTxs, txDesc)
+		if !fn(txDesc) {
+			break
+		}
+	}
+	for _, txDesc := range poppedTxs {
+		heap.Push(&mp.evictionHeap, txDesc)
+	}
+}
+
+// checkReplacementFitsPool makes sure that once tx replaces replacedTxs, the
+// pool can be brought back within its mass limit by evicting transactions
+// that pay lower fee rates than tx. Otherwise, tx would be evicted right
+// away, and the transactions it replaced would be lost along with it. The
+// ancestors of tx are never evicted for it, since that would evict tx too.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) checkReplacementFitsPool(tx *util.Tx, txFee uint64,
+	utxoSet blockdag.UTXOSet, replacedTxs []*TxDesc) error {
+
+	maxMass := mp.cfg.Policy.MaxMempoolMass
+	if maxMass == 0 {
+		return nil
+	}
+	mass, err := blockdag.CalcTxMassFromUTXOSet(tx, utxoSet)
+	if err != nil {
+		return err
+	}
+
+	poolMass := mp.totalMass + mass
+	evictedTxIDs := make(map[daghash.TxID]struct{}, len(replacedTxs))
+	for _, replacedTx := range replacedTxs {
+		evictedTxIDs[*replacedTx.Tx.ID()] = struct{}{}
+		poolMass -= replacedTx.Mass
+	}
+	protectedTxs := mp.txAncestors(tx)
+
+	mp.forEachEvictionCandidate(func(candidate *TxDesc) bool {
+		if poolMass <= maxMass {
+			return false
+		}
+		candidateID := *candidate.Tx.ID()
+		if _, ok := evictedTxIDs[candidateID]; ok {
+			return true
+		}
+		if _, ok := protectedTxs[candidateID]; ok {
+			return true
+		}
+
+		fee, candidateMass := evictionFeeRate(candidate)
+		if !hasHigherFeeRate(txFee, mass, fee, candidateMass) {
+			str := fmt.Sprintf("transaction %s pays too low a fee rate "+
+				"to replace transactions in the full mempool", tx.ID())
+			err = txRuleError(wire.RejectInsufficientFee, str)
+			return false
+		}
+
+		evictedTxIDs[candidateID] = struct{}{}
+		poolMass -= candidate.Mass
+		for descendantID, descendant := range mp.txDescendants(candidate.Tx) {
+			if _, ok := evictedTxIDs[descendantID]; ok {
+				continue
+			}
+			evictedTxIDs[descendantID] = struct{}{}
+			poolMass -= descendant.Mass
+		}
+		return true
+	})
+	return err
+}
+
+// limitPoolMass evicts the transactions with the lowest fee rates, along with
+// their descendants, until the total mass of the pool fits within the policy
+// limit. The dynamic minimum fee rate is raised above the fee rate of the
+// evicted transactions, so that they can't just be relayed back in. The
+// passed protected transactions are never evicted, which may leave the pool
+// above its limit.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) limitPoolMass(protectedTxs map[daghash.TxID]*TxDesc) error {
+	maxMass := mp.cfg.Policy.MaxMempoolMass
+	if maxMass == 0 {
+		return nil
+	}
+
+	// Protected transactions are taken out of the heap while evicting,
+	// so that the lowest unprotected transaction is always at its top.
+	var skippedTxs []*TxDesc
+	defer func() {
+		for _, txDesc := range skippedTxs {
+			heap.Push(&mp.evictionHeap, txDesc)
+		}
+	}()
+
+	for mp.totalMass > maxMass && mp.evictionHeap.Len() > 0 {
+		lowest := mp.evictionHeap[0]
+		if _, ok := protectedTxs[*lowest.Tx.ID()]; ok {
+			skippedTxs = append(skippedTxs, heap.Pop(&mp.evictionHeap).(*TxDesc))
+			continue
+		}
+		fee, mass := evictionFeeRate(lowest)
+		removedTxs, err := mp.removeTransactionWithDescendants(lowest.Tx)
+		if err != nil {
+			return err
+		}
+		log.Debugf("Evicted transaction %s and its descendants from the "+
+			"full mempool (pool mass: %d)", lowest.Tx.ID(), mp.totalMass)
+		mp.queueTransactionsRemoved(removedTxs, RemovalReasonEvicted)
+
+		minFeeRate := fee*1e6/mass + mp.IncrementalFeeRate()
+		if minFeeRate > mp.currentMinFeeRate() {
+			mp.minFeeRate = minFeeRate
+			mp.minFeeRateLastUpdated = time.Now()
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@
 package mempool
 
 import (
+	"container/heap"
 	"container/list"
 	"fmt"
 	"github.com/pkg/errors"
@@ -15,6 +16,7 @@ import (
 	"github.com/kaspanet/kaspad/blockdag"
 	"github.com/kaspanet/kaspad/dagconfig"
 	"github.com/kaspanet/kaspad/logger"
+	"github.com/kaspanet/kaspad/mempool/feeestimator"
 	"github.com/kaspanet/kaspad/mining"
 	"github.com/kaspanet/kaspad/rpcmodel"
 	"github.com/kaspanet/kaspad/txscript"
@@ -78,6 +80,10 @@ type Config struct {
 
 	// DAG is the BlockDAG we want to use (mainly for UTXO checks)
 	DAG *blockdag.BlockDAG
+
+	// FeeEstimator is notified of every transaction that is admitted
+	// into the pool. It may be nil.
+	FeeEstimator *feeestimator.FeeEstimator
 }
 
 // Policy houses the policy (configuration parameters) which is used to
@@ -105,6 +111,26 @@ type Policy struct {
 	// MinRelayTxFee defines the minimum transaction fee in KAS/kB to be
 	// considered a non-zero fee.
 	MinRelayTxFee util.Amount
+
+	// AcceptReplacement defines whether to accept transactions that
+	// replace transactions that signal replaceability and are already
+	// in the mempool.
+	AcceptReplacement bool
+
+	// MaxReplacementEvictions is the maximum number of transactions,
+	// including descendants, a single replacement transaction may evict
+	// from the mempool.
+	MaxReplacementEvictions int
+
+	// MaxMempoolMass is the maximum total mass of the transactions in the
+	// main pool. Once it's exceeded, the transactions with the lowest fee
+	// rates are evicted. A value of 0 means the pool is not limited.
+	MaxMempoolMass uint64
+
+	// MaxTxAge is the maximum amount of time a transaction may stay in the
+	// mempool. Older transactions are removed along with their descendants.
+	// A value of 0 means transactions never expire.
+	MaxTxAge time.Duration
 }
 
 // TxDesc is a descriptor containing a transaction in the mempool along with
@@ -112,10 +138,25 @@ type Policy struct {
 type TxDesc struct {
 	mining.TxDesc
 
+	// ReplacedTxs are the transactions that were evicted from the mempool
+	// when this transaction replaced them.
+	ReplacedTxs []*util.Tx
+
 	// depCount is not 0 for dependent transaction. Dependent transaction is
 	// one that is accepted to pool, but cannot be mined in next block because it
 	// depends on outputs of accepted, but still not mined transaction
 	depCount int
+
+	// descendantFee and descendantMass are the total fee and mass of this
+	// transaction and all the transactions in the pool that depend on it.
+	// These are the fee and mass that are evicted along with it when the
+	// pool is full.
+	descendantFee  uint64
+	descendantMass uint64
+
+	// evictionHeapIndex is the index of this transaction in the eviction
+	// heap of the pool, or -1 if it's not in the heap.
+	evictionHeapIndex int
 }
 
 // orphanTx is normal transaction that references an ancestor transaction
@@ -143,6 +184,11 @@ type TxPool struct {
 	orphansByPrev map[wire.Outpoint]map[daghash.TxID]*util.Tx
 	outpoints     map[wire.Outpoint]*util.Tx
 
+	// shortIDs indexes the transactions in the main pool, the depend pool
+	// and the orphan pool by their short IDs, so that compact blocks can
+	// be filled without going over the whole pool.
+	shortIDs map[uint64]map[daghash.TxID]*util.Tx
+
 	// nextExpireScan is the time after which the orphan pool will be
 	// scanned in order to evict orphans. This is NOT a hard deadline as
 	// the scan will only run when an orphan is added to the pool as opposed
@@ -150,6 +196,30 @@ type TxPool struct {
 	nextExpireScan time.Time
 
 	mpUTXOSet blockdag.UTXOSet
+
+	// totalMass is the total mass of the transactions in the main pool.
+	totalMass uint64
+
+	// evictionHeap holds the transactions in the main pool ordered by
+	// their eviction fee rates, so that the transaction that is evicted
+	// first when the pool is full is always at its top.
+	evictionHeap evictionHeap
+
+	// minFeeRate is the dynamic minimum fee rate, in sompi per megagram,
+	// that transactions have to pay in order to enter the pool. It is
+	// raised whenever transactions are evicted from a full pool, and
+	// decays over time. minFeeRateLastUpdated is the last time it decayed.
+	minFeeRate            uint64
+	minFeeRateLastUpdated time.Time
+
+	// removalCallbacks are the callbacks that were registered with
+	// SubscribeRemovals.
+	removalCallbacks     []RemovalCallback
+	removalCallbacksLock sync.RWMutex
+
+	// pendingRemovals are the removals that were queued while the mempool
+	// lock was held. See queueTransactionsRemoved.
+	pendingRemovals []pendingRemoval
 }
 
 // Ensure the TxPool type implements the mining.TxSource interface.
@@ -194,6 +264,7 @@ func (mp *TxPool) removeOrphan(tx *util.Tx, removeRedeemers bool) {
 
 	// Remove the transaction from the orphan pool.
 	delete(mp.orphans, *txID)
+	mp.removeFromShortIDIndex(tx)
 }
 
 // RemoveOrphan removes the passed orphan transaction from the orphan pool and
@@ -302,6 +373,7 @@ func (mp *TxPool) addOrphan(tx *util.Tx, tag Tag) {
 		}
 		mp.orphansByPrev[txIn.PreviousOutpoint][*tx.ID()] = tx
 	}
+	mp.addToShortIDIndex(tx)
 
 	log.Debugf("Stored orphan transaction %s (total: %d)", tx.ID(),
 		len(mp.orphans))
@@ -536,11 +608,23 @@ func (mp *TxPool) removeTransactionWithDiff(tx *util.Tx, diff *blockdag.UTXODiff
 	}
 
 	txDesc, _ := mp.fetchTxDesc(txID)
+	for _, descendant := range mp.txDescendants(tx) {
+		descendant.AncestorFee -= txDesc.Fee
+		descendant.AncestorMass -= txDesc.Mass
+	}
+	for _, ancestor := range mp.txAncestors(tx) {
+		ancestor.descendantFee -= txDesc.Fee
+		ancestor.descendantMass -= txDesc.Mass
+		mp.evictionHeap.fix(ancestor)
+	}
+	mp.evictionHeap.remove(txDesc)
+	mp.totalMass -= txDesc.Mass
 	if txDesc.depCount == 0 {
 		delete(mp.pool, *txID)
 	} else {
 		delete(mp.depends, *txID)
 	}
+	mp.removeFromShortIDIndex(tx)
 
 	mp.processRemovedTransactionDependencies(tx)
 
@@ -648,16 +732,21 @@ func (mp *TxPool) RemoveTransactions(txs []*util.Tx) error {
 //
 // This function is safe for concurrent access.
 func (mp *TxPool) RemoveDoubleSpends(tx *util.Tx) {
+	defer mp.notifyPendingRemovals()
+
 	// Protect concurrent access.
 	mp.mtx.Lock()
 	defer mp.mtx.Unlock()
+	var removedTxs []*util.Tx
 	for _, txIn := range tx.MsgTx().TxIn {
 		if txRedeemer, ok := mp.outpoints[txIn.PreviousOutpoint]; ok {
 			if !txRedeemer.ID().IsEqual(tx.ID()) {
+				removedTxs = append(removedTxs, mp.txWithDescendants(txRedeemer)...)
 				mp.removeTransaction(txRedeemer, true, false)
 			}
 		}
 	}
+	mp.queueTransactionsRemoved(removedTxs, RemovalReasonDoubleSpend)
 }
 
 // addTransaction adds the passed transaction to the memory pool. It should
@@ -672,15 +761,33 @@ func (mp *TxPool) addTransaction(tx *util.Tx, fee uint64, parentsInPool []*wire.
 	if err != nil {
 		return nil, err
 	}
+	ancestors := mp.txAncestors(tx)
+	ancestorFee, ancestorMass := fee, mass
+	for _, ancestor := range ancestors {
+		ancestorFee += ancestor.Fee
+		ancestorMass += ancestor.Mass
+		ancestor.descendantFee += fee
+		ancestor.descendantMass += mass
+	}
 	txD := &TxDesc{
 		TxDesc: mining.TxDesc{
 			Tx:             tx,
 			Added:          time.Now(),
 			Fee:            fee,
 			FeePerMegaGram: fee * 1e6 / mass,
+			Mass:           mass,
+			AncestorFee:    ancestorFee,
+			AncestorMass:   ancestorMass,
 		},
-		depCount: len(parentsInPool),
+		depCount:          len(parentsInPool),
+		descendantFee:     fee,
+		descendantMass:    mass,
+		evictionHeapIndex: -1,
+	}
+	for _, ancestor := range ancestors {
+		mp.evictionHeap.fix(ancestor)
 	}
+	heap.Push(&mp.evictionHeap, txD)
 
 	if len(parentsInPool) == 0 {
 		mp.pool[*tx.ID()] = txD
@@ -693,6 +800,9 @@ func (mp *TxPool) addTransaction(tx *util.Tx, fee uint64, parentsInPool []*wire.
 			mp.dependsByPrev[*previousOutpoint][*tx.ID()] = txD
 		}
 	}
+	mp.addToShortIDIndex(tx)
+
+	mp.totalMass += mass
 
 	for _, txIn := range tx.MsgTx().TxIn {
 		mp.outpoints[txIn.PreviousOutpoint] = tx
@@ -704,6 +814,10 @@ func (mp *TxPool) addTransaction(tx *util.Tx, fee uint64, parentsInPool []*wire.
 	}
 	atomic.StoreInt64(&mp.lastUpdated, time.Now().Unix())
 
+	if mp.cfg.FeeEstimator != nil {
+		mp.cfg.FeeEstimator.ObserveTransaction(&txD.TxDesc)
+	}
+
 	return txD, nil
 }
 
@@ -712,18 +826,30 @@ func (mp *TxPool) addTransaction(tx *util.Tx, fee uint64, parentsInPool []*wire.
 // Note it does not check for double spends against transactions already in the
 // DAG.
 //
+// If the pool accepts replacements and all the conflicting transactions signal
+// replaceability, no error is returned. Instead, the conflicting transactions
+// and all of their descendants are returned, so that the caller may replace
+// them.
+//
 // This function MUST be called with the mempool lock held (for reads).
-func (mp *TxPool) checkPoolDoubleSpend(tx *util.Tx) error {
+func (mp *TxPool) checkPoolDoubleSpend(tx *util.Tx) ([]*TxDesc, error) {
+	var conflicts []*util.Tx
 	for _, txIn := range tx.MsgTx().TxIn {
 		if txR, exists := mp.outpoints[txIn.PreviousOutpoint]; exists {
-			str := fmt.Sprintf("output %s already spent by "+
-				"transaction %s in the memory pool",
-				txIn.PreviousOutpoint, txR.ID())
-			return txRuleError(wire.RejectDuplicate, str)
+			if !mp.cfg.Policy.AcceptReplacement || !signalsReplacement(txR) {
+				str := fmt.Sprintf("output %s already spent by "+
+					"transaction %s in the memory pool",
+					txIn.PreviousOutpoint, txR.ID())
+				return nil, txRuleError(wire.RejectDuplicate, str)
+			}
+			conflicts = append(conflicts, txR)
 		}
 	}
+	if len(conflicts) == 0 {
+		return nil, nil
+	}
 
-	return nil
+	return mp.replacedTransactions(tx, conflicts)
 }
 
 // CheckSpend checks whether the passed outpoint is already spent by a
@@ -746,6 +872,63 @@ func (mp *TxPool) fetchTxDesc(txID *daghash.TxID) (*TxDesc, bool) {
 	return txDesc, exists
 }
 
+// txAncestors returns all the transactions in the pool that the passed
+// transaction depends on, either directly or indirectly.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) txAncestors(tx *util.Tx) map[daghash.TxID]*TxDesc {
+	ancestors := make(map[daghash.TxID]*TxDesc)
+	queue := []*util.Tx{tx}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, txIn := range current.MsgTx().TxIn {
+			parentID := txIn.PreviousOutpoint.TxID
+			if _, ok := ancestors[parentID]; ok {
+				continue
+			}
+			parent, exists := mp.fetchTxDesc(&parentID)
+			if !exists {
+				continue
+			}
+			ancestors[parentID] = parent
+			queue = append(queue, parent.Tx)
+		}
+	}
+	return ancestors
+}
+
+// txDescendants returns all the transactions in the pool that depend on
+// the passed transaction, either directly or indirectly.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) txDescendants(tx *util.Tx) map[daghash.TxID]*TxDesc {
+	descendants := make(map[daghash.TxID]*TxDesc)
+	queue := []*util.Tx{tx}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		prevOut := wire.Outpoint{TxID: *current.ID()}
+		for txOutIdx := range current.MsgTx().TxOut {
+			prevOut.Index = uint32(txOutIdx)
+			txRedeemer, exists := mp.outpoints[prevOut]
+			if !exists {
+				continue
+			}
+			if _, ok := descendants[*txRedeemer.ID()]; ok {
+				continue
+			}
+			descendant, exists := mp.fetchTxDesc(txRedeemer.ID())
+			if !exists {
+				continue
+			}
+			descendants[*txRedeemer.ID()] = descendant
+			queue = append(queue, txRedeemer)
+		}
+	}
+	return descendants
+}
+
 // FetchTxDesc returns the requested TxDesc from the transaction pool.
 // This only fetches from the main transaction pool and does not include
 // orphans.
@@ -762,6 +945,51 @@ func (mp *TxPool) FetchTxDesc(txID *daghash.TxID) (*TxDesc, error) {
 	return nil, errors.Errorf("transaction is not in the pool")
 }
 
+// FetchTxRelatives returns the IDs of the in-pool transactions that the
+// requested transaction spends from, and the IDs of the in-pool transactions
+// that spend from the requested transaction.
+// This only fetches from the main transaction pool and does not include
+// orphans.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) FetchTxRelatives(txID *daghash.TxID) (depends []*daghash.TxID, spentBy []*daghash.TxID, err error) {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	txDesc, exists := mp.fetchTxDesc(txID)
+	if !exists {
+		return nil, nil, errors.Errorf("transaction is not in the pool")
+	}
+
+	dependsSet := make(map[daghash.TxID]struct{})
+	for _, txIn := range txDesc.Tx.MsgTx().TxIn {
+		parentID := txIn.PreviousOutpoint.TxID
+		if _, ok := dependsSet[parentID]; ok {
+			continue
+		}
+		if _, ok := mp.fetchTxDesc(&parentID); ok {
+			dependsSet[parentID] = struct{}{}
+			depends = append(depends, &parentID)
+		}
+	}
+
+	spentBySet := make(map[daghash.TxID]struct{})
+	for i := range txDesc.Tx.MsgTx().TxOut {
+		outpoint := wire.Outpoint{TxID: *txID, Index: uint32(i)}
+		spendingTx, ok := mp.outpoints[outpoint]
+		if !ok {
+			continue
+		}
+		if _, ok := spentBySet[*spendingTx.ID()]; ok {
+			continue
+		}
+		spentBySet[*spendingTx.ID()] = struct{}{}
+		spentBy = append(spentBy, spendingTx.ID())
+	}
+
+	return depends, spentBy, nil
+}
+
 // FetchTransaction returns the requested transaction from the transaction pool.
 // This only fetches from the main transaction pool and does not include
 // orphans.
@@ -888,17 +1116,28 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 	// at this point. There is a more in-depth check that happens later
 	// after fetching the referenced transaction inputs from the DAG
 	// which examines the actual spend data and prevents double spends.
-	err = mp.checkPoolDoubleSpend(tx)
+	//
+	// The exception are transactions that replace the conflicting
+	// transactions, in which case the rest of the checks are performed
+	// against a UTXO set from which the replaced transactions are removed.
+	utxoSet := mp.mpUTXOSet
+	replacedTxs, err := mp.checkPoolDoubleSpend(tx)
 	if err != nil {
 		return nil, nil, err
 	}
+	if len(replacedTxs) > 0 {
+		utxoSet, err = mp.utxoSetWithoutTransactions(replacedTxs)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 
 	// Don't allow the transaction if it exists in the DAG and is
 	// not already fully spent.
 	prevOut := wire.Outpoint{TxID: *txID}
 	for txOutIdx := range tx.MsgTx().TxOut {
 		prevOut.Index = uint32(txOutIdx)
-		_, ok := mp.mpUTXOSet.Get(prevOut)
+		_, ok := utxoSet.Get(prevOut)
 		if ok {
 			return nil, nil, txRuleError(wire.RejectDuplicate,
 				"transaction already exists")
@@ -912,7 +1151,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 	var missingParents []*daghash.TxID
 	var parentsInPool []*wire.Outpoint
 	for _, txIn := range tx.MsgTx().TxIn {
-		if _, ok := mp.mpUTXOSet.Get(txIn.PreviousOutpoint); !ok {
+		if _, ok := utxoSet.Get(txIn.PreviousOutpoint); !ok {
 			// Must make a copy of the hash here since the iterator
 			// is replaced and taking its address directly would
 			// result in all of the entries pointing to the same
@@ -931,7 +1170,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 	// Don't allow the transaction into the mempool unless its sequence
 	// lock is active, meaning that it'll be allowed into the next block
 	// with respect to its defined relative lock times.
-	sequenceLock, err := mp.cfg.CalcSequenceLockNoLock(tx, mp.mpUTXOSet)
+	sequenceLock, err := mp.cfg.CalcSequenceLockNoLock(tx, utxoSet)
 	if err != nil {
 		var dagRuleErr blockdag.RuleError
 		if ok := errors.As(err, &dagRuleErr); ok {
@@ -947,7 +1186,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 
 	// Don't allow transactions that exceed the maximum allowed
 	// transaction mass.
-	err = blockdag.ValidateTxMass(tx, mp.mpUTXOSet)
+	err = blockdag.ValidateTxMass(tx, utxoSet)
 	if err != nil {
 		var ruleError blockdag.RuleError
 		if ok := errors.As(err, &ruleError); ok {
@@ -961,7 +1200,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 	// Also returns the fees associated with the transaction which will be
 	// used later.
 	txFee, err := blockdag.CheckTransactionInputsAndCalulateFee(tx, nextBlockBlueScore,
-		mp.mpUTXOSet, mp.cfg.DAGParams, false)
+		utxoSet, mp.cfg.DAGParams, false)
 	if err != nil {
 		var dagRuleErr blockdag.RuleError
 		if ok := errors.As(err, &dagRuleErr); ok {
@@ -973,7 +1212,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 	// Don't allow transactions with non-standard inputs if the network
 	// parameters forbid their acceptance.
 	if !mp.cfg.Policy.AcceptNonStd {
-		err := checkInputsStandard(tx, mp.mpUTXOSet)
+		err := checkInputsStandard(tx, utxoSet)
 		if err != nil {
 			// Attempt to extract a reject code from the error so
 			// it can be retained. When not possible, fall back to
@@ -1019,9 +1258,16 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 		return nil, nil, txRuleError(wire.RejectInsufficientFee, str)
 	}
 
+	// Don't allow transactions with fee rates lower than the dynamic
+	// minimum fee rate, which is raised when the pool gets full.
+	err = mp.checkMinFeeRate(tx, txFee, utxoSet)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Verify crypto signatures for each input and reject the transaction if
 	// any don't verify.
-	err = blockdag.ValidateTransactionScripts(tx, mp.mpUTXOSet,
+	err = blockdag.ValidateTransactionScripts(tx, utxoSet,
 		txscript.StandardVerifyFlags, mp.cfg.SigCache)
 	if err != nil {
 		var dagRuleErr blockdag.RuleError
@@ -1031,11 +1277,52 @@ func (mp *TxPool) maybeAcceptTransaction(tx *util.Tx, rejectDupOrphans bool) ([]
 		return nil, nil, err
 	}
 
+	// Make sure the transaction pays enough to replace the transactions
+	// it conflicts with, and that it won't be evicted right away from a
+	// full pool, and only then evict them from the pool.
+	if len(replacedTxs) > 0 {
+		err = mp.checkReplacementFee(tx, txFee, utxoSet, replacedTxs)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = mp.checkReplacementFitsPool(tx, txFee, utxoSet, replacedTxs)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = mp.removeReplacedTransactions(replacedTxs)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Add to transaction pool.
 	txD, err := mp.addTransaction(tx, txFee, parentsInPool)
 	if err != nil {
 		return nil, nil, err
 	}
+	for _, replacedTx := range replacedTxs {
+		txD.ReplacedTxs = append(txD.ReplacedTxs, replacedTx.Tx)
+	}
+
+	// Make room for the transaction if the pool is full. The transaction
+	// itself is evicted right away if it pays the lowest fee rate, unless
+	// it replaced other transactions. In that case it was already made
+	// sure that it fits, so it and its ancestors are protected from
+	// eviction.
+	var protectedTxs map[daghash.TxID]*TxDesc
+	if len(replacedTxs) > 0 {
+		protectedTxs = mp.txAncestors(tx)
+		protectedTxs[*txID] = txD
+	}
+	err = mp.limitPoolMass(protectedTxs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !mp.isTransactionInPool(txID) {
+		str := fmt.Sprintf("transaction %s pays too low a fee rate to "+
+			"enter the full mempool", txID)
+		return nil, nil, txRuleError(wire.RejectInsufficientFee, str)
+	}
 
 	log.Debugf("Accepted transaction %s (pool size: %d)", txID,
 		len(mp.pool))
@@ -1137,6 +1424,7 @@ func (mp *TxPool) processOrphans(acceptedTx *util.Tx) []*TxDesc {
 //
 // This function is safe for concurrent access.
 func (mp *TxPool) ProcessOrphans(acceptedTx *util.Tx) []*TxDesc {
+	defer mp.notifyPendingRemovals()
 	mp.cfg.DAG.RLock()
 	defer mp.cfg.DAG.RUnlock()
 	mp.mtx.Lock()
@@ -1160,6 +1448,10 @@ func (mp *TxPool) ProcessOrphans(acceptedTx *util.Tx) []*TxDesc {
 func (mp *TxPool) ProcessTransaction(tx *util.Tx, allowOrphan bool, tag Tag) ([]*TxDesc, error) {
 	log.Tracef("Processing transaction %s", tx.ID())
 
+	// Notify of the transactions that were removed while processing the
+	// transaction only once the mempool lock is released.
+	defer mp.notifyPendingRemovals()
+
 	// Protect concurrent access.
 	mp.cfg.DAG.RLock()
 	defer mp.cfg.DAG.RUnlock()
@@ -1269,23 +1561,77 @@ func (mp *TxPool) TxDescs() []*TxDesc {
 }
 
 // MiningDescs returns a slice of mining descriptors for all the transactions
-// in the pool.
+// in the main pool. The descriptors are copies, whose package fee and mass
+// also account for the dependent transactions in the pool.
 //
 // This is part of the mining.TxSource interface implementation and is safe for
 // concurrent access as required by the interface contract.
 func (mp *TxPool) MiningDescs() []*mining.TxDesc {
 	mp.mtx.RLock()
 	defer mp.mtx.RUnlock()
-	descs := make([]*mining.TxDesc, len(mp.pool))
-	i := 0
+	descs := make([]*mining.TxDesc, 0, len(mp.pool))
+	packages := make(map[daghash.TxID]feeAndMass, len(mp.pool)+len(mp.depends))
 	for _, desc := range mp.pool {
-		descs[i] = &desc.TxDesc
-		i++
+		descCopy := desc.TxDesc
+		bestPackage := mp.bestDescendantPackage(desc, packages)
+		descCopy.PackageFee = bestPackage.fee
+		descCopy.PackageMass = bestPackage.mass
+		descs = append(descs, &descCopy)
 	}
 
 	return descs
 }
 
+// feeAndMass is the total fee and mass of a package of transactions.
+type feeAndMass struct {
+	fee  uint64
+	mass uint64
+}
+
+// bestDescendantPackage returns the ancestor package with the highest fee
+// rate among the passed transaction and all of its descendants. Dependent
+// transactions can't be mined before their ancestors, so this is the package
+// that the passed transaction is credited with. The packages of the visited
+// transactions are memoized in packages, so that computing them for the
+// whole pool visits every transaction only once.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) bestDescendantPackage(txDesc *TxDesc,
+	packages map[daghash.TxID]feeAndMass) feeAndMass {
+
+	txID := *txDesc.Tx.ID()
+	if bestPackage, ok := packages[txID]; ok {
+		return bestPackage
+	}
+
+	bestPackage := feeAndMass{fee: txDesc.AncestorFee, mass: txDesc.AncestorMass}
+	prevOut := wire.Outpoint{TxID: txID}
+	for txOutIdx := range txDesc.Tx.MsgTx().TxOut {
+		prevOut.Index = uint32(txOutIdx)
+		txRedeemer, exists := mp.outpoints[prevOut]
+		if !exists {
+			continue
+		}
+		child, exists := mp.fetchTxDesc(txRedeemer.ID())
+		if !exists {
+			continue
+		}
+		childPackage := mp.bestDescendantPackage(child, packages)
+		if hasHigherFeeRate(childPackage.fee, childPackage.mass,
+			bestPackage.fee, bestPackage.mass) {
+
+			bestPackage = childPackage
+		}
+	}
+	packages[txID] = bestPackage
+	return bestPackage
+}
+
+// hasHigherFeeRate returns whether fee/mass is higher than otherFee/otherMass.
+func hasHigherFeeRate(fee uint64, mass uint64, otherFee uint64, otherMass uint64) bool {
+	return float64(fee)/float64(mass) > float64(otherFee)/float64(otherMass)
+}
+
 // RawMempoolVerbose returns all of the entries in the mempool as a fully
 // populated jsonrpc result.
 //
@@ -1375,6 +1721,7 @@ func New(cfg *Config) *TxPool {
 		orphansByPrev:  make(map[wire.Outpoint]map[daghash.TxID]*util.Tx),
 		nextExpireScan: time.Now().Add(orphanExpireScanInterval),
 		outpoints:      make(map[wire.Outpoint]*util.Tx),
+		shortIDs:       make(map[uint64]map[daghash.TxID]*util.Tx),
 		mpUTXOSet:      mpUTXO,
 	}
 }
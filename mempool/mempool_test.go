@@ -1543,6 +1543,677 @@ func TestCheckSpend(t *testing.T) {
 	}
 }
 
+func TestFetchTxRelatives(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 1, "TestFetchTxRelatives")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	const txChainLength = 3
+	chainedTxns, err := harness.CreateTxChain(outputs[0], txChainLength)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	for _, tx := range chainedTxns {
+		_, err := harness.txPool.ProcessTransaction(tx, true, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept "+
+				"tx: %v", err)
+		}
+	}
+
+	for i, tx := range chainedTxns {
+		depends, spentBy, err := harness.txPool.FetchTxRelatives(tx.ID())
+		if err != nil {
+			t.Fatalf("FetchTxRelatives: unexpected error: %v", err)
+		}
+
+		expectedDependsLen := 1
+		if i == 0 {
+			expectedDependsLen = 0
+		}
+		if len(depends) != expectedDependsLen {
+			t.Fatalf("FetchTxRelatives: expected tx %d to depend on %d "+
+				"transactions, but got %d", i, expectedDependsLen, len(depends))
+		}
+		if expectedDependsLen == 1 && !depends[0].IsEqual(chainedTxns[i-1].ID()) {
+			t.Fatalf("FetchTxRelatives: expected tx %d to depend on %s, "+
+				"but got %s", i, chainedTxns[i-1].ID(), depends[0])
+		}
+
+		expectedSpentByLen := 1
+		if i == txChainLength-1 {
+			expectedSpentByLen = 0
+		}
+		if len(spentBy) != expectedSpentByLen {
+			t.Fatalf("FetchTxRelatives: expected tx %d to be spent by %d "+
+				"transactions, but got %d", i, expectedSpentByLen, len(spentBy))
+		}
+		if expectedSpentByLen == 1 && !spentBy[0].IsEqual(chainedTxns[i+1].ID()) {
+			t.Fatalf("FetchTxRelatives: expected tx %d to be spent by %s, "+
+				"but got %s", i, chainedTxns[i+1].ID(), spentBy[0])
+		}
+	}
+
+	_, _, err = harness.txPool.FetchTxRelatives(&daghash.TxID{})
+	if err == nil {
+		t.Fatalf("FetchTxRelatives: expected an error for a transaction " +
+			"that is not in the pool")
+	}
+}
+
+// TestReplacement ensures that transactions that signal replaceability are
+// replaced, along with their descendants, only by conflicting transactions
+// that pay enough fees.
+func TestReplacement(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 2, "TestReplacement")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+	harness.txPool.cfg.Policy.AcceptReplacement = true
+	harness.txPool.cfg.Policy.MaxReplacementEvictions = 2
+
+	createReplaceableTx := func(outpoint spendableOutpoint, fee uint64) *util.Tx {
+		tx, err := harness.createTx(outpoint, fee, 1)
+		if err != nil {
+			t.Fatalf("unable to create transaction: %v", err)
+		}
+		msgTx := tx.MsgTx()
+		msgTx.TxIn[0].Sequence = MaxReplaceableSequenceNum
+		return util.NewTx(msgTx)
+	}
+	fee := uint64(txRelayFeeForTest)
+
+	// Transactions that don't signal replaceability can't be replaced.
+	nonReplaceableTx, err := harness.createTx(outputs[1], fee, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(nonReplaceableTx, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %s", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(createReplaceableTx(outputs[1], fee*10), true, 0)
+	if code, _ := extractRejectCode(err); code != wire.RejectDuplicate {
+		t.Fatalf("ProcessTransaction: expected reject code %s, got error: %v",
+			wire.RejectDuplicate, err)
+	}
+	testPoolMembership(tc, nonReplaceableTx, false, true, false)
+
+	replaceableTx := createReplaceableTx(outputs[0], fee)
+	_, err = harness.txPool.ProcessTransaction(replaceableTx, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %s", err)
+	}
+	childTx, err := harness.createTx(txOutToSpendableOutpoint(replaceableTx, 0), fee, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(childTx, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %s", err)
+	}
+
+	// A replacement must pay more than the replaced transaction and its
+	// descendant combined.
+	_, err = harness.txPool.ProcessTransaction(createReplaceableTx(outputs[0], fee+1), true, 0)
+	if code, _ := extractRejectCode(err); code != wire.RejectInsufficientFee {
+		t.Fatalf("ProcessTransaction: expected reject code %s, got error: %v",
+			wire.RejectInsufficientFee, err)
+	}
+	testPoolMembership(tc, replaceableTx, false, true, false)
+	testPoolMembership(tc, childTx, false, true, true)
+
+	// Replacing transactions is not allowed if it's disabled by the policy.
+	harness.txPool.cfg.Policy.AcceptReplacement = false
+	_, err = harness.txPool.ProcessTransaction(createReplaceableTx(outputs[0], fee*3), true, 0)
+	if code, _ := extractRejectCode(err); code != wire.RejectDuplicate {
+		t.Fatalf("ProcessTransaction: expected reject code %s, got error: %v",
+			wire.RejectDuplicate, err)
+	}
+	harness.txPool.cfg.Policy.AcceptReplacement = true
+
+	replacementTx := createReplaceableTx(outputs[0], fee*3)
+	acceptedTxs, err := harness.txPool.ProcessTransaction(replacementTx, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %s", err)
+	}
+	if len(acceptedTxs) != 1 || len(acceptedTxs[0].ReplacedTxs) != 2 ||
+		!acceptedTxs[0].ReplacedTxs[0].ID().IsEqual(replaceableTx.ID()) ||
+		!acceptedTxs[0].ReplacedTxs[1].ID().IsEqual(childTx.ID()) {
+		t.Fatalf("ProcessTransaction: expected the replacement to replace "+
+			"both %s and %s", replaceableTx.ID(), childTx.ID())
+	}
+	testPoolMembership(tc, replaceableTx, false, false, false)
+	testPoolMembership(tc, childTx, false, false, false)
+	testPoolMembership(tc, replacementTx, false, true, false)
+	if spender := harness.txPool.CheckSpend(outputs[0].outpoint); spender == nil ||
+		!spender.ID().IsEqual(replacementTx.ID()) {
+		t.Fatalf("CheckSpend: expected %s to be spent by the replacement", outputs[0].outpoint)
+	}
+
+	// A replacement may not evict more transactions than allowed.
+	childTx, err = harness.createTx(txOutToSpendableOutpoint(replacementTx, 0), fee, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(childTx, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %s", err)
+	}
+	harness.txPool.cfg.Policy.MaxReplacementEvictions = 1
+	_, err = harness.txPool.ProcessTransaction(createReplaceableTx(outputs[0], fee*10), true, 0)
+	if code, _ := extractRejectCode(err); code != wire.RejectNonstandard {
+		t.Fatalf("ProcessTransaction: expected reject code %s, got error: %v",
+			wire.RejectNonstandard, err)
+	}
+	testPoolMembership(tc, replacementTx, false, true, false)
+	testPoolMembership(tc, childTx, false, true, true)
+}
+
+// TestReplacementInFullPool ensures that a replacement that would be evicted
+// right away from the full pool is rejected without evicting the
+// transactions it replaces.
+func TestReplacementInFullPool(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 2, "TestReplacementInFullPool")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+	harness.txPool.cfg.Policy.AcceptReplacement = true
+	harness.txPool.cfg.Policy.MaxReplacementEvictions = 1
+
+	createReplaceableTx := func(outpoint spendableOutpoint, fee uint64) *util.Tx {
+		tx, err := harness.createTx(outpoint, fee, 1)
+		if err != nil {
+			t.Fatalf("unable to create transaction: %v", err)
+		}
+		msgTx := tx.MsgTx()
+		msgTx.TxIn[0].Sequence = MaxReplaceableSequenceNum
+		return util.NewTx(msgTx)
+	}
+	fee := uint64(txRelayFeeForTest)
+
+	replaceableTx := createReplaceableTx(outputs[0], fee)
+	_, err = harness.txPool.ProcessTransaction(replaceableTx, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %s", err)
+	}
+	highFeeTx, err := harness.createTx(outputs[1], fee*100, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(highFeeTx, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %s", err)
+	}
+
+	// All the transactions have the same mass, so any replacement has
+	// to make room for itself by evicting highFeeTx.
+	harness.txPool.cfg.Policy.MaxMempoolMass = harness.txPool.TotalMass() - 1
+
+	_, err = harness.txPool.ProcessTransaction(createReplaceableTx(outputs[0], fee*10), true, 0)
+	if code, _ := extractRejectCode(err); code != wire.RejectInsufficientFee {
+		t.Fatalf("ProcessTransaction: expected reject code %s, got error: %v",
+			wire.RejectInsufficientFee, err)
+	}
+	testPoolMembership(tc, replaceableTx, false, true, false)
+	testPoolMembership(tc, highFeeTx, false, true, false)
+
+	replacementTx := createReplaceableTx(outputs[0], fee*200)
+	_, err = harness.txPool.ProcessTransaction(replacementTx, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %s", err)
+	}
+	testPoolMembership(tc, replaceableTx, false, false, false)
+	testPoolMembership(tc, highFeeTx, false, false, false)
+	testPoolMembership(tc, replacementTx, false, true, false)
+	if _, ok := harness.txPool.mpUTXOSet.Get(outputs[1].outpoint); !ok {
+		t.Fatalf("TestReplacementInFullPool: expected %s to be spendable "+
+			"again after its spender was evicted", outputs[1].outpoint)
+	}
+}
+
+// TestAncestorPackages ensures that the ancestor fee and mass of transactions
+// are tracked as their ancestors enter and leave the pool, and that a parent
+// is credited with the package of a child that pays a higher fee rate.
+func TestAncestorPackages(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 1, "TestAncestorPackages")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	fee := uint64(txRelayFeeForTest)
+	parentTx, err := harness.createTx(outputs[0], fee, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(parentTx, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %s", err)
+	}
+	childTx, err := harness.createTx(txOutToSpendableOutpoint(parentTx, 0), fee*5, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(childTx, true, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %s", err)
+	}
+
+	parentDesc, err := harness.txPool.FetchTxDesc(parentTx.ID())
+	if err != nil {
+		t.Fatalf("FetchTxDesc: %s", err)
+	}
+	if parentDesc.AncestorFee != parentDesc.Fee || parentDesc.AncestorMass != parentDesc.Mass {
+		t.Fatalf("TestAncestorPackages: unexpected parent ancestor fee and mass: "+
+			"got (%d, %d), want (%d, %d)", parentDesc.AncestorFee, parentDesc.AncestorMass,
+			parentDesc.Fee, parentDesc.Mass)
+	}
+	childDesc, ok := harness.txPool.depends[*childTx.ID()]
+	if !ok {
+		t.Fatalf("TestAncestorPackages: child is missing from the depends pool")
+	}
+	expectedPackageFee := parentDesc.Fee + childDesc.Fee
+	expectedPackageMass := parentDesc.Mass + childDesc.Mass
+	if childDesc.AncestorFee != expectedPackageFee || childDesc.AncestorMass != expectedPackageMass {
+		t.Fatalf("TestAncestorPackages: unexpected child ancestor fee and mass: "+
+			"got (%d, %d), want (%d, %d)", childDesc.AncestorFee, childDesc.AncestorMass,
+			expectedPackageFee, expectedPackageMass)
+	}
+
+	miningDescs := harness.txPool.MiningDescs()
+	if len(miningDescs) != 1 || !miningDescs[0].Tx.ID().IsEqual(parentTx.ID()) {
+		t.Fatalf("TestAncestorPackages: expected only the parent to be minable")
+	}
+	if miningDescs[0].PackageFee != expectedPackageFee || miningDescs[0].PackageMass != expectedPackageMass {
+		t.Fatalf("TestAncestorPackages: unexpected parent package fee and mass: "+
+			"got (%d, %d), want (%d, %d)", miningDescs[0].PackageFee, miningDescs[0].PackageMass,
+			expectedPackageFee, expectedPackageMass)
+	}
+
+	// Once the parent is mined, the child is its own package.
+	tc.mineTransactions([]*util.Tx{parentTx}, 1)
+	testPoolMembership(tc, childTx, false, true, false)
+	if childDesc.AncestorFee != childDesc.Fee || childDesc.AncestorMass != childDesc.Mass {
+		t.Fatalf("TestAncestorPackages: unexpected child ancestor fee and mass after "+
+			"mining its parent: got (%d, %d), want (%d, %d)", childDesc.AncestorFee,
+			childDesc.AncestorMass, childDesc.Fee, childDesc.Mass)
+	}
+	miningDescs = harness.txPool.MiningDescs()
+	if len(miningDescs) != 1 || miningDescs[0].PackageFee != childDesc.Fee ||
+		miningDescs[0].PackageMass != childDesc.Mass {
+		t.Fatalf("TestAncestorPackages: expected the child to be minable on its own")
+	}
+}
+
+// TestMempoolLimit ensures that the transactions with the lowest fee rates
+// are evicted once the pool exceeds its maximum mass, and that the dynamic
+// minimum fee rate is raised accordingly.
+func TestMempoolLimit(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 4, "TestMempoolLimit")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	fee := uint64(txRelayFeeForTest)
+	createAndProcessTx := func(outpoint spendableOutpoint, fee uint64) (*util.Tx, error) {
+		tx, err := harness.createTx(outpoint, fee, 1)
+		if err != nil {
+			t.Fatalf("unable to create transaction: %v", err)
+		}
+		_, err = harness.txPool.ProcessTransaction(tx, true, 0)
+		return tx, err
+	}
+
+	lowFeeTx, err := createAndProcessTx(outputs[0], fee)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %s", err)
+	}
+	midFeeTx, err := createAndProcessTx(outputs[1], fee*2)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %s", err)
+	}
+	if harness.txPool.MinFeeRate() != 0 {
+		t.Fatalf("TestMempoolLimit: expected no minimum fee rate before the pool is full")
+	}
+
+	var evictedTxs []*util.Tx
+	harness.txPool.SubscribeRemovals(func(txs []*util.Tx, reason RemovalReason) {
+		if reason != RemovalReasonEvicted {
+			t.Fatalf("TestMempoolLimit: expected removal reason %s, got %s",
+				RemovalReasonEvicted, reason)
+		}
+		evictedTxs = append(evictedTxs, txs...)
+
+		// Callbacks are called once the mempool lock is released, so
+		// they may call back into the pool.
+		if harness.txPool.TotalMass() > harness.txPool.cfg.Policy.MaxMempoolMass {
+			t.Fatalf("TestMempoolLimit: pool mass %d exceeds the limit of %d "+
+				"after the eviction", harness.txPool.TotalMass(),
+				harness.txPool.cfg.Policy.MaxMempoolMass)
+		}
+	})
+
+	// Limit the pool to its current mass, so that a higher fee rate
+	// transaction evicts the lowest fee rate transaction.
+	harness.txPool.cfg.Policy.MaxMempoolMass = harness.txPool.TotalMass()
+	lowFeeTxDesc, err := harness.txPool.FetchTxDesc(lowFeeTx.ID())
+	if err != nil {
+		t.Fatalf("FetchTxDesc: %s", err)
+	}
+	highFeeTx, err := createAndProcessTx(outputs[2], fee*3)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: %s", err)
+	}
+	testPoolMembership(tc, lowFeeTx, false, false, false)
+	testPoolMembership(tc, midFeeTx, false, true, false)
+	testPoolMembership(tc, highFeeTx, false, true, false)
+	if len(evictedTxs) != 1 || !evictedTxs[0].ID().IsEqual(lowFeeTx.ID()) {
+		t.Fatalf("TestMempoolLimit: expected the eviction of %s to be notified",
+			lowFeeTx.ID())
+	}
+	if harness.txPool.TotalMass() > harness.txPool.cfg.Policy.MaxMempoolMass {
+		t.Fatalf("TestMempoolLimit: pool mass %d exceeds the limit of %d",
+			harness.txPool.TotalMass(), harness.txPool.cfg.Policy.MaxMempoolMass)
+	}
+	if _, ok := harness.txPool.mpUTXOSet.Get(outputs[0].outpoint); !ok {
+		t.Fatalf("TestMempoolLimit: the input of the evicted transaction was not restored")
+	}
+
+	// The evicted transaction, as well as anything else that doesn't pay
+	// more than it, can no longer enter the pool.
+	minFeeRate := harness.txPool.MinFeeRate()
+	if minFeeRate <= lowFeeTxDesc.FeePerMegaGram {
+		t.Fatalf("TestMempoolLimit: expected a minimum fee rate above %d, got %d",
+			lowFeeTxDesc.FeePerMegaGram, minFeeRate)
+	}
+	_, err = createAndProcessTx(outputs[0], fee)
+	if code, _ := extractRejectCode(err); code != wire.RejectInsufficientFee {
+		t.Fatalf("ProcessTransaction: expected reject code %s, got error: %v",
+			wire.RejectInsufficientFee, err)
+	}
+
+	// Once the minimum fee rate decays, a transaction that pays it but
+	// not more than the pool content is evicted right away.
+	harness.txPool.minFeeRate = 0
+	_, err = createAndProcessTx(outputs[3], fee)
+	if code, _ := extractRejectCode(err); code != wire.RejectInsufficientFee {
+		t.Fatalf("ProcessTransaction: expected reject code %s, got error: %v",
+			wire.RejectInsufficientFee, err)
+	}
+	testPoolMembership(tc, midFeeTx, false, true, false)
+	testPoolMembership(tc, highFeeTx, false, true, false)
+}
+
+// TestEvictionHeap ensures that the transaction with the lowest eviction fee
+// rate is kept at the top of the eviction heap as transactions enter and
+// leave the pool.
+func TestEvictionHeap(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 3, "TestEvictionHeap")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	fee := uint64(txRelayFeeForTest)
+	createAndProcessTx := func(outpoint spendableOutpoint, fee uint64) *util.Tx {
+		tx, err := harness.createTx(outpoint, fee, 1)
+		if err != nil {
+			t.Fatalf("unable to create transaction: %v", err)
+		}
+		_, err = harness.txPool.ProcessTransaction(tx, true, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: %s", err)
+		}
+		return tx
+	}
+	checkHeap := func(expectedLowestTx *util.Tx, expectedLen int) {
+		evictionHeap := harness.txPool.evictionHeap
+		if len(evictionHeap) != expectedLen {
+			t.Fatalf("TestEvictionHeap: expected %d transactions in the heap, got %d",
+				expectedLen, len(evictionHeap))
+		}
+		for i, txDesc := range evictionHeap {
+			if txDesc.evictionHeapIndex != i {
+				t.Fatalf("TestEvictionHeap: transaction %s is at index %d "+
+					"but has index %d", txDesc.Tx.ID(), i, txDesc.evictionHeapIndex)
+			}
+		}
+		if !evictionHeap[0].Tx.ID().IsEqual(expectedLowestTx.ID()) {
+			t.Fatalf("TestEvictionHeap: expected %s at the top of the heap, got %s",
+				expectedLowestTx.ID(), evictionHeap[0].Tx.ID())
+		}
+	}
+
+	highFeeTx := createAndProcessTx(outputs[0], fee*3)
+	lowFeeTx := createAndProcessTx(outputs[1], fee)
+	midFeeTx := createAndProcessTx(outputs[2], fee*2)
+	checkHeap(lowFeeTx, 3)
+
+	// A child that pays a high fee rate raises the eviction fee rate of
+	// its parent.
+	createAndProcessTx(txOutToSpendableOutpoint(lowFeeTx, 0), fee*10)
+	checkHeap(midFeeTx, 4)
+
+	_, err = harness.txPool.RemoveTransactionWithDescendants(midFeeTx.ID())
+	if err != nil {
+		t.Fatalf("RemoveTransactionWithDescendants: %s", err)
+	}
+	checkHeap(highFeeTx, 3)
+}
+
+// TestPersistMempool ensures that the transactions in the pool are loaded
+// back after they were saved.
+func TestPersistMempool(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 1, "TestPersistMempool")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	chainedTxns, err := harness.CreateTxChain(outputs[0], 3)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	for _, tx := range chainedTxns {
+		_, err = harness.txPool.ProcessTransaction(tx, false, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: %s", err)
+		}
+	}
+	err = harness.txPool.Save()
+	if err != nil {
+		t.Fatalf("Save: %s", err)
+	}
+
+	harness.txPool = New(&harness.txPool.cfg)
+	err = harness.txPool.Load()
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	testPoolMembership(tc, chainedTxns[0], false, true, false)
+	for _, tx := range chainedTxns[1:] {
+		testPoolMembership(tc, tx, false, true, true)
+	}
+}
+
+// TestRemovalReasonStringer tests the stringized output for the
+// RemovalReason type.
+func TestRemovalReasonStringer(t *testing.T) {
+	tests := []struct {
+		in   RemovalReason
+		want string
+	}{
+		{RemovalReasonExpired, "expired"},
+		{RemovalReasonManual, "manual"},
+		{RemovalReasonEvicted, "evicted"},
+		{RemovalReasonReplaced, "replaced"},
+		{RemovalReasonDoubleSpend, "doubleSpend"},
+		{0xff, "Unknown RemovalReason (255)"},
+	}
+	for i, test := range tests {
+		result := test.in.String()
+		if result != test.want {
+			t.Errorf("String #%d\n got: %s want: %s", i, result, test.want)
+		}
+	}
+}
+
+// TestExpireAndRemoveTransactions ensures that expired transactions, as well
+// as transactions that are explicitly removed, are removed from the pool along
+// with their descendants, and that their removal is notified.
+func TestExpireAndRemoveTransactions(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 3, "TestExpireAndRemoveTransactions")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	var notifiedTxs []*util.Tx
+	var notifiedReason RemovalReason
+	harness.txPool.SubscribeRemovals(func(txs []*util.Tx, reason RemovalReason) {
+		notifiedTxs = txs
+		notifiedReason = reason
+	})
+	checkRemoved := func(removedTxs []*util.Tx, expectedTxs []*util.Tx, expectedReason RemovalReason) {
+		if len(removedTxs) != len(expectedTxs) || len(notifiedTxs) != len(expectedTxs) {
+			t.Fatalf("TestExpireAndRemoveTransactions: expected %d removed transactions, "+
+				"got %d removed and %d notified", len(expectedTxs), len(removedTxs), len(notifiedTxs))
+		}
+		if notifiedReason != expectedReason {
+			t.Fatalf("TestExpireAndRemoveTransactions: expected removal reason %s, got %s",
+				expectedReason, notifiedReason)
+		}
+		for _, tx := range expectedTxs {
+			testPoolMembership(tc, tx, false, false, false)
+		}
+		notifiedTxs = nil
+	}
+
+	var chains [][]*util.Tx
+	for _, output := range outputs {
+		chainedTxns, err := harness.CreateTxChain(output, 2)
+		if err != nil {
+			t.Fatalf("unable to create transaction chain: %v", err)
+		}
+		for _, tx := range chainedTxns {
+			_, err = harness.txPool.ProcessTransaction(tx, false, 0)
+			if err != nil {
+				t.Fatalf("ProcessTransaction: %s", err)
+			}
+		}
+		chains = append(chains, chainedTxns)
+	}
+
+	// Nothing expires as long as no transaction is older than the maximum
+	// transaction age.
+	harness.txPool.cfg.Policy.MaxTxAge = time.Hour
+	removedTxs, err := harness.txPool.ExpireTransactions()
+	if err != nil {
+		t.Fatalf("ExpireTransactions: %s", err)
+	}
+	if len(removedTxs) != 0 || notifiedTxs != nil {
+		t.Fatalf("TestExpireAndRemoveTransactions: expected no transactions to expire")
+	}
+
+	// An expired transaction is removed along with its descendants.
+	expiredTxDesc, err := harness.txPool.FetchTxDesc(chains[0][0].ID())
+	if err != nil {
+		t.Fatalf("FetchTxDesc: %s", err)
+	}
+	expiredTxDesc.Added = time.Now().Add(-2 * time.Hour)
+	removedTxs, err = harness.txPool.ExpireTransactions()
+	if err != nil {
+		t.Fatalf("ExpireTransactions: %s", err)
+	}
+	checkRemoved(removedTxs, chains[0], RemovalReasonExpired)
+	if _, ok := harness.txPool.mpUTXOSet.Get(outputs[0].outpoint); !ok {
+		t.Fatalf("TestExpireAndRemoveTransactions: the input of the expired transaction was not restored")
+	}
+
+	// Removing a transaction removes its descendants as well.
+	removedTxs, err = harness.txPool.RemoveTransactionWithDescendants(chains[1][0].ID())
+	if err != nil {
+		t.Fatalf("RemoveTransactionWithDescendants: %s", err)
+	}
+	checkRemoved(removedTxs, chains[1], RemovalReasonManual)
+	_, err = harness.txPool.RemoveTransactionWithDescendants(chains[1][0].ID())
+	if err == nil {
+		t.Fatalf("RemoveTransactionWithDescendants: expected an error for a " +
+			"transaction that is not in the pool")
+	}
+
+	// Clearing the pool removes all the remaining transactions.
+	removedTxs, err = harness.txPool.Clear()
+	if err != nil {
+		t.Fatalf("Clear: %s", err)
+	}
+	checkRemoved(removedTxs, chains[2], RemovalReasonManual)
+	if harness.txPool.Count() != 0 || harness.txPool.DepCount() != 0 || harness.txPool.TotalMass() != 0 {
+		t.Fatalf("TestExpireAndRemoveTransactions: expected the pool to be empty after clearing it")
+	}
+}
+
+// TestRemoveDoubleSpends ensures that transactions that double spend a
+// transaction in a block are removed from the pool along with their
+// descendants, and that their removal is notified.
+func TestRemoveDoubleSpends(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 1, "TestRemoveDoubleSpends")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	var notifiedTxs []*util.Tx
+	var notifiedReason RemovalReason
+	harness.txPool.SubscribeRemovals(func(txs []*util.Tx, reason RemovalReason) {
+		notifiedTxs = txs
+		notifiedReason = reason
+	})
+
+	chainedTxns, err := harness.CreateTxChain(outputs[0], 2)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	for _, tx := range chainedTxns {
+		_, err = harness.txPool.ProcessTransaction(tx, false, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: %s", err)
+		}
+	}
+
+	doubleSpendTx, err := harness.createTx(outputs[0], uint64(txRelayFeeForTest)*2, 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	harness.txPool.RemoveDoubleSpends(doubleSpendTx)
+	for _, tx := range chainedTxns {
+		testPoolMembership(tc, tx, false, false, false)
+	}
+	if len(notifiedTxs) != len(chainedTxns) {
+		t.Fatalf("TestRemoveDoubleSpends: expected %d notified transactions, got %d",
+			len(chainedTxns), len(notifiedTxs))
+	}
+	if notifiedReason != RemovalReasonDoubleSpend {
+		t.Fatalf("TestRemoveDoubleSpends: expected removal reason %s, got %s",
+			RemovalReasonDoubleSpend, notifiedReason)
+	}
+}
+
 func TestCount(t *testing.T) {
 	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 1, "TestCount")
 	if err != nil {
@@ -1834,3 +2505,54 @@ func TestTransactionGas(t *testing.T) {
 		t.Error("ProcessTransaction did not return error, expecting ErrInvalidGas")
 	}
 }
+
+func TestFillCompactBlock(t *testing.T) {
+	tc, outputs, teardownFunc, err := newPoolHarness(t, &dagconfig.SimnetParams, 1, "TestFillCompactBlock")
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	defer teardownFunc()
+	harness := tc.harness
+
+	coinbase, err := harness.CreateCoinbaseTx(1, 1)
+	if err != nil {
+		t.Fatalf("CreateCoinbaseTx: unexpected error: %v", err)
+	}
+	chainedTxns, err := harness.CreateTxChain(outputs[0], 3)
+	if err != nil {
+		t.Fatalf("harness.CreateTxChain: unexpected error: %v", err)
+	}
+
+	// Add only the first two transactions of the chain to the pool, so
+	// that the second one depends on the first and the third one is
+	// missing.
+	for _, tx := range chainedTxns[:2] {
+		_, err = harness.txPool.ProcessTransaction(tx, true, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: unexpected error: %v", err)
+		}
+	}
+	testPoolMembership(tc, chainedTxns[1], false, true, true)
+
+	header := wire.NewBlockHeader(1, []*daghash.Hash{}, &daghash.Hash{},
+		&daghash.Hash{}, &daghash.Hash{}, 0, 0)
+	block := wire.NewMsgBlock(header)
+	block.AddTransaction(coinbase.MsgTx())
+	for _, tx := range chainedTxns {
+		block.AddTransaction(tx.MsgTx())
+	}
+	compactBlock := wire.NewMsgCmpctBlock(block)
+
+	txs, missingIndexes := harness.txPool.FillCompactBlock(compactBlock)
+	expectedTxs := []*wire.MsgTx{coinbase.MsgTx(), chainedTxns[0].MsgTx(),
+		chainedTxns[1].MsgTx(), nil}
+	if !reflect.DeepEqual(txs, expectedTxs) {
+		t.Errorf("FillCompactBlock: unexpected transactions. Want: %v, got: %v",
+			expectedTxs, txs)
+	}
+	expectedMissingIndexes := []uint32{3}
+	if !reflect.DeepEqual(missingIndexes, expectedMissingIndexes) {
+		t.Errorf("FillCompactBlock: unexpected missing indexes. Want: %v, got: %v",
+			expectedMissingIndexes, missingIndexes)
+	}
+}
@@ -0,0 +1,154 @@
+package mempool
+
+import (
+	"bytes"
+	"encoding/gob"
+	"time"
+
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+	"github.com/pkg/errors"
+)
+
+// persistedMempoolVersion is the current version of the mempool
+// transactions that are stored in the database.
+const persistedMempoolVersion = 1
+
+type persistedMempool struct {
+	Version      int
+	Transactions []persistedTx
+}
+
+type persistedTx struct {
+	Tx    []byte
+	Added int64
+}
+
+// Save stores all the transactions in the main pool in the database, so that
+// they can be loaded back with Load once the node restarts.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Save() error {
+	serializedMempool, err := mp.serialize()
+	if err != nil {
+		return err
+	}
+	return dbaccess.StoreMempool(dbaccess.NoTx(), serializedMempool)
+}
+
+func (mp *TxPool) serialize() ([]byte, error) {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	state := persistedMempool{Version: persistedMempoolVersion}
+	for _, txDesc := range mp.sortedTxDescs() {
+		w := &bytes.Buffer{}
+		err := txDesc.Tx.MsgTx().Serialize(w)
+		if err != nil {
+			return nil, err
+		}
+		state.Transactions = append(state.Transactions, persistedTx{
+			Tx:    w.Bytes(),
+			Added: txDesc.Added.Unix(),
+		})
+	}
+
+	w := &bytes.Buffer{}
+	encoder := gob.NewEncoder(w)
+	err := encoder.Encode(&state)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode mempool transactions")
+	}
+	return w.Bytes(), nil
+}
+
+// sortedTxDescs returns all the transactions in the main pool, where every
+// transaction comes after the transactions in the pool it depends on.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) sortedTxDescs() []*TxDesc {
+	sorted := make([]*TxDesc, 0, len(mp.pool)+len(mp.depends))
+	visited := make(map[daghash.TxID]struct{}, len(mp.pool)+len(mp.depends))
+	var visit func(txDesc *TxDesc)
+	visit = func(txDesc *TxDesc) {
+		if _, ok := visited[*txDesc.Tx.ID()]; ok {
+			return
+		}
+		visited[*txDesc.Tx.ID()] = struct{}{}
+		for _, txIn := range txDesc.Tx.MsgTx().TxIn {
+			if parent, exists := mp.fetchTxDesc(&txIn.PreviousOutpoint.TxID); exists {
+				visit(parent)
+			}
+		}
+		sorted = append(sorted, txDesc)
+	}
+	for _, txDesc := range mp.pool {
+		visit(txDesc)
+	}
+	for _, txDesc := range mp.depends {
+		visit(txDesc)
+	}
+	return sorted
+}
+
+// Load loads the transactions that were stored with Save back into the pool.
+// Every transaction is validated again, and transactions that are no longer
+// valid, such as ones that were mined in the meantime, are dropped.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Load() error {
+	serializedMempool, err := dbaccess.FetchMempool(dbaccess.NoTx())
+	if dbaccess.IsNotFoundError(err) {
+		log.Info("No mempool transactions were found in the database")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var state persistedMempool
+	decoder := gob.NewDecoder(bytes.NewBuffer(serializedMempool))
+	err = decoder.Decode(&state)
+	if err != nil {
+		return errors.Wrap(err, "error deserializing mempool transactions")
+	}
+	if state.Version != persistedMempoolVersion {
+		return errors.Errorf("unknown version %d in serialized "+
+			"mempool transactions", state.Version)
+	}
+
+	defer mp.notifyPendingRemovals()
+	mp.cfg.DAG.RLock()
+	defer mp.cfg.DAG.RUnlock()
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+
+	accepted := 0
+	for _, persisted := range state.Transactions {
+		msgTx := &wire.MsgTx{}
+		err := msgTx.Deserialize(bytes.NewReader(persisted.Tx))
+		if err != nil {
+			return errors.Wrap(err, "error deserializing mempool transaction")
+		}
+		tx := util.NewTx(msgTx)
+
+		missingParents, txD, err := mp.maybeAcceptTransaction(tx, true)
+		if err != nil {
+			log.Debugf("Dropped persisted transaction %s: %s", tx.ID(), err)
+			continue
+		}
+		if len(missingParents) > 0 {
+			log.Debugf("Dropped persisted transaction %s: it spends "+
+				"unknown or fully-spent outputs", tx.ID())
+			continue
+		}
+		txD.Added = time.Unix(persisted.Added, 0)
+		accepted++
+	}
+
+	log.Infof("Loaded %d out of %d mempool transactions from the database",
+		accepted, len(state.Transactions))
+	return nil
+}
@@ -0,0 +1,317 @@
+package mempool
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+// TxExpireScanInterval is the amount of time in between scans of the main
+// pool to remove expired transactions. See ExpireTransactions.
+const TxExpireScanInterval = time.Minute * 5
+
+// RemovalReason describes why transactions were removed from the pool.
+type RemovalReason int
+
+const (
+	// RemovalReasonExpired means the transactions stayed in the pool for
+	// longer than the maximum transaction age allowed by the policy, or
+	// depend on such transactions.
+	RemovalReasonExpired RemovalReason = iota
+
+	// RemovalReasonManual means the transactions were explicitly removed
+	// by an operator, or depend on such transactions.
+	RemovalReasonManual
+
+	// RemovalReasonEvicted means the transactions were evicted from the
+	// full pool to make room for transactions that pay higher fee rates,
+	// or depend on such transactions.
+	RemovalReasonEvicted
+
+	// RemovalReasonReplaced means the transactions were replaced by a
+	// conflicting transaction that pays a higher fee, or depend on such
+	// transactions.
+	RemovalReasonReplaced
+
+	// RemovalReasonDoubleSpend means the transactions spend outputs that
+	// were already spent by a transaction in a block, or depend on such
+	// transactions.
+	RemovalReasonDoubleSpend
+)
+
+// Map of removal reasons back to their constant names for pretty printing.
+var removalReasonStrings = map[RemovalReason]string{
+	RemovalReasonExpired:     "expired",
+	RemovalReasonManual:      "manual",
+	RemovalReasonEvicted:     "evicted",
+	RemovalReasonReplaced:    "replaced",
+	RemovalReasonDoubleSpend: "doubleSpend",
+}
+
+// String returns the RemovalReason as a human-readable name.
+func (reason RemovalReason) String() string {
+	if s, ok := removalReasonStrings[reason]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown RemovalReason (%d)", int(reason))
+}
+
+// RemovalCallback is called with the transactions that were dropped from
+// the pool without being included in a block, along with the reason for
+// their removal.
+type RemovalCallback func(txs []*util.Tx, reason RemovalReason)
+
+// pendingRemoval holds transactions that were removed from the pool while
+// the mempool lock was held, until the callbacks can be notified of them.
+type pendingRemoval struct {
+	txs    []*util.Tx
+	reason RemovalReason
+}
+
+// SubscribeRemovals registers a callback to be executed whenever
+// transactions are dropped from the pool without being included in a block.
+// The callback is never called with the mempool lock held.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) SubscribeRemovals(callback RemovalCallback) {
+	mp.removalCallbacksLock.Lock()
+	defer mp.removalCallbacksLock.Unlock()
+	mp.removalCallbacks = append(mp.removalCallbacks, callback)
+}
+
+// queueTransactionsRemoved queues the transactions that were removed from the
+// pool, so that the callbacks that were registered with SubscribeRemovals are
+// notified of them by notifyPendingRemovals once the mempool lock is released.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) queueTransactionsRemoved(txs []*util.Tx, reason RemovalReason) {
+	if len(txs) == 0 {
+		return
+	}
+	mp.pendingRemovals = append(mp.pendingRemovals, pendingRemoval{txs: txs, reason: reason})
+}
+
+// notifyPendingRemovals notifies the callbacks that were registered with
+// SubscribeRemovals of the removals that were queued with
+// queueTransactionsRemoved.
+//
+// This function MUST NOT be called with the mempool lock held.
+func (mp *TxPool) notifyPendingRemovals() {
+	mp.mtx.Lock()
+	pendingRemovals := mp.pendingRemovals
+	mp.pendingRemovals = nil
+	mp.mtx.Unlock()
+
+	for _, removal := range pendingRemovals {
+		mp.notifyTransactionsRemoved(removal.txs, removal.reason)
+	}
+}
+
+// notifyTransactionsRemoved passes the transactions that were removed from
+// the pool to the callbacks that were registered with SubscribeRemovals.
+//
+// This function MUST NOT be called with the mempool lock held.
+func (mp *TxPool) notifyTransactionsRemoved(txs []*util.Tx, reason RemovalReason) {
+	if len(txs) == 0 {
+		return
+	}
+	mp.removalCallbacksLock.RLock()
+	defer mp.removalCallbacksLock.RUnlock()
+	for _, callback := range mp.removalCallbacks {
+		callback(txs, reason)
+	}
+}
+
+// txWithDescendants returns the passed transaction followed by all the
+// transactions in the pool that depend on it.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) txWithDescendants(tx *util.Tx) []*util.Tx {
+	txs := []*util.Tx{tx}
+	for _, descendant := range mp.txDescendants(tx) {
+		txs = append(txs, descendant.Tx)
+	}
+	return txs
+}
+
+// removeTransactionWithDescendants removes the passed transaction from the
+// pool along with all the transactions that depend on it, and returns all
+// the removed transactions. The inputs of the removed transactions become
+// spendable by other transactions again.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) removeTransactionWithDescendants(tx *util.Tx) ([]*util.Tx, error) {
+	removedTxs := mp.txWithDescendants(tx)
+	err := mp.removeTransaction(tx, true, false)
+	if err != nil {
+		return nil, err
+	}
+	err = mp.restoreTransactionInputs(removedTxs)
+	if err != nil {
+		return nil, err
+	}
+	return removedTxs, nil
+}
+
+// restoreTransactionInputs adds the outpoints spent by the passed transactions,
+// which were just now removed from the pool, back to the mempool UTXO set.
+// Unlike markTransactionOutputsUnspent, it restores outpoints that were
+// spent from the DAG's UTXO set as well, and not only outpoints that were
+// spent from transactions in the pool.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) restoreTransactionInputs(removedTxs []*util.Tx) error {
+	diff := blockdag.NewUTXODiff()
+	for _, tx := range removedTxs {
+		for _, txIn := range tx.MsgTx().TxIn {
+			if _, exists := mp.mpUTXOSet.Get(txIn.PreviousOutpoint); exists {
+				continue
+			}
+			entry, exists := mp.fetchSpentUTXOEntry(txIn.PreviousOutpoint)
+			if !exists {
+				continue
+			}
+			err := diff.AddEntry(txIn.PreviousOutpoint, entry)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var err error
+	mp.mpUTXOSet, err = mp.mpUTXOSet.WithDiff(diff)
+	return err
+}
+
+// RemoveTransactionWithDescendants removes the transaction with the passed ID
+// from the pool along with all the transactions that depend on it, and
+// returns all the removed transactions. The inputs of the removed
+// transactions become spendable by other transactions again.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) RemoveTransactionWithDescendants(txID *daghash.TxID) ([]*util.Tx, error) {
+	removedTxs, err := func() ([]*util.Tx, error) {
+		mp.mtx.Lock()
+		defer mp.mtx.Unlock()
+
+		txDesc, exists := mp.fetchTxDesc(txID)
+		if !exists {
+			return nil, errors.Errorf("transaction %s is not in the pool", txID)
+		}
+		return mp.removeTransactionWithDescendants(txDesc.Tx)
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	mp.notifyTransactionsRemoved(removedTxs, RemovalReasonManual)
+	return removedTxs, nil
+}
+
+// Clear removes all the transactions from the main pool and the orphan pool,
+// and returns the transactions that were removed from the main pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Clear() ([]*util.Tx, error) {
+	removedTxs, err := func() ([]*util.Tx, error) {
+		mp.mtx.Lock()
+		defer mp.mtx.Unlock()
+
+		for _, orphan := range mp.orphans {
+			mp.removeOrphan(orphan.tx, false)
+		}
+
+		// Every dependent transaction is a descendant of some transaction
+		// in the main pool, so it's removed along with it.
+		poolTxs := make([]*util.Tx, 0, len(mp.pool))
+		for _, txDesc := range mp.pool {
+			poolTxs = append(poolTxs, txDesc.Tx)
+		}
+		var removedTxs []*util.Tx
+		for _, tx := range poolTxs {
+			if !mp.isTransactionInPool(tx.ID()) {
+				continue
+			}
+			txs, err := mp.removeTransactionWithDescendants(tx)
+			if err != nil {
+				return nil, err
+			}
+			removedTxs = append(removedTxs, txs...)
+		}
+		return removedTxs, nil
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Cleared %d transactions from the mempool", len(removedTxs))
+	mp.notifyTransactionsRemoved(removedTxs, RemovalReasonManual)
+	return removedTxs, nil
+}
+
+// expireTransactions removes the transactions that stayed in the pool for
+// longer than the maximum transaction age allowed by the policy, along with
+// their descendants, and returns all the removed transactions.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) expireTransactions() ([]*util.Tx, error) {
+	maxTxAge := mp.cfg.Policy.MaxTxAge
+	if maxTxAge == 0 {
+		return nil, nil
+	}
+
+	expiration := time.Now().Add(-maxTxAge)
+	var expiredTxs []*util.Tx
+	for _, txDesc := range mp.pool {
+		if txDesc.Added.Before(expiration) {
+			expiredTxs = append(expiredTxs, txDesc.Tx)
+		}
+	}
+	for _, txDesc := range mp.depends {
+		if txDesc.Added.Before(expiration) {
+			expiredTxs = append(expiredTxs, txDesc.Tx)
+		}
+	}
+
+	var removedTxs []*util.Tx
+	for _, tx := range expiredTxs {
+		// The transaction may have already been removed as a descendant
+		// of another expired transaction.
+		if !mp.isTransactionInPool(tx.ID()) {
+			continue
+		}
+		txs, err := mp.removeTransactionWithDescendants(tx)
+		if err != nil {
+			return nil, err
+		}
+		removedTxs = append(removedTxs, txs...)
+	}
+	if len(removedTxs) > 0 {
+		log.Debugf("Expired %d transactions from the mempool", len(removedTxs))
+	}
+	return removedTxs, nil
+}
+
+// ExpireTransactions removes the transactions that stayed in the pool for
+// longer than the maximum transaction age allowed by the policy, along with
+// their descendants, and returns all the removed transactions. It's meant to
+// be called once every TxExpireScanInterval.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) ExpireTransactions() ([]*util.Tx, error) {
+	removedTxs, err := func() ([]*util.Tx, error) {
+		mp.mtx.Lock()
+		defer mp.mtx.Unlock()
+		return mp.expireTransactions()
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	mp.notifyTransactionsRemoved(removedTxs, RemovalReasonExpired)
+	return removedTxs, nil
+}
@@ -0,0 +1,199 @@
+package mempool
+
+import (
+	"fmt"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// MaxReplaceableSequenceNum is the maximum input sequence number that
+// signals replaceability. A transaction that has at least one input with
+// a sequence number lower than or equal to this value may be replaced by
+// a conflicting transaction that pays a higher fee.
+const MaxReplaceableSequenceNum = wire.MaxTxInSequenceNum - 2
+
+// signalsReplacement returns whether the given transaction opted in to be
+// replaceable by a conflicting transaction.
+func signalsReplacement(tx *util.Tx) bool {
+	for _, txIn := range tx.MsgTx().TxIn {
+		if txIn.Sequence <= MaxReplaceableSequenceNum {
+			return true
+		}
+	}
+	return false
+}
+
+// replacedTransactions returns the given transactions that conflict with tx,
+// along with all of their descendants in the pool. These are all the
+// transactions that have to be evicted from the pool in order to accept tx.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) replacedTransactions(tx *util.Tx, conflicts []*util.Tx) ([]*TxDesc, error) {
+	replacedTxIDs := make(map[daghash.TxID]struct{})
+	var replacedTxs []*TxDesc
+	queue := conflicts
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if _, ok := replacedTxIDs[*current.ID()]; ok {
+			continue
+		}
+		txDesc, ok := mp.fetchTxDesc(current.ID())
+		if !ok {
+			continue
+		}
+		replacedTxIDs[*current.ID()] = struct{}{}
+		replacedTxs = append(replacedTxs, txDesc)
+		if len(replacedTxs) > mp.cfg.Policy.MaxReplacementEvictions {
+			str := fmt.Sprintf("transaction %s would replace more than "+
+				"the maximum of %d transactions", tx.ID(),
+				mp.cfg.Policy.MaxReplacementEvictions)
+			return nil, txRuleError(wire.RejectNonstandard, str)
+		}
+
+		prevOut := wire.Outpoint{TxID: *current.ID()}
+		for txOutIdx := range current.MsgTx().TxOut {
+			prevOut.Index = uint32(txOutIdx)
+			if txRedeemer, exists := mp.outpoints[prevOut]; exists {
+				queue = append(queue, txRedeemer)
+			}
+		}
+	}
+
+	// A transaction can't spend outputs of the transactions it replaces,
+	// since these outputs won't exist once they're evicted.
+	for _, txIn := range tx.MsgTx().TxIn {
+		if _, ok := replacedTxIDs[txIn.PreviousOutpoint.TxID]; ok {
+			str := fmt.Sprintf("transaction %s spends output %s of a "+
+				"transaction it replaces", tx.ID(), txIn.PreviousOutpoint)
+			return nil, txRuleError(wire.RejectInvalid, str)
+		}
+	}
+
+	return replacedTxs, nil
+}
+
+// utxoSetWithoutTransactions returns the mempool UTXO set as it would be
+// if the given transactions were removed from the pool. The mempool UTXO
+// set itself is not modified.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) utxoSetWithoutTransactions(txDescs []*TxDesc) (blockdag.UTXOSet, error) {
+	removedTxIDs := make(map[daghash.TxID]struct{}, len(txDescs))
+	for _, txDesc := range txDescs {
+		removedTxIDs[*txDesc.Tx.ID()] = struct{}{}
+	}
+
+	diff := blockdag.NewUTXODiff()
+	for _, txDesc := range txDescs {
+		tx := txDesc.Tx
+		for txOutIdx := range tx.MsgTx().TxOut {
+			outpoint := *wire.NewOutpoint(tx.ID(), uint32(txOutIdx))
+			if entry, exists := mp.mpUTXOSet.Get(outpoint); exists {
+				err := diff.RemoveEntry(outpoint, entry)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		for _, txIn := range tx.MsgTx().TxIn {
+			if _, ok := removedTxIDs[txIn.PreviousOutpoint.TxID]; ok {
+				continue
+			}
+			if entry, exists := mp.fetchSpentUTXOEntry(txIn.PreviousOutpoint); exists {
+				err := diff.AddEntry(txIn.PreviousOutpoint, entry)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return mp.mpUTXOSet.WithDiff(diff)
+}
+
+// fetchSpentUTXOEntry returns the UTXO entry of the given outpoint, which
+// is spent by some transaction in the pool. The entry is taken from the
+// transaction in the pool that created it, or from the DAG's UTXO set.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) fetchSpentUTXOEntry(outpoint wire.Outpoint) (*blockdag.UTXOEntry, bool) {
+	if prevTxDesc, exists := mp.fetchTxDesc(&outpoint.TxID); exists {
+		prevOut := prevTxDesc.Tx.MsgTx().TxOut[outpoint.Index]
+		return blockdag.NewUTXOEntry(prevOut, false, blockdag.UnacceptedBlueScore), true
+	}
+	return mp.cfg.DAG.GetUTXOEntry(outpoint)
+}
+
+// checkReplacementFee makes sure that tx pays a higher fee than all the
+// transactions it replaces combined, as well as a strictly higher fee rate
+// than each one of them. Otherwise, replacing them would let anyone flood
+// the network with transactions for free.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) checkReplacementFee(tx *util.Tx, txFee uint64, utxoSet blockdag.UTXOSet,
+	replacedTxs []*TxDesc) error {
+
+	mass, err := blockdag.CalcTxMassFromUTXOSet(tx, utxoSet)
+	if err != nil {
+		return err
+	}
+	feePerMegaGram := txFee * 1e6 / mass
+
+	var replacedFees uint64
+	for _, replacedTx := range replacedTxs {
+		if feePerMegaGram <= replacedTx.FeePerMegaGram {
+			str := fmt.Sprintf("transaction %s has a fee rate of %d "+
+				"sompi/megagram, which is not higher than the fee rate "+
+				"of %d sompi/megagram of transaction %s it replaces",
+				tx.ID(), feePerMegaGram, replacedTx.FeePerMegaGram,
+				replacedTx.Tx.ID())
+			return txRuleError(wire.RejectInsufficientFee, str)
+		}
+		replacedFees += replacedTx.Fee
+	}
+	if txFee <= replacedFees {
+		str := fmt.Sprintf("transaction %s has %d fees, which is not "+
+			"higher than the %d fees of the transactions it replaces",
+			tx.ID(), txFee, replacedFees)
+		return txRuleError(wire.RejectInsufficientFee, str)
+	}
+
+	return nil
+}
+
+// removeReplacedTransactions evicts the given transactions, which were
+// replaced by a conflicting transaction, from the pool. The outpoints they
+// spent become spendable again, so that the replacing transaction can spend
+// them.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) removeReplacedTransactions(replacedTxs []*TxDesc) error {
+	removedTxs := make([]*util.Tx, 0, len(replacedTxs))
+	for _, txDesc := range replacedTxs {
+		removedTxs = append(removedTxs, txDesc.Tx)
+
+		// The transaction may have already been removed as a
+		// descendant of another replaced transaction.
+		if !mp.isTransactionInPool(txDesc.Tx.ID()) {
+			continue
+		}
+		err := mp.removeTransaction(txDesc.Tx, true, false)
+		if err != nil {
+			return err
+		}
+	}
+	err := mp.restoreTransactionInputs(removedTxs)
+	if err != nil {
+		return err
+	}
+	for _, tx := range removedTxs {
+		log.Debugf("Replaced transaction %s", tx.ID())
+	}
+	mp.queueTransactionsRemoved(removedTxs, RemovalReasonReplaced)
+	return nil
+}
@@ -37,6 +37,26 @@ type TxDesc struct {
 
 	// FeePerMegaGram is the fee the transaction pays in sompi per million gram.
 	FeePerMegaGram uint64
+
+	// Mass is the mass of the transaction associated with the entry.
+	Mass uint64
+
+	// AncestorFee is the total fee of the transaction associated with the
+	// entry and all of its ancestors that are still in the source pool.
+	AncestorFee uint64
+
+	// AncestorMass is the total mass of the transaction associated with
+	// the entry and all of its ancestors that are still in the source pool.
+	AncestorMass uint64
+
+	// PackageFee and PackageMass are the ancestor fee and ancestor mass of
+	// the transaction with the highest ancestor fee rate among the
+	// transaction associated with the entry and its descendants in the
+	// source pool. Since a transaction can't be included in a block along
+	// with the transactions it spends, mining this transaction is what
+	// allows the rest of its package to be mined.
+	PackageFee  uint64
+	PackageMass uint64
 }
 
 // TxSource represents a source of transactions to consider for inclusion in
@@ -52,7 +52,8 @@ type txsForBlockTemplate struct {
 // The algorithm, roughly, is as follows:
 // 1. We assign a probability to each transaction equal to:
 //    (candidateTx.Value^alpha) / Σ(tx.Value^alpha)
-//    Where the sum of the probabilities of all txs is 1.
+//    Where the sum of the probabilities of all txs is 1, and the value of a
+//    transaction is derived from the fee rate of the best package it is part of.
 // 2. We draw a random number in [0,1) and select a transaction accordingly.
 // 3. If it's valid, add it to the selectedTxs and remove it from the candidates.
 // 4. Continue iterating the above until we have either selected all
@@ -165,13 +166,26 @@ func (g *BlkTmplGenerator) collectCandidatesTxs(sourceTxs []*TxDesc) []*candidat
 			}
 		}
 
-		// Calculate the tx value
-		txValue, err := g.calcTxValue(tx, txDesc.Fee)
+		// Calculate the tx value. A transaction that has descendants
+		// paying a higher fee rate in the source pool is valued by the
+		// fee rate of its package, so that a child may pay for its parent.
+		txValue, err := g.calcTxValue(tx, txDesc.Fee, txMass)
 		if err != nil {
 			log.Warnf("Skipping tx %s due to error in "+
 				"calcTxValue: %s", tx.ID(), err)
 			continue
 		}
+		if txDesc.PackageMass != 0 {
+			packageValue, err := g.calcTxValue(tx, txDesc.PackageFee, txDesc.PackageMass)
+			if err != nil {
+				log.Warnf("Skipping tx %s due to error in "+
+					"calcTxValue: %s", tx.ID(), err)
+				continue
+			}
+			if packageValue > txValue {
+				txValue = packageValue
+			}
+		}
 
 		candidateTxs = append(candidateTxs, &candidateTx{
 			txDesc:   txDesc,
@@ -190,14 +204,11 @@ func (g *BlkTmplGenerator) collectCandidatesTxs(sourceTxs []*TxDesc) []*candidat
 	return candidateTxs
 }
 
-// calcTxValue calculates a value to be used in transaction selection.
+// calcTxValue calculates a value to be used in transaction selection,
+// given the fee and the mass that are attributed to the transaction.
 // The higher the number the more likely it is that the transaction will be
 // included in the block.
-func (g *BlkTmplGenerator) calcTxValue(tx *util.Tx, fee uint64) (float64, error) {
-	mass, err := blockdag.CalcTxMassFromUTXOSet(tx, g.dag.UTXOSet())
-	if err != nil {
-		return 0, err
-	}
+func (g *BlkTmplGenerator) calcTxValue(tx *util.Tx, fee uint64, mass uint64) (float64, error) {
 	massLimit := g.policy.BlockMaxMass
 
 	msgTx := tx.MsgTx()
@@ -301,8 +312,9 @@ func (g *BlkTmplGenerator) populateTemplateFromCandidates(candidateTxs []*candid
 		txsForBlockTemplate.totalMass += selectedTx.txMass
 		txsForBlockTemplate.totalFees += selectedTx.txDesc.Fee
 
-		log.Tracef("Adding tx %s (feePerMegaGram %d)",
-			tx.ID(), selectedTx.txDesc.FeePerMegaGram)
+		log.Tracef("Adding tx %s (feePerMegaGram %d, packageFee %d, packageMass %d)",
+			tx.ID(), selectedTx.txDesc.FeePerMegaGram,
+			selectedTx.txDesc.PackageFee, selectedTx.txDesc.PackageMass)
 
 		markCandidateTxForDeletion(selectedTx)
 	}
@@ -0,0 +1,135 @@
+package mining
+
+import (
+	"testing"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/txscript"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// TestSelectTxsChildPaysForParent ensures that a low fee transaction whose
+// descendant pays a high fee rate is selected into the block template over
+// a transaction that pays a higher fee rate on its own.
+func TestSelectTxsChildPaysForParent(t *testing.T) {
+	params := dagconfig.SimnetParams
+	params.BlockCoinbaseMaturity = 0
+	dag, teardownFunc, err := blockdag.DAGSetup("TestSelectTxsChildPaysForParent", true, blockdag.Config{
+		DAGParams: &params,
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup DAG instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Add two blocks, so that there are two coinbase outputs to spend.
+	var coinbaseTxs []*wire.MsgTx
+	parentHashes := []*daghash.Hash{params.GenesisHash}
+	for i := 0; i < 2; i++ {
+		msgBlock, err := PrepareBlockForTest(dag, &params, parentHashes, nil, false)
+		if err != nil {
+			t.Fatalf("PrepareBlockForTest: %v", err)
+		}
+		isOrphan, isDelayed, err := dag.ProcessBlock(util.NewBlock(msgBlock), blockdag.BFNoPoWCheck)
+		if err != nil {
+			t.Fatalf("ProcessBlock: %v", err)
+		}
+		if isOrphan || isDelayed {
+			t.Fatalf("ProcessBlock: block %d was unexpectedly orphaned or delayed", i)
+		}
+		coinbaseTxs = append(coinbaseTxs, msgBlock.Transactions[0])
+		parentHashes = []*daghash.Hash{msgBlock.BlockHash()}
+	}
+
+	signatureScript, err := txscript.PayToScriptHashSignatureScript(blockdag.OpTrueScript, nil)
+	if err != nil {
+		t.Fatalf("Failed to build signature script: %s", err)
+	}
+	createTx := func(coinbaseTx *wire.MsgTx) *util.Tx {
+		txIn := &wire.TxIn{
+			PreviousOutpoint: wire.Outpoint{TxID: *coinbaseTx.TxID(), Index: 0},
+			SignatureScript:  signatureScript,
+			Sequence:         wire.MaxTxInSequenceNum,
+		}
+		txOut := &wire.TxOut{
+			ScriptPubKey: blockdag.OpTrueScript,
+			Value:        uint64(1),
+		}
+		return util.NewTx(wire.NewNativeMsgTx(wire.TxVersion, []*wire.TxIn{txIn}, []*wire.TxOut{txOut}))
+	}
+	parentTx := createTx(coinbaseTxs[0])
+	otherTx := createTx(coinbaseTxs[1])
+	txMass, err := blockdag.CalcTxMassFromUTXOSet(parentTx, dag.UTXOSet())
+	if err != nil {
+		t.Fatalf("CalcTxMassFromUTXOSet: %s", err)
+	}
+
+	payToAddress, err := OpTrueAddress(params.Prefix)
+	if err != nil {
+		t.Fatalf("OpTrueAddress: %s", err)
+	}
+	policy := Policy{BlockMaxMass: 50000}
+	txSource := &fakeTxSource{}
+	generator := NewBlkTmplGenerator(&policy, &params, txSource, dag,
+		blockdag.NewTimeSource(), txscript.NewSigCache(100000))
+
+	// Leave room in the block for the coinbase and a single transaction
+	// only, so that the transactions compete with each other.
+	txsForBlockTemplate, err := generator.newTxsForBlockTemplate(payToAddress, 0)
+	if err != nil {
+		t.Fatalf("newTxsForBlockTemplate: %s", err)
+	}
+	policy.BlockMaxMass = txsForBlockTemplate.totalMass + txMass
+
+	tests := []struct {
+		name               string
+		parentPackageFee   uint64
+		parentPackageMass  uint64
+		expectedSelectedTx *util.Tx
+	}{
+		{
+			name:               "no package",
+			expectedSelectedTx: otherTx,
+		},
+		{
+			name:               "child pays for parent",
+			parentPackageFee:   1 + 100000,
+			parentPackageMass:  2 * txMass,
+			expectedSelectedTx: parentTx,
+		},
+	}
+
+	for _, test := range tests {
+		txSource.txDescs = []*TxDesc{
+			{
+				Tx:          parentTx,
+				Fee:         1,
+				Mass:        txMass,
+				PackageFee:  test.parentPackageFee,
+				PackageMass: test.parentPackageMass,
+			},
+			{
+				Tx:   otherTx,
+				Fee:  100,
+				Mass: txMass,
+			},
+		}
+
+		txsForBlockTemplate, err := generator.selectTxs(payToAddress, GenerateDeterministicExtraNonceForTest())
+		if err != nil {
+			t.Fatalf("%s: selectTxs: %s", test.name, err)
+		}
+		selectedTxs := txsForBlockTemplate.selectedTxs
+		if len(selectedTxs) != 2 {
+			t.Fatalf("%s: expected the coinbase and a single transaction "+
+				"to be selected, but got %d transactions", test.name, len(selectedTxs))
+		}
+		if !selectedTxs[1].ID().IsEqual(test.expectedSelectedTx.ID()) {
+			t.Errorf("%s: expected %s to be selected, but got %s", test.name,
+				test.expectedSelectedTx.ID(), selectedTxs[1].ID())
+		}
+	}
+}
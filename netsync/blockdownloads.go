@@ -0,0 +1,487 @@
+package netsync
+
+import (
+	"sort"
+	"time"
+
+	peerpkg "github.com/kaspanet/kaspad/peer"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+const (
+	// maxInFlightBlocksPerPeer is the maximum number of scheduled blocks
+	// that may be requested from a single peer at the same time.
+	maxInFlightBlocksPerPeer = wire.MaxSyncBlockInvPerGetDataMsg
+
+	// maxBlockDownloadWindow is the maximum number of scheduled blocks that
+	// may be either requested or received and waiting for their parents at
+	// the same time. It limits how far ahead of the oldest missing block
+	// the download can get, and with it the memory that's used by blocks
+	// that arrived before their parents.
+	maxBlockDownloadWindow = 1024
+
+	// blockDownloadTimeout is the time after which a scheduled block that
+	// wasn't received is requested from another peer.
+	blockDownloadTimeout = time.Minute
+
+	// blockDownloadCheckInterval is the interval in which scheduled blocks
+	// are checked for timeouts.
+	blockDownloadCheckInterval = 5 * time.Second
+)
+
+// scheduledBlock is a block whose download is managed by the
+// blockDownloadScheduler.
+type scheduledBlock struct {
+	hash  *daghash.Hash
+	order uint64
+
+	// peer is the peer the block is currently requested from, or nil if
+	// the block is not currently requested.
+	peer        *peerpkg.Peer
+	requestTime time.Time
+
+	// failedPeers are the peers that the block was requested from and
+	// that didn't deliver it.
+	failedPeers map[*peerpkg.Peer]struct{}
+
+	// received is the block message of the block if it was received
+	// before all of its scheduled parents were processed, and
+	// missingParents are the hashes of its scheduled parents that were
+	// not processed yet.
+	received       *blockMsg
+	missingParents map[daghash.Hash]struct{}
+}
+
+// blockDownloadScheduler spreads the download of the blocks that are known
+// to be missing during sync across all the sync candidates. It keeps the
+// blocks in the order they were scheduled in, which is expected to have
+// every block after its parents, and requests them in that order. Blocks
+// that arrive before their parents are held until the parents are
+// processed, so that the blocks are processed in order.
+//
+// The scheduler is not safe for concurrent access. It's meant to be used
+// only from the messageHandler goroutine of the SyncManager.
+type blockDownloadScheduler struct {
+	queue     []*scheduledBlock
+	blocks    map[daghash.Hash]*scheduledBlock
+	inFlight  map[*peerpkg.Peer]int
+	nextOrder uint64
+
+	// heldChildren maps the hash of a scheduled block to the held blocks
+	// that are waiting for it to be processed, and released are the held
+	// blocks whose scheduled parents were all processed, sorted by the
+	// order they were scheduled in.
+	heldChildren map[daghash.Hash][]*scheduledBlock
+	released     []*scheduledBlock
+}
+
+func newBlockDownloadScheduler() *blockDownloadScheduler {
+	return &blockDownloadScheduler{
+		blocks:       make(map[daghash.Hash]*scheduledBlock),
+		inFlight:     make(map[*peerpkg.Peer]int),
+		heldChildren: make(map[daghash.Hash][]*scheduledBlock),
+	}
+}
+
+// schedule adds the given block hashes, which should be sorted such that
+// every block comes after its parents, to the end of the download queue.
+// Hashes that are already scheduled are ignored.
+func (s *blockDownloadScheduler) schedule(hashes []*daghash.Hash) {
+	for _, hash := range hashes {
+		if _, ok := s.blocks[*hash]; ok {
+			continue
+		}
+		block := &scheduledBlock{
+			hash:        hash,
+			order:       s.nextOrder,
+			failedPeers: make(map[*peerpkg.Peer]struct{}),
+		}
+		s.nextOrder++
+		s.blocks[*hash] = block
+		s.queue = append(s.queue, block)
+	}
+}
+
+// isScheduled returns whether the block of the given hash is scheduled and
+// wasn't processed yet.
+func (s *blockDownloadScheduler) isScheduled(hash *daghash.Hash) bool {
+	_, ok := s.blocks[*hash]
+	return ok
+}
+
+// isEmpty returns whether there are no scheduled blocks left.
+func (s *blockDownloadScheduler) isEmpty() bool {
+	return len(s.blocks) == 0
+}
+
+// requestedFrom returns the peer that the scheduled block of the given hash
+// is currently requested from, or nil if it isn't.
+func (s *blockDownloadScheduler) requestedFrom(hash *daghash.Hash) *peerpkg.Peer {
+	block, ok := s.blocks[*hash]
+	if !ok {
+		return nil
+	}
+	return block.peer
+}
+
+// hasScheduledParents returns whether any of the parents of the given block
+// is scheduled and wasn't processed yet.
+func (s *blockDownloadScheduler) hasScheduledParents(block *util.Block) bool {
+	for _, parentHash := range block.MsgBlock().Header.ParentHashes {
+		if s.isScheduled(parentHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// markReceived marks the scheduled block of the given hash as received
+// from the given peer, and frees the request slot of that peer.
+//
+// The block might have been received from a peer whose download timed out.
+// If it was queued again meanwhile it's taken out of the queue, and if it's
+// already requested from another peer, the request slot of the other peer is
+// freed once that peer delivers the block too, or once the block is
+// processed.
+func (s *blockDownloadScheduler) markReceived(hash *daghash.Hash, peer *peerpkg.Peer) {
+	block, ok := s.blocks[*hash]
+	if !ok {
+		return
+	}
+	if block.peer == peer {
+		s.inFlight[peer]--
+		block.peer = nil
+		return
+	}
+	if block.peer == nil && block.received == nil {
+		s.removeFromQueue(block)
+	}
+}
+
+// hold keeps the given block message, whose block has scheduled parents,
+// until its parents are processed. It's released by popReleasedBlock once
+// they are.
+func (s *blockDownloadScheduler) hold(bmsg *blockMsg) {
+	block, ok := s.blocks[*bmsg.block.Hash()]
+	if !ok || block.received != nil {
+		return
+	}
+	block.received = bmsg
+	block.missingParents = make(map[daghash.Hash]struct{})
+	for _, parentHash := range bmsg.block.MsgBlock().Header.ParentHashes {
+		if !s.isScheduled(parentHash) {
+			continue
+		}
+		block.missingParents[*parentHash] = struct{}{}
+		s.heldChildren[*parentHash] = append(s.heldChildren[*parentHash], block)
+	}
+	if len(block.missingParents) == 0 {
+		s.release(block)
+	}
+}
+
+// release adds the given held block to the released blocks, keeping them
+// sorted by the order they were scheduled in.
+func (s *blockDownloadScheduler) release(block *scheduledBlock) {
+	i := sort.Search(len(s.released), func(i int) bool {
+		return s.released[i].order > block.order
+	})
+	s.released = append(s.released, nil)
+	copy(s.released[i+1:], s.released[i:])
+	s.released[i] = block
+}
+
+// remove removes the block of the given hash from the scheduler, either
+// because it was processed or because it's no longer needed. The held
+// blocks that were waiting only for it are released.
+func (s *blockDownloadScheduler) remove(hash *daghash.Hash) {
+	block, ok := s.blocks[*hash]
+	if !ok {
+		return
+	}
+	delete(s.blocks, *hash)
+	if block.peer != nil {
+		s.inFlight[block.peer]--
+	} else if block.received == nil {
+		s.removeFromQueue(block)
+	}
+
+	for _, child := range s.heldChildren[*hash] {
+		// The child might have been queued again since it was held.
+		if child.received == nil {
+			continue
+		}
+		if _, ok := child.missingParents[*hash]; !ok {
+			continue
+		}
+		delete(child.missingParents, *hash)
+		if len(child.missingParents) == 0 {
+			s.release(child)
+		}
+	}
+	delete(s.heldChildren, *hash)
+}
+
+func (s *blockDownloadScheduler) removeFromQueue(block *scheduledBlock) {
+	for i, queuedBlock := range s.queue {
+		if queuedBlock == block {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// popReleasedBlock removes and returns the earliest scheduled held block
+// message whose scheduled parents were all processed, or nil if there is
+// none.
+func (s *blockDownloadScheduler) popReleasedBlock() *blockMsg {
+	for len(s.released) > 0 {
+		block := s.released[0]
+		s.released[0] = nil
+		s.released = s.released[1:]
+
+		// The block might have been queued again, or even removed,
+		// since it was released.
+		if block.received == nil || s.blocks[*block.hash] != block {
+			continue
+		}
+		bmsg := block.received
+		s.remove(block.hash)
+		return bmsg
+	}
+	return nil
+}
+
+// requeue puts the given requested or held block back in the download
+// queue, in its original position.
+func (s *blockDownloadScheduler) requeue(block *scheduledBlock) {
+	if block.peer != nil {
+		s.inFlight[block.peer]--
+		block.peer = nil
+	}
+	block.received = nil
+	block.missingParents = nil
+	i := sort.Search(len(s.queue), func(i int) bool {
+		return s.queue[i].order > block.order
+	})
+	s.queue = append(s.queue, nil)
+	copy(s.queue[i+1:], s.queue[i:])
+	s.queue[i] = block
+}
+
+// markFailed requeues the scheduled block of the given hash if it's
+// currently requested from the given peer, and makes sure it won't be
+// requested from that peer again. A block that was already received from
+// another peer is only no longer considered requested from the given peer.
+func (s *blockDownloadScheduler) markFailed(hash *daghash.Hash, peer *peerpkg.Peer) {
+	block, ok := s.blocks[*hash]
+	if !ok || block.peer != peer {
+		return
+	}
+	block.failedPeers[peer] = struct{}{}
+	if block.received != nil {
+		s.inFlight[peer]--
+		block.peer = nil
+		return
+	}
+	s.requeue(block)
+}
+
+// timedOutBlocks returns the hashes and peers of the scheduled blocks that
+// were requested more than blockDownloadTimeout ago and were not received
+// yet.
+func (s *blockDownloadScheduler) timedOutBlocks(now time.Time) map[daghash.Hash]*peerpkg.Peer {
+	timedOut := make(map[daghash.Hash]*peerpkg.Peer)
+	for hash, block := range s.blocks {
+		if block.peer != nil && block.received == nil &&
+			now.Sub(block.requestTime) > blockDownloadTimeout {
+			timedOut[hash] = block.peer
+		}
+	}
+	return timedOut
+}
+
+// removePeer requeues all the blocks that are requested from the given
+// peer or that were received from it and are held. Blocks that are
+// requested from the peer but were already received from another peer
+// are kept.
+func (s *blockDownloadScheduler) removePeer(peer *peerpkg.Peer) {
+	for _, block := range s.blocks {
+		switch {
+		case block.received != nil && block.received.peer == peer:
+			s.requeue(block)
+		case block.peer == peer && block.received != nil:
+			block.peer = nil
+		case block.peer == peer:
+			s.requeue(block)
+		}
+		delete(block.failedPeers, peer)
+	}
+	delete(s.inFlight, peer)
+}
+
+// removeUndownloadable removes the queued blocks that failed to download
+// from all of the given candidates, and returns whether any block was
+// removed. Such blocks are found again in the next sync round.
+func (s *blockDownloadScheduler) removeUndownloadable(candidates []*peerpkg.Peer) bool {
+	var undownloadable []*daghash.Hash
+	for _, block := range s.queue {
+		isDownloadable := false
+		for _, candidate := range candidates {
+			if _, ok := block.failedPeers[candidate]; !ok {
+				isDownloadable = true
+				break
+			}
+		}
+		if !isDownloadable {
+			undownloadable = append(undownloadable, block.hash)
+		}
+	}
+	for _, hash := range undownloadable {
+		s.remove(hash)
+	}
+	return len(undownloadable) > 0
+}
+
+// assign picks the next queued blocks that may be requested from the given
+// peer, according to the per-peer and window limits, and marks them as
+// requested from it.
+func (s *blockDownloadScheduler) assign(peer *peerpkg.Peer, now time.Time) []*daghash.Hash {
+	maxBlocks := maxInFlightBlocksPerPeer - s.inFlight[peer]
+	windowSpace := maxBlockDownloadWindow - (len(s.blocks) - len(s.queue))
+	if windowSpace < maxBlocks {
+		maxBlocks = windowSpace
+	}
+
+	var hashes []*daghash.Hash
+	remaining := s.queue[:0]
+	for _, block := range s.queue {
+		if len(hashes) >= maxBlocks {
+			remaining = append(remaining, block)
+			continue
+		}
+		if _, ok := block.failedPeers[peer]; ok {
+			remaining = append(remaining, block)
+			continue
+		}
+		block.peer = peer
+		block.requestTime = now
+		s.inFlight[peer]++
+		hashes = append(hashes, block.hash)
+	}
+	s.queue = remaining
+	return hashes
+}
+
+// syncCandidates returns all the peers that are currently sync candidates.
+func (sm *SyncManager) syncCandidates() []*peerpkg.Peer {
+	candidates := make([]*peerpkg.Peer, 0, len(sm.peerStates))
+	for peer, state := range sm.peerStates {
+		if state.syncCandidate {
+			candidates = append(candidates, peer)
+		}
+	}
+	return candidates
+}
+
+// requestScheduledBlocks requests the next scheduled blocks from each of
+// the sync candidates, as far as their in-flight limits allow.
+func (sm *SyncManager) requestScheduledBlocks() {
+	candidates := sm.syncCandidates()
+	if sm.blockDownloads.removeUndownloadable(candidates) {
+		sm.processReleasedBlocks()
+	}
+
+	now := time.Now()
+	for _, peer := range candidates {
+		state := sm.peerStates[peer]
+		gdmsg := wire.NewMsgGetData()
+		for _, hash := range sm.blockDownloads.assign(peer, now) {
+			// The block might have become known since it was
+			// scheduled, for example if it was relayed by
+			// another peer.
+			if sm.dag.IsKnownBlock(hash) {
+				sm.blockDownloads.remove(hash)
+				continue
+			}
+			sm.requestedBlocks[*hash] = struct{}{}
+			sm.limitHashMap(sm.requestedBlocks, maxRequestedBlocks)
+			state.requestedBlocks[*hash] = struct{}{}
+			gdmsg.AddInvVect(wire.NewInvVect(wire.InvTypeSyncBlock, hash))
+		}
+		if len(gdmsg.InvList) > 0 {
+			peer.QueueMessage(gdmsg, nil)
+		}
+	}
+}
+
+// processReleasedBlocks processes the held blocks whose parents were all
+// processed, until there are no such blocks left.
+func (sm *SyncManager) processReleasedBlocks() {
+	for {
+		bmsg := sm.blockDownloads.popReleasedBlock()
+		if bmsg == nil {
+			return
+		}
+		sm.processBlockMsg(bmsg, sm.peerStates[bmsg.peer])
+	}
+}
+
+// handleBlockDownloadTimeouts requests the scheduled blocks whose download
+// timed out from other peers.
+func (sm *SyncManager) handleBlockDownloadTimeouts() {
+	timedOut := sm.blockDownloads.timedOutBlocks(time.Now())
+	if len(timedOut) == 0 {
+		return
+	}
+	for hash, peer := range timedOut {
+		log.Debugf("Download of block %s from %s timed out", hash, peer)
+
+		// The block is kept in the requested blocks of the peer, since
+		// the peer may still send it.
+		delete(sm.requestedBlocks, hash)
+		sm.blockDownloads.markFailed(&hash, peer)
+	}
+	sm.requestScheduledBlocks()
+}
+
+// handleNotFoundMsg handles notfound messages from all peers. The blocks
+// and transactions in it are no longer considered requested from the peer,
+// and scheduled blocks are requested from other peers.
+func (sm *SyncManager) handleNotFoundMsg(nfmsg *notFoundMsg) {
+	peer := nfmsg.peer
+	state, exists := sm.peerStates[peer]
+	if !exists {
+		log.Warnf("Received notfound message from unknown peer %s", peer)
+		return
+	}
+
+	for _, iv := range nfmsg.notFound.InvList {
+		switch iv.Type {
+		case wire.InvTypeSyncBlock, wire.InvTypeMissingAncestor, wire.InvTypeBlock,
+			wire.InvTypeCompactBlock:
+			if _, exists := state.requestedBlocks[*iv.Hash]; !exists {
+				continue
+			}
+			delete(state.requestedBlocks, *iv.Hash)
+			delete(state.pendingCompactBlocks, *iv.Hash)
+			if sm.blockDownloads.requestedFrom(iv.Hash) == peer {
+				delete(sm.requestedBlocks, *iv.Hash)
+				sm.blockDownloads.markFailed(iv.Hash, peer)
+			} else if !sm.blockDownloads.isScheduled(iv.Hash) {
+				delete(sm.requestedBlocks, *iv.Hash)
+			}
+
+		case wire.InvTypeTx:
+			txID := daghash.TxID(*iv.Hash)
+			if _, exists := state.requestedTxns[txID]; !exists {
+				continue
+			}
+			delete(state.requestedTxns, txID)
+			delete(sm.requestedTxns, txID)
+		}
+	}
+	sm.requestScheduledBlocks()
+}
@@ -0,0 +1,161 @@
+package netsync
+
+import (
+	"testing"
+	"time"
+
+	peerpkg "github.com/kaspanet/kaspad/peer"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// newBlockForScheduler returns a block with the given parents, whose hash is
+// made unique by the given nonce.
+func newBlockForScheduler(nonce uint64, parents ...*util.Block) *util.Block {
+	parentHashes := make([]*daghash.Hash, len(parents))
+	for i, parent := range parents {
+		parentHashes[i] = parent.Hash()
+	}
+	header := wire.NewBlockHeader(1, parentHashes, &daghash.ZeroHash,
+		&daghash.ZeroHash, &daghash.ZeroHash, 0, nonce)
+	return util.NewBlock(wire.NewMsgBlock(header))
+}
+
+func blockHashes(blocks ...*util.Block) []*daghash.Hash {
+	hashes := make([]*daghash.Hash, len(blocks))
+	for i, block := range blocks {
+		hashes[i] = block.Hash()
+	}
+	return hashes
+}
+
+func checkHashes(t *testing.T, testName string, hashes []*daghash.Hash, expectedHashes []*daghash.Hash) {
+	if len(hashes) != len(expectedHashes) {
+		t.Fatalf("%s: expected %d hashes, but got %d", testName, len(expectedHashes), len(hashes))
+	}
+	for i, hash := range hashes {
+		if !hash.IsEqual(expectedHashes[i]) {
+			t.Fatalf("%s: unexpected hash at index %d. Want: %s, got: %s",
+				testName, i, expectedHashes[i], hash)
+		}
+	}
+}
+
+func TestBlockDownloadTimeout(t *testing.T) {
+	peerA := peerpkg.NewInboundPeer(&peerpkg.Config{})
+	peerB := peerpkg.NewInboundPeer(&peerpkg.Config{})
+	blockA := newBlockForScheduler(0)
+	blockB := newBlockForScheduler(1)
+
+	s := newBlockDownloadScheduler()
+	s.schedule(blockHashes(blockA, blockB))
+	requestTime := time.Now()
+	checkHashes(t, "TestBlockDownloadTimeout", s.assign(peerA, requestTime), blockHashes(blockA, blockB))
+
+	timedOut := s.timedOutBlocks(requestTime.Add(blockDownloadTimeout / 2))
+	if len(timedOut) != 0 {
+		t.Fatalf("TestBlockDownloadTimeout: %d blocks unexpectedly timed out", len(timedOut))
+	}
+
+	// Only blockB times out, since blockA was received meanwhile
+	s.markReceived(blockA.Hash(), peerA)
+	timedOut = s.timedOutBlocks(requestTime.Add(blockDownloadTimeout + time.Second))
+	if len(timedOut) != 1 || timedOut[*blockB.Hash()] != peerA {
+		t.Fatalf("TestBlockDownloadTimeout: expected only %s to time out, "+
+			"but got %v", blockB.Hash(), timedOut)
+	}
+
+	s.markFailed(blockB.Hash(), peerA)
+	if s.inFlight[peerA] != 0 {
+		t.Fatalf("TestBlockDownloadTimeout: expected no blocks in flight "+
+			"from %s, but got %d", peerA, s.inFlight[peerA])
+	}
+	if len(s.assign(peerA, time.Now())) != 0 {
+		t.Fatalf("TestBlockDownloadTimeout: a block that timed out was " +
+			"unexpectedly assigned to the same peer again")
+	}
+	checkHashes(t, "TestBlockDownloadTimeout", s.assign(peerB, time.Now()), blockHashes(blockB))
+}
+
+func TestBlockDownloadReassignment(t *testing.T) {
+	peerA := peerpkg.NewInboundPeer(&peerpkg.Config{})
+	peerB := peerpkg.NewInboundPeer(&peerpkg.Config{})
+	parent := newBlockForScheduler(0)
+	child := newBlockForScheduler(1, parent)
+
+	s := newBlockDownloadScheduler()
+	s.schedule(blockHashes(parent, child))
+	checkHashes(t, "TestBlockDownloadReassignment", s.assign(peerA, time.Now()), blockHashes(parent, child))
+	s.markFailed(child.Hash(), peerA)
+	checkHashes(t, "TestBlockDownloadReassignment", s.assign(peerB, time.Now()), blockHashes(child))
+
+	// The child arrives from peerA, whose download timed out, while it's
+	// requested from peerB. The request slot of peerB is kept until peerB
+	// delivers the block too.
+	s.markReceived(child.Hash(), peerA)
+	s.hold(&blockMsg{block: child, peer: peerA})
+	if s.requestedFrom(child.Hash()) != peerB || s.inFlight[peerB] != 1 {
+		t.Fatalf("TestBlockDownloadReassignment: the block is unexpectedly " +
+			"no longer requested from the peer it was reassigned to")
+	}
+	if len(s.timedOutBlocks(time.Now().Add(2*blockDownloadTimeout))) != 1 {
+		t.Fatalf("TestBlockDownloadReassignment: expected only the parent " +
+			"to time out, since the child was already received")
+	}
+	s.markReceived(child.Hash(), peerB)
+	if s.inFlight[peerB] != 0 {
+		t.Fatalf("TestBlockDownloadReassignment: expected no blocks in flight "+
+			"from %s, but got %d", peerB, s.inFlight[peerB])
+	}
+
+	// Removing peerA requeues both the parent, which is requested from it,
+	// and the child, which was received from it and is held.
+	s.removePeer(peerA)
+	if s.popReleasedBlock() != nil {
+		t.Fatalf("TestBlockDownloadReassignment: a block that was held for " +
+			"a removed peer was unexpectedly released")
+	}
+	checkHashes(t, "TestBlockDownloadReassignment", s.assign(peerB, time.Now()), blockHashes(parent, child))
+}
+
+func TestBlockDownloadOrderedRelease(t *testing.T) {
+	peer := peerpkg.NewInboundPeer(&peerpkg.Config{})
+	parent := newBlockForScheduler(0)
+	childA := newBlockForScheduler(1, parent)
+	childB := newBlockForScheduler(2, parent)
+	grandchild := newBlockForScheduler(3, childA, childB)
+
+	s := newBlockDownloadScheduler()
+	s.schedule(blockHashes(parent, childA, childB, grandchild))
+	s.assign(peer, time.Now())
+
+	// The blocks arrive in reverse order, so all of them but the parent
+	// are held.
+	for _, block := range []*util.Block{grandchild, childB, childA} {
+		s.markReceived(block.Hash(), peer)
+		if !s.hasScheduledParents(block) {
+			t.Fatalf("TestBlockDownloadOrderedRelease: block %s unexpectedly "+
+				"has no scheduled parents", block.Hash())
+		}
+		s.hold(&blockMsg{block: block, peer: peer})
+	}
+	if s.popReleasedBlock() != nil {
+		t.Fatalf("TestBlockDownloadOrderedRelease: a block was released " +
+			"before its parents were processed")
+	}
+
+	// Processing the parent releases the held blocks in the order they
+	// were scheduled in, each one after all of its parents.
+	s.markReceived(parent.Hash(), peer)
+	s.remove(parent.Hash())
+	var released []*daghash.Hash
+	for bmsg := s.popReleasedBlock(); bmsg != nil; bmsg = s.popReleasedBlock() {
+		released = append(released, bmsg.block.Hash())
+	}
+	checkHashes(t, "TestBlockDownloadOrderedRelease", released, blockHashes(childA, childB, grandchild))
+	if !s.isEmpty() {
+		t.Fatalf("TestBlockDownloadOrderedRelease: the scheduler " +
+			"unexpectedly still has blocks")
+	}
+}
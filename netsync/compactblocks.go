@@ -0,0 +1,136 @@
+package netsync
+
+import (
+	"fmt"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	peerpkg "github.com/kaspanet/kaspad/peer"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// pendingCompactBlock is a compact block whose transactions weren't all
+// found in the mempool, and whose missing transactions were requested
+// with a getblocktxn message.
+type pendingCompactBlock struct {
+	header         wire.BlockHeader
+	txs            []*wire.MsgTx
+	missingIndexes []uint32
+}
+
+// shouldRequestCompactBlocks returns whether relayed blocks should be
+// requested from the given peer as compact blocks. Partial nodes don't
+// request compact blocks, since their mempools only have the transactions
+// of their own subnetwork.
+func (sm *SyncManager) shouldRequestCompactBlocks(peer *peerpkg.Peer) bool {
+	return peer.SupportsCompactBlocks() && sm.dag.SubnetworkID() == nil
+}
+
+// handleCmpctBlockMsg handles cmpctblock messages from all peers. The block
+// is reconstructed from the prefilled transactions and the transactions in
+// the mempool, and the transactions that can't be found are requested from
+// the peer.
+func (sm *SyncManager) handleCmpctBlockMsg(cmsg *cmpctBlockMsg) {
+	peer := cmsg.peer
+	state, exists := sm.peerStates[peer]
+	if !exists {
+		log.Warnf("Received cmpctblock message from unknown peer %s", peer)
+		return
+	}
+
+	// If we didn't ask for this block then the peer is misbehaving.
+	header := cmsg.cmpctBlock.Header
+	blockHash := header.BlockHash()
+	if _, exists := state.requestedBlocks[*blockHash]; !exists {
+		peer.AddBanScoreAndPushRejectMsg(wire.CmdCmpctBlock, wire.RejectNotRequested, nil,
+			peerpkg.BanScoreUnrequestedBlock, 0, fmt.Sprintf("got unrequested compact block %s", blockHash))
+		return
+	}
+
+	txs, missingIndexes := sm.txMemPool.FillCompactBlock(cmsg.cmpctBlock)
+	if len(missingIndexes) == 0 {
+		sm.handleReconstructedBlock(peer, &header, txs)
+		return
+	}
+
+	log.Debugf("Requesting %d of the %d transactions of compact block %s "+
+		"from %s", len(missingIndexes), len(txs), blockHash, peer)
+	state.pendingCompactBlocks[*blockHash] = &pendingCompactBlock{
+		header:         header,
+		txs:            txs,
+		missingIndexes: missingIndexes,
+	}
+	peer.QueueMessage(wire.NewMsgGetBlockTxn(blockHash, missingIndexes), nil)
+}
+
+// handleBlockTxnMsg handles blocktxn messages from all peers. The
+// transactions in it complete the pending compact block they were
+// requested for.
+func (sm *SyncManager) handleBlockTxnMsg(bmsg *blockTxnMsg) {
+	peer := bmsg.peer
+	state, exists := sm.peerStates[peer]
+	if !exists {
+		log.Warnf("Received blocktxn message from unknown peer %s", peer)
+		return
+	}
+
+	// If we didn't ask for these transactions then the peer is
+	// misbehaving.
+	blockHash := bmsg.blockTxn.BlockHash
+	pending, exists := state.pendingCompactBlocks[*blockHash]
+	if !exists {
+		peer.AddBanScoreAndPushRejectMsg(wire.CmdBlockTxn, wire.RejectNotRequested, nil,
+			peerpkg.BanScoreUnrequestedBlockTxn, 0,
+			fmt.Sprintf("got unrequested transactions of block %s", blockHash))
+		return
+	}
+	delete(state.pendingCompactBlocks, *blockHash)
+
+	txs := bmsg.blockTxn.Transactions
+	if len(txs) != len(pending.missingIndexes) {
+		peer.AddBanScoreAndPushRejectMsg(wire.CmdBlockTxn, wire.RejectInvalid, nil,
+			peerpkg.BanScoreInvalidCompactBlock, 0,
+			fmt.Sprintf("got %d transactions of block %s while %d were requested",
+				len(txs), blockHash, len(pending.missingIndexes)))
+		sm.requestFullBlock(peer, blockHash)
+		return
+	}
+	for i, index := range pending.missingIndexes {
+		pending.txs[index] = txs[i]
+	}
+	sm.handleReconstructedBlock(peer, &pending.header, pending.txs)
+}
+
+// handleReconstructedBlock handles a block that was reconstructed from a
+// compact block the same way as a block that was received in full. If the
+// transactions don't match the header, which may happen if a short ID
+// matched the wrong transaction, the full block is requested instead.
+func (sm *SyncManager) handleReconstructedBlock(peer *peerpkg.Peer,
+	header *wire.BlockHeader, txs []*wire.MsgTx) {
+
+	msgBlock := wire.NewMsgBlock(header)
+	for _, tx := range txs {
+		msgBlock.AddTransaction(tx)
+	}
+	block := util.NewBlock(msgBlock)
+
+	hashMerkleTree := blockdag.BuildHashMerkleTreeStore(block.Transactions())
+	if !header.HashMerkleRoot.IsEqual(hashMerkleTree.Root()) {
+		log.Debugf("Reconstructed compact block %s from %s doesn't match "+
+			"its hash merkle root. Requesting the full block", block.Hash(), peer)
+		sm.requestFullBlock(peer, block.Hash())
+		return
+	}
+
+	sm.handleBlockMsg(&blockMsg{block: block, peer: peer})
+}
+
+// requestFullBlock requests the block of the given hash in full from the
+// given peer, after it failed to be reconstructed from a compact block.
+// The block remains in the requested blocks of the peer.
+func (sm *SyncManager) requestFullBlock(peer *peerpkg.Peer, blockHash *daghash.Hash) {
+	gdmsg := wire.NewMsgGetData()
+	gdmsg.AddInvVect(wire.NewInvVect(wire.InvTypeBlock, blockHash))
+	peer.QueueMessage(gdmsg, nil)
+}
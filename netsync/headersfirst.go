@@ -0,0 +1,89 @@
+package netsync
+
+import (
+	"fmt"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	peerpkg "github.com/kaspanet/kaspad/peer"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+	"github.com/pkg/errors"
+)
+
+// ShouldSyncHeadersFirst returns whether the blocks that are missing from
+// the highest block shared with the given peer should be found by requesting
+// and validating their headers before their bodies are requested, rather
+// than by requesting their invs.
+//
+// Headers-first sync is used only during the initial block download, and
+// only with peers that support it.
+//
+// This function is safe for concurrent access.
+func (sm *SyncManager) ShouldSyncHeadersFirst(peer *peerpkg.Peer) bool {
+	return sm.headersFirst && peer.SupportsHeadersFirst() && !sm.isSynced()
+}
+
+// handleHeadersMsg handles headers messages from the sync peer. The headers
+// are validated as a batch, and if they're valid, the blocks that are still
+// missing are scheduled for download from all sync candidates in the order
+// of the headers, which makes sure that every block is processed after its
+// parents.
+func (sm *SyncManager) handleHeadersMsg(hmsg *headersMsg) {
+	peer := hmsg.peer
+	_, exists := sm.peerStates[peer]
+	if !exists {
+		log.Warnf("Received headers message from unknown peer %s", peer)
+		return
+	}
+
+	// If we didn't ask for these headers then the peer is misbehaving.
+	if !peer.WereHeadersRequested() {
+		peer.AddBanScoreAndPushRejectMsg(wire.CmdHeaders, wire.RejectNotRequested, nil,
+			peerpkg.BanScoreUnrequestedHeaders, 0, "got unrequested headers message")
+		return
+	}
+	peer.SetWereHeadersRequested(false)
+
+	headers := hmsg.headers.Headers
+	if len(headers) == 0 {
+		log.Debugf("Received an empty headers message from %s", peer)
+		return
+	}
+
+	err := sm.dag.ValidateHeaders(headers, blockdag.BFNone)
+	if err != nil {
+		if !errors.As(err, &blockdag.RuleError{}) {
+			panic(errors.Wrapf(err, "failed to validate headers from %s", peer))
+		}
+		log.Infof("Rejected headers from %s: %s", peer, err)
+
+		peer.AddBanScoreAndPushRejectMsg(wire.CmdHeaders, wire.RejectInvalid, nil,
+			peerpkg.BanScoreInvalidHeaders, 0, fmt.Sprintf("got invalid headers: %s", err))
+		sm.stopSyncFromPeer(peer)
+		return
+	}
+
+	missingHashes := make([]*daghash.Hash, 0, len(headers))
+	for _, header := range headers {
+		blockHash := header.BlockHash()
+		if !sm.dag.IsKnownBlock(blockHash) {
+			missingHashes = append(missingHashes, blockHash)
+		}
+	}
+	log.Debugf("Received %d valid headers from %s, of which %d belong to "+
+		"missing blocks", len(headers), peer, len(missingHashes))
+
+	// We already have all the blocks of these headers, so request the
+	// ones that come after them. This should only happen if our DAG and
+	// the peer's DAG have diverged long time ago.
+	if len(missingHashes) == 0 {
+		if peer == sm.syncPeer {
+			lastHash := headers[len(headers)-1].BlockHash()
+			peer.PushGetBlockLocatorMsg(lastHash, sm.dagParams.GenesisHash)
+		}
+		return
+	}
+
+	sm.blockDownloads.schedule(missingHashes)
+	sm.requestScheduledBlocks()
+}
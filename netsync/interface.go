@@ -30,4 +30,8 @@ type Config struct {
 	TxMemPool    *mempool.TxPool
 	DAGParams    *dagconfig.Params
 	MaxPeers     int
+
+	// HeadersFirst enables downloading and validating the headers of
+	// the blocks before their bodies during the initial block download.
+	HeadersFirst bool
 }
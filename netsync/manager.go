@@ -60,6 +60,37 @@ type invMsg struct {
 	peer *peerpkg.Peer
 }
 
+// headersMsg packages a kaspa headers message and the peer it came from
+// together so the block handler has access to that information.
+type headersMsg struct {
+	headers *wire.MsgHeaders
+	peer    *peerpkg.Peer
+	reply   chan struct{}
+}
+
+// cmpctBlockMsg packages a kaspa cmpctblock message and the peer it came
+// from together so the block handler has access to that information.
+type cmpctBlockMsg struct {
+	cmpctBlock *wire.MsgCmpctBlock
+	peer       *peerpkg.Peer
+	reply      chan struct{}
+}
+
+// blockTxnMsg packages a kaspa blocktxn message and the peer it came from
+// together so the block handler has access to that information.
+type blockTxnMsg struct {
+	blockTxn *wire.MsgBlockTxn
+	peer     *peerpkg.Peer
+	reply    chan struct{}
+}
+
+// notFoundMsg packages a kaspa notfound message and the peer it came from
+// together so the block handler has access to that information.
+type notFoundMsg struct {
+	notFound *wire.MsgNotFound
+	peer     *peerpkg.Peer
+}
+
 // donePeerMsg signifies a newly disconnected peer to the block handler.
 type donePeerMsg struct {
 	peer *peerpkg.Peer
@@ -139,6 +170,8 @@ type peerSyncState struct {
 	requestQueues             map[wire.InvType]*requestQueueAndSet
 	requestedTxns             map[daghash.TxID]struct{}
 	requestedBlocks           map[daghash.Hash]struct{}
+	requestCompactBlocks      bool
+	pendingCompactBlocks      map[daghash.Hash]*pendingCompactBlock
 }
 
 // SyncManager is used to communicate block related messages with peers. The
@@ -159,6 +192,7 @@ type SyncManager struct {
 	quit           chan struct{}
 	syncPeerLock   sync.Mutex
 	isSyncing      bool
+	headersFirst   bool
 
 	// These fields should only be accessed from the messageHandler thread
 	rejectedTxns    map[daghash.TxID]struct{}
@@ -166,6 +200,7 @@ type SyncManager struct {
 	requestedBlocks map[daghash.Hash]struct{}
 	syncPeer        *peerpkg.Peer
 	peerStates      map[*peerpkg.Peer]*peerSyncState
+	blockDownloads  *blockDownloadScheduler
 }
 
 // startSync will choose the sync peer among the available candidate peers to
@@ -273,9 +308,9 @@ func (sm *SyncManager) isSyncCandidate(peer *peerpkg.Peer) bool {
 		}
 	} else {
 		// The peer is not a candidate for sync if it's not a full
-		// node.
+		// node, or if it pruned blocks that we might need.
 		nodeServices := peer.Services()
-		if nodeServices&wire.SFNodeNetwork != wire.SFNodeNetwork {
+		if nodeServices&wire.SFNodeNetwork != wire.SFNodeNetwork || peer.IsPruned() {
 			return false
 		}
 	}
@@ -298,17 +333,19 @@ func (sm *SyncManager) handleNewPeerMsg(peer *peerpkg.Peer) {
 	// Initialize the peer state
 	isSyncCandidate := sm.isSyncCandidate(peer)
 	requestQueues := make(map[wire.InvType]*requestQueueAndSet)
-	requestQueueInvTypes := []wire.InvType{wire.InvTypeTx, wire.InvTypeBlock, wire.InvTypeSyncBlock, wire.InvTypeMissingAncestor}
+	requestQueueInvTypes := []wire.InvType{wire.InvTypeTx, wire.InvTypeBlock, wire.InvTypeMissingAncestor}
 	for _, invType := range requestQueueInvTypes {
 		requestQueues[invType] = &requestQueueAndSet{
 			set: make(map[daghash.Hash]struct{}),
 		}
 	}
 	sm.peerStates[peer] = &peerSyncState{
-		syncCandidate:   isSyncCandidate,
-		requestedTxns:   make(map[daghash.TxID]struct{}),
-		requestedBlocks: make(map[daghash.Hash]struct{}),
-		requestQueues:   requestQueues,
+		syncCandidate:        isSyncCandidate,
+		requestedTxns:        make(map[daghash.TxID]struct{}),
+		requestedBlocks:      make(map[daghash.Hash]struct{}),
+		requestQueues:        requestQueues,
+		requestCompactBlocks: sm.shouldRequestCompactBlocks(peer),
+		pendingCompactBlocks: make(map[daghash.Hash]*pendingCompactBlock),
 	}
 
 	// Start syncing by choosing the best candidate if needed.
@@ -347,6 +384,11 @@ func (sm *SyncManager) handleDonePeerMsg(peer *peerpkg.Peer) {
 		delete(sm.requestedBlocks, blockHash)
 	}
 
+	// Request the scheduled blocks that were requested from this peer
+	// from the other sync candidates.
+	sm.blockDownloads.removePeer(peer)
+	sm.requestScheduledBlocks()
+
 	sm.stopSyncFromPeer(peer)
 }
 
@@ -431,6 +473,9 @@ func (sm *SyncManager) handleTxMsg(tmsg *txMsg) {
 		return
 	}
 
+	if len(acceptedTxs) > 0 {
+		peer.UpdateLastTxTime()
+	}
 	sm.peerNotifier.AnnounceNewTransactions(acceptedTxs)
 }
 
@@ -461,8 +506,9 @@ func (sm *SyncManager) shouldReplaceSyncPeer() bool {
 	syncPeerState.requestQueueMtx.Lock()
 	defer syncPeerState.requestQueueMtx.Unlock()
 	return len(syncPeerState.requestedBlocks) == 0 &&
-		len(syncPeerState.requestQueues[wire.InvTypeSyncBlock].queue) == 0 &&
-		!sm.syncPeer.WasBlockLocatorRequested()
+		sm.blockDownloads.isEmpty() &&
+		!sm.syncPeer.WasBlockLocatorRequested() &&
+		!sm.syncPeer.WereHeadersRequested()
 }
 
 // handleBlockMsg handles block messages from all peers.
@@ -487,13 +533,48 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 				peerpkg.BanScoreUnrequestedBlock, 0, fmt.Sprintf("got unrequested block %s", blockHash))
 			return
 		}
+	} else if !sm.blockDownloads.isScheduled(blockHash) && sm.dag.IsKnownBlock(blockHash) {
+		// A block might be received more than once if its download
+		// timed out and it was requested from another peer as well.
+		log.Debugf("Ignoring requested block %s from %s that is already known",
+			blockHash, peer)
+		delete(state.requestedBlocks, *blockHash)
+		delete(sm.requestedBlocks, *blockHash)
+		return
+	}
+
+	if !sm.blockDownloads.isScheduled(blockHash) {
+		sm.processBlockMsg(bmsg, state)
+		return
+	}
+
+	// Blocks that are scheduled for download are processed in the order
+	// they were scheduled in, so a block that arrived before its parents
+	// is held until they are processed.
+	sm.blockDownloads.markReceived(blockHash, peer)
+	if sm.blockDownloads.hasScheduledParents(bmsg.block) {
+		sm.blockDownloads.hold(bmsg)
+		delete(state.requestedBlocks, *blockHash)
+		delete(sm.requestedBlocks, *blockHash)
+	} else {
+		sm.processBlockMsg(bmsg, state)
+		sm.processReleasedBlocks()
 	}
+	sm.requestScheduledBlocks()
+}
+
+// processBlockMsg processes a block that was received from a peer and
+// requests whatever the processing requires, such as the missing ancestors
+// of orphans.
+func (sm *SyncManager) processBlockMsg(bmsg *blockMsg, state *peerSyncState) {
+	peer := bmsg.peer
+	blockHash := bmsg.block.Hash()
 
 	behaviorFlags := blockdag.BFNone
 	if bmsg.isDelayedBlock {
 		behaviorFlags |= blockdag.BFAfterDelay
 	}
-	if bmsg.peer == sm.syncPeer {
+	if bmsg.peer == sm.syncPeer || sm.blockDownloads.isScheduled(blockHash) {
 		behaviorFlags |= blockdag.BFIsSync
 	}
 
@@ -505,6 +586,7 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 	// the insertion fails and thus we'll retry next time we get an inv.
 	delete(state.requestedBlocks, *blockHash)
 	delete(sm.requestedBlocks, *blockHash)
+	sm.blockDownloads.remove(blockHash)
 
 	sm.restartSyncIfNeeded()
 
@@ -566,6 +648,10 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 		}
 		sm.progressLogger.LogBlockBlueScore(bmsg.block, blockBlueScore)
 
+		// Keep track of the peer relaying useful blocks, so it's
+		// protected from being evicted.
+		peer.UpdateLastBlockTime()
+
 		// Clear the rejected transactions.
 		sm.rejectedTxns = make(map[daghash.TxID]struct{})
 	}
@@ -730,6 +816,13 @@ func (sm *SyncManager) handleInvMsg(imsg *invMsg) {
 				haveUnknownInvBlock = true
 			}
 
+			// Blocks that are sent as part of netsync are
+			// downloaded from all of the sync candidates.
+			if iv.Type == wire.InvTypeSyncBlock {
+				sm.blockDownloads.schedule([]*daghash.Hash{iv.Hash})
+				continue
+			}
+
 			// Add it to the request queue.
 			state.addInvToRequestQueue(iv)
 			continue
@@ -784,6 +877,7 @@ func (sm *SyncManager) handleInvMsg(imsg *invMsg) {
 		}
 	}
 
+	sm.requestScheduledBlocks()
 	err := sm.sendInvsFromRequestQueue(peer, state)
 	if err != nil {
 		log.Errorf("Failed to send invs from queue: %s", err)
@@ -843,9 +937,13 @@ func (sm *SyncManager) addInvsToGetDataMessageFromQueue(gdmsg *wire.MsgGetData,
 		switch invType {
 		case wire.InvTypeMissingAncestor:
 			addBlockInv(iv)
-		case wire.InvTypeSyncBlock:
-			addBlockInv(iv)
 		case wire.InvTypeBlock:
+			// Relayed blocks are requested as compact blocks when
+			// possible, since their transactions are most likely
+			// already in the mempool.
+			if state.requestCompactBlocks {
+				iv = wire.NewInvVect(wire.InvTypeCompactBlock, iv.Hash)
+			}
 			addBlockInv(iv)
 
 		case wire.InvTypeTx:
@@ -875,10 +973,6 @@ func (sm *SyncManager) sendInvsFromRequestQueue(peer *peerpkg.Peer, state *peerS
 		return nil
 	}
 	gdmsg := wire.NewMsgGetData()
-	err := sm.addInvsToGetDataMessageFromQueue(gdmsg, state, wire.InvTypeSyncBlock, wire.MaxSyncBlockInvPerGetDataMsg)
-	if err != nil {
-		return err
-	}
 	if !sm.isSyncing || sm.isSynced() {
 		err := sm.addInvsToGetDataMessageFromQueue(gdmsg, state, wire.InvTypeMissingAncestor, wire.MaxInvPerGetDataMsg)
 		if err != nil {
@@ -971,6 +1065,9 @@ func (sm *SyncManager) handleSelectedTipMsg(msg *selectedTipMsg) {
 // important because the sync manager controls which blocks are needed and how
 // the fetching should proceed.
 func (sm *SyncManager) messageHandler() {
+	blockDownloadTicker := time.NewTicker(blockDownloadCheckInterval)
+	defer blockDownloadTicker.Stop()
+
 out:
 	for {
 		select {
@@ -990,6 +1087,21 @@ out:
 			case *invMsg:
 				sm.handleInvMsg(msg)
 
+			case *headersMsg:
+				sm.handleHeadersMsg(msg)
+				msg.reply <- struct{}{}
+
+			case *cmpctBlockMsg:
+				sm.handleCmpctBlockMsg(msg)
+				msg.reply <- struct{}{}
+
+			case *blockTxnMsg:
+				sm.handleBlockTxnMsg(msg)
+				msg.reply <- struct{}{}
+
+			case *notFoundMsg:
+				sm.handleNotFoundMsg(msg)
+
 			case *donePeerMsg:
 				sm.handleDonePeerMsg(msg.peer)
 
@@ -1026,6 +1138,9 @@ out:
 					"handler: %T", msg)
 			}
 
+		case <-blockDownloadTicker.C:
+			sm.handleBlockDownloadTimeouts()
+
 		case <-sm.quit:
 			break out
 		}
@@ -1124,6 +1239,57 @@ func (sm *SyncManager) QueueInv(inv *wire.MsgInv, peer *peerpkg.Peer) {
 	sm.msgChan <- &invMsg{inv: inv, peer: peer}
 }
 
+// QueueHeaders adds the passed headers message and peer to the block handling
+// queue. Responds to the done channel argument after the headers message is
+// processed.
+func (sm *SyncManager) QueueHeaders(headers *wire.MsgHeaders, peer *peerpkg.Peer, done chan struct{}) {
+	// Don't accept more headers if we're shutting down.
+	if atomic.LoadInt32(&sm.shutdown) != 0 {
+		done <- struct{}{}
+		return
+	}
+
+	sm.msgChan <- &headersMsg{headers: headers, peer: peer, reply: done}
+}
+
+// QueueCmpctBlock adds the passed cmpctblock message and peer to the block
+// handling queue. Responds to the done channel argument after the message
+// is processed.
+func (sm *SyncManager) QueueCmpctBlock(cmpctBlock *wire.MsgCmpctBlock, peer *peerpkg.Peer, done chan struct{}) {
+	// Don't accept more blocks if we're shutting down.
+	if atomic.LoadInt32(&sm.shutdown) != 0 {
+		done <- struct{}{}
+		return
+	}
+
+	sm.msgChan <- &cmpctBlockMsg{cmpctBlock: cmpctBlock, peer: peer, reply: done}
+}
+
+// QueueBlockTxn adds the passed blocktxn message and peer to the block
+// handling queue. Responds to the done channel argument after the message
+// is processed.
+func (sm *SyncManager) QueueBlockTxn(blockTxn *wire.MsgBlockTxn, peer *peerpkg.Peer, done chan struct{}) {
+	// Don't accept more blocks if we're shutting down.
+	if atomic.LoadInt32(&sm.shutdown) != 0 {
+		done <- struct{}{}
+		return
+	}
+
+	sm.msgChan <- &blockTxnMsg{blockTxn: blockTxn, peer: peer, reply: done}
+}
+
+// QueueNotFound adds the passed notfound message and peer to the block
+// handling queue.
+func (sm *SyncManager) QueueNotFound(notFound *wire.MsgNotFound, peer *peerpkg.Peer) {
+	// No channel handling here because peers do not need to block on
+	// notfound messages.
+	if atomic.LoadInt32(&sm.shutdown) != 0 {
+		return
+	}
+
+	sm.msgChan <- &notFoundMsg{notFound: notFound, peer: peer}
+}
+
 // QueueSelectedTipMsg adds the passed selected tip message and peer to the
 // block handling queue. Responds to the done channel argument after it finished
 // handling the message.
@@ -1242,10 +1408,12 @@ func New(config *Config) (*SyncManager, error) {
 		dag:             config.DAG,
 		txMemPool:       config.TxMemPool,
 		dagParams:       config.DAGParams,
+		headersFirst:    config.HeadersFirst,
 		rejectedTxns:    make(map[daghash.TxID]struct{}),
 		requestedTxns:   make(map[daghash.TxID]struct{}),
 		requestedBlocks: make(map[daghash.Hash]struct{}),
 		peerStates:      make(map[*peerpkg.Peer]*peerSyncState),
+		blockDownloads:  newBlockDownloadScheduler(),
 		progressLogger:  newBlockProgressLogger("Processed", log),
 		msgChan:         make(chan interface{}, config.MaxPeers*3),
 		quit:            make(chan struct{}),
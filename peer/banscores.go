@@ -7,9 +7,13 @@ const (
 	BanScoreInvalidInvBlock            = 100
 	BanScoreOrphanInvAsPartOfNetsync   = 100
 	BanScoreMalformedBlueScoreInOrphan = 100
+	BanScoreInvalidHeaders             = 100
+	BanScoreInvalidCompactBlock        = 100
 
 	BanScoreUnrequestedSelectedTip = 20
 	BanScoreUnrequestedTx          = 20
+	BanScoreUnrequestedHeaders     = 20
+	BanScoreUnrequestedBlockTxn    = 20
 	BanScoreInvalidTx              = 100
 
 	BanScoreMalformedMessage = 10
@@ -25,6 +29,8 @@ const (
 	BanScoreNoFilterLoaded   = 5
 
 	BanScoreInvalidMsgGetBlockInvs = 10
+	BanScoreInvalidMsgGetHeaders   = 10
+	BanScoreInvalidMsgGetBlockTxn  = 10
 
 	BanScoreInvalidMsgBlockLocator = 100
 
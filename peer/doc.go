@@ -30,6 +30,8 @@ A quick overview of the major features peer provides are as follows:
    - Ability to register callbacks for handling kaspa protocol messages
  - Inventory message batching and send trickling with known inventory detection
    and avoidance
+ - Optional encrypted and authenticated transport with peer identities, which
+   falls back to plaintext for peers that don't support it
  - Automatic periodic keep-alive pinging and pong responses
  - Random nonce generation and self connection detection
  - Proper handling of bloom filter related commands when the caller does not
@@ -124,6 +126,20 @@ appropriate reject message based on the provided parameters as well as
 optionally provides a flag to cause it to block until the message is actually
 sent.
 
+Encrypted Transport
+
+The Encryption field of Config enables an encrypted transport. When it is
+enabled, the version message carries an ephemeral secp256k1 public key, and if
+both sides offer one, all the messages after the version messages are sent over
+a ChaCha20-Poly1305 session whose keys are derived from their ECDH shared
+secret and from both version messages, so tampering with any of their fields
+breaks the session. Peers that don't offer a key are disconnected if encryption is
+required, and use a plaintext transport otherwise.
+
+A peer may also identify itself with the IdentityKey field of Config, in which
+case it signs its ephemeral key with it. Remote peers can then be restricted to
+the identities in the AllowedIdentities field of Config.
+
 Peer Statistics
 
 A snapshot of the current peer statistics can be obtained with the StatsSnapshot
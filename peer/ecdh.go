@@ -0,0 +1,106 @@
+package peer
+
+// The ECDH key exchange of the encrypted transport uses the secp256k1 curve.
+// The ephemeral keys are generated by the secp256k1 library, whose
+// multiplication by the generator is constant time. The library doesn't
+// expose ECDH to Go, so the shared point is computed by calling the public C
+// API of libsecp256k1, which is compiled into the secp256k1 package, directly.
+// Its multiplication of an arbitrary point is not constant time, which is
+// acceptable since every ephemeral key is used for a single key exchange.
+
+// #include <stddef.h>
+//
+// typedef struct secp256k1_context_struct secp256k1_context;
+// typedef struct { unsigned char data[64]; } secp256k1_pubkey;
+//
+// #define SECP256K1_CONTEXT_VERIFY ((1 << 0) | (1 << 8))
+// #define SECP256K1_EC_COMPRESSED ((1 << 1) | (1 << 8))
+//
+// secp256k1_context* secp256k1_context_create(unsigned int flags);
+// int secp256k1_ec_pubkey_parse(const secp256k1_context* ctx, secp256k1_pubkey* pubkey,
+//     const unsigned char *input, size_t inputlen);
+// int secp256k1_ec_pubkey_serialize(const secp256k1_context* ctx, unsigned char *output,
+//     size_t *outputlen, const secp256k1_pubkey* pubkey, unsigned int flags);
+// int secp256k1_ec_pubkey_tweak_mul(const secp256k1_context* ctx, secp256k1_pubkey *pubkey,
+//     const unsigned char *tweak);
+import "C"
+
+import (
+	"sync"
+
+	"github.com/kaspanet/go-secp256k1"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ecdhPrivateKeySize is the size of a serialized ECDH private key.
+	ecdhPrivateKeySize = secp256k1.SerializedPrivateKeySize
+
+	// ecdhPublicKeySize is the size of a serialized compressed ECDH
+	// public key.
+	ecdhPublicKeySize = 33
+)
+
+var (
+	// ecdhContext is the libsecp256k1 context of the key exchange. It's
+	// created once it's first needed, since building it takes a while.
+	ecdhContext     *C.secp256k1_context
+	ecdhContextOnce sync.Once
+)
+
+// ecdhKeyPair is an ephemeral key pair for a single ECDH key exchange.
+type ecdhKeyPair struct {
+	privateKey *secp256k1.SerializedPrivateKey
+	publicKey  []byte
+}
+
+// generateECDHKeyPair generates a random ECDH key pair.
+func generateECDHKeyPair() (*ecdhKeyPair, error) {
+	privateKey, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := privateKey.SchnorrPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	serializedPublicKey, err := publicKey.SerializeCompressed()
+	if err != nil {
+		return nil, err
+	}
+	return &ecdhKeyPair{
+		privateKey: privateKey.Serialize(),
+		publicKey:  serializedPublicKey,
+	}, nil
+}
+
+// sharedSecret returns the x coordinate of the shared point of the key pair
+// and the given remote public key.
+func (kp *ecdhKeyPair) sharedSecret(remotePublicKey []byte) ([]byte, error) {
+	if len(remotePublicKey) != ecdhPublicKeySize {
+		return nil, errors.Errorf("invalid public key length %d", len(remotePublicKey))
+	}
+	ecdhContextOnce.Do(func() {
+		ecdhContext = C.secp256k1_context_create(C.SECP256K1_CONTEXT_VERIFY)
+	})
+
+	var point C.secp256k1_pubkey
+	ret := C.secp256k1_ec_pubkey_parse(ecdhContext, &point,
+		(*C.uchar)(&remotePublicKey[0]), C.size_t(len(remotePublicKey)))
+	if ret != 1 {
+		return nil, errors.New("public key is not on the curve")
+	}
+	ret = C.secp256k1_ec_pubkey_tweak_mul(ecdhContext, &point, (*C.uchar)(&kp.privateKey[0]))
+	if ret != 1 {
+		return nil, errors.New("failed to compute the shared point")
+	}
+
+	serializedPoint := make([]byte, ecdhPublicKeySize)
+	serializedPointLength := C.size_t(len(serializedPoint))
+	ret = C.secp256k1_ec_pubkey_serialize(ecdhContext, (*C.uchar)(&serializedPoint[0]),
+		&serializedPointLength, &point, C.SECP256K1_EC_COMPRESSED)
+	if ret != 1 || int(serializedPointLength) != ecdhPublicKeySize {
+		return nil, errors.New("failed to serialize the shared point")
+	}
+	return serializedPoint[1:], nil
+}
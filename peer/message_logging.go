@@ -106,6 +106,9 @@ func messageSummary(msg wire.Message) string {
 	case *wire.MsgAddr:
 		return fmt.Sprintf("%d addr", len(msg.AddrList))
 
+	case *wire.MsgAddrV2:
+		return fmt.Sprintf("%d addr", len(msg.AddrList))
+
 	case *wire.MsgPing:
 		// No summary - perhaps add nonce.
 
@@ -122,6 +125,18 @@ func messageSummary(msg wire.Message) string {
 		return fmt.Sprintf("hash %s, ver %d, %d tx, %s", msg.BlockHash(),
 			header.Version, len(msg.Transactions), header.Timestamp)
 
+	case *wire.MsgCmpctBlock:
+		header := &msg.Header
+		return fmt.Sprintf("hash %s, ver %d, %d tx, %d prefilled, %s",
+			header.BlockHash(), header.Version, msg.TxCount(),
+			len(msg.PrefilledTxs), header.Timestamp)
+
+	case *wire.MsgGetBlockTxn:
+		return fmt.Sprintf("hash %s, %d tx", msg.BlockHash, len(msg.Indexes))
+
+	case *wire.MsgBlockTxn:
+		return fmt.Sprintf("hash %s, %d tx", msg.BlockHash, len(msg.Transactions))
+
 	case *wire.MsgInv:
 		return invSummary(msg.InvList)
 
@@ -139,6 +154,13 @@ func messageSummary(msg wire.Message) string {
 		return fmt.Sprintf("high hash %s, low hash %s", msg.HighHash,
 			msg.LowHash)
 
+	case *wire.MsgGetHeaders:
+		return fmt.Sprintf("low hash %s, high hash %s", msg.LowHash,
+			msg.HighHash)
+
+	case *wire.MsgHeaders:
+		return fmt.Sprintf("num %d", len(msg.Headers))
+
 	case *wire.MsgBlockLocator:
 		if len(msg.BlockLocatorHashes) > 0 {
 			return fmt.Sprintf("locator first hash: %s, last hash: %s", msg.BlockLocatorHashes[0], msg.BlockLocatorHashes[len(msg.BlockLocatorHashes)-1])
@@ -23,6 +23,7 @@ import (
 
 	"github.com/btcsuite/go-socks/socks"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/kaspanet/go-secp256k1"
 	"github.com/kaspanet/kaspad/blockdag"
 	"github.com/kaspanet/kaspad/dagconfig"
 	"github.com/kaspanet/kaspad/logger"
@@ -106,6 +107,9 @@ type MessageListeners struct {
 	// OnAddr is invoked when a peer receives an addr kaspa message.
 	OnAddr func(p *Peer, msg *wire.MsgAddr)
 
+	// OnAddrV2 is invoked when a peer receives an addrv2 kaspa message.
+	OnAddrV2 func(p *Peer, msg *wire.MsgAddrV2)
+
 	// OnPing is invoked when a peer receives a ping kaspa message.
 	OnPing func(p *Peer, msg *wire.MsgPing)
 
@@ -173,6 +177,25 @@ type MessageListeners struct {
 	// message.
 	OnSelectedTip func(p *Peer, msg *wire.MsgSelectedTip)
 
+	// OnGetHeaders is invoked when a peer receives a getheaders kaspa
+	// message.
+	OnGetHeaders func(p *Peer, msg *wire.MsgGetHeaders)
+
+	// OnHeaders is invoked when a peer receives a headers kaspa message.
+	OnHeaders func(p *Peer, msg *wire.MsgHeaders)
+
+	// OnCmpctBlock is invoked when a peer receives a cmpctblock kaspa
+	// message.
+	OnCmpctBlock func(p *Peer, msg *wire.MsgCmpctBlock)
+
+	// OnGetBlockTxn is invoked when a peer receives a getblocktxn kaspa
+	// message.
+	OnGetBlockTxn func(p *Peer, msg *wire.MsgGetBlockTxn)
+
+	// OnBlockTxn is invoked when a peer receives a blocktxn kaspa
+	// message.
+	OnBlockTxn func(p *Peer, msg *wire.MsgBlockTxn)
+
 	// OnRead is invoked when a peer receives a kaspa message. It
 	// consists of the number of bytes read, the message, and whether or not
 	// an error in the read occurred. Typically, callers will opt to use
@@ -255,6 +278,22 @@ type Config struct {
 	// SubnetworkID specifies which subnetwork the peer is associated with.
 	// It is nil in full nodes.
 	SubnetworkID *subnetworkid.SubnetworkID
+
+	// Encryption specifies whether the transport to the remote peer is
+	// encrypted. This field can be omitted in which case the transport
+	// is plaintext.
+	Encryption EncryptionMode
+
+	// IdentityKey is the private key with which the local peer identifies
+	// itself over encrypted transports. It can be nil in which case the
+	// local peer has no identity.
+	IdentityKey *secp256k1.PrivateKey
+
+	// AllowedIdentities are the serialized public keys of the identities
+	// that remote peers are allowed to connect with. If it's not empty,
+	// remote peers that don't identify with one of them over an encrypted
+	// transport are disconnected.
+	AllowedIdentities [][]byte
 }
 
 // minUint32 is a helper function to return the minimum of two uint32s.
@@ -280,11 +319,14 @@ func newNetAddress(addr net.Addr, services wire.ServiceFlag) (*wire.NetAddress,
 
 	// addr will be a socks.ProxiedAddr when using a proxy.
 	if proxiedAddr, ok := addr.(*socks.ProxiedAddr); ok {
+		port := uint16(proxiedAddr.Port)
+		if overlay, err := wire.ParseOverlayAddress(proxiedAddr.Host); err == nil {
+			return wire.NewNetAddressOverlay(overlay, port, services), nil
+		}
 		ip := net.ParseIP(proxiedAddr.Host)
 		if ip == nil {
 			ip = net.ParseIP("0.0.0.0")
 		}
-		port := uint16(proxiedAddr.Port)
 		na := wire.NewNetAddressIPPort(ip, port, services)
 		return na, nil
 	}
@@ -357,6 +399,8 @@ type StatsSnap struct {
 	LastPingNonce   uint64
 	LastPingTime    time.Time
 	LastPingMicros  int64
+	IsEncrypted     bool
+	IdentityPubKey  []byte
 }
 
 // HostToNetAddrFunc is a func which takes a host, port, services and returns
@@ -401,6 +445,13 @@ type Peer struct {
 
 	conn net.Conn
 
+	// stream is the stream that messages are read from and written to. It
+	// is conn itself, unless the transport is encrypted. It's set before
+	// any message is exchanged, and again when the encryption starts,
+	// which is before any message other than the version messages is
+	// exchanged.
+	stream io.ReadWriter
+
 	// These fields are set at creation time and never modified, so they are
 	// safe to read from concurrently without a mutex.
 	addr    string
@@ -417,12 +468,20 @@ type Peer struct {
 	protocolVersion    uint32 // negotiated protocol version
 	verAckReceived     bool
 
+	encryptionKeyPair         *ecdhKeyPair // ephemeral key pair of the local peer
+	remoteEncryptionPublicKey []byte
+	localVersionPayload       []byte // handshake transcript of the encrypted transport
+	remoteVersionPayload      []byte
+	identityPublicKey         []byte
+	isEncrypted               bool
+
 	knownInventory       *mruInventoryMap
 	prevGetBlockInvsMtx  sync.Mutex
 	prevGetBlockInvsLow  *daghash.Hash
 	prevGetBlockInvsHigh *daghash.Hash
 
 	wasBlockLocatorRequested bool
+	wereHeadersRequested     bool
 
 	// These fields keep track of statistics for the peer and are protected
 	// by the statsMtx mutex.
@@ -433,6 +492,8 @@ type Peer struct {
 	lastPingNonce   uint64    // Set to nonce if we have a pending ping.
 	lastPingTime    time.Time // Time we sent last ping.
 	lastPingMicros  int64     // Time for last ping to return.
+	lastBlockTime   time.Time // Time we last accepted a new block from the peer.
+	lastTxTime      time.Time // Time we last accepted a new transaction from the peer.
 
 	stallControl  chan stallControlMsg
 	outputQueue   chan outMsg
@@ -459,6 +520,43 @@ func (p *Peer) SetWasBlockLocatorRequested(wasBlockLocatorRequested bool) {
 	p.wasBlockLocatorRequested = wasBlockLocatorRequested
 }
 
+// WereHeadersRequested returns whether the node
+// is expecting to get headers from this peer.
+func (p *Peer) WereHeadersRequested() bool {
+	return p.wereHeadersRequested
+}
+
+// SetWereHeadersRequested sets whether the node
+// is expecting to get headers from this peer.
+func (p *Peer) SetWereHeadersRequested(wereHeadersRequested bool) {
+	p.wereHeadersRequested = wereHeadersRequested
+}
+
+// SupportsHeadersFirst returns whether the negotiated protocol version
+// allows requesting headers from this peer.
+//
+// This function is safe for concurrent access.
+func (p *Peer) SupportsHeadersFirst() bool {
+	return p.ProtocolVersion() >= wire.HeadersFirstVersion
+}
+
+// SupportsCompactBlocks returns whether the negotiated protocol version
+// allows exchanging compact blocks with this peer.
+//
+// This function is safe for concurrent access.
+func (p *Peer) SupportsCompactBlocks() bool {
+	return p.ProtocolVersion() >= wire.CompactBlocksVersion
+}
+
+// SupportsAddrV2 returns whether the negotiated protocol version allows
+// exchanging addrv2 messages, and therefore overlay network addresses, with
+// this peer.
+//
+// This function is safe for concurrent access.
+func (p *Peer) SupportsAddrV2() bool {
+	return p.ProtocolVersion() >= wire.AddrV2Version
+}
+
 // String returns the peer's address and directionality as a human-readable
 // string.
 //
@@ -509,6 +607,10 @@ func (p *Peer) StatsSnapshot() *StatsSnap {
 		LastPingNonce:   p.lastPingNonce,
 		LastPingMicros:  p.lastPingMicros,
 		LastPingTime:    p.lastPingTime,
+		IsEncrypted:     p.isEncrypted,
+	}
+	if p.isEncrypted {
+		statsSnap.IdentityPubKey = p.identityPublicKey
 	}
 
 	return statsSnap
@@ -557,6 +659,14 @@ func (p *Peer) Services() wire.ServiceFlag {
 	return p.services
 }
 
+// IsPruned returns whether the remote peer prunes old blocks, and therefore
+// can't serve the entire DAG.
+//
+// This function is safe for concurrent access.
+func (p *Peer) IsPruned() bool {
+	return p.Services()&wire.SFNodeNetworkLimited == wire.SFNodeNetworkLimited
+}
+
 // UserAgent returns the user agent of the remote peer.
 //
 // This function is safe for concurrent access.
@@ -600,6 +710,46 @@ func (p *Peer) LastPingMicros() int64 {
 	return p.lastPingMicros
 }
 
+// LastBlockTime returns the last time a new block from the remote peer was
+// accepted to the DAG, or the zero time if none was.
+//
+// This function is safe for concurrent access.
+func (p *Peer) LastBlockTime() time.Time {
+	p.statsMtx.RLock()
+	defer p.statsMtx.RUnlock()
+	return p.lastBlockTime
+}
+
+// UpdateLastBlockTime records that a new block from the remote peer was just
+// accepted to the DAG.
+//
+// This function is safe for concurrent access.
+func (p *Peer) UpdateLastBlockTime() {
+	p.statsMtx.Lock()
+	defer p.statsMtx.Unlock()
+	p.lastBlockTime = time.Now()
+}
+
+// LastTxTime returns the last time a new transaction from the remote peer was
+// accepted to the mempool, or the zero time if none was.
+//
+// This function is safe for concurrent access.
+func (p *Peer) LastTxTime() time.Time {
+	p.statsMtx.RLock()
+	defer p.statsMtx.RUnlock()
+	return p.lastTxTime
+}
+
+// UpdateLastTxTime records that a new transaction from the remote peer was
+// just accepted to the mempool.
+//
+// This function is safe for concurrent access.
+func (p *Peer) UpdateLastTxTime() {
+	p.statsMtx.Lock()
+	defer p.statsMtx.Unlock()
+	p.lastTxTime = time.Now()
+}
+
 // VersionKnown returns the whether or not the version of a peer is known
 // locally.
 //
@@ -771,6 +921,12 @@ func (p *Peer) localVersionMsg() (*wire.MsgVersion, error) {
 	// Advertise if inv messages for transactions are desired.
 	msg.DisableRelayTx = p.cfg.DisableRelayTx
 
+	// Offer an encrypted transport if it's enabled.
+	err := p.addLocalTransportFields(msg)
+	if err != nil {
+		return nil, err
+	}
+
 	return msg, nil
 }
 
@@ -778,30 +934,46 @@ func (p *Peer) localVersionMsg() (*wire.MsgVersion, error) {
 // addresses. This function is useful over manually sending the message via
 // QueueMessage since it automatically limits the addresses to the maximum
 // number allowed by the message and randomizes the chosen addresses when there
-// are too many. It returns the addresses that were actually sent.
+// are too many. Peers that support it are sent an addrv2 message instead, and
+// overlay network addresses are only sent to such peers. It returns the
+// addresses that were actually sent.
 //
 // This function is safe for concurrent access.
 func (p *Peer) PushAddrMsg(addresses []*wire.NetAddress, subnetworkID *subnetworkid.SubnetworkID) ([]*wire.NetAddress, error) {
-	addressCount := len(addresses)
+	supportsAddrV2 := p.SupportsAddrV2()
 
-	msg := wire.NewMsgAddr(false, subnetworkID)
-	msg.AddrList = make([]*wire.NetAddress, addressCount)
-	copy(msg.AddrList, addresses)
+	addrList := make([]*wire.NetAddress, 0, len(addresses))
+	for _, na := range addresses {
+		// Overlay network addresses can't be encoded in addr messages.
+		if !supportsAddrV2 && na.Overlay != nil {
+			continue
+		}
+		addrList = append(addrList, na)
+	}
+	addressCount := len(addrList)
 
 	// Randomize the addresses sent if there are more than the maximum allowed.
 	if addressCount > wire.MaxAddrPerMsg {
 		// Shuffle the address list.
 		for i := 0; i < wire.MaxAddrPerMsg; i++ {
 			j := i + rand.Intn(addressCount-i)
-			msg.AddrList[i], msg.AddrList[j] = msg.AddrList[j], msg.AddrList[i]
+			addrList[i], addrList[j] = addrList[j], addrList[i]
 		}
 
 		// Truncate it to the maximum size.
-		msg.AddrList = msg.AddrList[:wire.MaxAddrPerMsg]
+		addrList = addrList[:wire.MaxAddrPerMsg]
 	}
 
-	p.QueueMessage(msg, nil)
-	return msg.AddrList, nil
+	if supportsAddrV2 {
+		msg := wire.NewMsgAddrV2(false, subnetworkID)
+		msg.AddrList = addrList
+		p.QueueMessage(msg, nil)
+	} else {
+		msg := wire.NewMsgAddr(false, subnetworkID)
+		msg.AddrList = addrList
+		p.QueueMessage(msg, nil)
+	}
+	return addrList, nil
 }
 
 // PushGetBlockLocatorMsg sends a getlocator message for the provided high
@@ -814,6 +986,16 @@ func (p *Peer) PushGetBlockLocatorMsg(highHash, lowHash *daghash.Hash) {
 	p.QueueMessage(msg, nil)
 }
 
+// PushGetHeadersMsg sends a getheaders message for the headers of the blocks
+// between the provided low and high hash.
+//
+// This function is safe for concurrent access.
+func (p *Peer) PushGetHeadersMsg(lowHash, highHash *daghash.Hash) {
+	p.SetWereHeadersRequested(true)
+	msg := wire.NewMsgGetHeaders(lowHash, highHash)
+	p.QueueMessage(msg, nil)
+}
+
 func (p *Peer) isDuplicateGetBlockInvsMsg(lowHash, highHash *daghash.Hash) bool {
 	p.prevGetBlockInvsMtx.Lock()
 	defer p.prevGetBlockInvsMtx.Unlock()
@@ -930,6 +1112,12 @@ func (p *Peer) handleRemoteVersionMsg(msg *wire.MsgVersion) error {
 		return errors.New("incompatible subnetworks")
 	}
 
+	// Disconnect if the remote peer doesn't meet the encryption and
+	// identity requirements.
+	if err := p.handleRemoteTransportFields(msg); err != nil {
+		return err
+	}
+
 	p.updateStatsFromVersionMsg(msg)
 	p.updateFlagsFromVersionMsg(msg)
 
@@ -997,7 +1185,7 @@ func (p *Peer) handlePongMsg(msg *wire.MsgPong) {
 
 // readMessage reads the next kaspa message from the peer with logging.
 func (p *Peer) readMessage() (wire.Message, []byte, error) {
-	n, msg, buf, err := wire.ReadMessageN(p.conn,
+	n, msg, buf, err := wire.ReadMessageN(p.stream,
 		p.ProtocolVersion(), p.cfg.DAGParams.Net)
 	atomic.AddUint64(&p.bytesReceived, uint64(n))
 	if p.cfg.Listeners.OnRead != nil {
@@ -1062,7 +1250,7 @@ func (p *Peer) writeMessage(msg wire.Message) error {
 	}))
 
 	// Write the message to the peer.
-	n, err := wire.WriteMessageN(p.conn, msg,
+	n, err := wire.WriteMessageN(p.stream, msg,
 		p.ProtocolVersion(), p.cfg.DAGParams.Net)
 	atomic.AddUint64(&p.bytesSent, uint64(n))
 	if p.cfg.Listeners.OnWrite != nil {
@@ -1143,8 +1331,10 @@ func (p *Peer) maybeAddDeadline(pendingResponses map[string]time.Time, msgCmd st
 		pendingResponses[wire.CmdInv] = deadline
 
 	case wire.CmdGetData:
-		// Expects a block, merkleblock, tx, or notfound message.
+		// Expects a block, cmpctblock, merkleblock, tx, or notfound
+		// message.
 		pendingResponses[wire.CmdBlock] = deadline
+		pendingResponses[wire.CmdCmpctBlock] = deadline
 		pendingResponses[wire.CmdMerkleBlock] = deadline
 		pendingResponses[wire.CmdTx] = deadline
 		pendingResponses[wire.CmdNotFound] = deadline
@@ -1152,6 +1342,14 @@ func (p *Peer) maybeAddDeadline(pendingResponses map[string]time.Time, msgCmd st
 	case wire.CmdGetSelectedTip:
 		// Expects a selected tip message.
 		pendingResponses[wire.CmdSelectedTip] = deadline
+
+	case wire.CmdGetHeaders:
+		// Expects a headers message.
+		pendingResponses[wire.CmdHeaders] = deadline
+
+	case wire.CmdGetBlockTxn:
+		// Expects a blocktxn message.
+		pendingResponses[wire.CmdBlockTxn] = deadline
 	}
 }
 
@@ -1199,12 +1397,15 @@ out:
 				switch msgCmd := msg.message.Command(); msgCmd {
 				case wire.CmdBlock:
 					fallthrough
+				case wire.CmdCmpctBlock:
+					fallthrough
 				case wire.CmdMerkleBlock:
 					fallthrough
 				case wire.CmdTx:
 					fallthrough
 				case wire.CmdNotFound:
 					delete(pendingResponses, wire.CmdBlock)
+					delete(pendingResponses, wire.CmdCmpctBlock)
 					delete(pendingResponses, wire.CmdMerkleBlock)
 					delete(pendingResponses, wire.CmdTx)
 					delete(pendingResponses, wire.CmdNotFound)
@@ -1385,6 +1586,11 @@ out:
 				p.cfg.Listeners.OnAddr(p, msg)
 			}
 
+		case *wire.MsgAddrV2:
+			if p.cfg.Listeners.OnAddrV2 != nil {
+				p.cfg.Listeners.OnAddrV2(p, msg)
+			}
+
 		case *wire.MsgPing:
 			p.handlePingMsg(msg)
 			if p.cfg.Listeners.OnPing != nil {
@@ -1477,6 +1683,31 @@ out:
 				p.cfg.Listeners.OnSelectedTip(p, msg)
 			}
 
+		case *wire.MsgGetHeaders:
+			if p.cfg.Listeners.OnGetHeaders != nil {
+				p.cfg.Listeners.OnGetHeaders(p, msg)
+			}
+
+		case *wire.MsgHeaders:
+			if p.cfg.Listeners.OnHeaders != nil {
+				p.cfg.Listeners.OnHeaders(p, msg)
+			}
+
+		case *wire.MsgCmpctBlock:
+			if p.cfg.Listeners.OnCmpctBlock != nil {
+				p.cfg.Listeners.OnCmpctBlock(p, msg)
+			}
+
+		case *wire.MsgGetBlockTxn:
+			if p.cfg.Listeners.OnGetBlockTxn != nil {
+				p.cfg.Listeners.OnGetBlockTxn(p, msg)
+			}
+
+		case *wire.MsgBlockTxn:
+			if p.cfg.Listeners.OnBlockTxn != nil {
+				p.cfg.Listeners.OnBlockTxn(p, msg)
+			}
+
 		default:
 			log.Debugf("Received unhandled message of type %s "+
 				"from %s", rmsg.Command(), p)
@@ -1780,6 +2011,7 @@ func (p *Peer) AssociateConnection(conn net.Conn) error {
 	}
 
 	p.conn = conn
+	p.stream = conn
 	p.timeConnected = time.Now()
 
 	if p.inbound {
@@ -1878,7 +2110,7 @@ func (p *Peer) WaitForDisconnect() {
 // acceptable then return an error.
 func (p *Peer) readRemoteVersionMsg() error {
 	// Read their version message.
-	msg, _, err := p.readMessage()
+	msg, buf, err := p.readMessage()
 	if err != nil {
 		return err
 	}
@@ -1898,6 +2130,7 @@ func (p *Peer) readRemoteVersionMsg() error {
 	if err := p.handleRemoteVersionMsg(remoteVerMsg); err != nil {
 		return err
 	}
+	p.setRemoteVersionPayload(buf)
 
 	if p.cfg.Listeners.OnVersion != nil {
 		p.cfg.Listeners.OnVersion(p, remoteVerMsg)
@@ -1911,30 +2144,44 @@ func (p *Peer) writeLocalVersionMsg() error {
 	if err != nil {
 		return err
 	}
+	err = p.setLocalVersionPayload(localVerMsg)
+	if err != nil {
+		return err
+	}
 
 	return p.writeMessage(localVerMsg)
 }
 
 // negotiateInboundProtocol waits to receive a version message from the peer
 // then sends our version message. If the events do not occur in that order then
-// it returns an error.
+// it returns an error. The transport is encrypted from then on if both sides
+// offered encryption.
 func (p *Peer) negotiateInboundProtocol() error {
 	if err := p.readRemoteVersionMsg(); err != nil {
 		return err
 	}
 
-	return p.writeLocalVersionMsg()
+	if err := p.writeLocalVersionMsg(); err != nil {
+		return err
+	}
+
+	return p.startEncryption()
 }
 
 // negotiateOutboundProtocol sends our version message then waits to receive a
 // version message from the peer. If the events do not occur in that order then
-// it returns an error.
+// it returns an error. The transport is encrypted from then on if both sides
+// offered encryption.
 func (p *Peer) negotiateOutboundProtocol() error {
 	if err := p.writeLocalVersionMsg(); err != nil {
 		return err
 	}
 
-	return p.readRemoteVersionMsg()
+	if err := p.readRemoteVersionMsg(); err != nil {
+		return err
+	}
+
+	return p.startEncryption()
 }
 
 // newPeerBase returns a new base kaspa peer based on the inbound flag. This
@@ -5,6 +5,7 @@
 package peer
 
 import (
+	"fmt"
 	"io"
 	"net"
 	"strconv"
@@ -348,6 +349,9 @@ func TestPeerListeners(t *testing.T) {
 			OnAddr: func(p *Peer, msg *wire.MsgAddr) {
 				ok <- msg
 			},
+			OnAddrV2: func(p *Peer, msg *wire.MsgAddrV2) {
+				ok <- msg
+			},
 			OnPing: func(p *Peer, msg *wire.MsgPing) {
 				ok <- msg
 			},
@@ -433,6 +437,10 @@ func TestPeerListeners(t *testing.T) {
 			"OnAddr",
 			wire.NewMsgAddr(false, nil),
 		},
+		{
+			"OnAddrV2",
+			wire.NewMsgAddrV2(false, nil),
+		},
 		{
 			"OnPing",
 			wire.NewMsgPing(42),
@@ -639,7 +647,8 @@ func TestUnsupportedVersionPeer(t *testing.T) {
 
 	go func() {
 		err := p.AssociateConnection(localConn)
-		wantErrorMessage := "protocol version must be 1 or greater"
+		wantErrorMessage := fmt.Sprintf("protocol version must be %d or greater",
+			minAcceptableProtocolVersion)
 		if err == nil {
 			t.Fatalf("No error from AssociateConnection to invalid protocol version")
 		}
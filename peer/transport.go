@@ -0,0 +1,462 @@
+package peer
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+
+	"github.com/kaspanet/go-secp256k1"
+	"github.com/kaspanet/kaspad/wire"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// EncryptionMode specifies whether the transport to remote peers is
+// encrypted.
+type EncryptionMode uint8
+
+const (
+	// EncryptionDisabled indicates that the transport is never encrypted.
+	EncryptionDisabled EncryptionMode = iota
+
+	// EncryptionPreferred indicates that the transport is encrypted if
+	// the remote peer supports it, and is plaintext otherwise.
+	EncryptionPreferred
+
+	// EncryptionRequired indicates that remote peers which don't support
+	// an encrypted transport are disconnected.
+	EncryptionRequired
+)
+
+// Map of encryption modes back to their names for pretty printing.
+var encryptionModeStrings = map[EncryptionMode]string{
+	EncryptionDisabled:  "disabled",
+	EncryptionPreferred: "preferred",
+	EncryptionRequired:  "required",
+}
+
+// String returns the EncryptionMode in human-readable form.
+func (mode EncryptionMode) String() string {
+	if s, ok := encryptionModeStrings[mode]; ok {
+		return s
+	}
+	return "unknown"
+}
+
+const (
+	// maxEncryptedFramePayload is the maximum number of plaintext bytes in
+	// a single frame of the encrypted transport. Messages that are larger
+	// are split across several frames.
+	maxEncryptedFramePayload = 1 << 16
+
+	// encryptedFrameHeaderSize is the size of the header of a frame of the
+	// encrypted transport, which holds the length of the ciphertext.
+	encryptedFrameHeaderSize = 4
+
+	// transportKeyDerivationInfo and identitySignaturePrefix separate the
+	// key derivation and the identity signatures of the encrypted
+	// transport from other uses of the same keys.
+	transportKeyDerivationInfo = "kaspad/p2p-transport/keys"
+	identitySignaturePrefix    = "kaspad/p2p-transport/identity"
+)
+
+// errUnauthenticatedFrame is returned when a frame of the encrypted
+// transport fails authentication, which means it was tampered with.
+var errUnauthenticatedFrame = errors.New("failed to authenticate encrypted frame")
+
+// encryptedStream is an io.ReadWriter which encrypts and authenticates all
+// the data that is written to the underlying stream, and decrypts all the
+// data that is read from it.
+//
+// Every write is split into frames of up to maxEncryptedFramePayload bytes.
+// A frame consists of the length of its ciphertext as a little-endian uint32,
+// followed by the ciphertext, which is sealed by a ChaCha20-Poly1305 AEAD with
+// the length as additional data. Every direction has its own key, and the
+// nonce of a frame is the number of frames that were sent before it in its
+// direction, so frames can't be dropped, replayed or reordered without
+// detection.
+//
+// Reading and writing may be done concurrently, but neither reads nor
+// writes may be done by several goroutines at once.
+type encryptedStream struct {
+	stream     io.ReadWriter
+	readAEAD   cipher.AEAD
+	writeAEAD  cipher.AEAD
+	readNonce  uint64
+	writeNonce uint64
+
+	// readBuf holds the decrypted bytes of the last frame that have not
+	// been read yet.
+	readBuf []byte
+}
+
+// newEncryptedStream returns an encryptedStream over the given stream whose
+// keys are derived from the given ECDH shared secret and the handshake
+// transcript, which consists of the payloads of the version messages of both
+// sides. The version messages carry the ephemeral public keys and the
+// identities, so tampering with any of their fields results in different keys
+// on both sides, and the first frame fails authentication.
+func newEncryptedStream(stream io.ReadWriter, sharedSecret []byte, net wire.KaspaNet,
+	initiatorVersionPayload, responderVersionPayload []byte, isInitiator bool) (*encryptedStream, error) {
+
+	info := make([]byte, 0, len(transportKeyDerivationInfo)+4+
+		4+len(initiatorVersionPayload)+4+len(responderVersionPayload))
+	info = append(info, transportKeyDerivationInfo...)
+	info = appendKaspaNet(info, net)
+	info = appendLengthPrefixed(info, initiatorVersionPayload)
+	info = appendLengthPrefixed(info, responderVersionPayload)
+	keyReader := hkdf.New(sha256.New, sharedSecret, nil, info)
+
+	initiatorKey := make([]byte, chacha20poly1305.KeySize)
+	responderKey := make([]byte, chacha20poly1305.KeySize)
+	for _, key := range [][]byte{initiatorKey, responderKey} {
+		_, err := io.ReadFull(keyReader, key)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	initiatorAEAD, err := chacha20poly1305.New(initiatorKey)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	responderAEAD, err := chacha20poly1305.New(responderKey)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	es := &encryptedStream{stream: stream}
+	if isInitiator {
+		es.writeAEAD, es.readAEAD = initiatorAEAD, responderAEAD
+	} else {
+		es.writeAEAD, es.readAEAD = responderAEAD, initiatorAEAD
+	}
+	return es, nil
+}
+
+// frameNonce returns the AEAD nonce of the frame of the given index.
+func frameNonce(index uint64) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.LittleEndian.PutUint64(nonce, index)
+	return nonce
+}
+
+// Read reads decrypted data from the stream.
+//
+// This is part of the io.Reader interface implementation.
+func (es *encryptedStream) Read(b []byte) (int, error) {
+	for len(es.readBuf) == 0 {
+		err := es.readFrame()
+		if err != nil {
+			return 0, err
+		}
+	}
+	n := copy(b, es.readBuf)
+	es.readBuf = es.readBuf[n:]
+	return n, nil
+}
+
+// readFrame reads the next frame from the stream and decrypts it into
+// readBuf.
+func (es *encryptedStream) readFrame() error {
+	header := make([]byte, encryptedFrameHeaderSize)
+	_, err := io.ReadFull(es.stream, header)
+	if err != nil {
+		return err
+	}
+	length := binary.LittleEndian.Uint32(header)
+	overhead := uint32(es.readAEAD.Overhead())
+	if length < overhead || length > maxEncryptedFramePayload+overhead {
+		return errors.Errorf("invalid encrypted frame length %d", length)
+	}
+
+	ciphertext := make([]byte, length)
+	_, err = io.ReadFull(es.stream, ciphertext)
+	if err != nil {
+		return err
+	}
+	plaintext, err := es.readAEAD.Open(ciphertext[:0], frameNonce(es.readNonce),
+		ciphertext, header)
+	if err != nil {
+		return errors.WithStack(errUnauthenticatedFrame)
+	}
+	es.readNonce++
+	es.readBuf = plaintext
+	return nil
+}
+
+// Write encrypts the given data and writes it to the stream.
+//
+// This is part of the io.Writer interface implementation.
+func (es *encryptedStream) Write(b []byte) (int, error) {
+	written := 0
+	for written < len(b) {
+		chunk := b[written:]
+		if len(chunk) > maxEncryptedFramePayload {
+			chunk = chunk[:maxEncryptedFramePayload]
+		}
+
+		frame := make([]byte, encryptedFrameHeaderSize,
+			encryptedFrameHeaderSize+len(chunk)+es.writeAEAD.Overhead())
+		binary.LittleEndian.PutUint32(frame, uint32(len(chunk)+es.writeAEAD.Overhead()))
+		frame = es.writeAEAD.Seal(frame, frameNonce(es.writeNonce), chunk,
+			frame[:encryptedFrameHeaderSize])
+		es.writeNonce++
+
+		_, err := es.stream.Write(frame)
+		if err != nil {
+			return written, err
+		}
+		written += len(chunk)
+	}
+	return written, nil
+}
+
+// appendKaspaNet appends the little-endian bytes of the given network to b.
+func appendKaspaNet(b []byte, net wire.KaspaNet) []byte {
+	netBytes := make([]byte, 4)
+	binary.LittleEndian.PutUint32(netBytes, uint32(net))
+	return append(b, netBytes...)
+}
+
+// appendLengthPrefixed appends the given data to b, prefixed by its length as
+// a little-endian uint32.
+func appendLengthPrefixed(b []byte, data []byte) []byte {
+	lengthBytes := make([]byte, 4)
+	binary.LittleEndian.PutUint32(lengthBytes, uint32(len(data)))
+	b = append(b, lengthBytes...)
+	return append(b, data...)
+}
+
+// versionPayload returns the payload of the given version message, as it's
+// sent to the remote peer.
+func (p *Peer) versionPayload(msg *wire.MsgVersion) ([]byte, error) {
+	var buf bytes.Buffer
+	err := msg.KaspaEncode(&buf, p.ProtocolVersion())
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// identitySignatureHash returns the hash that an identity key signs to bind
+// the given ephemeral encryption public key to the identity.
+func identitySignatureHash(net wire.KaspaNet, encryptionPublicKey []byte) *secp256k1.Hash {
+	data := make([]byte, 0, len(identitySignaturePrefix)+4+len(encryptionPublicKey))
+	data = append(data, identitySignaturePrefix...)
+	data = appendKaspaNet(data, net)
+	data = append(data, encryptionPublicKey...)
+	hash := secp256k1.Hash(sha256.Sum256(data))
+	return &hash
+}
+
+// signIdentity returns the serialized public key of the given identity key,
+// along with its signature of the given encryption public key.
+func signIdentity(identityKey *secp256k1.PrivateKey, net wire.KaspaNet,
+	encryptionPublicKey []byte) (publicKey []byte, signature []byte, err error) {
+
+	identityPublicKey, err := identityKey.SchnorrPublicKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	publicKey, err = identityPublicKey.SerializeCompressed()
+	if err != nil {
+		return nil, nil, err
+	}
+	identitySignature, err := identityKey.SchnorrSign(identitySignatureHash(net, encryptionPublicKey))
+	if err != nil {
+		return nil, nil, err
+	}
+	return publicKey, identitySignature.Serialize()[:], nil
+}
+
+// verifyIdentity verifies that the given identity signature is a valid
+// signature of the given encryption public key by the given identity public
+// key.
+func verifyIdentity(net wire.KaspaNet, encryptionPublicKey []byte,
+	identityPublicKey []byte, signature []byte) error {
+
+	publicKey, err := secp256k1.DeserializeSchnorrPubKey(identityPublicKey)
+	if err != nil {
+		return errors.Wrap(err, "invalid identity public key")
+	}
+	deserializedSignature, err := secp256k1.DeserializeSchnorrSignatureFromSlice(signature)
+	if err != nil {
+		return errors.Wrap(err, "invalid identity signature")
+	}
+	if !publicKey.SchnorrVerify(identitySignatureHash(net, encryptionPublicKey), deserializedSignature) {
+		return errors.New("identity signature verification failed")
+	}
+	return nil
+}
+
+// isIdentityAllowed returns whether the given identity public key is allowed
+// to connect. All identities, including none, are allowed if there is no
+// allowlist.
+func (p *Peer) isIdentityAllowed(identityPublicKey []byte) bool {
+	if len(p.cfg.AllowedIdentities) == 0 {
+		return true
+	}
+	for _, allowedIdentity := range p.cfg.AllowedIdentities {
+		if identityPublicKey != nil && bytes.Equal(allowedIdentity, identityPublicKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// addLocalTransportFields adds the ephemeral encryption public key and the
+// identity of the local peer to the given version message, if encryption is
+// enabled.
+func (p *Peer) addLocalTransportFields(msg *wire.MsgVersion) error {
+	if p.cfg.Encryption == EncryptionDisabled {
+		return nil
+	}
+
+	keyPair, err := generateECDHKeyPair()
+	if err != nil {
+		return err
+	}
+	p.flagsMtx.Lock()
+	p.encryptionKeyPair = keyPair
+	p.flagsMtx.Unlock()
+	msg.EncryptionPublicKey = keyPair.publicKey
+
+	if p.cfg.IdentityKey != nil {
+		msg.IdentityPublicKey, msg.IdentitySignature, err = signIdentity(p.cfg.IdentityKey,
+			p.cfg.DAGParams.Net, keyPair.publicKey)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setLocalVersionPayload keeps the payload of the local version message for
+// the handshake transcript of the encrypted transport.
+func (p *Peer) setLocalVersionPayload(msg *wire.MsgVersion) error {
+	if p.cfg.Encryption == EncryptionDisabled {
+		return nil
+	}
+	payload, err := p.versionPayload(msg)
+	if err != nil {
+		return err
+	}
+	p.flagsMtx.Lock()
+	defer p.flagsMtx.Unlock()
+	p.localVersionPayload = payload
+	return nil
+}
+
+// handleRemoteTransportFields checks the encryption public key and the
+// identity that the remote peer offered in its version message, and returns
+// an error if the remote peer may not connect with them.
+func (p *Peer) handleRemoteTransportFields(msg *wire.MsgVersion) error {
+	if p.cfg.Encryption == EncryptionDisabled {
+		return nil
+	}
+
+	if msg.EncryptionPublicKey == nil {
+		if p.cfg.Encryption == EncryptionRequired {
+			return errors.New("peer doesn't support an encrypted transport")
+		}
+		return nil
+	}
+	if len(msg.EncryptionPublicKey) != ecdhPublicKeySize {
+		return errors.Errorf("invalid encryption public key length %d",
+			len(msg.EncryptionPublicKey))
+	}
+
+	if msg.IdentityPublicKey != nil {
+		err := verifyIdentity(p.cfg.DAGParams.Net, msg.EncryptionPublicKey,
+			msg.IdentityPublicKey, msg.IdentitySignature)
+		if err != nil {
+			return err
+		}
+	}
+	if !p.isIdentityAllowed(msg.IdentityPublicKey) {
+		if msg.IdentityPublicKey == nil {
+			return errors.New("peer has no identity and only allowed identities may connect")
+		}
+		return errors.Errorf("peer identity %s is not allowed",
+			hex.EncodeToString(msg.IdentityPublicKey))
+	}
+
+	p.flagsMtx.Lock()
+	defer p.flagsMtx.Unlock()
+	p.remoteEncryptionPublicKey = msg.EncryptionPublicKey
+	p.identityPublicKey = msg.IdentityPublicKey
+	return nil
+}
+
+// setRemoteVersionPayload keeps the payload of the remote version message, as
+// it was received, for the handshake transcript of the encrypted transport.
+func (p *Peer) setRemoteVersionPayload(payload []byte) {
+	if p.cfg.Encryption == EncryptionDisabled {
+		return
+	}
+	p.flagsMtx.Lock()
+	defer p.flagsMtx.Unlock()
+	p.remoteVersionPayload = payload
+}
+
+// startEncryption switches the transport to the remote peer to an encrypted
+// one if both sides offered encryption in their version messages. It must be
+// called once the version messages were exchanged, and before any other
+// message is sent or received.
+func (p *Peer) startEncryption() error {
+	p.flagsMtx.Lock()
+	defer p.flagsMtx.Unlock()
+
+	if p.encryptionKeyPair == nil || p.remoteEncryptionPublicKey == nil {
+		return nil
+	}
+	sharedSecret, err := p.encryptionKeyPair.sharedSecret(p.remoteEncryptionPublicKey)
+	if err != nil {
+		return err
+	}
+
+	// The outbound side initiates the connection.
+	initiatorVersionPayload, responderVersionPayload := p.localVersionPayload, p.remoteVersionPayload
+	if p.inbound {
+		initiatorVersionPayload, responderVersionPayload = responderVersionPayload, initiatorVersionPayload
+	}
+	stream, err := newEncryptedStream(p.conn, sharedSecret, p.cfg.DAGParams.Net,
+		initiatorVersionPayload, responderVersionPayload, !p.inbound)
+	if err != nil {
+		return err
+	}
+	p.stream = stream
+	p.isEncrypted = true
+	p.encryptionKeyPair = nil
+	p.localVersionPayload = nil
+	p.remoteVersionPayload = nil
+	log.Debugf("Encrypted the transport to peer %s", p)
+	return nil
+}
+
+// IsEncrypted returns whether the transport to the remote peer is encrypted.
+//
+// This function is safe for concurrent access.
+func (p *Peer) IsEncrypted() bool {
+	p.flagsMtx.Lock()
+	defer p.flagsMtx.Unlock()
+	return p.isEncrypted
+}
+
+// IdentityPublicKey returns the serialized public key with which the remote
+// peer identified itself over the encrypted transport, or nil if it has no
+// identity.
+//
+// This function is safe for concurrent access.
+func (p *Peer) IdentityPublicKey() []byte {
+	p.flagsMtx.Lock()
+	defer p.flagsMtx.Unlock()
+	if !p.isEncrypted {
+		return nil
+	}
+	return p.identityPublicKey
+}
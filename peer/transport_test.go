@@ -0,0 +1,400 @@
+package peer
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kaspanet/go-secp256k1"
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/util/testtools"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// secp256k1N is the order of the group of the secp256k1 curve.
+var secp256k1N, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+
+// TestECDH ensures that both sides of an ECDH key exchange agree on the
+// shared secret, and that it's the same as the x coordinate of the product of
+// both private keys and the generator, as computed by the secp256k1 library.
+func TestECDH(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		keyPair1, err := generateECDHKeyPair()
+		if err != nil {
+			t.Fatalf("generateECDHKeyPair: %s", err)
+		}
+		keyPair2, err := generateECDHKeyPair()
+		if err != nil {
+			t.Fatalf("generateECDHKeyPair: %s", err)
+		}
+
+		secret1, err := keyPair1.sharedSecret(keyPair2.publicKey)
+		if err != nil {
+			t.Fatalf("sharedSecret: %s", err)
+		}
+		secret2, err := keyPair2.sharedSecret(keyPair1.publicKey)
+		if err != nil {
+			t.Fatalf("sharedSecret: %s", err)
+		}
+		if !bytes.Equal(secret1, secret2) {
+			t.Fatalf("TestECDH: shared secrets %x and %x are not equal", secret1, secret2)
+		}
+
+		product := new(big.Int).Mul(new(big.Int).SetBytes(keyPair1.privateKey[:]),
+			new(big.Int).SetBytes(keyPair2.privateKey[:]))
+		product.Mod(product, secp256k1N)
+		productBytes := make([]byte, ecdhPrivateKeySize)
+		product.FillBytes(productBytes)
+		privateKey, err := secp256k1.DeserializePrivateKeyFromSlice(productBytes)
+		if err != nil {
+			t.Fatalf("DeserializePrivateKeyFromSlice: %s", err)
+		}
+		publicKey, err := privateKey.SchnorrPublicKey()
+		if err != nil {
+			t.Fatalf("SchnorrPublicKey: %s", err)
+		}
+		sharedPoint, err := publicKey.SerializeCompressed()
+		if err != nil {
+			t.Fatalf("SerializeCompressed: %s", err)
+		}
+		if !bytes.Equal(secret1, sharedPoint[1:]) {
+			t.Fatalf("TestECDH: shared secret is %x, but the secp256k1 library computed %x",
+				secret1, sharedPoint[1:])
+		}
+	}
+
+	keyPair, err := generateECDHKeyPair()
+	if err != nil {
+		t.Fatalf("generateECDHKeyPair: %s", err)
+	}
+	invalidPublicKeys := [][]byte{
+		nil,
+		keyPair.publicKey[1:],
+		append([]byte{0x04}, keyPair.publicKey[1:]...),
+		append([]byte{0x02}, bytes.Repeat([]byte{0xff}, 32)...),
+	}
+	for _, publicKey := range invalidPublicKeys {
+		_, err := keyPair.sharedSecret(publicKey)
+		if err == nil {
+			t.Errorf("TestECDH: sharedSecret unexpectedly succeeded for public key %x", publicKey)
+		}
+	}
+}
+
+// newEncryptedStreamPair returns both sides of an encrypted stream over the
+// given buffer, whose keys are derived from the given version payloads of
+// each side.
+func newEncryptedStreamPair(t *testing.T, buf *bytes.Buffer,
+	initiatorVersionPayloads, responderVersionPayloads [2][]byte) (initiator, responder *encryptedStream) {
+
+	initiatorKeyPair, err := generateECDHKeyPair()
+	if err != nil {
+		t.Fatalf("generateECDHKeyPair: %s", err)
+	}
+	responderKeyPair, err := generateECDHKeyPair()
+	if err != nil {
+		t.Fatalf("generateECDHKeyPair: %s", err)
+	}
+	secret, err := initiatorKeyPair.sharedSecret(responderKeyPair.publicKey)
+	if err != nil {
+		t.Fatalf("sharedSecret: %s", err)
+	}
+
+	initiator, err = newEncryptedStream(buf, secret, wire.Mainnet,
+		initiatorVersionPayloads[0], initiatorVersionPayloads[1], true)
+	if err != nil {
+		t.Fatalf("newEncryptedStream: %s", err)
+	}
+	responder, err = newEncryptedStream(buf, secret, wire.Mainnet,
+		responderVersionPayloads[0], responderVersionPayloads[1], false)
+	if err != nil {
+		t.Fatalf("newEncryptedStream: %s", err)
+	}
+	return initiator, responder
+}
+
+// TestEncryptedStream ensures that data that is written to an encrypted stream
+// is read as is by the other side, and that tampered data is rejected.
+func TestEncryptedStream(t *testing.T) {
+	versionPayloads := [2][]byte{[]byte("initiator version"), []byte("responder version")}
+	var buf bytes.Buffer
+	initiator, responder := newEncryptedStreamPair(t, &buf, versionPayloads, versionPayloads)
+
+	// Write a message which spans several frames.
+	message := bytes.Repeat([]byte("kaspa"), maxEncryptedFramePayload/2)
+	_, err := initiator.Write(message)
+	if err != nil {
+		t.Fatalf("Write: %s", err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("kaspakaspa")) {
+		t.Fatalf("TestEncryptedStream: the stream contains the plaintext")
+	}
+	received := make([]byte, len(message))
+	_, err = io.ReadFull(responder, received)
+	if err != nil {
+		t.Fatalf("ReadFull: %s", err)
+	}
+	if !bytes.Equal(received, message) {
+		t.Fatalf("TestEncryptedStream: received data is not the same as the sent data")
+	}
+
+	// Every direction has its own key, so a frame can't be reflected back
+	// to the side that sent it.
+	_, err = initiator.Write(message[:10])
+	if err != nil {
+		t.Fatalf("Write: %s", err)
+	}
+	_, err = initiator.Read(received)
+	if err == nil {
+		t.Fatalf("TestEncryptedStream: a reflected frame was unexpectedly accepted")
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(frame []byte) []byte
+	}{
+		{
+			name: "flipped ciphertext bit",
+			tamper: func(frame []byte) []byte {
+				frame[encryptedFrameHeaderSize] ^= 1
+				return frame
+			},
+		},
+		{
+			name: "truncated frame",
+			tamper: func(frame []byte) []byte {
+				return frame[:len(frame)-1]
+			},
+		},
+		{
+			name: "invalid length",
+			tamper: func(frame []byte) []byte {
+				frame[encryptedFrameHeaderSize-1] = 0xff
+				return frame
+			},
+		},
+	}
+	for _, test := range tests {
+		buf.Reset()
+		initiator, responder := newEncryptedStreamPair(t, &buf, versionPayloads, versionPayloads)
+		_, err := initiator.Write([]byte("kaspa"))
+		if err != nil {
+			t.Fatalf("Write: %s", err)
+		}
+		frame := test.tamper(buf.Bytes())
+		buf.Reset()
+		buf.Write(frame)
+
+		_, err = io.ReadFull(responder, make([]byte, 5))
+		if err == nil {
+			t.Errorf("TestEncryptedStream: %s: tampered frame was unexpectedly accepted",
+				test.name)
+		}
+	}
+
+	// A frame that is replayed is rejected since its nonce was used.
+	buf.Reset()
+	initiator, responder = newEncryptedStreamPair(t, &buf, versionPayloads, versionPayloads)
+	_, err = initiator.Write([]byte("kaspa"))
+	if err != nil {
+		t.Fatalf("Write: %s", err)
+	}
+	frame := append([]byte{}, buf.Bytes()...)
+	buf.Write(frame)
+	_, err = io.ReadFull(responder, make([]byte, 5))
+	if err != nil {
+		t.Fatalf("ReadFull: %s", err)
+	}
+	_, err = io.ReadFull(responder, make([]byte, 5))
+	if err == nil {
+		t.Fatalf("TestEncryptedStream: replayed frame was unexpectedly accepted")
+	}
+
+	// A version message that was tampered with results in different keys
+	// on both sides, so the first frame is rejected.
+	tamperedVersionPayloads := [2][]byte{versionPayloads[0], []byte("tampered version")}
+	for _, responderVersionPayloads := range [][2][]byte{
+		tamperedVersionPayloads,
+		{versionPayloads[1], versionPayloads[0]},
+	} {
+		buf.Reset()
+		initiator, responder = newEncryptedStreamPair(t, &buf, versionPayloads, responderVersionPayloads)
+		_, err = initiator.Write([]byte("kaspa"))
+		if err != nil {
+			t.Fatalf("Write: %s", err)
+		}
+		_, err = io.ReadFull(responder, make([]byte, 5))
+		if err == nil {
+			t.Fatalf("TestEncryptedStream: a frame was unexpectedly accepted "+
+				"with the version payloads %q", responderVersionPayloads)
+		}
+	}
+}
+
+// TestEncryptedTransport ensures that the transport between peers is
+// encrypted only if both of them enable it, and that the encryption and
+// identity requirements are enforced.
+func TestEncryptedTransport(t *testing.T) {
+	inIdentityKey, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("GeneratePrivateKey: %s", err)
+	}
+	outIdentityKey, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("GeneratePrivateKey: %s", err)
+	}
+	outIdentityPublicKey, err := outIdentityKey.SchnorrPublicKey()
+	if err != nil {
+		t.Fatalf("SchnorrPublicKey: %s", err)
+	}
+	outIdentity, err := outIdentityPublicKey.SerializeCompressed()
+	if err != nil {
+		t.Fatalf("SerializeCompressed: %s", err)
+	}
+	otherIdentityKey, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("GeneratePrivateKey: %s", err)
+	}
+
+	tests := []struct {
+		name              string
+		inEncryption      EncryptionMode
+		outEncryption     EncryptionMode
+		outIdentityKey    *secp256k1.PrivateKey
+		allowedIdentities [][]byte
+		wantEncrypted     bool
+		wantErr           string
+	}{
+		{
+			name:          "both disabled",
+			inEncryption:  EncryptionDisabled,
+			outEncryption: EncryptionDisabled,
+			wantEncrypted: false,
+		},
+		{
+			name:          "fallback to plaintext",
+			inEncryption:  EncryptionPreferred,
+			outEncryption: EncryptionDisabled,
+			wantEncrypted: false,
+		},
+		{
+			name:          "both preferred",
+			inEncryption:  EncryptionPreferred,
+			outEncryption: EncryptionPreferred,
+			wantEncrypted: true,
+		},
+		{
+			name:           "identity",
+			inEncryption:   EncryptionRequired,
+			outEncryption:  EncryptionPreferred,
+			outIdentityKey: outIdentityKey,
+			wantEncrypted:  true,
+		},
+		{
+			name:              "allowed identity",
+			inEncryption:      EncryptionRequired,
+			outEncryption:     EncryptionRequired,
+			outIdentityKey:    outIdentityKey,
+			allowedIdentities: [][]byte{outIdentity},
+			wantEncrypted:     true,
+		},
+		{
+			name:          "required but not supported",
+			inEncryption:  EncryptionRequired,
+			outEncryption: EncryptionDisabled,
+			wantErr:       "doesn't support an encrypted transport",
+		},
+		{
+			name:              "no identity",
+			inEncryption:      EncryptionRequired,
+			outEncryption:     EncryptionPreferred,
+			allowedIdentities: [][]byte{outIdentity},
+			wantErr:           "peer has no identity",
+		},
+		{
+			name:              "identity not allowed",
+			inEncryption:      EncryptionRequired,
+			outEncryption:     EncryptionPreferred,
+			outIdentityKey:    otherIdentityKey,
+			allowedIdentities: [][]byte{outIdentity},
+			wantErr:           "is not allowed",
+		},
+	}
+
+	for _, test := range tests {
+		inPeerVerack, outPeerVerack := make(chan struct{}, 1), make(chan struct{}, 1)
+		inPeerCfg := &Config{
+			Listeners: MessageListeners{
+				OnVerAck: func(p *Peer, msg *wire.MsgVerAck) {
+					inPeerVerack <- struct{}{}
+				},
+			},
+			DAGParams:         &dagconfig.MainnetParams,
+			SelectedTipHash:   fakeSelectedTipFn,
+			Encryption:        test.inEncryption,
+			IdentityKey:       inIdentityKey,
+			AllowedIdentities: test.allowedIdentities,
+		}
+		outPeerCfg := &Config{
+			Listeners: MessageListeners{
+				OnVerAck: func(p *Peer, msg *wire.MsgVerAck) {
+					outPeerVerack <- struct{}{}
+				},
+			},
+			DAGParams:       &dagconfig.MainnetParams,
+			SelectedTipHash: fakeSelectedTipFn,
+			Encryption:      test.outEncryption,
+			IdentityKey:     test.outIdentityKey,
+		}
+
+		inPeer, outPeer, err := setupPeers(inPeerCfg, outPeerCfg)
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("TestEncryptedTransport: %s: expected error containing %q, but got %v",
+					test.name, test.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("TestEncryptedTransport: %s: unexpected error %s", test.name, err)
+			continue
+		}
+
+		// The veracks are the first messages that are sent over the
+		// encrypted transport.
+		if !testtools.WaitTillAllCompleteOrTimeout(time.Second, inPeerVerack, outPeerVerack) {
+			t.Errorf("TestEncryptedTransport: %s: handshake timeout", test.name)
+		}
+		for _, p := range []*Peer{inPeer, outPeer} {
+			if p.IsEncrypted() != test.wantEncrypted {
+				t.Errorf("TestEncryptedTransport: %s: IsEncrypted is %t for %s, but expected %t",
+					test.name, p.IsEncrypted(), p, test.wantEncrypted)
+			}
+			if p.StatsSnapshot().IsEncrypted != test.wantEncrypted {
+				t.Errorf("TestEncryptedTransport: %s: unexpected IsEncrypted in the stats of %s",
+					test.name, p)
+			}
+		}
+		if test.wantEncrypted {
+			var wantInIdentity []byte
+			if test.outIdentityKey != nil {
+				wantInIdentity = outIdentity
+			}
+			if !bytes.Equal(inPeer.IdentityPublicKey(), wantInIdentity) {
+				t.Errorf("TestEncryptedTransport: %s: the identity of the outbound peer is %x, but expected %x",
+					test.name, inPeer.IdentityPublicKey(), wantInIdentity)
+			}
+			if outPeer.IdentityPublicKey() == nil {
+				t.Errorf("TestEncryptedTransport: %s: the inbound peer has no identity", test.name)
+			}
+		}
+
+		inPeer.Disconnect()
+		outPeer.Disconnect()
+		inPeer.WaitForDisconnect()
+		outPeer.WaitForDisconnect()
+	}
+}
@@ -291,6 +291,78 @@ func (c *Client) GetChainFromBlock(includeBlocks bool, startHash *string) (*rpcm
 	return c.GetChainFromBlockAsync(includeBlocks, startHash).Receive()
 }
 
+// FutureGetDAGGraphResult is a future promise to deliver the result of a
+// GetDAGGraphAsync RPC invocation (or an applicable error).
+type FutureGetDAGGraphResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// exported window of the DAG.
+func (r FutureGetDAGGraphResult) Receive() (*rpcmodel.GetDAGGraphResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result rpcmodel.GetDAGGraphResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, errors.Wrap(err, "couldn't decode getDagGraph response")
+	}
+	return &result, nil
+}
+
+// GetDAGGraphAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetDAGGraph for the blocking version and more details.
+func (c *Client) GetDAGGraphAsync(levels uint64, hash *string) FutureGetDAGGraphResult {
+	cmd := rpcmodel.NewGetDAGGraphCmd(pointers.String("json"), &levels, hash)
+	return c.sendCmd(cmd)
+}
+
+// GetDAGGraph returns the given number of blue score levels of the DAG, along
+// with the GHOSTDAG coloring and the reachability intervals of their blocks.
+// If hash is nil, these are the last levels of the DAG. Otherwise, they're the
+// levels around the block of the given hash, excluding its future.
+func (c *Client) GetDAGGraph(levels uint64, hash *string) (*rpcmodel.GetDAGGraphResult, error) {
+	return c.GetDAGGraphAsync(levels, hash).Receive()
+}
+
+// FutureGetDAGGraphFormattedResult is a future promise to deliver the result
+// of a GetDAGGraphFormattedAsync RPC invocation (or an applicable error).
+type FutureGetDAGGraphFormattedResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// exported window of the DAG in the requested format.
+func (r FutureGetDAGGraphFormattedResult) Receive() (string, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return "", err
+	}
+
+	var graph string
+	if err := json.Unmarshal(res, &graph); err != nil {
+		return "", errors.Wrap(err, "couldn't decode getDagGraph response")
+	}
+	return graph, nil
+}
+
+// GetDAGGraphFormattedAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetDAGGraphFormatted for the blocking version and more details.
+func (c *Client) GetDAGGraphFormattedAsync(format string, levels uint64, hash *string) FutureGetDAGGraphFormattedResult {
+	cmd := rpcmodel.NewGetDAGGraphCmd(&format, &levels, hash)
+	return c.sendCmd(cmd)
+}
+
+// GetDAGGraphFormatted is like GetDAGGraph, except that it returns the graph
+// in the given format, which is either dot or graphml.
+func (c *Client) GetDAGGraphFormatted(format string, levels uint64, hash *string) (string, error) {
+	return c.GetDAGGraphFormattedAsync(format, levels, hash).Receive()
+}
+
 // FutureGetDifficultyResult is a future promise to deliver the result of a
 // GetDifficultyAsync RPC invocation (or an applicable error).
 type FutureGetDifficultyResult chan *response
@@ -526,6 +598,71 @@ func (c *Client) GetMempoolEntry(txHash string) (*rpcmodel.GetMempoolEntryResult
 	return c.GetMempoolEntryAsync(txHash).Receive()
 }
 
+// FutureRemoveMempoolTransactionResult is a future promise to deliver the
+// result of a RemoveMempoolTransactionAsync or ClearMempoolAsync RPC
+// invocation (or an applicable error).
+type FutureRemoveMempoolTransactionResult chan *response
+
+// Receive waits for the response promised by the future and returns the IDs
+// of the transactions that were removed from the memory pool.
+func (r FutureRemoveMempoolTransactionResult) Receive() ([]*daghash.TxID, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the result as an array of strings.
+	var txIDStrs []string
+	err = json.Unmarshal(res, &txIDStrs)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't decode removed transactions response")
+	}
+
+	txIDs := make([]*daghash.TxID, 0, len(txIDStrs))
+	for _, txIDStr := range txIDStrs {
+		txID, err := daghash.NewTxIDFromStr(txIDStr)
+		if err != nil {
+			return nil, err
+		}
+		txIDs = append(txIDs, txID)
+	}
+
+	return txIDs, nil
+}
+
+// RemoveMempoolTransactionAsync returns an instance of a type that can be used
+// to get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See RemoveMempoolTransaction for the blocking version and more details.
+func (c *Client) RemoveMempoolTransactionAsync(txID *daghash.TxID) FutureRemoveMempoolTransactionResult {
+	cmd := rpcmodel.NewRemoveMempoolTransactionCmd(txID.String())
+	return c.sendCmd(cmd)
+}
+
+// RemoveMempoolTransaction removes the transaction with the given ID from the
+// memory pool, along with all the transactions that depend on it, and returns
+// the IDs of all the removed transactions.
+func (c *Client) RemoveMempoolTransaction(txID *daghash.TxID) ([]*daghash.TxID, error) {
+	return c.RemoveMempoolTransactionAsync(txID).Receive()
+}
+
+// ClearMempoolAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ClearMempool for the blocking version and more details.
+func (c *Client) ClearMempoolAsync() FutureRemoveMempoolTransactionResult {
+	cmd := rpcmodel.NewClearMempoolCmd()
+	return c.sendCmd(cmd)
+}
+
+// ClearMempool removes all the transactions from the memory pool and returns
+// their IDs.
+func (c *Client) ClearMempool() ([]*daghash.TxID, error) {
+	return c.ClearMempoolAsync().Receive()
+}
+
 // FutureGetRawMempoolResult is a future promise to deliver the result of a
 // GetRawMempoolAsync RPC invocation (or an applicable error).
 type FutureGetRawMempoolResult chan *response
@@ -704,6 +841,156 @@ func (c *Client) GetTxOut(txHash *daghash.Hash, index uint32, mempool bool) (*rp
 	return c.GetTxOutAsync(txHash, index, mempool).Receive()
 }
 
+// FutureGetTxOutSetInfoResult is a future promise to deliver the result of a
+// GetTxOutSetInfoAsync RPC invocation (or an applicable error).
+type FutureGetTxOutSetInfoResult chan *response
+
+// Receive waits for the response promised by the future and returns
+// statistics about the UTXO set of the virtual block.
+func (r FutureGetTxOutSetInfoResult) Receive() (*rpcmodel.GetTxOutSetInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var txOutSetInfo rpcmodel.GetTxOutSetInfoResult
+	err = json.Unmarshal(res, &txOutSetInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't decode getTxOutSetInfo response")
+	}
+
+	return &txOutSetInfo, nil
+}
+
+// GetTxOutSetInfoAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetTxOutSetInfo for the blocking version and more details.
+func (c *Client) GetTxOutSetInfoAsync() FutureGetTxOutSetInfoResult {
+	cmd := rpcmodel.NewGetTxOutSetInfoCmd()
+	return c.sendCmd(cmd)
+}
+
+// GetTxOutSetInfo returns statistics about the UTXO set of the virtual block.
+func (c *Client) GetTxOutSetInfo() (*rpcmodel.GetTxOutSetInfoResult, error) {
+	return c.GetTxOutSetInfoAsync().Receive()
+}
+
+// FutureEstimateFeeResult is a future promise to deliver the result of a
+// EstimateFeeAsync RPC invocation (or an applicable error).
+type FutureEstimateFeeResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// estimated fee rate.
+func (r FutureEstimateFeeResult) Receive() (*rpcmodel.EstimateFeeResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var estimateFeeResult rpcmodel.EstimateFeeResult
+	err = json.Unmarshal(res, &estimateFeeResult)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't decode estimateFee response")
+	}
+
+	return &estimateFeeResult, nil
+}
+
+// EstimateFeeAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See EstimateFee for the blocking version and more details.
+func (c *Client) EstimateFeeAsync(targetBlueScoreDelta uint64) FutureEstimateFeeResult {
+	cmd := rpcmodel.NewEstimateFeeCmd(targetBlueScoreDelta)
+	return c.sendCmd(cmd)
+}
+
+// EstimateFee returns the fee rate a transaction should pay in order to be
+// accepted by the virtual block within the given number of blue score units.
+func (c *Client) EstimateFee(targetBlueScoreDelta uint64) (*rpcmodel.EstimateFeeResult, error) {
+	return c.EstimateFeeAsync(targetBlueScoreDelta).Receive()
+}
+
+// FutureEstimateMempoolFeeRatesResult is a future promise to deliver the
+// result of a EstimateMempoolFeeRatesAsync RPC invocation (or an applicable
+// error).
+type FutureEstimateMempoolFeeRatesResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// estimated fee rates.
+func (r FutureEstimateMempoolFeeRatesResult) Receive() (*rpcmodel.EstimateMempoolFeeRatesResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var estimateMempoolFeeRatesResult rpcmodel.EstimateMempoolFeeRatesResult
+	err = json.Unmarshal(res, &estimateMempoolFeeRatesResult)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't decode estimateMempoolFeeRates response")
+	}
+
+	return &estimateMempoolFeeRatesResult, nil
+}
+
+// EstimateMempoolFeeRatesAsync returns an instance of a type that can be used
+// to get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See EstimateMempoolFeeRates for the blocking version and more details.
+func (c *Client) EstimateMempoolFeeRatesAsync() FutureEstimateMempoolFeeRatesResult {
+	cmd := rpcmodel.NewEstimateMempoolFeeRatesCmd()
+	return c.sendCmd(cmd)
+}
+
+// EstimateMempoolFeeRates returns the fee rates transactions should pay in
+// order to be accepted by the virtual block within a set of common targets.
+func (c *Client) EstimateMempoolFeeRates() (*rpcmodel.EstimateMempoolFeeRatesResult, error) {
+	return c.EstimateMempoolFeeRatesAsync().Receive()
+}
+
+// FutureDumpUTXOSnapshotResult is a future promise to deliver the result of a
+// DumpUTXOSnapshotAsync RPC invocation (or an applicable error).
+type FutureDumpUTXOSnapshotResult chan *response
+
+// Receive waits for the response promised by the future and returns a
+// description of the UTXO snapshot that was written.
+func (r FutureDumpUTXOSnapshotResult) Receive() (*rpcmodel.DumpUTXOSnapshotResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot rpcmodel.DumpUTXOSnapshotResult
+	err = json.Unmarshal(res, &snapshot)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't decode dumpUTXOSnapshot response")
+	}
+
+	return &snapshot, nil
+}
+
+// DumpUTXOSnapshotAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See DumpUTXOSnapshot for the blocking version and more details.
+func (c *Client) DumpUTXOSnapshotAsync(path string) FutureDumpUTXOSnapshotResult {
+	cmd := rpcmodel.NewDumpUTXOSnapshotCmd(path)
+	return c.sendCmd(cmd)
+}
+
+// DumpUTXOSnapshot makes the server write a snapshot of the UTXO set of the
+// virtual block, along with the DAG state that's needed in order to keep
+// processing blocks on top of it, to the file at the given path, which is
+// relative to the data directory of the server.
+func (c *Client) DumpUTXOSnapshot(path string) (*rpcmodel.DumpUTXOSnapshotResult, error) {
+	return c.DumpUTXOSnapshotAsync(path).Receive()
+}
+
 // FutureRescanBlocksResult is a future promise to deliver the result of a
 // RescanBlocksAsync RPC invocation (or an applicable error).
 type FutureRescanBlocksResult chan *response
@@ -27,6 +27,7 @@ import (
 	"github.com/btcsuite/go-socks/socks"
 	"github.com/btcsuite/websocket"
 	"github.com/kaspanet/kaspad/rpcmodel"
+	"github.com/kaspanet/kaspad/util/daghash"
 )
 
 var (
@@ -265,6 +266,15 @@ func (c *Client) trackRegisteredNtfns(cmd interface{}) {
 			c.ntfnState.notifyNewTx = true
 		}
 		c.ntfnState.notifyNewTxSubnetworkID = bcmd.Subnetwork
+
+	case *rpcmodel.NotifyTxStatusCmd:
+		for _, txIDStr := range bcmd.TxIDs {
+			txID, err := daghash.NewTxIDFromStr(txIDStr)
+			if err != nil {
+				continue
+			}
+			c.ntfnState.notifyTxStatus[*txID] = struct{}{}
+		}
 	}
 }
 
@@ -542,6 +552,21 @@ func (c *Client) reregisterNtfns() error {
 		}
 	}
 
+	// Reregister notifytxstatus if needed.
+	if len(stateCopy.notifyTxStatus) > 0 {
+		log.Debugf("Reregistering [notifytxstatus] (%d transactions)",
+			len(stateCopy.notifyTxStatus))
+		txIDs := make([]*daghash.TxID, 0, len(stateCopy.notifyTxStatus))
+		for txID := range stateCopy.notifyTxStatus {
+			txID := txID
+			txIDs = append(txIDs, &txID)
+		}
+		err := c.NotifyTxStatus(txIDs)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
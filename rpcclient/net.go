@@ -266,6 +266,143 @@ func (c *Client) GetNetTotals() (*rpcmodel.GetNetTotalsResult, error) {
 	return c.GetNetTotalsAsync().Receive()
 }
 
+// FutureGetNetworkInfoResult is a future promise to deliver the result of a
+// GetNetworkInfoAsync RPC invocation (or an applicable error).
+type FutureGetNetworkInfoResult chan *response
+
+// Receive waits for the response promised by the future and returns data
+// about the network state of the server.
+func (r FutureGetNetworkInfoResult) Receive() (*rpcmodel.GetNetworkInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getNetworkInfo result object.
+	var networkInfo rpcmodel.GetNetworkInfoResult
+	err = json.Unmarshal(res, &networkInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &networkInfo, nil
+}
+
+// GetNetworkInfoAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetNetworkInfo for the blocking version and more details.
+func (c *Client) GetNetworkInfoAsync() FutureGetNetworkInfoResult {
+	cmd := rpcmodel.NewGetNetworkInfoCmd()
+	return c.sendCmd(cmd)
+}
+
+// GetNetworkInfo returns data about the network state of the server.
+func (c *Client) GetNetworkInfo() (*rpcmodel.GetNetworkInfoResult, error) {
+	return c.GetNetworkInfoAsync().Receive()
+}
+
+// FutureSetBanResult is a future promise to deliver the result of a
+// SetBanAsync RPC invocation (or an applicable error).
+type FutureSetBanResult chan *response
+
+// Receive waits for the response promised by the future and returns an error if
+// any occurred when performing the specified command.
+func (r FutureSetBanResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// SetBanAsync returns an instance of a type that can be used to get the result
+// of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See SetBan for the blocking version and more details.
+func (c *Client) SetBanAsync(subnet string, subCmd rpcmodel.SetBanSubCmd, banTime *int64,
+	absolute *bool, reason *string) FutureSetBanResult {
+
+	cmd := rpcmodel.NewSetBanCmd(subnet, subCmd, banTime, absolute, reason)
+	return c.sendCmd(cmd)
+}
+
+// SetBan bans the passed subnet, given in CIDR notation or as a single IP
+// address, or removes its ban, depending on the passed sub command.
+//
+// The ban time is the number of seconds to ban the subnet for, or the unix
+// timestamp until which it's banned if absolute is true. Passing nil for the
+// optional parameters will ban the subnet for the default ban duration of the
+// server.
+func (c *Client) SetBan(subnet string, subCmd rpcmodel.SetBanSubCmd, banTime *int64,
+	absolute *bool, reason *string) error {
+
+	return c.SetBanAsync(subnet, subCmd, banTime, absolute, reason).Receive()
+}
+
+// FutureListBannedResult is a future promise to deliver the result of a
+// ListBannedAsync RPC invocation (or an applicable error).
+type FutureListBannedResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// banned subnets.
+func (r FutureListBannedResult) Receive() ([]rpcmodel.ListBannedResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal as an array of listBanned result objects.
+	var bannedSubnets []rpcmodel.ListBannedResult
+	err = json.Unmarshal(res, &bannedSubnets)
+	if err != nil {
+		return nil, err
+	}
+
+	return bannedSubnets, nil
+}
+
+// ListBannedAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See ListBanned for the blocking version and more details.
+func (c *Client) ListBannedAsync() FutureListBannedResult {
+	cmd := rpcmodel.NewListBannedCmd()
+	return c.sendCmd(cmd)
+}
+
+// ListBanned returns the subnets that are banned by the server.
+func (c *Client) ListBanned() ([]rpcmodel.ListBannedResult, error) {
+	return c.ListBannedAsync().Receive()
+}
+
+// FutureClearBannedResult is a future promise to deliver the result of a
+// ClearBannedAsync RPC invocation (or an applicable error).
+type FutureClearBannedResult chan *response
+
+// Receive waits for the response promised by the future and returns an error if
+// any occurred when performing the specified command.
+func (r FutureClearBannedResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// ClearBannedAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See ClearBanned for the blocking version and more details.
+func (c *Client) ClearBannedAsync() FutureClearBannedResult {
+	cmd := rpcmodel.NewClearBannedCmd()
+	return c.sendCmd(cmd)
+}
+
+// ClearBanned removes the bans of all the subnets that are banned by the
+// server.
+func (c *Client) ClearBanned() error {
+	return c.ClearBannedAsync().Receive()
+}
+
 // FutureDebugLevelResult is a future promise to deliver the result of a
 // DebugLevelAsync RPC invocation (or an applicable error).
 type FutureDebugLevelResult chan *response
@@ -37,6 +37,7 @@ type notificationState struct {
 	notifyNewTx             bool
 	notifyNewTxVerbose      bool
 	notifyNewTxSubnetworkID *string
+	notifyTxStatus          map[daghash.TxID]struct{}
 }
 
 // Copy returns a deep copy of the receiver.
@@ -47,13 +48,19 @@ func (s *notificationState) Copy() *notificationState {
 	stateCopy.notifyNewTx = s.notifyNewTx
 	stateCopy.notifyNewTxVerbose = s.notifyNewTxVerbose
 	stateCopy.notifyNewTxSubnetworkID = s.notifyNewTxSubnetworkID
+	stateCopy.notifyTxStatus = make(map[daghash.TxID]struct{}, len(s.notifyTxStatus))
+	for txID := range s.notifyTxStatus {
+		stateCopy.notifyTxStatus[txID] = struct{}{}
+	}
 
 	return &stateCopy
 }
 
 // newNotificationState returns a new notification state ready to be populated.
 func newNotificationState() *notificationState {
-	return &notificationState{}
+	return &notificationState{
+		notifyTxStatus: make(map[daghash.TxID]struct{}),
+	}
 }
 
 // newNilFutureResult returns a new future result channel that already has the
@@ -114,6 +121,36 @@ type NotificationHandlers struct {
 	// made to register for the notification and the function is non-nil.
 	OnTxAcceptedVerbose func(txDetails *rpcmodel.TxRawResult)
 
+	// OnTxReplaced is invoked when a transaction in the memory pool is
+	// replaced by a conflicting transaction that pays a higher fee. It
+	// will only be invoked if a preceding call to NotifyNewTransactions
+	// has been made to register for the notification and the function is
+	// non-nil.
+	OnTxReplaced func(replacedTxID *daghash.TxID, replacementTxID *daghash.TxID)
+
+	// OnTxRemoved is invoked when a transaction is dropped from the memory
+	// pool without being included in a block, for any reason other than
+	// being replaced. The reason is one of "expired", "manual", "evicted"
+	// or "doubleSpend". It will
+	// only be invoked if a preceding call to NotifyNewTransactions has been
+	// made to register for the notification and the function is non-nil.
+	OnTxRemoved func(txID *daghash.TxID, reason string)
+
+	// OnTxRemovedFromMempool is invoked when a transaction whose status is
+	// watched is dropped from the memory pool without being included in a
+	// block. The reason is one of "expired", "manual", "evicted",
+	// "replaced" or "doubleSpend". It will only be invoked if a preceding
+	// call to NotifyTxStatus has been made to register for the notification
+	// and the function is non-nil.
+	OnTxRemovedFromMempool func(txID *daghash.TxID, reason string)
+
+	// OnTxAcceptedByVirtual is invoked when a transaction whose status is
+	// watched is accepted by a block that was added to the selected parent
+	// chain of the virtual block. It will only be invoked if a preceding
+	// call to NotifyTxStatus has been made to register for the notification
+	// and the function is non-nil.
+	OnTxAcceptedByVirtual func(txID *daghash.TxID, acceptingBlockHash *daghash.Hash)
+
 	// OnUnknownNotification is invoked when an unrecognized notification
 	// is received. This typically means the notification handling code
 	// for this package needs to be updated for a new notification type or
@@ -222,6 +259,74 @@ func (c *Client) handleNotification(ntfn *rawNotification) {
 
 		c.ntfnHandlers.OnTxAcceptedVerbose(rawTx)
 
+	// OnTxReplaced
+	case rpcmodel.TxReplacedNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnTxReplaced == nil {
+			return
+		}
+
+		replacedTxID, replacementTxID, err := parseTxReplacedNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warnf("Received invalid tx replaced "+
+				"notification: %s", err)
+			return
+		}
+
+		c.ntfnHandlers.OnTxReplaced(replacedTxID, replacementTxID)
+
+	// OnTxRemoved
+	case rpcmodel.TxRemovedNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnTxRemoved == nil {
+			return
+		}
+
+		txID, reason, err := parseTxRemovedNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warnf("Received invalid tx removed "+
+				"notification: %s", err)
+			return
+		}
+
+		c.ntfnHandlers.OnTxRemoved(txID, reason)
+
+	// OnTxRemovedFromMempool
+	case rpcmodel.TxRemovedFromMempoolNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnTxRemovedFromMempool == nil {
+			return
+		}
+
+		txID, reason, err := parseTxRemovedNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warnf("Received invalid tx removed from mempool "+
+				"notification: %s", err)
+			return
+		}
+
+		c.ntfnHandlers.OnTxRemovedFromMempool(txID, reason)
+
+	// OnTxAcceptedByVirtual
+	case rpcmodel.TxAcceptedByVirtualNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnTxAcceptedByVirtual == nil {
+			return
+		}
+
+		txID, acceptingBlockHash, err := parseTxAcceptedByVirtualNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warnf("Received invalid tx accepted by virtual "+
+				"notification: %s", err)
+			return
+		}
+
+		c.ntfnHandlers.OnTxAcceptedByVirtual(txID, acceptingBlockHash)
+
 	// OnUnknownNotification
 	default:
 		if c.ntfnHandlers.OnUnknownNotification == nil {
@@ -423,6 +528,110 @@ func parseTxAcceptedNtfnParams(params []json.RawMessage) (*daghash.Hash,
 	return txHash, amt, nil
 }
 
+// parseTxReplacedNtfnParams parses out the IDs of the replaced transaction
+// and the replacement transaction from the parameters of a txreplaced
+// notification.
+func parseTxReplacedNtfnParams(params []json.RawMessage) (*daghash.TxID,
+	*daghash.TxID, error) {
+
+	if len(params) != 2 {
+		return nil, nil, wrongNumParams(len(params))
+	}
+
+	// Unmarshal first parameter as a string.
+	var replacedTxIDStr string
+	err := json.Unmarshal(params[0], &replacedTxIDStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Unmarshal second parameter as a string.
+	var replacementTxIDStr string
+	err = json.Unmarshal(params[1], &replacementTxIDStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	replacedTxID, err := daghash.NewTxIDFromStr(replacedTxIDStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	replacementTxID, err := daghash.NewTxIDFromStr(replacementTxIDStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return replacedTxID, replacementTxID, nil
+}
+
+// parseTxRemovedNtfnParams parses out the ID of the removed transaction and
+// the reason for its removal from the parameters of a txremoved or a
+// txRemovedFromMempool notification.
+func parseTxRemovedNtfnParams(params []json.RawMessage) (*daghash.TxID,
+	string, error) {
+
+	if len(params) != 2 {
+		return nil, "", wrongNumParams(len(params))
+	}
+
+	// Unmarshal first parameter as a string.
+	var txIDStr string
+	err := json.Unmarshal(params[0], &txIDStr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Unmarshal second parameter as a string.
+	var reason string
+	err = json.Unmarshal(params[1], &reason)
+	if err != nil {
+		return nil, "", err
+	}
+
+	txID, err := daghash.NewTxIDFromStr(txIDStr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return txID, reason, nil
+}
+
+// parseTxAcceptedByVirtualNtfnParams parses out the ID of the accepted
+// transaction and the hash of the accepting block from the parameters of a
+// txAcceptedByVirtual notification.
+func parseTxAcceptedByVirtualNtfnParams(params []json.RawMessage) (*daghash.TxID,
+	*daghash.Hash, error) {
+
+	if len(params) != 2 {
+		return nil, nil, wrongNumParams(len(params))
+	}
+
+	// Unmarshal first parameter as a string.
+	var txIDStr string
+	err := json.Unmarshal(params[0], &txIDStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Unmarshal second parameter as a string.
+	var acceptingBlockHashStr string
+	err = json.Unmarshal(params[1], &acceptingBlockHashStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	txID, err := daghash.NewTxIDFromStr(txIDStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	acceptingBlockHash, err := daghash.NewHashFromStr(acceptingBlockHashStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return txID, acceptingBlockHash, nil
+}
+
 // parseTxAcceptedVerboseNtfnParams parses out details about a raw transaction
 // from the parameters of a txacceptedverbose notification.
 func parseTxAcceptedVerboseNtfnParams(params []json.RawMessage) (*rpcmodel.TxRawResult,
@@ -571,11 +780,62 @@ func (c *Client) NotifyNewTransactionsAsync(verbose bool, subnetworkID *string)
 //
 // The notifications delivered as a result of this call will be via one of
 // OnTxAccepted (when verbose is false) or OnTxAcceptedVerbose (when verbose is
-// true).
+// true), via OnTxReplaced when a transaction is replaced, and via OnTxRemoved
+// when a transaction is otherwise dropped from the memory pool.
 func (c *Client) NotifyNewTransactions(verbose bool, subnetworkID *string) error {
 	return c.NotifyNewTransactionsAsync(verbose, subnetworkID).Receive()
 }
 
+// FutureNotifyTxStatusResult is a future promise to deliver the result of a
+// NotifyTxStatusAsync RPC invocation (or an applicable error).
+type FutureNotifyTxStatusResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if the registration was not successful.
+func (r FutureNotifyTxStatusResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// NotifyTxStatusAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See NotifyTxStatus for the blocking version and more details.
+func (c *Client) NotifyTxStatusAsync(txIDs []*daghash.TxID) FutureNotifyTxStatusResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	txIDStrs := make([]string, len(txIDs))
+	for i, txID := range txIDs {
+		txIDStrs[i] = txID.String()
+	}
+	cmd := rpcmodel.NewNotifyTxStatusCmd(txIDStrs)
+	return c.sendCmd(cmd)
+}
+
+// NotifyTxStatus registers the client to receive notifications about the
+// status of the passed transactions. The notifications are delivered to the
+// notification handlers associated with the client. Calling this function
+// has no effect if there are no notification handlers and will result in an
+// error if the client is configured to run in HTTP POST mode.
+//
+// The notifications delivered as a result of this call will be via
+// OnTxRemovedFromMempool when a transaction is dropped from the memory pool,
+// and via OnTxAcceptedByVirtual when a transaction is accepted by the virtual
+// block.
+func (c *Client) NotifyTxStatus(txIDs []*daghash.TxID) error {
+	return c.NotifyTxStatusAsync(txIDs).Receive()
+}
+
 // FutureLoadTxFilterResult is a future promise to deliver the result
 // of a LoadTxFilterAsync RPC invocation (or an applicable error).
 type FutureLoadTxFilterResult chan *response
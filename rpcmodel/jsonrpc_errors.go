@@ -59,6 +59,8 @@ const (
 	ErrRPCOutOfRange         RPCErrorCode = -1
 	ErrRPCNoTxInfo           RPCErrorCode = -5
 	ErrRPCNoAcceptanceIndex  RPCErrorCode = -5
+	ErrRPCNoTxIndex          RPCErrorCode = -5
+	ErrRPCNoAddressIndex     RPCErrorCode = -5
 	ErrRPCNoNewestBlockInfo  RPCErrorCode = -5
 	ErrRPCInvalidTxVout      RPCErrorCode = -5
 	ErrRPCSubnetworkNotFound RPCErrorCode = -5
@@ -66,6 +68,8 @@ const (
 	ErrRPCDecodeHexString    RPCErrorCode = -22
 	ErrRPCOrphanBlock        RPCErrorCode = -6
 	ErrRPCBlockInvalid       RPCErrorCode = -5
+	ErrRPCBlockPruned        RPCErrorCode = -1
+	ErrRPCTooManyUTXOs       RPCErrorCode = -1
 )
 
 // Errors that are specific to kaspad.
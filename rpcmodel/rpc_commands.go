@@ -47,6 +47,24 @@ type TransactionInput struct {
 	Vout uint32 `json:"vout"`
 }
 
+// ClearMempoolCmd defines the clearMempool JSON-RPC command.
+type ClearMempoolCmd struct{}
+
+// NewClearMempoolCmd returns a new instance which can be used to issue a
+// clearMempool JSON-RPC command.
+func NewClearMempoolCmd() *ClearMempoolCmd {
+	return &ClearMempoolCmd{}
+}
+
+// ClearBannedCmd defines the clearBanned JSON-RPC command.
+type ClearBannedCmd struct{}
+
+// NewClearBannedCmd returns a new instance which can be used to issue a
+// clearBanned JSON-RPC command.
+func NewClearBannedCmd() *ClearBannedCmd {
+	return &ClearBannedCmd{}
+}
+
 // CreateRawTransactionCmd defines the createRawTransaction JSON-RPC command.
 type CreateRawTransactionCmd struct {
 	Inputs   []TransactionInput
@@ -94,6 +112,94 @@ func NewDecodeScriptCmd(hexScript string) *DecodeScriptCmd {
 	}
 }
 
+// DumpUTXOSnapshotCmd defines the dumpUTXOSnapshot JSON-RPC command.
+type DumpUTXOSnapshotCmd struct {
+	Path string
+}
+
+// NewDumpUTXOSnapshotCmd returns a new instance which can be used to issue a
+// dumpUTXOSnapshot JSON-RPC command.
+func NewDumpUTXOSnapshotCmd(path string) *DumpUTXOSnapshotCmd {
+	return &DumpUTXOSnapshotCmd{
+		Path: path,
+	}
+}
+
+// EstimateFeeCmd defines the estimateFee JSON-RPC command.
+type EstimateFeeCmd struct {
+	TargetBlueScoreDelta uint64
+}
+
+// NewEstimateFeeCmd returns a new instance which can be used to issue an
+// estimateFee JSON-RPC command.
+func NewEstimateFeeCmd(targetBlueScoreDelta uint64) *EstimateFeeCmd {
+	return &EstimateFeeCmd{
+		TargetBlueScoreDelta: targetBlueScoreDelta,
+	}
+}
+
+// EstimateMempoolFeeRatesCmd defines the estimateMempoolFeeRates JSON-RPC command.
+type EstimateMempoolFeeRatesCmd struct{}
+
+// NewEstimateMempoolFeeRatesCmd returns a new instance which can be used to
+// issue an estimateMempoolFeeRates JSON-RPC command.
+func NewEstimateMempoolFeeRatesCmd() *EstimateMempoolFeeRatesCmd {
+	return &EstimateMempoolFeeRatesCmd{}
+}
+
+// GetAddressTransactionsCmd defines the getAddressTransactions JSON-RPC command.
+type GetAddressTransactionsCmd struct {
+	Address string
+	Skip    *uint64 `jsonrpcdefault:"0"`
+	Count   *uint64 `jsonrpcdefault:"1000"`
+}
+
+// NewGetAddressTransactionsCmd returns a new instance which can be used to
+// issue a getAddressTransactions JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional. Passing nil
+// for optional parameters will use the default value.
+func NewGetAddressTransactionsCmd(address string, skip *uint64, count *uint64) *GetAddressTransactionsCmd {
+	return &GetAddressTransactionsCmd{
+		Address: address,
+		Skip:    skip,
+		Count:   count,
+	}
+}
+
+// GetAddressBalanceCmd defines the getAddressBalance JSON-RPC command.
+type GetAddressBalanceCmd struct {
+	Address string
+}
+
+// NewGetAddressBalanceCmd returns a new instance which can be used to issue a
+// getAddressBalance JSON-RPC command.
+func NewGetAddressBalanceCmd(address string) *GetAddressBalanceCmd {
+	return &GetAddressBalanceCmd{
+		Address: address,
+	}
+}
+
+// GetAddressUTXOsCmd defines the getAddressUTXOs JSON-RPC command.
+type GetAddressUTXOsCmd struct {
+	Address string
+	Skip    *uint64 `jsonrpcdefault:"0"`
+	Count   *uint64 `jsonrpcdefault:"1000"`
+}
+
+// NewGetAddressUTXOsCmd returns a new instance which can be used to issue a
+// getAddressUTXOs JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional. Passing nil
+// for optional parameters will use the default value.
+func NewGetAddressUTXOsCmd(address string, skip *uint64, count *uint64) *GetAddressUTXOsCmd {
+	return &GetAddressUTXOsCmd{
+		Address: address,
+		Skip:    skip,
+		Count:   count,
+	}
+}
+
 // GetManualNodeInfoCmd defines the getManualNodeInfo JSON-RPC command.
 type GetManualNodeInfoCmd struct {
 	Node    string
@@ -306,6 +412,26 @@ func NewGetChainFromBlockCmd(includeBlocks bool, startHash *string) *GetChainFro
 	}
 }
 
+// GetDAGGraphCmd defines the getDagGraph JSON-RPC command.
+type GetDAGGraphCmd struct {
+	Format *string `jsonrpcdefault:"\"json\""`
+	Levels *uint64 `jsonrpcdefault:"10"`
+	Hash   *string
+}
+
+// NewGetDAGGraphCmd returns a new instance which can be used to issue a
+// getDagGraph JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional. Passing nil
+// for optional parameters will use the default value.
+func NewGetDAGGraphCmd(format *string, levels *uint64, hash *string) *GetDAGGraphCmd {
+	return &GetDAGGraphCmd{
+		Format: format,
+		Levels: levels,
+		Hash:   hash,
+	}
+}
+
 // GetDAGTipsCmd defines the getDagTips JSON-RPC command.
 type GetDAGTipsCmd struct{}
 
@@ -407,6 +533,24 @@ func NewGetRawMempoolCmd(verbose *bool) *GetRawMempoolCmd {
 	}
 }
 
+// GetRawTransactionCmd defines the getRawTransaction JSON-RPC command.
+type GetRawTransactionCmd struct {
+	TxID    string
+	Verbose *bool `jsonrpcdefault:"false"`
+}
+
+// NewGetRawTransactionCmd returns a new instance which can be used to issue a
+// getRawTransaction JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional. Passing nil
+// for optional parameters will use the default value.
+func NewGetRawTransactionCmd(txID string, verbose *bool) *GetRawTransactionCmd {
+	return &GetRawTransactionCmd{
+		TxID:    txID,
+		Verbose: verbose,
+	}
+}
+
 // GetSubnetworkCmd defines the getSubnetwork JSON-RPC command.
 type GetSubnetworkCmd struct {
 	SubnetworkID string
@@ -465,6 +609,15 @@ func NewHelpCmd(command *string) *HelpCmd {
 	}
 }
 
+// ListBannedCmd defines the listBanned JSON-RPC command.
+type ListBannedCmd struct{}
+
+// NewListBannedCmd returns a new instance which can be used to issue a
+// listBanned JSON-RPC command.
+func NewListBannedCmd() *ListBannedCmd {
+	return &ListBannedCmd{}
+}
+
 // PingCmd defines the ping JSON-RPC command.
 type PingCmd struct{}
 
@@ -474,6 +627,20 @@ func NewPingCmd() *PingCmd {
 	return &PingCmd{}
 }
 
+// RemoveMempoolTransactionCmd defines the removeMempoolTransaction JSON-RPC
+// command.
+type RemoveMempoolTransactionCmd struct {
+	TxID string
+}
+
+// NewRemoveMempoolTransactionCmd returns a new instance which can be used to
+// issue a removeMempoolTransaction JSON-RPC command.
+func NewRemoveMempoolTransactionCmd(txID string) *RemoveMempoolTransactionCmd {
+	return &RemoveMempoolTransactionCmd{
+		TxID: txID,
+	}
+}
+
 // SendRawTransactionCmd defines the sendRawTransaction JSON-RPC command.
 type SendRawTransactionCmd struct {
 	HexTx         string
@@ -492,6 +659,44 @@ func NewSendRawTransactionCmd(hexTx string, allowHighFees *bool) *SendRawTransac
 	}
 }
 
+// SetBanSubCmd defines the type used in the `setBan` JSON-RPC command for the
+// sub command field.
+type SetBanSubCmd string
+
+const (
+	// SBAdd indicates the specified subnet should be banned.
+	SBAdd SetBanSubCmd = "add"
+
+	// SBRemove indicates the ban of the specified subnet should be removed.
+	SBRemove SetBanSubCmd = "remove"
+)
+
+// SetBanCmd defines the setBan JSON-RPC command.
+type SetBanCmd struct {
+	Subnet   string
+	SubCmd   SetBanSubCmd `jsonrpcusage:"\"add|remove\""`
+	BanTime  *int64       `jsonrpcdefault:"0"`
+	Absolute *bool        `jsonrpcdefault:"false"`
+	Reason   *string
+}
+
+// NewSetBanCmd returns a new instance which can be used to issue a setBan
+// JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional. Passing nil
+// for optional parameters will use the default value.
+func NewSetBanCmd(subnet string, subCmd SetBanSubCmd, banTime *int64,
+	absolute *bool, reason *string) *SetBanCmd {
+
+	return &SetBanCmd{
+		Subnet:   subnet,
+		SubCmd:   subCmd,
+		BanTime:  banTime,
+		Absolute: absolute,
+		Reason:   reason,
+	}
+}
+
 // StopCmd defines the stop JSON-RPC command.
 type StopCmd struct{}
 
@@ -668,9 +873,17 @@ func init() {
 	flags := UsageFlag(0)
 
 	MustRegisterCommand("addManualNode", (*AddManualNodeCmd)(nil), flags)
+	MustRegisterCommand("clearBanned", (*ClearBannedCmd)(nil), flags)
+	MustRegisterCommand("clearMempool", (*ClearMempoolCmd)(nil), flags)
 	MustRegisterCommand("createRawTransaction", (*CreateRawTransactionCmd)(nil), flags)
 	MustRegisterCommand("decodeRawTransaction", (*DecodeRawTransactionCmd)(nil), flags)
 	MustRegisterCommand("decodeScript", (*DecodeScriptCmd)(nil), flags)
+	MustRegisterCommand("dumpUTXOSnapshot", (*DumpUTXOSnapshotCmd)(nil), flags)
+	MustRegisterCommand("estimateFee", (*EstimateFeeCmd)(nil), flags)
+	MustRegisterCommand("estimateMempoolFeeRates", (*EstimateMempoolFeeRatesCmd)(nil), flags)
+	MustRegisterCommand("getAddressBalance", (*GetAddressBalanceCmd)(nil), flags)
+	MustRegisterCommand("getAddressTransactions", (*GetAddressTransactionsCmd)(nil), flags)
+	MustRegisterCommand("getAddressUTXOs", (*GetAddressUTXOsCmd)(nil), flags)
 	MustRegisterCommand("getAllManualNodesInfo", (*GetAllManualNodesInfoCmd)(nil), flags)
 	MustRegisterCommand("getSelectedTipHash", (*GetSelectedTipHashCmd)(nil), flags)
 	MustRegisterCommand("getBlock", (*GetBlockCmd)(nil), flags)
@@ -680,6 +893,7 @@ func init() {
 	MustRegisterCommand("getBlockHeader", (*GetBlockHeaderCmd)(nil), flags)
 	MustRegisterCommand("getBlockTemplate", (*GetBlockTemplateCmd)(nil), flags)
 	MustRegisterCommand("getChainFromBlock", (*GetChainFromBlockCmd)(nil), flags)
+	MustRegisterCommand("getDagGraph", (*GetDAGGraphCmd)(nil), flags)
 	MustRegisterCommand("getDagTips", (*GetDAGTipsCmd)(nil), flags)
 	MustRegisterCommand("getConnectionCount", (*GetConnectionCountCmd)(nil), flags)
 	MustRegisterCommand("getDifficulty", (*GetDifficultyCmd)(nil), flags)
@@ -692,13 +906,17 @@ func init() {
 	MustRegisterCommand("getConnectedPeerInfo", (*GetConnectedPeerInfoCmd)(nil), flags)
 	MustRegisterCommand("getPeerAddresses", (*GetPeerAddressesCmd)(nil), flags)
 	MustRegisterCommand("getRawMempool", (*GetRawMempoolCmd)(nil), flags)
+	MustRegisterCommand("getRawTransaction", (*GetRawTransactionCmd)(nil), flags)
 	MustRegisterCommand("getSubnetwork", (*GetSubnetworkCmd)(nil), flags)
 	MustRegisterCommand("getTxOut", (*GetTxOutCmd)(nil), flags)
 	MustRegisterCommand("getTxOutSetInfo", (*GetTxOutSetInfoCmd)(nil), flags)
 	MustRegisterCommand("help", (*HelpCmd)(nil), flags)
+	MustRegisterCommand("listBanned", (*ListBannedCmd)(nil), flags)
 	MustRegisterCommand("ping", (*PingCmd)(nil), flags)
 	MustRegisterCommand("removeManualNode", (*RemoveManualNodeCmd)(nil), flags)
+	MustRegisterCommand("removeMempoolTransaction", (*RemoveMempoolTransactionCmd)(nil), flags)
 	MustRegisterCommand("sendRawTransaction", (*SendRawTransactionCmd)(nil), flags)
+	MustRegisterCommand("setBan", (*SetBanCmd)(nil), flags)
 	MustRegisterCommand("stop", (*StopCmd)(nil), flags)
 	MustRegisterCommand("submitBlock", (*SubmitBlockCmd)(nil), flags)
 	MustRegisterCommand("uptime", (*UptimeCmd)(nil), flags)
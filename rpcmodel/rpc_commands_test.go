@@ -42,6 +42,28 @@ func TestRPCServerCommands(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"addManualNode","params":["127.0.0.1"],"id":1}`,
 			unmarshalled: &rpcmodel.AddManualNodeCmd{Addr: "127.0.0.1", OneTry: pointers.Bool(false)},
 		},
+		{
+			name: "clearBanned",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("clearBanned")
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewClearBannedCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"clearBanned","params":[],"id":1}`,
+			unmarshalled: &rpcmodel.ClearBannedCmd{},
+		},
+		{
+			name: "clearMempool",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("clearMempool")
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewClearMempoolCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"clearMempool","params":[],"id":1}`,
+			unmarshalled: &rpcmodel.ClearMempoolCmd{},
+		},
 		{
 			name: "createRawTransaction",
 			newCmd: func() (interface{}, error) {
@@ -104,6 +126,97 @@ func TestRPCServerCommands(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"decodeScript","params":["00"],"id":1}`,
 			unmarshalled: &rpcmodel.DecodeScriptCmd{HexScript: "00"},
 		},
+		{
+			name: "dumpUTXOSnapshot",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("dumpUTXOSnapshot", "snapshot.dat")
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewDumpUTXOSnapshotCmd("snapshot.dat")
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"dumpUTXOSnapshot","params":["snapshot.dat"],"id":1}`,
+			unmarshalled: &rpcmodel.DumpUTXOSnapshotCmd{Path: "snapshot.dat"},
+		},
+		{
+			name: "estimateFee",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("estimateFee", 10)
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewEstimateFeeCmd(10)
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"estimateFee","params":[10],"id":1}`,
+			unmarshalled: &rpcmodel.EstimateFeeCmd{TargetBlueScoreDelta: 10},
+		},
+		{
+			name: "estimateMempoolFeeRates",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("estimateMempoolFeeRates")
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewEstimateMempoolFeeRatesCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"estimateMempoolFeeRates","params":[],"id":1}`,
+			unmarshalled: &rpcmodel.EstimateMempoolFeeRatesCmd{},
+		},
+		{
+			name: "getAddressBalance",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("getAddressBalance", "kaspa:123")
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewGetAddressBalanceCmd("kaspa:123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getAddressBalance","params":["kaspa:123"],"id":1}`,
+			unmarshalled: &rpcmodel.GetAddressBalanceCmd{
+				Address: "kaspa:123",
+			},
+		},
+		{
+			name: "getAddressTransactions",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("getAddressTransactions", "kaspa:123")
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewGetAddressTransactionsCmd("kaspa:123", nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getAddressTransactions","params":["kaspa:123"],"id":1}`,
+			unmarshalled: &rpcmodel.GetAddressTransactionsCmd{
+				Address: "kaspa:123",
+				Skip:    pointers.Uint64(0),
+				Count:   pointers.Uint64(1000),
+			},
+		},
+		{
+			name: "getAddressTransactions optional",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("getAddressTransactions", "kaspa:123", 10, 20)
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewGetAddressTransactionsCmd("kaspa:123", pointers.Uint64(10), pointers.Uint64(20))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getAddressTransactions","params":["kaspa:123",10,20],"id":1}`,
+			unmarshalled: &rpcmodel.GetAddressTransactionsCmd{
+				Address: "kaspa:123",
+				Skip:    pointers.Uint64(10),
+				Count:   pointers.Uint64(20),
+			},
+		},
+		{
+			name: "getAddressUTXOs",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("getAddressUTXOs", "kaspa:123")
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewGetAddressUTXOsCmd("kaspa:123", nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getAddressUTXOs","params":["kaspa:123"],"id":1}`,
+			unmarshalled: &rpcmodel.GetAddressUTXOsCmd{
+				Address: "kaspa:123",
+				Skip:    pointers.Uint64(0),
+				Count:   pointers.Uint64(1000),
+			},
+		},
 		{
 			name: "getAllManualNodesInfo",
 			newCmd: func() (interface{}, error) {
@@ -339,6 +452,37 @@ func TestRPCServerCommands(t *testing.T) {
 				StartHash:     pointers.String("123"),
 			},
 		},
+		{
+			name: "getDagGraph",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("getDagGraph")
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewGetDAGGraphCmd(nil, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getDagGraph","params":[],"id":1}`,
+			unmarshalled: &rpcmodel.GetDAGGraphCmd{
+				Format: pointers.String("json"),
+				Levels: pointers.Uint64(10),
+				Hash:   nil,
+			},
+		},
+		{
+			name: "getDagGraph optional",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("getDagGraph", "dot", 5, "123")
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewGetDAGGraphCmd(pointers.String("dot"),
+					pointers.Uint64(5), pointers.String("123"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getDagGraph","params":["dot",5,"123"],"id":1}`,
+			unmarshalled: &rpcmodel.GetDAGGraphCmd{
+				Format: pointers.String("dot"),
+				Levels: pointers.Uint64(5),
+				Hash:   pointers.String("123"),
+			},
+		},
 		{
 			name: "getDagTips",
 			newCmd: func() (interface{}, error) {
@@ -480,6 +624,34 @@ func TestRPCServerCommands(t *testing.T) {
 				Verbose: pointers.Bool(false),
 			},
 		},
+		{
+			name: "getRawTransaction",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("getRawTransaction", "123")
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewGetRawTransactionCmd("123", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getRawTransaction","params":["123"],"id":1}`,
+			unmarshalled: &rpcmodel.GetRawTransactionCmd{
+				TxID:    "123",
+				Verbose: pointers.Bool(false),
+			},
+		},
+		{
+			name: "getRawTransaction optional",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("getRawTransaction", "123", true)
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewGetRawTransactionCmd("123", pointers.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getRawTransaction","params":["123",true],"id":1}`,
+			unmarshalled: &rpcmodel.GetRawTransactionCmd{
+				TxID:    "123",
+				Verbose: pointers.Bool(true),
+			},
+		},
 		{
 			name: "getSubnetwork",
 			newCmd: func() (interface{}, error) {
@@ -560,6 +732,17 @@ func TestRPCServerCommands(t *testing.T) {
 				Command: pointers.String("getBlock"),
 			},
 		},
+		{
+			name: "listBanned",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("listBanned")
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewListBannedCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"listBanned","params":[],"id":1}`,
+			unmarshalled: &rpcmodel.ListBannedCmd{},
+		},
 		{
 			name: "ping",
 			newCmd: func() (interface{}, error) {
@@ -582,6 +765,17 @@ func TestRPCServerCommands(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"removeManualNode","params":["127.0.0.1"],"id":1}`,
 			unmarshalled: &rpcmodel.RemoveManualNodeCmd{Addr: "127.0.0.1"},
 		},
+		{
+			name: "removeMempoolTransaction",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("removeMempoolTransaction", "123")
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewRemoveMempoolTransactionCmd("123")
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"removeMempoolTransaction","params":["123"],"id":1}`,
+			unmarshalled: &rpcmodel.RemoveMempoolTransactionCmd{TxID: "123"},
+		},
 		{
 			name: "sendRawTransaction",
 			newCmd: func() (interface{}, error) {
@@ -610,6 +804,40 @@ func TestRPCServerCommands(t *testing.T) {
 				AllowHighFees: pointers.Bool(false),
 			},
 		},
+		{
+			name: "setBan",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("setBan", "10.0.0.0/8", rpcmodel.SBAdd)
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewSetBanCmd("10.0.0.0/8", rpcmodel.SBAdd, nil, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"setBan","params":["10.0.0.0/8","add"],"id":1}`,
+			unmarshalled: &rpcmodel.SetBanCmd{
+				Subnet:   "10.0.0.0/8",
+				SubCmd:   rpcmodel.SBAdd,
+				BanTime:  pointers.Int64(0),
+				Absolute: pointers.Bool(false),
+			},
+		},
+		{
+			name: "setBan optional",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("setBan", "127.0.0.1", rpcmodel.SBAdd, 1600000000, true, "misbehaving")
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewSetBanCmd("127.0.0.1", rpcmodel.SBAdd, pointers.Int64(1600000000),
+					pointers.Bool(true), pointers.String("misbehaving"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"setBan","params":["127.0.0.1","add",1600000000,true,"misbehaving"],"id":1}`,
+			unmarshalled: &rpcmodel.SetBanCmd{
+				Subnet:   "127.0.0.1",
+				SubCmd:   rpcmodel.SBAdd,
+				BanTime:  pointers.Int64(1600000000),
+				Absolute: pointers.Bool(true),
+				Reason:   pointers.String("misbehaving"),
+			},
+		},
 		{
 			name: "stop",
 			newCmd: func() (interface{}, error) {
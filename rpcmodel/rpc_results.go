@@ -69,6 +69,16 @@ type DecodeScriptResult struct {
 	P2sh    string  `json:"p2sh,omitempty"`
 }
 
+// DumpUTXOSnapshotResult models the data returned from the dumpUTXOSnapshot
+// command.
+type DumpUTXOSnapshotResult struct {
+	Path                   string `json:"path"`
+	FinalityPointHash      string `json:"finalityPointHash"`
+	FinalityPointBlueScore uint64 `json:"finalityPointBlueScore"`
+	UTXOCount              uint64 `json:"utxoCount"`
+	BlockCount             uint64 `json:"blockCount"`
+}
+
 // GetManualNodeInfoResultAddr models the data of the addresses portion of the
 // getmanualnodeinfo command.
 type GetManualNodeInfoResultAddr struct {
@@ -83,6 +93,15 @@ type GetManualNodeInfoResult struct {
 	Addresses  *[]GetManualNodeInfoResultAddr `json:"addresses,omitempty"`
 }
 
+// ListBannedResult models a banned subnet in the data from the listBanned
+// command.
+type ListBannedResult struct {
+	Address     string `json:"address"`
+	BanCreated  int64  `json:"banCreated"`
+	BannedUntil int64  `json:"bannedUntil"`
+	Reason      string `json:"reason"`
+}
+
 // SoftForkDescription describes the current state of a soft-fork which was
 // deployed using a super-majority block signalling.
 type SoftForkDescription struct {
@@ -120,6 +139,25 @@ type GetBlockDAGInfoResult struct {
 	Bip9SoftForks        map[string]*Bip9SoftForkDescription `json:"bip9SoftForks"`
 }
 
+// GetDAGGraphResult models the data returned from the getDagGraph command
+// when its format is json.
+type GetDAGGraphResult struct {
+	Blocks []GetDAGGraphResultBlock `json:"blocks"`
+}
+
+// GetDAGGraphResultBlock models the blocks field of the getDagGraph command.
+type GetDAGGraphResultBlock struct {
+	Hash                      string   `json:"hash"`
+	ParentHashes              []string `json:"parentHashes"`
+	SelectedParentHash        string   `json:"selectedParentHash,omitempty"`
+	BlueScore                 uint64   `json:"blueScore"`
+	IsBlue                    bool     `json:"isBlue"`
+	MergeSetBlueHashes        []string `json:"mergeSetBlueHashes"`
+	MergeSetRedHashes         []string `json:"mergeSetRedHashes"`
+	ReachabilityIntervalStart uint64   `json:"reachabilityIntervalStart"`
+	ReachabilityIntervalEnd   uint64   `json:"reachabilityIntervalEnd"`
+}
+
 // GetBlockTemplateResultTx models the transactions field of the
 // getblocktemplate command.
 type GetBlockTemplateResultTx struct {
@@ -166,19 +204,38 @@ type GetBlockTemplateResult struct {
 	RejectReason string   `json:"rejectReason,omitempty"`
 }
 
+// EstimateFeeResult models the data returned from the estimateFee command.
+type EstimateFeeResult struct {
+	TargetBlueScoreDelta uint64  `json:"targetBlueScoreDelta"`
+	FeeRate              float64 `json:"feeRate"`
+	IsEstimated          bool    `json:"isEstimated"`
+}
+
+// EstimateMempoolFeeRatesResult models the data returned from the
+// estimateMempoolFeeRates command.
+type EstimateMempoolFeeRatesResult struct {
+	Estimates []EstimateFeeResult `json:"estimates"`
+}
+
 // GetMempoolEntryResult models the data returned from the getMempoolEntry
 // command.
 type GetMempoolEntryResult struct {
-	Fee   uint64      `json:"fee"`
-	Time  int64       `json:"time"`
-	RawTx TxRawResult `json:"rawTx"`
+	Fee     uint64      `json:"fee"`
+	Mass    uint64      `json:"mass"`
+	Time    int64       `json:"time"`
+	Depends []string    `json:"depends"`
+	SpentBy []string    `json:"spentBy"`
+	RawTx   TxRawResult `json:"rawTx"`
 }
 
 // GetMempoolInfoResult models the data returned from the getmempoolinfo
 // command.
 type GetMempoolInfoResult struct {
-	Size  int64 `json:"size"`
-	Bytes int64 `json:"bytes"`
+	Size       int64   `json:"size"`
+	Bytes      int64   `json:"bytes"`
+	Mass       uint64  `json:"mass"`
+	MaxMass    uint64  `json:"maxMass"`
+	MinFeeRate float64 `json:"minFeeRate"`
 }
 
 // NetworksResult models the networks data from the getnetworkinfo command.
@@ -237,6 +294,16 @@ type GetConnectedPeerInfoResult struct {
 	BanScore    int32   `json:"banScore"`
 	FeeFilter   int64   `json:"feeFilter"`
 	SyncNode    bool    `json:"syncNode"`
+
+	// BlockRelayOnly is whether only blocks are relayed over the
+	// connection, and never transactions or addresses.
+	BlockRelayOnly bool `json:"blockRelayOnly"`
+
+	// Transport is whether the connection to the peer is plaintext or
+	// encrypted, and IdentityPubKey is the public key the peer identified
+	// with over the encrypted connection, if any.
+	Transport      string `json:"transport"`
+	IdentityPubKey string `json:"identityPubKey,omitempty"`
 }
 
 // GetPeerAddressesResult models the data returned from the getPeerAddresses command.
@@ -301,6 +368,16 @@ type GetTxOutResult struct {
 	Coinbase      bool               `json:"coinbase"`
 }
 
+// GetTxOutSetInfoResult models the data from the getTxOutSetInfo command.
+type GetTxOutSetInfoResult struct {
+	SelectedTipHash  string  `json:"selectedTipHash"`
+	VirtualBlueScore uint64  `json:"virtualBlueScore"`
+	UTXOCount        uint64  `json:"utxoCount"`
+	TotalAmount      float64 `json:"totalAmount"`
+	SerializedSize   uint64  `json:"serializedSize"`
+	MultisetHash     string  `json:"multisetHash"`
+}
+
 // GetNetTotalsResult models the data returned from the getnettotals command.
 type GetNetTotalsResult struct {
 	TotalBytesRecv uint64 `json:"totalBytesRecv"`
@@ -476,6 +553,44 @@ type GetChainFromBlockResult struct {
 	Blocks                  []GetBlockVerboseResult `json:"blocks"`
 }
 
+// AddressTransaction models a transaction that either spends from or pays to
+// some address.
+type AddressTransaction struct {
+	TxID               string `json:"txId"`
+	AcceptingBlockHash string `json:"acceptingBlockHash"`
+	AcceptingBlueScore uint64 `json:"acceptingBlueScore"`
+}
+
+// GetAddressTransactionsResult models the data from the getAddressTransactions
+// command.
+type GetAddressTransactionsResult struct {
+	Address      string               `json:"address"`
+	Transactions []AddressTransaction `json:"transactions"`
+}
+
+// GetAddressBalanceResult models the data from the getAddressBalance command.
+type GetAddressBalanceResult struct {
+	Address   string `json:"address"`
+	Balance   uint64 `json:"balance"`
+	UTXOCount uint64 `json:"utxoCount"`
+}
+
+// AddressUTXO models an unspent transaction output that pays to some address.
+type AddressUTXO struct {
+	TxID           string `json:"txId"`
+	Index          uint32 `json:"index"`
+	Amount         uint64 `json:"amount"`
+	ScriptPubKey   string `json:"scriptPubKey"`
+	BlockBlueScore uint64 `json:"blockBlueScore"`
+	IsCoinbase     bool   `json:"isCoinbase"`
+}
+
+// GetAddressUTXOsResult models the data from the getAddressUTXOs command.
+type GetAddressUTXOsResult struct {
+	Address string        `json:"address"`
+	UTXOs   []AddressUTXO `json:"utxos"`
+}
+
 // GetBlocksResult models the data from the getBlocks command.
 type GetBlocksResult struct {
 	Hashes        []string                `json:"hashes"`
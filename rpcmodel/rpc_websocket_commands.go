@@ -98,6 +98,32 @@ func NewStopNotifyNewTransactionsCmd() *StopNotifyNewTransactionsCmd {
 	return &StopNotifyNewTransactionsCmd{}
 }
 
+// NotifyTxStatusCmd defines the notifyTxStatus JSON-RPC command.
+type NotifyTxStatusCmd struct {
+	TxIDs []string
+}
+
+// NewNotifyTxStatusCmd returns a new instance which can be used to issue a
+// notifyTxStatus JSON-RPC command.
+func NewNotifyTxStatusCmd(txIDs []string) *NotifyTxStatusCmd {
+	return &NotifyTxStatusCmd{
+		TxIDs: txIDs,
+	}
+}
+
+// StopNotifyTxStatusCmd defines the stopNotifyTxStatus JSON-RPC command.
+type StopNotifyTxStatusCmd struct {
+	TxIDs []string
+}
+
+// NewStopNotifyTxStatusCmd returns a new instance which can be used to issue a
+// stopNotifyTxStatus JSON-RPC command.
+func NewStopNotifyTxStatusCmd(txIDs []string) *StopNotifyTxStatusCmd {
+	return &StopNotifyTxStatusCmd{
+		TxIDs: txIDs,
+	}
+}
+
 // Outpoint describes a transaction outpoint that will be marshalled to and
 // from JSON.
 type Outpoint struct {
@@ -144,9 +170,11 @@ func init() {
 	MustRegisterCommand("notifyBlocks", (*NotifyBlocksCmd)(nil), flags)
 	MustRegisterCommand("notifyChainChanges", (*NotifyChainChangesCmd)(nil), flags)
 	MustRegisterCommand("notifyNewTransactions", (*NotifyNewTransactionsCmd)(nil), flags)
+	MustRegisterCommand("notifyTxStatus", (*NotifyTxStatusCmd)(nil), flags)
 	MustRegisterCommand("session", (*SessionCmd)(nil), flags)
 	MustRegisterCommand("stopNotifyBlocks", (*StopNotifyBlocksCmd)(nil), flags)
 	MustRegisterCommand("stopNotifyChainChanges", (*StopNotifyChainChangesCmd)(nil), flags)
 	MustRegisterCommand("stopNotifyNewTransactions", (*StopNotifyNewTransactionsCmd)(nil), flags)
+	MustRegisterCommand("stopNotifyTxStatus", (*StopNotifyTxStatusCmd)(nil), flags)
 	MustRegisterCommand("rescanBlocks", (*RescanBlocksCmd)(nil), flags)
 }
@@ -137,6 +137,32 @@ func TestRPCServerWebsocketCommands(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"stopNotifyNewTransactions","params":[],"id":1}`,
 			unmarshalled: &rpcmodel.StopNotifyNewTransactionsCmd{},
 		},
+		{
+			name: "notifyTxStatus",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("notifyTxStatus", `["123","456"]`)
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewNotifyTxStatusCmd([]string{"123", "456"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"notifyTxStatus","params":[["123","456"]],"id":1}`,
+			unmarshalled: &rpcmodel.NotifyTxStatusCmd{
+				TxIDs: []string{"123", "456"},
+			},
+		},
+		{
+			name: "stopNotifyTxStatus",
+			newCmd: func() (interface{}, error) {
+				return rpcmodel.NewCommand("stopNotifyTxStatus", `["123"]`)
+			},
+			staticCmd: func() interface{} {
+				return rpcmodel.NewStopNotifyTxStatusCmd([]string{"123"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"stopNotifyTxStatus","params":[["123"]],"id":1}`,
+			unmarshalled: &rpcmodel.StopNotifyTxStatusCmd{
+				TxIDs: []string{"123"},
+			},
+		},
 		{
 			name: "loadTxFilter",
 			newCmd: func() (interface{}, error) {
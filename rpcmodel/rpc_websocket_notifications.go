@@ -23,6 +23,28 @@ const (
 	// more details in the notification.
 	TxAcceptedVerboseNtfnMethod = "txAcceptedVerbose"
 
+	// TxReplacedNtfnMethod is the method used for notifications from the
+	// kaspa rpc server that a transaction in the mempool has been replaced
+	// by a conflicting transaction that pays a higher fee.
+	TxReplacedNtfnMethod = "txReplaced"
+
+	// TxRemovedNtfnMethod is the method used for notifications from the
+	// kaspa rpc server that a transaction has been dropped from the mempool
+	// without being included in a block for any reason other than being
+	// replaced.
+	TxRemovedNtfnMethod = "txRemoved"
+
+	// TxRemovedFromMempoolNtfnMethod is the method used for notifications
+	// from the kaspa rpc server that a transaction whose status the client
+	// is watching has been dropped from the mempool.
+	TxRemovedFromMempoolNtfnMethod = "txRemovedFromMempool"
+
+	// TxAcceptedByVirtualNtfnMethod is the method used for notifications
+	// from the kaspa rpc server that a transaction whose status the client
+	// is watching has been accepted by a block in the selected parent chain
+	// of the virtual block.
+	TxAcceptedByVirtualNtfnMethod = "txAcceptedByVirtual"
+
 	// RelevantTxAcceptedNtfnMethod is the new method used for notifications
 	// from the kaspa rpc server that inform a client that a transaction that
 	// matches the loaded filter was accepted by the mempool.
@@ -113,6 +135,68 @@ func NewTxAcceptedVerboseNtfn(rawTx TxRawResult) *TxAcceptedVerboseNtfn {
 	}
 }
 
+// TxReplacedNtfn defines the txReplaced JSON-RPC notification.
+type TxReplacedNtfn struct {
+	ReplacedTxID    string
+	ReplacementTxID string
+}
+
+// NewTxReplacedNtfn returns a new instance which can be used to issue a
+// txReplaced JSON-RPC notification.
+func NewTxReplacedNtfn(replacedTxID string, replacementTxID string) *TxReplacedNtfn {
+	return &TxReplacedNtfn{
+		ReplacedTxID:    replacedTxID,
+		ReplacementTxID: replacementTxID,
+	}
+}
+
+// TxRemovedNtfn defines the txRemoved JSON-RPC notification.
+type TxRemovedNtfn struct {
+	TxID   string
+	Reason string
+}
+
+// NewTxRemovedNtfn returns a new instance which can be used to issue a
+// txRemoved JSON-RPC notification.
+func NewTxRemovedNtfn(txID string, reason string) *TxRemovedNtfn {
+	return &TxRemovedNtfn{
+		TxID:   txID,
+		Reason: reason,
+	}
+}
+
+// TxRemovedFromMempoolNtfn defines the txRemovedFromMempool JSON-RPC
+// notification.
+type TxRemovedFromMempoolNtfn struct {
+	TxID   string
+	Reason string
+}
+
+// NewTxRemovedFromMempoolNtfn returns a new instance which can be used to
+// issue a txRemovedFromMempool JSON-RPC notification.
+func NewTxRemovedFromMempoolNtfn(txID string, reason string) *TxRemovedFromMempoolNtfn {
+	return &TxRemovedFromMempoolNtfn{
+		TxID:   txID,
+		Reason: reason,
+	}
+}
+
+// TxAcceptedByVirtualNtfn defines the txAcceptedByVirtual JSON-RPC
+// notification.
+type TxAcceptedByVirtualNtfn struct {
+	TxID               string
+	AcceptingBlockHash string
+}
+
+// NewTxAcceptedByVirtualNtfn returns a new instance which can be used to
+// issue a txAcceptedByVirtual JSON-RPC notification.
+func NewTxAcceptedByVirtualNtfn(txID string, acceptingBlockHash string) *TxAcceptedByVirtualNtfn {
+	return &TxAcceptedByVirtualNtfn{
+		TxID:               txID,
+		AcceptingBlockHash: acceptingBlockHash,
+	}
+}
+
 // RelevantTxAcceptedNtfn defines the parameters to the relevantTxAccepted
 // JSON-RPC notification.
 type RelevantTxAcceptedNtfn struct {
@@ -133,6 +217,10 @@ func init() {
 	MustRegisterCommand(FilteredBlockAddedNtfnMethod, (*FilteredBlockAddedNtfn)(nil), flags)
 	MustRegisterCommand(TxAcceptedNtfnMethod, (*TxAcceptedNtfn)(nil), flags)
 	MustRegisterCommand(TxAcceptedVerboseNtfnMethod, (*TxAcceptedVerboseNtfn)(nil), flags)
+	MustRegisterCommand(TxReplacedNtfnMethod, (*TxReplacedNtfn)(nil), flags)
+	MustRegisterCommand(TxRemovedNtfnMethod, (*TxRemovedNtfn)(nil), flags)
+	MustRegisterCommand(TxRemovedFromMempoolNtfnMethod, (*TxRemovedFromMempoolNtfn)(nil), flags)
+	MustRegisterCommand(TxAcceptedByVirtualNtfnMethod, (*TxAcceptedByVirtualNtfn)(nil), flags)
 	MustRegisterCommand(RelevantTxAcceptedNtfnMethod, (*RelevantTxAcceptedNtfn)(nil), flags)
 	MustRegisterCommand(ChainChangedNtfnMethod, (*ChainChangedNtfn)(nil), flags)
 }
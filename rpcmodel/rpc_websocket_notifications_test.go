@@ -127,6 +127,62 @@ func TestRPCServerWebsocketNotifications(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "txReplaced",
+			newNtfn: func() (interface{}, error) {
+				return rpcmodel.NewCommand("txReplaced", "123", "456")
+			},
+			staticNtfn: func() interface{} {
+				return rpcmodel.NewTxReplacedNtfn("123", "456")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"txReplaced","params":["123","456"],"id":null}`,
+			unmarshalled: &rpcmodel.TxReplacedNtfn{
+				ReplacedTxID:    "123",
+				ReplacementTxID: "456",
+			},
+		},
+		{
+			name: "txRemoved",
+			newNtfn: func() (interface{}, error) {
+				return rpcmodel.NewCommand("txRemoved", "123", "expired")
+			},
+			staticNtfn: func() interface{} {
+				return rpcmodel.NewTxRemovedNtfn("123", "expired")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"txRemoved","params":["123","expired"],"id":null}`,
+			unmarshalled: &rpcmodel.TxRemovedNtfn{
+				TxID:   "123",
+				Reason: "expired",
+			},
+		},
+		{
+			name: "txRemovedFromMempool",
+			newNtfn: func() (interface{}, error) {
+				return rpcmodel.NewCommand("txRemovedFromMempool", "123", "doubleSpend")
+			},
+			staticNtfn: func() interface{} {
+				return rpcmodel.NewTxRemovedFromMempoolNtfn("123", "doubleSpend")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"txRemovedFromMempool","params":["123","doubleSpend"],"id":null}`,
+			unmarshalled: &rpcmodel.TxRemovedFromMempoolNtfn{
+				TxID:   "123",
+				Reason: "doubleSpend",
+			},
+		},
+		{
+			name: "txAcceptedByVirtual",
+			newNtfn: func() (interface{}, error) {
+				return rpcmodel.NewCommand("txAcceptedByVirtual", "123", "456")
+			},
+			staticNtfn: func() interface{} {
+				return rpcmodel.NewTxAcceptedByVirtualNtfn("123", "456")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"txAcceptedByVirtual","params":["123","456"],"id":null}`,
+			unmarshalled: &rpcmodel.TxAcceptedByVirtualNtfn{
+				TxID:               "123",
+				AcceptingBlockHash: "456",
+			},
+		},
 		{
 			name: "relevantTxAccepted",
 			newNtfn: func() (interface{}, error) {
@@ -0,0 +1,202 @@
+package p2p
+
+import (
+	"bytes"
+	"encoding/gob"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrAlreadyBanned is an error that is thrown if the subnet is already
+	// banned.
+	ErrAlreadyBanned = errors.New("subnet is already banned")
+
+	// ErrNotBanned is an error that is thrown if the subnet is not banned.
+	ErrNotBanned = errors.New("subnet is not banned")
+)
+
+// BannedSubnet is a subnet whose peers are not allowed to connect to the
+// server until its ban expires.
+type BannedSubnet struct {
+	Subnet    *net.IPNet
+	Reason    string
+	CreatedAt time.Time
+	Expiry    time.Time
+}
+
+// isExpired returns whether the ban has expired at the given time.
+func (bs *BannedSubnet) isExpired(now time.Time) bool {
+	return !now.Before(bs.Expiry)
+}
+
+// serializedBannedSubnet is the gob serialization of a BannedSubnet.
+type serializedBannedSubnet struct {
+	Subnet    string
+	Reason    string
+	CreatedAt int64
+	Expiry    int64
+}
+
+// banList holds the banned subnets. It is only accessed from the peerHandler
+// goroutine, so it doesn't need a lock.
+type banList struct {
+	subnets map[string]*BannedSubnet
+}
+
+// ParseSubnet parses a subnet in CIDR notation, such as 10.0.0.0/8, or a
+// single IP address, which is treated as a subnet of its own.
+func ParseSubnet(subnet string) (*net.IPNet, error) {
+	_, ipNet, err := net.ParseCIDR(subnet)
+	if err == nil {
+		return ipNet, nil
+	}
+	ip := net.ParseIP(subnet)
+	if ip == nil {
+		return nil, errors.Errorf("invalid IP address or subnet %s", subnet)
+	}
+	return singleIPSubnet(ip), nil
+}
+
+// singleIPSubnet returns the subnet which contains only the given IP.
+func singleIPSubnet(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(8*net.IPv4len, 8*net.IPv4len)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(8*net.IPv6len, 8*net.IPv6len)}
+}
+
+// newBanList returns a new empty ban list.
+func newBanList() *banList {
+	return &banList{subnets: make(map[string]*BannedSubnet)}
+}
+
+// loadBanList loads the banned subnets from the database. If they are
+// missing, an empty ban list is returned.
+func loadBanList() (*banList, error) {
+	bans := newBanList()
+
+	serializedBans, err := dbaccess.FetchBans(dbaccess.NoTx())
+	if dbaccess.IsNotFoundError(err) {
+		return bans, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var serializedSubnets []*serializedBannedSubnet
+	err = gob.NewDecoder(bytes.NewReader(serializedBans)).Decode(&serializedSubnets)
+	if err != nil {
+		return nil, errors.Wrap(err, "error deserializing bans")
+	}
+	for _, serializedSubnet := range serializedSubnets {
+		_, subnet, err := net.ParseCIDR(serializedSubnet.Subnet)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error deserializing banned subnet %s",
+				serializedSubnet.Subnet)
+		}
+		bans.subnets[subnet.String()] = &BannedSubnet{
+			Subnet:    subnet,
+			Reason:    serializedSubnet.Reason,
+			CreatedAt: time.Unix(serializedSubnet.CreatedAt, 0),
+			Expiry:    time.Unix(serializedSubnet.Expiry, 0),
+		}
+	}
+	bans.removeExpired()
+	return bans, nil
+}
+
+// save stores the banned subnets in the database.
+func (bl *banList) save() error {
+	serializedSubnets := make([]*serializedBannedSubnet, 0, len(bl.subnets))
+	for _, bannedSubnet := range bl.subnets {
+		serializedSubnets = append(serializedSubnets, &serializedBannedSubnet{
+			Subnet:    bannedSubnet.Subnet.String(),
+			Reason:    bannedSubnet.Reason,
+			CreatedAt: bannedSubnet.CreatedAt.Unix(),
+			Expiry:    bannedSubnet.Expiry.Unix(),
+		})
+	}
+
+	w := &bytes.Buffer{}
+	err := gob.NewEncoder(w).Encode(serializedSubnets)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode bans")
+	}
+	return dbaccess.StoreBans(dbaccess.NoTx(), w.Bytes())
+}
+
+// ban bans the given subnet until the given expiry. It returns
+// ErrAlreadyBanned if the subnet is already banned until a later time, and
+// extends the ban otherwise.
+func (bl *banList) ban(subnet *net.IPNet, reason string, expiry time.Time) error {
+	key := subnet.String()
+	if bannedSubnet, ok := bl.subnets[key]; ok && !bannedSubnet.Expiry.Before(expiry) {
+		return errors.WithStack(ErrAlreadyBanned)
+	}
+	bl.subnets[key] = &BannedSubnet{
+		Subnet:    subnet,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+		Expiry:    expiry,
+	}
+	return bl.save()
+}
+
+// unban removes the ban of the given subnet. It returns ErrNotBanned if
+// the subnet isn't banned.
+func (bl *banList) unban(subnet *net.IPNet) error {
+	key := subnet.String()
+	if _, ok := bl.subnets[key]; !ok {
+		return errors.WithStack(ErrNotBanned)
+	}
+	delete(bl.subnets, key)
+	return bl.save()
+}
+
+// clear removes all the bans.
+func (bl *banList) clear() error {
+	bl.subnets = make(map[string]*BannedSubnet)
+	return bl.save()
+}
+
+// bannedSubnetOf returns the ban of a subnet which contains the given IP,
+// or nil if the IP is not banned.
+func (bl *banList) bannedSubnetOf(ip net.IP) *BannedSubnet {
+	bl.removeExpired()
+	for _, bannedSubnet := range bl.subnets {
+		if bannedSubnet.Subnet.Contains(ip) {
+			return bannedSubnet
+		}
+	}
+	return nil
+}
+
+// list returns the bans which have not expired, sorted by their subnets.
+func (bl *banList) list() []*BannedSubnet {
+	bl.removeExpired()
+	bannedSubnets := make([]*BannedSubnet, 0, len(bl.subnets))
+	for _, bannedSubnet := range bl.subnets {
+		bannedSubnets = append(bannedSubnets, bannedSubnet)
+	}
+	sort.Slice(bannedSubnets, func(i, j int) bool {
+		return bannedSubnets[i].Subnet.String() < bannedSubnets[j].Subnet.String()
+	})
+	return bannedSubnets
+}
+
+// removeExpired removes the bans that have expired. The expired bans are
+// removed from the database the next time the ban list is saved.
+func (bl *banList) removeExpired() {
+	now := time.Now()
+	for key, bannedSubnet := range bl.subnets {
+		if bannedSubnet.isExpired(now) {
+			srvrLog.Infof("Subnet %s is no longer banned", key)
+			delete(bl.subnets, key)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package p2p
+
+import (
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/pkg/errors"
+)
+
+// newBanListForTest opens a database in a temporary directory and loads the
+// ban list from it. It returns the ban list along with a function that
+// closes the database.
+func newBanListForTest(t *testing.T, testName string) (bans *banList, teardown func()) {
+	dbPath, err := ioutil.TempDir("", testName)
+	if err != nil {
+		t.Fatalf("%s: error creating temporary directory: %s", testName, err)
+	}
+	err = dbaccess.Open(dbPath)
+	if err != nil {
+		t.Fatalf("%s: error creating db: %s", testName, err)
+	}
+	bans, err = loadBanList()
+	if err != nil {
+		t.Fatalf("%s: loadBanList unexpectedly failed: %s", testName, err)
+	}
+	return bans, func() {
+		err := dbaccess.Close()
+		if err != nil {
+			t.Fatalf("%s: error closing the database: %s", testName, err)
+		}
+	}
+}
+
+// parseSubnetForTest parses the given subnet and fails the test if it's
+// invalid.
+func parseSubnetForTest(t *testing.T, subnet string) *net.IPNet {
+	ipNet, err := ParseSubnet(subnet)
+	if err != nil {
+		t.Fatalf("ParseSubnet(%s) unexpectedly failed: %s", subnet, err)
+	}
+	return ipNet
+}
+
+func TestBanListBanAndUnban(t *testing.T) {
+	bans, teardown := newBanListForTest(t, "TestBanListBanAndUnban")
+	defer teardown()
+
+	subnet := parseSubnetForTest(t, "10.0.0.0/8")
+	ip := net.ParseIP("10.1.2.3")
+	expiry := time.Now().Add(time.Hour)
+	err := bans.ban(subnet, "misbehaving", expiry)
+	if err != nil {
+		t.Fatalf("TestBanListBanAndUnban: ban unexpectedly failed: %s", err)
+	}
+	bannedSubnet := bans.bannedSubnetOf(ip)
+	if bannedSubnet == nil || bannedSubnet.Reason != "misbehaving" {
+		t.Fatalf("TestBanListBanAndUnban: expected %s to be banned for "+
+			"misbehaving, but got %v", ip, bannedSubnet)
+	}
+
+	// A ban that doesn't last longer than the existing one is rejected,
+	// and a ban that does extends it
+	err = bans.ban(subnet, "misbehaving again", expiry)
+	if !errors.Is(err, ErrAlreadyBanned) {
+		t.Fatalf("TestBanListBanAndUnban: expected ErrAlreadyBanned, but got %v", err)
+	}
+	laterExpiry := expiry.Add(time.Hour)
+	err = bans.ban(subnet, "misbehaving again", laterExpiry)
+	if err != nil {
+		t.Fatalf("TestBanListBanAndUnban: ban unexpectedly failed: %s", err)
+	}
+	bannedSubnet = bans.bannedSubnetOf(ip)
+	if bannedSubnet == nil || !bannedSubnet.Expiry.Equal(laterExpiry) {
+		t.Fatalf("TestBanListBanAndUnban: expected the ban of %s to be "+
+			"extended, but got %v", ip, bannedSubnet)
+	}
+
+	err = bans.unban(subnet)
+	if err != nil {
+		t.Fatalf("TestBanListBanAndUnban: unban unexpectedly failed: %s", err)
+	}
+	if bannedSubnet := bans.bannedSubnetOf(ip); bannedSubnet != nil {
+		t.Fatalf("TestBanListBanAndUnban: %s is unexpectedly still banned", ip)
+	}
+	err = bans.unban(subnet)
+	if !errors.Is(err, ErrNotBanned) {
+		t.Fatalf("TestBanListBanAndUnban: expected ErrNotBanned, but got %v", err)
+	}
+}
+
+func TestBanListSubnetMatching(t *testing.T) {
+	bans, teardown := newBanListForTest(t, "TestBanListSubnetMatching")
+	defer teardown()
+
+	expiry := time.Now().Add(time.Hour)
+	for _, subnet := range []string{"10.0.0.0/8", "192.168.1.1", "2001:db8::/32"} {
+		err := bans.ban(parseSubnetForTest(t, subnet), "", expiry)
+		if err != nil {
+			t.Fatalf("TestBanListSubnetMatching: ban of %s unexpectedly failed: %s", subnet, err)
+		}
+	}
+
+	tests := []struct {
+		ip             string
+		expectedSubnet string
+	}{
+		{ip: "10.0.0.1", expectedSubnet: "10.0.0.0/8"},
+		{ip: "10.255.255.255", expectedSubnet: "10.0.0.0/8"},
+		{ip: "::ffff:10.1.2.3", expectedSubnet: "10.0.0.0/8"},
+		{ip: "11.0.0.1"},
+		{ip: "192.168.1.1", expectedSubnet: "192.168.1.1/32"},
+		{ip: "192.168.1.2"},
+		{ip: "2001:db8::1", expectedSubnet: "2001:db8::/32"},
+		{ip: "2001:db9::1"},
+	}
+	for _, test := range tests {
+		bannedSubnet := bans.bannedSubnetOf(net.ParseIP(test.ip))
+		if test.expectedSubnet == "" {
+			if bannedSubnet != nil {
+				t.Errorf("TestBanListSubnetMatching: expected %s not to be "+
+					"banned, but it's banned by %s", test.ip, bannedSubnet.Subnet)
+			}
+			continue
+		}
+		if bannedSubnet == nil || bannedSubnet.Subnet.String() != test.expectedSubnet {
+			t.Errorf("TestBanListSubnetMatching: expected %s to be banned "+
+				"by %s, but got %v", test.ip, test.expectedSubnet, bannedSubnet)
+		}
+	}
+
+	_, err := ParseSubnet("10.0.0.0/33")
+	if err == nil {
+		t.Fatalf("TestBanListSubnetMatching: ParseSubnet unexpectedly " +
+			"succeeded for an invalid subnet")
+	}
+}
+
+func TestBanListExpiry(t *testing.T) {
+	bans, teardown := newBanListForTest(t, "TestBanListExpiry")
+	defer teardown()
+
+	expiredSubnet := parseSubnetForTest(t, "10.0.0.0/8")
+	err := bans.ban(expiredSubnet, "", time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatalf("TestBanListExpiry: ban unexpectedly failed: %s", err)
+	}
+	activeSubnet := parseSubnetForTest(t, "11.0.0.0/8")
+	err = bans.ban(activeSubnet, "", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("TestBanListExpiry: ban unexpectedly failed: %s", err)
+	}
+
+	if bannedSubnet := bans.bannedSubnetOf(net.ParseIP("10.0.0.1")); bannedSubnet != nil {
+		t.Fatalf("TestBanListExpiry: the expired ban of %s unexpectedly "+
+			"still applies", bannedSubnet.Subnet)
+	}
+	list := bans.list()
+	if len(list) != 1 || list[0].Subnet.String() != activeSubnet.String() {
+		t.Fatalf("TestBanListExpiry: expected only %s to be listed, but got %v",
+			activeSubnet, list)
+	}
+
+	// An expired subnet can be banned again
+	err = bans.ban(expiredSubnet, "", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("TestBanListExpiry: ban unexpectedly failed: %s", err)
+	}
+}
+
+func TestBanListPersistence(t *testing.T) {
+	bans, teardown := newBanListForTest(t, "TestBanListPersistence")
+	defer teardown()
+
+	expiry := time.Now().Add(time.Hour)
+	err := bans.ban(parseSubnetForTest(t, "10.0.0.0/8"), "first", expiry)
+	if err != nil {
+		t.Fatalf("TestBanListPersistence: ban unexpectedly failed: %s", err)
+	}
+	err = bans.ban(parseSubnetForTest(t, "2001:db8::1"), "second", expiry)
+	if err != nil {
+		t.Fatalf("TestBanListPersistence: ban unexpectedly failed: %s", err)
+	}
+	err = bans.ban(parseSubnetForTest(t, "11.0.0.0/8"), "expired", time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatalf("TestBanListPersistence: ban unexpectedly failed: %s", err)
+	}
+
+	// The expired ban is not loaded, and the rest are loaded as they were
+	// banned, up to a second, which is the precision they are stored in
+	loadedBans, err := loadBanList()
+	if err != nil {
+		t.Fatalf("TestBanListPersistence: loadBanList unexpectedly failed: %s", err)
+	}
+	loadedList := loadedBans.list()
+	if len(loadedList) != 2 {
+		t.Fatalf("TestBanListPersistence: expected 2 bans to be loaded, "+
+			"but got %v", loadedList)
+	}
+	for i, expected := range bans.list() {
+		loaded := loadedList[i]
+		if loaded.Subnet.String() != expected.Subnet.String() ||
+			loaded.Reason != expected.Reason ||
+			loaded.CreatedAt.Unix() != expected.CreatedAt.Unix() ||
+			loaded.Expiry.Unix() != expected.Expiry.Unix() {
+			t.Fatalf("TestBanListPersistence: expected loaded ban %v, but got %v",
+				expected, loaded)
+		}
+	}
+
+	err = bans.clear()
+	if err != nil {
+		t.Fatalf("TestBanListPersistence: clear unexpectedly failed: %s", err)
+	}
+	loadedBans, err = loadBanList()
+	if err != nil {
+		t.Fatalf("TestBanListPersistence: loadBanList unexpectedly failed: %s", err)
+	}
+	if loadedList := loadedBans.list(); len(loadedList) != 0 {
+		t.Fatalf("TestBanListPersistence: expected no bans after clearing, "+
+			"but got %v", loadedList)
+	}
+}
@@ -0,0 +1,201 @@
+package p2p
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"github.com/kaspanet/kaspad/addrmgr"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+const (
+	// evictionProtectedNetGroups is the number of network groups whose
+	// longest connected inbound peer is protected from eviction.
+	evictionProtectedNetGroups = 4
+
+	// evictionProtectedLowPing is the number of inbound peers with the
+	// lowest ping time that are protected from eviction.
+	evictionProtectedLowPing = 8
+
+	// evictionProtectedTxRelay is the number of inbound peers that most
+	// recently relayed us a new transaction that are protected from
+	// eviction.
+	evictionProtectedTxRelay = 4
+
+	// evictionProtectedBlockRelay is the number of inbound peers that most
+	// recently relayed us a new block that are protected from eviction.
+	evictionProtectedBlockRelay = 4
+)
+
+// evictionCandidate is an inbound peer that may be evicted to make room for a
+// new inbound peer. It's implemented by peer.Peer.
+type evictionCandidate interface {
+	ID() int32
+	NA() *wire.NetAddress
+	LastPingMicros() int64
+	LastBlockTime() time.Time
+	LastTxTime() time.Time
+	TimeConnected() time.Time
+}
+
+// selectPeerToEvict selects which of the given inbound peers should be
+// evicted to make room for a new inbound peer, or returns nil if all of them
+// are protected from eviction.
+//
+// An attacker can cheaply take up inbound slots, but it's much harder for it
+// to control peers from many network groups, to be closer to us than honest
+// peers, to relay us new blocks and transactions before honest peers do, or
+// to have connected long before the attack started. Therefore, the peers that
+// stand out in any of these are protected, and the youngest peer of the
+// network group with the most remaining peers is evicted.
+//
+// netGroupKey is a secret random key which determines which network groups
+// are protected, so that an attacker can't predict it.
+func selectPeerToEvict(candidates []evictionCandidate, netGroupKey uint64) evictionCandidate {
+	remaining := make([]evictionCandidate, len(candidates))
+	copy(remaining, candidates)
+
+	// protect sorts the remaining peers from the most deserving of
+	// protection to the least, and removes up to count of the most
+	// deserving ones for which isDeserving returns true.
+	protect := func(count int, isMoreDeserving func(a, b evictionCandidate) bool,
+		isDeserving func(c evictionCandidate) bool) {
+
+		sort.Slice(remaining, func(i, j int) bool {
+			a, b := remaining[i], remaining[j]
+			if isDeserving(a) != isDeserving(b) {
+				return isDeserving(a)
+			}
+			if isMoreDeserving(a, b) != isMoreDeserving(b, a) {
+				return isMoreDeserving(a, b)
+			}
+			return a.ID() < b.ID()
+		})
+		protected := 0
+		for protected < count && protected < len(remaining) && isDeserving(remaining[protected]) {
+			protected++
+		}
+		remaining = remaining[protected:]
+	}
+	always := func(evictionCandidate) bool { return true }
+
+	// Protect the longest connected peer of a few network groups. The
+	// network groups are ordered by their keyed hash, so an attacker
+	// can't know which of them to connect from in order to be protected.
+	netGroups := make(map[int32]string, len(remaining))
+	oldestOfNetGroup := make(map[string]evictionCandidate)
+	netGroupHashes := make(map[string][]byte)
+	for _, candidate := range remaining {
+		netGroup := addrmgr.GroupKey(candidate.NA())
+		netGroups[candidate.ID()] = netGroup
+		oldest, ok := oldestOfNetGroup[netGroup]
+		if !ok || isYounger(oldest, candidate) {
+			oldestOfNetGroup[netGroup] = candidate
+		}
+		netGroupHashes[netGroup] = keyedNetGroupHash(netGroup, netGroupKey)
+	}
+	protect(evictionProtectedNetGroups, func(a, b evictionCandidate) bool {
+		return bytes.Compare(netGroupHashes[netGroups[a.ID()]], netGroupHashes[netGroups[b.ID()]]) < 0
+	}, func(c evictionCandidate) bool {
+		return oldestOfNetGroup[netGroups[c.ID()]] == c
+	})
+
+	// Protect the peers with the lowest ping time. Peers that haven't
+	// answered a ping yet are not.
+	protect(evictionProtectedLowPing, func(a, b evictionCandidate) bool {
+		return a.LastPingMicros() < b.LastPingMicros()
+	}, func(c evictionCandidate) bool {
+		return c.LastPingMicros() > 0
+	})
+
+	// Protect the peers that most recently relayed us new transactions
+	// and new blocks.
+	protect(evictionProtectedTxRelay, func(a, b evictionCandidate) bool {
+		return a.LastTxTime().After(b.LastTxTime())
+	}, func(c evictionCandidate) bool {
+		return !c.LastTxTime().IsZero()
+	})
+	protect(evictionProtectedBlockRelay, func(a, b evictionCandidate) bool {
+		return a.LastBlockTime().After(b.LastBlockTime())
+	}, func(c evictionCandidate) bool {
+		return !c.LastBlockTime().IsZero()
+	})
+
+	// Protect half of the remaining peers, choosing the ones that have
+	// been connected the longest.
+	protect(len(remaining)/2, func(a, b evictionCandidate) bool {
+		return a.TimeConnected().Before(b.TimeConnected())
+	}, always)
+
+	if len(remaining) == 0 {
+		return nil
+	}
+
+	// Find the network group with the most remaining peers, preferring
+	// the one with the youngest peer in case of a tie, and evict its
+	// youngest peer.
+	youngestOf := func(peers []evictionCandidate) evictionCandidate {
+		youngest := peers[0]
+		for _, candidate := range peers[1:] {
+			if isYounger(candidate, youngest) {
+				youngest = candidate
+			}
+		}
+		return youngest
+	}
+	netGroupPeers := make(map[string][]evictionCandidate)
+	for _, candidate := range remaining {
+		netGroup := netGroups[candidate.ID()]
+		netGroupPeers[netGroup] = append(netGroupPeers[netGroup], candidate)
+	}
+	var toEvict evictionCandidate
+	largestNetGroupSize := 0
+	for _, peers := range netGroupPeers {
+		youngest := youngestOf(peers)
+		if len(peers) > largestNetGroupSize ||
+			(len(peers) == largestNetGroupSize && isYounger(youngest, toEvict)) {
+
+			largestNetGroupSize = len(peers)
+			toEvict = youngest
+		}
+	}
+	return toEvict
+}
+
+// isYounger returns whether peer a connected after peer b.
+func isYounger(a, b evictionCandidate) bool {
+	if !a.TimeConnected().Equal(b.TimeConnected()) {
+		return a.TimeConnected().After(b.TimeConnected())
+	}
+	return a.ID() > b.ID()
+}
+
+// keyedNetGroupHash returns the hash of the given network group, keyed with
+// netGroupKey.
+func keyedNetGroupHash(netGroup string, netGroupKey uint64) []byte {
+	var key [8]byte
+	binary.LittleEndian.PutUint64(key[:], netGroupKey)
+	hash := sha256.Sum256(append(key[:], netGroup...))
+	return hash[:]
+}
+
+// selectInboundPeerToEvict selects which inbound peer should be evicted to
+// make room for a new inbound peer, or returns nil if none should. Whitelisted
+// peers are never evicted.
+func (s *Server) selectInboundPeerToEvict(state *peerState) *Peer {
+	candidates := make([]evictionCandidate, 0, len(state.inboundPeers))
+	for _, sp := range state.inboundPeers {
+		if sp.isWhitelisted {
+			continue
+		}
+		candidates = append(candidates, sp.Peer)
+	}
+	toEvict := selectPeerToEvict(candidates, s.evictionNetGroupKey)
+	if toEvict == nil {
+		return nil
+	}
+	return state.inboundPeers[toEvict.ID()]
+}
@@ -0,0 +1,223 @@
+package p2p
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/kaspanet/kaspad/config"
+	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// setMainnetConfig sets the active config to use mainnet, so network groups
+// are determined like they are on mainnet. It returns a function that
+// restores the original config.
+func setMainnetConfig() (restore func()) {
+	originalActiveCfg := config.ActiveConfig()
+	config.SetActiveConfig(&config.Config{
+		Flags: &config.Flags{
+			NetworkFlags: config.NetworkFlags{
+				ActiveNetParams: &dagconfig.MainnetParams},
+		},
+	})
+	return func() {
+		config.SetActiveConfig(originalActiveCfg)
+	}
+}
+
+// mockEvictionCandidate is a mock of an inbound peer.
+type mockEvictionCandidate struct {
+	id            int32
+	na            *wire.NetAddress
+	pingMicros    int64
+	lastBlockTime time.Time
+	lastTxTime    time.Time
+	timeConnected time.Time
+}
+
+func (m *mockEvictionCandidate) ID() int32                { return m.id }
+func (m *mockEvictionCandidate) NA() *wire.NetAddress     { return m.na }
+func (m *mockEvictionCandidate) LastPingMicros() int64    { return m.pingMicros }
+func (m *mockEvictionCandidate) LastBlockTime() time.Time { return m.lastBlockTime }
+func (m *mockEvictionCandidate) LastTxTime() time.Time    { return m.lastTxTime }
+func (m *mockEvictionCandidate) TimeConnected() time.Time { return m.timeConnected }
+
+// newMockEvictionCandidates returns count mock peers connecting from the
+// given IP prefix, which determines their network group. The peers are
+// assigned consecutive IDs starting from firstID, and connected at
+// consecutive minutes starting from connectedAt.
+func newMockEvictionCandidates(firstID int32, count int, ipPrefix string,
+	connectedAt time.Time) []*mockEvictionCandidate {
+
+	candidates := make([]*mockEvictionCandidate, count)
+	for i := range candidates {
+		ip := net.ParseIP(fmt.Sprintf("%s.%d", ipPrefix, i+1))
+		candidates[i] = &mockEvictionCandidate{
+			id:            firstID + int32(i),
+			na:            wire.NewNetAddressIPPort(ip, 16111, wire.SFNodeNetwork),
+			timeConnected: connectedAt.Add(time.Duration(i) * time.Minute),
+		}
+	}
+	return candidates
+}
+
+func toEvictionCandidates(mocks ...[]*mockEvictionCandidate) []evictionCandidate {
+	var candidates []evictionCandidate
+	for _, m := range mocks {
+		for _, candidate := range m {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// TestSelectPeerToEvictAllProtected ensures no peer is evicted when all of
+// them are protected.
+func TestSelectPeerToEvictAllProtected(t *testing.T) {
+	defer setMainnetConfig()()
+
+	if toEvict := selectPeerToEvict(nil, 0); toEvict != nil {
+		t.Errorf("selectPeerToEvict: evicted peer %d out of no peers", toEvict.ID())
+	}
+
+	// Peers from distinct network groups are protected.
+	now := time.Now()
+	var mocks [][]*mockEvictionCandidate
+	for i := 0; i < evictionProtectedNetGroups; i++ {
+		mocks = append(mocks, newMockEvictionCandidates(int32(i), 1, fmt.Sprintf("%d.1.1", i+1), now))
+	}
+	if toEvict := selectPeerToEvict(toEvictionCandidates(mocks...), 0); toEvict != nil {
+		t.Errorf("selectPeerToEvict: evicted peer %d from a distinct network group",
+			toEvict.ID())
+	}
+
+	// A single peer is protected for being the longest connected peer of
+	// its network group.
+	single := newMockEvictionCandidates(0, 1, "1.1.1", now)
+	if toEvict := selectPeerToEvict(toEvictionCandidates(single), 0); toEvict != nil {
+		t.Errorf("selectPeerToEvict: evicted a single peer")
+	}
+}
+
+// TestSelectPeerToEvictProtections ensures that peers that have a low ping
+// time or that relayed useful blocks or transactions are protected from
+// eviction.
+func TestSelectPeerToEvictProtections(t *testing.T) {
+	defer setMainnetConfig()()
+
+	now := time.Now()
+	tests := []struct {
+		name    string
+		protect func(youngest *mockEvictionCandidate)
+	}{
+		{
+			name:    "no protection",
+			protect: func(*mockEvictionCandidate) {},
+		},
+		{
+			name: "low ping",
+			protect: func(youngest *mockEvictionCandidate) {
+				youngest.pingMicros = 50000
+			},
+		},
+		{
+			name: "tx relay",
+			protect: func(youngest *mockEvictionCandidate) {
+				youngest.lastTxTime = now
+			},
+		},
+		{
+			name: "block relay",
+			protect: func(youngest *mockEvictionCandidate) {
+				youngest.lastBlockTime = now
+			},
+		},
+	}
+
+	for _, test := range tests {
+		// All the peers are from the same network group, so the
+		// youngest peer would normally be evicted.
+		mocks := newMockEvictionCandidates(0, 30, "1.1.1", now.Add(-time.Hour))
+		youngest := mocks[len(mocks)-1]
+		secondYoungest := mocks[len(mocks)-2]
+		test.protect(youngest)
+
+		expected := secondYoungest
+		if test.name == "no protection" {
+			expected = youngest
+		}
+		toEvict := selectPeerToEvict(toEvictionCandidates(mocks), 0)
+		if toEvict != expected {
+			t.Errorf("%s: selectPeerToEvict: evicted %v, want peer %d",
+				test.name, toEvict, expected.ID())
+		}
+	}
+}
+
+// TestSelectPeerToEvictLargestNetGroup ensures that the youngest peer of the
+// network group with the most unprotected peers is evicted, rather than the
+// youngest peer overall.
+func TestSelectPeerToEvictLargestNetGroup(t *testing.T) {
+	defer setMainnetConfig()()
+
+	now := time.Now()
+	smallGroup := newMockEvictionCandidates(0, 3, "1.1.1", now)
+	largeGroup := newMockEvictionCandidates(10, 5, "2.1.1", now)
+	setConnectedMinutesAgo := func(mocks []*mockEvictionCandidate, minutesAgo ...int) {
+		for i, minutes := range minutesAgo {
+			mocks[i].timeConnected = now.Add(-time.Duration(minutes) * time.Minute)
+		}
+	}
+	// After protecting the longest connected peer of each network group,
+	// and the longest connected half of the rest, one peer of the small
+	// group remains, which is the youngest overall, and two of the large
+	// group remain.
+	setConnectedMinutesAgo(smallGroup, 100, 5, 1)
+	setConnectedMinutesAgo(largeGroup, 200, 190, 180, 3, 2)
+
+	for netGroupKey := uint64(0); netGroupKey < 10; netGroupKey++ {
+		toEvict := selectPeerToEvict(toEvictionCandidates(smallGroup, largeGroup), netGroupKey)
+		if toEvict != largeGroup[4] {
+			t.Errorf("selectPeerToEvict: evicted %v, want peer %d",
+				toEvict, largeGroup[4].ID())
+		}
+	}
+}
+
+// TestSelectPeerToEvictAttack ensures that an attacker that connects many
+// peers from a single network group can't get honest peers that stand out
+// evicted.
+func TestSelectPeerToEvictAttack(t *testing.T) {
+	defer setMainnetConfig()()
+
+	now := time.Now()
+
+	// The honest peers connected before the attack started, from
+	// distinct network groups, and have a low ping time.
+	var honest []*mockEvictionCandidate
+	for i := 0; i < 12; i++ {
+		peers := newMockEvictionCandidates(int32(i), 1, fmt.Sprintf("%d.1.1", i+1),
+			now.Add(-time.Hour))
+		peers[0].pingMicros = int64(50000 + i)
+		honest = append(honest, peers...)
+	}
+
+	// The attacker's peers connect from a single network group, and
+	// relay transactions, but have a high ping time.
+	attacker := newMockEvictionCandidates(100, 40, "100.1.1", now.Add(-time.Hour/2))
+	for _, peer := range attacker {
+		peer.pingMicros = 500000
+		peer.lastTxTime = now
+	}
+
+	candidates := toEvictionCandidates(honest, attacker)
+	for netGroupKey := uint64(0); netGroupKey < 100; netGroupKey++ {
+		toEvict := selectPeerToEvict(candidates, netGroupKey)
+		if toEvict == nil || toEvict.ID() < 100 {
+			t.Fatalf("selectPeerToEvict: evicted %v, want one of the attacker's peers",
+				toEvict)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package p2p
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kaspanet/go-secp256k1"
+	"github.com/kaspanet/kaspad/config"
+	"github.com/kaspanet/kaspad/peer"
+	"github.com/pkg/errors"
+)
+
+// identityKeyFilename is the name of the file in the data directory that
+// holds the hex-encoded private key with which the server identifies itself
+// to peers over encrypted connections.
+const identityKeyFilename = "p2pidentity.key"
+
+// encryptionModes maps the values of the --p2pencryption option to the
+// encryption modes of the peer package.
+var encryptionModes = map[string]peer.EncryptionMode{
+	config.P2PEncryptionDisabled:  peer.EncryptionDisabled,
+	config.P2PEncryptionPreferred: peer.EncryptionPreferred,
+	config.P2PEncryptionRequired:  peer.EncryptionRequired,
+}
+
+// loadIdentityKey loads the identity key of the server from the given data
+// directory, or creates it if it doesn't exist yet.
+func loadIdentityKey(dataDir string) (*secp256k1.PrivateKey, error) {
+	path := filepath.Join(dataDir, identityKeyFilename)
+	serializedKey, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return createIdentityKey(path)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	keyBytes, err := hex.DecodeString(strings.TrimSpace(string(serializedKey)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "the identity key in %s is not hex-encoded", path)
+	}
+	identityKey, err := secp256k1.DeserializePrivateKeyFromSlice(keyBytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "the identity key in %s is invalid", path)
+	}
+	return identityKey, nil
+}
+
+// createIdentityKey generates a new identity key and saves it to the given
+// path, so only the current user may read it.
+func createIdentityKey(path string) (*secp256k1.PrivateKey, error) {
+	identityKey, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	serializedKey := hex.EncodeToString(identityKey.Serialize()[:]) + "\n"
+	err = ioutil.WriteFile(path, []byte(serializedKey), 0600)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	srvrLog.Infof("Created a new P2P identity key in %s", path)
+	return identityKey, nil
+}
+
+// identityPublicKeyString returns the hex-encoded public key of the given
+// identity key, which is what peers put in their --p2pallowedidentity option
+// to allow it.
+func identityPublicKeyString(identityKey *secp256k1.PrivateKey) (string, error) {
+	publicKey, err := identityKey.SchnorrPublicKey()
+	if err != nil {
+		return "", err
+	}
+	serializedPublicKey, err := publicKey.SerializeCompressed()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(serializedPublicKey), nil
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/kaspanet/kaspad/addrmgr"
 	"github.com/kaspanet/kaspad/config"
 	"github.com/kaspanet/kaspad/peer"
+	"github.com/kaspanet/kaspad/util/subnetworkid"
 	"github.com/kaspanet/kaspad/wire"
 	"time"
 )
@@ -12,6 +13,14 @@ import (
 // OnAddr is invoked when a peer receives an addr kaspa message and is
 // used to notify the server about advertised addresses.
 func (sp *Peer) OnAddr(_ *peer.Peer, msg *wire.MsgAddr) {
+	sp.handleAddresses(msg, msg.IncludeAllSubnetworks, msg.SubnetworkID, msg.AddrList)
+}
+
+// handleAddresses handles the addresses that a peer advertised in an addr or
+// addrv2 message.
+func (sp *Peer) handleAddresses(msg wire.Message, includeAllSubnetworks bool,
+	subnetworkID *subnetworkid.SubnetworkID, addrList []*wire.NetAddress) {
+
 	// Ignore addresses when running on the simulation test network. This
 	// helps prevent the network from becoming another public test network
 	// since it will not be able to learn about other peers that have not
@@ -20,25 +29,32 @@ func (sp *Peer) OnAddr(_ *peer.Peer, msg *wire.MsgAddr) {
 		return
 	}
 
-	if len(msg.AddrList) > addrmgr.GetAddrMax {
+	// Addresses are never relayed to or from block relay only peers, so
+	// ignore any addresses they advertise.
+	if sp.blockRelayOnly {
+		peerLog.Debugf("Ignoring addresses from block relay only peer %s", sp)
+		return
+	}
+
+	if len(addrList) > addrmgr.GetAddrMax {
 		sp.AddBanScoreAndPushRejectMsg(msg.Command(), wire.RejectInvalid, nil,
 			peer.BanScoreSentTooManyAddresses, 0, fmt.Sprintf("address count excceeded %d", addrmgr.GetAddrMax))
 		return
 	}
 
-	if msg.IncludeAllSubnetworks {
+	if includeAllSubnetworks {
 		sp.AddBanScoreAndPushRejectMsg(msg.Command(), wire.RejectInvalid, nil,
 			peer.BanScoreMsgAddrWithInvalidSubnetwork, 0,
 			fmt.Sprintf("got unexpected IncludeAllSubnetworks=true in [%s] command", msg.Command()))
 		return
-	} else if !msg.SubnetworkID.IsEqual(config.ActiveConfig().SubnetworkID) && msg.SubnetworkID != nil {
+	} else if !subnetworkID.IsEqual(config.ActiveConfig().SubnetworkID) && subnetworkID != nil {
 		peerLog.Errorf("Only full nodes and %s subnetwork IDs are allowed in [%s] command, but got subnetwork ID %s from %s",
-			config.ActiveConfig().SubnetworkID, msg.Command(), msg.SubnetworkID, sp.Peer)
+			config.ActiveConfig().SubnetworkID, msg.Command(), subnetworkID, sp.Peer)
 		sp.Disconnect()
 		return
 	}
 
-	for _, na := range msg.AddrList {
+	for _, na := range addrList {
 		// Don't add more address if we're disconnecting.
 		if !sp.Connected() {
 			return
@@ -59,5 +75,5 @@ func (sp *Peer) OnAddr(_ *peer.Peer, msg *wire.MsgAddr) {
 	// Add addresses to server address manager. The address manager handles
 	// the details of things such as preventing duplicate addresses, max
 	// addresses, and last seen updates.
-	sp.server.AddrManager.AddAddresses(msg.AddrList, sp.NA(), msg.SubnetworkID)
+	sp.server.AddrManager.AddAddresses(addrList, sp.NA(), subnetworkID)
 }
@@ -0,0 +1,13 @@
+package p2p
+
+import (
+	"github.com/kaspanet/kaspad/peer"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// OnAddrV2 is invoked when a peer receives an addrv2 kaspa message and is
+// used to notify the server about advertised addresses, which may include
+// overlay network addresses.
+func (sp *Peer) OnAddrV2(_ *peer.Peer, msg *wire.MsgAddrV2) {
+	sp.handleAddresses(msg, msg.IncludeAllSubnetworks, msg.SubnetworkID, msg.AddrList)
+}
@@ -30,6 +30,16 @@ func (sp *Peer) OnBlockLocator(_ *peer.Peer, msg *wire.MsgBlockLocator) {
 			return
 		}
 
+		// When syncing headers-first, the headers of the missing blocks
+		// are requested and validated before the blocks themselves.
+		if sp.server.SyncManager.ShouldSyncHeadersFirst(sp.Peer) {
+			if highHash.IsEqual(sp.Peer.SelectedTipHash()) {
+				return
+			}
+			sp.Peer.PushGetHeadersMsg(highHash, sp.Peer.SelectedTipHash())
+			return
+		}
+
 		// We send the highHash as the GetBlockInvsMsg's lowHash here.
 		// This is not a mistake. The invs we desire start from the highest
 		// hash that we know of and end at the highest hash that the peer
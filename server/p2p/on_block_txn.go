@@ -0,0 +1,13 @@
+package p2p
+
+import (
+	"github.com/kaspanet/kaspad/peer"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// OnBlockTxn is invoked when a peer receives a blocktxn kaspa message. It
+// blocks until the block the transactions belong to has been handled.
+func (sp *Peer) OnBlockTxn(_ *peer.Peer, msg *wire.MsgBlockTxn) {
+	sp.server.SyncManager.QueueBlockTxn(msg, sp.Peer, sp.blockProcessed)
+	<-sp.blockProcessed
+}
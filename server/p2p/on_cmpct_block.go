@@ -0,0 +1,18 @@
+package p2p
+
+import (
+	"github.com/kaspanet/kaspad/peer"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// OnCmpctBlock is invoked when a peer receives a cmpctblock kaspa message.
+// It blocks until the compact block has been handled, in the same way that
+// OnBlock does.
+func (sp *Peer) OnCmpctBlock(_ *peer.Peer, msg *wire.MsgCmpctBlock) {
+	// Add the block to the known inventory for the peer.
+	iv := wire.NewInvVect(wire.InvTypeBlock, msg.Header.BlockHash())
+	sp.AddKnownInventory(iv)
+
+	sp.server.SyncManager.QueueCmpctBlock(msg, sp.Peer, sp.blockProcessed)
+	<-sp.blockProcessed
+}
@@ -0,0 +1,35 @@
+package p2p
+
+import (
+	"fmt"
+
+	"github.com/kaspanet/kaspad/peer"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// OnGetBlockTxn is invoked when a peer receives a getblocktxn kaspa
+// message. It sends the requested transactions of the block to the
+// requesting peer.
+func (sp *Peer) OnGetBlockTxn(_ *peer.Peer, msg *wire.MsgGetBlockTxn) {
+	block, err := sp.server.DAG.BlockByHash(msg.BlockHash)
+	if err != nil {
+		sp.AddBanScoreAndPushRejectMsg(wire.CmdGetBlockTxn, wire.RejectInvalid, nil,
+			peer.BanScoreInvalidMsgGetBlockTxn, 0,
+			fmt.Sprintf("error getting block %s: %s", msg.BlockHash, err))
+		return
+	}
+
+	transactions := block.MsgBlock().Transactions
+	blockTxnMsg := wire.NewMsgBlockTxn(msg.BlockHash)
+	for _, index := range msg.Indexes {
+		if index >= uint32(len(transactions)) {
+			sp.AddBanScoreAndPushRejectMsg(wire.CmdGetBlockTxn, wire.RejectInvalid, nil,
+				peer.BanScoreInvalidMsgGetBlockTxn, 0,
+				fmt.Sprintf("transaction index %d is out of range for block %s "+
+					"with %d transactions", index, msg.BlockHash, len(transactions)))
+			return
+		}
+		blockTxnMsg.AddTransaction(transactions[index])
+	}
+	sp.QueueMessage(blockTxnMsg, nil)
+}
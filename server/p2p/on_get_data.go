@@ -48,6 +48,8 @@ func (sp *Peer) OnGetData(_ *peer.Peer, msg *wire.MsgGetData) {
 			fallthrough
 		case wire.InvTypeBlock:
 			err = sp.server.pushBlockMsg(sp, iv.Hash, c, waitChan)
+		case wire.InvTypeCompactBlock:
+			err = sp.server.pushCompactBlockMsg(sp, iv.Hash, c, waitChan)
 		case wire.InvTypeFilteredBlock:
 			err = sp.server.pushMerkleBlockMsg(sp, iv.Hash, c, waitChan)
 		default:
@@ -0,0 +1,35 @@
+package p2p
+
+import (
+	"fmt"
+	"github.com/kaspanet/kaspad/peer"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// OnGetHeaders is invoked when a peer receives a getheaders kaspa
+// message.
+// It finds the headers of the blocks between msg.LowHash and
+// msg.HighHash and sends them to the requesting peer.
+func (sp *Peer) OnGetHeaders(_ *peer.Peer, msg *wire.MsgGetHeaders) {
+	headers, err := sp.server.DAG.AntiPastHeadersBetween(msg.LowHash, msg.HighHash,
+		wire.MaxBlockHeadersPerMsg)
+	if err != nil {
+		sp.AddBanScoreAndPushRejectMsg(wire.CmdGetHeaders, wire.RejectInvalid, nil,
+			peer.BanScoreInvalidMsgGetHeaders, 0,
+			fmt.Sprintf("error getting antiPast headers between %s and %s: %s", msg.LowHash, msg.HighHash, err))
+		return
+	}
+
+	// The headers message is sent even if it's empty, since the
+	// requesting peer waits for it.
+	headersMsg := wire.NewMsgHeaders()
+	for _, header := range headers {
+		err := headersMsg.AddBlockHeader(header)
+		if err != nil {
+			peerLog.Errorf("Failed to add a header to the headers message "+
+				"for peer %s: %s", sp, err)
+			return
+		}
+	}
+	sp.QueueMessage(headersMsg, nil)
+}
@@ -0,0 +1,14 @@
+package p2p
+
+import (
+	"github.com/kaspanet/kaspad/peer"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// OnHeaders is invoked when a peer receives a headers kaspa
+// message.
+func (sp *Peer) OnHeaders(peer *peer.Peer, msg *wire.MsgHeaders) {
+	done := make(chan struct{})
+	sp.server.SyncManager.QueueHeaders(msg, peer, done)
+	<-done
+}
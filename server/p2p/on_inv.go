@@ -1,7 +1,6 @@
 package p2p
 
 import (
-	"github.com/kaspanet/kaspad/config"
 	"github.com/kaspanet/kaspad/peer"
 	"github.com/kaspanet/kaspad/wire"
 )
@@ -11,7 +10,7 @@ import (
 // accordingly. We pass the message down to blockmanager which will call
 // QueueMessage with any appropriate responses.
 func (sp *Peer) OnInv(_ *peer.Peer, msg *wire.MsgInv) {
-	if !config.ActiveConfig().BlocksOnly {
+	if !sp.isBlocksOnly() {
 		if len(msg.InvList) > 0 {
 			sp.server.SyncManager.QueueInv(msg, sp.Peer)
 		}
@@ -22,9 +21,9 @@ func (sp *Peer) OnInv(_ *peer.Peer, msg *wire.MsgInv) {
 	for _, invVect := range msg.InvList {
 		if invVect.Type == wire.InvTypeTx {
 			peerLog.Tracef("Ignoring tx %s in inv from %s -- "+
-				"blocksonly enabled", invVect.Hash, sp)
+				"only blocks are relayed", invVect.Hash, sp)
 			sp.AddBanScoreAndPushRejectMsg(msg.Command(), wire.RejectNotRequested, invVect.Hash,
-				peer.BanScoreSentTxToBlocksOnly, 0, "announced transactions when blocksonly is enabled")
+				peer.BanScoreSentTxToBlocksOnly, 0, "announced transactions when only blocks are relayed")
 			return
 		}
 		err := newInv.AddInvVect(invVect)
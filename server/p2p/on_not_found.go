@@ -0,0 +1,12 @@
+package p2p
+
+import (
+	"github.com/kaspanet/kaspad/peer"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// OnNotFound is invoked when a peer receives a notfound kaspa
+// message.
+func (sp *Peer) OnNotFound(peer *peer.Peer, msg *wire.MsgNotFound) {
+	sp.server.SyncManager.QueueNotFound(msg, peer)
+}
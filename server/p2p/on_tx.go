@@ -1,7 +1,6 @@
 package p2p
 
 import (
-	"github.com/kaspanet/kaspad/config"
 	"github.com/kaspanet/kaspad/peer"
 	"github.com/kaspanet/kaspad/util"
 	"github.com/kaspanet/kaspad/util/daghash"
@@ -13,8 +12,8 @@ import (
 // handler this does not serialize all transactions through a single thread
 // transactions don't rely on the previous one in a linear fashion like blocks.
 func (sp *Peer) OnTx(_ *peer.Peer, msg *wire.MsgTx) {
-	if config.ActiveConfig().BlocksOnly {
-		peerLog.Tracef("Ignoring tx %s from %s - blocksonly enabled",
+	if sp.isBlocksOnly() {
+		peerLog.Tracef("Ignoring tx %s from %s - only blocks are relayed",
 			msg.TxID(), sp)
 		return
 	}
@@ -25,26 +25,11 @@ func (sp *Peer) OnVersion(_ *peer.Peer, msg *wire.MsgVersion) {
 
 		// Outbound connections.
 		if !sp.Inbound() {
-			// TODO(davec): Only do this if not doing the initial block
-			// download and the local address is routable.
-			if !config.ActiveConfig().DisableListen {
-				// Get address that best matches.
-				lna := addrManager.GetBestLocalAddress(sp.NA())
-				if addrmgr.IsRoutable(lna) {
-					// Filter addresses the peer already knows about.
-					addresses := []*wire.NetAddress{lna}
-					sp.pushAddrMsg(addresses, sp.SubnetworkID())
-				}
-			}
-
-			// Request known addresses if the server address manager needs
-			// more.
-			if addrManager.NeedMoreAddresses() {
-				sp.QueueMessage(wire.NewMsgGetAddr(false, sp.SubnetworkID()), nil)
-
-				if sp.SubnetworkID() != nil {
-					sp.QueueMessage(wire.NewMsgGetAddr(false, nil), nil)
-				}
+			// Addresses are never relayed to or from block relay
+			// only peers, so that the connections to them can't be
+			// inferred from the addresses they relay.
+			if !sp.blockRelayOnly {
+				sp.exchangeAddresses(addrManager)
 			}
 
 			// Mark the address as a known good address.
@@ -52,3 +37,29 @@ func (sp *Peer) OnVersion(_ *peer.Peer, msg *wire.MsgVersion) {
 		}
 	}
 }
+
+// exchangeAddresses advertises the local address to the peer and requests
+// known addresses from it if the address manager needs more.
+func (sp *Peer) exchangeAddresses(addrManager *addrmgr.AddrManager) {
+	// TODO(davec): Only do this if not doing the initial block
+	// download and the local address is routable.
+	if !config.ActiveConfig().DisableListen {
+		// Get address that best matches.
+		lna := addrManager.GetBestLocalAddress(sp.NA())
+		if addrmgr.IsRoutable(lna) {
+			// Filter addresses the peer already knows about.
+			addresses := []*wire.NetAddress{lna}
+			sp.pushAddrMsg(addresses, sp.SubnetworkID())
+		}
+	}
+
+	// Request known addresses if the server address manager needs
+	// more.
+	if addrManager.NeedMoreAddresses() {
+		sp.QueueMessage(wire.NewMsgGetAddr(false, sp.SubnetworkID()), nil)
+
+		if sp.SubnetworkID() != nil {
+			sp.QueueMessage(wire.NewMsgGetAddr(false, nil), nil)
+		}
+	}
+}
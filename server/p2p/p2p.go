@@ -22,6 +22,7 @@ import (
 
 	"github.com/kaspanet/kaspad/util/subnetworkid"
 
+	"github.com/kaspanet/go-secp256k1"
 	"github.com/kaspanet/kaspad/addrmgr"
 	"github.com/kaspanet/kaspad/blockdag"
 	"github.com/kaspanet/kaspad/blockdag/indexers"
@@ -30,6 +31,7 @@ import (
 	"github.com/kaspanet/kaspad/dagconfig"
 	"github.com/kaspanet/kaspad/logger"
 	"github.com/kaspanet/kaspad/mempool"
+	"github.com/kaspanet/kaspad/mempool/feeestimator"
 	"github.com/kaspanet/kaspad/netsync"
 	"github.com/kaspanet/kaspad/peer"
 	"github.com/kaspanet/kaspad/server/serverutils"
@@ -37,6 +39,7 @@ import (
 	"github.com/kaspanet/kaspad/util"
 	"github.com/kaspanet/kaspad/util/bloom"
 	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/util/random"
 	"github.com/kaspanet/kaspad/version"
 	"github.com/kaspanet/kaspad/wire"
 )
@@ -54,6 +57,10 @@ const (
 	// retries when connecting to persistent peers. It is adjusted by the
 	// number of retries such that there is a retry backoff.
 	connectionRetryInterval = time.Second * 5
+
+	// banScoreExceededReason is the reason of the bans of peers whose
+	// ban scores exceeded the ban threshold.
+	banScoreExceededReason = "ban score exceeded"
 )
 
 var (
@@ -121,6 +128,7 @@ type Peer struct {
 	connReq         *connmgr.ConnReq
 	server          *Server
 	persistent      bool
+	blockRelayOnly  bool
 	relayMtx        sync.Mutex
 	DisableRelayTx  bool
 	sentAddrs       bool
@@ -134,18 +142,20 @@ type Peer struct {
 	blockProcessed chan struct{}
 }
 
-// peerState maintains state of inbound, persistent, outbound peers as well
-// as banned peers and outbound groups.
+// peerState maintains state of inbound, persistent, outbound and block relay
+// only outbound peers as well as banned peers and outbound groups.
 type peerState struct {
-	inboundPeers    map[int32]*Peer
-	outboundPeers   map[int32]*Peer
-	persistentPeers map[int32]*Peer
-	banned          map[string]time.Time
+	inboundPeers        map[int32]*Peer
+	outboundPeers       map[int32]*Peer
+	persistentPeers     map[int32]*Peer
+	blockRelayOnlyPeers map[int32]*Peer
+	bans                *banList
 }
 
 // Count returns the count of all known peers.
 func (ps *peerState) Count() int {
-	return ps.countInboundPeers() + ps.countOutboundPeers()
+	return ps.countInboundPeers() + ps.countOutboundPeers() +
+		ps.countBlockRelayOnlyPeers()
 }
 
 func (ps *peerState) countInboundPeers() int {
@@ -157,6 +167,10 @@ func (ps *peerState) countOutboundPeers() int {
 		len(ps.persistentPeers)
 }
 
+func (ps *peerState) countBlockRelayOnlyPeers() int {
+	return len(ps.blockRelayOnlyPeers)
+}
+
 // forAllOutboundPeers is a helper function that runs a callback on all outbound
 // peers known to peerState.
 // The loop stops and returns false if one of the callback calls returns false.
@@ -174,6 +188,12 @@ func (ps *peerState) forAllOutboundPeers(callback func(sp *Peer) bool) bool {
 			return false
 		}
 	}
+	for _, e := range ps.blockRelayOnlyPeers {
+		shouldContinue := callback(e)
+		if !shouldContinue {
+			return false
+		}
+	}
 	return true
 }
 
@@ -213,13 +233,14 @@ type Server struct {
 	shutdown      int32
 	shutdownSched int32
 
-	DAGParams   *dagconfig.Params
-	AddrManager *addrmgr.AddrManager
-	connManager *connmgr.ConnManager
-	SigCache    *txscript.SigCache
-	SyncManager *netsync.SyncManager
-	DAG         *blockdag.BlockDAG
-	TxMemPool   *mempool.TxPool
+	DAGParams    *dagconfig.Params
+	AddrManager  *addrmgr.AddrManager
+	connManager  *connmgr.ConnManager
+	SigCache     *txscript.SigCache
+	SyncManager  *netsync.SyncManager
+	DAG          *blockdag.BlockDAG
+	TxMemPool    *mempool.TxPool
+	FeeEstimator *feeestimator.FeeEstimator
 
 	modifyRebroadcastInv chan interface{}
 	newPeers             chan *Peer
@@ -233,6 +254,17 @@ type Server struct {
 	TimeSource           blockdag.TimeSource
 	services             wire.ServiceFlag
 
+	// encryptionMode specifies whether the connections to peers are
+	// encrypted, and identityKey is the key with which the server
+	// identifies itself over encrypted connections, or nil if it
+	// doesn't.
+	encryptionMode peer.EncryptionMode
+	identityKey    *secp256k1.PrivateKey
+
+	// evictionNetGroupKey is the secret key with which network groups
+	// are hashed when choosing which of them are protected from eviction.
+	evictionNetGroupKey uint64
+
 	// We add to quitWaitGroup before every instance in which we wait for
 	// the quit channel so that all those instances finish before we shut
 	// down the managers (connManager, addrManager, etc),
@@ -244,6 +276,8 @@ type Server struct {
 	// initial creation of the server and never changed afterwards, so they
 	// do not need to be protected for concurrent access.
 	AcceptanceIndex *indexers.AcceptanceIndex
+	TxIndex         *indexers.TxIndex
+	AddressIndex    *indexers.AddressIndex
 
 	notifyNewTransactions func(txns []*mempool.TxDesc)
 }
@@ -301,7 +335,23 @@ func (sp *Peer) setDisableRelayTx(disable bool) {
 func (sp *Peer) relayTxDisabled() bool {
 	sp.relayMtx.Lock()
 	defer sp.relayMtx.Unlock()
-	return sp.DisableRelayTx
+	return sp.DisableRelayTx || sp.blockRelayOnly
+}
+
+// BlockRelayOnly returns whether only blocks are relayed to and from the peer,
+// and never transactions or addresses.
+// It is safe for concurrent access.
+func (sp *Peer) BlockRelayOnly() bool {
+	// blockRelayOnly doesn't change after initialization, therefore it is
+	// not protected by a mutex.
+	return sp.blockRelayOnly
+}
+
+// isBlocksOnly returns whether transactions from the peer should be rejected,
+// either because blocksonly is enabled or because only blocks are relayed to
+// and from the peer.
+func (sp *Peer) isBlocksOnly() bool {
+	return config.ActiveConfig().BlocksOnly || sp.blockRelayOnly
 }
 
 // pushAddrMsg sends an addr message to the connected peer using the provided
@@ -521,6 +571,41 @@ func (s *Server) pushBlockMsg(sp *Peer, hash *daghash.Hash, doneChan chan<- stru
 	return nil
 }
 
+// pushCompactBlockMsg sends a cmpctblock message for the provided block hash
+// to the connected peer. Partial nodes are sent the full block instead, since
+// they can't reconstruct blocks from their mempools. An error is returned if
+// the block hash is not known.
+func (s *Server) pushCompactBlockMsg(sp *Peer, hash *daghash.Hash, doneChan chan<- struct{},
+	waitChan <-chan struct{}) error {
+
+	if sp.Peer.SubnetworkID() != nil {
+		return s.pushBlockMsg(sp, hash, doneChan, waitChan)
+	}
+
+	// Fetch the block from the database.
+	block, err := s.DAG.BlockByHash(hash)
+	if err != nil {
+		peerLog.Tracef("Unable to fetch requested block hash %s: %s",
+			hash, err)
+
+		if doneChan != nil {
+			doneChan <- struct{}{}
+		}
+		return err
+	}
+
+	msg := wire.NewMsgCmpctBlock(block.MsgBlock())
+
+	// Once we have fetched data wait for any previous operation to finish.
+	if waitChan != nil {
+		<-waitChan
+	}
+
+	sp.QueueMessage(msg, doneChan)
+
+	return nil
+}
+
 // pushMerkleBlockMsg sends a merkleblock message for the provided block hash to
 // the connected peer. Since a merkle block requires the peer to have a filter
 // loaded, this call will simply be ignored if there is no filter loaded. An
@@ -602,26 +687,32 @@ func (s *Server) handleAddPeerMsg(state *peerState, sp *Peer) bool {
 		sp.Disconnect()
 		return false
 	}
-	if banEnd, ok := state.banned[host]; ok {
-		if time.Now().Before(banEnd) {
-			srvrLog.Debugf("Peer %s is banned for another %s - disconnecting",
-				host, time.Until(banEnd))
+	if ip := net.ParseIP(host); ip != nil {
+		if bannedSubnet := state.bans.bannedSubnetOf(ip); bannedSubnet != nil {
+			srvrLog.Debugf("Peer %s is banned (%s) for another %s - disconnecting",
+				host, bannedSubnet.Subnet, time.Until(bannedSubnet.Expiry))
 			sp.Disconnect()
 			return false
 		}
-
-		srvrLog.Infof("Peer %s is no longer banned", host)
-		delete(state.banned, host)
 	}
 
 	// TODO: Check for max peers from a single IP.
 
-	// Limit max number of total peers.
+	// Limit max number of total peers. When the inbound slots are
+	// exhausted, try to evict one of the inbound peers to make room for
+	// the new one, so that an attacker can't take up all of them.
 	if sp.Inbound() && len(state.inboundPeers) >= config.ActiveConfig().MaxInboundPeers {
-		srvrLog.Infof("Max inbound peers reached [%d] - disconnecting peer %s",
-			config.ActiveConfig().MaxInboundPeers, sp)
-		sp.Disconnect()
-		return false
+		toEvict := s.selectInboundPeerToEvict(state)
+		if toEvict == nil {
+			srvrLog.Infof("Max inbound peers reached [%d] - disconnecting peer %s",
+				config.ActiveConfig().MaxInboundPeers, sp)
+			sp.Disconnect()
+			return false
+		}
+		srvrLog.Infof("Max inbound peers reached [%d] - evicting peer %s "+
+			"to make room for peer %s", config.ActiveConfig().MaxInboundPeers, toEvict, sp)
+		delete(state.inboundPeers, toEvict.ID())
+		toEvict.Disconnect()
 	}
 
 	// Add the new peer and start it.
@@ -631,6 +722,8 @@ func (s *Server) handleAddPeerMsg(state *peerState, sp *Peer) bool {
 	} else {
 		if sp.persistent {
 			state.persistentPeers[sp.ID()] = sp
+		} else if sp.blockRelayOnly {
+			state.blockRelayOnlyPeers[sp.ID()] = sp
 		} else {
 			state.outboundPeers[sp.ID()] = sp
 		}
@@ -650,6 +743,8 @@ func (s *Server) handleDonePeerMsg(state *peerState, sp *Peer) {
 		list = state.persistentPeers
 	} else if sp.Inbound() {
 		list = state.inboundPeers
+	} else if sp.blockRelayOnly {
+		list = state.blockRelayOnlyPeers
 	} else {
 		list = state.outboundPeers
 	}
@@ -684,10 +779,35 @@ func (s *Server) handleBanPeerMsg(state *peerState, sp *Peer) {
 		srvrLog.Debugf("can't split ban peer %s: %s", sp.Addr(), err)
 		return
 	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		srvrLog.Debugf("can't ban peer %s: %s is not an IP address", sp.Addr(), host)
+		return
+	}
 	direction := logger.DirectionString(sp.Inbound())
 	srvrLog.Infof("Banned peer %s (%s) for %s", host, direction,
 		config.ActiveConfig().BanDuration)
-	state.banned[host] = time.Now().Add(config.ActiveConfig().BanDuration)
+	expiry := time.Now().Add(config.ActiveConfig().BanDuration)
+	err = state.bans.ban(singleIPSubnet(ip), banScoreExceededReason, expiry)
+	if err != nil && !errors.Is(err, ErrAlreadyBanned) {
+		srvrLog.Errorf("Failed to save the ban of peer %s: %s", host, err)
+	}
+}
+
+// disconnectBannedPeers disconnects all the connected peers whose IPs are
+// in the given subnet. It is invoked from the peerHandler goroutine.
+func (s *Server) disconnectBannedPeers(state *peerState, subnet *net.IPNet) {
+	state.forAllPeers(func(sp *Peer) bool {
+		host, _, err := net.SplitHostPort(sp.Addr())
+		if err != nil {
+			return true
+		}
+		if ip := net.ParseIP(host); ip != nil && subnet.Contains(ip) {
+			srvrLog.Infof("Disconnecting peer %s - subnet %s is banned", sp, subnet)
+			sp.Disconnect()
+		}
+		return true
+	})
 }
 
 // handleRelayInvMsg deals with relaying inventory to peers that are not already
@@ -795,6 +915,30 @@ type RemoveNodeMsg struct {
 	Reply chan error
 }
 
+//BanSubnetMsg is the message type which is used by the rpc server to ban a subnet until the given expiry
+type BanSubnetMsg struct {
+	Subnet *net.IPNet
+	Reason string
+	Expiry time.Time
+	Reply  chan error
+}
+
+//UnbanSubnetMsg is the message type which is used by the rpc server to remove the ban of a subnet
+type UnbanSubnetMsg struct {
+	Subnet *net.IPNet
+	Reply  chan error
+}
+
+//GetBannedSubnetsMsg is the message type which is used by the rpc server to get the banned subnets from the p2p server
+type GetBannedSubnetsMsg struct {
+	Reply chan []*BannedSubnet
+}
+
+//ClearBannedSubnetsMsg is the message type which is used by the rpc server to remove all the bans
+type ClearBannedSubnetsMsg struct {
+	Reply chan error
+}
+
 // handleQuery is the central handler for all queries and commands from other
 // goroutines related to peer state.
 func (s *Server) handleQuery(state *peerState, querymsg interface{}) {
@@ -890,7 +1034,38 @@ func (s *Server) handleQuery(state *peerState, querymsg interface{}) {
 			return
 		}
 
+		// Check block relay only outbound peers.
+		found = disconnectPeer(state.blockRelayOnlyPeers, msg.Cmp)
+		if found {
+			msg.Reply <- nil
+			return
+		}
+
 		msg.Reply <- errors.WithStack(connmgr.ErrPeerNotFound)
+	case BanSubnetMsg:
+		err := state.bans.ban(msg.Subnet, msg.Reason, msg.Expiry)
+		if err != nil {
+			msg.Reply <- err
+			return
+		}
+		srvrLog.Infof("Banned subnet %s for %s", msg.Subnet,
+			time.Until(msg.Expiry).Round(time.Second))
+		s.disconnectBannedPeers(state, msg.Subnet)
+		msg.Reply <- nil
+	case UnbanSubnetMsg:
+		err := state.bans.unban(msg.Subnet)
+		if err == nil {
+			srvrLog.Infof("Unbanned subnet %s", msg.Subnet)
+		}
+		msg.Reply <- err
+	case GetBannedSubnetsMsg:
+		msg.Reply <- state.bans.list()
+	case ClearBannedSubnetsMsg:
+		err := state.bans.clear()
+		if err == nil {
+			srvrLog.Infof("Cleared all bans")
+		}
+		msg.Reply <- err
 	}
 }
 
@@ -932,8 +1107,15 @@ func newPeerConfig(sp *Peer) *peer.Config {
 			OnFilterLoad:      sp.OnFilterLoad,
 			OnGetAddr:         sp.OnGetAddr,
 			OnAddr:            sp.OnAddr,
+			OnAddrV2:          sp.OnAddrV2,
 			OnGetSelectedTip:  sp.OnGetSelectedTip,
 			OnSelectedTip:     sp.OnSelectedTip,
+			OnGetHeaders:      sp.OnGetHeaders,
+			OnHeaders:         sp.OnHeaders,
+			OnNotFound:        sp.OnNotFound,
+			OnCmpctBlock:      sp.OnCmpctBlock,
+			OnGetBlockTxn:     sp.OnGetBlockTxn,
+			OnBlockTxn:        sp.OnBlockTxn,
 			OnRead:            sp.OnRead,
 			OnWrite:           sp.OnWrite,
 		},
@@ -947,9 +1129,12 @@ func newPeerConfig(sp *Peer) *peer.Config {
 		UserAgentComments: config.ActiveConfig().UserAgentComments,
 		DAGParams:         sp.server.DAGParams,
 		Services:          sp.server.services,
-		DisableRelayTx:    config.ActiveConfig().BlocksOnly,
+		DisableRelayTx:    sp.isBlocksOnly(),
 		ProtocolVersion:   peer.MaxProtocolVersion,
 		SubnetworkID:      config.ActiveConfig().SubnetworkID,
+		Encryption:        sp.server.encryptionMode,
+		IdentityKey:       sp.server.identityKey,
+		AllowedIdentities: config.ActiveConfig().P2PAllowedIdentities,
 	}
 }
 
@@ -971,6 +1156,7 @@ func (s *Server) inboundPeerConnected(conn net.Conn) {
 // manager of the attempt.
 func (s *Server) outboundPeerConnected(connReq *connmgr.ConnReq, conn net.Conn) {
 	sp := newServerPeer(s, connReq.Permanent)
+	sp.blockRelayOnly = connReq.BlockRelayOnly
 	outboundPeer, err := peer.NewOutboundPeer(newPeerConfig(sp), connReq.Addr.String())
 	if err != nil {
 		srvrLog.Debugf("Cannot create outbound peer %s: %s", connReq.Addr, err)
@@ -1021,9 +1207,13 @@ func (s *Server) outboundPeerConnectionFailed(connReq *connmgr.ConnReq) {
 	}
 
 	// defaultServices is used here because Attempt makes no use
-	// of the services field and NewNetAddressIPPort does not
+	// of the services field and HostToNetAddress does not
 	// take nil for it.
-	netAddress := wire.NewNetAddressIPPort(net.ParseIP(host), uint16(port), defaultServices)
+	netAddress, err := s.AddrManager.HostToNetAddress(host, uint16(port), defaultServices)
+	if err != nil {
+		srvrLog.Debugf("Cannot convert %s to a net address: %s", connReq.Addr, err)
+		return
+	}
 
 	s.AddrManager.Attempt(netAddress)
 }
@@ -1064,15 +1254,22 @@ func (s *Server) peerHandler() {
 	}
 	s.SyncManager.Start()
 
+	bans, err := loadBanList()
+	if err != nil {
+		srvrLog.Errorf("Failed to load the ban list, starting with no bans: %s", err)
+		bans = newBanList()
+	}
+
 	s.quitWaitGroup.Add(1)
 
 	srvrLog.Tracef("Starting peer handler")
 
 	state := &peerState{
-		inboundPeers:    make(map[int32]*Peer),
-		persistentPeers: make(map[int32]*Peer),
-		outboundPeers:   make(map[int32]*Peer),
-		banned:          make(map[string]time.Time),
+		inboundPeers:        make(map[int32]*Peer),
+		persistentPeers:     make(map[int32]*Peer),
+		outboundPeers:       make(map[int32]*Peer),
+		blockRelayOnlyPeers: make(map[int32]*Peer),
+		bans:                bans,
 	}
 
 	if !config.ActiveConfig().DisableDNSSeed {
@@ -1143,6 +1340,18 @@ out:
 	s.SyncManager.Stop()
 	s.AddrManager.Stop()
 
+	err = s.FeeEstimator.Save()
+	if err != nil {
+		srvrLog.Errorf("Failed to save the fee estimator state: %s", err)
+	}
+
+	if config.ActiveConfig().PersistMempool {
+		err = s.TxMemPool.Save()
+		if err != nil {
+			srvrLog.Errorf("Failed to save the mempool transactions: %s", err)
+		}
+	}
+
 	// Drain channels before exiting so nothing is left waiting around
 	// to send.
 cleanup:
@@ -1186,6 +1395,22 @@ func (s *Server) BroadcastMessage(msg wire.Message, exclPeers ...*Peer) {
 	s.broadcast <- bmsg
 }
 
+// Services returns the services the server advertises to its peers.
+func (s *Server) Services() wire.ServiceFlag {
+	return s.services
+}
+
+// UserAgent returns the user agent the server advertises to its peers.
+func (s *Server) UserAgent() string {
+	msg := wire.MsgVersion{UserAgent: wire.DefaultUserAgent}
+	err := msg.AddUserAgent(userAgentName, userAgentVersion,
+		config.ActiveConfig().UserAgentComments...)
+	if err != nil {
+		return wire.DefaultUserAgent
+	}
+	return msg.UserAgent
+}
+
 // ConnectedCount returns the number of currently connected peers.
 func (s *Server) ConnectedCount() int32 {
 	replyChan := make(chan int32)
@@ -1214,6 +1439,12 @@ func (s *Server) NetTotals() (uint64, uint64) {
 		atomic.LoadUint64(&s.bytesSent)
 }
 
+// IsNetworkActive returns whether the server is connecting to and accepting
+// connections from peers. It is safe for concurrent access.
+func (s *Server) IsNetworkActive() bool {
+	return atomic.LoadInt32(&s.shutdown) == 0 && s.connManager.IsActive()
+}
+
 // rebroadcastHandler keeps track of user submitted inventories that we have
 // sent out but have not yet made it into a block. We periodically rebroadcast
 // them in case our peers restarted or otherwise lost track of them.
@@ -1273,6 +1504,30 @@ cleanup:
 	s.wg.Done()
 }
 
+// mempoolExpiryHandler periodically removes the transactions that stayed in
+// the mempool for too long. They will probably never be accepted. It must be
+// run as a goroutine.
+func (s *Server) mempoolExpiryHandler() {
+	ticker := time.NewTicker(mempool.TxExpireScanInterval)
+	defer ticker.Stop()
+
+out:
+	for {
+		select {
+		case <-ticker.C:
+			_, err := s.TxMemPool.ExpireTransactions()
+			if err != nil {
+				srvrLog.Errorf("Failed to expire mempool transactions: %s", err)
+			}
+
+		case <-s.quit:
+			break out
+		}
+	}
+
+	s.wg.Done()
+}
+
 // Start begins accepting connections from peers.
 func (s *Server) Start() {
 
@@ -1295,6 +1550,11 @@ func (s *Server) Start() {
 		// the RPC server are rebroadcast until being included in a block.
 		spawn(s.rebroadcastHandler)
 	}
+
+	if cfg.MempoolExpiry != 0 {
+		s.wg.Add(1)
+		spawn(s.mempoolExpiryHandler)
+	}
 }
 
 // Stop gracefully shuts down the server by stopping and disconnecting all
@@ -1467,6 +1727,13 @@ func NewServer(listenAddrs []string, dagParams *dagconfig.Params, interrupt <-ch
 	if config.ActiveConfig().NoPeerBloomFilters {
 		services &^= wire.SFNodeBloom
 	}
+	// Pruned nodes can't serve the entire DAG to their peers.
+	var pruneDepth uint64
+	if config.ActiveConfig().Prune {
+		services &^= wire.SFNodeNetwork
+		services |= wire.SFNodeNetworkLimited
+		pruneDepth = config.ActiveConfig().PruneDepth
+	}
 
 	addressManager := addrmgr.New(serverutils.KaspadLookup, config.ActiveConfig().SubnetworkID)
 
@@ -1483,7 +1750,8 @@ func NewServer(listenAddrs []string, dagParams *dagconfig.Params, interrupt <-ch
 		}
 	}
 
-	maxPeers := config.ActiveConfig().TargetOutboundPeers + config.ActiveConfig().MaxInboundPeers
+	maxPeers := config.ActiveConfig().TargetOutboundPeers + config.ActiveConfig().BlockRelayOnlyPeers +
+		config.ActiveConfig().MaxInboundPeers
 
 	s := Server{
 		DAGParams:             dagParams,
@@ -1501,6 +1769,26 @@ func NewServer(listenAddrs []string, dagParams *dagconfig.Params, interrupt <-ch
 		services:              services,
 		SigCache:              txscript.NewSigCache(config.ActiveConfig().SigCacheMaxSize),
 		notifyNewTransactions: notifyNewTransactions,
+		encryptionMode:        encryptionModes[config.ActiveConfig().P2PEncryption],
+	}
+
+	evictionNetGroupKey, err := random.Uint64()
+	if err != nil {
+		return nil, err
+	}
+	s.evictionNetGroupKey = evictionNetGroupKey
+
+	if config.ActiveConfig().P2PIdentity {
+		identityKey, err := loadIdentityKey(config.ActiveConfig().DataDir)
+		if err != nil {
+			return nil, err
+		}
+		identity, err := identityPublicKeyString(identityKey)
+		if err != nil {
+			return nil, err
+		}
+		srvrLog.Infof("P2P identity public key: %s", identity)
+		s.identityKey = identityKey
 	}
 
 	// Create indexes if needed.
@@ -1510,6 +1798,16 @@ func NewServer(listenAddrs []string, dagParams *dagconfig.Params, interrupt <-ch
 		s.AcceptanceIndex = indexers.NewAcceptanceIndex()
 		indexes = append(indexes, s.AcceptanceIndex)
 	}
+	if config.ActiveConfig().TxIndex {
+		indxLog.Info("transaction index is enabled")
+		s.TxIndex = indexers.NewTxIndex()
+		indexes = append(indexes, s.TxIndex)
+	}
+	if config.ActiveConfig().AddrIndex {
+		indxLog.Info("address index is enabled")
+		s.AddressIndex = indexers.NewAddressIndex()
+		indexes = append(indexes, s.AddressIndex)
+	}
 
 	// Create an index manager if any of the optional indexes are enabled.
 	var indexManager blockdag.IndexManager
@@ -1518,7 +1816,6 @@ func NewServer(listenAddrs []string, dagParams *dagconfig.Params, interrupt <-ch
 	}
 
 	// Create a new block DAG instance with the appropriate configuration.
-	var err error
 	s.DAG, err = blockdag.New(&blockdag.Config{
 		Interrupt:    interrupt,
 		DAGParams:    s.DAGParams,
@@ -1526,18 +1823,34 @@ func NewServer(listenAddrs []string, dagParams *dagconfig.Params, interrupt <-ch
 		SigCache:     s.SigCache,
 		IndexManager: indexManager,
 		SubnetworkID: config.ActiveConfig().SubnetworkID,
+		PruneDepth:   pruneDepth,
 	})
 	if err != nil {
 		return nil, err
 	}
+	// Nodes that were loaded from a UTXO snapshot are missing the blocks
+	// below its finality point, just like pruned nodes.
+	if s.DAG.HasPrunedBlocks() {
+		s.services &^= wire.SFNodeNetwork
+		s.services |= wire.SFNodeNetworkLimited
+	}
+
+	s.FeeEstimator, err = feeestimator.New(s.DAG)
+	if err != nil {
+		return nil, err
+	}
 
 	txC := mempool.Config{
 		Policy: mempool.Policy{
-			AcceptNonStd:    config.ActiveConfig().RelayNonStd,
-			MaxOrphanTxs:    config.ActiveConfig().MaxOrphanTxs,
-			MaxOrphanTxSize: config.DefaultMaxOrphanTxSize,
-			MinRelayTxFee:   config.ActiveConfig().MinRelayTxFee,
-			MaxTxVersion:    1,
+			AcceptNonStd:            config.ActiveConfig().RelayNonStd,
+			MaxOrphanTxs:            config.ActiveConfig().MaxOrphanTxs,
+			MaxOrphanTxSize:         config.DefaultMaxOrphanTxSize,
+			MinRelayTxFee:           config.ActiveConfig().MinRelayTxFee,
+			MaxTxVersion:            1,
+			AcceptReplacement:       config.ActiveConfig().AcceptReplacement,
+			MaxReplacementEvictions: config.ActiveConfig().MaxReplacements,
+			MaxMempoolMass:          config.ActiveConfig().MaxMempool,
+			MaxTxAge:                config.ActiveConfig().MempoolExpiry,
 		},
 		DAGParams:      dagParams,
 		MedianTimePast: func() time.Time { return s.DAG.CalcPastMedianTime() },
@@ -1547,8 +1860,15 @@ func NewServer(listenAddrs []string, dagParams *dagconfig.Params, interrupt <-ch
 		IsDeploymentActive: s.DAG.IsDeploymentActive,
 		SigCache:           s.SigCache,
 		DAG:                s.DAG,
+		FeeEstimator:       s.FeeEstimator,
 	}
 	s.TxMemPool = mempool.New(&txC)
+	if config.ActiveConfig().PersistMempool {
+		err = s.TxMemPool.Load()
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	s.SyncManager, err = netsync.New(&netsync.Config{
 		PeerNotifier: &s,
@@ -1556,6 +1876,7 @@ func NewServer(listenAddrs []string, dagParams *dagconfig.Params, interrupt <-ch
 		TxMemPool:    s.TxMemPool,
 		DAGParams:    s.DAGParams,
 		MaxPeers:     maxPeers,
+		HeadersFirst: config.ActiveConfig().HeadersFirst,
 	})
 	if err != nil {
 		return nil, err
@@ -1563,14 +1884,16 @@ func NewServer(listenAddrs []string, dagParams *dagconfig.Params, interrupt <-ch
 
 	// Create a connection manager.
 	cmgr, err := connmgr.New(&connmgr.Config{
-		Listeners:          listeners,
-		OnAccept:           s.inboundPeerConnected,
-		RetryDuration:      connectionRetryInterval,
-		TargetOutbound:     uint32(config.ActiveConfig().TargetOutboundPeers),
-		Dial:               serverutils.KaspadDial,
-		OnConnection:       s.outboundPeerConnected,
-		OnConnectionFailed: s.outboundPeerConnectionFailed,
-		AddrManager:        s.AddrManager,
+		Listeners:                    listeners,
+		OnAccept:                     s.inboundPeerConnected,
+		RetryDuration:                connectionRetryInterval,
+		TargetOutbound:               uint32(config.ActiveConfig().TargetOutboundPeers),
+		TargetBlockRelayOnlyOutbound: uint32(config.ActiveConfig().BlockRelayOnlyPeers),
+		Dial:                         serverutils.KaspadDial,
+		OnConnection:                 s.outboundPeerConnected,
+		OnConnectionFailed:           s.outboundPeerConnectionFailed,
+		AddrManager:                  s.AddrManager,
+		OverlayProxies:               config.ActiveConfig().OverlayProxies,
 	})
 	if err != nil {
 		return nil, err
@@ -1674,14 +1997,23 @@ func initListeners(amgr *addrmgr.AddrManager, listenAddrs []string, services wir
 		}
 	}
 
+	// Add hidden services to address manager to be advertised to peers.
+	// Their proxies forward the connections to them to our listeners.
+	for _, hiddenService := range config.ActiveConfig().HiddenServices {
+		err := addHiddenService(amgr, hiddenService, services)
+		if err != nil {
+			amgrLog.Warnf("Skipping hidden service %s: %s", hiddenService, err)
+		}
+	}
+
 	return listeners, nat, nil
 }
 
 // addrStringToNetAddr takes an address in the form of 'host:port' and returns
 // a net.Addr which maps to the original address with any host names resolved
-// to IP addresses. It also handles tor addresses properly by returning a
-// net.Addr that encapsulates the address.
-func addrStringToNetAddr(addr string) (*net.TCPAddr, error) {
+// to IP addresses. It also handles overlay network addresses, such as tor
+// addresses, properly by returning a net.Addr that encapsulates the address.
+func addrStringToNetAddr(addr string) (net.Addr, error) {
 	host, strPort, err := net.SplitHostPort(addr)
 	if err != nil {
 		return nil, err
@@ -1692,6 +2024,19 @@ func addrStringToNetAddr(addr string) (*net.TCPAddr, error) {
 		return nil, err
 	}
 
+	// Overlay network addresses can't be resolved, and are dialed through
+	// the proxy of their network instead.
+	if wire.IsOverlayHost(host) {
+		overlay, err := wire.ParseOverlayAddress(host)
+		if err != nil {
+			return nil, err
+		}
+		return &wire.OverlayTCPAddr{
+			Overlay: *overlay,
+			Port:    port,
+		}, nil
+	}
+
 	// Skip if host is already an IP address.
 	if ip := net.ParseIP(host); ip != nil {
 		return &net.TCPAddr{
@@ -1761,6 +2106,26 @@ func addLocalAddress(addrMgr *addrmgr.AddrManager, addr string, services wire.Se
 	return nil
 }
 
+// addHiddenService adds an overlay network address, in the form of host:port,
+// that forwards connections to this node to the address manager so that it
+// may be relayed to peers.
+func addHiddenService(addrMgr *addrmgr.AddrManager, addr string, services wire.ServiceFlag) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return err
+	}
+
+	netAddr, err := addrMgr.HostToNetAddress(host, uint16(port), services)
+	if err != nil {
+		return err
+	}
+	return addrMgr.AddLocalAddress(netAddr, addrmgr.ManualPrio)
+}
+
 // dynamicTickDuration is a convenience function used to dynamically choose a
 // tick duration based on remaining time. It is primarily used during
 // server shutdown to make shutdown warnings more frequent as the shutdown time
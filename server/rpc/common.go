@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"fmt"
+	"github.com/kaspanet/kaspad/blockdag"
 	"github.com/kaspanet/kaspad/dagconfig"
 	"github.com/kaspanet/kaspad/rpcmodel"
 	"github.com/kaspanet/kaspad/txscript"
@@ -46,6 +47,14 @@ func rpcDecodeHexError(gotHex string) *rpcmodel.RPCError {
 			gotHex))
 }
 
+// rpcBlockPrunedError is a convenience function for returning a nicely
+// formatted RPC error which indicates the data of the provided block was
+// pruned.
+func rpcBlockPrunedError(hash *daghash.Hash) *rpcmodel.RPCError {
+	return rpcmodel.NewRPCError(rpcmodel.ErrRPCBlockPruned,
+		fmt.Sprintf("Block %s not available (pruned data)", hash))
+}
+
 // rpcNoTxInfoError is a convenience function for returning a nicely formatted
 // RPC error which indicates there is no information available for the provided
 // transaction hash.
@@ -55,6 +64,37 @@ func rpcNoTxInfoError(txID *daghash.TxID) *rpcmodel.RPCError {
 			txID))
 }
 
+// rpcNoAddressIndexError is a convenience function for returning a nicely
+// formatted RPC error which indicates that the address index is disabled.
+func rpcNoAddressIndexError() *rpcmodel.RPCError {
+	return &rpcmodel.RPCError{
+		Code: rpcmodel.ErrRPCNoAddressIndex,
+		Message: "The address index must be " +
+			"enabled to query addresses " +
+			"(specify --addrindex)",
+	}
+}
+
+// addressToScriptPubKey decodes the given address and returns the public
+// key script that pays to it.
+func addressToScriptPubKey(encodedAddr string, params *dagconfig.Params) ([]byte, error) {
+	addr, err := util.DecodeAddress(encodedAddr, params.Prefix)
+	if err != nil {
+		return nil, &rpcmodel.RPCError{
+			Code:    rpcmodel.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+	scriptPubKey, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, &rpcmodel.RPCError{
+			Code:    rpcmodel.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+	return scriptPubKey, nil
+}
+
 // messageToHex serializes a message to the wire protocol encoding using the
 // latest protocol version and returns a hex-encoded string of the result.
 func messageToHex(msg wire.Message) (string, error) {
@@ -339,6 +379,9 @@ func hashesToGetBlockVerboseResults(s *Server, hashes []*daghash.Hash) ([]rpcmod
 	getBlockVerboseResults := make([]rpcmodel.GetBlockVerboseResult, 0, len(hashes))
 	for _, blockHash := range hashes {
 		block, err := s.cfg.DAG.BlockByHash(blockHash)
+		if blockdag.IsPrunedErr(err) {
+			return nil, rpcBlockPrunedError(blockHash)
+		}
 		if err != nil {
 			return nil, &rpcmodel.RPCError{
 				Code:    rpcmodel.ErrRPCInternal.Code,
@@ -0,0 +1,13 @@
+package rpc
+
+// handleClearBanned implements the clearBanned command.
+func handleClearBanned(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	err := s.cfg.ConnMgr.ClearBannedSubnets()
+	if err != nil {
+		context := "Failed to clear the ban list"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	// no data returned unless an error.
+	return nil, nil
+}
@@ -0,0 +1,16 @@
+package rpc
+
+// handleClearMempool implements the clearMempool command.
+func handleClearMempool(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	removedTxs, err := s.cfg.TxMemPool.Clear()
+	if err != nil {
+		context := "Failed to clear the mempool"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	removedTxIDs := make([]string, len(removedTxs))
+	for i, tx := range removedTxs {
+		removedTxIDs[i] = tx.ID().String()
+	}
+	return removedTxIDs, nil
+}
@@ -0,0 +1,92 @@
+package rpc
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/config"
+	"github.com/kaspanet/kaspad/rpcmodel"
+)
+
+// handleDumpUTXOSnapshot handles dumpUTXOSnapshot commands.
+func handleDumpUTXOSnapshot(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*rpcmodel.DumpUTXOSnapshotCmd)
+
+	// Snapshots may only be written into the data directory, so that
+	// RPC clients can't overwrite or create files anywhere else.
+	path, ok := utxoSnapshotPath(config.ActiveConfig().DataDir, c.Path)
+	if !ok {
+		return nil, &rpcmodel.RPCError{
+			Code: rpcmodel.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("%s is not a relative path within "+
+				"the data directory", c.Path),
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return nil, &rpcmodel.RPCError{
+			Code:    rpcmodel.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("%s already exists", path),
+		}
+	}
+
+	// Write the snapshot to a temporary file first, so that a partially
+	// written snapshot is never mistaken for a complete one.
+	tempPath := path + ".incomplete"
+	info, err := writeUTXOSnapshotFile(s, tempPath)
+	if err != nil {
+		os.Remove(tempPath)
+		context := "Failed to write UTXO snapshot"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	err = os.Rename(tempPath, path)
+	if err != nil {
+		context := "Failed to rename UTXO snapshot file"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	return &rpcmodel.DumpUTXOSnapshotResult{
+		Path:                   path,
+		FinalityPointHash:      info.FinalityPointHash.String(),
+		FinalityPointBlueScore: info.FinalityPointBlueScore,
+		UTXOCount:              info.UTXOCount,
+		BlockCount:             info.BlockCount,
+	}, nil
+}
+
+// utxoSnapshotPath joins the given relative path to the data directory. It
+// returns false if the path is absolute or leads outside of the data
+// directory.
+func utxoSnapshotPath(dataDir string, path string) (string, bool) {
+	if path == "" || filepath.IsAbs(path) || filepath.VolumeName(path) != "" {
+		return "", false
+	}
+	cleanPath := filepath.Clean(path)
+	if cleanPath == "." || cleanPath == ".." ||
+		strings.HasPrefix(cleanPath, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.Join(dataDir, cleanPath), true
+}
+
+func writeUTXOSnapshotFile(s *Server, path string) (*blockdag.UTXOSnapshotInfo, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	info, err := s.cfg.DAG.WriteUTXOSnapshot(w)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Flush()
+	if err != nil {
+		return nil, err
+	}
+	return info, file.Sync()
+}
@@ -0,0 +1,38 @@
+package rpc
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestUTXOSnapshotPath(t *testing.T) {
+	dataDir := filepath.Join("home", "kaspad", "data")
+	tests := []struct {
+		path         string
+		expectedPath string
+		expectedOK   bool
+	}{
+		{path: "snapshot", expectedPath: filepath.Join(dataDir, "snapshot"), expectedOK: true},
+		{path: filepath.Join("snapshots", "..", "snapshot"), expectedPath: filepath.Join(dataDir, "snapshot"), expectedOK: true},
+		{path: filepath.Join("snapshots", "snapshot"), expectedPath: filepath.Join(dataDir, "snapshots", "snapshot"), expectedOK: true},
+		{path: "", expectedOK: false},
+		{path: ".", expectedOK: false},
+		{path: "..", expectedOK: false},
+		{path: filepath.Join("..", "snapshot"), expectedOK: false},
+		{path: filepath.Join("snapshots", "..", "..", "snapshot"), expectedOK: false},
+		{path: string(filepath.Separator) + filepath.Join("tmp", "snapshot"), expectedOK: false},
+	}
+
+	for _, test := range tests {
+		path, ok := utxoSnapshotPath(dataDir, test.path)
+		if ok != test.expectedOK {
+			t.Errorf("utxoSnapshotPath(%q): unexpected ok. Want: %t, got: %t",
+				test.path, test.expectedOK, ok)
+			continue
+		}
+		if path != test.expectedPath {
+			t.Errorf("utxoSnapshotPath(%q): unexpected path. Want: %s, got: %s",
+				test.path, test.expectedPath, path)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package rpc
+
+import (
+	"fmt"
+	"github.com/kaspanet/kaspad/mempool/feeestimator"
+	"github.com/kaspanet/kaspad/rpcmodel"
+)
+
+// handleEstimateFee implements the estimateFee command.
+func handleEstimateFee(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*rpcmodel.EstimateFeeCmd)
+
+	if c.TargetBlueScoreDelta == 0 || c.TargetBlueScoreDelta > feeestimator.MaxTargetBlueScoreDelta {
+		return nil, &rpcmodel.RPCError{
+			Code: rpcmodel.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("targetBlueScoreDelta must be between 1 and %d",
+				feeestimator.MaxTargetBlueScoreDelta),
+		}
+	}
+
+	return estimateFee(s, c.TargetBlueScoreDelta)
+}
+
+// estimateFee returns the fee rate, in sompi per gram, a transaction should
+// pay in order to be accepted by the virtual block within the given number
+// of blue score units. If the fee estimator does not have enough data to
+// estimate that, the default fee rate is returned instead.
+func estimateFee(s *Server, targetBlueScoreDelta uint64) (*rpcmodel.EstimateFeeResult, error) {
+	feePerMegaGram, err := s.cfg.FeeEstimator.EstimateFee(targetBlueScoreDelta)
+	if err == feeestimator.ErrInsufficientData {
+		return &rpcmodel.EstimateFeeResult{
+			TargetBlueScoreDelta: targetBlueScoreDelta,
+			FeeRate:              float64(feeestimator.DefaultFeeRate) / 1e6,
+			IsEstimated:          false,
+		}, nil
+	}
+	if err != nil {
+		context := "Failed to estimate fee"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	return &rpcmodel.EstimateFeeResult{
+		TargetBlueScoreDelta: targetBlueScoreDelta,
+		FeeRate:              float64(feePerMegaGram) / 1e6,
+		IsEstimated:          true,
+	}, nil
+}
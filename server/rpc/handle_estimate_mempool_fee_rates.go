@@ -0,0 +1,25 @@
+package rpc
+
+import (
+	"github.com/kaspanet/kaspad/rpcmodel"
+)
+
+// mempoolFeeRateTargets are the target blue score deltas for which the
+// estimateMempoolFeeRates command returns fee rate estimates.
+var mempoolFeeRateTargets = []uint64{1, 2, 5, 10, 20, 50, 100}
+
+// handleEstimateMempoolFeeRates implements the estimateMempoolFeeRates command.
+func handleEstimateMempoolFeeRates(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	estimates := make([]rpcmodel.EstimateFeeResult, len(mempoolFeeRateTargets))
+	for i, targetBlueScoreDelta := range mempoolFeeRateTargets {
+		estimate, err := estimateFee(s, targetBlueScoreDelta)
+		if err != nil {
+			return nil, err
+		}
+		estimates[i] = *estimate
+	}
+
+	return &rpcmodel.EstimateMempoolFeeRatesResult{
+		Estimates: estimates,
+	}, nil
+}
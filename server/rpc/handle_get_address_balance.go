@@ -0,0 +1,44 @@
+package rpc
+
+import (
+	"fmt"
+	"github.com/kaspanet/kaspad/blockdag/indexers"
+	"github.com/kaspanet/kaspad/rpcmodel"
+)
+
+const (
+	// maxUTXOsInGetAddressBalance is the max amount of UTXOs that
+	// getAddressBalance sums up. Addresses that have more UTXOs
+	// should be queried with getAddressUTXOs.
+	maxUTXOsInGetAddressBalance = 100000
+)
+
+// handleGetAddressBalance implements the getAddressBalance command.
+func handleGetAddressBalance(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if s.cfg.AddressIndex == nil {
+		return nil, rpcNoAddressIndexError()
+	}
+
+	c := cmd.(*rpcmodel.GetAddressBalanceCmd)
+	scriptPubKey, err := addressToScriptPubKey(c.Address, s.cfg.DAGParams)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, utxoCount, err := s.cfg.AddressIndex.BalanceByScriptPubKey(scriptPubKey, maxUTXOsInGetAddressBalance)
+	if indexers.IsTooManyUTXOsErr(err) {
+		return nil, rpcmodel.NewRPCError(rpcmodel.ErrRPCTooManyUTXOs,
+			fmt.Sprintf("Address %s has more than %d unspent outputs. "+
+				"Use getAddressUTXOs to page through them", c.Address, maxUTXOsInGetAddressBalance))
+	}
+	if err != nil {
+		context := "Failed to retrieve address UTXOs"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	return &rpcmodel.GetAddressBalanceResult{
+		Address:   c.Address,
+		Balance:   balance,
+		UTXOCount: utxoCount,
+	}, nil
+}
@@ -0,0 +1,53 @@
+package rpc
+
+import (
+	"github.com/kaspanet/kaspad/rpcmodel"
+)
+
+const (
+	// maxTxsInGetAddressTransactionsResult is the max amount of
+	// transactions that are allowed in a GetAddressTransactionsResult.
+	maxTxsInGetAddressTransactionsResult = 1000
+)
+
+// handleGetAddressTransactions implements the getAddressTransactions command.
+func handleGetAddressTransactions(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if s.cfg.AddressIndex == nil {
+		return nil, rpcNoAddressIndexError()
+	}
+
+	c := cmd.(*rpcmodel.GetAddressTransactionsCmd)
+	scriptPubKey, err := addressToScriptPubKey(c.Address, s.cfg.DAGParams)
+	if err != nil {
+		return nil, err
+	}
+
+	skip := uint64(0)
+	if c.Skip != nil {
+		skip = *c.Skip
+	}
+	count := uint64(maxTxsInGetAddressTransactionsResult)
+	if c.Count != nil && *c.Count < count {
+		count = *c.Count
+	}
+
+	addressTxs, err := s.cfg.AddressIndex.TxsByScriptPubKey(scriptPubKey, skip, count)
+	if err != nil {
+		context := "Failed to retrieve address transactions"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	transactions := make([]rpcmodel.AddressTransaction, len(addressTxs))
+	for i, addressTx := range addressTxs {
+		transactions[i] = rpcmodel.AddressTransaction{
+			TxID:               addressTx.TxID.String(),
+			AcceptingBlockHash: addressTx.AcceptingBlockHash.String(),
+			AcceptingBlueScore: addressTx.AcceptingBlueScore,
+		}
+	}
+
+	return &rpcmodel.GetAddressTransactionsResult{
+		Address:      c.Address,
+		Transactions: transactions,
+	}, nil
+}
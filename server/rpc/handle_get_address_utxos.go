@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"github.com/kaspanet/kaspad/rpcmodel"
+)
+
+const (
+	// maxUTXOsInGetAddressUTXOsResult is the max amount of UTXOs
+	// that are allowed in a GetAddressUTXOsResult.
+	maxUTXOsInGetAddressUTXOsResult = 1000
+)
+
+// handleGetAddressUTXOs implements the getAddressUTXOs command.
+func handleGetAddressUTXOs(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if s.cfg.AddressIndex == nil {
+		return nil, rpcNoAddressIndexError()
+	}
+
+	c := cmd.(*rpcmodel.GetAddressUTXOsCmd)
+	scriptPubKey, err := addressToScriptPubKey(c.Address, s.cfg.DAGParams)
+	if err != nil {
+		return nil, err
+	}
+
+	skip := uint64(0)
+	if c.Skip != nil {
+		skip = *c.Skip
+	}
+	count := uint64(maxUTXOsInGetAddressUTXOsResult)
+	if c.Count != nil && *c.Count < count {
+		count = *c.Count
+	}
+
+	utxos, err := s.cfg.AddressIndex.UTXOsByScriptPubKey(scriptPubKey, skip, count)
+	if err != nil {
+		context := "Failed to retrieve address UTXOs"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	results := make([]rpcmodel.AddressUTXO, len(utxos))
+	for i, utxo := range utxos {
+		results[i] = rpcmodel.AddressUTXO{
+			TxID:           utxo.Outpoint.TxID.String(),
+			Index:          utxo.Outpoint.Index,
+			Amount:         utxo.Entry.Amount(),
+			ScriptPubKey:   hex.EncodeToString(utxo.Entry.ScriptPubKey()),
+			BlockBlueScore: utxo.Entry.BlockBlueScore(),
+			IsCoinbase:     utxo.Entry.IsCoinbase(),
+		}
+	}
+
+	return &rpcmodel.GetAddressUTXOsResult{
+		Address: c.Address,
+		UTXOs:   results,
+	}, nil
+}
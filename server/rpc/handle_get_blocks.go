@@ -2,6 +2,7 @@ package rpc
 
 import (
 	"encoding/hex"
+	"github.com/kaspanet/kaspad/blockdag"
 	"github.com/kaspanet/kaspad/rpcmodel"
 	"github.com/kaspanet/kaspad/util"
 	"github.com/kaspanet/kaspad/util/daghash"
@@ -78,6 +79,9 @@ func hashesToBlockBytes(s *Server, hashes []*daghash.Hash) ([][]byte, error) {
 	blocks := make([][]byte, len(hashes))
 	for i, hash := range hashes {
 		block, err := s.cfg.DAG.BlockByHash(hash)
+		if blockdag.IsPrunedErr(err) {
+			return nil, rpcBlockPrunedError(hash)
+		}
 		if err != nil {
 			return nil, err
 		}
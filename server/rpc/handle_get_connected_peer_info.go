@@ -1,11 +1,18 @@
 package rpc
 
 import (
+	"encoding/hex"
 	"fmt"
 	"github.com/kaspanet/kaspad/rpcmodel"
 	"time"
 )
 
+// The values of the transport field of getConnectedPeerInfo results.
+const (
+	peerTransportPlaintext = "plaintext"
+	peerTransportEncrypted = "encrypted"
+)
+
 // handleGetConnectedPeerInfo implements the getConnectedPeerInfo command.
 func handleGetConnectedPeerInfo(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	peers := s.cfg.ConnMgr.ConnectedPeers()
@@ -17,7 +24,7 @@ func handleGetConnectedPeerInfo(s *Server, cmd interface{}, closeChan <-chan str
 			ID:          statsSnap.ID,
 			Addr:        statsSnap.Addr,
 			Services:    fmt.Sprintf("%08d", uint64(statsSnap.Services)),
-			RelayTxes:   !p.IsTxRelayDisabled(),
+			RelayTxes:   !p.IsTxRelayDisabled() && !p.IsBlockRelayOnly(),
 			LastSend:    statsSnap.LastSend.Unix(),
 			LastRecv:    statsSnap.LastRecv.Unix(),
 			BytesSent:   statsSnap.BytesSent,
@@ -32,6 +39,15 @@ func handleGetConnectedPeerInfo(s *Server, cmd interface{}, closeChan <-chan str
 			BanScore:    int32(p.BanScore()),
 			FeeFilter:   p.FeeFilter(),
 			SyncNode:    statsSnap.ID == syncPeerID,
+			Transport:   peerTransportPlaintext,
+
+			BlockRelayOnly: p.IsBlockRelayOnly(),
+		}
+		if statsSnap.IsEncrypted {
+			info.Transport = peerTransportEncrypted
+			if statsSnap.IdentityPubKey != nil {
+				info.IdentityPubKey = hex.EncodeToString(statsSnap.IdentityPubKey)
+			}
 		}
 		if p.ToPeer().LastPingNonce() != 0 {
 			wait := float64(time.Since(statsSnap.LastPingTime).Nanoseconds())
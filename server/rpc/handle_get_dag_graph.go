@@ -0,0 +1,264 @@
+package rpc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/kaspanet/kaspad/blockdag"
+	"github.com/kaspanet/kaspad/rpcmodel"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxDAGGraphLevels is the maximum number of blue score levels that
+	// a single getDagGraph command may export.
+	maxDAGGraphLevels = 1000
+
+	// maxDAGGraphDepth is the maximum distance in blue score between the
+	// selected tip and the block that a getDagGraph command exports the
+	// window around, since the window is found by traversing the DAG from
+	// its tips.
+	maxDAGGraphDepth = 10000
+
+	// maxDAGGraphBlocks is the maximum number of blocks that a single
+	// getDagGraph command may traverse.
+	maxDAGGraphBlocks = 20000
+
+	dagGraphFormatJSON    = "json"
+	dagGraphFormatDOT     = "dot"
+	dagGraphFormatGraphML = "graphml"
+)
+
+// handleGetDAGGraph implements the getDagGraph command.
+func handleGetDAGGraph(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*rpcmodel.GetDAGGraphCmd)
+
+	format := *c.Format
+	if format != dagGraphFormatJSON && format != dagGraphFormatDOT && format != dagGraphFormatGraphML {
+		return nil, &rpcmodel.RPCError{
+			Code: rpcmodel.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Unknown format %s -- supported formats are %s, %s and %s",
+				format, dagGraphFormatJSON, dagGraphFormatDOT, dagGraphFormatGraphML),
+		}
+	}
+	levels := *c.Levels
+	if levels == 0 || levels > maxDAGGraphLevels {
+		return nil, &rpcmodel.RPCError{
+			Code:    rpcmodel.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Levels must be between 1 and %d", maxDAGGraphLevels),
+		}
+	}
+
+	var hash *daghash.Hash
+	if c.Hash != nil {
+		var err error
+		hash, err = daghash.NewHashFromStr(*c.Hash)
+		if err != nil {
+			return nil, rpcDecodeHexError(*c.Hash)
+		}
+		if !s.cfg.DAG.IsInDAG(hash) || s.cfg.DAG.IsKnownInvalid(hash) {
+			return nil, &rpcmodel.RPCError{
+				Code:    rpcmodel.ErrRPCBlockNotFound,
+				Message: "Block not found",
+			}
+		}
+		blueScore, err := s.cfg.DAG.BlueScoreByBlockHash(hash)
+		if err != nil {
+			context := "Failed to get the blue score of the block"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		if s.cfg.DAG.SelectedTipBlueScore() > blueScore+maxDAGGraphDepth {
+			return nil, &rpcmodel.RPCError{
+				Code: rpcmodel.ErrRPCInvalidParameter,
+				Message: fmt.Sprintf("The blue score of the block must be at most %d "+
+					"below the blue score of the selected tip", maxDAGGraphDepth),
+			}
+		}
+	}
+
+	blocks, err := s.cfg.DAG.DAGGraph(hash, levels, maxDAGGraphBlocks)
+	if err != nil {
+		if errors.As(err, &blockdag.DAGGraphTooLargeError{}) {
+			return nil, &rpcmodel.RPCError{
+				Code:    rpcmodel.ErrRPCInvalidParameter,
+				Message: fmt.Sprintf("The window is too large: %s", err),
+			}
+		}
+		context := "Failed to export the DAG graph"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	result := buildGetDAGGraphResult(blocks)
+
+	switch format {
+	case dagGraphFormatDOT:
+		return dagGraphDOT(result), nil
+	case dagGraphFormatGraphML:
+		graphML, err := dagGraphGraphML(result)
+		if err != nil {
+			context := "Failed to marshal the DAG graph"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		return graphML, nil
+	default:
+		return result, nil
+	}
+}
+
+func buildGetDAGGraphResult(blocks []*blockdag.DAGGraphBlock) *rpcmodel.GetDAGGraphResult {
+	result := &rpcmodel.GetDAGGraphResult{
+		Blocks: make([]rpcmodel.GetDAGGraphResultBlock, len(blocks)),
+	}
+	for i, block := range blocks {
+		resultBlock := rpcmodel.GetDAGGraphResultBlock{
+			Hash:                      block.Hash.String(),
+			ParentHashes:              daghash.Strings(block.ParentHashes),
+			BlueScore:                 block.BlueScore,
+			IsBlue:                    block.IsBlue,
+			MergeSetBlueHashes:        daghash.Strings(block.MergeSetBlueHashes),
+			MergeSetRedHashes:         daghash.Strings(block.MergeSetRedHashes),
+			ReachabilityIntervalStart: block.ReachabilityIntervalStart,
+			ReachabilityIntervalEnd:   block.ReachabilityIntervalEnd,
+		}
+		if block.SelectedParentHash != nil {
+			resultBlock.SelectedParentHash = block.SelectedParentHash.String()
+		}
+		result.Blocks[i] = resultBlock
+	}
+	return result
+}
+
+// dagGraphEdges calls the given function for every edge of the DAG graph
+// whose both ends are in the graph, in the order of the blocks.
+func dagGraphEdges(result *rpcmodel.GetDAGGraphResult,
+	f func(block *rpcmodel.GetDAGGraphResultBlock, parentHash string, isSelectedParent bool)) {
+
+	inGraph := make(map[string]struct{}, len(result.Blocks))
+	for i := range result.Blocks {
+		block := &result.Blocks[i]
+		for _, parentHash := range block.ParentHashes {
+			if _, ok := inGraph[parentHash]; ok {
+				f(block, parentHash, parentHash == block.SelectedParentHash)
+			}
+		}
+		inGraph[block.Hash] = struct{}{}
+	}
+}
+
+// dagGraphDOT returns the DAG graph in the DOT language of Graphviz. Blocks
+// point to their parents, blue and red blocks are filled with the respective
+// colors, and the edges to selected parents are drawn in bold.
+func dagGraphDOT(result *rpcmodel.GetDAGGraphResult) string {
+	var builder strings.Builder
+	builder.WriteString("digraph dag {\n")
+	builder.WriteString("\trankdir=RL;\n")
+	builder.WriteString("\tnode [shape=box, style=filled];\n")
+	for _, block := range result.Blocks {
+		color := "lightcoral"
+		if block.IsBlue {
+			color = "lightblue"
+		}
+		fmt.Fprintf(&builder, "\t\"%s\" [label=\"%s\\nblue score %d\\n[%d, %d]\", fillcolor=%s];\n",
+			block.Hash, block.Hash[:8], block.BlueScore,
+			block.ReachabilityIntervalStart, block.ReachabilityIntervalEnd, color)
+	}
+	dagGraphEdges(result, func(block *rpcmodel.GetDAGGraphResultBlock, parentHash string, isSelectedParent bool) {
+		style := ""
+		if isSelectedParent {
+			style = " [style=bold]"
+		}
+		fmt.Fprintf(&builder, "\t\"%s\" -> \"%s\"%s;\n", block.Hash, parentHash, style)
+	})
+	builder.WriteString("}")
+	return builder.String()
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// dagGraphGraphML returns the DAG graph in the GraphML format. Blocks point
+// to their parents, and the rest of the data of the blocks is kept in node
+// and edge attributes.
+func dagGraphGraphML(result *rpcmodel.GetDAGGraphResult) (string, error) {
+	document := graphMLDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "blueScore", For: "node", Name: "blueScore", Type: "long"},
+			{ID: "isBlue", For: "node", Name: "isBlue", Type: "boolean"},
+			{ID: "selectedParentHash", For: "node", Name: "selectedParentHash", Type: "string"},
+			{ID: "mergeSetBlueHashes", For: "node", Name: "mergeSetBlueHashes", Type: "string"},
+			{ID: "mergeSetRedHashes", For: "node", Name: "mergeSetRedHashes", Type: "string"},
+			{ID: "reachabilityIntervalStart", For: "node", Name: "reachabilityIntervalStart", Type: "long"},
+			{ID: "reachabilityIntervalEnd", For: "node", Name: "reachabilityIntervalEnd", Type: "long"},
+			{ID: "isSelectedParent", For: "edge", Name: "isSelectedParent", Type: "boolean"},
+		},
+		Graph: graphMLGraph{
+			ID:          "dag",
+			EdgeDefault: "directed",
+		},
+	}
+	for _, block := range result.Blocks {
+		document.Graph.Nodes = append(document.Graph.Nodes, graphMLNode{
+			ID: block.Hash,
+			Data: []graphMLData{
+				{Key: "blueScore", Value: fmt.Sprint(block.BlueScore)},
+				{Key: "isBlue", Value: fmt.Sprint(block.IsBlue)},
+				{Key: "selectedParentHash", Value: block.SelectedParentHash},
+				{Key: "mergeSetBlueHashes", Value: strings.Join(block.MergeSetBlueHashes, ",")},
+				{Key: "mergeSetRedHashes", Value: strings.Join(block.MergeSetRedHashes, ",")},
+				{Key: "reachabilityIntervalStart", Value: fmt.Sprint(block.ReachabilityIntervalStart)},
+				{Key: "reachabilityIntervalEnd", Value: fmt.Sprint(block.ReachabilityIntervalEnd)},
+			},
+		})
+	}
+	dagGraphEdges(result, func(block *rpcmodel.GetDAGGraphResultBlock, parentHash string, isSelectedParent bool) {
+		document.Graph.Edges = append(document.Graph.Edges, graphMLEdge{
+			Source: block.Hash,
+			Target: parentHash,
+			Data: []graphMLData{
+				{Key: "isSelectedParent", Value: fmt.Sprint(isSelectedParent)},
+			},
+		})
+	})
+
+	serialized, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(serialized), nil
+}
@@ -5,9 +5,10 @@ import (
 	"encoding/hex"
 	"github.com/kaspanet/kaspad/rpcmodel"
 	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
 )
 
-const getHeadersMaxHeaders = 2000
+const getHeadersMaxHeaders = wire.MaxBlockHeadersPerMsg
 
 // handleGetHeaders implements the getHeaders command.
 func handleGetHeaders(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
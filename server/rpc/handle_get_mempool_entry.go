@@ -5,16 +5,21 @@ import (
 	"github.com/kaspanet/kaspad/util/daghash"
 )
 
+// handleGetMempoolEntry implements the getMempoolEntry command.
 func handleGetMempoolEntry(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*rpcmodel.GetMempoolEntryCmd)
 	txID, err := daghash.NewTxIDFromStr(c.TxID)
 	if err != nil {
-		return nil, err
+		return nil, rpcDecodeHexError(c.TxID)
 	}
 
 	txDesc, err := s.cfg.TxMemPool.FetchTxDesc(txID)
 	if err != nil {
-		return nil, err
+		return nil, rpcNoTxInfoError(txID)
+	}
+	depends, spentBy, err := s.cfg.TxMemPool.FetchTxRelatives(txID)
+	if err != nil {
+		return nil, rpcNoTxInfoError(txID)
 	}
 
 	tx := txDesc.Tx
@@ -25,8 +30,19 @@ func handleGetMempoolEntry(s *Server, cmd interface{}, closeChan <-chan struct{}
 	}
 
 	return &rpcmodel.GetMempoolEntryResult{
-		Fee:   txDesc.Fee,
-		Time:  txDesc.Added.Unix(),
-		RawTx: *rawTx,
+		Fee:     txDesc.Fee,
+		Mass:    txDesc.Mass,
+		Time:    txDesc.Added.Unix(),
+		Depends: txIDsToStrings(depends),
+		SpentBy: txIDsToStrings(spentBy),
+		RawTx:   *rawTx,
 	}, nil
 }
+
+func txIDsToStrings(txIDs []*daghash.TxID) []string {
+	txIDStrings := make([]string, len(txIDs))
+	for i, txID := range txIDs {
+		txIDStrings[i] = txID.String()
+	}
+	return txIDStrings
+}
@@ -1,6 +1,9 @@
 package rpc
 
-import "github.com/kaspanet/kaspad/rpcmodel"
+import (
+	"github.com/kaspanet/kaspad/config"
+	"github.com/kaspanet/kaspad/rpcmodel"
+)
 
 // handleGetMempoolInfo implements the getMempoolInfo command.
 func handleGetMempoolInfo(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
@@ -11,9 +14,19 @@ func handleGetMempoolInfo(s *Server, cmd interface{}, closeChan <-chan struct{})
 		numBytes += int64(txD.Tx.MsgTx().SerializeSize())
 	}
 
+	// The minimum relay fee is defined per kB, which roughly equals a
+	// kilogram of mass.
+	minFeeRate := float64(config.ActiveConfig().MinRelayTxFee) / 1e3
+	if dynamicMinFeeRate := float64(s.cfg.TxMemPool.MinFeeRate()) / 1e6; dynamicMinFeeRate > minFeeRate {
+		minFeeRate = dynamicMinFeeRate
+	}
+
 	ret := &rpcmodel.GetMempoolInfoResult{
-		Size:  int64(len(mempoolTxns)),
-		Bytes: numBytes,
+		Size:       int64(len(mempoolTxns)),
+		Bytes:      numBytes,
+		Mass:       s.cfg.TxMemPool.TotalMass(),
+		MaxMass:    config.ActiveConfig().MaxMempool,
+		MinFeeRate: minFeeRate,
 	}
 
 	return ret, nil
@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"github.com/kaspanet/kaspad/config"
+	"github.com/kaspanet/kaspad/rpcmodel"
+	"github.com/kaspanet/kaspad/util"
+	"github.com/kaspanet/kaspad/version"
+	"github.com/kaspanet/kaspad/wire"
+	"net"
+)
+
+// handleGetNetworkInfo implements the getNetworkInfo command.
+func handleGetNetworkInfo(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	activeConfig := config.ActiveConfig()
+
+	// Clearnet networks are reachable either through the configured
+	// proxy or, without one, through a local interface that has a
+	// global address of the network. Overlay network addresses can
+	// only be reached when a proxy is configured for their network.
+	proxy := activeConfig.Proxy
+	hasIPv4, hasIPv6 := globalInterfaceAddresses()
+	networks := []rpcmodel.NetworksResult{
+		{Name: wire.NetworkIPv4.String(), Reachable: proxy != "" || hasIPv4, Proxy: proxy},
+		{Name: wire.NetworkIPv6.String(), Reachable: proxy != "" || hasIPv6, Proxy: proxy},
+	}
+	for _, network := range wire.OverlayNetworks {
+		overlayProxy, ok := activeConfig.OverlayProxies[network]
+		networkResult := rpcmodel.NetworksResult{
+			Name:      network.String(),
+			Limited:   !ok,
+			Reachable: ok,
+		}
+		if ok {
+			networkResult.Proxy = overlayProxy.Addr
+		}
+		networks = append(networks, networkResult)
+	}
+
+	localAddresses := make([]rpcmodel.LocalAddressesResult, 0)
+	for _, localAddress := range s.cfg.addressManager.LocalAddresses() {
+		localAddresses = append(localAddresses, rpcmodel.LocalAddressesResult{
+			Address: localAddress.Address.Host(),
+			Port:    localAddress.Address.Port,
+			Score:   int32(localAddress.Score),
+		})
+	}
+
+	// The relay fee is raised above the configured minimum relay fee
+	// while the mempool is full. Mempool fee rates are defined in
+	// sompi per megagram, and a kB roughly equals a kilogram of mass.
+	relayFee := activeConfig.MinRelayTxFee
+	if dynamicRelayFee := util.Amount(s.cfg.TxMemPool.MinFeeRate() / 1e3); dynamicRelayFee > relayFee {
+		relayFee = dynamicRelayFee
+	}
+	incrementalFee := util.Amount(s.cfg.TxMemPool.IncrementalFeeRate() / 1e3)
+
+	return &rpcmodel.GetNetworkInfoResult{
+		Version:         version.NumericVersion(),
+		SubVersion:      s.cfg.UserAgent,
+		ProtocolVersion: int32(maxProtocolVersion),
+		LocalServices:   s.cfg.LocalServices.String(),
+		LocalRelay:      !activeConfig.BlocksOnly,
+		Connections:     s.cfg.ConnMgr.ConnectedCount(),
+		NetworkActive:   s.cfg.ConnMgr.IsNetworkActive(),
+		Networks:        networks,
+		RelayFee:        relayFee.ToKAS(),
+		IncrementalFee:  incrementalFee.ToKAS(),
+		LocalAddresses:  localAddresses,
+	}, nil
+}
+
+// globalInterfaceAddresses returns whether any of the local network
+// interfaces has a global unicast IPv4 address, and whether any of them
+// has a global unicast IPv6 address.
+func globalInterfaceAddresses() (hasIPv4 bool, hasIPv6 bool) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		log.Warnf("Failed to list the local interface addresses: %s", err)
+		return false, false
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			hasIPv4 = true
+		} else {
+			hasIPv6 = true
+		}
+	}
+	return hasIPv4, hasIPv6
+}
@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/kaspanet/kaspad/dbaccess"
+	"github.com/kaspanet/kaspad/rpcmodel"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/kaspanet/kaspad/wire"
+)
+
+// handleGetRawTransaction implements the getRawTransaction command.
+func handleGetRawTransaction(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*rpcmodel.GetRawTransactionCmd)
+
+	// Convert the provided transaction hash hex to a TxID.
+	txID, err := daghash.NewTxIDFromStr(c.TxID)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.TxID)
+	}
+
+	verbose := false
+	if c.Verbose != nil {
+		verbose = *c.Verbose
+	}
+
+	// Try to fetch the transaction from the memory pool and if that fails,
+	// try the transaction index.
+	var mtx *wire.MsgTx
+	var blockHash *daghash.Hash
+	var acceptingBlockHash *daghash.Hash
+	isInMempool := false
+	tx, err := s.cfg.TxMemPool.FetchTransaction(txID)
+	if err == nil {
+		mtx = tx.MsgTx()
+		isInMempool = true
+	} else {
+		if s.cfg.TxIndex == nil {
+			return nil, &rpcmodel.RPCError{
+				Code: rpcmodel.ErrRPCNoTxIndex,
+				Message: "The transaction index must be " +
+					"enabled to query the DAG " +
+					"(specify --txindex)",
+			}
+		}
+
+		mtx, blockHash, acceptingBlockHash, err = s.cfg.TxIndex.TxByID(txID)
+		if dbaccess.IsNotFoundError(err) {
+			return nil, rpcNoTxInfoError(txID)
+		}
+		if err != nil {
+			context := "Failed to retrieve transaction from the transaction index"
+			return nil, internalRPCError(err.Error(), context)
+		}
+	}
+
+	// When the verbose flag isn't set, simply return the
+	// network-serialized transaction as a hex-encoded string.
+	if !verbose {
+		var buf bytes.Buffer
+		buf.Grow(mtx.SerializeSize())
+		err := mtx.Serialize(&buf)
+		if err != nil {
+			context := "Failed to serialize transaction"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		return hex.EncodeToString(buf.Bytes()), nil
+	}
+
+	var blockHeader *wire.BlockHeader
+	var blockHashStr string
+	if blockHash != nil {
+		blockHeader, err = s.cfg.DAG.HeaderByHash(blockHash)
+		if err != nil {
+			context := "Failed to retrieve block header"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		blockHashStr = blockHash.String()
+	}
+
+	rawTxn, err := createTxRawResult(s.cfg.DAGParams, mtx, txID.String(),
+		blockHeader, blockHashStr, acceptingBlockHash, isInMempool)
+	if err != nil {
+		return nil, err
+	}
+	return rawTxn, nil
+}
@@ -0,0 +1,24 @@
+package rpc
+
+import (
+	"github.com/kaspanet/kaspad/rpcmodel"
+	"github.com/kaspanet/kaspad/util"
+)
+
+// handleGetTxOutSetInfo handles getTxOutSetInfo commands.
+func handleGetTxOutSetInfo(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	stats, err := s.cfg.DAG.UTXOSetStats()
+	if err != nil {
+		context := "Failed to calculate UTXO set statistics"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	return &rpcmodel.GetTxOutSetInfoResult{
+		SelectedTipHash:  stats.SelectedTipHash.String(),
+		VirtualBlueScore: stats.VirtualBlueScore,
+		UTXOCount:        stats.UTXOCount,
+		TotalAmount:      util.Amount(stats.TotalAmount).ToKAS(),
+		SerializedSize:   stats.SerializedSize,
+		MultisetHash:     stats.MultisetHash.String(),
+	}, nil
+}
@@ -0,0 +1,18 @@
+package rpc
+
+import "github.com/kaspanet/kaspad/rpcmodel"
+
+// handleListBanned implements the listBanned command.
+func handleListBanned(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	bannedSubnets := s.cfg.ConnMgr.BannedSubnets()
+	results := make([]*rpcmodel.ListBannedResult, len(bannedSubnets))
+	for i, bannedSubnet := range bannedSubnets {
+		results[i] = &rpcmodel.ListBannedResult{
+			Address:     bannedSubnet.Subnet.String(),
+			BanCreated:  bannedSubnet.CreatedAt.Unix(),
+			BannedUntil: bannedSubnet.Expiry.Unix(),
+			Reason:      bannedSubnet.Reason,
+		}
+	}
+	return results, nil
+}
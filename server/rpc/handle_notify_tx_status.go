@@ -0,0 +1,36 @@
+package rpc
+
+import (
+	"github.com/kaspanet/kaspad/rpcmodel"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// handleNotifyTxStatus implements the notifyTxStatus command extension for
+// websocket connections.
+func handleNotifyTxStatus(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*rpcmodel.NotifyTxStatusCmd)
+	if !ok {
+		return nil, rpcmodel.ErrRPCInternal
+	}
+
+	txIDs, err := decodeTxIDs(cmd.TxIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	wsc.server.ntfnMgr.RegisterTxStatusUpdates(wsc, txIDs)
+	return nil, nil
+}
+
+// decodeTxIDs decodes the passed hex-encoded transaction IDs.
+func decodeTxIDs(txIDStrs []string) ([]*daghash.TxID, error) {
+	txIDs := make([]*daghash.TxID, len(txIDStrs))
+	for i, txIDStr := range txIDStrs {
+		txID, err := daghash.NewTxIDFromStr(txIDStr)
+		if err != nil {
+			return nil, rpcDecodeHexError(txIDStr)
+		}
+		txIDs[i] = txID
+	}
+	return txIDs, nil
+}
@@ -0,0 +1,30 @@
+package rpc
+
+import (
+	"github.com/kaspanet/kaspad/rpcmodel"
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// handleRemoveMempoolTransaction implements the removeMempoolTransaction command.
+func handleRemoveMempoolTransaction(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*rpcmodel.RemoveMempoolTransactionCmd)
+	txID, err := daghash.NewTxIDFromStr(c.TxID)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.TxID)
+	}
+
+	if !s.cfg.TxMemPool.IsTransactionInPool(txID) {
+		return nil, rpcNoTxInfoError(txID)
+	}
+	removedTxs, err := s.cfg.TxMemPool.RemoveTransactionWithDescendants(txID)
+	if err != nil {
+		context := "Failed to remove the transaction from the mempool"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	removedTxIDs := make([]string, len(removedTxs))
+	for i, tx := range removedTxs {
+		removedTxIDs[i] = tx.ID().String()
+	}
+	return removedTxIDs, nil
+}
@@ -2,6 +2,7 @@ package rpc
 
 import (
 	"fmt"
+	"github.com/kaspanet/kaspad/blockdag"
 	"github.com/kaspanet/kaspad/rpcmodel"
 	"github.com/kaspanet/kaspad/util/daghash"
 )
@@ -44,6 +45,9 @@ func handleRescanBlocks(wsc *wsClient, icmd interface{}) (interface{}, error) {
 	var lastBlockHash *daghash.Hash
 	for i := range blockHashes {
 		block, err := bc.BlockByHash(blockHashes[i])
+		if blockdag.IsPrunedErr(err) {
+			return nil, rpcBlockPrunedError(blockHashes[i])
+		}
 		if err != nil {
 			return nil, &rpcmodel.RPCError{
 				Code:    rpcmodel.ErrRPCBlockNotFound,
@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/kaspanet/kaspad/config"
+	"github.com/kaspanet/kaspad/rpcmodel"
+	"github.com/kaspanet/kaspad/server/p2p"
+	"github.com/pkg/errors"
+)
+
+// manualBanReason is the reason of bans that are added by the setBan
+// command without a reason.
+const manualBanReason = "manually banned"
+
+// maxBanTime is the maximum relative ban time, in seconds, of the setBan
+// command. Longer ban times overflow when converted to a time.Duration.
+const maxBanTime = math.MaxInt64 / int64(time.Second)
+
+// handleSetBan implements the setBan command.
+func handleSetBan(s *Server, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*rpcmodel.SetBanCmd)
+
+	subnet, err := p2p.ParseSubnet(c.Subnet)
+	if err != nil {
+		return nil, &rpcmodel.RPCError{
+			Code:    rpcmodel.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+
+	switch c.SubCmd {
+	case rpcmodel.SBAdd:
+		expiry, err := setBanExpiry(*c.BanTime, *c.Absolute)
+		if err != nil {
+			return nil, err
+		}
+		reason := manualBanReason
+		if c.Reason != nil {
+			reason = *c.Reason
+		}
+		err = s.cfg.ConnMgr.BanSubnet(subnet, reason, expiry)
+	case rpcmodel.SBRemove:
+		err = s.cfg.ConnMgr.UnbanSubnet(subnet)
+	default:
+		return nil, &rpcmodel.RPCError{
+			Code:    rpcmodel.ErrRPCInvalidParameter,
+			Message: "invalid subcommand for setBan",
+		}
+	}
+
+	if errors.Is(err, p2p.ErrAlreadyBanned) || errors.Is(err, p2p.ErrNotBanned) {
+		return nil, &rpcmodel.RPCError{
+			Code:    rpcmodel.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+	if err != nil {
+		context := "Failed to update the ban list"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	// no data returned unless an error.
+	return nil, nil
+}
+
+// setBanExpiry returns the expiry of a ban from the ban time of the setBan
+// command. The ban time is either a number of seconds from now or, if it's
+// absolute, a unix timestamp. A ban time of 0 means the default ban duration.
+func setBanExpiry(banTime int64, isAbsolute bool) (time.Time, error) {
+	now := time.Now()
+	if banTime == 0 {
+		return now.Add(config.ActiveConfig().BanDuration), nil
+	}
+	if banTime < 0 {
+		return time.Time{}, &rpcmodel.RPCError{
+			Code:    rpcmodel.ErrRPCInvalidParameter,
+			Message: "Ban time must not be negative",
+		}
+	}
+	if !isAbsolute {
+		if banTime > maxBanTime {
+			return time.Time{}, &rpcmodel.RPCError{
+				Code:    rpcmodel.ErrRPCInvalidParameter,
+				Message: fmt.Sprintf("Ban time must be at most %d seconds", maxBanTime),
+			}
+		}
+		return now.Add(time.Duration(banTime) * time.Second), nil
+	}
+	expiry := time.Unix(banTime, 0)
+	if !expiry.After(now) {
+		return time.Time{}, &rpcmodel.RPCError{
+			Code:    rpcmodel.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Ban expiry %s is in the past", expiry),
+		}
+	}
+	return expiry, nil
+}
@@ -0,0 +1,20 @@
+package rpc
+
+import "github.com/kaspanet/kaspad/rpcmodel"
+
+// handleStopNotifyTxStatus implements the stopNotifyTxStatus command extension
+// for websocket connections.
+func handleStopNotifyTxStatus(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*rpcmodel.StopNotifyTxStatusCmd)
+	if !ok {
+		return nil, rpcmodel.ErrRPCInternal
+	}
+
+	txIDs, err := decodeTxIDs(cmd.TxIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	wsc.server.ntfnMgr.UnregisterTxStatusUpdates(wsc, txIDs)
+	return nil, nil
+}
@@ -5,7 +5,9 @@
 package rpc
 
 import (
+	"net"
 	"sync/atomic"
+	"time"
 
 	"github.com/kaspanet/kaspad/blockdag"
 	"github.com/kaspanet/kaspad/mempool"
@@ -44,6 +46,15 @@ func (p *rpcPeer) IsTxRelayDisabled() bool {
 	return (*p2p.Peer)(p).DisableRelayTx
 }
 
+// IsBlockRelayOnly returns whether only blocks are relayed to and from the
+// peer.
+//
+// This function is safe for concurrent access and is part of the rpcserverPeer
+// interface implementation.
+func (p *rpcPeer) IsBlockRelayOnly() bool {
+	return (*p2p.Peer)(p).BlockRelayOnly()
+}
+
 // BanScore returns the current integer value that represents how close the peer
 // is to being banned.
 //
@@ -165,6 +176,15 @@ func (cm *rpcConnManager) NetTotals() (uint64, uint64) {
 	return cm.server.NetTotals()
 }
 
+// IsNetworkActive returns whether the server is connecting to and accepting
+// connections from peers.
+//
+// This function is safe for concurrent access and is part of the
+// rpcserverConnManager interface implementation.
+func (cm *rpcConnManager) IsNetworkActive() bool {
+	return cm.server.IsNetworkActive()
+}
+
 // ConnectedPeers returns an array consisting of all connected peers.
 //
 // This function is safe for concurrent access and is part of the
@@ -224,6 +244,55 @@ func (cm *rpcConnManager) RelayTransactions(txns []*mempool.TxDesc) {
 	cm.server.RelayTransactions(txns)
 }
 
+// BanSubnet bans the given subnet until the given expiry and disconnects
+// all the connected peers in it.
+//
+// This function is safe for concurrent access and is part of the
+// rpcserverConnManager interface implementation.
+func (cm *rpcConnManager) BanSubnet(subnet *net.IPNet, reason string, expiry time.Time) error {
+	replyChan := make(chan error)
+	cm.server.Query <- p2p.BanSubnetMsg{
+		Subnet: subnet,
+		Reason: reason,
+		Expiry: expiry,
+		Reply:  replyChan,
+	}
+	return <-replyChan
+}
+
+// UnbanSubnet removes the ban of the given subnet.
+//
+// This function is safe for concurrent access and is part of the
+// rpcserverConnManager interface implementation.
+func (cm *rpcConnManager) UnbanSubnet(subnet *net.IPNet) error {
+	replyChan := make(chan error)
+	cm.server.Query <- p2p.UnbanSubnetMsg{
+		Subnet: subnet,
+		Reply:  replyChan,
+	}
+	return <-replyChan
+}
+
+// BannedSubnets returns an array consisting of all the banned subnets.
+//
+// This function is safe for concurrent access and is part of the
+// rpcserverConnManager interface implementation.
+func (cm *rpcConnManager) BannedSubnets() []*p2p.BannedSubnet {
+	replyChan := make(chan []*p2p.BannedSubnet)
+	cm.server.Query <- p2p.GetBannedSubnetsMsg{Reply: replyChan}
+	return <-replyChan
+}
+
+// ClearBannedSubnets removes the bans of all the subnets.
+//
+// This function is safe for concurrent access and is part of the
+// rpcserverConnManager interface implementation.
+func (cm *rpcConnManager) ClearBannedSubnets() error {
+	replyChan := make(chan error)
+	cm.server.Query <- p2p.ClearBannedSubnetsMsg{Reply: replyChan}
+	return <-replyChan
+}
+
 // rpcSyncMgr provides a block manager for use with the RPC server and
 // implements the rpcserverSyncManager interface.
 type rpcSyncMgr struct {
@@ -31,6 +31,7 @@ import (
 	"github.com/kaspanet/kaspad/config"
 	"github.com/kaspanet/kaspad/dagconfig"
 	"github.com/kaspanet/kaspad/mempool"
+	"github.com/kaspanet/kaspad/mempool/feeestimator"
 	"github.com/kaspanet/kaspad/mining"
 	"github.com/kaspanet/kaspad/peer"
 	"github.com/kaspanet/kaspad/rpcmodel"
@@ -60,10 +61,16 @@ type commandHandler func(*Server, interface{}, <-chan struct{}) (interface{}, er
 var rpcHandlers map[string]commandHandler
 var rpcHandlersBeforeInit = map[string]commandHandler{
 	"addManualNode":         handleAddManualNode,
+	"clearBanned":           handleClearBanned,
+	"clearMempool":          handleClearMempool,
 	"createRawTransaction":  handleCreateRawTransaction,
 	"debugLevel":            handleDebugLevel,
 	"decodeRawTransaction":  handleDecodeRawTransaction,
 	"decodeScript":          handleDecodeScript,
+	"dumpUTXOSnapshot":      handleDumpUTXOSnapshot,
+	"estimateFee":           handleEstimateFee,
+	"getAddressBalance":     handleGetAddressBalance,
+	"getAddressUTXOs":       handleGetAddressUTXOs,
 	"getAllManualNodesInfo": handleGetAllManualNodesInfo,
 	"getSelectedTip":        handleGetSelectedTip,
 	"getSelectedTipHash":    handleGetSelectedTipHash,
@@ -76,6 +83,7 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"getChainFromBlock":     handleGetChainFromBlock,
 	"getConnectionCount":    handleGetConnectionCount,
 	"getCurrentNet":         handleGetCurrentNet,
+	"getDagGraph":           handleGetDAGGraph,
 	"getDifficulty":         handleGetDifficulty,
 	"getHeaders":            handleGetHeaders,
 	"getTopHeaders":         handleGetTopHeaders,
@@ -83,29 +91,35 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"getManualNodeInfo":     handleGetManualNodeInfo,
 	"getMempoolInfo":        handleGetMempoolInfo,
 	"getMempoolEntry":       handleGetMempoolEntry,
+	"getNetworkInfo":        handleGetNetworkInfo,
 	"getNetTotals":          handleGetNetTotals,
 	"getConnectedPeerInfo":  handleGetConnectedPeerInfo,
 	"getPeerAddresses":      handleGetPeerAddresses,
 	"getRawMempool":         handleGetRawMempool,
+	"getRawTransaction":     handleGetRawTransaction,
 	"getSubnetwork":         handleGetSubnetwork,
 	"getTxOut":              handleGetTxOut,
+	"getTxOutSetInfo":       handleGetTxOutSetInfo,
 	"help":                  handleHelp,
+	"listBanned":            handleListBanned,
 	"node":                  handleNode,
 	"ping":                  handlePing,
 	"removeManualNode":      handleRemoveManualNode,
 	"sendRawTransaction":    handleSendRawTransaction,
+	"setBan":                handleSetBan,
 	"stop":                  handleStop,
 	"submitBlock":           handleSubmitBlock,
 	"uptime":                handleUptime,
 	"validateAddress":       handleValidateAddress,
 	"version":               handleVersion,
+
+	"estimateMempoolFeeRates":  handleEstimateMempoolFeeRates,
+	"getAddressTransactions":   handleGetAddressTransactions,
+	"removeMempoolTransaction": handleRemoveMempoolTransaction,
 }
 
 // Commands that are currently unimplemented, but should ultimately be.
-var rpcUnimplemented = map[string]struct{}{
-	"getMempoolEntry": {},
-	"getNetworkInfo":  {},
-}
+var rpcUnimplemented = map[string]struct{}{}
 
 // Commands that are available to a limited user
 var rpcLimited = map[string]struct{}{
@@ -127,6 +141,9 @@ var rpcLimited = map[string]struct{}{
 	"createRawTransaction": {},
 	"decodeRawTransaction": {},
 	"decodeScript":         {},
+	"estimateFee":          {},
+	"getAddressBalance":    {},
+	"getAddressUTXOs":      {},
 	"getSelectedTip":       {},
 	"getSelectedTipHash":   {},
 	"getBlock":             {},
@@ -139,14 +156,20 @@ var rpcLimited = map[string]struct{}{
 	"getDifficulty":        {},
 	"getHeaders":           {},
 	"getInfo":              {},
+	"getMempoolEntry":      {},
 	"getNetTotals":         {},
 	"getRawMempool":        {},
+	"getRawTransaction":    {},
 	"getTxOut":             {},
+	"getTxOutSetInfo":      {},
 	"sendRawTransaction":   {},
 	"submitBlock":          {},
 	"uptime":               {},
 	"validateAddress":      {},
 	"version":              {},
+
+	"estimateMempoolFeeRates": {},
+	"getAddressTransactions":  {},
 }
 
 // handleUnimplemented is the handler for commands that should ultimately be
@@ -268,12 +291,28 @@ func (s *Server) NotifyNewTransactions(txns []*mempool.TxDesc) {
 		// Notify websocket clients about mempool transactions.
 		s.ntfnMgr.NotifyMempoolTx(txD.Tx, true)
 
+		// Notify websocket clients about mempool transactions that
+		// were replaced by the new transaction.
+		for _, replacedTx := range txD.ReplacedTxs {
+			s.ntfnMgr.NotifyMempoolTxReplaced(replacedTx, txD.Tx)
+		}
+
 		// Potentially notify any getBlockTemplate long poll clients
 		// about stale block templates due to the new transaction.
 		s.gbtWorkState.NotifyMempoolTx(s.cfg.TxMemPool.LastUpdated())
 	}
 }
 
+// NotifyRemovedTransactions notifies both websocket and getBlockTemplate long
+// poll clients of the passed transactions, which were removed from the mempool
+// for the passed reason.
+func (s *Server) NotifyRemovedTransactions(txs []*util.Tx, reason mempool.RemovalReason) {
+	for _, tx := range txs {
+		s.ntfnMgr.NotifyMempoolTxRemoved(tx, reason)
+	}
+	s.gbtWorkState.NotifyMempoolTx(s.cfg.TxMemPool.LastUpdated())
+}
+
 // limitConnections responds with a 503 service unavailable and returns true if
 // adding another client would exceed the maximum allow RPC clients.
 //
@@ -650,6 +689,10 @@ type rpcserverPeer interface {
 	// transaction relay.
 	IsTxRelayDisabled() bool
 
+	// IsBlockRelayOnly returns whether only blocks are relayed to and
+	// from the peer.
+	IsBlockRelayOnly() bool
+
 	// BanScore returns the current integer value that represents how close
 	// the peer is to being banned.
 	BanScore() uint32
@@ -699,6 +742,10 @@ type rpcserverConnManager interface {
 	// network for all peers.
 	NetTotals() (uint64, uint64)
 
+	// IsNetworkActive returns whether the server is connecting to and
+	// accepting connections from peers.
+	IsNetworkActive() bool
+
 	// ConnectedPeers returns an array consisting of all connected peers.
 	ConnectedPeers() []rpcserverPeer
 
@@ -718,6 +765,19 @@ type rpcserverConnManager interface {
 	// RelayTransactions generates and relays inventory vectors for all of
 	// the passed transactions to all connected peers.
 	RelayTransactions(txns []*mempool.TxDesc)
+
+	// BanSubnet bans the provided subnet until the provided expiry and
+	// disconnects all the connected peers in it.
+	BanSubnet(subnet *net.IPNet, reason string, expiry time.Time) error
+
+	// UnbanSubnet removes the ban of the provided subnet.
+	UnbanSubnet(subnet *net.IPNet) error
+
+	// BannedSubnets returns an array consisting of all the banned subnets.
+	BannedSubnets() []*p2p.BannedSubnet
+
+	// ClearBannedSubnets removes the bans of all the subnets.
+	ClearBannedSubnets() error
 }
 
 // rpcserverSyncManager represents a sync manager for use with the RPC server.
@@ -776,6 +836,9 @@ type rpcserverConfig struct {
 	// TxMemPool defines the transaction memory pool to interact with.
 	TxMemPool *mempool.TxPool
 
+	// FeeEstimator defines the fee estimator to interact with.
+	FeeEstimator *feeestimator.FeeEstimator
+
 	// These fields allow the RPC server to interface with mining.
 	//
 	// Generator produces block templates that can be retrieved
@@ -785,9 +848,19 @@ type rpcserverConfig struct {
 	// These fields define any optional indexes the RPC server can make use
 	// of to provide additional data when queried.
 	AcceptanceIndex *indexers.AcceptanceIndex
+	TxIndex         *indexers.TxIndex
+	AddressIndex    *indexers.AddressIndex
 
 	// addressManager defines the address manager for the RPC server to use.
 	addressManager *addrmgr.AddrManager
+
+	// LocalServices defines the services the p2p server advertises to
+	// its peers.
+	LocalServices wire.ServiceFlag
+
+	// UserAgent defines the user agent the p2p server advertises to its
+	// peers.
+	UserAgent string
 }
 
 // setupRPCListeners returns a slice of listeners that are configured for use
@@ -861,11 +934,16 @@ func NewRPCServer(
 		ConnMgr:         &rpcConnManager{p2pServer},
 		SyncMgr:         &rpcSyncMgr{p2pServer, p2pServer.SyncManager},
 		addressManager:  p2pServer.AddrManager,
+		LocalServices:   p2pServer.Services(),
+		UserAgent:       p2pServer.UserAgent(),
 		TimeSource:      p2pServer.TimeSource,
 		DAGParams:       p2pServer.DAGParams,
 		TxMemPool:       p2pServer.TxMemPool,
+		FeeEstimator:    p2pServer.FeeEstimator,
 		Generator:       blockTemplateGenerator,
 		AcceptanceIndex: p2pServer.AcceptanceIndex,
+		TxIndex:         p2pServer.TxIndex,
+		AddressIndex:    p2pServer.AddressIndex,
 		DAG:             p2pServer.DAG,
 	}
 	rpc := Server{
@@ -888,6 +966,7 @@ func NewRPCServer(
 	}
 	rpc.ntfnMgr = newWsNotificationManager(&rpc)
 	rpc.cfg.DAG.Subscribe(rpc.handleBlockDAGNotification)
+	rpc.cfg.TxMemPool.SubscribeRemovals(rpc.NotifyRemovedTransactions)
 
 	return &rpc, nil
 }
@@ -920,15 +999,10 @@ func (s *Server) handleBlockDAGNotification(notification *blockdag.Notification)
 			break
 		}
 
-		// If the acceptance index is off we aren't capable of serving
-		// ChainChanged notifications.
-		if s.cfg.AcceptanceIndex == nil {
-			break
-		}
-
-		// Notify registered websocket clients of chain changes.
+		// Notify registered websocket clients of chain changes and of
+		// the transactions accepted by the new chain blocks.
 		s.ntfnMgr.NotifyChainChanged(data.RemovedChainBlockHashes,
-			data.AddedChainBlockHashes)
+			data.AddedChainBlockHashes, data.AddedChainBlocksAcceptanceData)
 	}
 }
 
@@ -34,6 +34,73 @@ var helpDescsEnUS = map[string]string{
 	"addManualNode-addr":      "IP address and port of the peer to operate on",
 	"addManualNode-oneTry":    "When enabled, will try a single connection to a peer",
 
+	// ClearMempoolCmd help.
+	// ClearBannedCmd help.
+	"clearBanned--synopsis": "Removes the bans of all the banned subnets.",
+
+	"clearMempool--synopsis": "Removes all the transactions from the memory pool and the orphan pool.",
+	"clearMempool--result0":  "The IDs of the transactions that were removed from the memory pool",
+
+	// GetAddressTransactionsCmd help.
+	"getAddressTransactions--synopsis": "Returns the transactions that either spend from or pay to the given address, ordered by acceptance.\n" +
+		"Only transactions that were accepted by some block are returned.",
+	"getAddressTransactions-address": "The address to query",
+	"getAddressTransactions-skip":    "The number of leading transactions to skip",
+	"getAddressTransactions-count":   "The maximum number of transactions to return (at most 1000)",
+
+	// GetAddressTransactionsResult help.
+	"getAddressTransactionsResult-address":      "The queried address",
+	"getAddressTransactionsResult-transactions": "The transactions that touched the address",
+
+	// AddressTransaction help.
+	"addressTransaction-txId":               "The ID of the transaction",
+	"addressTransaction-acceptingBlockHash": "The hash of the first block that accepted the transaction",
+	"addressTransaction-acceptingBlueScore": "The blue score of the first block that accepted the transaction",
+
+	// GetAddressBalanceCmd help.
+	"getAddressBalance--synopsis": "Returns the total amount of the unspent outputs that pay to the given address. Fails if more than 100000 unspent outputs pay to the address.",
+	"getAddressBalance-address":   "The address to query",
+
+	// GetAddressBalanceResult help.
+	"getAddressBalanceResult-address":   "The queried address",
+	"getAddressBalanceResult-balance":   "The total amount in sompis of the unspent outputs that pay to the address",
+	"getAddressBalanceResult-utxoCount": "The number of unspent outputs that pay to the address",
+
+	// GetAddressUTXOsCmd help.
+	"getAddressUTXOs--synopsis": "Returns the unspent outputs that pay to the given address.",
+	"getAddressUTXOs-address":   "The address to query",
+	"getAddressUTXOs-skip":      "The number of leading unspent outputs to skip",
+	"getAddressUTXOs-count":     "The maximum number of unspent outputs to return (at most 1000)",
+
+	// GetAddressUTXOsResult help.
+	"getAddressUtxOsResult-address": "The queried address",
+	"getAddressUtxOsResult-utxos":   "The unspent outputs that pay to the address",
+
+	// AddressUTXO help.
+	"addressUtxo-txId":           "The ID of the transaction that created the output",
+	"addressUtxo-index":          "The index of the output within its transaction",
+	"addressUtxo-amount":         "The amount of the output in sompis",
+	"addressUtxo-scriptPubKey":   "Hex-encoded public key script of the output",
+	"addressUtxo-blockBlueScore": "The blue score of the block that accepted the output",
+	"addressUtxo-isCoinbase":     "Whether the output belongs to a coinbase transaction",
+
+	// SetBanCmd help.
+	"setBan--synopsis": "Bans a subnet, disconnecting its connected peers, or removes the ban of a subnet. Bans are kept across restarts.",
+	"setBan-subnet":    "The subnet to operate on in CIDR notation (e.g. 10.0.0.0/8), or a single IP address",
+	"setBan-subCmd":    "'add' to ban the subnet or 'remove' to remove its ban",
+	"setBan-banTime":   "The number of seconds to ban the subnet for, or the unix timestamp until which it's banned if absolute is set (0 for the default ban duration)",
+	"setBan-absolute":  "Whether banTime is a unix timestamp rather than a number of seconds",
+	"setBan-reason":    "The reason of the ban",
+
+	// ListBannedCmd help.
+	"listBanned--synopsis": "Returns the banned subnets.",
+
+	// ListBannedResult help.
+	"listBannedResult-address":     "The banned subnet in CIDR notation",
+	"listBannedResult-banCreated":  "The time the ban was created, in seconds since 1 Jan 1970 GMT",
+	"listBannedResult-bannedUntil": "The time the ban expires, in seconds since 1 Jan 1970 GMT",
+	"listBannedResult-reason":      "The reason of the ban",
+
 	// NodeCmd help.
 	"node--synopsis":     "Attempts to add or remove a peer.",
 	"node-subCmd":        "'disconnect' to remove all matching non-persistent peers, 'remove' to remove a persistent peer, or 'connect' to connect to a peer",
@@ -119,6 +186,32 @@ var helpDescsEnUS = map[string]string{
 	"decodeScript--synopsis": "Returns a JSON object with information about the provided hex-encoded script.",
 	"decodeScript-hexScript": "Hex-encoded script",
 
+	// DumpUTXOSnapshotCmd help.
+	"dumpUTXOSnapshot--synopsis": "Writes a snapshot of the UTXO set of the virtual block, along with the DAG state that's needed in order to keep processing blocks on top of it, to a file. The snapshot can be loaded into a new node with --loadsnapshot.",
+	"dumpUTXOSnapshot-path":      "The path of the file to write the snapshot to, relative to the data directory. The file must not exist yet and may not be outside of the data directory",
+
+	// DumpUTXOSnapshotResult help.
+	"dumpUtxoSnapshotResult-path":                   "The path of the file the snapshot was written to",
+	"dumpUtxoSnapshotResult-finalityPointHash":      "The hash of the finality point the snapshot is verified against when it's loaded",
+	"dumpUtxoSnapshotResult-finalityPointBlueScore": "The blue score of the finality point",
+	"dumpUtxoSnapshotResult-utxoCount":              "The number of unspent transaction outputs in the snapshot",
+	"dumpUtxoSnapshotResult-blockCount":             "The number of block bodies in the snapshot",
+
+	// EstimateFeeCmd help.
+	"estimateFee--synopsis":            "Estimates the fee rate a transaction should pay in order to be accepted by the virtual block within the given number of blue score units.",
+	"estimateFee-targetBlueScoreDelta": "The number of blue score units within which the transaction should be accepted (between 1 and 100)",
+
+	// EstimateFeeResult help.
+	"estimateFeeResult-targetBlueScoreDelta": "The number of blue score units the estimate is for",
+	"estimateFeeResult-feeRate":              "The estimated fee rate in sompi per gram",
+	"estimateFeeResult-isEstimated":          "Whether the fee rate was estimated, or is the default fee rate because not enough data was gathered yet",
+
+	// EstimateMempoolFeeRatesCmd help.
+	"estimateMempoolFeeRates--synopsis": "Estimates the fee rates transactions should pay in order to be accepted by the virtual block within a set of common blue score targets.",
+
+	// EstimateMempoolFeeRatesResult help.
+	"estimateMempoolFeeRatesResult-estimates": "The fee rate estimates, ordered by ascending target",
+
 	// GetAllManualNodesInfoCmd help.
 	"getAllManualNodesInfo--synopsis":   "Returns information about manually added (persistent) peers.",
 	"getAllManualNodesInfo-details":     "Specifies whether the returned data is a JSON object including DNS and connection information, or just a list of added peers",
@@ -361,6 +454,33 @@ var helpDescsEnUS = map[string]string{
 	"getCurrentNet--synopsis": "Get kaspa network the server is running on.",
 	"getCurrentNet--result0":  "The network identifer",
 
+	// GetDAGGraphCmd help.
+	"getDagGraph--synopsis": "Exports a window of the DAG, along with the GHOSTDAG coloring and the reachability intervals of its blocks, for debugging and visualization. " +
+		"The dot and graphml formats are returned as a single string, which kaspactl prints as is, so that it can be piped into Graphviz or saved to a file. " +
+		"The command fails if finding the window requires traversing more than 20000 blocks.",
+	"getDagGraph-format": "The format of the graph: json, dot or graphml",
+	"getDagGraph-levels": "The number of blue score levels to export (between 1 and 1000). Without a hash, these are the last levels, counting back from the selected tip. " +
+		"With a hash, the window holds the blocks in the past and the anticone of the block whose blue scores are at most this number of levels away from its own",
+	"getDagGraph-hash":        "The hash of the block to export the window around, whose blue score must be at most 10000 below the blue score of the selected tip",
+	"getDagGraph--condition0": "format=json",
+	"getDagGraph--condition1": "format=dot or format=graphml",
+	"getDagGraph--result0":    "The graph in the json format",
+	"getDagGraph--result1":    "The graph in the dot or the graphml format",
+
+	// GetDAGGraphResult help.
+	"getDagGraphResult-blocks": "The blocks of the window, ordered such that every block appears after its parents",
+
+	// GetDAGGraphResultBlock help.
+	"getDagGraphResultBlock-hash":                      "The hash of the block",
+	"getDagGraphResultBlock-parentHashes":              "The hashes of the parents of the block, some of which may be outside the window",
+	"getDagGraphResultBlock-selectedParentHash":        "The hash of the selected parent of the block, omitted for the genesis",
+	"getDagGraphResultBlock-blueScore":                 "The blue score of the block",
+	"getDagGraphResultBlock-isBlue":                    "Whether the block is blue in the worldview of the virtual block",
+	"getDagGraphResultBlock-mergeSetBlueHashes":        "The hashes of the blocks that this block colored blue, including its selected parent",
+	"getDagGraphResultBlock-mergeSetRedHashes":         "The hashes of the blocks that this block colored red",
+	"getDagGraphResultBlock-reachabilityIntervalStart": "The start of the interval of the block in the reachability tree",
+	"getDagGraphResultBlock-reachabilityIntervalEnd":   "The end of the interval of the block in the reachability tree",
+
 	// GetDifficultyCmd help.
 	"getDifficulty--synopsis": "Returns the proof-of-work difficulty as a multiple of the minimum difficulty.",
 	"getDifficulty--result0":  "The difficulty",
@@ -397,16 +517,52 @@ var helpDescsEnUS = map[string]string{
 	"getMempoolEntry-txId":      "The transaction ID",
 
 	// getMempoolEntryResult help.
-	"getMempoolEntryResult-fee":   "Transaction fee in sompis",
-	"getMempoolEntryResult-time":  "Local time transaction entered pool in seconds since 1 Jan 1970 GMT",
-	"getMempoolEntryResult-rawTx": "The transaction as a JSON object",
+	"getMempoolEntryResult-fee":     "Transaction fee in sompis",
+	"getMempoolEntryResult-mass":    "Transaction mass",
+	"getMempoolEntryResult-time":    "Local time transaction entered pool in seconds since 1 Jan 1970 GMT",
+	"getMempoolEntryResult-depends": "Unconfirmed transactions used as inputs for this transaction",
+	"getMempoolEntryResult-spentBy": "Unconfirmed transactions spending outputs of this transaction",
+	"getMempoolEntryResult-rawTx":   "The transaction as a JSON object",
+
+	// GetNetworkInfoCmd help.
+	"getNetworkInfo--synopsis": "Returns a JSON object containing network-related state info.",
+
+	// NetworksResult help.
+	"networksResult-name":                      "The network name (ipv4, ipv6, onion or i2p)",
+	"networksResult-limited":                   "Whether connections to this network are disallowed",
+	"networksResult-reachable":                 "Whether connections to this network are possible",
+	"networksResult-proxy":                     "The proxy used to connect to this network, if any",
+	"networksResult-proxyRandomizeCredentials": "Whether randomized credentials are used for the proxy",
+
+	// LocalAddressesResult help.
+	"localAddressesResult-address": "The local network address",
+	"localAddressesResult-port":    "The local network port",
+	"localAddressesResult-score":   "The relative priority of the address",
+
+	// GetNetworkInfoResult help.
+	"getNetworkInfoResult-version":         "The version of the node as a numeric value",
+	"getNetworkInfoResult-subVersion":      "The user agent the node advertises to its peers",
+	"getNetworkInfoResult-protocolVersion": "The latest supported protocol version",
+	"getNetworkInfoResult-localServices":   "The services supported by the node",
+	"getNetworkInfoResult-localRelay":      "Whether transactions are relayed to and from peers",
+	"getNetworkInfoResult-timeOffset":      "The time offset of the node in seconds",
+	"getNetworkInfoResult-connections":     "The number of connected peers",
+	"getNetworkInfoResult-networkActive":   "Whether networking is enabled",
+	"getNetworkInfoResult-networks":        "Information about each network",
+	"getNetworkInfoResult-relayFee":        "Minimum transaction fee in KAS/kB for transactions to be relayed",
+	"getNetworkInfoResult-incrementalFee":  "Minimum fee increment in KAS/kB for mempool limiting",
+	"getNetworkInfoResult-localAddresses":  "The local addresses the node advertises",
+	"getNetworkInfoResult-warnings":        "Any network warnings",
 
 	// GetMempoolInfoCmd help.
 	"getMempoolInfo--synopsis": "Returns memory pool information",
 
 	// GetMempoolInfoResult help.
-	"getMempoolInfoResult-bytes": "Size in bytes of the mempool",
-	"getMempoolInfoResult-size":  "Number of transactions in the mempool",
+	"getMempoolInfoResult-bytes":      "Size in bytes of the mempool",
+	"getMempoolInfoResult-size":       "Number of transactions in the mempool",
+	"getMempoolInfoResult-mass":       "Total mass of the transactions in the mempool",
+	"getMempoolInfoResult-maxMass":    "Maximum total mass of the transactions in the mempool (0 means no limit)",
+	"getMempoolInfoResult-minFeeRate": "Minimum fee rate in sompi per gram for transactions to be accepted into the mempool, which is raised when the mempool is full",
 
 	// GetNetTotalsCmd help.
 	"getNetTotals--synopsis": "Returns a JSON object containing network traffic statistics.",
@@ -417,25 +573,28 @@ var helpDescsEnUS = map[string]string{
 	"getNetTotalsResult-timeMillis":     "Number of milliseconds since 1 Jan 1970 GMT",
 
 	// GetConnectedPeerInfoResult help.
-	"getConnectedPeerInfoResult-id":          "A unique node ID",
-	"getConnectedPeerInfoResult-addr":        "The ip address and port of the peer",
-	"getConnectedPeerInfoResult-services":    "Services bitmask which represents the services supported by the peer",
-	"getConnectedPeerInfoResult-relayTxes":   "Peer has requested transactions be relayed to it",
-	"getConnectedPeerInfoResult-lastSend":    "Time the last message was received in seconds since 1 Jan 1970 GMT",
-	"getConnectedPeerInfoResult-lastRecv":    "Time the last message was sent in seconds since 1 Jan 1970 GMT",
-	"getConnectedPeerInfoResult-bytesSent":   "Total bytes sent",
-	"getConnectedPeerInfoResult-bytesRecv":   "Total bytes received",
-	"getConnectedPeerInfoResult-connTime":    "Time the connection was made in seconds since 1 Jan 1970 GMT",
-	"getConnectedPeerInfoResult-timeOffset":  "The time offset of the peer",
-	"getConnectedPeerInfoResult-pingTime":    "Number of microseconds the last ping took",
-	"getConnectedPeerInfoResult-pingWait":    "Number of microseconds a queued ping has been waiting for a response",
-	"getConnectedPeerInfoResult-version":     "The protocol version of the peer",
-	"getConnectedPeerInfoResult-subVer":      "The user agent of the peer",
-	"getConnectedPeerInfoResult-inbound":     "Whether or not the peer is an inbound connection",
-	"getConnectedPeerInfoResult-selectedTip": "The selected tip of the peer",
-	"getConnectedPeerInfoResult-banScore":    "The ban score",
-	"getConnectedPeerInfoResult-feeFilter":   "The requested minimum fee a transaction must have to be announced to the peer",
-	"getConnectedPeerInfoResult-syncNode":    "Whether or not the peer is the sync peer",
+	"getConnectedPeerInfoResult-id":             "A unique node ID",
+	"getConnectedPeerInfoResult-addr":           "The ip address and port of the peer",
+	"getConnectedPeerInfoResult-services":       "Services bitmask which represents the services supported by the peer",
+	"getConnectedPeerInfoResult-relayTxes":      "Peer has requested transactions be relayed to it",
+	"getConnectedPeerInfoResult-lastSend":       "Time the last message was received in seconds since 1 Jan 1970 GMT",
+	"getConnectedPeerInfoResult-lastRecv":       "Time the last message was sent in seconds since 1 Jan 1970 GMT",
+	"getConnectedPeerInfoResult-bytesSent":      "Total bytes sent",
+	"getConnectedPeerInfoResult-bytesRecv":      "Total bytes received",
+	"getConnectedPeerInfoResult-connTime":       "Time the connection was made in seconds since 1 Jan 1970 GMT",
+	"getConnectedPeerInfoResult-timeOffset":     "The time offset of the peer",
+	"getConnectedPeerInfoResult-pingTime":       "Number of microseconds the last ping took",
+	"getConnectedPeerInfoResult-pingWait":       "Number of microseconds a queued ping has been waiting for a response",
+	"getConnectedPeerInfoResult-version":        "The protocol version of the peer",
+	"getConnectedPeerInfoResult-subVer":         "The user agent of the peer",
+	"getConnectedPeerInfoResult-inbound":        "Whether or not the peer is an inbound connection",
+	"getConnectedPeerInfoResult-selectedTip":    "The selected tip of the peer",
+	"getConnectedPeerInfoResult-banScore":       "The ban score",
+	"getConnectedPeerInfoResult-feeFilter":      "The requested minimum fee a transaction must have to be announced to the peer",
+	"getConnectedPeerInfoResult-syncNode":       "Whether or not the peer is the sync peer",
+	"getConnectedPeerInfoResult-blockRelayOnly": "Whether only blocks are relayed to and from the peer, and never transactions or addresses",
+	"getConnectedPeerInfoResult-transport":      "Whether the connection to the peer is plaintext or encrypted",
+	"getConnectedPeerInfoResult-identityPubKey": "The hex-encoded public key that the peer identified with over the encrypted connection, if any",
 
 	// GetConnectedPeerInfoCmd help.
 	"getConnectedPeerInfo--synopsis": "Returns data about each connected network peer as an array of json objects.",
@@ -482,6 +641,14 @@ var helpDescsEnUS = map[string]string{
 	"getRawMempool--condition1": "verbose=true",
 	"getRawMempool--result0":    "Array of transaction hashes",
 
+	// GetRawTransactionCmd help.
+	"getRawTransaction--synopsis":   "Returns information about a transaction given its ID.",
+	"getRawTransaction-txId":        "The ID of the transaction",
+	"getRawTransaction-verbose":     "Specifies the transaction is returned as a JSON object instead of a hex-encoded string",
+	"getRawTransaction--condition0": "verbose=false",
+	"getRawTransaction--condition1": "verbose=true",
+	"getRawTransaction--result0":    "Hex-encoded bytes of the serialized transaction",
+
 	// GetSubnetworkCmd help.
 	"getSubnetwork--synopsis":    "Returns information about a subnetwork given its ID.",
 	"getSubnetwork-subnetworkId": "The ID of the subnetwork",
@@ -504,6 +671,17 @@ var helpDescsEnUS = map[string]string{
 	"getTxOut-vout":           "The index of the output",
 	"getTxOut-includeMempool": "Include the mempool when true",
 
+	// GetTxOutSetInfoCmd help.
+	"getTxOutSetInfo--synopsis": "Returns statistics about the unspent transaction output set of the virtual block.",
+
+	// GetTxOutSetInfoResult help.
+	"getTxOutSetInfoResult-selectedTipHash":  "The hash of the selected tip",
+	"getTxOutSetInfoResult-virtualBlueScore": "The blue score of the virtual block",
+	"getTxOutSetInfoResult-utxoCount":        "The number of unspent transaction outputs",
+	"getTxOutSetInfoResult-totalAmount":      "The total amount of all unspent transaction outputs in KAS",
+	"getTxOutSetInfoResult-serializedSize":   "The serialized size of the unspent transaction output set in bytes",
+	"getTxOutSetInfoResult-multisetHash":     "The ECMH multiset hash of the unspent transaction output set",
+
 	// HelpCmd help.
 	"help--synopsis":   "Returns a list of all commands or help for a specified command.",
 	"help-command":     "The command to retrieve help for",
@@ -520,6 +698,11 @@ var helpDescsEnUS = map[string]string{
 	"removeManualNode--synopsis": "Removes a peer from the manual nodes list",
 	"removeManualNode-addr":      "IP address and port of the peer to remove",
 
+	// RemoveMempoolTransactionCmd help.
+	"removeMempoolTransaction--synopsis": "Removes a transaction from the memory pool, along with all the transactions that depend on it.",
+	"removeMempoolTransaction-txId":      "The ID of the transaction to remove",
+	"removeMempoolTransaction--result0":  "The IDs of the transactions that were removed from the memory pool",
+
 	// SendRawTransactionCmd help.
 	"sendRawTransaction--synopsis":     "Submits the serialized, hex-encoded transaction to the local peer and relays it to the network.",
 	"sendRawTransaction-hexTx":         "Serialized, hex-encoded signed transaction",
@@ -568,13 +751,21 @@ var helpDescsEnUS = map[string]string{
 	"stopNotifyChainChanges--synopsis": "Cancel registered notifications for whenever the selected parent chain changes.",
 
 	// NotifyNewTransactionsCmd help.
-	"notifyNewTransactions--synopsis":  "Send either a txaccepted or a txacceptedverbose notification when a new transaction is accepted into the mempool.",
+	"notifyNewTransactions--synopsis":  "Send either a txaccepted or a txacceptedverbose notification when a new transaction is accepted into the mempool, and a txreplaced notification when a transaction in the mempool is replaced by a conflicting one, and a txremoved notification when a transaction is otherwise dropped from the mempool without being included in a block.",
 	"notifyNewTransactions-verbose":    "Specifies which type of notification to receive. If verbose is true, then the caller receives txacceptedverbose, otherwise the caller receives txaccepted",
 	"notifyNewTransactions-subnetwork": "Specifies which subnetwork to receive full transactions of. Requires verbose=true. Not allowed when node subnetwork is Native. Must be equal to node subnetwork when node is partial.",
 
 	// StopNotifyNewTransactionsCmd help.
 	"stopNotifyNewTransactions--synopsis": "Stop sending either a txaccepted or a txacceptedverbose notification when a new transaction is accepted into the mempool.",
 
+	// NotifyTxStatusCmd help.
+	"notifyTxStatus--synopsis": "Send a txRemovedFromMempool notification, along with the reason, when any of the given transactions is dropped from the mempool, and a txAcceptedByVirtual notification, along with the hash of the accepting chain block, when any of them is accepted by the virtual block.",
+	"notifyTxStatus-txIDs":     "The IDs of the transactions to watch",
+
+	// StopNotifyTxStatusCmd help.
+	"stopNotifyTxStatus--synopsis": "Stop sending notifications about the status of the given transactions.",
+	"stopNotifyTxStatus-txIDs":     "The IDs of the transactions to stop watching",
+
 	// Outpoint help.
 	"outpoint-txid":  "The hex-encoded bytes of the outpoint transaction ID",
 	"outpoint-index": "The index of the outpoint",
@@ -618,10 +809,16 @@ var helpDescsEnUS = map[string]string{
 // pointer to the type (or nil to indicate no return value).
 var rpcResultTypes = map[string][]interface{}{
 	"addManualNode":         nil,
+	"clearBanned":           nil,
+	"clearMempool":          {(*[]string)(nil)},
 	"createRawTransaction":  {(*string)(nil)},
 	"debugLevel":            {(*string)(nil), (*string)(nil)},
 	"decodeRawTransaction":  {(*rpcmodel.TxRawDecodeResult)(nil)},
 	"decodeScript":          {(*rpcmodel.DecodeScriptResult)(nil)},
+	"dumpUTXOSnapshot":      {(*rpcmodel.DumpUTXOSnapshotResult)(nil)},
+	"estimateFee":           {(*rpcmodel.EstimateFeeResult)(nil)},
+	"getAddressBalance":     {(*rpcmodel.GetAddressBalanceResult)(nil)},
+	"getAddressUTXOs":       {(*rpcmodel.GetAddressUTXOsResult)(nil)},
 	"getAllManualNodesInfo": {(*[]string)(nil), (*[]rpcmodel.GetManualNodeInfoResult)(nil)},
 	"getSelectedTip":        {(*rpcmodel.GetBlockVerboseResult)(nil)},
 	"getSelectedTipHash":    {(*string)(nil)},
@@ -634,6 +831,7 @@ var rpcResultTypes = map[string][]interface{}{
 	"getChainFromBlock":     {(*rpcmodel.GetChainFromBlockResult)(nil)},
 	"getConnectionCount":    {(*int32)(nil)},
 	"getCurrentNet":         {(*uint32)(nil)},
+	"getDagGraph":           {(*rpcmodel.GetDAGGraphResult)(nil), (*string)(nil)},
 	"getDifficulty":         {(*float64)(nil)},
 	"getTopHeaders":         {(*[]string)(nil)},
 	"getHeaders":            {(*[]string)(nil)},
@@ -641,23 +839,32 @@ var rpcResultTypes = map[string][]interface{}{
 	"getManualNodeInfo":     {(*string)(nil), (*rpcmodel.GetManualNodeInfoResult)(nil)},
 	"getMempoolInfo":        {(*rpcmodel.GetMempoolInfoResult)(nil)},
 	"getMempoolEntry":       {(*rpcmodel.GetMempoolEntryResult)(nil)},
+	"getNetworkInfo":        {(*rpcmodel.GetNetworkInfoResult)(nil)},
 	"getNetTotals":          {(*rpcmodel.GetNetTotalsResult)(nil)},
 	"getConnectedPeerInfo":  {(*[]rpcmodel.GetConnectedPeerInfoResult)(nil)},
 	"getPeerAddresses":      {(*[]rpcmodel.GetPeerAddressesResult)(nil)},
 	"getRawMempool":         {(*[]string)(nil), (*rpcmodel.GetRawMempoolVerboseResult)(nil)},
+	"getRawTransaction":     {(*string)(nil), (*rpcmodel.TxRawResult)(nil)},
 	"getSubnetwork":         {(*rpcmodel.GetSubnetworkResult)(nil)},
 	"getTxOut":              {(*rpcmodel.GetTxOutResult)(nil)},
+	"getTxOutSetInfo":       {(*rpcmodel.GetTxOutSetInfoResult)(nil)},
 	"node":                  nil,
 	"help":                  {(*string)(nil), (*string)(nil)},
+	"listBanned":            {(*[]rpcmodel.ListBannedResult)(nil)},
 	"ping":                  nil,
 	"removeManualNode":      nil,
 	"sendRawTransaction":    {(*string)(nil)},
+	"setBan":                nil,
 	"stop":                  {(*string)(nil)},
 	"submitBlock":           {nil, (*string)(nil)},
 	"uptime":                {(*int64)(nil)},
 	"validateAddress":       {(*rpcmodel.ValidateAddressResult)(nil)},
 	"version":               {(*map[string]rpcmodel.VersionResult)(nil)},
 
+	"estimateMempoolFeeRates":  {(*rpcmodel.EstimateMempoolFeeRatesResult)(nil)},
+	"getAddressTransactions":   {(*rpcmodel.GetAddressTransactionsResult)(nil)},
+	"removeMempoolTransaction": {(*[]string)(nil)},
+
 	// Websocket commands.
 	"loadTxFilter":              nil,
 	"session":                   {(*rpcmodel.SessionResult)(nil)},
@@ -667,6 +874,8 @@ var rpcResultTypes = map[string][]interface{}{
 	"stopNotifyChainChanges":    nil,
 	"notifyNewTransactions":     nil,
 	"stopNotifyNewTransactions": nil,
+	"notifyTxStatus":            nil,
+	"stopNotifyTxStatus":        nil,
 	"rescanBlocks":              {(*[]rpcmodel.RescannedBlock)(nil)},
 }
 
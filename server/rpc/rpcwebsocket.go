@@ -24,8 +24,10 @@ import (
 	"golang.org/x/crypto/ripemd160"
 
 	"github.com/btcsuite/websocket"
+	"github.com/kaspanet/kaspad/blockdag"
 	"github.com/kaspanet/kaspad/config"
 	"github.com/kaspanet/kaspad/dagconfig"
+	"github.com/kaspanet/kaspad/mempool"
 	"github.com/kaspanet/kaspad/rpcmodel"
 	"github.com/kaspanet/kaspad/txscript"
 	"github.com/kaspanet/kaspad/util"
@@ -69,10 +71,12 @@ var wsHandlersBeforeInit = map[string]wsCommandHandler{
 	"notifyBlocks":              handleNotifyBlocks,
 	"notifyChainChanges":        handleNotifyChainChanges,
 	"notifyNewTransactions":     handleNotifyNewTransactions,
+	"notifyTxStatus":            handleNotifyTxStatus,
 	"session":                   handleSession,
 	"stopNotifyBlocks":          handleStopNotifyBlocks,
 	"stopNotifyChainChanges":    handleStopNotifyChainChanges,
 	"stopNotifyNewTransactions": handleStopNotifyNewTransactions,
+	"stopNotifyTxStatus":        handleStopNotifyTxStatus,
 	"rescanBlocks":              handleRescanBlocks,
 }
 
@@ -212,12 +216,16 @@ func (m *wsNotificationManager) NotifyBlockAdded(block *util.Block) {
 }
 
 // NotifyChainChanged passes changes to the selected parent chain of
-// the blockDAG to the notification manager for processing.
+// the blockDAG, along with a function that returns the transactions
+// accepted by the added chain blocks, to the notification manager for
+// processing.
 func (m *wsNotificationManager) NotifyChainChanged(removedChainBlockHashes []*daghash.Hash,
-	addedChainBlockHashes []*daghash.Hash) {
+	addedChainBlockHashes []*daghash.Hash,
+	addedChainBlocksAcceptanceData func() ([]blockdag.MultiBlockTxsAcceptanceData, error)) {
 	n := &notificationChainChanged{
-		removedChainBlockHashes: removedChainBlockHashes,
-		addedChainBlocksHashes:  addedChainBlockHashes,
+		removedChainBlockHashes:        removedChainBlockHashes,
+		addedChainBlocksHashes:         addedChainBlockHashes,
+		addedChainBlocksAcceptanceData: addedChainBlocksAcceptanceData,
 	}
 	// As NotifyChainChanged will be called by the DAG manager
 	// and the RPC server may no longer be running, use a select
@@ -249,6 +257,44 @@ func (m *wsNotificationManager) NotifyMempoolTx(tx *util.Tx, isNew bool) {
 	}
 }
 
+// NotifyMempoolTxReplaced passes a transaction that was evicted from the
+// mempool by a conflicting replacement transaction to the notification
+// manager for transaction notification processing.
+func (m *wsNotificationManager) NotifyMempoolTxReplaced(replacedTx *util.Tx, replacementTx *util.Tx) {
+	n := &notificationTxReplacedInMempool{
+		replacedTx:    replacedTx,
+		replacementTx: replacementTx,
+	}
+
+	// As NotifyMempoolTxReplaced will be called by mempool and the RPC
+	// server may no longer be running, use a select statement to unblock
+	// enqueuing the notification once the RPC server has begun shutting
+	// down.
+	select {
+	case m.queueNotification <- n:
+	case <-m.quit:
+	}
+}
+
+// NotifyMempoolTxRemoved passes a transaction that was removed from the
+// mempool, and the reason for its removal, to the notification manager for
+// transaction notification processing.
+func (m *wsNotificationManager) NotifyMempoolTxRemoved(tx *util.Tx, reason mempool.RemovalReason) {
+	n := &notificationTxRemovedFromMempool{
+		tx:     tx,
+		reason: reason,
+	}
+
+	// As NotifyMempoolTxRemoved will be called by mempool and the RPC
+	// server may no longer be running, use a select statement to unblock
+	// enqueuing the notification once the RPC server has begun shutting
+	// down.
+	select {
+	case m.queueNotification <- n:
+	case <-m.quit:
+	}
+}
+
 // wsClientFilter tracks relevant addresses for each websocket client for
 // the `rescanBlocks` extension. It is modified by the `loadTxFilter` command.
 //
@@ -370,13 +416,22 @@ func (f *wsClientFilter) existsUnspentOutpoint(op *wire.Outpoint) bool {
 // Notification types
 type notificationBlockAdded util.Block
 type notificationChainChanged struct {
-	removedChainBlockHashes []*daghash.Hash
-	addedChainBlocksHashes  []*daghash.Hash
+	removedChainBlockHashes        []*daghash.Hash
+	addedChainBlocksHashes         []*daghash.Hash
+	addedChainBlocksAcceptanceData func() ([]blockdag.MultiBlockTxsAcceptanceData, error)
 }
 type notificationTxAcceptedByMempool struct {
 	isNew bool
 	tx    *util.Tx
 }
+type notificationTxReplacedInMempool struct {
+	replacedTx    *util.Tx
+	replacementTx *util.Tx
+}
+type notificationTxRemovedFromMempool struct {
+	tx     *util.Tx
+	reason mempool.RemovalReason
+}
 
 // Notification control requests
 type notificationRegisterClient wsClient
@@ -387,6 +442,14 @@ type notificationRegisterChainChanges wsClient
 type notificationUnregisterChainChanges wsClient
 type notificationRegisterNewMempoolTxs wsClient
 type notificationUnregisterNewMempoolTxs wsClient
+type notificationRegisterTxStatus struct {
+	wsc   *wsClient
+	txIDs []*daghash.TxID
+}
+type notificationUnregisterTxStatus struct {
+	wsc   *wsClient
+	txIDs []*daghash.TxID
+}
 
 // notificationHandler reads notifications and control messages from the queue
 // handler and processes one at a time.
@@ -404,6 +467,7 @@ func (m *wsNotificationManager) notificationHandler() {
 	blockNotifications := make(map[chan struct{}]*wsClient)
 	chainChangeNotifications := make(map[chan struct{}]*wsClient)
 	txNotifications := make(map[chan struct{}]*wsClient)
+	txStatusNotifications := make(map[daghash.TxID]map[chan struct{}]*wsClient)
 
 out:
 	for {
@@ -423,8 +487,20 @@ out:
 				}
 
 			case *notificationChainChanged:
-				m.notifyChainChanged(chainChangeNotifications,
-					n.removedChainBlockHashes, n.addedChainBlocksHashes)
+				if len(chainChangeNotifications) != 0 {
+					m.notifyChainChanged(chainChangeNotifications,
+						n.removedChainBlockHashes, n.addedChainBlocksHashes)
+				}
+				if len(txStatusNotifications) != 0 {
+					addedChainBlocksAcceptanceData, err := n.addedChainBlocksAcceptanceData()
+					if err != nil {
+						log.Errorf("Failed to get the acceptance data of "+
+							"the added chain blocks: %s", err)
+						break
+					}
+					m.notifyTxsAcceptedByVirtual(txStatusNotifications,
+						n.addedChainBlocksHashes, addedChainBlocksAcceptanceData)
+				}
 
 			case *notificationTxAcceptedByMempool:
 				if n.isNew && len(txNotifications) != 0 {
@@ -432,6 +508,21 @@ out:
 				}
 				m.notifyRelevantTxAccepted(n.tx, clients)
 
+			case *notificationTxReplacedInMempool:
+				if len(txNotifications) != 0 {
+					m.notifyForReplacedTx(txNotifications, n.replacedTx, n.replacementTx)
+				}
+
+			case *notificationTxRemovedFromMempool:
+				// Replaced transactions are already announced to
+				// these clients with a txReplaced notification.
+				if n.reason != mempool.RemovalReasonReplaced && len(txNotifications) != 0 {
+					m.notifyForRemovedTx(txNotifications, n.tx, n.reason)
+				}
+				if clients, ok := txStatusNotifications[*n.tx.ID()]; ok {
+					m.notifyTxRemovedFromMempool(clients, n.tx, n.reason)
+				}
+
 			case *notificationRegisterBlocks:
 				wsc := (*wsClient)(n)
 				blockNotifications[wsc.quit] = wsc
@@ -459,6 +550,8 @@ out:
 				delete(blockNotifications, wsc.quit)
 				delete(chainChangeNotifications, wsc.quit)
 				delete(txNotifications, wsc.quit)
+				removeTxStatusRequests(txStatusNotifications, wsc,
+					wsc.txStatusRequestIDs())
 				delete(clients, wsc.quit)
 
 			case *notificationRegisterNewMempoolTxs:
@@ -469,6 +562,12 @@ out:
 				wsc := (*wsClient)(n)
 				delete(txNotifications, wsc.quit)
 
+			case *notificationRegisterTxStatus:
+				addTxStatusRequests(txStatusNotifications, n.wsc, n.txIDs)
+
+			case *notificationUnregisterTxStatus:
+				removeTxStatusRequests(txStatusNotifications, n.wsc, n.txIDs)
+
 			default:
 				log.Warn("Unhandled notification type")
 			}
@@ -754,6 +853,155 @@ func (m *wsNotificationManager) notifyForNewTx(clients map[chan struct{}]*wsClie
 	}
 }
 
+// notifyForReplacedTx notifies websocket clients that have registered for
+// updates when a transaction in the memory pool is replaced by a conflicting
+// transaction.
+func (m *wsNotificationManager) notifyForReplacedTx(clients map[chan struct{}]*wsClient,
+	replacedTx *util.Tx, replacementTx *util.Tx) {
+
+	ntfn := rpcmodel.NewTxReplacedNtfn(replacedTx.ID().String(), replacementTx.ID().String())
+	marshalledJSON, err := rpcmodel.MarshalCommand(nil, ntfn)
+	if err != nil {
+		log.Errorf("Failed to marshal tx replaced notification: %s", err)
+		return
+	}
+
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// notifyForRemovedTx notifies websocket clients that have registered for
+// updates when a transaction is dropped from the memory pool without being
+// included in a block.
+func (m *wsNotificationManager) notifyForRemovedTx(clients map[chan struct{}]*wsClient,
+	tx *util.Tx, reason mempool.RemovalReason) {
+
+	ntfn := rpcmodel.NewTxRemovedNtfn(tx.ID().String(), reason.String())
+	marshalledJSON, err := rpcmodel.MarshalCommand(nil, ntfn)
+	if err != nil {
+		log.Errorf("Failed to marshal tx removed notification: %s", err)
+		return
+	}
+
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// RegisterTxStatusUpdates requests notifications to the passed websocket
+// client when any of the passed transactions is dropped from the memory pool
+// or accepted by the virtual block.
+func (m *wsNotificationManager) RegisterTxStatusUpdates(wsc *wsClient, txIDs []*daghash.TxID) {
+	m.queueNotification <- &notificationRegisterTxStatus{
+		wsc:   wsc,
+		txIDs: txIDs,
+	}
+}
+
+// UnregisterTxStatusUpdates removes the notifications to the passed websocket
+// client about the status of the passed transactions.
+func (m *wsNotificationManager) UnregisterTxStatusUpdates(wsc *wsClient, txIDs []*daghash.TxID) {
+	m.queueNotification <- &notificationUnregisterTxStatus{
+		wsc:   wsc,
+		txIDs: txIDs,
+	}
+}
+
+// addTxStatusRequests adds the passed websocket client to the clients that
+// are notified about the status of each of the passed transactions.
+func addTxStatusRequests(txStatusNotifications map[daghash.TxID]map[chan struct{}]*wsClient,
+	wsc *wsClient, txIDs []*daghash.TxID) {
+
+	for _, txID := range txIDs {
+		// Track the request in the client as well so it can be quickly
+		// removed on disconnect.
+		wsc.txStatusRequests[*txID] = struct{}{}
+
+		clients, ok := txStatusNotifications[*txID]
+		if !ok {
+			clients = make(map[chan struct{}]*wsClient)
+			txStatusNotifications[*txID] = clients
+		}
+		clients[wsc.quit] = wsc
+	}
+}
+
+// removeTxStatusRequests removes the passed websocket client from the clients
+// that are notified about the status of each of the passed transactions.
+func removeTxStatusRequests(txStatusNotifications map[daghash.TxID]map[chan struct{}]*wsClient,
+	wsc *wsClient, txIDs []*daghash.TxID) {
+
+	for _, txID := range txIDs {
+		delete(wsc.txStatusRequests, *txID)
+
+		clients, ok := txStatusNotifications[*txID]
+		if !ok {
+			continue
+		}
+		delete(clients, wsc.quit)
+		if len(clients) == 0 {
+			delete(txStatusNotifications, *txID)
+		}
+	}
+}
+
+// notifyTxRemovedFromMempool notifies websocket clients that have registered
+// for the status of a transaction that it was dropped from the memory pool.
+func (m *wsNotificationManager) notifyTxRemovedFromMempool(clients map[chan struct{}]*wsClient,
+	tx *util.Tx, reason mempool.RemovalReason) {
+
+	ntfn := rpcmodel.NewTxRemovedFromMempoolNtfn(tx.ID().String(), reason.String())
+	marshalledJSON, err := rpcmodel.MarshalCommand(nil, ntfn)
+	if err != nil {
+		log.Errorf("Failed to marshal tx removed from mempool "+
+			"notification: %s", err)
+		return
+	}
+
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// notifyTxsAcceptedByVirtual notifies websocket clients that have registered
+// for the status of transactions that are accepted by any of the blocks that
+// were added to the selected parent chain. addedChainBlocksAcceptanceData
+// holds the transactions accepted by each of the added chain blocks, in the
+// same order as addedChainBlockHashes.
+func (m *wsNotificationManager) notifyTxsAcceptedByVirtual(
+	txStatusNotifications map[daghash.TxID]map[chan struct{}]*wsClient,
+	addedChainBlockHashes []*daghash.Hash,
+	addedChainBlocksAcceptanceData []blockdag.MultiBlockTxsAcceptanceData) {
+
+	for i, acceptanceData := range addedChainBlocksAcceptanceData {
+		hash := addedChainBlockHashes[i]
+		for _, blockAcceptanceData := range acceptanceData {
+			for _, txAcceptanceData := range blockAcceptanceData.TxAcceptanceData {
+				if !txAcceptanceData.IsAccepted {
+					continue
+				}
+				txID := txAcceptanceData.Tx.ID()
+				clients, ok := txStatusNotifications[*txID]
+				if !ok {
+					continue
+				}
+
+				ntfn := rpcmodel.NewTxAcceptedByVirtualNtfn(txID.String(), hash.String())
+				marshalledJSON, err := rpcmodel.MarshalCommand(nil, ntfn)
+				if err != nil {
+					log.Errorf("Failed to marshal tx accepted by "+
+						"virtual notification: %s", err)
+					return
+				}
+				for _, wsc := range clients {
+					wsc.QueueNotification(marshalledJSON)
+				}
+			}
+		}
+	}
+}
+
 // txHexString returns the serialized transaction encoded in hexadecimal.
 func txHexString(tx *wire.MsgTx) string {
 	buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
@@ -895,6 +1143,11 @@ type wsClient struct {
 	// `rescanBlocks` methods.
 	filterData *wsClientFilter
 
+	// txStatusRequests is a set of the transactions whose status the
+	// client has requested to be notified about. It's only accessed by
+	// the notification manager's handler goroutine.
+	txStatusRequests map[daghash.TxID]struct{}
+
 	// Networking infrastructure.
 	serviceRequestSem semaphore
 	ntfnChan          chan []byte
@@ -1323,6 +1576,7 @@ func newWebsocketClient(server *Server, conn *websocket.Conn,
 		isAdmin:           isAdmin,
 		sessionID:         sessionID,
 		server:            server,
+		txStatusRequests:  make(map[daghash.TxID]struct{}),
 		serviceRequestSem: makeSemaphore(config.ActiveConfig().RPCMaxConcurrentReqs),
 		ntfnChan:          make(chan []byte, 1), // nonblocking sync
 		sendChan:          make(chan wsResponse, websocketSendBufferSize),
@@ -1331,6 +1585,20 @@ func newWebsocketClient(server *Server, conn *websocket.Conn,
 	return client, nil
 }
 
+// txStatusRequestIDs returns the IDs of the transactions whose status the
+// client has requested to be notified about.
+//
+// This function MUST only be called from the notification manager's handler
+// goroutine.
+func (c *wsClient) txStatusRequestIDs() []*daghash.TxID {
+	txIDs := make([]*daghash.TxID, 0, len(c.txStatusRequests))
+	for txID := range c.txStatusRequests {
+		txID := txID
+		txIDs = append(txIDs, &txID)
+	}
+	return txIDs
+}
+
 func init() {
 	wsHandlers = wsHandlersBeforeInit
 }
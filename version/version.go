@@ -39,6 +39,12 @@ func Version() string {
 	return version
 }
 
+// NumericVersion returns the application version encoded as a single
+// integer, so that e.g. version 1.2.3 becomes 1020300.
+func NumericVersion() int32 {
+	return int32(1000000*appMajor + 10000*appMinor + 100*appPatch)
+}
+
 // checkAppBuild verifies that appBuild does not contain any characters outside of validCharacters.
 // In case of any invalid characters checkAppBuild panics
 func checkAppBuild(appBuild string) {
@@ -175,6 +175,14 @@ func ReadElement(r io.Reader, element interface{}) error {
 		}
 		*e = RejectCode(rv)
 		return nil
+
+	case *NetworkID:
+		rv, err := binaryserializer.Uint8(r)
+		if err != nil {
+			return err
+		}
+		*e = NetworkID(rv)
+		return nil
 	}
 
 	// Fall back to the slower binary.Read if a fast path was not available
@@ -311,6 +319,13 @@ func WriteElement(w io.Writer, element interface{}) error {
 			return err
 		}
 		return nil
+
+	case NetworkID:
+		err := binaryserializer.PutUint8(w, uint8(e))
+		if err != nil {
+			return err
+		}
+		return nil
 	}
 
 	// Fall back to the slower binary.Write if a fast path was not available
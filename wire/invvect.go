@@ -39,6 +39,7 @@ const (
 	InvTypeFilteredBlock   InvType = 3
 	InvTypeSyncBlock       InvType = 4
 	InvTypeMissingAncestor InvType = 5
+	InvTypeCompactBlock    InvType = 6
 )
 
 // Map of service flags back to their constant names for pretty printing.
@@ -49,6 +50,7 @@ var ivStrings = map[InvType]string{
 	InvTypeFilteredBlock:   "MSG_FILTERED_BLOCK",
 	InvTypeSyncBlock:       "MSG_SYNC_BLOCK",
 	InvTypeMissingAncestor: "MSG_MISSING_ANCESTOR",
+	InvTypeCompactBlock:    "MSG_CMPCT_BLOCK",
 }
 
 // String returns the InvType in human-readable form.
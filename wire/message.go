@@ -51,6 +51,12 @@ const (
 	CmdBlockLocator    = "locator"
 	CmdSelectedTip     = "selectedtip"
 	CmdGetSelectedTip  = "getseltip"
+	CmdGetHeaders      = "getheaders"
+	CmdHeaders         = "headers"
+	CmdCmpctBlock      = "cmpctblock"
+	CmdGetBlockTxn     = "getblocktxn"
+	CmdBlockTxn        = "blocktxn"
+	CmdAddrV2          = "addrv2"
 )
 
 // Message is an interface that describes a kaspa message. A type that
@@ -135,6 +141,24 @@ func makeEmptyMessage(command string) (Message, error) {
 	case CmdSelectedTip:
 		msg = &MsgSelectedTip{}
 
+	case CmdGetHeaders:
+		msg = &MsgGetHeaders{}
+
+	case CmdHeaders:
+		msg = &MsgHeaders{}
+
+	case CmdCmpctBlock:
+		msg = &MsgCmpctBlock{}
+
+	case CmdGetBlockTxn:
+		msg = &MsgGetBlockTxn{}
+
+	case CmdBlockTxn:
+		msg = &MsgBlockTxn{}
+
+	case CmdAddrV2:
+		msg = &MsgAddrV2{}
+
 	default:
 		return nil, errors.Errorf("unhandled command [%s]", command)
 	}
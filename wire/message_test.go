@@ -69,6 +69,13 @@ func TestMessage(t *testing.T) {
 	bh := NewBlockHeader(1, []*daghash.Hash{mainnetGenesisHash, simnetGenesisHash}, &daghash.Hash{}, &daghash.Hash{}, &daghash.Hash{}, 0, 0)
 	msgMerkleBlock := NewMsgMerkleBlock(bh)
 	msgReject := NewMsgReject("block", RejectDuplicate, "duplicate block")
+	msgGetHeaders := NewMsgGetHeaders(&daghash.Hash{}, &daghash.Hash{})
+	msgHeaders := NewMsgHeaders()
+	msgCmpctBlock := NewMsgCmpctBlock(&blockOne)
+	msgGetBlockTxn := NewMsgGetBlockTxn(&daghash.Hash{}, []uint32{1, 2})
+	msgBlockTxn := NewMsgBlockTxn(&daghash.Hash{})
+	msgBlockTxn.AddTransaction(msgTx)
+	msgAddrV2 := NewMsgAddrV2(false, nil)
 
 	tests := []struct {
 		in       Message  // Value to encode
@@ -97,6 +104,12 @@ func TestMessage(t *testing.T) {
 		{msgFilterLoad, msgFilterLoad, pver, Mainnet, 35},
 		{msgMerkleBlock, msgMerkleBlock, pver, Mainnet, 215},
 		{msgReject, msgReject, pver, Mainnet, 79},
+		{msgGetHeaders, msgGetHeaders, pver, Mainnet, 88},
+		{msgHeaders, msgHeaders, pver, Mainnet, 25},
+		{msgCmpctBlock, msgCmpctBlock, pver, Mainnet, 374},
+		{msgGetBlockTxn, msgGetBlockTxn, pver, Mainnet, 59},
+		{msgBlockTxn, msgBlockTxn, pver, Mainnet, 91},
+		{msgAddrV2, msgAddrV2, pver, Mainnet, 27},
 	}
 
 	t.Logf("Running %d tests", len(tests))
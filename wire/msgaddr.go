@@ -62,29 +62,12 @@ func (msg *MsgAddr) ClearAddresses() {
 // KaspaDecode decodes r using the kaspa protocol encoding into the receiver.
 // This is part of the Message interface implementation.
 func (msg *MsgAddr) KaspaDecode(r io.Reader, pver uint32) error {
-	msg.SubnetworkID = nil
-
-	err := ReadElement(r, &msg.IncludeAllSubnetworks)
+	var err error
+	msg.IncludeAllSubnetworks, msg.SubnetworkID, err = readAddrSubnetwork(r)
 	if err != nil {
 		return err
 	}
 
-	if !msg.IncludeAllSubnetworks {
-		var isFullNode bool
-		err := ReadElement(r, &isFullNode)
-		if err != nil {
-			return err
-		}
-		if !isFullNode {
-			var subnetworkID subnetworkid.SubnetworkID
-			err = ReadElement(r, &subnetworkID)
-			if err != nil {
-				return err
-			}
-			msg.SubnetworkID = &subnetworkID
-		}
-	}
-
 	// Read addresses array
 	count, err := ReadVarInt(r)
 	if err != nil {
@@ -121,26 +104,11 @@ func (msg *MsgAddr) KaspaEncode(w io.Writer, pver uint32) error {
 		return messageError("MsgAddr.KaspaEncode", str)
 	}
 
-	err := WriteElement(w, msg.IncludeAllSubnetworks)
+	err := writeAddrSubnetwork(w, msg.IncludeAllSubnetworks, msg.SubnetworkID)
 	if err != nil {
 		return err
 	}
 
-	if !msg.IncludeAllSubnetworks {
-		// Write subnetwork ID
-		isFullNode := msg.SubnetworkID == nil
-		err = WriteElement(w, isFullNode)
-		if err != nil {
-			return err
-		}
-		if !isFullNode {
-			err = WriteElement(w, msg.SubnetworkID)
-			if err != nil {
-				return err
-			}
-		}
-	}
-
 	err = WriteVarInt(w, uint64(count))
 	if err != nil {
 		return err
@@ -156,6 +124,60 @@ func (msg *MsgAddr) KaspaEncode(w io.Writer, pver uint32) error {
 	return nil
 }
 
+// readAddrSubnetwork reads the subnetwork that the addresses of an addr or
+// addrv2 message belong to.
+func readAddrSubnetwork(r io.Reader) (includeAllSubnetworks bool,
+	subnetworkID *subnetworkid.SubnetworkID, err error) {
+
+	err = ReadElement(r, &includeAllSubnetworks)
+	if err != nil {
+		return false, nil, err
+	}
+	if includeAllSubnetworks {
+		return true, nil, nil
+	}
+
+	var isFullNode bool
+	err = ReadElement(r, &isFullNode)
+	if err != nil {
+		return false, nil, err
+	}
+	if isFullNode {
+		return false, nil, nil
+	}
+	subnetworkID = &subnetworkid.SubnetworkID{}
+	err = ReadElement(r, subnetworkID)
+	if err != nil {
+		return false, nil, err
+	}
+	return false, subnetworkID, nil
+}
+
+// writeAddrSubnetwork writes the subnetwork that the addresses of an addr or
+// addrv2 message belong to.
+func writeAddrSubnetwork(w io.Writer, includeAllSubnetworks bool,
+	subnetworkID *subnetworkid.SubnetworkID) error {
+
+	err := WriteElement(w, includeAllSubnetworks)
+	if err != nil {
+		return err
+	}
+	if includeAllSubnetworks {
+		return nil
+	}
+
+	// Write subnetwork ID
+	isFullNode := subnetworkID == nil
+	err = WriteElement(w, isFullNode)
+	if err != nil {
+		return err
+	}
+	if !isFullNode {
+		return WriteElement(w, subnetworkID)
+	}
+	return nil
+}
+
 // Command returns the protocol command string for the message. This is part
 // of the Message interface implementation.
 func (msg *MsgAddr) Command() string {
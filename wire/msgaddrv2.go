@@ -0,0 +1,154 @@
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/kaspanet/kaspad/util/subnetworkid"
+)
+
+// MsgAddrV2 implements the Message interface and represents a kaspa addrv2
+// message. It is the same as the addr message (MsgAddr), except that its
+// addresses are encoded along with their network, so that it can also relay
+// addresses that aren't IP addresses, such as those of Tor onion services.
+//
+// Addresses of networks that are unknown to the receiver are skipped when the
+// message is decoded.
+//
+// This message was not added until protocol versions starting with
+// AddrV2Version.
+type MsgAddrV2 struct {
+	IncludeAllSubnetworks bool
+	SubnetworkID          *subnetworkid.SubnetworkID
+	AddrList              []*NetAddress
+}
+
+// AddAddress adds a known active peer to the message.
+func (msg *MsgAddrV2) AddAddress(na *NetAddress) error {
+	if len(msg.AddrList)+1 > MaxAddrPerMsg {
+		str := fmt.Sprintf("too many addresses in message [max %d]",
+			MaxAddrPerMsg)
+		return messageError("MsgAddrV2.AddAddress", str)
+	}
+
+	msg.AddrList = append(msg.AddrList, na)
+	return nil
+}
+
+// AddAddresses adds multiple known active peers to the message.
+func (msg *MsgAddrV2) AddAddresses(netAddrs ...*NetAddress) error {
+	for _, na := range netAddrs {
+		err := msg.AddAddress(na)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClearAddresses removes all addresses from the message.
+func (msg *MsgAddrV2) ClearAddresses() {
+	msg.AddrList = []*NetAddress{}
+}
+
+// KaspaDecode decodes r using the kaspa protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgAddrV2) KaspaDecode(r io.Reader, pver uint32) error {
+	if pver < AddrV2Version {
+		str := fmt.Sprintf("addrv2 message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgAddrV2.KaspaDecode", str)
+	}
+
+	var err error
+	msg.IncludeAllSubnetworks, msg.SubnetworkID, err = readAddrSubnetwork(r)
+	if err != nil {
+		return err
+	}
+
+	// Read addresses array
+	count, err := ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+
+	// Limit to max addresses per message.
+	if count > MaxAddrPerMsg {
+		str := fmt.Sprintf("too many addresses for message "+
+			"[count %d, max %d]", count, MaxAddrPerMsg)
+		return messageError("MsgAddrV2.KaspaDecode", str)
+	}
+
+	msg.AddrList = make([]*NetAddress, 0, count)
+	for i := uint64(0); i < count; i++ {
+		na := &NetAddress{}
+		known, err := readNetAddressV2(r, pver, na)
+		if err != nil {
+			return err
+		}
+		if !known {
+			continue
+		}
+		msg.AddAddress(na)
+	}
+	return nil
+}
+
+// KaspaEncode encodes the receiver to w using the kaspa protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgAddrV2) KaspaEncode(w io.Writer, pver uint32) error {
+	if pver < AddrV2Version {
+		str := fmt.Sprintf("addrv2 message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgAddrV2.KaspaEncode", str)
+	}
+
+	count := len(msg.AddrList)
+	if count > MaxAddrPerMsg {
+		str := fmt.Sprintf("too many addresses for message "+
+			"[count %d, max %d]", count, MaxAddrPerMsg)
+		return messageError("MsgAddrV2.KaspaEncode", str)
+	}
+
+	err := writeAddrSubnetwork(w, msg.IncludeAllSubnetworks, msg.SubnetworkID)
+	if err != nil {
+		return err
+	}
+
+	err = WriteVarInt(w, uint64(count))
+	if err != nil {
+		return err
+	}
+
+	for _, na := range msg.AddrList {
+		err = writeNetAddressV2(w, pver, na)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message. This is part
+// of the Message interface implementation.
+func (msg *MsgAddrV2) Command() string {
+	return CmdAddrV2
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver. This is part of the Message interface implementation.
+func (msg *MsgAddrV2) MaxPayloadLength(pver uint32) uint32 {
+	// IncludeAllSubnetworks flag 1 byte + isFullNode 1 byte + SubnetworkID length + Num addresses (varInt) + max allowed addresses.
+	return 1 + 1 + subnetworkid.IDLength + MaxVarIntPayload + (MaxAddrPerMsg * maxNetAddressV2Payload(pver))
+}
+
+// NewMsgAddrV2 returns a new kaspa addrv2 message that conforms to the
+// Message interface. See MsgAddrV2 for details.
+func NewMsgAddrV2(includeAllSubnetworks bool, subnetworkID *subnetworkid.SubnetworkID) *MsgAddrV2 {
+	return &MsgAddrV2{
+		IncludeAllSubnetworks: includeAllSubnetworks,
+		SubnetworkID:          subnetworkID,
+		AddrList:              make([]*NetAddress, 0, MaxAddrPerMsg),
+	}
+}
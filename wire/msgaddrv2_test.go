@@ -0,0 +1,229 @@
+package wire
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/pkg/errors"
+)
+
+// TestAddrV2 tests the MsgAddrV2 API.
+func TestAddrV2(t *testing.T) {
+	pver := ProtocolVersion
+
+	// Ensure the command is expected value.
+	wantCmd := "addrv2"
+	msg := NewMsgAddrV2(false, nil)
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgAddrV2: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	wantPayload := uint32(540031)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Ensure NetAddresses are added properly.
+	overlay, err := ParseOverlayAddress("2gzyxa5ihm7nsggfxnu52rck2vv4rvmdlkiu3zzui5du4xyclen53wid.onion")
+	if err != nil {
+		t.Fatalf("ParseOverlayAddress: %v", err)
+	}
+	na := NewNetAddressOverlay(overlay, 16111, SFNodeNetwork)
+	err = msg.AddAddress(na)
+	if err != nil {
+		t.Errorf("AddAddress: %v", err)
+	}
+	if msg.AddrList[0] != na {
+		t.Errorf("AddAddress: wrong address added - got %v, want %v",
+			spew.Sprint(msg.AddrList[0]), spew.Sprint(na))
+	}
+
+	// Ensure the address list is cleared properly.
+	msg.ClearAddresses()
+	if len(msg.AddrList) != 0 {
+		t.Errorf("ClearAddresses: address list is not empty - "+
+			"got %v [%v], want %v", len(msg.AddrList),
+			spew.Sprint(msg.AddrList[0]), 0)
+	}
+
+	// Ensure adding more than the max allowed addresses per message returns
+	// error.
+	for i := 0; i < MaxAddrPerMsg+1; i++ {
+		err = msg.AddAddress(na)
+	}
+	if err == nil {
+		t.Errorf("AddAddress: expected error on too many addresses " +
+			"not received")
+	}
+}
+
+// TestAddrV2Wire tests the MsgAddrV2 wire encode and decode of addresses of
+// the different networks.
+func TestAddrV2Wire(t *testing.T) {
+	timestamp := time.Unix(0x495fab29, 0) // 2009-01-03 12:15:05 -0600 CST
+	ipv4 := &NetAddress{
+		Timestamp: timestamp,
+		Services:  SFNodeNetwork,
+		IP:        net.ParseIP("127.0.0.1"),
+		Port:      16111,
+	}
+	ipv6 := &NetAddress{
+		Timestamp: timestamp,
+		Services:  SFNodeNetwork,
+		IP:        net.ParseIP("2001:db8::1"),
+		Port:      16111,
+	}
+	onion := &NetAddress{
+		Timestamp: timestamp,
+		Services:  SFNodeNetwork,
+		Overlay:   &OverlayAddress{Network: NetworkTorV3},
+		Port:      16111,
+	}
+	for i := range onion.Overlay.Key {
+		onion.Overlay.Key[i] = byte(i)
+	}
+
+	msg := NewMsgAddrV2(true, nil)
+	msg.AddAddresses(ipv4, ipv6, onion)
+	encoded := []byte{
+		0x01,                                           // All subnetworks
+		0x03,                                           // Varint for number of addresses
+		0x29, 0xab, 0x5f, 0x49, 0x00, 0x00, 0x00, 0x00, // Timestamp
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // SFNodeNetwork
+		0x01,                   // NetworkIPv4
+		0x04,                   // Varint for address length
+		0x7f, 0x00, 0x00, 0x01, // IP 127.0.0.1
+		0x3e, 0xef, // Port 16111 in big-endian
+		0x29, 0xab, 0x5f, 0x49, 0x00, 0x00, 0x00, 0x00, // Timestamp
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // SFNodeNetwork
+		0x02,                                           // NetworkIPv6
+		0x10,                                           // Varint for address length
+		0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x00, // IP 2001:db8::1
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+		0x3e, 0xef, // Port 16111 in big-endian
+		0x29, 0xab, 0x5f, 0x49, 0x00, 0x00, 0x00, 0x00, // Timestamp
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // SFNodeNetwork
+		0x04,                                           // NetworkTorV3
+		0x20,                                           // Varint for address length
+		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, // Onion service key
+		0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+		0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17,
+		0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+		0x3e, 0xef, // Port 16111 in big-endian
+	}
+
+	var buf bytes.Buffer
+	err := msg.KaspaEncode(&buf, ProtocolVersion)
+	if err != nil {
+		t.Fatalf("KaspaEncode error %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), encoded) {
+		t.Fatalf("KaspaEncode\n got: %s want: %s",
+			spew.Sdump(buf.Bytes()), spew.Sdump(encoded))
+	}
+
+	var decodedMsg MsgAddrV2
+	err = decodedMsg.KaspaDecode(bytes.NewReader(encoded), ProtocolVersion)
+	if err != nil {
+		t.Fatalf("KaspaDecode error %v", err)
+	}
+	if !reflect.DeepEqual(&decodedMsg, msg) {
+		t.Fatalf("KaspaDecode\n got: %s want: %s",
+			spew.Sdump(&decodedMsg), spew.Sdump(msg))
+	}
+
+	// Ensure addresses of unknown networks are skipped.
+	withUnknown := []byte{
+		0x01,                                           // All subnetworks
+		0x02,                                           // Varint for number of addresses
+		0x29, 0xab, 0x5f, 0x49, 0x00, 0x00, 0x00, 0x00, // Timestamp
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // SFNodeNetwork
+		0x06,             // Unknown network
+		0x03,             // Varint for address length
+		0x01, 0x02, 0x03, // Address
+		0x3e, 0xef, // Port 16111 in big-endian
+		0x29, 0xab, 0x5f, 0x49, 0x00, 0x00, 0x00, 0x00, // Timestamp
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // SFNodeNetwork
+		0x01,                   // NetworkIPv4
+		0x04,                   // Varint for address length
+		0x7f, 0x00, 0x00, 0x01, // IP 127.0.0.1
+		0x3e, 0xef, // Port 16111 in big-endian
+	}
+	decodedMsg = MsgAddrV2{}
+	err = decodedMsg.KaspaDecode(bytes.NewReader(withUnknown), ProtocolVersion)
+	if err != nil {
+		t.Fatalf("KaspaDecode error %v", err)
+	}
+	wantMsg := NewMsgAddrV2(true, nil)
+	wantMsg.AddAddress(ipv4)
+	if !reflect.DeepEqual(&decodedMsg, wantMsg) {
+		t.Fatalf("KaspaDecode\n got: %s want: %s",
+			spew.Sdump(&decodedMsg), spew.Sdump(wantMsg))
+	}
+}
+
+// TestAddrV2WireErrors performs negative tests against wire decode of
+// MsgAddrV2 to confirm error paths work correctly.
+func TestAddrV2WireErrors(t *testing.T) {
+	header := []byte{
+		0x01,                                           // All subnetworks
+		0x01,                                           // Varint for number of addresses
+		0x29, 0xab, 0x5f, 0x49, 0x00, 0x00, 0x00, 0x00, // Timestamp
+		0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // SFNodeNetwork
+	}
+	withHeader := func(b ...byte) []byte {
+		return append(append([]byte{}, header...), b...)
+	}
+
+	tests := []struct {
+		name string
+		buf  []byte
+		pver uint32
+	}{
+		{
+			name: "protocol version before AddrV2Version",
+			buf:  withHeader(0x01, 0x04, 0x7f, 0x00, 0x00, 0x01, 0x3e, 0xef),
+			pver: AddrV2Version - 1,
+		},
+		{
+			name: "too many addresses",
+			buf:  []byte{0x01, 0xfd, 0x03, 0xe9},
+			pver: ProtocolVersion,
+		},
+		{
+			name: "IPv4 address of the wrong length",
+			buf:  withHeader(0x01, 0x05, 0x7f, 0x00, 0x00, 0x00, 0x01, 0x3e, 0xef),
+			pver: ProtocolVersion,
+		},
+		{
+			name: "address longer than allowed",
+			buf:  withHeader(0x06, 0xfd, 0x01, 0x02),
+			pver: ProtocolVersion,
+		},
+	}
+
+	for _, test := range tests {
+		var msg MsgAddrV2
+		err := msg.KaspaDecode(bytes.NewReader(test.buf), test.pver)
+		if msgErr := &(MessageError{}); !errors.As(err, &msgErr) {
+			t.Errorf("KaspaDecode: %s: expected a MessageError, got: %v",
+				test.name, err)
+		}
+	}
+
+	// Ensure the message can't be encoded for peers that don't support it.
+	var buf bytes.Buffer
+	err := NewMsgAddrV2(true, nil).KaspaEncode(&buf, AddrV2Version-1)
+	if msgErr := &(MessageError{}); !errors.As(err, &msgErr) {
+		t.Errorf("KaspaEncode: expected a MessageError, got: %v", err)
+	}
+}
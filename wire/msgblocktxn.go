@@ -0,0 +1,118 @@
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// MsgBlockTxn implements the Message interface and represents a kaspa
+// blocktxn message. It is used to deliver the transactions that were
+// requested by a getblocktxn message, in the order they were requested in.
+//
+// This message was not added until protocol versions starting with
+// CompactBlocksVersion.
+type MsgBlockTxn struct {
+	BlockHash    *daghash.Hash
+	Transactions []*MsgTx
+}
+
+// AddTransaction adds a transaction to the message.
+func (msg *MsgBlockTxn) AddTransaction(tx *MsgTx) {
+	msg.Transactions = append(msg.Transactions, tx)
+}
+
+// KaspaDecode decodes r using the kaspa protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgBlockTxn) KaspaDecode(r io.Reader, pver uint32) error {
+	if pver < CompactBlocksVersion {
+		str := fmt.Sprintf("blocktxn message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgBlockTxn.KaspaDecode", str)
+	}
+
+	msg.BlockHash = &daghash.Hash{}
+	err := ReadElement(r, msg.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	txCount, err := ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+	if txCount > maxTxPerBlock {
+		str := fmt.Sprintf("too many transactions to fit into a block "+
+			"[count %d, max %d]", txCount, maxTxPerBlock)
+		return messageError("MsgBlockTxn.KaspaDecode", str)
+	}
+
+	msg.Transactions = make([]*MsgTx, 0, txCount)
+	for i := uint64(0); i < txCount; i++ {
+		tx := MsgTx{}
+		err := tx.KaspaDecode(r, pver)
+		if err != nil {
+			return err
+		}
+		msg.Transactions = append(msg.Transactions, &tx)
+	}
+
+	return nil
+}
+
+// KaspaEncode encodes the receiver to w using the kaspa protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgBlockTxn) KaspaEncode(w io.Writer, pver uint32) error {
+	if pver < CompactBlocksVersion {
+		str := fmt.Sprintf("blocktxn message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgBlockTxn.KaspaEncode", str)
+	}
+
+	txCount := len(msg.Transactions)
+	if txCount > maxTxPerBlock {
+		str := fmt.Sprintf("too many transactions to fit into a block "+
+			"[count %d, max %d]", txCount, maxTxPerBlock)
+		return messageError("MsgBlockTxn.KaspaEncode", str)
+	}
+
+	err := WriteElement(w, msg.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	err = WriteVarInt(w, uint64(txCount))
+	if err != nil {
+		return err
+	}
+	for _, tx := range msg.Transactions {
+		err = tx.KaspaEncode(w, pver)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message. This is part
+// of the Message interface implementation.
+func (msg *MsgBlockTxn) Command() string {
+	return CmdBlockTxn
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver. This is part of the Message interface implementation.
+func (msg *MsgBlockTxn) MaxPayloadLength(pver uint32) uint32 {
+	return MaxMessagePayload
+}
+
+// NewMsgBlockTxn returns a new kaspa blocktxn message for the block of the
+// given hash that conforms to the Message interface. See MsgBlockTxn for
+// details.
+func NewMsgBlockTxn(blockHash *daghash.Hash) *MsgBlockTxn {
+	return &MsgBlockTxn{
+		BlockHash: blockHash,
+	}
+}
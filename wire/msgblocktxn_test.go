@@ -0,0 +1,94 @@
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+// TestBlockTxn tests the MsgBlockTxn API.
+func TestBlockTxn(t *testing.T) {
+	blockHash := blockOne.BlockHash()
+	msg := NewMsgBlockTxn(blockHash)
+	if !msg.BlockHash.IsEqual(blockHash) {
+		t.Errorf("NewMsgBlockTxn: wrong block hash - got %v, want %v",
+			msg.BlockHash, blockHash)
+	}
+
+	// Ensure the command is expected value.
+	wantCmd := "blocktxn"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgBlockTxn: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure transactions are added properly.
+	tx := blockOne.Transactions[0]
+	msg.AddTransaction(tx)
+	if len(msg.Transactions) != 1 || msg.Transactions[0] != tx {
+		t.Errorf("AddTransaction: wrong transactions - got %v, want %v",
+			spew.Sdump(msg.Transactions), spew.Sdump(tx))
+	}
+}
+
+// TestBlockTxnWire tests the MsgBlockTxn wire encode and decode.
+func TestBlockTxnWire(t *testing.T) {
+	msg := NewMsgBlockTxn(blockOne.BlockHash())
+	msg.AddTransaction(blockOne.Transactions[0])
+	msg.AddTransaction(NewNativeMsgTx(1, nil, nil))
+
+	var buf bytes.Buffer
+	err := msg.KaspaEncode(&buf, ProtocolVersion)
+	if err != nil {
+		t.Fatalf("KaspaEncode error %v", err)
+	}
+
+	var decodedMsg MsgBlockTxn
+	err = decodedMsg.KaspaDecode(bytes.NewReader(buf.Bytes()), ProtocolVersion)
+	if err != nil {
+		t.Fatalf("KaspaDecode error %v", err)
+	}
+	if !reflect.DeepEqual(&decodedMsg, msg) {
+		t.Fatalf("KaspaDecode\n got: %s want: %s",
+			spew.Sdump(&decodedMsg), spew.Sdump(msg))
+	}
+}
+
+// TestBlockTxnWireErrors performs negative tests against wire decode of
+// MsgBlockTxn to confirm error paths work correctly.
+func TestBlockTxnWireErrors(t *testing.T) {
+	hashBytes := make([]byte, daghash.HashSize)
+	withHash := func(b ...byte) []byte {
+		return append(append([]byte{}, hashBytes...), b...)
+	}
+
+	tests := []struct {
+		name string
+		buf  []byte
+		pver uint32
+	}{
+		{
+			name: "protocol version before CompactBlocksVersion",
+			buf:  withHash(0x00),
+			pver: CompactBlocksVersion - 1,
+		},
+		{
+			name: "too many transactions",
+			buf:  withHash(0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f),
+			pver: ProtocolVersion,
+		},
+	}
+
+	for _, test := range tests {
+		var msg MsgBlockTxn
+		err := msg.KaspaDecode(bytes.NewReader(test.buf), test.pver)
+		if msgErr := &(MessageError{}); !errors.As(err, &msgErr) {
+			t.Errorf("KaspaDecode: %s: expected a MessageError, got: %v",
+				test.name, err)
+		}
+	}
+}
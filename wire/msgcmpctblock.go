@@ -0,0 +1,213 @@
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// ShortTxIDSize is the size in bytes of the short transaction IDs that
+// are sent in compact blocks.
+const ShortTxIDSize = 6
+
+// PrefilledTx is a transaction that is sent in full inside a compact block,
+// along with its index in the block.
+type PrefilledTx struct {
+	Index uint32
+	Tx    *MsgTx
+}
+
+// MsgCmpctBlock implements the Message interface and represents a kaspa
+// cmpctblock message. It is used to deliver a block to a peer that most
+// likely already has most of its transactions in its mempool. Instead of the
+// full transactions, the message contains a short ID for every transaction
+// of the block, and only the transactions that the peer is not expected to
+// have, such as the coinbase, are sent in full as prefilled transactions.
+// Transactions that can't be found by their short IDs are requested with a
+// getblocktxn message.
+//
+// The short IDs are not salted, so that the mempool can keep them indexed
+// instead of calculating them for every compact block. A short ID that
+// matches more than one transaction is resolved with getblocktxn, so a
+// collision only costs a round trip.
+//
+// This message was not added until protocol versions starting with
+// CompactBlocksVersion.
+type MsgCmpctBlock struct {
+	Header       BlockHeader
+	ShortIDs     []uint64
+	PrefilledTxs []*PrefilledTx
+}
+
+// TxCount returns the number of transactions in the block.
+func (msg *MsgCmpctBlock) TxCount() int {
+	return len(msg.ShortIDs) + len(msg.PrefilledTxs)
+}
+
+// ShortTxID returns the short ID of the transaction with the given ID, which
+// is made of its first ShortTxIDSize bytes.
+func ShortTxID(txID *daghash.TxID) uint64 {
+	return littleEndian.Uint64(txID[:8]) & (1<<(8*ShortTxIDSize) - 1)
+}
+
+// KaspaDecode decodes r using the kaspa protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) KaspaDecode(r io.Reader, pver uint32) error {
+	if pver < CompactBlocksVersion {
+		str := fmt.Sprintf("cmpctblock message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCmpctBlock.KaspaDecode", str)
+	}
+
+	err := readBlockHeader(r, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+
+	shortIDCount, err := ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+	if shortIDCount > maxTxPerBlock {
+		str := fmt.Sprintf("too many short transaction IDs for message "+
+			"[count %d, max %d]", shortIDCount, maxTxPerBlock)
+		return messageError("MsgCmpctBlock.KaspaDecode", str)
+	}
+	msg.ShortIDs = make([]uint64, shortIDCount)
+	var shortIDBytes [8]byte
+	for i := range msg.ShortIDs {
+		_, err := io.ReadFull(r, shortIDBytes[:ShortTxIDSize])
+		if err != nil {
+			return err
+		}
+		msg.ShortIDs[i] = littleEndian.Uint64(shortIDBytes[:])
+	}
+
+	prefilledCount, err := ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+	// Compare against the remaining room rather than checking the sum,
+	// since the sum of the counts may overflow.
+	if prefilledCount > maxTxPerBlock-shortIDCount {
+		str := fmt.Sprintf("too many transactions to fit into a block "+
+			"[short IDs %d, prefilled %d, max %d]", shortIDCount,
+			prefilledCount, maxTxPerBlock)
+		return messageError("MsgCmpctBlock.KaspaDecode", str)
+	}
+	txCount := shortIDCount + prefilledCount
+	msg.PrefilledTxs = make([]*PrefilledTx, 0, prefilledCount)
+	for i := uint64(0); i < prefilledCount; i++ {
+		index, err := ReadVarInt(r)
+		if err != nil {
+			return err
+		}
+		if index >= txCount {
+			str := fmt.Sprintf("prefilled transaction index %d is "+
+				"out of range [count %d]", index, txCount)
+			return messageError("MsgCmpctBlock.KaspaDecode", str)
+		}
+		if i > 0 && uint32(index) <= msg.PrefilledTxs[i-1].Index {
+			str := "prefilled transaction indexes are not in " +
+				"increasing order"
+			return messageError("MsgCmpctBlock.KaspaDecode", str)
+		}
+
+		tx := MsgTx{}
+		err = tx.KaspaDecode(r, pver)
+		if err != nil {
+			return err
+		}
+		msg.PrefilledTxs = append(msg.PrefilledTxs, &PrefilledTx{
+			Index: uint32(index),
+			Tx:    &tx,
+		})
+	}
+
+	return nil
+}
+
+// KaspaEncode encodes the receiver to w using the kaspa protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) KaspaEncode(w io.Writer, pver uint32) error {
+	if pver < CompactBlocksVersion {
+		str := fmt.Sprintf("cmpctblock message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCmpctBlock.KaspaEncode", str)
+	}
+
+	txCount := msg.TxCount()
+	if txCount > maxTxPerBlock {
+		str := fmt.Sprintf("too many transactions to fit into a block "+
+			"[count %d, max %d]", txCount, maxTxPerBlock)
+		return messageError("MsgCmpctBlock.KaspaEncode", str)
+	}
+
+	err := writeBlockHeader(w, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+
+	err = WriteVarInt(w, uint64(len(msg.ShortIDs)))
+	if err != nil {
+		return err
+	}
+	var shortIDBytes [8]byte
+	for _, shortID := range msg.ShortIDs {
+		littleEndian.PutUint64(shortIDBytes[:], shortID)
+		_, err := w.Write(shortIDBytes[:ShortTxIDSize])
+		if err != nil {
+			return err
+		}
+	}
+
+	err = WriteVarInt(w, uint64(len(msg.PrefilledTxs)))
+	if err != nil {
+		return err
+	}
+	for _, prefilledTx := range msg.PrefilledTxs {
+		err = WriteVarInt(w, uint64(prefilledTx.Index))
+		if err != nil {
+			return err
+		}
+		err = prefilledTx.Tx.KaspaEncode(w, pver)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message. This is part
+// of the Message interface implementation.
+func (msg *MsgCmpctBlock) Command() string {
+	return CmdCmpctBlock
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver. This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) MaxPayloadLength(pver uint32) uint32 {
+	return MaxMessagePayload
+}
+
+// NewMsgCmpctBlock returns a new kaspa cmpctblock message of the given block
+// that conforms to the Message interface. The first transaction of the
+// block, which is the coinbase, is prefilled, and the rest of the
+// transactions are represented by their short IDs.
+func NewMsgCmpctBlock(block *MsgBlock) *MsgCmpctBlock {
+	msg := &MsgCmpctBlock{
+		Header:   block.Header,
+		ShortIDs: make([]uint64, 0, len(block.Transactions)),
+	}
+	if len(block.Transactions) == 0 {
+		return msg
+	}
+
+	msg.PrefilledTxs = []*PrefilledTx{{Index: 0, Tx: block.Transactions[0]}}
+	for _, tx := range block.Transactions[1:] {
+		msg.ShortIDs = append(msg.ShortIDs, ShortTxID(tx.TxID()))
+	}
+	return msg
+}
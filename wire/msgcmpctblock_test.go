@@ -0,0 +1,155 @@
+package wire
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/pkg/errors"
+)
+
+// TestCmpctBlock tests the MsgCmpctBlock API.
+func TestCmpctBlock(t *testing.T) {
+	tx := NewNativeMsgTx(1, nil, nil)
+	block := MsgBlock{
+		Header:       blockOne.Header,
+		Transactions: []*MsgTx{blockOne.Transactions[0], tx},
+	}
+	msg := NewMsgCmpctBlock(&block)
+
+	// Ensure the command is expected value.
+	wantCmd := "cmpctblock"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgCmpctBlock: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure the first transaction is prefilled and the rest are
+	// represented by their short IDs.
+	if msg.TxCount() != len(block.Transactions) {
+		t.Errorf("TxCount: wrong transaction count - got %d, want %d",
+			msg.TxCount(), len(block.Transactions))
+	}
+	if len(msg.PrefilledTxs) != 1 || msg.PrefilledTxs[0].Index != 0 ||
+		msg.PrefilledTxs[0].Tx != block.Transactions[0] {
+		t.Errorf("NewMsgCmpctBlock: wrong prefilled transactions - got %v",
+			spew.Sdump(msg.PrefilledTxs))
+	}
+	wantShortID := ShortTxID(tx.TxID())
+	if len(msg.ShortIDs) != 1 || msg.ShortIDs[0] != wantShortID {
+		t.Errorf("NewMsgCmpctBlock: wrong short IDs - got %v, want [%d]",
+			msg.ShortIDs, wantShortID)
+	}
+	if wantShortID >= 1<<(8*ShortTxIDSize) {
+		t.Errorf("ShortTxID: short ID %d is longer than %d bytes",
+			wantShortID, ShortTxIDSize)
+	}
+}
+
+// TestCmpctBlockWire tests the MsgCmpctBlock wire encode and decode.
+func TestCmpctBlockWire(t *testing.T) {
+	block := MsgBlock{
+		Header: blockOne.Header,
+		Transactions: []*MsgTx{blockOne.Transactions[0],
+			NewNativeMsgTx(1, nil, nil), NewNativeMsgTx(2, nil, nil)},
+	}
+	msg := NewMsgCmpctBlock(&block)
+
+	var buf bytes.Buffer
+	err := msg.KaspaEncode(&buf, ProtocolVersion)
+	if err != nil {
+		t.Fatalf("KaspaEncode error %v", err)
+	}
+
+	var decodedMsg MsgCmpctBlock
+	err = decodedMsg.KaspaDecode(bytes.NewReader(buf.Bytes()), ProtocolVersion)
+	if err != nil {
+		t.Fatalf("KaspaDecode error %v", err)
+	}
+	if !reflect.DeepEqual(&decodedMsg, msg) {
+		t.Fatalf("KaspaDecode\n got: %s want: %s",
+			spew.Sdump(&decodedMsg), spew.Sdump(msg))
+	}
+}
+
+// TestCmpctBlockWireErrors performs negative tests against wire decode of
+// MsgCmpctBlock to confirm error paths work correctly.
+func TestCmpctBlockWireErrors(t *testing.T) {
+	var prefix bytes.Buffer
+	err := writeBlockHeader(&prefix, ProtocolVersion, &blockOne.Header)
+	if err != nil {
+		t.Fatalf("writeBlockHeader error %v", err)
+	}
+	withPrefix := func(b ...byte) []byte {
+		return append(append([]byte{}, prefix.Bytes()...), b...)
+	}
+	var txBuf bytes.Buffer
+	err = blockOne.Transactions[0].KaspaEncode(&txBuf, ProtocolVersion)
+	if err != nil {
+		t.Fatalf("KaspaEncode error %v", err)
+	}
+	txBytes := txBuf.Bytes()
+	varInt := func(val uint64) []byte {
+		var buf bytes.Buffer
+		err := WriteVarInt(&buf, val)
+		if err != nil {
+			t.Fatalf("WriteVarInt error %v", err)
+		}
+		return buf.Bytes()
+	}
+	oneShortID := []byte{0x01, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06}
+
+	tests := []struct {
+		name string
+		buf  []byte
+		pver uint32
+	}{
+		{
+			name: "protocol version before CompactBlocksVersion",
+			buf:  withPrefix(0x00, 0x00),
+			pver: CompactBlocksVersion - 1,
+		},
+		{
+			name: "too many short IDs",
+			buf:  withPrefix(0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f),
+			pver: ProtocolVersion,
+		},
+		{
+			name: "too many prefilled transactions",
+			buf:  withPrefix(append([]byte{0x00}, varInt(maxTxPerBlock+1)...)...),
+			pver: ProtocolVersion,
+		},
+		{
+			name: "too many transactions in total",
+			buf:  withPrefix(append(oneShortID, varInt(maxTxPerBlock)...)...),
+			pver: ProtocolVersion,
+		},
+		{
+			name: "overflowing transaction count",
+			buf:  withPrefix(append(oneShortID, varInt(math.MaxUint64)...)...),
+			pver: ProtocolVersion,
+		},
+		{
+			name: "prefilled transaction index out of range",
+			buf:  withPrefix(append([]byte{0x00, 0x01, 0x01}, txBytes...)...),
+			pver: ProtocolVersion,
+		},
+		{
+			name: "prefilled transaction indexes not in increasing order",
+			buf: withPrefix(append(append([]byte{0x00, 0x02, 0x00}, txBytes...),
+				append([]byte{0x00}, txBytes...)...)...),
+			pver: ProtocolVersion,
+		},
+	}
+
+	for _, test := range tests {
+		var msg MsgCmpctBlock
+		err := msg.KaspaDecode(bytes.NewReader(test.buf), test.pver)
+		if msgErr := &(MessageError{}); !errors.As(err, &msgErr) {
+			t.Errorf("KaspaDecode: %s: expected a MessageError, got: %v",
+				test.name, err)
+		}
+	}
+}
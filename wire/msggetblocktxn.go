@@ -0,0 +1,120 @@
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// MsgGetBlockTxn implements the Message interface and represents a kaspa
+// getblocktxn message. It is used to request the transactions of a block
+// that was received as a compact block and whose transactions couldn't all
+// be found by their short IDs. The transactions are identified by their
+// indexes in the block, and the response is a blocktxn message.
+//
+// This message was not added until protocol versions starting with
+// CompactBlocksVersion.
+type MsgGetBlockTxn struct {
+	BlockHash *daghash.Hash
+	Indexes   []uint32
+}
+
+// KaspaDecode decodes r using the kaspa protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetBlockTxn) KaspaDecode(r io.Reader, pver uint32) error {
+	if pver < CompactBlocksVersion {
+		str := fmt.Sprintf("getblocktxn message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetBlockTxn.KaspaDecode", str)
+	}
+
+	msg.BlockHash = &daghash.Hash{}
+	err := ReadElement(r, msg.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	count, err := ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+	if count > maxTxPerBlock {
+		str := fmt.Sprintf("too many transaction indexes for message "+
+			"[count %d, max %d]", count, maxTxPerBlock)
+		return messageError("MsgGetBlockTxn.KaspaDecode", str)
+	}
+
+	msg.Indexes = make([]uint32, count)
+	for i := range msg.Indexes {
+		index, err := ReadVarInt(r)
+		if err != nil {
+			return err
+		}
+		if index >= maxTxPerBlock {
+			str := fmt.Sprintf("transaction index %d is out of range "+
+				"[max %d]", index, maxTxPerBlock-1)
+			return messageError("MsgGetBlockTxn.KaspaDecode", str)
+		}
+		msg.Indexes[i] = uint32(index)
+	}
+
+	return nil
+}
+
+// KaspaEncode encodes the receiver to w using the kaspa protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetBlockTxn) KaspaEncode(w io.Writer, pver uint32) error {
+	if pver < CompactBlocksVersion {
+		str := fmt.Sprintf("getblocktxn message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetBlockTxn.KaspaEncode", str)
+	}
+
+	count := len(msg.Indexes)
+	if count > maxTxPerBlock {
+		str := fmt.Sprintf("too many transaction indexes for message "+
+			"[count %d, max %d]", count, maxTxPerBlock)
+		return messageError("MsgGetBlockTxn.KaspaEncode", str)
+	}
+
+	err := WriteElement(w, msg.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	err = WriteVarInt(w, uint64(count))
+	if err != nil {
+		return err
+	}
+	for _, index := range msg.Indexes {
+		err = WriteVarInt(w, uint64(index))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message. This is part
+// of the Message interface implementation.
+func (msg *MsgGetBlockTxn) Command() string {
+	return CmdGetBlockTxn
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver. This is part of the Message interface implementation.
+func (msg *MsgGetBlockTxn) MaxPayloadLength(pver uint32) uint32 {
+	// Block hash + num indexes (varInt) + max allowed indexes.
+	return daghash.HashSize + MaxVarIntPayload + maxTxPerBlock*MaxVarIntPayload
+}
+
+// NewMsgGetBlockTxn returns a new kaspa getblocktxn message that conforms to
+// the Message interface using the passed parameters.
+func NewMsgGetBlockTxn(blockHash *daghash.Hash, indexes []uint32) *MsgGetBlockTxn {
+	return &MsgGetBlockTxn{
+		BlockHash: blockHash,
+		Indexes:   indexes,
+	}
+}
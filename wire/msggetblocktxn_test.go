@@ -0,0 +1,102 @@
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+// TestGetBlockTxn tests the MsgGetBlockTxn API.
+func TestGetBlockTxn(t *testing.T) {
+	blockHash := blockOne.BlockHash()
+	indexes := []uint32{1, 3, 4}
+
+	// Ensure we get the same data back out.
+	msg := NewMsgGetBlockTxn(blockHash, indexes)
+	if !msg.BlockHash.IsEqual(blockHash) {
+		t.Errorf("NewMsgGetBlockTxn: wrong block hash - got %v, want %v",
+			msg.BlockHash, blockHash)
+	}
+	if !reflect.DeepEqual(msg.Indexes, indexes) {
+		t.Errorf("NewMsgGetBlockTxn: wrong indexes - got %v, want %v",
+			msg.Indexes, indexes)
+	}
+
+	// Ensure the command is expected value.
+	wantCmd := "getblocktxn"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgGetBlockTxn: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+}
+
+// TestGetBlockTxnWire tests the MsgGetBlockTxn wire encode and decode.
+func TestGetBlockTxnWire(t *testing.T) {
+	msg := NewMsgGetBlockTxn(blockOne.BlockHash(), []uint32{1, 300, 70000})
+
+	var buf bytes.Buffer
+	err := msg.KaspaEncode(&buf, ProtocolVersion)
+	if err != nil {
+		t.Fatalf("KaspaEncode error %v", err)
+	}
+	// Block hash + count + 1-byte, 3-byte and 5-byte varints.
+	wantLength := daghash.HashSize + 1 + 1 + 3 + 5
+	if buf.Len() != wantLength {
+		t.Fatalf("KaspaEncode: wrong encoded length - got %d, want %d",
+			buf.Len(), wantLength)
+	}
+
+	var decodedMsg MsgGetBlockTxn
+	err = decodedMsg.KaspaDecode(bytes.NewReader(buf.Bytes()), ProtocolVersion)
+	if err != nil {
+		t.Fatalf("KaspaDecode error %v", err)
+	}
+	if !reflect.DeepEqual(&decodedMsg, msg) {
+		t.Fatalf("KaspaDecode\n got: %s want: %s",
+			spew.Sdump(&decodedMsg), spew.Sdump(msg))
+	}
+}
+
+// TestGetBlockTxnWireErrors performs negative tests against wire decode of
+// MsgGetBlockTxn to confirm error paths work correctly.
+func TestGetBlockTxnWireErrors(t *testing.T) {
+	hashBytes := make([]byte, daghash.HashSize)
+	withHash := func(b ...byte) []byte {
+		return append(append([]byte{}, hashBytes...), b...)
+	}
+
+	tests := []struct {
+		name string
+		buf  []byte
+		pver uint32
+	}{
+		{
+			name: "protocol version before CompactBlocksVersion",
+			buf:  withHash(0x00),
+			pver: CompactBlocksVersion - 1,
+		},
+		{
+			name: "too many indexes",
+			buf:  withHash(0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f),
+			pver: ProtocolVersion,
+		},
+		{
+			name: "index out of range",
+			buf:  withHash(0x01, 0xfe, 0xff, 0xff, 0xff, 0xff),
+			pver: ProtocolVersion,
+		},
+	}
+
+	for _, test := range tests {
+		var msg MsgGetBlockTxn
+		err := msg.KaspaDecode(bytes.NewReader(test.buf), test.pver)
+		if msgErr := &(MessageError{}); !errors.As(err, &msgErr) {
+			t.Errorf("KaspaDecode: %s: expected a MessageError, got: %v",
+				test.name, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/kaspanet/kaspad/util/daghash"
+)
+
+// MsgGetHeaders implements the Message interface and represents a kaspa
+// getheaders message. It is used to request the headers of the blocks
+// starting after the low hash and until the high hash, sorted such that
+// every header comes after the headers of its parents. The response is a
+// headers message with up to MaxBlockHeadersPerMsg headers.
+//
+// This message was not added until protocol versions starting with
+// HeadersFirstVersion.
+type MsgGetHeaders struct {
+	LowHash  *daghash.Hash
+	HighHash *daghash.Hash
+}
+
+// KaspaDecode decodes r using the kaspa protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetHeaders) KaspaDecode(r io.Reader, pver uint32) error {
+	if pver < HeadersFirstVersion {
+		str := fmt.Sprintf("getheaders message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetHeaders.KaspaDecode", str)
+	}
+
+	msg.LowHash = &daghash.Hash{}
+	err := ReadElement(r, msg.LowHash)
+	if err != nil {
+		return err
+	}
+
+	msg.HighHash = &daghash.Hash{}
+	return ReadElement(r, msg.HighHash)
+}
+
+// KaspaEncode encodes the receiver to w using the kaspa protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetHeaders) KaspaEncode(w io.Writer, pver uint32) error {
+	if pver < HeadersFirstVersion {
+		str := fmt.Sprintf("getheaders message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetHeaders.KaspaEncode", str)
+	}
+
+	err := WriteElement(w, msg.LowHash)
+	if err != nil {
+		return err
+	}
+
+	return WriteElement(w, msg.HighHash)
+}
+
+// Command returns the protocol command string for the message. This is part
+// of the Message interface implementation.
+func (msg *MsgGetHeaders) Command() string {
+	return CmdGetHeaders
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver. This is part of the Message interface implementation.
+func (msg *MsgGetHeaders) MaxPayloadLength(pver uint32) uint32 {
+	// low hash + high hash.
+	return 2 * daghash.HashSize
+}
+
+// NewMsgGetHeaders returns a new kaspa getheaders message that conforms to
+// the Message interface using the passed parameters and defaults for the
+// remaining fields.
+func NewMsgGetHeaders(lowHash, highHash *daghash.Hash) *MsgGetHeaders {
+	return &MsgGetHeaders{
+		LowHash:  lowHash,
+		HighHash: highHash,
+	}
+}
@@ -0,0 +1,119 @@
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/kaspanet/kaspad/util/daghash"
+	"github.com/pkg/errors"
+)
+
+// TestGetHeaders tests the MsgGetHeaders API.
+func TestGetHeaders(t *testing.T) {
+	pver := ProtocolVersion
+
+	hashStr := "000000000002e7ad7b9eef9479e4aabc65cb831269cc20d2632c13684406dee0"
+	lowHash, err := daghash.NewHashFromStr(hashStr)
+	if err != nil {
+		t.Errorf("NewHashFromStr: %v", err)
+	}
+
+	hashStr = "3ba27aa200b1cecaad478d2b00432346c3f1f3986da1afd33e506"
+	highHash, err := daghash.NewHashFromStr(hashStr)
+	if err != nil {
+		t.Errorf("NewHashFromStr: %v", err)
+	}
+
+	// Ensure we get the same data back out.
+	msg := NewMsgGetHeaders(lowHash, highHash)
+	if !msg.LowHash.IsEqual(lowHash) {
+		t.Errorf("NewMsgGetHeaders: wrong low hash - got %v, want %v",
+			msg.LowHash, lowHash)
+	}
+	if !msg.HighHash.IsEqual(highHash) {
+		t.Errorf("NewMsgGetHeaders: wrong high hash - got %v, want %v",
+			msg.HighHash, highHash)
+	}
+
+	// Ensure the command is expected value.
+	wantCmd := "getheaders"
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgGetHeaders: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is low hash (32 bytes) + high hash (32 bytes).
+	wantPayload := uint32(64)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+}
+
+// TestGetHeadersWire tests the MsgGetHeaders wire encode and decode for
+// various protocol versions.
+func TestGetHeadersWire(t *testing.T) {
+	hashStr := "2710f40c87ec93d010a6fd95f42c59a2cbacc60b18cf6b7957535"
+	lowHash, err := daghash.NewHashFromStr(hashStr)
+	if err != nil {
+		t.Errorf("NewHashFromStr: %v", err)
+	}
+
+	hashStr = "3ba27aa200b1cecaad478d2b00432346c3f1f3986da1afd33e506"
+	highHash, err := daghash.NewHashFromStr(hashStr)
+	if err != nil {
+		t.Errorf("NewHashFromStr: %v", err)
+	}
+
+	msg := NewMsgGetHeaders(lowHash, highHash)
+	msgEncoded := []byte{
+		0x35, 0x75, 0x95, 0xb7, 0xf6, 0x8c, 0xb1, 0x60,
+		0xcc, 0xba, 0x2c, 0x9a, 0xc5, 0x42, 0x5f, 0xd9,
+		0x6f, 0x0a, 0x01, 0x3d, 0xc9, 0x7e, 0xc8, 0x40,
+		0x0f, 0x71, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, // Low hash
+		0x06, 0xe5, 0x33, 0xfd, 0x1a, 0xda, 0x86, 0x39,
+		0x1f, 0x3f, 0x6c, 0x34, 0x32, 0x04, 0xb0, 0xd2,
+		0x78, 0xd4, 0xaa, 0xec, 0x1c, 0x0b, 0x20, 0xaa,
+		0x27, 0xba, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, // High hash
+	}
+
+	// Encode the message to wire format.
+	var buf bytes.Buffer
+	err = msg.KaspaEncode(&buf, ProtocolVersion)
+	if err != nil {
+		t.Fatalf("KaspaEncode error %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), msgEncoded) {
+		t.Fatalf("KaspaEncode\n got: %s want: %s",
+			spew.Sdump(buf.Bytes()), spew.Sdump(msgEncoded))
+	}
+
+	// Decode the message from wire format.
+	var decodedMsg MsgGetHeaders
+	err = decodedMsg.KaspaDecode(bytes.NewReader(msgEncoded), ProtocolVersion)
+	if err != nil {
+		t.Fatalf("KaspaDecode error %v", err)
+	}
+	if !reflect.DeepEqual(&decodedMsg, msg) {
+		t.Fatalf("KaspaDecode\n got: %s want: %s",
+			spew.Sdump(&decodedMsg), spew.Sdump(msg))
+	}
+
+	// The message must not be used with protocol versions before
+	// HeadersFirstVersion.
+	pver := HeadersFirstVersion - 1
+	err = msg.KaspaEncode(&bytes.Buffer{}, pver)
+	if msgErr := &(MessageError{}); !errors.As(err, &msgErr) {
+		t.Errorf("KaspaEncode: expected a MessageError for protocol "+
+			"version %d, got: %v", pver, err)
+	}
+	err = decodedMsg.KaspaDecode(bytes.NewReader(msgEncoded), pver)
+	if msgErr := &(MessageError{}); !errors.As(err, &msgErr) {
+		t.Errorf("KaspaDecode: expected a MessageError for protocol "+
+			"version %d, got: %v", pver, err)
+	}
+}
@@ -0,0 +1,127 @@
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// MaxBlockHeadersPerMsg is the maximum number of block headers that can be in
+// a single kaspa headers message.
+const MaxBlockHeadersPerMsg = 2000
+
+// MsgHeaders implements the Message interface and represents a kaspa headers
+// message. It is used to deliver block header information in response to a
+// getheaders message (MsgGetHeaders). The maximum number of block headers per
+// message is currently 2000. See MsgGetHeaders for details on requesting the
+// headers.
+//
+// This message was not added until protocol versions starting with
+// HeadersFirstVersion.
+type MsgHeaders struct {
+	Headers []*BlockHeader
+}
+
+// AddBlockHeader adds a new block header to the message.
+func (msg *MsgHeaders) AddBlockHeader(bh *BlockHeader) error {
+	if len(msg.Headers)+1 > MaxBlockHeadersPerMsg {
+		str := fmt.Sprintf("too many block headers in message [max %d]",
+			MaxBlockHeadersPerMsg)
+		return messageError("MsgHeaders.AddBlockHeader", str)
+	}
+
+	msg.Headers = append(msg.Headers, bh)
+	return nil
+}
+
+// KaspaDecode decodes r using the kaspa protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgHeaders) KaspaDecode(r io.Reader, pver uint32) error {
+	if pver < HeadersFirstVersion {
+		str := fmt.Sprintf("headers message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgHeaders.KaspaDecode", str)
+	}
+
+	count, err := ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+
+	// Limit to max block headers per message.
+	if count > MaxBlockHeadersPerMsg {
+		str := fmt.Sprintf("too many block headers for message "+
+			"[count %d, max %d]", count, MaxBlockHeadersPerMsg)
+		return messageError("MsgHeaders.KaspaDecode", str)
+	}
+
+	// Create a contiguous slice of headers to deserialize into in order to
+	// reduce the number of allocations.
+	headers := make([]BlockHeader, count)
+	msg.Headers = make([]*BlockHeader, 0, count)
+	for i := uint64(0); i < count; i++ {
+		bh := &headers[i]
+		err := readBlockHeader(r, pver, bh)
+		if err != nil {
+			return err
+		}
+		err = msg.AddBlockHeader(bh)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// KaspaEncode encodes the receiver to w using the kaspa protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgHeaders) KaspaEncode(w io.Writer, pver uint32) error {
+	if pver < HeadersFirstVersion {
+		str := fmt.Sprintf("headers message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgHeaders.KaspaEncode", str)
+	}
+
+	// Limit to max block headers per message.
+	count := len(msg.Headers)
+	if count > MaxBlockHeadersPerMsg {
+		str := fmt.Sprintf("too many block headers for message "+
+			"[count %d, max %d]", count, MaxBlockHeadersPerMsg)
+		return messageError("MsgHeaders.KaspaEncode", str)
+	}
+
+	err := WriteVarInt(w, uint64(count))
+	if err != nil {
+		return err
+	}
+
+	for _, bh := range msg.Headers {
+		err := writeBlockHeader(w, pver, bh)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message. This is part
+// of the Message interface implementation.
+func (msg *MsgHeaders) Command() string {
+	return CmdHeaders
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver. This is part of the Message interface implementation.
+func (msg *MsgHeaders) MaxPayloadLength(pver uint32) uint32 {
+	// Num headers (varInt) + max allowed headers.
+	return MaxVarIntPayload + (MaxBlockHeadersPerMsg * MaxBlockHeaderPayload)
+}
+
+// NewMsgHeaders returns a new kaspa headers message that conforms to the
+// Message interface. See MsgHeaders for details.
+func NewMsgHeaders() *MsgHeaders {
+	return &MsgHeaders{
+		Headers: make([]*BlockHeader, 0, MaxBlockHeadersPerMsg),
+	}
+}
@@ -0,0 +1,111 @@
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/pkg/errors"
+)
+
+// TestHeaders tests the MsgHeaders API.
+func TestHeaders(t *testing.T) {
+	pver := ProtocolVersion
+
+	// Ensure the command is expected value.
+	wantCmd := "headers"
+	msg := NewMsgHeaders()
+	if cmd := msg.Command(); cmd != wantCmd {
+		t.Errorf("NewMsgHeaders: wrong command - got %v want %v",
+			cmd, wantCmd)
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	// Num headers (varInt) + max allowed headers.
+	wantPayload := uint32(MaxVarIntPayload + MaxBlockHeadersPerMsg*MaxBlockHeaderPayload)
+	maxPayload := msg.MaxPayloadLength(pver)
+	if maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length for "+
+			"protocol version %d - got %v, want %v", pver,
+			maxPayload, wantPayload)
+	}
+
+	// Ensure headers are added properly.
+	bh := &blockOne.Header
+	msg.AddBlockHeader(bh)
+	if !reflect.DeepEqual(msg.Headers[0], bh) {
+		t.Errorf("AddHeader: wrong header - got %v, want %v",
+			spew.Sdump(msg.Headers),
+			spew.Sdump(bh))
+	}
+
+	// Ensure adding more than the max allowed headers per message returns
+	// error.
+	var err error
+	for i := 0; i < MaxBlockHeadersPerMsg+1; i++ {
+		err = msg.AddBlockHeader(bh)
+	}
+	if reflect.TypeOf(err) != reflect.TypeOf(&MessageError{}) {
+		t.Errorf("AddBlockHeader: expected error on too many headers " +
+			"not received")
+	}
+}
+
+// TestHeadersWire tests the MsgHeaders wire encode and decode.
+func TestHeadersWire(t *testing.T) {
+	msg := NewMsgHeaders()
+	msg.AddBlockHeader(&blockOne.Header)
+	msg.AddBlockHeader(&blockOne.Header)
+
+	var buf bytes.Buffer
+	err := msg.KaspaEncode(&buf, ProtocolVersion)
+	if err != nil {
+		t.Fatalf("KaspaEncode error %v", err)
+	}
+	wantLength := 1 + 2*blockOne.Header.SerializeSize()
+	if buf.Len() != wantLength {
+		t.Fatalf("KaspaEncode: wrong encoded length - got %d, want %d",
+			buf.Len(), wantLength)
+	}
+
+	var decodedMsg MsgHeaders
+	err = decodedMsg.KaspaDecode(bytes.NewReader(buf.Bytes()), ProtocolVersion)
+	if err != nil {
+		t.Fatalf("KaspaDecode error %v", err)
+	}
+	if !reflect.DeepEqual(decodedMsg.Headers, msg.Headers) {
+		t.Fatalf("KaspaDecode\n got: %s want: %s",
+			spew.Sdump(decodedMsg.Headers), spew.Sdump(msg.Headers))
+	}
+}
+
+// TestHeadersWireErrors performs negative tests against wire decode of
+// MsgHeaders to confirm error paths work correctly.
+func TestHeadersWireErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		buf  []byte
+		pver uint32
+	}{
+		{
+			name: "too many headers",
+			buf:  []byte{0xfd, 0xd1, 0x07}, // Varint for number of headers (2001)
+			pver: ProtocolVersion,
+		},
+		{
+			name: "protocol version before HeadersFirstVersion",
+			buf:  []byte{0x00},
+			pver: HeadersFirstVersion - 1,
+		},
+	}
+
+	for _, test := range tests {
+		var msg MsgHeaders
+		err := msg.KaspaDecode(bytes.NewReader(test.buf), test.pver)
+		if msgErr := &(MessageError{}); !errors.As(err, &msgErr) {
+			t.Errorf("KaspaDecode: %s: expected a MessageError, got: %v",
+				test.name, err)
+		}
+	}
+}
@@ -21,6 +21,14 @@ import (
 // version message (MsgVersion).
 const MaxUserAgentLen = 256
 
+// MaxTransportPublicKeyLen is the maximum allowed length for the encryption
+// and identity public key fields in a version message (MsgVersion).
+const MaxTransportPublicKeyLen = 33
+
+// MaxIdentitySignatureLen is the maximum allowed length for the identity
+// signature field in a version message (MsgVersion).
+const MaxIdentitySignatureLen = 64
+
 // DefaultUserAgent for wire in the stack
 var DefaultUserAgent = fmt.Sprintf("/kaspad:%s/", version.Version())
 
@@ -63,6 +71,19 @@ type MsgVersion struct {
 
 	// The subnetwork of the generator of the version message. Should be nil in full nodes
 	SubnetworkID *subnetworkid.SubnetworkID
+
+	// The ephemeral public key that the generator of the version message
+	// offers for an encrypted transport. Nil if encryption is not offered.
+	// This field and the identity fields are optional, and are only
+	// encoded when encryption is offered, so peers that don't support
+	// encryption ignore them.
+	EncryptionPublicKey []byte
+
+	// The public key that identifies the generator of the version message,
+	// and its signature of the encryption public key. Both are nil if the
+	// generator doesn't have an identity.
+	IdentityPublicKey []byte
+	IdentitySignature []byte
 }
 
 // HasService returns whether the specified service is supported by the peer
@@ -150,9 +171,45 @@ func (msg *MsgVersion) KaspaDecode(r io.Reader, pver uint32) error {
 	}
 	msg.DisableRelayTx = !relayTx
 
+	// The encryption fields are optional, so they are only read if there
+	// are bytes remaining.
+	if buf.Len() > 0 {
+		msg.EncryptionPublicKey, err = readOptionalVarBytes(buf, pver,
+			MaxTransportPublicKeyLen, "EncryptionPublicKey")
+		if err != nil {
+			return err
+		}
+	}
+	if buf.Len() > 0 {
+		msg.IdentityPublicKey, err = readOptionalVarBytes(buf, pver,
+			MaxTransportPublicKeyLen, "IdentityPublicKey")
+		if err != nil {
+			return err
+		}
+	}
+	if buf.Len() > 0 {
+		msg.IdentitySignature, err = readOptionalVarBytes(buf, pver,
+			MaxIdentitySignatureLen, "IdentitySignature")
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// readOptionalVarBytes reads a variable length byte array, and returns nil
+// if it's empty.
+func readOptionalVarBytes(buf *bytes.Buffer, pver uint32, maxAllowed uint32,
+	fieldName string) ([]byte, error) {
+
+	b, err := ReadVarBytes(buf, pver, maxAllowed, fieldName)
+	if err != nil || len(b) == 0 {
+		return nil, err
+	}
+	return b, nil
+}
+
 // KaspaEncode encodes the receiver to w using the kaspa protocol encoding.
 // This is part of the Message interface implementation.
 func (msg *MsgVersion) KaspaEncode(w io.Writer, pver uint32) error {
@@ -211,7 +268,19 @@ func (msg *MsgVersion) KaspaEncode(w io.Writer, pver uint32) error {
 	if err != nil {
 		return err
 	}
-	return nil
+
+	if msg.EncryptionPublicKey == nil {
+		return nil
+	}
+	err = WriteVarBytes(w, pver, msg.EncryptionPublicKey)
+	if err != nil {
+		return err
+	}
+	err = WriteVarBytes(w, pver, msg.IdentityPublicKey)
+	if err != nil {
+		return err
+	}
+	return WriteVarBytes(w, pver, msg.IdentitySignature)
 }
 
 // Command returns the protocol command string for the message. This is part
@@ -226,9 +295,11 @@ func (msg *MsgVersion) MaxPayloadLength(pver uint32) uint32 {
 	// Protocol version 4 bytes + services 8 bytes + timestamp 16 bytes +
 	// remote and local net addresses + nonce 8 bytes + length of user
 	// agent (varInt) + max allowed useragent length + selected tip hash length +
-	// relay transactions flag 1 byte.
+	// relay transactions flag 1 byte + encryption and identity public keys
+	// and identity signature, each with a length of 1 byte.
 	return 29 + (maxNetAddressPayload(pver) * 2) + MaxVarIntPayload +
-		MaxUserAgentLen + daghash.HashSize
+		MaxUserAgentLen + daghash.HashSize + 3 +
+		(MaxTransportPublicKeyLen * 2) + MaxIdentitySignatureLen
 }
 
 // NewMsgVersion returns a new kaspa version message that conforms to the
@@ -109,8 +109,9 @@ func TestVersion(t *testing.T) {
 	// Protocol version 4 bytes + services 8 bytes + timestamp 16 bytes +
 	// remote and local net addresses + nonce 8 bytes + length of user
 	// agent (varInt) + max allowed useragent length + selected tip hash length +
-	// relay transactions flag 1 byte.
-	wantPayload := uint32(394)
+	// relay transactions flag 1 byte + encryption and identity public keys
+	// and identity signature, each with a length of 1 byte.
+	wantPayload := uint32(527)
 	maxPayload := msg.MaxPayloadLength(pver)
 	if maxPayload != wantPayload {
 		t.Errorf("MaxPayloadLength: wrong max payload length for "+
@@ -140,6 +141,32 @@ func TestVersionWire(t *testing.T) {
 	copy(verRelayTxFalseEncoded, baseVersionWithRelayTxEncoded)
 	verRelayTxFalseEncoded[len(verRelayTxFalseEncoded)-1] = 0
 
+	// verEncryption and verEncryptionEncoded is a version message which
+	// offers an encrypted transport with an identity.
+	baseVersionWithEncryptionCopy := *baseVersionWithRelayTx
+	verEncryption := &baseVersionWithEncryptionCopy
+	verEncryption.EncryptionPublicKey = bytes.Repeat([]byte{0x02}, MaxTransportPublicKeyLen)
+	verEncryption.IdentityPublicKey = bytes.Repeat([]byte{0x03}, MaxTransportPublicKeyLen)
+	verEncryption.IdentitySignature = bytes.Repeat([]byte{0x04}, MaxIdentitySignatureLen)
+	verEncryptionEncoded := append([]byte{}, baseVersionWithRelayTxEncoded...)
+	verEncryptionEncoded = append(verEncryptionEncoded, MaxTransportPublicKeyLen)
+	verEncryptionEncoded = append(verEncryptionEncoded, verEncryption.EncryptionPublicKey...)
+	verEncryptionEncoded = append(verEncryptionEncoded, MaxTransportPublicKeyLen)
+	verEncryptionEncoded = append(verEncryptionEncoded, verEncryption.IdentityPublicKey...)
+	verEncryptionEncoded = append(verEncryptionEncoded, MaxIdentitySignatureLen)
+	verEncryptionEncoded = append(verEncryptionEncoded, verEncryption.IdentitySignature...)
+
+	// verEncryptionNoIdentity and verEncryptionNoIdentityEncoded is a
+	// version message which offers an encrypted transport without an
+	// identity.
+	baseVersionWithEncryptionNoIdentityCopy := *baseVersionWithRelayTx
+	verEncryptionNoIdentity := &baseVersionWithEncryptionNoIdentityCopy
+	verEncryptionNoIdentity.EncryptionPublicKey = verEncryption.EncryptionPublicKey
+	verEncryptionNoIdentityEncoded := append([]byte{}, baseVersionWithRelayTxEncoded...)
+	verEncryptionNoIdentityEncoded = append(verEncryptionNoIdentityEncoded, MaxTransportPublicKeyLen)
+	verEncryptionNoIdentityEncoded = append(verEncryptionNoIdentityEncoded, verEncryption.EncryptionPublicKey...)
+	verEncryptionNoIdentityEncoded = append(verEncryptionNoIdentityEncoded, 0x00, 0x00)
+
 	tests := []struct {
 		in   *MsgVersion // Message to encode
 		out  *MsgVersion // Expected decoded message
@@ -159,6 +186,18 @@ func TestVersionWire(t *testing.T) {
 			verRelayTxFalseEncoded,
 			ProtocolVersion,
 		},
+		{
+			verEncryption,
+			verEncryption,
+			verEncryptionEncoded,
+			ProtocolVersion,
+		},
+		{
+			verEncryptionNoIdentity,
+			verEncryptionNoIdentity,
+			verEncryptionNoIdentityEncoded,
+			ProtocolVersion,
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
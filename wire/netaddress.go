@@ -6,6 +6,7 @@ package wire
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
 	"net"
 	"time"
@@ -20,6 +21,28 @@ func maxNetAddressPayload(pver uint32) uint32 {
 	return uint32(34)
 }
 
+// maxNetAddressV2AddrLength is the maximum length of the address of a
+// NetAddress in an addrv2 message (MsgAddrV2). Longer addresses are rejected
+// even when they belong to an unknown network.
+const maxNetAddressV2AddrLength = 512
+
+// maxNetAddressV2Payload returns the max payload size for a kaspa NetAddress
+// in an addrv2 message based on the protocol version.
+func maxNetAddressV2Payload(pver uint32) uint32 {
+	// Timestamp 8 bytes + services 8 bytes + network ID 1 byte +
+	// address length (varInt) + address + port 2 bytes.
+	return 8 + 8 + 1 + MaxVarIntPayload + maxNetAddressV2AddrLength + 2
+}
+
+// netAddressV2AddrLengths maps the networks that are known to the addrv2
+// encoding to the length of their addresses.
+var netAddressV2AddrLengths = map[NetworkID]int{
+	NetworkIPv4:  net.IPv4len,
+	NetworkIPv6:  net.IPv6len,
+	NetworkTorV3: OverlayAddressKeySize,
+	NetworkI2P:   OverlayAddressKeySize,
+}
+
 // NetAddress defines information about a peer on the network including the time
 // it was last seen, the services it supports, its IP address, and port.
 type NetAddress struct {
@@ -29,9 +52,15 @@ type NetAddress struct {
 	// Bitfield which identifies the services supported by the address.
 	Services ServiceFlag
 
-	// IP address of the peer.
+	// IP address of the peer. It's nil when the peer is in an overlay
+	// network.
 	IP net.IP
 
+	// Overlay is the address of the peer in an overlay network, such as Tor.
+	// It's nil when the peer has an IP address. Overlay addresses can only
+	// be relayed in addrv2 messages.
+	Overlay *OverlayAddress
+
 	// Port the peer is using. This is encoded in big endian on the wire
 	// which differs from most everything else.
 	Port uint16
@@ -56,6 +85,38 @@ func (na *NetAddress) TCPAddress() *net.TCPAddr {
 	}
 }
 
+// NetworkID returns the network of the address.
+func (na *NetAddress) NetworkID() NetworkID {
+	if na.Overlay != nil {
+		return na.Overlay.Network
+	}
+	if na.IP.To4() != nil {
+		return NetworkIPv4
+	}
+	return NetworkIPv6
+}
+
+// Host returns the host part of the address, which is either its IP or its
+// overlay network hostname.
+func (na *NetAddress) Host() string {
+	if na.Overlay != nil {
+		return na.Overlay.String()
+	}
+	return na.IP.String()
+}
+
+// NetAddr converts the NetAddress to a net.Addr, which is a *net.TCPAddr
+// for IP addresses and a *OverlayTCPAddr for overlay addresses.
+func (na *NetAddress) NetAddr() net.Addr {
+	if na.Overlay != nil {
+		return &OverlayTCPAddr{
+			Overlay: *na.Overlay,
+			Port:    int(na.Port),
+		}
+	}
+	return na.TCPAddress()
+}
+
 // NewNetAddressIPPort returns a new NetAddress using the provided IP, port, and
 // supported services with defaults for the remaining fields.
 func NewNetAddressIPPort(ip net.IP, port uint16, services ServiceFlag) *NetAddress {
@@ -84,6 +145,15 @@ func NewNetAddress(addr *net.TCPAddr, services ServiceFlag) *NetAddress {
 	return NewNetAddressIPPort(addr.IP, uint16(addr.Port), services)
 }
 
+// NewNetAddressOverlay returns a new NetAddress using the provided overlay
+// address, port, and supported services with defaults for the remaining
+// fields.
+func NewNetAddressOverlay(overlay *OverlayAddress, port uint16, services ServiceFlag) *NetAddress {
+	na := NewNetAddressTimestamp(time.Now(), services, nil, port)
+	na.Overlay = overlay
+	return na
+}
+
 // readNetAddress reads an encoded NetAddress from r depending on the protocol
 // version and whether or not the timestamp is included per ts. Some messages
 // like version do not include the timestamp.
@@ -138,3 +208,73 @@ func writeNetAddress(w io.Writer, pver uint32, na *NetAddress, ts bool) error {
 
 	return binary.Write(w, bigEndian, na.Port)
 }
+
+// readNetAddressV2 reads a NetAddress that is encoded as in an addrv2 message
+// (MsgAddrV2) from r. Addresses of networks that are unknown to this version
+// are read, but reported as not known so they can be skipped.
+func readNetAddressV2(r io.Reader, pver uint32, na *NetAddress) (known bool, err error) {
+	var networkID NetworkID
+	err = readElements(r, (*int64Time)(&na.Timestamp), &na.Services, &networkID)
+	if err != nil {
+		return false, err
+	}
+	addr, err := ReadVarBytes(r, pver, maxNetAddressV2AddrLength, "NetAddress.Addr")
+	if err != nil {
+		return false, err
+	}
+	port, err := binaryserializer.Uint16(r, bigEndian)
+	if err != nil {
+		return false, err
+	}
+
+	addrLength, known := netAddressV2AddrLengths[networkID]
+	if !known {
+		return false, nil
+	}
+	if len(addr) != addrLength {
+		str := fmt.Sprintf("invalid address length for network %s "+
+			"[length %d, expected %d]", networkID, len(addr), addrLength)
+		return false, messageError("readNetAddressV2", str)
+	}
+
+	*na = NetAddress{
+		Timestamp: na.Timestamp,
+		Services:  na.Services,
+		Port:      port,
+	}
+	switch networkID {
+	case NetworkIPv4, NetworkIPv6:
+		na.IP = net.IP(addr).To16()
+	default:
+		na.Overlay = &OverlayAddress{Network: networkID}
+		copy(na.Overlay.Key[:], addr)
+	}
+	return true, nil
+}
+
+// writeNetAddressV2 serializes a NetAddress to w as it is encoded in an addrv2
+// message (MsgAddrV2).
+func writeNetAddressV2(w io.Writer, pver uint32, na *NetAddress) error {
+	networkID := na.NetworkID()
+	var addr []byte
+	switch networkID {
+	case NetworkIPv4:
+		addr = na.IP.To4()
+	case NetworkIPv6:
+		// Ensure to always write 16 bytes even if the ip is nil.
+		addr = make([]byte, net.IPv6len)
+		copy(addr, na.IP.To16())
+	default:
+		addr = na.Overlay.Key[:]
+	}
+
+	err := writeElements(w, int64(na.Timestamp.Unix()), na.Services, networkID)
+	if err != nil {
+		return err
+	}
+	err = WriteVarBytes(w, pver, addr)
+	if err != nil {
+		return err
+	}
+	return binary.Write(w, bigEndian, na.Port)
+}
@@ -0,0 +1,171 @@
+package wire
+
+import (
+	"encoding/base32"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+)
+
+// NetworkID identifies the network of an address in an addrv2 message
+// (MsgAddrV2). The values are the network IDs that are defined by BIP155.
+type NetworkID uint8
+
+const (
+	// NetworkIPv4 is the network of IPv4 addresses.
+	NetworkIPv4 NetworkID = 1
+
+	// NetworkIPv6 is the network of IPv6 addresses.
+	NetworkIPv6 NetworkID = 2
+
+	// NetworkTorV3 is the network of Tor v3 onion services.
+	NetworkTorV3 NetworkID = 4
+
+	// NetworkI2P is the network of I2P destinations.
+	NetworkI2P NetworkID = 5
+)
+
+// networkIDStrings is a map of network IDs back to their names, which are
+// also used to name the networks in the configuration.
+var networkIDStrings = map[NetworkID]string{
+	NetworkIPv4:  "ipv4",
+	NetworkIPv6:  "ipv6",
+	NetworkTorV3: "onion",
+	NetworkI2P:   "i2p",
+}
+
+// String returns the NetworkID in human-readable form.
+func (id NetworkID) String() string {
+	if s, ok := networkIDStrings[id]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown NetworkID (%d)", uint8(id))
+}
+
+// OverlayNetworks are the overlay networks whose addresses are supported.
+var OverlayNetworks = []NetworkID{NetworkTorV3, NetworkI2P}
+
+// OverlayAddressKeySize is the size of the key that identifies an address in
+// an overlay network.
+const OverlayAddressKeySize = 32
+
+const (
+	torV3HostSuffix     = ".onion"
+	torV3Version        = 3
+	torV3ChecksumPrefix = ".onion checksum"
+	torV3ChecksumSize   = 2
+
+	i2pHostSuffix = ".b32.i2p"
+)
+
+// overlayHostEncoding is the base32 encoding of the hostnames of overlay
+// addresses.
+var overlayHostEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").
+	WithPadding(base32.NoPadding)
+
+// OverlayAddress is the address of a node in an overlay network, which can
+// only be reached through a proxy of that network. For Tor v3 onion services,
+// the key is the ed25519 public key of the service, and for I2P destinations
+// it's the SHA256 hash of the destination.
+type OverlayAddress struct {
+	Network NetworkID
+	Key     [OverlayAddressKeySize]byte
+}
+
+// IsOverlayHost returns whether the given host is the hostname of an address
+// in one of the supported overlay networks. The hostname itself is not
+// validated.
+func IsOverlayHost(host string) bool {
+	host = strings.ToLower(host)
+	return strings.HasSuffix(host, torV3HostSuffix) || strings.HasSuffix(host, i2pHostSuffix)
+}
+
+// ParseOverlayAddress parses the hostname of an address in one of the
+// supported overlay networks, such as a Tor v3 onion service or an I2P
+// destination.
+func ParseOverlayAddress(host string) (*OverlayAddress, error) {
+	host = strings.ToLower(host)
+	switch {
+	case strings.HasSuffix(host, torV3HostSuffix):
+		decoded, err := overlayHostEncoding.DecodeString(strings.TrimSuffix(host, torV3HostSuffix))
+		if err != nil || len(decoded) != OverlayAddressKeySize+torV3ChecksumSize+1 {
+			return nil, errors.Errorf("invalid onion address %s", host)
+		}
+		key := decoded[:OverlayAddressKeySize]
+		checksum := decoded[OverlayAddressKeySize : OverlayAddressKeySize+torV3ChecksumSize]
+		version := decoded[len(decoded)-1]
+		if version != torV3Version {
+			return nil, errors.Errorf("unsupported onion address version %d", version)
+		}
+		if string(checksum) != string(torV3Checksum(key)) {
+			return nil, errors.Errorf("invalid checksum of onion address %s", host)
+		}
+		oa := &OverlayAddress{Network: NetworkTorV3}
+		copy(oa.Key[:], key)
+		return oa, nil
+
+	case strings.HasSuffix(host, i2pHostSuffix):
+		decoded, err := overlayHostEncoding.DecodeString(strings.TrimSuffix(host, i2pHostSuffix))
+		if err != nil || len(decoded) != OverlayAddressKeySize {
+			return nil, errors.Errorf("invalid I2P address %s", host)
+		}
+		oa := &OverlayAddress{Network: NetworkI2P}
+		copy(oa.Key[:], decoded)
+		return oa, nil
+	}
+	return nil, errors.Errorf("%s is not an overlay address", host)
+}
+
+// torV3Checksum returns the checksum of a Tor v3 onion address of the given
+// key, as defined by the Tor rendezvous specification.
+func torV3Checksum(key []byte) []byte {
+	hash := sha3.New256()
+	hash.Write([]byte(torV3ChecksumPrefix))
+	hash.Write(key)
+	hash.Write([]byte{torV3Version})
+	return hash.Sum(nil)[:torV3ChecksumSize]
+}
+
+// String returns the hostname of the overlay address.
+func (oa *OverlayAddress) String() string {
+	switch oa.Network {
+	case NetworkTorV3:
+		decoded := make([]byte, 0, OverlayAddressKeySize+torV3ChecksumSize+1)
+		decoded = append(decoded, oa.Key[:]...)
+		decoded = append(decoded, torV3Checksum(oa.Key[:])...)
+		decoded = append(decoded, torV3Version)
+		return overlayHostEncoding.EncodeToString(decoded) + torV3HostSuffix
+	case NetworkI2P:
+		return overlayHostEncoding.EncodeToString(oa.Key[:]) + i2pHostSuffix
+	}
+	return fmt.Sprintf("%x.%s", oa.Key, oa.Network)
+}
+
+// OverlayTCPAddr is the net.Addr of a TCP endpoint in an overlay network. It
+// is what net.Addr values hold in place of a *net.TCPAddr for overlay
+// addresses, which have no IP.
+type OverlayTCPAddr struct {
+	Overlay OverlayAddress
+	Port    int
+}
+
+// Network returns the name of the network of the address.
+//
+// This is part of the net.Addr interface.
+func (a *OverlayTCPAddr) Network() string {
+	return "tcp"
+}
+
+// String returns the address in the form of host:port.
+//
+// This is part of the net.Addr interface.
+func (a *OverlayTCPAddr) String() string {
+	return net.JoinHostPort(a.Overlay.String(), strconv.Itoa(a.Port))
+}
+
+// Ensure OverlayTCPAddr implements the net.Addr interface.
+var _ net.Addr = (*OverlayTCPAddr)(nil)
@@ -0,0 +1,111 @@
+package wire
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestParseOverlayAddress tests parsing overlay network hostnames.
+func TestParseOverlayAddress(t *testing.T) {
+	tests := []struct {
+		name        string
+		host        string
+		network     NetworkID
+		key         string
+		expectedErr bool
+	}{
+		{
+			name:    "onion",
+			host:    "2gzyxa5ihm7nsggfxnu52rck2vv4rvmdlkiu3zzui5du4xyclen53wid.onion",
+			network: NetworkTorV3,
+			key:     "d1b38b83a83b3ed918c5bb69dd444ad56bc8d5835a914de73447474e5f02591b",
+		},
+		{
+			name:    "onion in upper case",
+			host:    "2GZYXA5IHM7NSGGFXNU52RCK2VV4RVMDLKIU3ZZUI5DU4XYCLEN53WID.ONION",
+			network: NetworkTorV3,
+			key:     "d1b38b83a83b3ed918c5bb69dd444ad56bc8d5835a914de73447474e5f02591b",
+		},
+		{
+			name:        "onion with a bad checksum",
+			host:        "2gzyxa5ihm7nsggfxnu52rck2vv4rvmdlkiu3zzui5du4xyclen52wid.onion",
+			expectedErr: true,
+		},
+		{
+			name:        "onion v2",
+			host:        "expyuzz4wqqyqhjn.onion",
+			expectedErr: true,
+		},
+		{
+			name:    "i2p",
+			host:    "udhdrtrcetjm5sxzskjyr5ztpeszydbh4dpl3pl4utgqqw2v4jna.b32.i2p",
+			network: NetworkI2P,
+			key:     "a0ce38ce2224d2cecaf9929388f73379259c0c27e0debdbd7ca4cd085b55e25a",
+		},
+		{
+			name:        "i2p with a short hash",
+			host:        "udhdrtrcetjm5sxzskjyr5ztpeszydbh4dpl3pl4utgqqw2v.b32.i2p",
+			expectedErr: true,
+		},
+		{
+			name:        "dns name",
+			host:        "seeder.example.com",
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		overlay, err := ParseOverlayAddress(test.host)
+		if test.expectedErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s", test.name, overlay)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if overlay.Network != test.network {
+			t.Errorf("%s: wrong network - got %s, want %s", test.name,
+				overlay.Network, test.network)
+		}
+		if key := hex.EncodeToString(overlay.Key[:]); key != test.key {
+			t.Errorf("%s: wrong key - got %s, want %s", test.name, key, test.key)
+		}
+
+		// Ensure the address converts back to its hostname.
+		reparsed, err := ParseOverlayAddress(overlay.String())
+		if err != nil {
+			t.Errorf("%s: unexpected error when parsing %s: %s", test.name,
+				overlay, err)
+			continue
+		}
+		if *reparsed != *overlay {
+			t.Errorf("%s: %s was not parsed back to the same address",
+				test.name, overlay)
+		}
+	}
+}
+
+// TestIsOverlayHost tests detecting overlay network hostnames.
+func TestIsOverlayHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"2gzyxa5ihm7nsggfxnu52rck2vv4rvmdlkiu3zzui5du4xyclen53wid.onion", true},
+		{"Example.ONION", true},
+		{"udhdrtrcetjm5sxzskjyr5ztpeszydbh4dpl3pl4utgqqw2v4jna.b32.i2p", true},
+		{"example.i2p", false},
+		{"127.0.0.1", false},
+		{"seeder.example.com", false},
+	}
+
+	for _, test := range tests {
+		if got := IsOverlayHost(test.host); got != test.want {
+			t.Errorf("IsOverlayHost(%s): got %t, want %t", test.host,
+				got, test.want)
+		}
+	}
+}
@@ -10,10 +10,21 @@ import (
 	"strings"
 )
 
-// XXX pedro: we will probably need to bump this.
 const (
 	// ProtocolVersion is the latest protocol version this package supports.
-	ProtocolVersion uint32 = 1
+	ProtocolVersion uint32 = 4
+
+	// HeadersFirstVersion is the protocol version which added the
+	// getheaders and headers messages.
+	HeadersFirstVersion uint32 = 2
+
+	// CompactBlocksVersion is the protocol version which added the
+	// cmpctblock, getblocktxn and blocktxn messages.
+	CompactBlocksVersion uint32 = 3
+
+	// AddrV2Version is the protocol version which added the addrv2
+	// message.
+	AddrV2Version uint32 = 4
 )
 
 // ServiceFlag identifies services supported by a kaspa peer.
@@ -41,16 +52,21 @@ const (
 	// SFNodeCF is a flag used to indicate a peer supports committed
 	// filters (CFs).
 	SFNodeCF
+
+	// SFNodeNetworkLimited is a flag used to indicate a peer only serves
+	// the blocks above the depth it prunes blocks at.
+	SFNodeNetworkLimited
 )
 
 // Map of service flags back to their constant names for pretty printing.
 var sfStrings = map[ServiceFlag]string{
-	SFNodeNetwork: "SFNodeNetwork",
-	SFNodeGetUTXO: "SFNodeGetUTXO",
-	SFNodeBloom:   "SFNodeBloom",
-	SFNodeXthin:   "SFNodeXthin",
-	SFNodeBit5:    "SFNodeBit5",
-	SFNodeCF:      "SFNodeCF",
+	SFNodeNetwork:        "SFNodeNetwork",
+	SFNodeGetUTXO:        "SFNodeGetUTXO",
+	SFNodeBloom:          "SFNodeBloom",
+	SFNodeXthin:          "SFNodeXthin",
+	SFNodeBit5:           "SFNodeBit5",
+	SFNodeCF:             "SFNodeCF",
+	SFNodeNetworkLimited: "SFNodeNetworkLimited",
 }
 
 // orderedSFStrings is an ordered list of service flags from highest to
@@ -62,6 +78,7 @@ var orderedSFStrings = []ServiceFlag{
 	SFNodeXthin,
 	SFNodeBit5,
 	SFNodeCF,
+	SFNodeNetworkLimited,
 }
 
 // String returns the ServiceFlag in human-readable form.